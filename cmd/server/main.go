@@ -14,6 +14,7 @@ import (
 	"ai-dev-platform/internal/config"
 	"ai-dev-platform/internal/repository"
 	"ai-dev-platform/internal/service"
+	"ai-dev-platform/internal/tracing"
 
 	"github.com/gin-gonic/gin"
 )
@@ -31,6 +32,19 @@ func main() {
 
 	log.Infof("启动AI开发平台服务器 [环境: %s] [端口: %s] [Gin模式: %s]", cfg.Env, cfg.Port, gin.Mode())
 
+	// 初始化链路追踪
+	var tracer *tracing.Tracer
+	switch cfg.Tracing.Exporter {
+	case "log":
+		tracer = tracing.NewTracer(tracing.NewLogExporter(os.Stdout))
+	case "otlp":
+		tracer = tracing.NewTracer(tracing.NewOTLPExporter(cfg.Tracing.OTLPEndpoint, cfg.Tracing.ServiceName))
+	}
+	if tracer != nil {
+		tracing.SetTracer(tracer)
+		log.Infof("链路追踪已启用 [导出方式: %s]", cfg.Tracing.Exporter)
+	}
+
 	// 初始化数据库
 	db, err := repository.NewDatabase(cfg)
 	if err != nil {
@@ -57,6 +71,9 @@ func main() {
 	// 初始化仓库
 	repo := repository.NewMySQLRepository(db)
 
+	// 初始化AI调用审计服务
+	aiAuditService := service.NewAIAuditService(repo, cfg)
+
 	// 初始化AI管理器
 	aiManagerConfig := ai.AIManagerConfig{
 		DefaultProvider: ai.AIProvider(cfg.AI.DefaultProvider),
@@ -65,8 +82,9 @@ func main() {
 			BaseURL: os.Getenv("OPENAI_BASE_URL"),
 			Model:   cfg.AI.OpenAIConfig.DefaultModel,
 		},
-		EnableCache: cfg.AI.EnableCache,
-		CacheTTL:    cfg.AI.CacheTTL,
+		EnableCache:   cfg.AI.EnableCache,
+		CacheTTL:      cfg.AI.CacheTTL,
+		AuditRecorder: aiAuditService,
 	}
 
 	aiManager, err := ai.NewAIManager(aiManagerConfig)
@@ -76,6 +94,7 @@ func main() {
 		aiManager, _ = ai.NewAIManager(ai.AIManagerConfig{
 			DefaultProvider: ai.ProviderOpenAI,
 			EnableCache:     false,
+			AuditRecorder:   aiAuditService,
 		})
 	}
 
@@ -88,7 +107,7 @@ func main() {
 	aiService := service.NewAIService(aiManager, repo.(*repository.MySQLRepository))
 
 	// 初始化PUML渲染服务
-	pumlService := service.NewPUMLService(&cfg.PUML)
+	pumlService := service.NewPUMLService(&cfg.PUML, repo)
 
 	// 初始化异步任务服务
 	asyncTaskService := service.NewAsyncTaskService(repo, aiService, aiManager)
@@ -99,9 +118,10 @@ func main() {
 		log.Fatalf("获取SQL数据库连接失败: %v", err)
 	}
 	specService := service.NewSpecService(sqlDB, aiManager, repo.(*repository.MySQLRepository))
+	asyncTaskService.SetSpecService(specService)
 
 	// 初始化路由器（返回 Gin Engine）
-	ginEngine := api.NewGinRouter(cfg, userService, projectService, aiService, pumlService, asyncTaskService, specService)
+	ginEngine := api.NewGinRouter(cfg, userService, projectService, aiService, pumlService, asyncTaskService, specService, aiAuditService)
 
 	// 创建HTTP服务器
 	server := &http.Server{
@@ -137,4 +157,11 @@ func main() {
 	} else {
 		log.Info("服务器已优雅关闭")
 	}
+
+	// 导出剩余的链路数据
+	if tracer != nil {
+		if err := tracer.Shutdown(ctx); err != nil {
+			log.Infof("关闭链路追踪失败: %v", err)
+		}
+	}
 }
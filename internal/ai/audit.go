@@ -0,0 +1,137 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"ai-dev-platform/internal/requestid"
+)
+
+// AuditRecord AI调用审计记录
+type AuditRecord struct {
+	RequestID        string        `json:"request_id"`
+	UserID           string        `json:"user_id"`
+	ProjectID        string        `json:"project_id"`
+	Operation        string        `json:"operation"`
+	Provider         AIProvider    `json:"provider"`
+	Model            string        `json:"model"`
+	Prompt           string        `json:"prompt"`
+	Response         string        `json:"response"`
+	ParseResult      string        `json:"parse_result"`
+	Error            string        `json:"error"`
+	PromptTokens     int           `json:"prompt_tokens"`
+	CompletionTokens int           `json:"completion_tokens"`
+	TotalTokens      int           `json:"total_tokens"`
+	Duration         time.Duration `json:"duration"`
+	CreatedAt        time.Time     `json:"created_at"`
+}
+
+// AuditRecorder AI调用审计记录器
+type AuditRecorder interface {
+	// Record 保存一条审计记录
+	Record(ctx context.Context, record *AuditRecord)
+}
+
+// AuditScope 审计上下文信息
+type AuditScope struct {
+	UserID    string
+	ProjectID string
+	Operation string // 直接调用AI接口（未经过具体操作）时使用的操作名
+}
+
+type auditScopeKey struct{}
+
+type auditRecordKey struct{}
+
+// WithAuditScope 在上下文中附加审计所需的用户、项目信息，空字段沿用上层上下文中的值
+func WithAuditScope(ctx context.Context, scope AuditScope) context.Context {
+	parent := auditScopeFromContext(ctx)
+	if scope.UserID == "" {
+		scope.UserID = parent.UserID
+	}
+	if scope.ProjectID == "" {
+		scope.ProjectID = parent.ProjectID
+	}
+	if scope.Operation == "" {
+		scope.Operation = parent.Operation
+	}
+	return context.WithValue(ctx, auditScopeKey{}, scope)
+}
+
+// auditScopeFromContext 从上下文读取审计信息
+func auditScopeFromContext(ctx context.Context) AuditScope {
+	if ctx == nil {
+		return AuditScope{}
+	}
+	if scope, ok := ctx.Value(auditScopeKey{}).(AuditScope); ok {
+		return scope
+	}
+	return AuditScope{}
+}
+
+// startAudit 开始一次审计，将记录挂载到上下文中供底层调用填充
+func startAudit(ctx context.Context, operation string) (context.Context, *AuditRecord) {
+	scope := auditScopeFromContext(ctx)
+	if operation == "" {
+		operation = scope.Operation
+	}
+	if operation == "" {
+		operation = "raw"
+	}
+
+	record := &AuditRecord{
+		RequestID: requestid.GetID(ctx),
+		UserID:    scope.UserID,
+		ProjectID: scope.ProjectID,
+		Operation: operation,
+		CreatedAt: time.Now(),
+	}
+	return context.WithValue(ctx, auditRecordKey{}, record), record
+}
+
+// auditRecordFromContext 获取上下文中正在进行的审计记录
+func auditRecordFromContext(ctx context.Context) *AuditRecord {
+	if ctx == nil {
+		return nil
+	}
+	record, _ := ctx.Value(auditRecordKey{}).(*AuditRecord)
+	return record
+}
+
+// fillCall 记录一次AI接口调用的请求与原始响应
+func (r *AuditRecord) fillCall(provider AIProvider, model, prompt string, response *AIResponse, err error, duration time.Duration) {
+	r.Provider = provider
+	r.Model = model
+	r.Prompt = prompt
+	r.Duration = duration
+	if response != nil {
+		r.Response = response.Content
+		r.PromptTokens = response.Usage.PromptTokens
+		r.CompletionTokens = response.Usage.CompletionTokens
+		r.TotalTokens = response.Usage.TotalTokens
+		if response.Model != "" {
+			r.Model = response.Model
+		}
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+// finishAudit 写入解析结果并提交审计记录
+func finishAudit(ctx context.Context, recorder AuditRecorder, record *AuditRecord, result interface{}, err error) {
+	if recorder == nil || record == nil {
+		return
+	}
+
+	if err != nil {
+		record.Error = err.Error()
+	} else if result != nil {
+		if data, marshalErr := json.Marshal(result); marshalErr == nil {
+			record.ParseResult = string(data)
+		}
+	}
+
+	recorder.Record(ctx, record)
+}
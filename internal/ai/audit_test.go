@@ -0,0 +1,89 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ai-dev-platform/internal/requestid"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingAuditor 记录所有审计记录的测试用记录器
+type recordingAuditor struct {
+	records []*AuditRecord
+}
+
+func (r *recordingAuditor) Record(ctx context.Context, record *AuditRecord) {
+	r.records = append(r.records, record)
+}
+
+func newTestOpenAIServer(content string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"resp-1","model":"gpt-test","choices":[{"message":{"content":` + content + `}}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`))
+	}))
+}
+
+func TestOpenAIClient_AuditRecord(t *testing.T) {
+	server := newTestOpenAIServer(`"{\"message\":\"你好\",\"suggestions\":[\"补充角色\"]}"`)
+	defer server.Close()
+
+	auditor := &recordingAuditor{}
+	client := NewOpenAIClient(OpenAIConfig{APIKey: "test", BaseURL: server.URL, Model: "gpt-test"})
+	client.SetAuditRecorder(auditor)
+
+	ctx := requestid.NewContext(context.Background(), "req-123", time.Now())
+	ctx = WithAuditScope(ctx, AuditScope{UserID: "user-1", ProjectID: "project-1"})
+
+	_, err := client.ProjectChat(ctx, "你好", "")
+	assert.NoError(t, err)
+
+	if assert.Len(t, auditor.records, 1) {
+		record := auditor.records[0]
+		assert.Equal(t, "req-123", record.RequestID)
+		assert.Equal(t, "user-1", record.UserID)
+		assert.Equal(t, "project-1", record.ProjectID)
+		assert.Equal(t, "chat", record.Operation)
+		assert.Equal(t, ProviderOpenAI, record.Provider)
+		assert.Equal(t, "gpt-test", record.Model)
+		assert.Contains(t, record.Prompt, "你好")
+		assert.Contains(t, record.Response, "suggestions")
+		assert.Contains(t, record.ParseResult, "补充角色")
+		assert.Equal(t, 15, record.TotalTokens)
+		assert.Empty(t, record.Error)
+	}
+}
+
+func TestOpenAIClient_AuditRecordOnError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`rate limited`))
+	}))
+	defer server.Close()
+
+	auditor := &recordingAuditor{}
+	client := NewOpenAIClient(OpenAIConfig{APIKey: "test", BaseURL: server.URL})
+	client.SetAuditRecorder(auditor)
+
+	_, err := client.CallOpenAI(WithAuditScope(context.Background(), AuditScope{Operation: "replay"}), "prompt")
+	assert.Error(t, err)
+
+	if assert.Len(t, auditor.records, 1) {
+		assert.Equal(t, "replay", auditor.records[0].Operation)
+		assert.Equal(t, "prompt", auditor.records[0].Prompt)
+		assert.Contains(t, auditor.records[0].Error, "429")
+	}
+}
+
+func TestWithAuditScope_InheritsParent(t *testing.T) {
+	ctx := WithAuditScope(context.Background(), AuditScope{UserID: "user-1", ProjectID: "project-1"})
+	ctx = WithAuditScope(ctx, AuditScope{ProjectID: "project-2"})
+
+	scope := auditScopeFromContext(ctx)
+	assert.Equal(t, "user-1", scope.UserID)
+	assert.Equal(t, "project-2", scope.ProjectID)
+}
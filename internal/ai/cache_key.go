@@ -0,0 +1,221 @@
+package ai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultMaxTokens 默认最大生成Token数
+	defaultMaxTokens = 2000
+	// defaultTemperature 默认采样温度
+	defaultTemperature = 0.3
+	// minPruneTrackedKeys 项目键索引达到该条目数后才清理已过期的键
+	minPruneTrackedKeys = 1024
+)
+
+// GenerationParams 生成参数，参与缓存键计算
+type GenerationParams struct {
+	MaxTokens   int     `json:"max_tokens"`
+	Temperature float64 `json:"temperature"`
+}
+
+// CacheKeySource 可选接口：客户端提供模型、生成参数及提示语，用于计算内容寻址的缓存键
+type CacheKeySource interface {
+	// GetModel 返回当前使用的模型
+	GetModel() string
+
+	// GetGenerationParams 返回生成参数
+	GetGenerationParams() GenerationParams
+
+	// BuildPrompt 构建指定操作的提示语，不支持的操作返回空字符串
+	BuildPrompt(operation string, analysis *RequirementAnalysis, args ...string) string
+}
+
+// CacheOperationStats 单个操作的缓存命中统计
+type CacheOperationStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// cacheStatsRecorder 缓存命中统计及项目键索引
+type cacheStatsRecorder struct {
+	mutex       sync.Mutex
+	operations  map[string]*CacheOperationStats
+	projectKeys map[string]map[string]struct{}
+	keys        map[string]trackedKey // 缓存键 -> 所属项目及过期时间
+	pruneAt     int                   // 索引条目数达到该值时清理过期的键
+}
+
+// trackedKey 项目键索引中的一个缓存键
+type trackedKey struct {
+	projectID string
+	expireAt  time.Time
+}
+
+func newCacheStatsRecorder() *cacheStatsRecorder {
+	return &cacheStatsRecorder{
+		operations:  make(map[string]*CacheOperationStats),
+		projectKeys: make(map[string]map[string]struct{}),
+		keys:        make(map[string]trackedKey),
+		pruneAt:     minPruneTrackedKeys,
+	}
+}
+
+// record 记录一次缓存命中或未命中
+func (r *cacheStatsRecorder) record(operation string, hit bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stats, exists := r.operations[operation]
+	if !exists {
+		stats = &CacheOperationStats{}
+		r.operations[operation] = stats
+	}
+	if hit {
+		stats.Hits++
+	} else {
+		stats.Misses++
+	}
+}
+
+// track 记录缓存键所属项目及过期时间；索引过大时顺带清理已过期的键
+func (r *cacheStatsRecorder) track(projectID, key string, ttl time.Duration) {
+	if projectID == "" {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if previous, exists := r.keys[key]; exists && previous.projectID != projectID {
+		r.remove(key, previous.projectID)
+	}
+	keys, exists := r.projectKeys[projectID]
+	if !exists {
+		keys = make(map[string]struct{})
+		r.projectKeys[projectID] = keys
+	}
+	keys[key] = struct{}{}
+	r.keys[key] = trackedKey{projectID: projectID, expireAt: time.Now().Add(ttl)}
+
+	if len(r.keys) >= r.pruneAt {
+		r.prune(time.Now())
+		// 未过期的键仍然很多时推迟下次清理，避免每次写入都遍历索引
+		r.pruneAt = minPruneTrackedKeys
+		if 2*len(r.keys) > r.pruneAt {
+			r.pruneAt = 2 * len(r.keys)
+		}
+	}
+}
+
+// forget 缓存未命中时移除索引中的键，覆盖过期和被缓存淘汰的条目
+func (r *cacheStatsRecorder) forget(key string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if tracked, exists := r.keys[key]; exists {
+		r.remove(key, tracked.projectID)
+	}
+}
+
+// prune 移除已过期的键，调用方需持有锁
+func (r *cacheStatsRecorder) prune(now time.Time) {
+	for key, tracked := range r.keys {
+		if now.After(tracked.expireAt) {
+			r.remove(key, tracked.projectID)
+		}
+	}
+}
+
+// remove 从两个索引中移除键，调用方需持有锁
+func (r *cacheStatsRecorder) remove(key, projectID string) {
+	delete(r.keys, key)
+	if keys, exists := r.projectKeys[projectID]; exists {
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(r.projectKeys, projectID)
+		}
+	}
+}
+
+// takeProjectKeys 取出并移除项目下的所有缓存键
+func (r *cacheStatsRecorder) takeProjectKeys(projectID string) []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	keys := make([]string, 0, len(r.projectKeys[projectID]))
+	for key := range r.projectKeys[projectID] {
+		keys = append(keys, key)
+		delete(r.keys, key)
+	}
+	delete(r.projectKeys, projectID)
+	return keys
+}
+
+// snapshot 返回各操作统计的副本
+func (r *cacheStatsRecorder) snapshot() map[string]CacheOperationStats {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	result := make(map[string]CacheOperationStats, len(r.operations))
+	for operation, stats := range r.operations {
+		result[operation] = *stats
+	}
+	return result
+}
+
+// reset 清空项目键索引（统计计数保留）
+func (r *cacheStatsRecorder) reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.projectKeys = make(map[string]map[string]struct{})
+	r.keys = make(map[string]trackedKey)
+	r.pruneAt = minPruneTrackedKeys
+}
+
+// AnalysisContentHash 计算需求分析内容的规范化哈希
+// ID和时间戳不参与计算，内容相同的分析得到相同的哈希
+func AnalysisContentHash(analysis *RequirementAnalysis) string {
+	if analysis == nil {
+		return ""
+	}
+
+	canonical := struct {
+		ProjectID         string            `json:"project_id"`
+		OriginalText      string            `json:"original_text"`
+		CoreFunctions     []string          `json:"core_functions"`
+		Roles             []string          `json:"roles"`
+		BusinessProcesses []BusinessProcess `json:"business_processes"`
+		DataEntities      []DataEntity      `json:"data_entities"`
+		MissingInfo       []string          `json:"missing_info"`
+		CompletionScore   float64           `json:"completion_score"`
+	}{
+		ProjectID:         analysis.ProjectID,
+		OriginalText:      strings.TrimSpace(analysis.OriginalText),
+		CoreFunctions:     analysis.CoreFunctions,
+		Roles:             analysis.Roles,
+		BusinessProcesses: analysis.BusinessProcesses,
+		DataEntities:      analysis.DataEntities,
+		MissingInfo:       analysis.MissingInfo,
+		CompletionScore:   analysis.CompletionScore,
+	}
+
+	data, err := json.Marshal(canonical)
+	if err != nil {
+		return ""
+	}
+
+	return hashString(string(data))
+}
+
+// hashString 计算字符串的SHA-256十六进制摘要
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,251 @@
+package ai
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// 完整度检查项
+const (
+	CheckRolesDefined     = "roles_defined"
+	CheckCoreFunctions    = "core_functions"
+	CheckProcessActors    = "process_actors"
+	CheckProcessSteps     = "process_steps"
+	CheckEntityAttributes = "entity_typed_attributes"
+	CheckEntityRelations  = "entity_relations"
+	CheckNFRPerformance   = "nfr_performance"
+	CheckNFRSecurity      = "nfr_security"
+	CheckNFRAvailability  = "nfr_availability"
+	CheckErrorCases       = "error_cases"
+)
+
+const (
+	minProcessSteps            = 2   // 业务流程至少应有的步骤数
+	completenessScorePrecision = 100 // 评分保留两位小数
+)
+
+// completenessCheck 加权检查项定义，权重合计为100
+type completenessCheck struct {
+	id          string
+	category    string
+	description string
+	weight      float64
+	evaluate    func(a *RequirementAnalysis, text string) (float64, []string)
+}
+
+var completenessChecks = []completenessCheck{
+	{CheckRolesDefined, "roles", "定义了参与角色", 10, checkRoles},
+	{CheckCoreFunctions, "functions", "列出了核心功能", 10, checkCoreFunctions},
+	{CheckProcessActors, "processes", "每个业务流程都有参与者", 15, checkProcessActors},
+	{CheckProcessSteps, "processes", fmt.Sprintf("每个业务流程至少有%d个步骤", minProcessSteps), 15, checkProcessSteps},
+	{CheckEntityAttributes, "entities", "数据实体有属性且属性均标注类型", 15, checkEntityAttributes},
+	{CheckEntityRelations, "entities", "数据实体之间定义了关系", 10, checkEntityRelations},
+	{CheckNFRPerformance, "non_functional", "覆盖性能要求", 5, keywordCheck("性能要求", performanceKeywords)},
+	{CheckNFRSecurity, "non_functional", "覆盖安全要求", 5, keywordCheck("安全要求", securityKeywords)},
+	{CheckNFRAvailability, "non_functional", "覆盖可用性要求", 5, keywordCheck("可用性要求", availabilityKeywords)},
+	{CheckErrorCases, "error_handling", "描述了异常和错误场景", 10, keywordCheck("异常或错误场景", errorCaseKeywords)},
+}
+
+var (
+	performanceKeywords  = []string{"性能", "响应时间", "并发", "吞吐", "延迟", "毫秒", "秒内", "qps", "tps", "performance", "latency", "throughput", "response time", "concurrent"}
+	securityKeywords     = []string{"安全", "权限", "认证", "鉴权", "授权", "加密", "登录", "审计", "security", "authentication", "authorization", "permission", "encrypt", "access control", "audit"}
+	availabilityKeywords = []string{"可用性", "高可用", "容灾", "备份", "故障转移", "冗余", "7x24", "99.9", "availability", "uptime", "failover", "backup", "disaster recovery"}
+	errorCaseKeywords    = []string{"异常", "错误", "失败", "超时", "重试", "回滚", "无效", "不足", "冲突", "error", "fail", "exception", "timeout", "retry", "rollback", "invalid", "reject"}
+)
+
+// CompletenessCheckResult 单个检查项的评分明细
+type CompletenessCheckResult struct {
+	ID          string   `json:"id"`
+	Category    string   `json:"category"`
+	Description string   `json:"description"`
+	Weight      float64  `json:"weight"`
+	Ratio       float64  `json:"ratio"`  // 达成比例 (0-1)
+	Points      float64  `json:"points"` // Weight * Ratio
+	Passed      bool     `json:"passed"`
+	Issues      []string `json:"issues,omitempty"` // 未达成的具体原因
+}
+
+// CompletenessReport 基于规则的完整度评分结果，相同输入总是得到相同结果
+type CompletenessReport struct {
+	Score      float64                   `json:"score"` // 完整度评分 (0-1)
+	Points     float64                   `json:"points"`
+	MaxPoints  float64                   `json:"max_points"`
+	Checks     []CompletenessCheckResult `json:"checks"`
+	ModelScore float64                   `json:"model_score"` // 模型给出的评分，仅作参考
+}
+
+// ScoreCompleteness 按加权检查清单计算需求分析的完整度
+func ScoreCompleteness(analysis *RequirementAnalysis) *CompletenessReport {
+	if analysis == nil {
+		analysis = &RequirementAnalysis{}
+	}
+	text := strings.ToLower(analysisText(analysis))
+
+	report := &CompletenessReport{ModelScore: analysis.CompletionScore, Checks: make([]CompletenessCheckResult, 0, len(completenessChecks))}
+	for _, check := range completenessChecks {
+		ratio, issues := check.evaluate(analysis, text)
+		ratio = math.Max(0, math.Min(1, ratio))
+		result := CompletenessCheckResult{
+			ID:          check.id,
+			Category:    check.category,
+			Description: check.description,
+			Weight:      check.weight,
+			Ratio:       roundScore(ratio),
+			Points:      roundScore(check.weight * ratio),
+			Passed:      ratio >= 1,
+			Issues:      issues,
+		}
+		report.Checks = append(report.Checks, result)
+		report.Points += check.weight * ratio
+		report.MaxPoints += check.weight
+	}
+
+	report.Points = roundScore(report.Points)
+	if report.MaxPoints > 0 {
+		report.Score = roundScore(report.Points / report.MaxPoints)
+	}
+	return report
+}
+
+func roundScore(v float64) float64 {
+	return math.Round(v*completenessScorePrecision) / completenessScorePrecision
+}
+
+// analysisText 汇总分析中的文本，用于关键词检查；缺失信息描述的是缺少的内容，不参与匹配
+func analysisText(a *RequirementAnalysis) string {
+	parts := []string{a.OriginalText}
+	parts = append(parts, a.CoreFunctions...)
+	for _, p := range a.BusinessProcesses {
+		parts = append(parts, p.Name, p.Description)
+		parts = append(parts, p.Steps...)
+	}
+	for _, e := range a.DataEntities {
+		parts = append(parts, e.Description)
+		for _, attr := range e.Attributes {
+			parts = append(parts, attr.Description)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func checkRoles(a *RequirementAnalysis, _ string) (float64, []string) {
+	if len(nonEmpty(a.Roles)) == 0 {
+		return 0, []string{"未定义参与角色"}
+	}
+	return 1, nil
+}
+
+func checkCoreFunctions(a *RequirementAnalysis, _ string) (float64, []string) {
+	if len(nonEmpty(a.CoreFunctions)) == 0 {
+		return 0, []string{"未列出核心功能"}
+	}
+	return 1, nil
+}
+
+func checkProcessActors(a *RequirementAnalysis, _ string) (float64, []string) {
+	if len(a.BusinessProcesses) == 0 {
+		return 0, []string{"未定义业务流程"}
+	}
+	var issues []string
+	for _, p := range a.BusinessProcesses {
+		if len(nonEmpty(p.Actors)) == 0 {
+			issues = append(issues, fmt.Sprintf("流程「%s」缺少参与者", p.Name))
+		}
+	}
+	return passRatio(len(a.BusinessProcesses), len(issues)), issues
+}
+
+func checkProcessSteps(a *RequirementAnalysis, _ string) (float64, []string) {
+	if len(a.BusinessProcesses) == 0 {
+		return 0, []string{"未定义业务流程"}
+	}
+	var issues []string
+	for _, p := range a.BusinessProcesses {
+		if n := len(nonEmpty(p.Steps)); n < minProcessSteps {
+			issues = append(issues, fmt.Sprintf("流程「%s」只有%d个步骤", p.Name, n))
+		}
+	}
+	return passRatio(len(a.BusinessProcesses), len(issues)), issues
+}
+
+// checkEntityAttributes 按属性计分：实体没有属性计为一个未达成项
+func checkEntityAttributes(a *RequirementAnalysis, _ string) (float64, []string) {
+	if len(a.DataEntities) == 0 {
+		return 0, []string{"未定义数据实体"}
+	}
+	var issues []string
+	total := 0
+	for _, e := range a.DataEntities {
+		if len(e.Attributes) == 0 {
+			total++
+			issues = append(issues, fmt.Sprintf("实体「%s」没有属性", e.Name))
+			continue
+		}
+		for _, attr := range e.Attributes {
+			total++
+			if strings.TrimSpace(attr.Type) == "" {
+				issues = append(issues, fmt.Sprintf("实体「%s」的属性「%s」未标注类型", e.Name, attr.Name))
+			}
+		}
+	}
+	return passRatio(total, len(issues)), issues
+}
+
+// checkEntityRelations 多个实体时，每个实体应至少作为关系的一端；只有一个实体时无需关系
+func checkEntityRelations(a *RequirementAnalysis, _ string) (float64, []string) {
+	switch len(a.DataEntities) {
+	case 0:
+		return 0, []string{"未定义数据实体"}
+	case 1:
+		return 1, nil
+	}
+
+	related := make(map[string]bool)
+	for _, e := range a.DataEntities {
+		for _, r := range e.Relations {
+			if strings.TrimSpace(r.TargetEntity) == "" {
+				continue
+			}
+			related[normalizeName(e.Name)] = true
+			related[normalizeName(r.TargetEntity)] = true
+		}
+	}
+
+	var issues []string
+	for _, e := range a.DataEntities {
+		if !related[normalizeName(e.Name)] {
+			issues = append(issues, fmt.Sprintf("实体「%s」未与其他实体建立关系", e.Name))
+		}
+	}
+	return passRatio(len(a.DataEntities), len(issues)), issues
+}
+
+// keywordCheck 文本中出现任一关键词即视为覆盖
+func keywordCheck(subject string, keywords []string) func(*RequirementAnalysis, string) (float64, []string) {
+	return func(_ *RequirementAnalysis, text string) (float64, []string) {
+		for _, keyword := range keywords {
+			if strings.Contains(text, keyword) {
+				return 1, nil
+			}
+		}
+		return 0, []string{"未提及" + subject}
+	}
+}
+
+func passRatio(total, failed int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(total-failed) / float64(total)
+}
+
+func nonEmpty(values []string) []string {
+	var result []string
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
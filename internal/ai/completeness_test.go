@@ -0,0 +1,102 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checkByID(t *testing.T, report *CompletenessReport, id string) CompletenessCheckResult {
+	t.Helper()
+	for _, check := range report.Checks {
+		if check.ID == id {
+			return check
+		}
+	}
+	t.Fatalf("check %s not found", id)
+	return CompletenessCheckResult{}
+}
+
+func TestScoreCompletenessFull(t *testing.T) {
+	analysis := &RequirementAnalysis{
+		OriginalText:  "图书管理系统，借阅接口响应时间小于200毫秒，读者需登录认证，服务可用性99.9%。",
+		CoreFunctions: []string{"借阅", "归还"},
+		Roles:         []string{"读者", "管理员"},
+		BusinessProcesses: []BusinessProcess{
+			{Name: "借书", Steps: []string{"选书", "确认借阅", "库存不足时提示失败"}, Actors: []string{"读者"}},
+		},
+		DataEntities: []DataEntity{
+			{Name: "Book", Attributes: []EntityAttribute{{Name: "title", Type: "string"}}, Relations: []EntityRelation{{TargetEntity: "Loan"}}},
+			{Name: "Loan", Attributes: []EntityAttribute{{Name: "due_at", Type: "datetime"}}},
+		},
+		CompletionScore: 0.42,
+	}
+
+	report := ScoreCompleteness(analysis)
+	assert.Equal(t, 1.0, report.Score)
+	assert.Equal(t, 100.0, report.MaxPoints)
+	assert.Equal(t, 0.42, report.ModelScore)
+	for _, check := range report.Checks {
+		assert.True(t, check.Passed, check.ID)
+		assert.Empty(t, check.Issues, check.ID)
+	}
+
+	// 相同输入得到相同结果
+	assert.Equal(t, report, ScoreCompleteness(analysis))
+}
+
+func TestScoreCompletenessPartial(t *testing.T) {
+	analysis := &RequirementAnalysis{
+		CoreFunctions: []string{"下单"},
+		BusinessProcesses: []BusinessProcess{
+			{Name: "下单", Steps: []string{"提交订单", "支付"}, Actors: []string{"买家"}},
+			{Name: "发货", Steps: []string{"发货"}},
+		},
+		DataEntities: []DataEntity{
+			{Name: "Order", Attributes: []EntityAttribute{{Name: "id", Type: "int"}, {Name: "amount"}}},
+			{Name: "Author", Description: "author of the book"},
+		},
+	}
+
+	report := ScoreCompleteness(analysis)
+	require.Len(t, report.Checks, len(completenessChecks))
+
+	roles := checkByID(t, report, CheckRolesDefined)
+	assert.False(t, roles.Passed)
+	assert.Equal(t, 0.0, roles.Points)
+
+	actors := checkByID(t, report, CheckProcessActors)
+	assert.Equal(t, 0.5, actors.Ratio)
+	assert.Equal(t, 7.5, actors.Points)
+	assert.Equal(t, []string{"流程「发货」缺少参与者"}, actors.Issues)
+
+	steps := checkByID(t, report, CheckProcessSteps)
+	assert.Equal(t, 0.5, steps.Ratio)
+
+	// Order 有一个无类型属性，Author 没有属性：3项中1项达成
+	attributes := checkByID(t, report, CheckEntityAttributes)
+	assert.Equal(t, 0.33, attributes.Ratio)
+	assert.Len(t, attributes.Issues, 2)
+
+	relations := checkByID(t, report, CheckEntityRelations)
+	assert.Equal(t, 0.0, relations.Ratio)
+
+	// "author" 不应被当作安全关键词
+	assert.False(t, checkByID(t, report, CheckNFRSecurity).Passed)
+	assert.False(t, checkByID(t, report, CheckErrorCases).Passed)
+
+	// 10(功能) + 7.5 + 7.5 + 5 = 30
+	assert.Equal(t, 30.0, report.Points)
+	assert.Equal(t, 0.3, report.Score)
+}
+
+func TestScoreCompletenessEmpty(t *testing.T) {
+	report := ScoreCompleteness(nil)
+	assert.Equal(t, 0.0, report.Score)
+	assert.Equal(t, 100.0, report.MaxPoints)
+	for _, check := range report.Checks {
+		assert.False(t, check.Passed)
+		assert.NotEmpty(t, check.Issues)
+	}
+}
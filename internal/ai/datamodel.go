@@ -0,0 +1,854 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ai-dev-platform/internal/plantuml"
+)
+
+// ErrNoDataModel 没有可用于生成数据模型图的数据实体或表
+var ErrNoDataModel = errors.New("没有可用于生成数据模型图的数据实体或表")
+
+// DataModelStyle 数据模型图的表示法
+type DataModelStyle string
+
+const (
+	DataModelEntity DataModelStyle = "entity" // ER 图：entity 与鸦脚关系
+	DataModelClass  DataModelStyle = "class"  // 类图：class 与多重性
+)
+
+// DataModelOptions 生成数据模型图的选项
+type DataModelOptions struct {
+	Title    string            `json:"title,omitempty"`
+	Style    DataModelStyle    `json:"style,omitempty"`    // 默认为 entity
+	Packages map[string]string `json:"packages,omitempty"` // 实体或表名 -> 包名；未指定时按表名前缀分组
+}
+
+// 关系端点的基数
+type cardinality int
+
+const (
+	cardOne cardinality = iota
+	cardZeroOne
+	cardMany
+	cardOneMany
+)
+
+// ER 图中左端和右端的鸦脚写法，以及类图中的多重性
+var (
+	leftEnds    = map[cardinality]string{cardOne: "||", cardZeroOne: "|o", cardMany: "}o", cardOneMany: "}|"}
+	rightEnds   = map[cardinality]string{cardOne: "||", cardZeroOne: "o|", cardMany: "o{", cardOneMany: "|{"}
+	classEnds   = map[cardinality]string{cardOne: "1", cardZeroOne: "0..1", cardMany: "0..*", cardOneMany: "1..*"}
+	crowEndRe   = regexp.MustCompile(`^([|o}{]{2})?[-.]+([|o}{]{2})?$`)
+	plainNameRe = regexp.MustCompile(`^[\p{L}\p{N}_]+$`)
+	sizedTypeRe = regexp.MustCompile(`^(\w+)\s*\(\s*(\d+)\s*\)$`)
+)
+
+// dataTable 生成和解析共用的中间结构：一个实体或表
+type dataTable struct {
+	name    string
+	pkg     string
+	columns []dataColumn
+}
+
+type dataColumn struct {
+	name     string
+	typ      string
+	required bool
+	pk       bool
+	fk       bool
+}
+
+// dataLink 两个实体之间的关系，left、right 为两端的基数
+type dataLink struct {
+	left, right string
+	leftCard    cardinality
+	rightCard   cardinality
+	label       string
+}
+
+// DataModelDiagram 不调用AI，由数据库设计或需求分析中的数据实体确定性地生成数据模型图；
+// 数据库设计中有表时以数据库设计为准
+func DataModelDiagram(analysis *RequirementAnalysis, design *DatabaseDesign, opts DataModelOptions) (*PUMLDiagram, error) {
+	if opts.Title == "" {
+		opts.Title = "数据模型图"
+	}
+	diagram := &PUMLDiagram{Type: PUMLTypeDataModel, Title: opts.Title, Version: 1}
+	switch {
+	case design != nil && len(design.Tables) > 0:
+		diagram.Content = DatabaseDesignPUML(design, opts)
+		diagram.Description = fmt.Sprintf("根据数据库设计生成，包含 %d 张表和 %d 个外键关系", len(design.Tables), len(design.Relations))
+	case analysis != nil && len(analysis.DataEntities) > 0:
+		diagram.Content = DataEntitiesPUML(analysis.DataEntities, opts)
+		diagram.Description = fmt.Sprintf("根据需求分析中的 %d 个数据实体生成", len(analysis.DataEntities))
+	default:
+		return nil, ErrNoDataModel
+	}
+	if analysis != nil {
+		diagram.ProjectID = analysis.ProjectID
+	}
+	return diagram, nil
+}
+
+// DatabaseDesignPUML 由数据库设计生成数据模型图：主键列在分隔线之上，外键列标 <<FK>>，非空列标 *；
+// 外键列非空时被引用端为“恰好一个”，否则为“零或一个”；外键列唯一时引用端为“零或一个”，否则为“零或多个”
+func DatabaseDesignPUML(design *DatabaseDesign, opts DataModelOptions) string {
+	fkColumns := make(map[string]bool)
+	for _, r := range design.Relations {
+		fkColumns[r.FromTable+"."+r.FromColumn] = true
+	}
+	unique := uniqueColumns(design)
+
+	tables := make([]dataTable, 0, len(design.Tables))
+	columnsByTable := make(map[string]map[string]ColumnDesign)
+	for _, t := range design.Tables {
+		table := dataTable{name: t.Name}
+		columnsByTable[t.Name] = make(map[string]ColumnDesign)
+		for _, c := range t.Columns {
+			columnsByTable[t.Name][c.Name] = c
+			table.columns = append(table.columns, dataColumn{
+				name:     c.Name,
+				typ:      columnType(c),
+				required: !c.Nullable || c.PrimaryKey,
+				pk:       c.PrimaryKey,
+				fk:       fkColumns[t.Name+"."+c.Name],
+			})
+		}
+		tables = append(tables, table)
+	}
+
+	links := make([]dataLink, 0, len(design.Relations))
+	for _, r := range design.Relations {
+		link := dataLink{left: r.ToTable, right: r.FromTable, leftCard: cardZeroOne, rightCard: cardMany, label: r.FromColumn}
+		if link.label == "" {
+			link.label = r.Name
+		}
+		if c, ok := columnsByTable[r.FromTable][r.FromColumn]; ok && (!c.Nullable || c.PrimaryKey) {
+			link.leftCard = cardOne
+		}
+		if unique[r.FromTable+"."+r.FromColumn] {
+			link.rightCard = cardZeroOne
+		}
+		links = append(links, link)
+	}
+	return renderDataModel(tables, links, opts, true)
+}
+
+// uniqueColumns 单独构成主键或唯一索引的列
+func uniqueColumns(design *DatabaseDesign) map[string]bool {
+	unique := make(map[string]bool)
+	for _, t := range design.Tables {
+		var pks []string
+		for _, c := range t.Columns {
+			if c.PrimaryKey {
+				pks = append(pks, c.Name)
+			}
+		}
+		if len(pks) == 1 {
+			unique[t.Name+"."+pks[0]] = true
+		}
+	}
+	for _, idx := range design.Indexes {
+		if idx.Unique && len(idx.Columns) == 1 {
+			unique[idx.Table+"."+idx.Columns[0]] = true
+		}
+	}
+	return unique
+}
+
+// columnType 列类型，带长度时写成 varchar(50)
+func columnType(c ColumnDesign) string {
+	if c.Length > 0 && !strings.Contains(c.Type, "(") {
+		return fmt.Sprintf("%s(%d)", c.Type, c.Length)
+	}
+	return c.Type
+}
+
+// DataEntitiesPUML 由需求分析中的数据实体生成数据模型图；描述含“主键”或名为 id 的属性为主键，
+// 描述含“外键”或以 id 结尾的属性为外键；两个实体之间的同一关系只画一次
+func DataEntitiesPUML(entities []DataEntity, opts DataModelOptions) string {
+	tables := make([]dataTable, 0, len(entities))
+	for _, e := range entities {
+		table := dataTable{name: e.Name}
+		for _, a := range e.Attributes {
+			pk := isPrimaryAttribute(a)
+			table.columns = append(table.columns, dataColumn{
+				name:     a.Name,
+				typ:      a.Type,
+				required: a.Required || pk,
+				pk:       pk,
+				fk:       !pk && isForeignAttribute(a),
+			})
+		}
+		tables = append(tables, table)
+	}
+
+	var links []dataLink
+	seen := make(map[string]bool)
+	for _, e := range entities {
+		for _, r := range e.Relations {
+			if strings.TrimSpace(r.TargetEntity) == "" {
+				continue
+			}
+			link := dataLink{left: e.Name, right: r.TargetEntity, label: r.Description}
+			switch normalizeRelationType(r.RelationType) {
+			case "one-to-one":
+				link.leftCard, link.rightCard = cardOne, cardZeroOne
+			case "many-to-one":
+				link.left, link.right = r.TargetEntity, e.Name
+				link.leftCard, link.rightCard = cardOne, cardMany
+			case "many-to-many":
+				link.leftCard, link.rightCard = cardMany, cardMany
+			default:
+				link.leftCard, link.rightCard = cardOne, cardMany
+			}
+			key := link.left + "\x00" + link.right + "\x00" + leftEnds[link.leftCard] + rightEnds[link.rightCard]
+			if link.leftCard == link.rightCard {
+				// 对称的关系与方向无关
+				pair := []string{link.left, link.right}
+				sort.Strings(pair)
+				key = pair[0] + "\x00" + pair[1] + "\x00" + leftEnds[link.leftCard]
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			links = append(links, link)
+		}
+	}
+	return renderDataModel(tables, links, opts, false)
+}
+
+// normalizeRelationType 统一关系类型的写法，如 1:N、one_to_many、一对多
+func normalizeRelationType(t string) string {
+	t = strings.ToLower(strings.TrimSpace(t))
+	t = strings.NewReplacer("_", "-", " ", "-").Replace(t)
+	switch t {
+	case "1:1", "1-1", "一对一", "one-to-one":
+		return "one-to-one"
+	case "n:1", "n-1", "多对一", "many-to-one":
+		return "many-to-one"
+	case "n:m", "m:n", "n:n", "多对多", "many-to-many":
+		return "many-to-many"
+	}
+	return "one-to-many"
+}
+
+func isPrimaryAttribute(a EntityAttribute) bool {
+	return strings.EqualFold(a.Name, "id") || strings.Contains(a.Description, "主键") || strings.Contains(strings.ToLower(a.Description), "primary key")
+}
+
+func isForeignAttribute(a EntityAttribute) bool {
+	if strings.Contains(a.Description, "外键") || strings.Contains(strings.ToLower(a.Description), "foreign key") {
+		return true
+	}
+	lower := strings.ToLower(a.Name)
+	return strings.HasSuffix(lower, "_id") || len(a.Name) > 2 && strings.HasSuffix(a.Name, "ID") || strings.HasSuffix(a.Name, "Id")
+}
+
+// renderDataModel 输出 PlantUML 源码；groupByPrefix 为真时未指定包的表按名称前缀分组
+func renderDataModel(tables []dataTable, links []dataLink, opts DataModelOptions, groupByPrefix bool) string {
+	style := opts.Style
+	if style != DataModelClass {
+		style = DataModelEntity
+	}
+	assignPackages(tables, opts.Packages, groupByPrefix)
+
+	var b strings.Builder
+	b.WriteString("@startuml\n")
+	if opts.Title != "" {
+		fmt.Fprintf(&b, "title %s\n", opts.Title)
+	}
+	if style == DataModelEntity {
+		b.WriteString("hide circle\nskinparam linetype ortho\n")
+	}
+	b.WriteString("\n")
+
+	var packages []string
+	byPackage := make(map[string][]dataTable)
+	for _, t := range tables {
+		if _, ok := byPackage[t.pkg]; !ok && t.pkg != "" {
+			packages = append(packages, t.pkg)
+		}
+		byPackage[t.pkg] = append(byPackage[t.pkg], t)
+	}
+	for _, t := range byPackage[""] {
+		writeTable(&b, t, style, "")
+		b.WriteString("\n")
+	}
+	for _, pkg := range packages {
+		fmt.Fprintf(&b, "package %s {\n", dataModelRef(pkg))
+		for i, t := range byPackage[pkg] {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			writeTable(&b, t, style, "  ")
+		}
+		b.WriteString("}\n\n")
+	}
+
+	for _, l := range links {
+		var line string
+		if style == DataModelClass {
+			line = fmt.Sprintf("%s %q -- %q %s", dataModelRef(l.left), classEnds[l.leftCard], classEnds[l.rightCard], dataModelRef(l.right))
+		} else {
+			line = fmt.Sprintf("%s %s--%s %s", dataModelRef(l.left), leftEnds[l.leftCard], rightEnds[l.rightCard], dataModelRef(l.right))
+		}
+		if l.label != "" {
+			line += " : " + l.label
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("@enduml\n")
+	return b.String()
+}
+
+// assignPackages 指定了包的表按指定分组；否则多张表共用同一个下划线前缀时归入以前缀命名的包
+func assignPackages(tables []dataTable, packages map[string]string, groupByPrefix bool) {
+	prefixes := make(map[string]int)
+	if groupByPrefix && len(packages) == 0 {
+		for _, t := range tables {
+			if i := strings.Index(t.name, "_"); i > 0 {
+				prefixes[t.name[:i]]++
+			}
+		}
+	}
+	for i := range tables {
+		if pkg, ok := packages[tables[i].name]; ok {
+			tables[i].pkg = pkg
+		} else if j := strings.Index(tables[i].name, "_"); j > 0 && prefixes[tables[i].name[:j]] > 1 {
+			tables[i].pkg = tables[i].name[:j]
+		}
+	}
+}
+
+func writeTable(b *strings.Builder, t dataTable, style DataModelStyle, indent string) {
+	fmt.Fprintf(b, "%s%s %s {\n", indent, style, dataModelRef(t.name))
+	var keys, others []dataColumn
+	for _, c := range t.columns {
+		if c.pk {
+			keys = append(keys, c)
+		} else {
+			others = append(others, c)
+		}
+	}
+	for _, c := range keys {
+		writeColumn(b, c, indent)
+	}
+	if len(keys) > 0 && len(others) > 0 {
+		fmt.Fprintf(b, "%s  --\n", indent)
+	}
+	for _, c := range others {
+		writeColumn(b, c, indent)
+	}
+	fmt.Fprintf(b, "%s}\n", indent)
+}
+
+func writeColumn(b *strings.Builder, c dataColumn, indent string) {
+	line := indent + "  "
+	if c.required {
+		line += "* "
+	}
+	line += c.name
+	if c.typ != "" {
+		line += " : " + c.typ
+	}
+	if c.pk {
+		line += " <<PK>>"
+	}
+	if c.fk {
+		line += " <<FK>>"
+	}
+	b.WriteString(line + "\n")
+}
+
+// dataModelRef 名称含空格等字符时加引号
+func dataModelRef(name string) string {
+	if plainNameRe.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// parseDataModel 解析编辑后的数据模型图，支持 entity 鸦脚写法和 class 多重性写法
+func parseDataModel(source string) ([]dataTable, []dataLink, error) {
+	doc := plantuml.Parse(source)
+	if doc.HasErrors() {
+		for _, d := range doc.Diagnostics {
+			if d.Severity == plantuml.SeverityError {
+				return nil, nil, fmt.Errorf("数据模型图第 %d 行: %s", d.Line, d.Message)
+			}
+		}
+	}
+	if doc.Type != plantuml.DiagramEntity && doc.Type != plantuml.DiagramClass {
+		return nil, nil, fmt.Errorf("不是ER图或类图: %s", doc.Type)
+	}
+
+	var tables []dataTable
+	for _, e := range doc.Elements {
+		if e.Kind != "entity" && e.Kind != "class" {
+			continue
+		}
+		table := dataTable{name: e.ID(), pkg: e.Parent}
+		for _, m := range e.Members {
+			if m.Method || m.Name == "" {
+				continue
+			}
+			table.columns = append(table.columns, dataColumn{
+				name:     m.Name,
+				typ:      m.Type,
+				required: strings.HasPrefix(strings.TrimSpace(m.Text), "*") || m.PrimaryKey,
+				pk:       m.PrimaryKey,
+				fk:       m.ForeignKey,
+			})
+		}
+		tables = append(tables, table)
+	}
+	if len(tables) == 0 {
+		return nil, nil, fmt.Errorf("数据模型图中没有实体")
+	}
+
+	var links []dataLink
+	for _, r := range doc.Relations {
+		from, to := doc.Element(r.From), doc.Element(r.To)
+		if from == nil || to == nil {
+			continue
+		}
+		link := dataLink{left: from.ID(), right: to.ID(), leftCard: cardOne, rightCard: cardMany, label: r.Label}
+		if m := crowEndRe.FindStringSubmatch(r.Arrow); m != nil && (m[1] != "" || m[2] != "") {
+			link.leftCard, link.rightCard = crowCardinality(m[1]), crowCardinality(m[2])
+		} else {
+			link.leftCard, link.rightCard = multiplicity(r.FromCardinality), multiplicity(r.ToCardinality)
+			if r.FromCardinality == "" && r.ToCardinality == "" {
+				link.leftCard, link.rightCard = cardOne, cardMany
+			}
+		}
+		links = append(links, link)
+	}
+	return tables, links, nil
+}
+
+// crowCardinality 鸦脚端点对应的基数，左右两端的写法互为镜像
+func crowCardinality(end string) cardinality {
+	switch end {
+	case "||":
+		return cardOne
+	case "|o", "o|":
+		return cardZeroOne
+	case "}|", "|{":
+		return cardOneMany
+	case "}o", "o{":
+		return cardMany
+	}
+	return cardOne
+}
+
+// multiplicity 类图多重性对应的基数
+func multiplicity(s string) cardinality {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "0..1":
+		return cardZeroOne
+	case "1..*", "1..n":
+		return cardOneMany
+	case "*", "0..*", "0..n", "n", "many":
+		return cardMany
+	}
+	return cardOne
+}
+
+func isMany(c cardinality) bool {
+	return c == cardMany || c == cardOneMany
+}
+
+// ParseDataEntities 把编辑后的数据模型图解析为数据实体：主键、外键记在属性描述中，
+// 关系记在“一”端的实体上，多对一的关系转换为对端的一对多
+func ParseDataEntities(source string) ([]DataEntity, error) {
+	tables, links, err := parseDataModel(source)
+	if err != nil {
+		return nil, err
+	}
+	entities := make([]DataEntity, len(tables))
+	index := make(map[string]int, len(tables))
+	for i, t := range tables {
+		index[t.name] = i
+		entity := DataEntity{Name: t.name, Attributes: []EntityAttribute{}, Relations: []EntityRelation{}}
+		for _, c := range t.columns {
+			attr := EntityAttribute{Name: c.name, Type: c.typ, Required: c.required}
+			switch {
+			case c.pk:
+				attr.Description = "主键"
+			case c.fk:
+				attr.Description = "外键"
+			}
+			entity.Attributes = append(entity.Attributes, attr)
+		}
+		entities[i] = entity
+	}
+	for _, l := range links {
+		owner, target := l.left, l.right
+		relationType := "one-to-many"
+		switch {
+		case isMany(l.leftCard) && isMany(l.rightCard):
+			relationType = "many-to-many"
+		case isMany(l.leftCard):
+			owner, target = l.right, l.left
+		case !isMany(l.rightCard):
+			relationType = "one-to-one"
+		}
+		if i, ok := index[owner]; ok {
+			entities[i].Relations = append(entities[i].Relations, EntityRelation{TargetEntity: target, RelationType: relationType, Description: l.label})
+		}
+	}
+	return entities, nil
+}
+
+// ParseDatabaseDesign 把编辑后的数据模型图解析为数据库设计：“多”或可选的一端为外键所在的表，
+// 关系标签为该表中的列名时作为外键列，否则取该表中的第一个外键列；被引用列为对端的主键
+func ParseDatabaseDesign(source string) (*DatabaseDesign, error) {
+	tables, links, err := parseDataModel(source)
+	if err != nil {
+		return nil, err
+	}
+	design := &DatabaseDesign{Tables: []TableDesign{}, Indexes: []IndexDesign{}, Relations: []RelationDesign{}}
+	byName := make(map[string]dataTable, len(tables))
+	for _, t := range tables {
+		byName[t.name] = t
+		table := TableDesign{Name: t.name, Columns: []ColumnDesign{}}
+		for _, c := range t.columns {
+			column := ColumnDesign{Name: c.name, Type: c.typ, Nullable: !c.required, PrimaryKey: c.pk}
+			if m := sizedTypeRe.FindStringSubmatch(c.typ); m != nil {
+				column.Type = m[1]
+				column.Length, _ = strconv.Atoi(m[2])
+			}
+			table.Columns = append(table.Columns, column)
+		}
+		design.Tables = append(design.Tables, table)
+	}
+
+	for _, l := range links {
+		child, parent := l.right, l.left
+		if isMany(l.leftCard) && !isMany(l.rightCard) || l.leftCard == cardZeroOne && l.rightCard == cardOne {
+			child, parent = l.left, l.right
+		}
+		relation := RelationDesign{FromTable: child, ToTable: parent}
+		for _, c := range byName[child].columns {
+			if c.name == l.label {
+				relation.FromColumn = c.name
+				break
+			}
+		}
+		if relation.FromColumn == "" {
+			relation.Name = l.label
+			for _, c := range byName[child].columns {
+				if c.fk {
+					relation.FromColumn = c.name
+					break
+				}
+			}
+		}
+		for _, c := range byName[parent].columns {
+			if c.pk {
+				relation.ToColumn = c.name
+				break
+			}
+		}
+		design.Relations = append(design.Relations, relation)
+	}
+	return design, nil
+}
+
+// MergeDatabaseDesign 以编辑后的结构为准合并数据库设计：表、列和外键关系取 edited，已有的表保持原顺序，
+// 图中无法表示的注释、默认值、自增、索引、外键名称和级联规则从 base 中同名的表、列和关系保留
+func MergeDatabaseDesign(base, edited *DatabaseDesign) *DatabaseDesign {
+	merged := &DatabaseDesign{Tables: []TableDesign{}, Indexes: []IndexDesign{}, Relations: []RelationDesign{}}
+	baseTables := make(map[string]TableDesign)
+	order := make(map[string]int)
+	if base != nil {
+		for i, t := range base.Tables {
+			baseTables[t.Name] = t
+			order[t.Name] = i
+		}
+	}
+	tables := make(map[string]map[string]bool)
+	for _, t := range edited.Tables {
+		old, ok := baseTables[t.Name]
+		columns := make(map[string]ColumnDesign)
+		if ok {
+			t.Comment = old.Comment
+			for _, c := range old.Columns {
+				columns[c.Name] = c
+			}
+		}
+		tables[t.Name] = make(map[string]bool)
+		for i, c := range t.Columns {
+			tables[t.Name][c.Name] = true
+			if old, ok := columns[c.Name]; ok {
+				c.Default, c.Comment, c.AutoIncrement = old.Default, old.Comment, old.AutoIncrement
+				if c.Length == 0 && strings.EqualFold(c.Type, old.Type) {
+					c.Length = old.Length
+				}
+				t.Columns[i] = c
+			}
+		}
+		merged.Tables = append(merged.Tables, t)
+	}
+	sort.SliceStable(merged.Tables, func(i, j int) bool {
+		return mergeOrder(order, merged.Tables[i].Name) < mergeOrder(order, merged.Tables[j].Name)
+	})
+
+	if base != nil {
+		// 索引涉及的列都还在时保留
+		for _, idx := range base.Indexes {
+			keep := tables[idx.Table] != nil
+			for _, c := range idx.Columns {
+				keep = keep && tables[idx.Table][c]
+			}
+			if keep {
+				merged.Indexes = append(merged.Indexes, idx)
+			}
+		}
+	}
+	for _, r := range edited.Relations {
+		if base != nil {
+			for _, old := range base.Relations {
+				if old.FromTable == r.FromTable && old.FromColumn == r.FromColumn && old.ToTable == r.ToTable {
+					if r.Name == "" {
+						r.Name = old.Name
+					}
+					if r.ToColumn == "" {
+						r.ToColumn = old.ToColumn
+					}
+					r.OnDelete, r.OnUpdate = old.OnDelete, old.OnUpdate
+					break
+				}
+			}
+		}
+		merged.Relations = append(merged.Relations, r)
+	}
+	return merged
+}
+
+// MergeDataEntities 以编辑后的实体为准合并数据实体，实体和属性的描述、关系的描述从 base 中同名的条目保留
+func MergeDataEntities(base, edited []DataEntity) []DataEntity {
+	baseEntities := make(map[string]DataEntity, len(base))
+	order := make(map[string]int, len(base))
+	for i, e := range base {
+		baseEntities[e.Name] = e
+		order[e.Name] = i
+	}
+	merged := make([]DataEntity, 0, len(edited))
+	for _, e := range edited {
+		old, ok := baseEntities[e.Name]
+		if ok {
+			e.Description = old.Description
+			attributes := make(map[string]EntityAttribute, len(old.Attributes))
+			for _, a := range old.Attributes {
+				attributes[a.Name] = a
+			}
+			for i, a := range e.Attributes {
+				if o, ok := attributes[a.Name]; ok && o.Description != "" {
+					e.Attributes[i].Description = o.Description
+				}
+			}
+			for i, r := range e.Relations {
+				for _, o := range old.Relations {
+					if o.TargetEntity == r.TargetEntity && normalizeRelationType(o.RelationType) == r.RelationType {
+						if r.Description == "" {
+							e.Relations[i].Description = o.Description
+						}
+						break
+					}
+				}
+			}
+		}
+		merged = append(merged, e)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return mergeOrder(order, merged[i].Name) < mergeOrder(order, merged[j].Name)
+	})
+	return merged
+}
+
+// mergeOrder 合并结果的排序：已有的条目保持原顺序，新增的排在后面并保持图中的顺序
+func mergeOrder(order map[string]int, name string) int {
+	if i, ok := order[name]; ok {
+		return i
+	}
+	return len(order)
+}
+
+// DatabaseDesignEntities 把数据库设计（如从现有数据库导入的结构）转换为需求分析中的数据实体：
+// 表注释作为实体描述，主键、外键和列注释记在属性描述中，外键关系记在被引用的实体上
+func DatabaseDesignEntities(design *DatabaseDesign) []DataEntity {
+	fkTargets := make(map[string]string)
+	for _, r := range design.Relations {
+		target := r.ToTable
+		if r.ToColumn != "" {
+			target += "." + r.ToColumn
+		}
+		fkTargets[r.FromTable+"."+r.FromColumn] = target
+	}
+	unique := uniqueColumns(design)
+
+	entities := make([]DataEntity, 0, len(design.Tables))
+	index := make(map[string]int, len(design.Tables))
+	for _, t := range design.Tables {
+		entity := DataEntity{Name: t.Name, Description: t.Comment, Attributes: []EntityAttribute{}, Relations: []EntityRelation{}}
+		for _, c := range t.Columns {
+			var notes []string
+			if c.PrimaryKey {
+				notes = append(notes, "主键")
+			}
+			if target, ok := fkTargets[t.Name+"."+c.Name]; ok {
+				notes = append(notes, "外键，引用 "+target)
+			}
+			if c.Comment != "" && !(c.PrimaryKey && c.Comment == "主键") {
+				notes = append(notes, c.Comment)
+			}
+			entity.Attributes = append(entity.Attributes, EntityAttribute{
+				Name:        c.Name,
+				Type:        columnType(c),
+				Required:    !c.Nullable || c.PrimaryKey,
+				Description: strings.Join(notes, "；"),
+			})
+		}
+		index[t.Name] = len(entities)
+		entities = append(entities, entity)
+	}
+	for _, r := range design.Relations {
+		i, ok := index[r.ToTable]
+		if !ok {
+			continue
+		}
+		relationType := "one-to-many"
+		if unique[r.FromTable+"."+r.FromColumn] {
+			relationType = "one-to-one"
+		}
+		entities[i].Relations = append(entities[i].Relations, EntityRelation{TargetEntity: r.FromTable, RelationType: relationType, Description: r.FromColumn})
+	}
+	return entities
+}
+
+// MergeImportedEntities 把由现有数据库结构得到的实体合并到需求分析中：表名与实体名忽略大小写、分隔符和
+// 英文复数后相同即视为同一实体，改用表名，属性的类型、必填和关系以数据库为准，数据库没有注释时保留已有的描述；
+// 数据库中没有的实体和属性保留在后面，其中指向已改名实体的关系随之改名；返回合并结果和新增的实体名
+func MergeImportedEntities(base, imported []DataEntity) ([]DataEntity, []string) {
+	matched := make(map[int]bool)
+	renamed := make(map[string]string)
+	merged := make([]DataEntity, 0, len(base)+len(imported))
+	var added []string
+	for _, e := range imported {
+		i := -1
+		for j, old := range base {
+			if !matched[j] && sameEntityName(old.Name, e.Name) {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			merged = append(merged, e)
+			added = append(added, e.Name)
+			continue
+		}
+		matched[i] = true
+		old := base[i]
+		renamed[old.Name] = e.Name
+		if e.Description == "" {
+			e.Description = old.Description
+		}
+		for j, a := range e.Attributes {
+			for _, o := range old.Attributes {
+				if !sameEntityName(o.Name, a.Name) {
+					continue
+				}
+				switch {
+				case o.Description == "" || strings.Contains(a.Description, o.Description):
+				case a.Description == "":
+					e.Attributes[j].Description = o.Description
+				case !isPrimaryAttribute(o) && !isForeignAttribute(o):
+					// 已有描述中的主键、外键说明以数据库为准，其余说明附在后面
+					e.Attributes[j].Description = a.Description + "；" + o.Description
+				}
+				break
+			}
+		}
+		for _, o := range old.Attributes {
+			if !hasAttribute(e.Attributes, o.Name) {
+				e.Attributes = append(e.Attributes, o)
+			}
+		}
+		for _, o := range old.Relations {
+			if !hasRelationTo(e.Relations, o.TargetEntity) {
+				e.Relations = append(e.Relations, o)
+			}
+		}
+		merged = append(merged, e)
+	}
+	for i, e := range base {
+		if !matched[i] {
+			merged = append(merged, e)
+		}
+	}
+	for i := range merged {
+		for j, r := range merged[i].Relations {
+			if name, ok := renamed[r.TargetEntity]; ok {
+				merged[i].Relations[j].TargetEntity = name
+			}
+		}
+	}
+	return merged, added
+}
+
+func hasAttribute(attributes []EntityAttribute, name string) bool {
+	for _, a := range attributes {
+		if sameEntityName(a.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRelationTo(relations []EntityRelation, target string) bool {
+	for _, r := range relations {
+		if sameEntityName(r.TargetEntity, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameEntityName 实体名与表名是否指同一对象，如 User 与 users、OrderItem 与 order_items
+func sameEntityName(a, b string) bool {
+	for _, x := range singularForms(a) {
+		for _, y := range singularForms(b) {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// singularForms 去掉大小写和分隔符后，名称本身及其可能的英文单数形式
+func singularForms(name string) []string {
+	key := strings.ToLower(name)
+	for _, sep := range []string{"_", "-", " "} {
+		key = strings.ReplaceAll(key, sep, "")
+	}
+	forms := []string{key}
+	if strings.HasSuffix(key, "s") && len(key) > 1 {
+		forms = append(forms, strings.TrimSuffix(key, "s"))
+	}
+	if strings.HasSuffix(key, "es") && len(key) > 2 {
+		forms = append(forms, strings.TrimSuffix(key, "es"))
+	}
+	if strings.HasSuffix(key, "ies") && len(key) > 3 {
+		forms = append(forms, strings.TrimSuffix(key, "ies")+"y")
+	}
+	return forms
+}
@@ -0,0 +1,239 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleDatabaseDesign() *DatabaseDesign {
+	return &DatabaseDesign{
+		Tables: []TableDesign{
+			{Name: "sys_user", Comment: "用户", Columns: []ColumnDesign{
+				{Name: "id", Type: "bigint", PrimaryKey: true, AutoIncrement: true, Comment: "主键"},
+				{Name: "email", Type: "varchar", Length: 100, Nullable: false, Comment: "邮箱"},
+				{Name: "nickname", Type: "varchar", Length: 50, Nullable: true},
+			}},
+			{Name: "sys_profile", Columns: []ColumnDesign{
+				{Name: "id", Type: "bigint", PrimaryKey: true},
+				{Name: "user_id", Type: "bigint", Nullable: false},
+			}},
+			{Name: "orders", Columns: []ColumnDesign{
+				{Name: "id", Type: "bigint", PrimaryKey: true},
+				{Name: "user_id", Type: "bigint", Nullable: true},
+				{Name: "amount", Type: "decimal(10,2)", Nullable: false, Default: "0"},
+			}},
+		},
+		Indexes: []IndexDesign{
+			{Name: "uk_profile_user", Table: "sys_profile", Columns: []string{"user_id"}, Unique: true},
+			{Name: "idx_orders_user", Table: "orders", Columns: []string{"user_id"}},
+		},
+		Relations: []RelationDesign{
+			{Name: "fk_profile_user", FromTable: "sys_profile", FromColumn: "user_id", ToTable: "sys_user", ToColumn: "id", OnDelete: "CASCADE"},
+			{Name: "fk_orders_user", FromTable: "orders", FromColumn: "user_id", ToTable: "sys_user", ToColumn: "id", OnDelete: "SET NULL", OnUpdate: "CASCADE"},
+		},
+	}
+}
+
+func TestDatabaseDesignPUML(t *testing.T) {
+	source := DatabaseDesignPUML(sampleDatabaseDesign(), DataModelOptions{Title: "数据模型"})
+	assert.Equal(t, `@startuml
+title 数据模型
+hide circle
+skinparam linetype ortho
+
+entity orders {
+  * id : bigint <<PK>>
+  --
+  user_id : bigint <<FK>>
+  * amount : decimal(10,2)
+}
+
+package sys {
+  entity sys_user {
+    * id : bigint <<PK>>
+    --
+    * email : varchar(100)
+    nickname : varchar(50)
+  }
+
+  entity sys_profile {
+    * id : bigint <<PK>>
+    --
+    * user_id : bigint <<FK>>
+  }
+}
+
+sys_user ||--o| sys_profile : user_id
+sys_user |o--o{ orders : user_id
+@enduml
+`, source)
+}
+
+func TestDatabaseDesign_RoundTrip(t *testing.T) {
+	design := sampleDatabaseDesign()
+	parsed, err := ParseDatabaseDesign(DatabaseDesignPUML(design, DataModelOptions{}))
+	require.NoError(t, err)
+
+	// 图中不表示注释、默认值和级联规则，合并后与原设计一致
+	merged := MergeDatabaseDesign(design, parsed)
+	assert.Equal(t, design.Tables, merged.Tables)
+	assert.Equal(t, design.Indexes, merged.Indexes)
+	assert.ElementsMatch(t, design.Relations, merged.Relations)
+}
+
+func TestParseDatabaseDesign_Edited(t *testing.T) {
+	design, err := ParseDatabaseDesign(`@startuml
+entity users {
+  * id : bigint <<PK>>
+  name : varchar(50)
+}
+entity orders {
+  * id : bigint <<PK>>
+  * buyer : bigint <<FK>>
+}
+orders }o--|| users : 下单
+@enduml`)
+	require.NoError(t, err)
+
+	require.Len(t, design.Tables, 2)
+	assert.Equal(t, ColumnDesign{Name: "name", Type: "varchar", Length: 50, Nullable: true}, design.Tables[0].Columns[1])
+	assert.Equal(t, []RelationDesign{{Name: "下单", FromTable: "orders", FromColumn: "buyer", ToTable: "users", ToColumn: "id"}}, design.Relations)
+}
+
+func TestDataEntitiesPUML_RoundTrip(t *testing.T) {
+	entities := []DataEntity{
+		{Name: "用户", Description: "注册用户", Attributes: []EntityAttribute{
+			{Name: "用户ID", Type: "bigint", Description: "主键"},
+			{Name: "用户名", Type: "varchar(50)", Required: true, Description: "登录名"},
+		}, Relations: []EntityRelation{
+			{TargetEntity: "订单", RelationType: "one-to-many", Description: "下单"},
+		}},
+		{Name: "订单", Attributes: []EntityAttribute{
+			{Name: "订单ID", Type: "bigint", Description: "主键"},
+			{Name: "用户ID", Type: "bigint", Required: true, Description: "外键"},
+		}, Relations: []EntityRelation{
+			// 与用户的一对多是同一关系，不重复绘制
+			{TargetEntity: "用户", RelationType: "many-to-one"},
+			{TargetEntity: "商品", RelationType: "many_to_many", Description: "包含"},
+		}},
+		{Name: "商品"},
+	}
+
+	source := DataEntitiesPUML(entities, DataModelOptions{Style: DataModelClass})
+	assert.Equal(t, `@startuml
+
+class 用户 {
+  * 用户ID : bigint <<PK>>
+  --
+  * 用户名 : varchar(50)
+}
+
+class 订单 {
+  * 订单ID : bigint <<PK>>
+  --
+  * 用户ID : bigint <<FK>>
+}
+
+class 商品 {
+}
+
+用户 "1" -- "0..*" 订单 : 下单
+订单 "0..*" -- "0..*" 商品 : 包含
+@enduml
+`, source)
+
+	parsed, err := ParseDataEntities(source)
+	require.NoError(t, err)
+	merged := MergeDataEntities(entities, parsed)
+	require.Len(t, merged, 3)
+	assert.Equal(t, "注册用户", merged[0].Description)
+	assert.Equal(t, EntityAttribute{Name: "用户名", Type: "varchar(50)", Required: true, Description: "登录名"}, merged[0].Attributes[1])
+	assert.Equal(t, []EntityRelation{{TargetEntity: "订单", RelationType: "one-to-many", Description: "下单"}}, merged[0].Relations)
+	assert.Equal(t, []EntityRelation{{TargetEntity: "商品", RelationType: "many-to-many", Description: "包含"}}, merged[1].Relations)
+	assert.Equal(t, "外键", merged[1].Attributes[1].Description)
+}
+
+func TestDataModelDiagram(t *testing.T) {
+	analysis := &RequirementAnalysis{ProjectID: "p1", DataEntities: []DataEntity{{Name: "用户"}}}
+
+	diagram, err := DataModelDiagram(analysis, &DatabaseDesign{}, DataModelOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, PUMLTypeDataModel, diagram.Type)
+	assert.Equal(t, "数据模型图", diagram.Title)
+	assert.Contains(t, diagram.Content, "entity 用户 {")
+
+	diagram, err = DataModelDiagram(analysis, sampleDatabaseDesign(), DataModelOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, diagram.Content, "entity orders {")
+
+	_, err = DataModelDiagram(&RequirementAnalysis{}, nil, DataModelOptions{})
+	assert.ErrorIs(t, err, ErrNoDataModel)
+
+	_, err = ParseDataEntities("@startuml\nA -> B\n@enduml")
+	assert.Error(t, err)
+}
+
+func TestDatabaseDesignEntities(t *testing.T) {
+	entities := DatabaseDesignEntities(sampleDatabaseDesign())
+	require.Len(t, entities, 3)
+
+	user := entities[0]
+	assert.Equal(t, "sys_user", user.Name)
+	assert.Equal(t, "用户", user.Description)
+	assert.Equal(t, []EntityAttribute{
+		{Name: "id", Type: "bigint", Required: true, Description: "主键"},
+		{Name: "email", Type: "varchar(100)", Required: true, Description: "邮箱"},
+		{Name: "nickname", Type: "varchar(50)"},
+	}, user.Attributes[:3])
+	assert.Equal(t, []EntityRelation{
+		{TargetEntity: "sys_profile", RelationType: "one-to-one", Description: "user_id"},
+		{TargetEntity: "orders", RelationType: "one-to-many", Description: "user_id"},
+	}, user.Relations)
+
+	assert.Equal(t, EntityAttribute{Name: "user_id", Type: "bigint", Description: "外键，引用 sys_user.id"}, entities[2].Attributes[1])
+	assert.True(t, isForeignAttribute(entities[2].Attributes[1]))
+}
+
+func TestMergeImportedEntities(t *testing.T) {
+	base := []DataEntity{
+		{Name: "User", Description: "平台用户", Attributes: []EntityAttribute{
+			{Name: "id", Type: "int", Description: "主键"},
+			{Name: "email", Type: "string", Description: "登录邮箱"},
+			{Name: "avatar", Type: "string", Description: "头像地址"},
+		}},
+		{Name: "Coupon", Relations: []EntityRelation{{TargetEntity: "User", RelationType: "many-to-one"}}},
+	}
+	imported := []DataEntity{
+		{Name: "users", Attributes: []EntityAttribute{
+			{Name: "id", Type: "bigint", Required: true, Description: "主键"},
+			{Name: "email", Type: "varchar(100)", Required: true, Description: "邮箱"},
+		}, Relations: []EntityRelation{{TargetEntity: "order_items", RelationType: "one-to-many", Description: "user_id"}}},
+		{Name: "order_items"},
+	}
+
+	merged, added := MergeImportedEntities(base, imported)
+	assert.Equal(t, []string{"order_items"}, added)
+	require.Len(t, merged, 3)
+	assert.Equal(t, "users", merged[0].Name)
+	assert.Equal(t, "平台用户", merged[0].Description)
+	assert.Equal(t, []EntityAttribute{
+		{Name: "id", Type: "bigint", Required: true, Description: "主键"},
+		{Name: "email", Type: "varchar(100)", Required: true, Description: "邮箱；登录邮箱"},
+		{Name: "avatar", Type: "string", Description: "头像地址"},
+	}, merged[0].Attributes)
+	assert.Equal(t, "order_items", merged[1].Name)
+	// 需求分析中独有的实体保留，指向已改名实体的关系随之改名
+	assert.Equal(t, "Coupon", merged[2].Name)
+	assert.Equal(t, "users", merged[2].Relations[0].TargetEntity)
+}
+
+func TestSameEntityName(t *testing.T) {
+	assert.True(t, sameEntityName("User", "users"))
+	assert.True(t, sameEntityName("OrderItem", "order_items"))
+	assert.True(t, sameEntityName("Category", "categories"))
+	assert.True(t, sameEntityName("Address", "addresses"))
+	assert.True(t, sameEntityName("status", "statuses"))
+	assert.False(t, sameEntityName("User", "user_roles"))
+}
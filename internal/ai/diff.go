@@ -0,0 +1,308 @@
+package ai
+
+import "strings"
+
+// ListDiff 列表的新增与删除项
+type ListDiff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// Empty 是否无变化
+func (d ListDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// FieldChange 单个字段的变化
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// ProcessChange 同名业务流程的变化
+type ProcessChange struct {
+	Name    string        `json:"name"`
+	Changes []FieldChange `json:"changes,omitempty"` // 描述变化
+	Steps   ListDiff      `json:"steps"`
+	Actors  ListDiff      `json:"actors"`
+}
+
+// AttributeChange 同名实体属性的变化
+type AttributeChange struct {
+	Name    string        `json:"name"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// EntityChange 同名数据实体的变化
+type EntityChange struct {
+	Name              string            `json:"name"`
+	Changes           []FieldChange     `json:"changes,omitempty"` // 描述变化
+	AddedAttributes   []EntityAttribute `json:"added_attributes"`
+	RemovedAttributes []EntityAttribute `json:"removed_attributes"`
+	ChangedAttributes []AttributeChange `json:"changed_attributes"`
+	AddedRelations    []EntityRelation  `json:"added_relations"`
+	RemovedRelations  []EntityRelation  `json:"removed_relations"`
+}
+
+// ProcessDiff 业务流程差异
+type ProcessDiff struct {
+	Added    []string        `json:"added"`
+	Removed  []string        `json:"removed"`
+	Modified []ProcessChange `json:"modified"`
+}
+
+// EntityDiff 数据实体差异
+type EntityDiff struct {
+	Added    []string       `json:"added"`
+	Removed  []string       `json:"removed"`
+	Modified []EntityChange `json:"modified"`
+}
+
+// AnalysisDiff 两次需求分析之间的结构化差异
+type AnalysisDiff struct {
+	CoreFunctions     ListDiff    `json:"core_functions"`
+	Roles             ListDiff    `json:"roles"`
+	BusinessProcesses ProcessDiff `json:"business_processes"`
+	DataEntities      EntityDiff  `json:"data_entities"`
+	MissingInfo       ListDiff    `json:"missing_info"`
+	ScoreBefore       float64     `json:"score_before"`
+	ScoreAfter        float64     `json:"score_after"`
+	ScoreDelta        float64     `json:"score_delta"`
+	Changed           bool        `json:"changed"`
+}
+
+// DiffAnalysis 按名称比较两次需求分析（名称忽略大小写和多余空白）
+func DiffAnalysis(from, to *RequirementAnalysis) *AnalysisDiff {
+	if from == nil {
+		from = &RequirementAnalysis{}
+	}
+	if to == nil {
+		to = &RequirementAnalysis{}
+	}
+
+	diff := &AnalysisDiff{
+		CoreFunctions:     diffStrings(from.CoreFunctions, to.CoreFunctions),
+		Roles:             diffStrings(from.Roles, to.Roles),
+		BusinessProcesses: diffProcesses(from.BusinessProcesses, to.BusinessProcesses),
+		DataEntities:      diffEntities(from.DataEntities, to.DataEntities),
+		MissingInfo:       diffStrings(from.MissingInfo, to.MissingInfo),
+		ScoreBefore:       from.CompletionScore,
+		ScoreAfter:        to.CompletionScore,
+		ScoreDelta:        to.CompletionScore - from.CompletionScore,
+	}
+
+	diff.Changed = !diff.CoreFunctions.Empty() || !diff.Roles.Empty() || !diff.MissingInfo.Empty() ||
+		len(diff.BusinessProcesses.Added)+len(diff.BusinessProcesses.Removed)+len(diff.BusinessProcesses.Modified) > 0 ||
+		len(diff.DataEntities.Added)+len(diff.DataEntities.Removed)+len(diff.DataEntities.Modified) > 0 ||
+		diff.ScoreDelta != 0
+
+	return diff
+}
+
+// diffStrings 比较字符串列表
+func diffStrings(from, to []string) ListDiff {
+	diff := ListDiff{Added: []string{}, Removed: []string{}}
+
+	fromSet := make(map[string]bool, len(from))
+	for _, item := range from {
+		fromSet[normalizeName(item)] = true
+	}
+	toSet := make(map[string]bool, len(to))
+	for _, item := range to {
+		key := normalizeName(item)
+		if key == "" || toSet[key] {
+			continue
+		}
+		toSet[key] = true
+		if !fromSet[key] {
+			diff.Added = append(diff.Added, item)
+		}
+	}
+
+	seen := make(map[string]bool, len(from))
+	for _, item := range from {
+		key := normalizeName(item)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if !toSet[key] {
+			diff.Removed = append(diff.Removed, item)
+		}
+	}
+	return diff
+}
+
+// descriptionChange 描述不同时返回字段变化
+func descriptionChange(from, to string) []FieldChange {
+	if strings.TrimSpace(from) == strings.TrimSpace(to) {
+		return nil
+	}
+	return []FieldChange{{Field: "description", Old: from, New: to}}
+}
+
+// diffProcesses 比较业务流程
+func diffProcesses(from, to []BusinessProcess) ProcessDiff {
+	diff := ProcessDiff{Added: []string{}, Removed: []string{}, Modified: []ProcessChange{}}
+
+	fromIndex := make(map[string]BusinessProcess, len(from))
+	for _, process := range from {
+		fromIndex[normalizeName(process.Name)] = process
+	}
+	toIndex := make(map[string]bool, len(to))
+
+	for _, process := range to {
+		key := normalizeName(process.Name)
+		if key == "" || toIndex[key] {
+			continue
+		}
+		toIndex[key] = true
+
+		old, exists := fromIndex[key]
+		if !exists {
+			diff.Added = append(diff.Added, process.Name)
+			continue
+		}
+
+		change := ProcessChange{
+			Name:    process.Name,
+			Changes: descriptionChange(old.Description, process.Description),
+			Steps:   diffStrings(old.Steps, process.Steps),
+			Actors:  diffStrings(old.Actors, process.Actors),
+		}
+		if len(change.Changes) > 0 || !change.Steps.Empty() || !change.Actors.Empty() {
+			diff.Modified = append(diff.Modified, change)
+		}
+	}
+
+	for _, process := range from {
+		key := normalizeName(process.Name)
+		if key != "" && !toIndex[key] {
+			diff.Removed = append(diff.Removed, process.Name)
+			toIndex[key] = true
+		}
+	}
+	return diff
+}
+
+// diffEntities 比较数据实体，同名实体比较属性与关系
+func diffEntities(from, to []DataEntity) EntityDiff {
+	diff := EntityDiff{Added: []string{}, Removed: []string{}, Modified: []EntityChange{}}
+
+	fromIndex := make(map[string]DataEntity, len(from))
+	for _, entity := range from {
+		fromIndex[normalizeName(entity.Name)] = entity
+	}
+	toIndex := make(map[string]bool, len(to))
+
+	for _, entity := range to {
+		key := normalizeName(entity.Name)
+		if key == "" || toIndex[key] {
+			continue
+		}
+		toIndex[key] = true
+
+		old, exists := fromIndex[key]
+		if !exists {
+			diff.Added = append(diff.Added, entity.Name)
+			continue
+		}
+
+		if change, changed := diffEntity(old, entity); changed {
+			diff.Modified = append(diff.Modified, change)
+		}
+	}
+
+	for _, entity := range from {
+		key := normalizeName(entity.Name)
+		if key != "" && !toIndex[key] {
+			diff.Removed = append(diff.Removed, entity.Name)
+			toIndex[key] = true
+		}
+	}
+	return diff
+}
+
+// diffEntity 比较同名实体
+func diffEntity(from, to DataEntity) (EntityChange, bool) {
+	change := EntityChange{
+		Name:              to.Name,
+		Changes:           descriptionChange(from.Description, to.Description),
+		AddedAttributes:   []EntityAttribute{},
+		RemovedAttributes: []EntityAttribute{},
+		ChangedAttributes: []AttributeChange{},
+		AddedRelations:    []EntityRelation{},
+		RemovedRelations:  []EntityRelation{},
+	}
+
+	fromAttrs := make(map[string]EntityAttribute, len(from.Attributes))
+	for _, attr := range from.Attributes {
+		fromAttrs[normalizeName(attr.Name)] = attr
+	}
+	toAttrs := make(map[string]bool, len(to.Attributes))
+	for _, attr := range to.Attributes {
+		key := normalizeName(attr.Name)
+		if key == "" || toAttrs[key] {
+			continue
+		}
+		toAttrs[key] = true
+
+		old, exists := fromAttrs[key]
+		if !exists {
+			change.AddedAttributes = append(change.AddedAttributes, attr)
+			continue
+		}
+		if fields := diffAttribute(old, attr); len(fields) > 0 {
+			change.ChangedAttributes = append(change.ChangedAttributes, AttributeChange{Name: attr.Name, Changes: fields})
+		}
+	}
+	for _, attr := range from.Attributes {
+		key := normalizeName(attr.Name)
+		if key != "" && !toAttrs[key] {
+			change.RemovedAttributes = append(change.RemovedAttributes, attr)
+			toAttrs[key] = true
+		}
+	}
+
+	fromRels := make(map[string]bool, len(from.Relations))
+	for _, rel := range from.Relations {
+		fromRels[relationKey(rel)] = true
+	}
+	toRels := make(map[string]bool, len(to.Relations))
+	for _, rel := range to.Relations {
+		key := relationKey(rel)
+		if toRels[key] {
+			continue
+		}
+		toRels[key] = true
+		if !fromRels[key] {
+			change.AddedRelations = append(change.AddedRelations, rel)
+		}
+	}
+	for _, rel := range from.Relations {
+		key := relationKey(rel)
+		if !toRels[key] {
+			change.RemovedRelations = append(change.RemovedRelations, rel)
+			toRels[key] = true
+		}
+	}
+
+	changed := len(change.Changes) > 0 ||
+		len(change.AddedAttributes)+len(change.RemovedAttributes)+len(change.ChangedAttributes) > 0 ||
+		len(change.AddedRelations)+len(change.RemovedRelations) > 0
+	return change, changed
+}
+
+// diffAttribute 比较同名属性的类型、必填和描述
+func diffAttribute(from, to EntityAttribute) []FieldChange {
+	var changes []FieldChange
+	if !strings.EqualFold(strings.TrimSpace(from.Type), strings.TrimSpace(to.Type)) {
+		changes = append(changes, FieldChange{Field: "type", Old: from.Type, New: to.Type})
+	}
+	if from.Required != to.Required {
+		changes = append(changes, FieldChange{Field: "required", Old: from.Required, New: to.Required})
+	}
+	changes = append(changes, descriptionChange(from.Description, to.Description)...)
+	return changes
+}
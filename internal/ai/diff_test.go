@@ -0,0 +1,100 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffAnalysis(t *testing.T) {
+	from := &RequirementAnalysis{
+		CoreFunctions: []string{"图书借阅", "图书归还"},
+		Roles:         []string{"读者"},
+		BusinessProcesses: []BusinessProcess{
+			{Name: "借书流程", Steps: []string{"选书", "借阅"}},
+			{Name: "续借流程", Steps: []string{"续借"}},
+		},
+		DataEntities: []DataEntity{
+			{
+				Name: "Book",
+				Attributes: []EntityAttribute{
+					{Name: "title", Type: "string", Required: true},
+					{Name: "isbn", Type: "string"},
+					{Name: "price", Type: "float"},
+				},
+				Relations: []EntityRelation{{TargetEntity: "Author", RelationType: "many-to-one"}},
+			},
+			{Name: "Author"},
+		},
+		MissingInfo:     []string{"逾期规则"},
+		CompletionScore: 0.5,
+	}
+	to := &RequirementAnalysis{
+		CoreFunctions: []string{"图书借阅", "逾期提醒"},
+		Roles:         []string{"读者", "管理员"},
+		BusinessProcesses: []BusinessProcess{
+			{Name: "借书流程", Steps: []string{"借阅", "登记"}},
+			{Name: "逾期处理"},
+		},
+		DataEntities: []DataEntity{
+			{
+				Name: "book",
+				Attributes: []EntityAttribute{
+					{Name: "title", Type: "string", Required: true},
+					{Name: "isbn", Type: "varchar", Required: true},
+					{Name: "stock", Type: "int"},
+				},
+				Relations: []EntityRelation{{TargetEntity: "Author", RelationType: "many-to-many"}},
+			},
+			{Name: "Author"},
+			{Name: "Loan"},
+		},
+		CompletionScore: 0.75,
+	}
+
+	diff := DiffAnalysis(from, to)
+	assert.True(t, diff.Changed)
+
+	assert.Equal(t, []string{"逾期提醒"}, diff.CoreFunctions.Added)
+	assert.Equal(t, []string{"图书归还"}, diff.CoreFunctions.Removed)
+	assert.Equal(t, []string{"管理员"}, diff.Roles.Added)
+	assert.Empty(t, diff.Roles.Removed)
+	assert.Equal(t, []string{"逾期规则"}, diff.MissingInfo.Removed)
+
+	assert.Equal(t, []string{"逾期处理"}, diff.BusinessProcesses.Added)
+	assert.Equal(t, []string{"续借流程"}, diff.BusinessProcesses.Removed)
+	require.Len(t, diff.BusinessProcesses.Modified, 1)
+	assert.Equal(t, []string{"登记"}, diff.BusinessProcesses.Modified[0].Steps.Added)
+	assert.Equal(t, []string{"选书"}, diff.BusinessProcesses.Modified[0].Steps.Removed)
+
+	assert.Equal(t, []string{"Loan"}, diff.DataEntities.Added)
+	assert.Empty(t, diff.DataEntities.Removed)
+	require.Len(t, diff.DataEntities.Modified, 1)
+	book := diff.DataEntities.Modified[0]
+	assert.Equal(t, "book", book.Name)
+	require.Len(t, book.AddedAttributes, 1)
+	assert.Equal(t, "stock", book.AddedAttributes[0].Name)
+	require.Len(t, book.RemovedAttributes, 1)
+	assert.Equal(t, "price", book.RemovedAttributes[0].Name)
+	require.Len(t, book.ChangedAttributes, 1)
+	assert.Equal(t, "isbn", book.ChangedAttributes[0].Name)
+	assert.Equal(t, []FieldChange{
+		{Field: "type", Old: "string", New: "varchar"},
+		{Field: "required", Old: false, New: true},
+	}, book.ChangedAttributes[0].Changes)
+	assert.Len(t, book.AddedRelations, 1)
+	assert.Len(t, book.RemovedRelations, 1)
+
+	assert.InDelta(t, 0.25, diff.ScoreDelta, 1e-9)
+}
+
+func TestDiffAnalysisUnchanged(t *testing.T) {
+	analysis := &RequirementAnalysis{
+		CoreFunctions: []string{"登录"},
+		DataEntities:  []DataEntity{{Name: "User", Attributes: []EntityAttribute{{Name: "name", Type: "string"}}}},
+	}
+	diff := DiffAnalysis(analysis, analysis)
+	assert.False(t, diff.Changed)
+	assert.Empty(t, diff.DataEntities.Modified)
+}
@@ -0,0 +1,91 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type existingSchemaKey struct{}
+
+// maxSchemaPromptTables 注入提示语的表数上限，避免大型数据库挤占提示语长度
+const maxSchemaPromptTables = 80
+
+// WithExistingSchema 在上下文中附加项目现有数据库结构提示段，该上下文中的AI调用会将其置于提示语之前
+func WithExistingSchema(ctx context.Context, section string) context.Context {
+	return context.WithValue(ctx, existingSchemaKey{}, strings.TrimSpace(section))
+}
+
+// existingSchemaFromContext 从上下文读取现有数据库结构提示段
+func existingSchemaFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	section, _ := ctx.Value(existingSchemaKey{}).(string)
+	return section
+}
+
+// withExistingSchemaPrompt 将上下文中的现有数据库结构置于提示语之前
+func withExistingSchemaPrompt(ctx context.Context, prompt string) string {
+	section := existingSchemaFromContext(ctx)
+	if section == "" {
+		return prompt
+	}
+	return section + "\n\n" + prompt
+}
+
+// existingSchemaKeyParams 存在现有数据库结构时将其哈希追加到缓存键参数，重新导入后不再命中旧缓存
+func existingSchemaKeyParams(ctx context.Context, params ...string) []string {
+	if section := existingSchemaFromContext(ctx); section != "" {
+		params = append(params, "schema:"+hashString(section))
+	}
+	return params
+}
+
+// ExistingSchemaPromptSection 生成注入AI提示语的现有数据库结构段落，每张表一行，外键列注明引用的表；
+// 没有表时返回空字符串
+func ExistingSchemaPromptSection(design *DatabaseDesign) string {
+	if design == nil || len(design.Tables) == 0 {
+		return ""
+	}
+	references := make(map[string]string)
+	for _, r := range design.Relations {
+		target := r.ToTable
+		if r.ToColumn != "" {
+			target += "." + r.ToColumn
+		}
+		references[r.FromTable+"."+r.FromColumn] = target
+	}
+
+	var b strings.Builder
+	b.WriteString("项目现有数据库结构（新功能应复用已有的表和字段，新增的表和字段与其命名、类型风格保持一致，不要重复建表）：\n")
+	for i, t := range design.Tables {
+		if i == maxSchemaPromptTables {
+			b.WriteString(fmt.Sprintf("- 其余 %d 张表从略\n", len(design.Tables)-i))
+			break
+		}
+		b.WriteString("- " + t.Name)
+		if t.Comment != "" {
+			b.WriteString("（" + t.Comment + "）")
+		}
+		columns := make([]string, 0, len(t.Columns))
+		for _, c := range t.Columns {
+			column := c.Name + " " + columnType(c)
+			switch {
+			case c.PrimaryKey:
+				column += " 主键"
+			case !c.Nullable:
+				column += " 非空"
+			}
+			if target, ok := references[t.Name+"."+c.Name]; ok {
+				column += " 引用" + target
+			}
+			if c.Comment != "" && !(c.PrimaryKey && c.Comment == "主键") {
+				column += " " + c.Comment
+			}
+			columns = append(columns, column)
+		}
+		b.WriteString("：" + strings.Join(columns, "，") + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
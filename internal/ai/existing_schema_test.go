@@ -0,0 +1,26 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithExistingSchemaPrompt(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "prompt", withExistingSchemaPrompt(ctx, "prompt"))
+	assert.Equal(t, []string{"a"}, existingSchemaKeyParams(ctx, "a"))
+
+	ctx = WithExistingSchema(ctx, ExistingSchemaPromptSection(sampleDatabaseDesign()))
+	prompt := withGlossaryPrompt(WithGlossary(ctx, "项目术语表：\n- 订单"), withExistingSchemaPrompt(ctx, "prompt"))
+	assert.Equal(t, "项目术语表：\n- 订单\n\n"+
+		"项目现有数据库结构（新功能应复用已有的表和字段，新增的表和字段与其命名、类型风格保持一致，不要重复建表）：\n"+
+		"- sys_user（用户）：id bigint 主键，email varchar(100) 非空 邮箱，nickname varchar(50)\n"+
+		"- sys_profile：id bigint 主键，user_id bigint 非空 引用sys_user.id\n"+
+		"- orders：id bigint 主键，user_id bigint 引用sys_user.id，amount decimal(10,2) 非空\n"+
+		"\nprompt", prompt)
+
+	assert.Len(t, existingSchemaKeyParams(ctx, "a"), 2)
+	assert.Empty(t, ExistingSchemaPromptSection(&DatabaseDesign{}))
+}
@@ -11,6 +11,8 @@ import (
 	"strings"
 	"time"
 
+	"ai-dev-platform/internal/tracing"
+
 	"github.com/google/uuid"
 )
 
@@ -20,6 +22,7 @@ type GeminiClient struct {
 	baseURL    string
 	model      string
 	httpClient *http.Client
+	auditor    AuditRecorder
 }
 
 // GeminiConfig Gemini配置
@@ -56,8 +59,45 @@ func (c *GeminiClient) GetProvider() AIProvider {
 	return ProviderGemini
 }
 
+// SetAuditRecorder 设置审计记录器
+func (c *GeminiClient) SetAuditRecorder(recorder AuditRecorder) {
+	c.auditor = recorder
+}
+
+// GetModel 返回当前使用的模型
+func (c *GeminiClient) GetModel() string {
+	return c.model
+}
+
+// GetGenerationParams 返回生成参数
+func (c *GeminiClient) GetGenerationParams() GenerationParams {
+	return GenerationParams{MaxTokens: defaultMaxTokens, Temperature: defaultTemperature}
+}
+
+// BuildPrompt 构建指定操作的提示语
+func (c *GeminiClient) BuildPrompt(operation string, analysis *RequirementAnalysis, args ...string) string {
+	switch operation {
+	case "questions":
+		return c.buildQuestionsPrompt(analysis)
+	case "puml":
+		if len(args) > 0 {
+			return c.buildPUMLPrompt(analysis, PUMLType(args[0]))
+		}
+	case "document":
+		return c.buildDocumentPrompt(analysis)
+	case "stage_doc":
+		if len(args) > 0 {
+			return c.buildStageDocumentPrompt(analysis, args[0])
+		}
+	}
+	return ""
+}
+
 // AnalyzeRequirement 分析业务需求
-func (c *GeminiClient) AnalyzeRequirement(ctx context.Context, requirement string) (*RequirementAnalysis, error) {
+func (c *GeminiClient) AnalyzeRequirement(ctx context.Context, requirement string) (analysis *RequirementAnalysis, err error) {
+	ctx, record := startAudit(ctx, "analyze")
+	defer func() { finishAudit(ctx, c.auditor, record, analysis, err) }()
+
 	prompt := c.buildAnalysisPrompt(requirement)
 	
 	response, err := c.callGemini(ctx, prompt)
@@ -65,7 +105,7 @@ func (c *GeminiClient) AnalyzeRequirement(ctx context.Context, requirement strin
 		return nil, fmt.Errorf("调用Gemini API失败: %w", err)
 	}
 
-	analysis, err := c.parseAnalysisResponse(response.Content, requirement)
+	analysis, err = c.parseAnalysisResponse(response.Content, requirement)
 	if err != nil {
 		return nil, fmt.Errorf("解析需求分析结果失败: %w", err)
 	}
@@ -74,7 +114,10 @@ func (c *GeminiClient) AnalyzeRequirement(ctx context.Context, requirement strin
 }
 
 // GenerateQuestions 基于分析结果生成补充问题
-func (c *GeminiClient) GenerateQuestions(ctx context.Context, analysis *RequirementAnalysis) ([]Question, error) {
+func (c *GeminiClient) GenerateQuestions(ctx context.Context, analysis *RequirementAnalysis) (questions []Question, err error) {
+	ctx, record := startAudit(ctx, "questions")
+	defer func() { finishAudit(ctx, c.auditor, record, questions, err) }()
+
 	prompt := c.buildQuestionsPrompt(analysis)
 	
 	response, err := c.callGemini(ctx, prompt)
@@ -82,7 +125,7 @@ func (c *GeminiClient) GenerateQuestions(ctx context.Context, analysis *Requirem
 		return nil, fmt.Errorf("调用Gemini API失败: %w", err)
 	}
 
-	questions, err := c.parseQuestionsResponse(response.Content)
+	questions, err = c.parseQuestionsResponse(response.Content)
 	if err != nil {
 		return nil, fmt.Errorf("解析问题生成结果失败: %w", err)
 	}
@@ -91,15 +134,18 @@ func (c *GeminiClient) GenerateQuestions(ctx context.Context, analysis *Requirem
 }
 
 // GeneratePUML 生成PUML图表代码
-func (c *GeminiClient) GeneratePUML(ctx context.Context, analysis *RequirementAnalysis, diagramType PUMLType) (*PUMLDiagram, error) {
-	prompt := c.buildPUMLPrompt(analysis, diagramType)
+func (c *GeminiClient) GeneratePUML(ctx context.Context, analysis *RequirementAnalysis, diagramType PUMLType) (diagram *PUMLDiagram, err error) {
+	ctx, record := startAudit(ctx, "puml")
+	defer func() { finishAudit(ctx, c.auditor, record, diagram, err) }()
+
+	prompt := pumlPrompt(ctx, analysis, diagramType, c.buildPUMLPrompt)
 	
 	response, err := c.callGemini(ctx, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("调用Gemini API失败: %w", err)
 	}
 
-	diagram, err := c.parsePUMLResponse(response.Content, analysis.ProjectID, diagramType)
+	diagram, err = c.parsePUMLResponse(response.Content, analysis.ProjectID, diagramType)
 	if err != nil {
 		return nil, fmt.Errorf("解析PUML生成结果失败: %w", err)
 	}
@@ -108,7 +154,10 @@ func (c *GeminiClient) GeneratePUML(ctx context.Context, analysis *RequirementAn
 }
 
 // GenerateDocument 生成开发文档
-func (c *GeminiClient) GenerateDocument(ctx context.Context, analysis *RequirementAnalysis) (*DevelopmentDocument, error) {
+func (c *GeminiClient) GenerateDocument(ctx context.Context, analysis *RequirementAnalysis) (document *DevelopmentDocument, err error) {
+	ctx, record := startAudit(ctx, "document")
+	defer func() { finishAudit(ctx, c.auditor, record, document, err) }()
+
 	prompt := c.buildDocumentPrompt(analysis)
 	
 	response, err := c.callGemini(ctx, prompt)
@@ -116,7 +165,7 @@ func (c *GeminiClient) GenerateDocument(ctx context.Context, analysis *Requireme
 		return nil, fmt.Errorf("调用Gemini API失败: %w", err)
 	}
 
-	document, err := c.parseDocumentResponse(response.Content, analysis.ProjectID)
+	document, err = c.parseDocumentResponse(response.Content, analysis.ProjectID)
 	if err != nil {
 		return nil, fmt.Errorf("解析文档生成结果失败: %w", err)
 	}
@@ -125,7 +174,10 @@ func (c *GeminiClient) GenerateDocument(ctx context.Context, analysis *Requireme
 }
 
 // ProjectChat 项目上下文AI对话
-func (c *GeminiClient) ProjectChat(ctx context.Context, message, context string) (*ProjectChatResponse, error) {
+func (c *GeminiClient) ProjectChat(ctx context.Context, message, context string) (chatResponse *ProjectChatResponse, err error) {
+	ctx, record := startAudit(ctx, "chat")
+	defer func() { finishAudit(ctx, c.auditor, record, chatResponse, err) }()
+
 	prompt := c.buildProjectChatPrompt(message, context)
 	
 	response, err := c.callGemini(ctx, prompt)
@@ -133,7 +185,7 @@ func (c *GeminiClient) ProjectChat(ctx context.Context, message, context string)
 		return nil, fmt.Errorf("调用Gemini API失败: %w", err)
 	}
 
-	chatResponse, err := c.parseProjectChatResponse(response.Content)
+	chatResponse, err = c.parseProjectChatResponse(response.Content)
 	if err != nil {
 		return nil, fmt.Errorf("解析项目对话结果失败: %w", err)
 	}
@@ -142,7 +194,10 @@ func (c *GeminiClient) ProjectChat(ctx context.Context, message, context string)
 }
 
 // GenerateStageSpecificDocument 生成特定阶段的文档
-func (c *GeminiClient) GenerateStageSpecificDocument(ctx context.Context, analysis *RequirementAnalysis, documentType string) (*DevelopmentDocument, error) {
+func (c *GeminiClient) GenerateStageSpecificDocument(ctx context.Context, analysis *RequirementAnalysis, documentType string) (document *DevelopmentDocument, err error) {
+	ctx, record := startAudit(ctx, "stage_doc")
+	defer func() { finishAudit(ctx, c.auditor, record, document, err) }()
+
 	prompt := c.buildStageDocumentPrompt(analysis, documentType)
 	
 	response, err := c.callGemini(ctx, prompt)
@@ -150,7 +205,7 @@ func (c *GeminiClient) GenerateStageSpecificDocument(ctx context.Context, analys
 		return nil, fmt.Errorf("调用Gemini API失败: %w", err)
 	}
 
-	document, err := c.parseStageDocumentResponse(response.Content, analysis.ProjectID, documentType)
+	document, err = c.parseStageDocumentResponse(response.Content, analysis.ProjectID, documentType)
 	if err != nil {
 		return nil, fmt.Errorf("解析文档生成响应失败: %w", err)
 	}
@@ -163,8 +218,32 @@ func (c *GeminiClient) CallGemini(ctx context.Context, prompt string) (*AIRespon
 	return c.callGemini(ctx, prompt)
 }
 
-// callGemini 调用Gemini API
+// callGemini 调用Gemini API并记录审计信息
 func (c *GeminiClient) callGemini(ctx context.Context, prompt string) (*AIResponse, error) {
+	// 未经过具体操作的直接调用，单独提交一条审计记录
+	record := auditRecordFromContext(ctx)
+	standalone := record == nil
+	if standalone {
+		ctx, record = startAudit(ctx, "")
+	}
+
+	prompt = withGlossaryPrompt(ctx, withExistingSchemaPrompt(ctx, prompt))
+	spanCtx, span := tracing.Start(ctx, "ai.call")
+	started := time.Now()
+	response, err := c.sendGeminiRequest(spanCtx, prompt)
+	record.fillCall(ProviderGemini, c.model, prompt, response, err, time.Since(started))
+	observeAICall(record, err)
+	endAISpan(span, record, err)
+
+	if standalone {
+		finishAudit(ctx, c.auditor, record, nil, err)
+	}
+
+	return response, err
+}
+
+// sendGeminiRequest 发送Gemini API请求
+func (c *GeminiClient) sendGeminiRequest(ctx context.Context, prompt string) (*AIResponse, error) {
 	req := map[string]interface{}{
 		"contents": []map[string]interface{}{
 			{
@@ -176,8 +255,8 @@ func (c *GeminiClient) callGemini(ctx context.Context, prompt string) (*AIRespon
 			},
 		},
 		"generationConfig": map[string]interface{}{
-			"temperature":     0.3,
-			"maxOutputTokens": 2000,
+			"temperature":     defaultTemperature,
+			"maxOutputTokens": defaultMaxTokens,
 		},
 	}
 
@@ -0,0 +1,40 @@
+package ai
+
+import (
+	"context"
+	"strings"
+)
+
+type glossaryKey struct{}
+
+// WithGlossary 在上下文中附加项目术语表提示段，该上下文中的AI调用会将其置于提示语之前
+func WithGlossary(ctx context.Context, section string) context.Context {
+	return context.WithValue(ctx, glossaryKey{}, strings.TrimSpace(section))
+}
+
+// glossaryFromContext 从上下文读取术语表提示段
+func glossaryFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	section, _ := ctx.Value(glossaryKey{}).(string)
+	return section
+}
+
+// withGlossaryPrompt 将上下文中的术语表置于提示语之前
+func withGlossaryPrompt(ctx context.Context, prompt string) string {
+	section := glossaryFromContext(ctx)
+	if section == "" {
+		return prompt
+	}
+	return section + "\n\n" + prompt
+}
+
+// glossaryKeyParams 存在术语表时将其哈希追加到缓存键参数，使术语表变更后不再命中旧缓存
+// 未设置术语表时参数保持不变，已有缓存键不受影响
+func glossaryKeyParams(ctx context.Context, params ...string) []string {
+	if section := glossaryFromContext(ctx); section != "" {
+		params = append(params, "glossary:"+hashString(section))
+	}
+	return params
+}
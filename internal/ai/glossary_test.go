@@ -0,0 +1,21 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithGlossaryPrompt(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "prompt", withGlossaryPrompt(ctx, "prompt"))
+	assert.Equal(t, []string{"a"}, glossaryKeyParams(ctx, "a"))
+
+	ctx = WithGlossary(ctx, "  项目术语表：\n- 订单\n")
+	assert.Equal(t, "项目术语表：\n- 订单\n\nprompt", withGlossaryPrompt(ctx, "prompt"))
+
+	params := glossaryKeyParams(ctx, "a")
+	assert.Len(t, params, 2)
+	assert.NotEqual(t, params, glossaryKeyParams(WithGlossary(ctx, "项目术语表：\n- 用户"), "a"))
+}
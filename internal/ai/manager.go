@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"ai-dev-platform/internal/metrics"
 )
 
 // AIManager AI服务管理器
@@ -15,6 +17,9 @@ type AIManager struct {
 	defaultProvider AIProvider
 	cache       AICache
 	mutex       sync.RWMutex
+	statsOnce   sync.Once
+	stats       *cacheStatsRecorder
+	auditor     AuditRecorder
 }
 
 // AIManagerConfig AI管理器配置
@@ -25,6 +30,7 @@ type AIManagerConfig struct {
 	GeminiConfig    *GeminiConfig
 	EnableCache     bool
 	CacheTTL        time.Duration
+	AuditRecorder   AuditRecorder // 可选，AI调用审计记录器
 }
 
 // ClaudeConfig Claude配置（预留）
@@ -77,6 +83,7 @@ func (c *MemoryCache) Get(key string) (interface{}, bool) {
 	
 	if time.Now().After(item.expireAt) {
 		delete(c.data, key)
+		metrics.CacheEntries.Dec("ai")
 		return nil, false
 	}
 	
@@ -88,6 +95,9 @@ func (c *MemoryCache) Set(key string, value interface{}, ttl time.Duration) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	
+	if _, exists := c.data[key]; !exists {
+		metrics.CacheEntries.Inc("ai")
+	}
 	c.data[key] = &cacheItem{
 		value:    value,
 		expireAt: time.Now().Add(ttl),
@@ -99,7 +109,10 @@ func (c *MemoryCache) Delete(key string) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	
-	delete(c.data, key)
+	if _, exists := c.data[key]; exists {
+		delete(c.data, key)
+		metrics.CacheEntries.Dec("ai")
+	}
 }
 
 // Clear 清空缓存
@@ -107,6 +120,7 @@ func (c *MemoryCache) Clear() {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	
+	metrics.CacheEntries.Add(-float64(len(c.data)), "ai")
 	c.data = make(map[string]*cacheItem)
 }
 
@@ -121,6 +135,7 @@ func (c *MemoryCache) cleanup() {
 		for key, item := range c.data {
 			if now.After(item.expireAt) {
 				delete(c.data, key)
+				metrics.CacheEntries.Dec("ai")
 			}
 		}
 		c.mutex.Unlock()
@@ -132,6 +147,7 @@ func NewAIManager(config AIManagerConfig) (*AIManager, error) {
 	manager := &AIManager{
 		clients:         make(map[AIProvider]AIClient),
 		defaultProvider: config.DefaultProvider,
+		auditor:         config.AuditRecorder,
 	}
 	
 	// 初始化缓存
@@ -142,6 +158,7 @@ func NewAIManager(config AIManagerConfig) (*AIManager, error) {
 	// 初始化OpenAI客户端
 	if config.OpenAIConfig != nil {
 		openAIClient := NewOpenAIClient(*config.OpenAIConfig)
+		openAIClient.SetAuditRecorder(config.AuditRecorder)
 		manager.clients[ProviderOpenAI] = openAIClient
 	}
 	
@@ -155,6 +172,7 @@ func NewAIManager(config AIManagerConfig) (*AIManager, error) {
 	// 初始化Gemini客户端
 	if config.GeminiConfig != nil {
 		geminiClient := NewGeminiClient(*config.GeminiConfig)
+		geminiClient.SetAuditRecorder(config.AuditRecorder)
 		manager.clients[ProviderGemini] = geminiClient
 	}
 	
@@ -196,12 +214,10 @@ func (m *AIManager) AnalyzeRequirement(ctx context.Context, requirement string,
 	}
 	
 	// 检查缓存
-	if m.cache != nil {
-		cacheKey := m.generateCacheKey("analyze", targetProvider, requirement)
-		if cached, exists := m.cache.Get(cacheKey); exists {
-			if analysis, ok := cached.(*RequirementAnalysis); ok {
-				return analysis, nil
-			}
+	cacheKey := m.generateCacheKey("analyze", targetProvider, glossaryKeyParams(ctx, existingSchemaKeyParams(ctx, requirement)...)...)
+	if cached, exists := m.cacheGet("analyze", cacheKey); exists {
+		if analysis, ok := cached.(*RequirementAnalysis); ok {
+			return analysis, nil
 		}
 	}
 	
@@ -218,10 +234,7 @@ func (m *AIManager) AnalyzeRequirement(ctx context.Context, requirement string,
 	}
 	
 	// 缓存结果
-	if m.cache != nil {
-		cacheKey := m.generateCacheKey("analyze", targetProvider, requirement)
-		m.cache.Set(cacheKey, analysis, 30*time.Minute)
-	}
+	m.cacheSet(cacheKey, analysis.ProjectID, analysis, 30*time.Minute)
 	
 	return analysis, nil
 }
@@ -235,12 +248,10 @@ func (m *AIManager) GenerateQuestions(ctx context.Context, analysis *Requirement
 	}
 	
 	// 检查缓存
-	if m.cache != nil {
-		cacheKey := m.generateCacheKey("questions", targetProvider, analysis.ID)
-		if cached, exists := m.cache.Get(cacheKey); exists {
-			if questions, ok := cached.([]Question); ok {
-				return questions, nil
-			}
+	cacheKey := m.contentCacheKey(ctx, "questions", targetProvider, analysis)
+	if cached, exists := m.cacheGet("questions", cacheKey); exists {
+		if questions, ok := cached.([]Question); ok {
+			return questions, nil
 		}
 	}
 	
@@ -257,10 +268,7 @@ func (m *AIManager) GenerateQuestions(ctx context.Context, analysis *Requirement
 	}
 	
 	// 缓存结果
-	if m.cache != nil {
-		cacheKey := m.generateCacheKey("questions", targetProvider, analysis.ID)
-		m.cache.Set(cacheKey, questions, 15*time.Minute)
-	}
+	m.cacheSet(cacheKey, analysis.ProjectID, questions, 15*time.Minute)
 	
 	return questions, nil
 }
@@ -274,12 +282,10 @@ func (m *AIManager) GeneratePUML(ctx context.Context, analysis *RequirementAnaly
 	}
 	
 	// 检查缓存
-	if m.cache != nil {
-		cacheKey := m.generateCacheKey("puml", targetProvider, analysis.ID, string(diagramType))
-		if cached, exists := m.cache.Get(cacheKey); exists {
-			if diagram, ok := cached.(*PUMLDiagram); ok {
-				return diagram, nil
-			}
+	cacheKey := m.contentCacheKey(ctx, "puml", targetProvider, analysis, string(diagramType))
+	if cached, exists := m.cacheGet("puml", cacheKey); exists {
+		if diagram, ok := cached.(*PUMLDiagram); ok {
+			return diagram, nil
 		}
 	}
 	
@@ -296,10 +302,7 @@ func (m *AIManager) GeneratePUML(ctx context.Context, analysis *RequirementAnaly
 	}
 	
 	// 缓存结果
-	if m.cache != nil {
-		cacheKey := m.generateCacheKey("puml", targetProvider, analysis.ID, string(diagramType))
-		m.cache.Set(cacheKey, diagram, 60*time.Minute)
-	}
+	m.cacheSet(cacheKey, analysis.ProjectID, diagram, 60*time.Minute)
 	
 	return diagram, nil
 }
@@ -313,12 +316,10 @@ func (m *AIManager) GenerateDocument(ctx context.Context, analysis *RequirementA
 	}
 	
 	// 检查缓存
-	if m.cache != nil {
-		cacheKey := m.generateCacheKey("document", targetProvider, analysis.ID)
-		if cached, exists := m.cache.Get(cacheKey); exists {
-			if document, ok := cached.(*DevelopmentDocument); ok {
-				return document, nil
-			}
+	cacheKey := m.contentCacheKey(ctx, "document", targetProvider, analysis)
+	if cached, exists := m.cacheGet("document", cacheKey); exists {
+		if document, ok := cached.(*DevelopmentDocument); ok {
+			return document, nil
 		}
 	}
 	
@@ -335,14 +336,16 @@ func (m *AIManager) GenerateDocument(ctx context.Context, analysis *RequirementA
 	}
 	
 	// 缓存结果
-	if m.cache != nil {
-		cacheKey := m.generateCacheKey("document", targetProvider, analysis.ID)
-		m.cache.Set(cacheKey, document, 60*time.Minute)
-	}
+	m.cacheSet(cacheKey, analysis.ProjectID, document, 60*time.Minute)
 	
 	return document, nil
 }
 
+// GetAuditRecorder 获取AI调用审计记录器
+func (m *AIManager) GetAuditRecorder() AuditRecorder {
+	return m.auditor
+}
+
 // ListProviders 列出所有可用的AI提供商
 func (m *AIManager) ListProviders() []AIProvider {
 	m.mutex.RLock()
@@ -378,7 +381,77 @@ func (m *AIManager) SetDefaultProvider(provider AIProvider) error {
 func (m *AIManager) ClearCache() {
 	if m.cache != nil {
 		m.cache.Clear()
+		m.recorder().reset()
+	}
+}
+
+// InvalidateProjectCache 使指定项目的缓存失效，返回删除的缓存条目数
+func (m *AIManager) InvalidateProjectCache(projectID string) int {
+	if m.cache == nil || projectID == "" {
+		return 0
+	}
+	
+	keys := m.recorder().takeProjectKeys(projectID)
+	for _, key := range keys {
+		m.cache.Delete(key)
 	}
+	
+	return len(keys)
+}
+
+// recorder 获取缓存统计记录器（延迟初始化）
+func (m *AIManager) recorder() *cacheStatsRecorder {
+	m.statsOnce.Do(func() {
+		if m.stats == nil {
+			m.stats = newCacheStatsRecorder()
+		}
+	})
+	return m.stats
+}
+
+// cacheGet 读取缓存并记录命中情况
+func (m *AIManager) cacheGet(operation, key string) (interface{}, bool) {
+	if m.cache == nil {
+		return nil, false
+	}
+	
+	value, exists := m.cache.Get(key)
+	m.recorder().record(operation, exists)
+	if !exists {
+		m.recorder().forget(key)
+	}
+	metrics.RecordCacheLookup("ai", exists)
+	return value, exists
+}
+
+// cacheSet 写入缓存并登记所属项目，便于按项目失效
+func (m *AIManager) cacheSet(key, projectID string, value interface{}, ttl time.Duration) {
+	if m.cache == nil {
+		return
+	}
+	
+	m.cache.Set(key, value, ttl)
+	m.recorder().track(projectID, key, ttl)
+}
+
+// contentCacheKey 基于分析内容、模型、生成参数和提示语哈希生成缓存键
+// 需求内容被修改后即使分析ID不变也会得到新的缓存键
+func (m *AIManager) contentCacheKey(ctx context.Context, operation string, provider AIProvider, analysis *RequirementAnalysis, args ...string) string {
+	params := []string{AnalysisContentHash(analysis)}
+	
+	if client, err := m.GetClient(provider); err == nil {
+		if source, ok := client.(CacheKeySource); ok {
+			generationParams, _ := json.Marshal(source.GetGenerationParams())
+			params = append(params,
+				source.GetModel(),
+				string(generationParams),
+				hashString(source.BuildPrompt(operation, analysis, args...)),
+			)
+		}
+	}
+	
+	params = append(params, args...)
+	return m.generateCacheKey(operation, provider, glossaryKeyParams(ctx, existingSchemaKeyParams(ctx, diagramSyntaxKeyParams(ctx, params...)...)...)...)
 }
 
 // generateCacheKey 生成缓存键
@@ -410,11 +483,14 @@ func (m *AIManager) GetCacheStats() map[string]interface{} {
 		}
 	}
 	
+	operations := m.recorder().snapshot()
+	
 	memCache, ok := m.cache.(*MemoryCache)
 	if !ok {
 		return map[string]interface{}{
-			"enabled": true,
-			"type":    "unknown",
+			"enabled":    true,
+			"type":       "unknown",
+			"operations": operations,
 		}
 	}
 	
@@ -422,9 +498,10 @@ func (m *AIManager) GetCacheStats() map[string]interface{} {
 	defer memCache.mutex.RUnlock()
 	
 	return map[string]interface{}{
-		"enabled": true,
-		"type":    "memory",
-		"size":    len(memCache.data),
+		"enabled":    true,
+		"type":       "memory",
+		"size":       len(memCache.data),
+		"operations": operations,
 	}
 }
 
@@ -450,7 +527,7 @@ func (m *AIManager) ProjectChat(ctx context.Context, message, context string, pr
 	
 	// 缓存结果
 	if m.cache != nil && err == nil {
-		cacheKey := m.generateCacheKey("chat", targetProvider, message, context)
+		cacheKey := m.generateCacheKey("chat", targetProvider, glossaryKeyParams(ctx, existingSchemaKeyParams(ctx, message, context)...)...)
 		m.cache.Set(cacheKey, response, time.Hour)
 	}
 	
@@ -465,16 +542,6 @@ func (m *AIManager) GenerateStageDocument(ctx context.Context, analysis *Require
 		targetProvider = provider[0]
 	}
 	
-	// 检查缓存
-	cacheKey := m.generateCacheKey("stage_doc", targetProvider, analysis.ID, documentType)
-	if m.cache != nil {
-		if cached, found := m.cache.Get(cacheKey); found {
-			if doc, ok := cached.(*DevelopmentDocument); ok {
-				return doc, nil
-			}
-		}
-	}
-	
 	// 获取客户端
 	client, err := m.GetClient(targetProvider)
 	if err != nil {
@@ -483,11 +550,19 @@ func (m *AIManager) GenerateStageDocument(ctx context.Context, analysis *Require
 	
 	// 检查客户端是否支持分阶段文档生成
 	if geminiClient, ok := client.(*GeminiClient); ok {
+		// 缓存键基于包含文档类型的分阶段提示语
+		cacheKey := m.contentCacheKey(ctx, "stage_doc", targetProvider, analysis, documentType)
+		if cached, found := m.cacheGet("stage_doc", cacheKey); found {
+			if doc, ok := cached.(*DevelopmentDocument); ok {
+				return doc, nil
+			}
+		}
+		
 		document, err := geminiClient.GenerateStageSpecificDocument(ctx, analysis, documentType)
 		
 		// 缓存结果
-		if m.cache != nil && err == nil {
-			m.cache.Set(cacheKey, document, time.Hour)
+		if err == nil {
+			m.cacheSet(cacheKey, analysis.ProjectID, document, time.Hour)
 		}
 		
 		return document, err
@@ -2,6 +2,7 @@ package ai
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -497,6 +498,101 @@ func (suite *AIManagerTestSuite) TestGetCacheStats() {
 	assert.True(suite.T(), stats["enabled"].(bool))
 }
 
+func (suite *AIManagerTestSuite) TestGeneratePUML_CacheInvalidatedOnContentChange() {
+	// Arrange
+	ctx := context.Background()
+	analysis := &RequirementAnalysis{
+		ID:            "test-id",
+		ProjectID:     "project-1",
+		CoreFunctions: []string{"用户注册"},
+	}
+	edited := &RequirementAnalysis{
+		ID:            "test-id",
+		ProjectID:     "project-1",
+		CoreFunctions: []string{"用户注册", "用户登录"},
+	}
+
+	diagram := &PUMLDiagram{ID: "diagram-1", Content: "@startuml\n@enduml"}
+	editedDiagram := &PUMLDiagram{ID: "diagram-2", Content: "@startuml\nA -> B\n@enduml"}
+	suite.mockOpenAI.On("GeneratePUML", ctx, analysis, PUMLTypeBusinessFlow).Return(diagram, nil).Once()
+	suite.mockOpenAI.On("GeneratePUML", ctx, edited, PUMLTypeBusinessFlow).Return(editedDiagram, nil).Once()
+
+	// Act
+	result1, err1 := suite.manager.GeneratePUML(ctx, analysis, PUMLTypeBusinessFlow)
+	result2, err2 := suite.manager.GeneratePUML(ctx, analysis, PUMLTypeBusinessFlow)
+	result3, err3 := suite.manager.GeneratePUML(ctx, edited, PUMLTypeBusinessFlow)
+
+	// Assert
+	assert.NoError(suite.T(), err1)
+	assert.NoError(suite.T(), err2)
+	assert.NoError(suite.T(), err3)
+	assert.Equal(suite.T(), diagram, result1)
+	assert.Equal(suite.T(), diagram, result2)      // 内容未变，命中缓存
+	assert.Equal(suite.T(), editedDiagram, result3) // 内容变化，ID相同也重新生成
+
+	stats := suite.manager.GetCacheStats()
+	operations := stats["operations"].(map[string]CacheOperationStats)
+	assert.Equal(suite.T(), int64(1), operations["puml"].Hits)
+	assert.Equal(suite.T(), int64(2), operations["puml"].Misses)
+}
+
+func (suite *AIManagerTestSuite) TestInvalidateProjectCache() {
+	// Arrange
+	ctx := context.Background()
+	analysis := &RequirementAnalysis{ID: "test-id", ProjectID: "project-1"}
+	other := &RequirementAnalysis{ID: "other-id", ProjectID: "project-2"}
+
+	document := &DevelopmentDocument{ID: "doc-1"}
+	otherDocument := &DevelopmentDocument{ID: "doc-2"}
+	suite.mockOpenAI.On("GenerateDocument", ctx, analysis).Return(document, nil).Twice()
+	suite.mockOpenAI.On("GenerateDocument", ctx, other).Return(otherDocument, nil).Once()
+
+	_, err := suite.manager.GenerateDocument(ctx, analysis)
+	assert.NoError(suite.T(), err)
+	_, err = suite.manager.GenerateDocument(ctx, other)
+	assert.NoError(suite.T(), err)
+
+	// Act
+	removed := suite.manager.InvalidateProjectCache("project-1")
+
+	// Assert
+	assert.Equal(suite.T(), 1, removed)
+	_, err = suite.manager.GenerateDocument(ctx, analysis) // 重新请求AI服务
+	assert.NoError(suite.T(), err)
+	_, err = suite.manager.GenerateDocument(ctx, other) // 其他项目仍命中缓存
+	assert.NoError(suite.T(), err)
+}
+
+func TestCacheStatsRecorder_ProjectKeys(t *testing.T) {
+	r := newCacheStatsRecorder()
+	r.track("project-1", "a", time.Hour)
+	r.track("project-1", "b", -time.Second)
+	r.track("project-2", "c", time.Hour)
+
+	// 缓存未命中的键从索引中移除
+	r.forget("a")
+	assert.NotContains(t, r.projectKeys["project-1"], "a")
+	assert.Len(t, r.keys, 2)
+
+	// 索引达到阈值时清理已过期的键
+	for i := 0; i < minPruneTrackedKeys; i++ {
+		r.track("project-3", fmt.Sprintf("k%d", i), time.Hour)
+	}
+	assert.NotContains(t, r.projectKeys, "project-1")
+	assert.Equal(t, []string{"c"}, r.takeProjectKeys("project-2"))
+	assert.Len(t, r.keys, minPruneTrackedKeys)
+}
+
+func TestAnalysisContentHash(t *testing.T) {
+	a := &RequirementAnalysis{ID: "a", OriginalText: "需求", CreatedAt: time.Now()}
+	b := &RequirementAnalysis{ID: "b", OriginalText: "需求 "}
+	c := &RequirementAnalysis{ID: "a", OriginalText: "需求", Roles: []string{"管理员"}}
+
+	assert.Equal(t, AnalysisContentHash(a), AnalysisContentHash(b))
+	assert.NotEqual(t, AnalysisContentHash(a), AnalysisContentHash(c))
+	assert.Empty(t, AnalysisContentHash(nil))
+}
+
 func TestMemoryCacheTestSuite(t *testing.T) {
 	suite.Run(t, new(MemoryCacheTestSuite))
 }
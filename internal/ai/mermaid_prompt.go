@@ -0,0 +1,142 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ai-dev-platform/internal/plantuml"
+)
+
+type diagramSyntaxKey struct{}
+
+// WithDiagramSyntax 在上下文中指定生成图表的语法，该上下文中的图表生成按此语法构建提示语；缺省为 PlantUML
+func WithDiagramSyntax(ctx context.Context, syntax plantuml.Syntax) context.Context {
+	return context.WithValue(ctx, diagramSyntaxKey{}, syntax)
+}
+
+// diagramSyntaxFromContext 从上下文读取生成图表的语法
+func diagramSyntaxFromContext(ctx context.Context) plantuml.Syntax {
+	if ctx == nil {
+		return plantuml.SyntaxPlantUML
+	}
+	if syntax, ok := ctx.Value(diagramSyntaxKey{}).(plantuml.Syntax); ok && syntax != "" {
+		return syntax
+	}
+	return plantuml.SyntaxPlantUML
+}
+
+// diagramSyntaxKeyParams 生成 Mermaid 时追加到缓存键参数，与同一需求的 PlantUML 结果区分
+// PlantUML 时参数保持不变，已有缓存键不受影响
+func diagramSyntaxKeyParams(ctx context.Context, params ...string) []string {
+	if syntax := diagramSyntaxFromContext(ctx); syntax != plantuml.SyntaxPlantUML {
+		params = append(params, "syntax:"+string(syntax))
+	}
+	return params
+}
+
+// pumlPrompt 按上下文中的语法选择图表生成提示语，PlantUML 使用各客户端自己的提示语
+func pumlPrompt(ctx context.Context, analysis *RequirementAnalysis, diagramType PUMLType, plantUMLPrompt func(*RequirementAnalysis, PUMLType) string) string {
+	if diagramSyntaxFromContext(ctx) == plantuml.SyntaxMermaid {
+		return buildMermaidPrompt(analysis, diagramType)
+	}
+	return plantUMLPrompt(analysis, diagramType)
+}
+
+// buildMermaidPrompt 构建 Mermaid 图表生成的提示语，各类图表使用 Mermaid 对应的图表类型
+func buildMermaidPrompt(analysis *RequirementAnalysis, diagramType PUMLType) string {
+	var diagramDescription string
+	var example string
+
+	switch diagramType {
+	case PUMLTypeArchitecture:
+		diagramDescription = "系统架构图（flowchart，用 subgraph 表示分层）"
+		example = `flowchart LR
+    subgraph 前端
+        UI[用户界面]
+    end
+    subgraph 后端
+        API[API服务]
+        BIZ[业务逻辑]
+    end
+    DB[(用户数据)]
+    UI --> API
+    API --> BIZ
+    BIZ --> DB`
+	case PUMLTypeSequence:
+		diagramDescription = "序列图（sequenceDiagram）"
+		example = `sequenceDiagram
+    actor U as 用户
+    participant FE as 前端
+    participant BE as 后端
+    participant DB as 数据库
+    U->>FE: 发起请求
+    FE->>BE: API调用
+    BE->>DB: 查询数据
+    DB-->>BE: 返回数据
+    BE-->>FE: 返回结果
+    FE-->>U: 显示结果`
+	case PUMLTypeClass:
+		diagramDescription = "类图（classDiagram）"
+		example = `classDiagram
+    class UserService["用户服务"] {
+        +login(email, password) User
+        +register(info) User
+    }
+    class ProjectService["项目服务"] {
+        +create(info) Project
+        +list(userId) List~Project~
+    }
+    UserService --> ProjectService : 使用`
+	case PUMLTypeDataModel:
+		diagramDescription = "数据模型图（erDiagram）"
+		example = `erDiagram
+    USER ||--o{ PROJECT : 拥有
+    USER {
+        bigint user_id PK "用户ID"
+        varchar username "用户名"
+        varchar email "邮箱"
+        datetime created_at "创建时间"
+    }
+    PROJECT {
+        bigint project_id PK "项目ID"
+        bigint user_id FK "用户ID"
+        varchar name "项目名称"
+        varchar status "状态"
+    }`
+	default:
+		diagramDescription = "业务流程图（flowchart）"
+		example = `flowchart TD
+    start([开始]) --> login[用户登录]
+    login --> check{验证成功?}
+    check -->|是| enter[进入系统]
+    enter --> operate[执行操作]
+    check -->|否| fail[显示错误信息]
+    operate --> stop([结束])
+    fail --> stop`
+	}
+
+	coreFunc := strings.Join(analysis.CoreFunctions, "\n- ")
+
+	return fmt.Sprintf(`基于以下需求分析结果，生成%s的Mermaid代码。
+
+核心功能：
+- %s
+
+请按照以下JSON格式返回：
+{
+  "title": "图表标题",
+  "content": "完整的Mermaid代码，不要包含 %s 代码块标记",
+  "description": "图表说明"
+}
+
+示例格式：
+%s
+
+要求：
+1. 代码要能被 Mermaid 直接渲染，第一行为图表类型声明
+2. 包含所有主要功能模块
+3. 体现业务流程逻辑关系
+4. 节点ID使用英文字母和数字，显示文字使用中文标注
+5. 文字中不要使用分号和双引号`, diagramDescription, coreFunc, "```", example)
+}
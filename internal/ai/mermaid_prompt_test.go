@@ -0,0 +1,39 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ai-dev-platform/internal/plantuml"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPUMLPrompt_Syntax(t *testing.T) {
+	analysis := &RequirementAnalysis{CoreFunctions: []string{"下单", "支付"}}
+	plantUMLPrompt := func(*RequirementAnalysis, PUMLType) string { return "plantuml" }
+
+	ctx := context.Background()
+	assert.Equal(t, "plantuml", pumlPrompt(ctx, analysis, PUMLTypeSequence, plantUMLPrompt))
+	assert.Equal(t, []string{"a"}, diagramSyntaxKeyParams(ctx, "a"))
+
+	ctx = WithDiagramSyntax(ctx, plantuml.SyntaxMermaid)
+	prompt := pumlPrompt(ctx, analysis, PUMLTypeSequence, plantUMLPrompt)
+	assert.Contains(t, prompt, "序列图（sequenceDiagram）的Mermaid代码")
+	assert.Contains(t, prompt, "- 下单\n- 支付")
+	assert.Equal(t, []string{"a", "syntax:mermaid"}, diagramSyntaxKeyParams(ctx, "a"))
+
+	assert.Contains(t, buildMermaidPrompt(analysis, PUMLTypeDataModel), "erDiagram")
+	assert.Contains(t, buildMermaidPrompt(analysis, PUMLTypeBusinessFlow), "flowchart TD")
+}
+
+// 提示语中的示例本身必须是可以解析的 Mermaid
+func TestBuildMermaidPrompt_ExamplesParse(t *testing.T) {
+	for _, diagramType := range []PUMLType{PUMLTypeBusinessFlow, PUMLTypeArchitecture, PUMLTypeSequence, PUMLTypeClass, PUMLTypeDataModel} {
+		_, example, _ := strings.Cut(buildMermaidPrompt(&RequirementAnalysis{}, diagramType), "示例格式：\n")
+		example, _, _ = strings.Cut(example, "\n\n要求：")
+		doc := plantuml.ParseMermaid(example)
+		assert.Empty(t, doc.Diagnostics, diagramType)
+	}
+}
@@ -0,0 +1,19 @@
+package ai
+
+import (
+	"ai-dev-platform/internal/metrics"
+)
+
+// observeAICall 记录AI接口调用指标
+func observeAICall(record *AuditRecord, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+
+	provider := string(record.Provider)
+	metrics.AIRequestsTotal.Inc(provider, record.Model, record.Operation, status)
+	metrics.AIRequestDuration.Observe(record.Duration.Seconds(), provider, record.Model, record.Operation)
+	metrics.AITokensTotal.Add(float64(record.PromptTokens), provider, record.Model, record.Operation, "prompt")
+	metrics.AITokensTotal.Add(float64(record.CompletionTokens), provider, record.Model, record.Operation, "completion")
+}
@@ -12,6 +12,8 @@ import (
 	"strings"
 	"time"
 
+	"ai-dev-platform/internal/tracing"
+
 	"github.com/google/uuid"
 )
 
@@ -21,6 +23,7 @@ type OpenAIClient struct {
 	baseURL    string
 	model      string
 	httpClient *http.Client
+	auditor    AuditRecorder
 }
 
 // OpenAIConfig OpenAI配置
@@ -57,8 +60,41 @@ func (c *OpenAIClient) GetProvider() AIProvider {
 	return ProviderOpenAI
 }
 
+// SetAuditRecorder 设置审计记录器
+func (c *OpenAIClient) SetAuditRecorder(recorder AuditRecorder) {
+	c.auditor = recorder
+}
+
+// GetModel 返回当前使用的模型
+func (c *OpenAIClient) GetModel() string {
+	return c.model
+}
+
+// GetGenerationParams 返回生成参数
+func (c *OpenAIClient) GetGenerationParams() GenerationParams {
+	return GenerationParams{MaxTokens: defaultMaxTokens, Temperature: defaultTemperature}
+}
+
+// BuildPrompt 构建指定操作的提示语
+func (c *OpenAIClient) BuildPrompt(operation string, analysis *RequirementAnalysis, args ...string) string {
+	switch operation {
+	case "questions":
+		return c.buildQuestionsPrompt(analysis)
+	case "puml":
+		if len(args) > 0 {
+			return c.buildPUMLPrompt(analysis, PUMLType(args[0]))
+		}
+	case "document":
+		return c.buildDocumentPrompt(analysis)
+	}
+	return ""
+}
+
 // AnalyzeRequirement 分析业务需求
-func (c *OpenAIClient) AnalyzeRequirement(ctx context.Context, requirement string) (*RequirementAnalysis, error) {
+func (c *OpenAIClient) AnalyzeRequirement(ctx context.Context, requirement string) (analysis *RequirementAnalysis, err error) {
+	ctx, record := startAudit(ctx, "analyze")
+	defer func() { finishAudit(ctx, c.auditor, record, analysis, err) }()
+
 	prompt := c.buildAnalysisPrompt(requirement)
 	
 	response, err := c.callOpenAI(ctx, prompt)
@@ -66,7 +102,7 @@ func (c *OpenAIClient) AnalyzeRequirement(ctx context.Context, requirement strin
 		return nil, fmt.Errorf("调用OpenAI API失败: %w", err)
 	}
 
-	analysis, err := c.parseAnalysisResponse(response.Content, requirement)
+	analysis, err = c.parseAnalysisResponse(response.Content, requirement)
 	if err != nil {
 		return nil, fmt.Errorf("解析需求分析结果失败: %w", err)
 	}
@@ -75,7 +111,10 @@ func (c *OpenAIClient) AnalyzeRequirement(ctx context.Context, requirement strin
 }
 
 // GenerateQuestions 基于分析结果生成补充问题
-func (c *OpenAIClient) GenerateQuestions(ctx context.Context, analysis *RequirementAnalysis) ([]Question, error) {
+func (c *OpenAIClient) GenerateQuestions(ctx context.Context, analysis *RequirementAnalysis) (questions []Question, err error) {
+	ctx, record := startAudit(ctx, "questions")
+	defer func() { finishAudit(ctx, c.auditor, record, questions, err) }()
+
 	prompt := c.buildQuestionsPrompt(analysis)
 	
 	response, err := c.callOpenAI(ctx, prompt)
@@ -83,7 +122,7 @@ func (c *OpenAIClient) GenerateQuestions(ctx context.Context, analysis *Requirem
 		return nil, fmt.Errorf("调用OpenAI API失败: %w", err)
 	}
 
-	questions, err := c.parseQuestionsResponse(response.Content)
+	questions, err = c.parseQuestionsResponse(response.Content)
 	if err != nil {
 		return nil, fmt.Errorf("解析问题生成结果失败: %w", err)
 	}
@@ -92,15 +131,18 @@ func (c *OpenAIClient) GenerateQuestions(ctx context.Context, analysis *Requirem
 }
 
 // GeneratePUML 生成PUML图表代码
-func (c *OpenAIClient) GeneratePUML(ctx context.Context, analysis *RequirementAnalysis, diagramType PUMLType) (*PUMLDiagram, error) {
-	prompt := c.buildPUMLPrompt(analysis, diagramType)
+func (c *OpenAIClient) GeneratePUML(ctx context.Context, analysis *RequirementAnalysis, diagramType PUMLType) (diagram *PUMLDiagram, err error) {
+	ctx, record := startAudit(ctx, "puml")
+	defer func() { finishAudit(ctx, c.auditor, record, diagram, err) }()
+
+	prompt := pumlPrompt(ctx, analysis, diagramType, c.buildPUMLPrompt)
 	
 	response, err := c.callOpenAI(ctx, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("调用OpenAI API失败: %w", err)
 	}
 
-	diagram, err := c.parsePUMLResponse(response.Content, analysis.ProjectID, diagramType)
+	diagram, err = c.parsePUMLResponse(response.Content, analysis.ProjectID, diagramType)
 	if err != nil {
 		return nil, fmt.Errorf("解析PUML生成结果失败: %w", err)
 	}
@@ -109,7 +151,10 @@ func (c *OpenAIClient) GeneratePUML(ctx context.Context, analysis *RequirementAn
 }
 
 // GenerateDocument 生成开发文档
-func (c *OpenAIClient) GenerateDocument(ctx context.Context, analysis *RequirementAnalysis) (*DevelopmentDocument, error) {
+func (c *OpenAIClient) GenerateDocument(ctx context.Context, analysis *RequirementAnalysis) (document *DevelopmentDocument, err error) {
+	ctx, record := startAudit(ctx, "document")
+	defer func() { finishAudit(ctx, c.auditor, record, document, err) }()
+
 	prompt := c.buildDocumentPrompt(analysis)
 	
 	response, err := c.callOpenAI(ctx, prompt)
@@ -117,7 +162,7 @@ func (c *OpenAIClient) GenerateDocument(ctx context.Context, analysis *Requireme
 		return nil, fmt.Errorf("调用OpenAI API失败: %w", err)
 	}
 
-	document, err := c.parseDocumentResponse(response.Content, analysis.ProjectID)
+	document, err = c.parseDocumentResponse(response.Content, analysis.ProjectID)
 	if err != nil {
 		return nil, fmt.Errorf("解析文档生成结果失败: %w", err)
 	}
@@ -126,7 +171,10 @@ func (c *OpenAIClient) GenerateDocument(ctx context.Context, analysis *Requireme
 }
 
 // ProjectChat 项目上下文AI对话
-func (c *OpenAIClient) ProjectChat(ctx context.Context, message, context string) (*ProjectChatResponse, error) {
+func (c *OpenAIClient) ProjectChat(ctx context.Context, message, context string) (chatResponse *ProjectChatResponse, err error) {
+	ctx, record := startAudit(ctx, "chat")
+	defer func() { finishAudit(ctx, c.auditor, record, chatResponse, err) }()
+
 	prompt := c.buildProjectChatPrompt(message, context)
 	
 	response, err := c.callOpenAI(ctx, prompt)
@@ -134,7 +182,7 @@ func (c *OpenAIClient) ProjectChat(ctx context.Context, message, context string)
 		return nil, fmt.Errorf("调用OpenAI API失败: %w", err)
 	}
 
-	chatResponse, err := c.parseProjectChatResponse(response.Content)
+	chatResponse, err = c.parseProjectChatResponse(response.Content)
 	if err != nil {
 		return nil, fmt.Errorf("解析项目对话结果失败: %w", err)
 	}
@@ -142,8 +190,37 @@ func (c *OpenAIClient) ProjectChat(ctx context.Context, message, context string)
 	return chatResponse, nil
 }
 
-// callOpenAI 调用OpenAI API
+// CallOpenAI 公开的OpenAI调用方法
+func (c *OpenAIClient) CallOpenAI(ctx context.Context, prompt string) (*AIResponse, error) {
+	return c.callOpenAI(ctx, prompt)
+}
+
+// callOpenAI 调用OpenAI API并记录审计信息
 func (c *OpenAIClient) callOpenAI(ctx context.Context, prompt string) (*AIResponse, error) {
+	// 未经过具体操作的直接调用，单独提交一条审计记录
+	record := auditRecordFromContext(ctx)
+	standalone := record == nil
+	if standalone {
+		ctx, record = startAudit(ctx, "")
+	}
+
+	prompt = withGlossaryPrompt(ctx, withExistingSchemaPrompt(ctx, prompt))
+	spanCtx, span := tracing.Start(ctx, "ai.call")
+	started := time.Now()
+	response, err := c.sendOpenAIRequest(spanCtx, prompt)
+	record.fillCall(ProviderOpenAI, c.model, prompt, response, err, time.Since(started))
+	observeAICall(record, err)
+	endAISpan(span, record, err)
+
+	if standalone {
+		finishAudit(ctx, c.auditor, record, nil, err)
+	}
+
+	return response, err
+}
+
+// sendOpenAIRequest 发送OpenAI API请求
+func (c *OpenAIClient) sendOpenAIRequest(ctx context.Context, prompt string) (*AIResponse, error) {
 	req := map[string]interface{}{
 		"model": c.model,
 		"messages": []map[string]string{
@@ -156,8 +233,8 @@ func (c *OpenAIClient) callOpenAI(ctx context.Context, prompt string) (*AIRespon
 				"content": prompt,
 			},
 		},
-		"max_tokens":   2000,
-		"temperature":  0.3,
+		"max_tokens":   defaultMaxTokens,
+		"temperature":  defaultTemperature,
 	}
 
 	jsonData, err := json.Marshal(req)
@@ -0,0 +1,186 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ClarificationAnswer 补充问题及其回答
+type ClarificationAnswer struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// MergeSummary 合并分析结果时新增的内容
+type MergeSummary struct {
+	CoreFunctions     []string `json:"core_functions,omitempty"`
+	Roles             []string `json:"roles,omitempty"`
+	BusinessProcesses []string `json:"business_processes,omitempty"`
+	DataEntities      []string `json:"data_entities,omitempty"`
+}
+
+// BuildClarifiedRequirement 将原始需求与问答拼接为再次分析用的需求文本
+func BuildClarifiedRequirement(original string, answers []ClarificationAnswer) string {
+	original = strings.TrimSpace(original)
+	if len(answers) == 0 {
+		return original
+	}
+
+	var builder strings.Builder
+	builder.WriteString(original)
+	builder.WriteString("\n\n补充说明（需求澄清问答）：\n")
+	for i, qa := range answers {
+		builder.WriteString(fmt.Sprintf("%d. 问：%s\n   答：%s\n", i+1, strings.TrimSpace(qa.Question), strings.TrimSpace(qa.Answer)))
+	}
+	return builder.String()
+}
+
+// MergeAnalysis 将再次分析的结果合并到已有分析中
+// 已有的功能、角色、流程和实体全部保留，新结果中的同名流程和实体补充步骤、属性与关系；
+// 缺失信息和完整度评分以新结果为准
+func MergeAnalysis(base, refined *RequirementAnalysis) (*RequirementAnalysis, MergeSummary) {
+	var summary MergeSummary
+	if base == nil {
+		base = &RequirementAnalysis{}
+	}
+	if refined == nil {
+		return base, summary
+	}
+
+	merged := *base
+	merged.CoreFunctions, summary.CoreFunctions = mergeStrings(base.CoreFunctions, refined.CoreFunctions)
+	merged.Roles, summary.Roles = mergeStrings(base.Roles, refined.Roles)
+	merged.BusinessProcesses, summary.BusinessProcesses = mergeProcesses(base.BusinessProcesses, refined.BusinessProcesses)
+	merged.DataEntities, summary.DataEntities = mergeEntities(base.DataEntities, refined.DataEntities)
+	merged.MissingInfo = append([]string(nil), refined.MissingInfo...)
+	merged.CompletionScore = refined.CompletionScore
+	if !refined.UpdatedAt.IsZero() {
+		merged.UpdatedAt = refined.UpdatedAt
+	}
+
+	return &merged, summary
+}
+
+// normalizeName 规范化名称用于去重比较
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// mergeStrings 合并字符串列表并返回新增项
+func mergeStrings(base, extra []string) ([]string, []string) {
+	result := append([]string(nil), base...)
+	seen := make(map[string]bool, len(base))
+	for _, item := range base {
+		seen[normalizeName(item)] = true
+	}
+
+	var added []string
+	for _, item := range extra {
+		key := normalizeName(item)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, item)
+		added = append(added, item)
+	}
+	return result, added
+}
+
+// mergeProcesses 按名称合并业务流程
+func mergeProcesses(base, extra []BusinessProcess) ([]BusinessProcess, []string) {
+	result := make([]BusinessProcess, len(base))
+	index := make(map[string]int, len(base))
+	for i, process := range base {
+		result[i] = process
+		index[normalizeName(process.Name)] = i
+	}
+
+	var added []string
+	for _, process := range extra {
+		key := normalizeName(process.Name)
+		if key == "" {
+			continue
+		}
+		i, exists := index[key]
+		if !exists {
+			index[key] = len(result)
+			result = append(result, process)
+			added = append(added, process.Name)
+			continue
+		}
+
+		existing := result[i]
+		if existing.Description == "" {
+			existing.Description = process.Description
+		}
+		existing.Steps, _ = mergeStrings(existing.Steps, process.Steps)
+		existing.Actors, _ = mergeStrings(existing.Actors, process.Actors)
+		result[i] = existing
+	}
+	return result, added
+}
+
+// mergeEntities 按名称合并数据实体，同名实体合并属性与关系
+func mergeEntities(base, extra []DataEntity) ([]DataEntity, []string) {
+	result := make([]DataEntity, len(base))
+	index := make(map[string]int, len(base))
+	for i, entity := range base {
+		result[i] = entity
+		index[normalizeName(entity.Name)] = i
+	}
+
+	var added []string
+	for _, entity := range extra {
+		key := normalizeName(entity.Name)
+		if key == "" {
+			continue
+		}
+		i, exists := index[key]
+		if !exists {
+			index[key] = len(result)
+			result = append(result, entity)
+			added = append(added, entity.Name)
+			continue
+		}
+
+		existing := result[i]
+		if existing.Description == "" {
+			existing.Description = entity.Description
+		}
+
+		attributes := append([]EntityAttribute(nil), existing.Attributes...)
+		seenAttributes := make(map[string]bool, len(attributes))
+		for _, attr := range attributes {
+			seenAttributes[normalizeName(attr.Name)] = true
+		}
+		for _, attr := range entity.Attributes {
+			if k := normalizeName(attr.Name); k != "" && !seenAttributes[k] {
+				seenAttributes[k] = true
+				attributes = append(attributes, attr)
+			}
+		}
+		existing.Attributes = attributes
+
+		relations := append([]EntityRelation(nil), existing.Relations...)
+		seenRelations := make(map[string]bool, len(relations))
+		for _, rel := range relations {
+			seenRelations[relationKey(rel)] = true
+		}
+		for _, rel := range entity.Relations {
+			if k := relationKey(rel); !seenRelations[k] {
+				seenRelations[k] = true
+				relations = append(relations, rel)
+			}
+		}
+		existing.Relations = relations
+
+		result[i] = existing
+	}
+	return result, added
+}
+
+// relationKey 关系去重键：目标实体 + 关系类型
+func relationKey(rel EntityRelation) string {
+	return normalizeName(rel.TargetEntity) + "|" + normalizeName(rel.RelationType)
+}
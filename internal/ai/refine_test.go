@@ -0,0 +1,72 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildClarifiedRequirement(t *testing.T) {
+	assert.Equal(t, "原始需求", BuildClarifiedRequirement(" 原始需求 ", nil))
+
+	text := BuildClarifiedRequirement("开发一个图书管理系统", []ClarificationAnswer{
+		{Question: "是否需要借阅逾期提醒？", Answer: "需要，逾期3天短信提醒"},
+	})
+	assert.Contains(t, text, "开发一个图书管理系统")
+	assert.Contains(t, text, "1. 问：是否需要借阅逾期提醒？")
+	assert.Contains(t, text, "答：需要，逾期3天短信提醒")
+}
+
+func TestMergeAnalysis(t *testing.T) {
+	base := &RequirementAnalysis{
+		ID:            "analysis-1",
+		CoreFunctions: []string{"图书借阅"},
+		Roles:         []string{"读者"},
+		BusinessProcesses: []BusinessProcess{
+			{Name: "借书流程", Steps: []string{"选书", "借阅"}},
+		},
+		DataEntities: []DataEntity{
+			{Name: "Book", Attributes: []EntityAttribute{{Name: "title"}}},
+		},
+		MissingInfo:     []string{"逾期规则", "角色权限"},
+		CompletionScore: 0.5,
+	}
+	refined := &RequirementAnalysis{
+		CoreFunctions: []string{"图书借阅", "逾期提醒"},
+		Roles:         []string{"读者", "管理员"},
+		BusinessProcesses: []BusinessProcess{
+			{Name: "借书流程", Steps: []string{"借阅", "登记"}, Actors: []string{"读者"}},
+			{Name: "逾期处理", Steps: []string{"发送提醒"}},
+		},
+		DataEntities: []DataEntity{
+			{Name: "book", Attributes: []EntityAttribute{{Name: "Title"}, {Name: "isbn"}},
+				Relations: []EntityRelation{{TargetEntity: "Loan", RelationType: "one-to-many"}}},
+			{Name: "Loan"},
+		},
+		MissingInfo:     []string{"角色权限"},
+		CompletionScore: 0.8,
+	}
+
+	merged, summary := MergeAnalysis(base, refined)
+
+	assert.Equal(t, "analysis-1", merged.ID)
+	assert.Equal(t, []string{"图书借阅", "逾期提醒"}, merged.CoreFunctions)
+	assert.Equal(t, []string{"读者", "管理员"}, merged.Roles)
+	assert.Len(t, merged.BusinessProcesses, 2)
+	assert.Equal(t, []string{"选书", "借阅", "登记"}, merged.BusinessProcesses[0].Steps)
+	assert.Equal(t, []string{"读者"}, merged.BusinessProcesses[0].Actors)
+	assert.Len(t, merged.DataEntities, 2)
+	assert.Len(t, merged.DataEntities[0].Attributes, 2)
+	assert.Len(t, merged.DataEntities[0].Relations, 1)
+	assert.Equal(t, []string{"角色权限"}, merged.MissingInfo)
+	assert.Equal(t, 0.8, merged.CompletionScore)
+
+	assert.Equal(t, []string{"逾期提醒"}, summary.CoreFunctions)
+	assert.Equal(t, []string{"管理员"}, summary.Roles)
+	assert.Equal(t, []string{"逾期处理"}, summary.BusinessProcesses)
+	assert.Equal(t, []string{"Loan"}, summary.DataEntities)
+
+	// 原分析不被修改
+	assert.Len(t, base.CoreFunctions, 1)
+	assert.Len(t, base.BusinessProcesses[0].Steps, 2)
+}
@@ -0,0 +1,16 @@
+package ai
+
+import (
+	"ai-dev-platform/internal/tracing"
+)
+
+// endAISpan 补充AI调用Span的属性并结束Span
+func endAISpan(span *tracing.Span, record *AuditRecord, err error) {
+	span.SetAttribute("ai.provider", string(record.Provider))
+	span.SetAttribute("ai.model", record.Model)
+	span.SetAttribute("ai.operation", record.Operation)
+	span.SetAttribute("ai.prompt_tokens", record.PromptTokens)
+	span.SetAttribute("ai.completion_tokens", record.CompletionTokens)
+	span.RecordError(err)
+	span.End()
+}
@@ -3,9 +3,16 @@ package middleware
 import (
 	"ai-dev-platform/internal/config"
 	"ai-dev-platform/internal/log"
+	"ai-dev-platform/internal/metrics"
+	"ai-dev-platform/internal/model"
+	"ai-dev-platform/internal/requestid"
 	"ai-dev-platform/internal/service"
+	"ai-dev-platform/internal/tracing"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -96,6 +103,26 @@ func AuthMiddleware(userService service.UserService) gin.HandlerFunc {
 	}
 }
 
+// AdminMiddleware 管理员权限中间件，需在AuthMiddleware之后使用
+func AdminMiddleware(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("user")
+		user, ok := value.(*model.User)
+		if !ok || !cfg.IsAdmin(user.Username) {
+			log.WarnfId(c, "AdminMiddleware: 非管理员用户访问管理接口")
+			c.JSON(403, gin.H{
+				"success": false,
+				"error":   "需要管理员权限",
+				"code":    403,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // SecurityMiddleware 安全头中间件
 func SecurityMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -141,6 +168,55 @@ func LoggingMiddleware() gin.HandlerFunc {
 	})
 }
 
+// MetricsMiddleware 请求指标中间件，按路由模板统计请求数与耗时
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		c.Next()
+
+		// 使用路由模板而非实际路径，避免路径参数导致标签基数膨胀
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		metrics.HTTPRequestsTotal.Inc(c.Request.Method, route, status)
+		metrics.HTTPRequestDuration.Observe(time.Since(started).Seconds(), c.Request.Method, route, status)
+	}
+}
+
+// TracingMiddleware 链路追踪中间件，读取上游 traceparent 并为每个请求创建Span
+func TracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := tracing.Extract(c.Request.Context(), c.Request.Header)
+		ctx, span := tracing.Start(ctx, "HTTP "+c.Request.Method)
+		defer span.End()
+
+		if sc := tracing.SpanContextFromContext(ctx); sc.IsValid() {
+			c.Writer.Header().Set(tracing.TraceparentHeader, sc.Traceparent())
+			// 日志中间件从请求头读取链路ID
+			c.Request.Header.Set("X-Trace-ID", sc.TraceIDString())
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		span.SetName("HTTP " + c.Request.Method + " " + route)
+		span.SetAttribute("http.method", c.Request.Method)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("http.status_code", c.Writer.Status())
+		span.SetAttribute("request_id", requestid.GetID(ctx))
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			span.RecordError(fmt.Errorf("HTTP %d", c.Writer.Status()))
+		}
+	}
+}
+
 // RecoveryMiddleware 恢复中间件
 func RecoveryMiddleware() gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
@@ -3,6 +3,7 @@ package api
 import (
 	"ai-dev-platform/internal/api/middleware"
 	"ai-dev-platform/internal/controller"
+	"ai-dev-platform/internal/metrics"
 	"net/http"
 
 	"ai-dev-platform/internal/config"
@@ -12,12 +13,14 @@ import (
 )
 
 // NewGinRouter 创建 Gin 路由器
-func NewGinRouter(cfg *config.Config, userService service.UserService, projectService service.ProjectService, aiService *service.AIService, pumlService *service.PUMLService, asyncTaskService *service.AsyncTaskService, specService *service.SpecService) *gin.Engine {
+func NewGinRouter(cfg *config.Config, userService service.UserService, projectService service.ProjectService, aiService *service.AIService, pumlService *service.PUMLService, asyncTaskService *service.AsyncTaskService, specService *service.SpecService, aiAuditService *service.AIAuditService) *gin.Engine {
 	// 创建 Gin 引擎
 	r := gin.New()
 
 	// 添加自定义中间件
-	r.Use(middleware.RequestIdRouter())    // 请求ID和链路追踪
+	r.Use(middleware.RequestIdRouter())    // 请求ID
+	r.Use(middleware.TracingMiddleware())  // 链路追踪（traceparent传播）
+	r.Use(middleware.MetricsMiddleware())  // 请求指标中间件（需在恢复中间件之外，才能统计panic请求）
 	r.Use(middleware.RecoveryMiddleware()) // 恢复中间件
 	r.Use(middleware.LoggingMiddleware())  // 日志中间件
 	r.Use(middleware.SecurityMiddleware()) // 安全头中间件
@@ -30,12 +33,16 @@ func NewGinRouter(cfg *config.Config, userService service.UserService, projectSe
 	pumlController := controller.NewPUMLController(pumlService, aiService)
 	asyncController := controller.NewAsyncController(asyncTaskService, aiService)
 	specController := controller.NewSpecController(specService)
+	aiAuditController := controller.NewAIAuditController(aiAuditService)
 
 	// 健康检查
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
 	})
 
+	// Prometheus 指标
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	// API 路由组
 	api := r.Group("/api")
 
@@ -74,12 +81,29 @@ func NewGinRouter(cfg *config.Config, userService service.UserService, projectSe
 			ai.POST("/analyze", aiController.AnalyzeRequirement)
 			ai.GET("/analysis/:id", aiController.GetRequirementAnalysis)
 			ai.GET("/analysis/project/:projectId", aiController.GetRequirementAnalysesByProject)
+			ai.GET("/analysis/:id/questions", aiController.ListRequirementQuestions)
+			ai.POST("/analysis/:id/refine", aiController.RefineRequirement)
+			ai.GET("/analysis/:id/refinements", aiController.GetRefinementHistory)
+			ai.GET("/analysis/:id/versions", aiController.ListRequirementVersions)
+			ai.GET("/analysis/:id/versions/:version", aiController.GetRequirementVersion)
+			ai.GET("/analysis/:id/diff", aiController.DiffRequirementVersions)
+			ai.GET("/analysis/:id/completeness", aiController.GetRequirementCompleteness)
+			ai.GET("/analysis/:id/ears", aiController.GetRequirementEARSReport)
+			ai.POST("/analysis/:id/ears", aiController.LintRequirementAnalysis)
+			ai.POST("/ears/lint", aiController.LintEARS)
+			ai.POST("/completeness", aiController.ScoreCompleteness)
+			ai.PUT("/questions/:questionId/answer", aiController.AnswerQuestion)
+			ai.POST("/questions/:questionId/skip", aiController.SkipQuestion)
 			ai.POST("/puml/generate", aiController.GeneratePUML)
 			ai.GET("/puml/project/:projectId", aiController.GetPUMLDiagramsByProjectID)
 			ai.PUT("/puml/:id", aiController.UpdatePUML)
+			ai.POST("/puml/:id/data-model/sync", aiController.SyncDataModel)
 			ai.POST("/document/generate", aiController.GenerateDocument)
 			ai.GET("/document/project/:projectId", aiController.GetDocumentsByProjectID)
 			ai.PUT("/document/:id", aiController.UpdateDocument)
+			ai.GET("/document/:id/ddl", aiController.GenerateDDL)
+			ai.POST("/document/:id/ddl/migration", aiController.MigrateDDL)
+			ai.POST("/schema/project/:projectId/import", aiController.ImportSchema)
 			ai.POST("/chat/session", aiController.CreateChatSession)
 			ai.POST("/chat/message", aiController.SendChatMessage)
 			ai.GET("/chat/session/:sessionId/messages", aiController.GetChatMessages)
@@ -91,6 +115,14 @@ func NewGinRouter(cfg *config.Config, userService service.UserService, projectSe
 			ai.POST("/chat", aiController.ProjectChat)
 			ai.POST("/generate-stage-documents", aiController.GenerateStageDocuments)
 			ai.POST("/generate-document-list", aiController.GenerateStageDocumentList)
+			ai.GET("/cache/stats", aiController.GetCacheStats)
+			ai.DELETE("/cache/project/:projectId", aiController.InvalidateProjectCache)
+			ai.GET("/glossary/project/:projectId", aiController.ListGlossaryTerms)
+			ai.POST("/glossary/project/:projectId", aiController.CreateGlossaryTerm)
+			ai.GET("/glossary/project/:projectId/candidates", aiController.ExtractGlossaryCandidates)
+			ai.GET("/glossary/project/:projectId/check", aiController.CheckTerminology)
+			ai.PUT("/glossary/:termId", aiController.UpdateGlossaryTerm)
+			ai.DELETE("/glossary/:termId", aiController.DeleteGlossaryTerm)
 		}
 
 		// 异步任务
@@ -102,6 +134,7 @@ func NewGinRouter(cfg *config.Config, userService service.UserService, projectSe
 			async.GET("/tasks/:taskId/poll", asyncController.PollTaskStatus)
 			async.GET("/projects/:projectId/progress", asyncController.GetProjectProgress)
 			async.GET("/projects/:projectId/stages/:stage/documents", asyncController.GetStageDocuments)
+			async.POST("/projects/:projectId/regenerate", asyncController.RegenerateArtifacts)
 		}
 
 		// PUML 功能（需要认证）
@@ -111,6 +144,11 @@ func NewGinRouter(cfg *config.Config, userService service.UserService, projectSe
 			puml.GET("/project/:projectId", pumlController.GetProjectPUMLs)
 			puml.PUT("/:pumlId", pumlController.UpdatePUMLDiagram)
 			puml.DELETE("/:pumlId", pumlController.DeletePUML)
+			puml.GET("/:pumlId/versions", pumlController.ListPUMLVersions)
+			puml.GET("/:pumlId/versions/:version", pumlController.GetPUMLVersion)
+			puml.POST("/:pumlId/versions/:version/restore", pumlController.RestorePUMLVersion)
+			puml.GET("/:pumlId/diff", pumlController.DiffPUMLVersions)
+			puml.GET("/:pumlId/diff/svg", pumlController.RenderPUMLVersionDiff)
 			puml.POST("/export", pumlController.ExportPUML)
 			puml.GET("/stats", pumlController.GetPUMLStats)
 			puml.POST("/cache/clear", pumlController.ClearPUMLCache)
@@ -121,11 +159,35 @@ func NewGinRouter(cfg *config.Config, userService service.UserService, projectSe
 		{
 			spec.GET("", specController.GetSpec)
 			spec.POST("/requirements", specController.CreateRequirements)
+			spec.POST("/requirements/:requirementsId/ears", specController.LintRequirements)
+			spec.GET("/traceability", specController.GetTraceability)
+			spec.POST("/import/preview", specController.PreviewImport)
+			spec.POST("/import", specController.ImportRequirements)
+			spec.POST("/consistency/analyze", specController.AnalyzeConsistency)
+			spec.GET("/consistency/findings", specController.ListFindings)
+			spec.PUT("/consistency/findings/:findingId", specController.UpdateFinding)
+			spec.GET("/impact", specController.GetImpact)
+			spec.POST("/impact/changes", specController.ReportUpstreamChange)
+			spec.GET("/priorities", specController.GetPriorities)
+			spec.POST("/priorities/suggest", specController.SuggestPriorities)
+			spec.PUT("/priorities/stories/:storyId", specController.UpdateStoryPriority)
+			spec.GET("/priorities/stories/:storyId/history", specController.GetStoryPriorityHistory)
+			spec.POST("/stories/:storyId/split", specController.SplitStory)
+			spec.GET("/story-map", specController.GetStoryMap)
 			spec.POST("/design", specController.CreateDesign)
 			spec.POST("/tasks", specController.CreateTasks)
 			spec.PUT("", specController.UpdateSpec)
 			spec.DELETE("", specController.DeleteSpec)
 		}
+
+		// 管理接口
+		admin := protected.Group("/admin")
+		admin.Use(middleware.AdminMiddleware(cfg))
+		{
+			admin.GET("/ai-audit", aiAuditController.ListAuditLogs)
+			admin.GET("/ai-audit/:logId", aiAuditController.GetAuditLog)
+			admin.POST("/ai-audit/:logId/replay", aiAuditController.ReplayAuditLog)
+		}
 	}
 
 	// 公共 PUML 功能（不需要认证）
@@ -136,6 +198,7 @@ func NewGinRouter(cfg *config.Config, userService service.UserService, projectSe
 		pumlPublic.POST("/generate-image", pumlController.GenerateImage)
 		pumlPublic.POST("/validate", pumlController.ValidatePUML)
 		pumlPublic.POST("/preview", pumlController.PreviewPUML)
+		pumlPublic.POST("/convert", pumlController.ConvertPUML)
 	}
 
 	// 静态文件（开发环境）
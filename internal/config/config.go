@@ -4,6 +4,7 @@ import (
 	"ai-dev-platform/internal/log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -30,6 +31,12 @@ type Config struct {
 
 	// CORS配置
 	CORS CORSConfig
+
+	// 管理员配置
+	Admin AdminConfig
+
+	// 链路追踪配置
+	Tracing TracingConfig
 }
 
 // DatabaseConfig 数据库配置
@@ -69,11 +76,35 @@ type AIConfig struct {
 	OpenAIConfig    *OpenAIConfig `json:"openai_config" mapstructure:"openai_config"`
 	ClaudeConfig    *ClaudeConfig `json:"claude_config" mapstructure:"claude_config"`
 	GeminiConfig    *GeminiConfig `json:"gemini_config" mapstructure:"gemini_config"`
+	Audit           AIAuditConfig `json:"audit" mapstructure:"audit"`
+}
+
+// AIAuditConfig AI调用审计配置
+type AIAuditConfig struct {
+	Enabled       bool `json:"enabled" mapstructure:"enabled"`
+	RetentionDays int  `json:"retention_days" mapstructure:"retention_days"`   // 审计记录保留天数，0表示永久保留
+	MaxFieldBytes int  `json:"max_field_bytes" mapstructure:"max_field_bytes"` // 提示语、响应等字段的最大字节数，超出部分截断
 }
 
 // PUMLConfig puml服务相关配置
 type PUMLConfig struct {
 	ServerURL string `json:"server_url" mapstructure:"server_url"`
+
+	// 本地渲染：Renderer 为 local 时通过 PlantUML 可执行文件或 jar 渲染，不把图表发送到外部服务器
+	Renderer         string `json:"renderer" mapstructure:"renderer"`                     // server（默认）或 local
+	Executable       string `json:"executable" mapstructure:"executable"`                 // plantuml 可执行文件，优先于 JarPath
+	JarPath          string `json:"jar_path" mapstructure:"jar_path"`                     // plantuml.jar 路径
+	JavaPath         string `json:"java_path" mapstructure:"java_path"`                   // 运行 jar 的 java，默认 java
+	Workers          int    `json:"workers" mapstructure:"workers"`                       // 同时运行的渲染进程数
+	RenderTimeout    int    `json:"render_timeout" mapstructure:"render_timeout"`         // 单次渲染超时（秒），包含排队时间
+	FallbackToServer bool   `json:"fallback_to_server" mapstructure:"fallback_to_server"` // 本地渲染不可用时改用服务器渲染
+
+	// 渲染缓存：内存中按 LRU 淘汰，配置了 CacheDir 时同时保存到磁盘，重启后仍可命中
+	CacheEnabled     bool   `json:"cache_enabled" mapstructure:"cache_enabled"`
+	CacheMaxEntries  int    `json:"cache_max_entries" mapstructure:"cache_max_entries"`     // 内存中最多缓存的渲染结果数
+	CacheMaxMemoryMB int    `json:"cache_max_memory_mb" mapstructure:"cache_max_memory_mb"` // 内存缓存总大小上限（MB）
+	CacheDir         string `json:"cache_dir" mapstructure:"cache_dir"`                     // 磁盘缓存目录，为空时只使用内存缓存
+	CacheMaxDiskMB   int    `json:"cache_max_disk_mb" mapstructure:"cache_max_disk_mb"`     // 磁盘缓存总大小上限（MB），0 表示不限制
 }
 
 // OpenAIConfig OpenAI相关配置
@@ -94,6 +125,18 @@ type GeminiConfig struct {
 	DefaultModel string `json:"default_model" mapstructure:"default_model"`
 }
 
+// AdminConfig 管理员配置
+type AdminConfig struct {
+	Usernames []string // 拥有管理接口权限的用户名
+}
+
+// TracingConfig 链路追踪配置
+type TracingConfig struct {
+	Exporter     string // none、log（JSON日志）或 otlp（OTLP/HTTP）
+	OTLPEndpoint string // OTLP收集器地址，如 http://localhost:4318
+	ServiceName  string
+}
+
 // CORSConfig CORS配置
 type CORSConfig struct {
 	Origins     []string
@@ -152,9 +195,34 @@ func Load() *Config {
 				APIKey:       os.Getenv("GEMINI_API_KEY"),
 				DefaultModel: "gemini-pro",
 			},
+			Audit: AIAuditConfig{
+				Enabled:       getEnv("AI_AUDIT_ENABLED", "true") == "true",
+				RetentionDays: getEnvInt("AI_AUDIT_RETENTION_DAYS", 30),
+				MaxFieldBytes: getEnvInt("AI_AUDIT_MAX_FIELD_BYTES", 64*1024),
+			},
+		},
+		Admin: AdminConfig{
+			Usernames: getEnvList("ADMIN_USERNAMES"),
+		},
+		Tracing: TracingConfig{
+			Exporter:     getEnv("TRACING_EXPORTER", "none"),
+			OTLPEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+			ServiceName:  getEnv("TRACING_SERVICE_NAME", "ai-dev-platform"),
 		},
 		PUML: PUMLConfig{
-			ServerURL: "http://localhost:8888",
+			ServerURL:        "http://localhost:8888",
+			Renderer:         getEnv("PUML_RENDERER", "server"),
+			Executable:       getEnv("PUML_EXECUTABLE", ""),
+			JarPath:          getEnv("PUML_JAR_PATH", ""),
+			JavaPath:         getEnv("PUML_JAVA_PATH", "java"),
+			Workers:          getEnvInt("PUML_WORKERS", 2),
+			RenderTimeout:    getEnvInt("PUML_RENDER_TIMEOUT", 30),
+			FallbackToServer: getEnv("PUML_FALLBACK_TO_SERVER", "true") == "true",
+			CacheEnabled:     getEnv("PUML_CACHE_ENABLED", "true") == "true",
+			CacheMaxEntries:  getEnvInt("PUML_CACHE_MAX_ENTRIES", 500),
+			CacheMaxMemoryMB: getEnvInt("PUML_CACHE_MAX_MEMORY_MB", 64),
+			CacheDir:         getEnv("PUML_CACHE_DIR", ""),
+			CacheMaxDiskMB:   getEnvInt("PUML_CACHE_MAX_DISK_MB", 1024),
 		},
 
 		CORS: CORSConfig{
@@ -204,6 +272,27 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvList 获取逗号分隔的列表类型环境变量
+func getEnvList(key string) []string {
+	var result []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// IsAdmin 判断用户名是否为管理员
+func (c *Config) IsAdmin(username string) bool {
+	for _, name := range c.Admin.Usernames {
+		if name == username {
+			return true
+		}
+	}
+	return false
+}
+
 // IsDevelopment 判断是否为开发环境
 func (c *Config) IsDevelopment() bool {
 	return c.Env == "development"
@@ -0,0 +1,73 @@
+package consistency
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaxAIPairs 单次AI分类最多提交的候选对数量，按相似度从高到低选取
+const MaxAIPairs = 50
+
+// BuildClassificationPrompt 构建候选对分类的提示词，候选对按 1 开始编号
+func BuildClassificationPrompt(pairs []*Pair) string {
+	var b strings.Builder
+	b.WriteString(`
+你是一个资深的需求分析师。下面是同一项目中成对出现的需求语句，请判断每一对之间的关系：
+
+- duplicate：表达的是同一个需求
+- conflict：两条需求相互矛盾，不能同时满足
+- refinement：一条需求是另一条的细化或补充
+- unrelated：没有实质关系
+
+**需求对：**
+`)
+	for i, pair := range pairs {
+		fmt.Fprintf(&b, "%d. A：%s\n   B：%s\n", i+1, strings.TrimSpace(pair.A.Text), strings.TrimSpace(pair.B.Text))
+	}
+	b.WriteString(`
+**输出格式（JSON）：**
+` + "```json" + `
+{
+  "results": [
+    {"pair": 1, "relation": "duplicate/conflict/refinement/unrelated", "reason": "简要说明判断依据"}
+  ]
+}
+` + "```" + `
+`)
+	return b.String()
+}
+
+// ApplyClassification 将AI返回的分类结果写回候选对，返回被分类的数量
+// 编号越界或关系无法识别的结果会被忽略，对应候选对保持词法判定
+func ApplyClassification(jsonStr string, pairs []*Pair) (int, error) {
+	var parsed struct {
+		Results []struct {
+			Pair     int    `json:"pair"`
+			Relation string `json:"relation"`
+			Reason   string `json:"reason"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(jsonStr), &parsed); err != nil {
+		return 0, fmt.Errorf("解析AI分类结果失败: %w", err)
+	}
+
+	classified := 0
+	for _, result := range parsed.Results {
+		if result.Pair < 1 || result.Pair > len(pairs) {
+			continue
+		}
+		kind := Kind(strings.ToLower(strings.TrimSpace(result.Relation)))
+		switch kind {
+		case KindDuplicate, KindConflict, KindRefinement, KindUnrelated:
+		default:
+			continue
+		}
+		pair := pairs[result.Pair-1]
+		pair.Kind = kind
+		pair.Method = MethodAI
+		pair.Reason = strings.TrimSpace(result.Reason)
+		classified++
+	}
+	return classified, nil
+}
@@ -0,0 +1,218 @@
+// Package consistency 检测功能需求和用户故事之间的重复与冲突
+// 第一阶段用字符片段（shingling）+ MinHash 计算词法相似度并聚类重复项；
+// 第二阶段可选地由AI将候选对分类为重复、冲突或细化
+package consistency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kind 需求对之间的关系
+type Kind string
+
+const (
+	KindDuplicate  Kind = "duplicate"  // 重复
+	KindConflict   Kind = "conflict"   // 冲突
+	KindRefinement Kind = "refinement" // 一方是另一方的细化
+	KindCandidate  Kind = "candidate"  // 词法相似，待AI分类
+	KindUnrelated  Kind = "unrelated"  // AI判定无关
+)
+
+// 判定方式
+const (
+	MethodLexical = "lexical"
+	MethodAI      = "ai"
+)
+
+// 默认阈值
+const (
+	DefaultDuplicateThreshold = 0.8
+	DefaultConflictThreshold  = 0.5
+	DefaultCandidateThreshold = 0.2
+	// 语句数量不超过该值时逐对比较，超过时用 LSH 筛选候选对
+	exhaustiveLimit = 300
+)
+
+// Statement 参与检测的一条需求语句
+type Statement struct {
+	Ref        string `json:"ref"`         // 唯一标识，如 requirements_doc:<id>#2、user_story:<id>
+	SourceType string `json:"source_type"` // requirements_doc 或 user_story
+	SourceID   string `json:"source_id"`
+	Index      int    `json:"index"` // 功能需求在数组中的下标，用户故事为0
+	Text       string `json:"text"`
+}
+
+// Pair 两条需求语句的检测结果
+type Pair struct {
+	A          Statement `json:"a"`
+	B          Statement `json:"b"`
+	Similarity float64   `json:"similarity"` // 字符片段的 Jaccard 相似度
+	Kind       Kind      `json:"kind"`
+	Method     string    `json:"method"`
+	Reason     string    `json:"reason,omitempty"`
+}
+
+// Fingerprint 与顺序无关的需求对标识，用于重复检测时保留已处理结论
+func (p *Pair) Fingerprint() string {
+	refs := []string{p.A.Ref, p.B.Ref}
+	sort.Strings(refs)
+	sum := sha256.Sum256([]byte(strings.Join(refs, "\n")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Cluster 词法重复的语句簇
+type Cluster struct {
+	Refs []string `json:"refs"`
+}
+
+// Options 检测阈值，为0时使用默认值
+type Options struct {
+	DuplicateThreshold float64 `json:"duplicate_threshold,omitempty"`
+	ConflictThreshold  float64 `json:"conflict_threshold,omitempty"`
+	CandidateThreshold float64 `json:"candidate_threshold,omitempty"`
+}
+
+func (o Options) withDefaults() Options {
+	if o.DuplicateThreshold <= 0 {
+		o.DuplicateThreshold = DefaultDuplicateThreshold
+	}
+	if o.ConflictThreshold <= 0 {
+		o.ConflictThreshold = DefaultConflictThreshold
+	}
+	if o.CandidateThreshold <= 0 {
+		o.CandidateThreshold = DefaultCandidateThreshold
+	}
+	return o
+}
+
+// Report 词法检测结果
+type Report struct {
+	Statements int       `json:"statements"`
+	Pairs      []*Pair   `json:"pairs"`
+	Clusters   []Cluster `json:"clusters"`
+}
+
+// 否定表述：相似度高但一方否定时视为冲突
+var (
+	zhNegationRe = regexp.MustCompile(`不能|不可|不得|不允许|不应|不需要|不支持|不会|无需|无法|禁止|严禁`)
+	enNegationRe = regexp.MustCompile(`(?i)\b(?:not|cannot|can't|never|no|don't|doesn't|won't|shouldn't|mustn't|forbidden|prohibited)\b`)
+)
+
+func negated(text string) bool {
+	return zhNegationRe.MatchString(text) || enNegationRe.MatchString(text)
+}
+
+// Analyze 计算语句两两之间的词法相似度，返回候选对和重复簇
+// 相似度达到重复阈值且否定表述一致的为重复，达到冲突阈值且一方否定的为冲突，其余达到候选阈值的待AI分类
+func Analyze(statements []Statement, opts Options) *Report {
+	opts = opts.withDefaults()
+
+	var items []Statement
+	var shingles []map[string]struct{}
+	for _, st := range statements {
+		set := Shingles(st.Text)
+		if len(set) == 0 {
+			continue
+		}
+		items = append(items, st)
+		shingles = append(shingles, set)
+	}
+
+	report := &Report{Statements: len(items), Pairs: []*Pair{}, Clusters: []Cluster{}}
+	parent := make([]int, len(items))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	for _, c := range comparePairs(shingles) {
+		i, j := c[0], c[1]
+		similarity := Jaccard(shingles[i], shingles[j])
+		if similarity < opts.CandidateThreshold {
+			continue
+		}
+
+		pair := &Pair{A: items[i], B: items[j], Similarity: roundSimilarity(similarity), Kind: KindCandidate, Method: MethodLexical}
+		opposite := negated(items[i].Text) != negated(items[j].Text)
+		switch {
+		case opposite && similarity >= opts.ConflictThreshold:
+			pair.Kind = KindConflict
+			pair.Reason = "表述高度相似，但一方为否定"
+		case !opposite && similarity >= opts.DuplicateThreshold:
+			pair.Kind = KindDuplicate
+			pair.Reason = "词法相似度超过重复阈值"
+			union(parent, i, j)
+		}
+		report.Pairs = append(report.Pairs, pair)
+	}
+
+	sort.SliceStable(report.Pairs, func(x, y int) bool {
+		if report.Pairs[x].Similarity != report.Pairs[y].Similarity {
+			return report.Pairs[x].Similarity > report.Pairs[y].Similarity
+		}
+		return report.Pairs[x].A.Ref+report.Pairs[x].B.Ref < report.Pairs[y].A.Ref+report.Pairs[y].B.Ref
+	})
+
+	groups := make(map[int][]string)
+	var roots []int
+	for i := range items {
+		root := find(parent, i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], items[i].Ref)
+	}
+	for _, root := range roots {
+		if len(groups[root]) > 1 {
+			report.Clusters = append(report.Clusters, Cluster{Refs: groups[root]})
+		}
+	}
+
+	return report
+}
+
+// comparePairs 语句较少时逐对比较，否则用 MinHash LSH 筛选
+func comparePairs(shingles []map[string]struct{}) [][2]int {
+	if len(shingles) <= exhaustiveLimit {
+		var pairs [][2]int
+		for i := range shingles {
+			for j := i + 1; j < len(shingles); j++ {
+				pairs = append(pairs, [2]int{i, j})
+			}
+		}
+		return pairs
+	}
+
+	sigs := make([][numHashes]uint64, len(shingles))
+	for i, set := range shingles {
+		sigs[i] = signature(set)
+	}
+	return candidatePairs(sigs)
+}
+
+func find(parent []int, i int) int {
+	for parent[i] != i {
+		parent[i] = parent[parent[i]]
+		i = parent[i]
+	}
+	return i
+}
+
+func union(parent []int, i, j int) {
+	ri, rj := find(parent, i), find(parent, j)
+	if ri == rj {
+		return
+	}
+	if ri < rj {
+		parent[rj] = ri
+	} else {
+		parent[ri] = rj
+	}
+}
+
+func roundSimilarity(v float64) float64 {
+	return float64(int(v*1000+0.5)) / 1000
+}
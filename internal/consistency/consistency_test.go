@@ -0,0 +1,108 @@
+package consistency
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func st(ref, text string) Statement {
+	return Statement{Ref: ref, SourceType: "requirements_doc", SourceID: "doc", Text: text}
+}
+
+func TestShinglesAndJaccard(t *testing.T) {
+	assert.Equal(t, 1.0, Jaccard(Shingles("Check out"), Shingles("checkout!")))
+	assert.Equal(t, 0.0, Jaccard(Shingles(""), Shingles("abc")))
+	// 汉字为主的文本使用二元组
+	assert.Contains(t, Shingles("用户登录"), "登录")
+	assert.Len(t, Shingles("ab"), 1)
+}
+
+func TestAnalyze(t *testing.T) {
+	statements := []Statement{
+		st("r#0", "The system shall allow readers to borrow books online."),
+		st("r#1", "The system shall allow readers to borrow books online"),
+		st("s:1", "System shall allow readers to borrow books online!"),
+		st("r#2", "Guests can check out"),
+		st("r#3", "Guests cannot check out"),
+		st("r#4", "Export monthly reports as PDF"),
+		st("r#5", "   "),
+	}
+
+	report := Analyze(statements, Options{})
+	assert.Equal(t, 6, report.Statements)
+
+	kinds := make(map[string]Kind)
+	for _, pair := range report.Pairs {
+		kinds[pair.A.Ref+"|"+pair.B.Ref] = pair.Kind
+		assert.Equal(t, MethodLexical, pair.Method)
+	}
+	assert.Equal(t, KindDuplicate, kinds["r#0|r#1"])
+	assert.Equal(t, KindDuplicate, kinds["r#0|s:1"])
+	assert.Equal(t, KindConflict, kinds["r#2|r#3"])
+	_, related := kinds["r#4|r#0"]
+	assert.False(t, related)
+
+	require.Len(t, report.Clusters, 1)
+	assert.Equal(t, []string{"r#0", "r#1", "s:1"}, report.Clusters[0].Refs)
+
+	// 按相似度降序
+	for i := 1; i < len(report.Pairs); i++ {
+		assert.GreaterOrEqual(t, report.Pairs[i-1].Similarity, report.Pairs[i].Similarity)
+	}
+}
+
+func TestAnalyzeLSH(t *testing.T) {
+	var statements []Statement
+	for i := 0; i < exhaustiveLimit+20; i++ {
+		statements = append(statements, st(fmt.Sprintf("r#%d", i), fmt.Sprintf("requirement %d about topic %x%x", i, i*7919, i*104729)))
+	}
+	statements = append(statements,
+		st("dup#a", "Librarians can renew overdue loans for registered readers"),
+		st("dup#b", "Librarians can renew overdue loans for registered readers."),
+	)
+
+	report := Analyze(statements, Options{})
+	found := false
+	for _, pair := range report.Pairs {
+		if pair.A.Ref == "dup#a" && pair.B.Ref == "dup#b" {
+			found = true
+			assert.Equal(t, KindDuplicate, pair.Kind)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestFingerprint(t *testing.T) {
+	a, b := st("x", "a"), st("y", "b")
+	assert.Equal(t, (&Pair{A: a, B: b}).Fingerprint(), (&Pair{A: b, B: a}).Fingerprint())
+	assert.NotEqual(t, (&Pair{A: a, B: b}).Fingerprint(), (&Pair{A: a, B: st("z", "c")}).Fingerprint())
+}
+
+func TestClassification(t *testing.T) {
+	pairs := []*Pair{
+		{A: st("a", "访客可以直接结账"), B: st("b", "结账前必须登录"), Kind: KindCandidate, Method: MethodLexical},
+		{A: st("c", "支持导出报表"), B: st("d", "支持导出PDF格式的月度报表"), Kind: KindCandidate, Method: MethodLexical},
+	}
+	prompt := BuildClassificationPrompt(pairs)
+	assert.Contains(t, prompt, "1. A：访客可以直接结账")
+	assert.Contains(t, prompt, "2. A：支持导出报表")
+
+	n, err := ApplyClassification(`{"results":[
+		{"pair":1,"relation":"Conflict","reason":"访客无需登录与必须登录矛盾"},
+		{"pair":2,"relation":"refinement"},
+		{"pair":3,"relation":"duplicate"},
+		{"pair":2,"relation":"maybe"}
+	]}`, pairs)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, KindConflict, pairs[0].Kind)
+	assert.Equal(t, MethodAI, pairs[0].Method)
+	assert.Equal(t, "访客无需登录与必须登录矛盾", pairs[0].Reason)
+	assert.Equal(t, KindRefinement, pairs[1].Kind)
+
+	_, err = ApplyClassification("not json", pairs)
+	assert.Error(t, err)
+}
@@ -0,0 +1,139 @@
+package consistency
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	shingleSize    = 3   // 字符 n-gram 长度
+	cjkShingleSize = 2   // 以汉字为主的文本使用二元组
+	numHashes      = 128 // MinHash 签名长度
+	lshBands       = 64  // LSH 分段数，每段 numHashes/lshBands 行，相似度约 0.13 以上的文本大概率成为候选
+)
+
+// hashSeeds 固定种子，保证同一输入在不同进程中得到相同签名
+var hashSeeds = func() [numHashes]uint64 {
+	var seeds [numHashes]uint64
+	state := uint64(0x9E3779B97F4A7C15)
+	for i := range seeds {
+		state = splitmix64(state)
+		seeds[i] = state
+	}
+	return seeds
+}()
+
+func splitmix64(x uint64) uint64 {
+	x += 0x9E3779B97F4A7C15
+	x = (x ^ (x >> 30)) * 0xBF58476D1CE4E5B9
+	x = (x ^ (x >> 27)) * 0x94D049BB133111EB
+	return x ^ (x >> 31)
+}
+
+// normalizeText 小写并只保留字母和数字，去掉空白使 "check out" 与 "checkout" 得到相同的片段
+func normalizeText(text string) []rune {
+	var runes []rune
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, r)
+		}
+	}
+	return runes
+}
+
+// Shingles 文本的字符 n-gram 集合，过短的文本整体作为一个片段
+func Shingles(text string) map[string]struct{} {
+	runes := normalizeText(text)
+	set := make(map[string]struct{})
+	if len(runes) == 0 {
+		return set
+	}
+
+	size := shingleSize
+	han := 0
+	for _, r := range runes {
+		if unicode.Is(unicode.Han, r) {
+			han++
+		}
+	}
+	if han*2 > len(runes) {
+		size = cjkShingleSize
+	}
+
+	if len(runes) <= size {
+		set[string(runes)] = struct{}{}
+		return set
+	}
+	for i := 0; i+size <= len(runes); i++ {
+		set[string(runes[i:i+size])] = struct{}{}
+	}
+	return set
+}
+
+// Jaccard 两个片段集合的 Jaccard 相似度
+func Jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	shared := 0
+	for s := range a {
+		if _, ok := b[s]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// signature 计算片段集合的 MinHash 签名
+func signature(shingles map[string]struct{}) [numHashes]uint64 {
+	var sig [numHashes]uint64
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+	for s := range shingles {
+		h := fnv.New64a()
+		h.Write([]byte(s))
+		base := h.Sum64()
+		for i, seed := range hashSeeds {
+			if v := splitmix64(base ^ seed); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// candidatePairs 通过 LSH 分段找出可能相似的文本对（下标 i < j）
+func candidatePairs(sigs [][numHashes]uint64) [][2]int {
+	rows := numHashes / lshBands
+	seen := make(map[[2]int]bool)
+	var pairs [][2]int
+
+	for band := 0; band < lshBands; band++ {
+		buckets := make(map[uint64][]int)
+		for i, sig := range sigs {
+			h := uint64(band)
+			for _, v := range sig[band*rows : (band+1)*rows] {
+				h = splitmix64(h ^ v)
+			}
+			buckets[h] = append(buckets[h], i)
+		}
+		for _, members := range buckets {
+			for x := 0; x < len(members); x++ {
+				for y := x + 1; y < len(members); y++ {
+					pair := [2]int{members[x], members[y]}
+					if !seen[pair] {
+						seen[pair] = true
+						pairs = append(pairs, pair)
+					}
+				}
+			}
+		}
+	}
+	return pairs
+}
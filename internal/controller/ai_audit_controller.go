@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"ai-dev-platform/internal/log"
+	"ai-dev-platform/internal/model"
+	"ai-dev-platform/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AIAuditController AI调用审计控制器（管理接口）
+type AIAuditController struct {
+	auditService *service.AIAuditService
+}
+
+// NewAIAuditController 创建AI调用审计控制器
+func NewAIAuditController(auditService *service.AIAuditService) *AIAuditController {
+	return &AIAuditController{
+		auditService: auditService,
+	}
+}
+
+// ListAuditLogs 查询AI调用审计日志
+func (ac *AIAuditController) ListAuditLogs(c *gin.Context) {
+	log.InfofId(c, "ListAuditLogs: 开始查询AI审计日志")
+
+	query := &model.AIAuditLogQuery{
+		RequestID: c.Query("request_id"),
+		UserID:    c.Query("user_id"),
+		ProjectID: c.Query("project_id"),
+		Operation: c.Query("operation"),
+		Provider:  c.Query("provider"),
+		Keyword:   c.Query("keyword"),
+	}
+
+	if p := c.Query("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
+			query.Page = parsed
+		}
+	}
+	if ps := c.Query("page_size"); ps != "" {
+		if parsed, err := strconv.Atoi(ps); err == nil && parsed > 0 {
+			if parsed > 100 {
+				parsed = 100
+			}
+			query.PageSize = parsed
+		}
+	}
+	if he := c.Query("has_error"); he != "" {
+		hasError := he == "true"
+		query.HasError = &hasError
+	}
+	for key, target := range map[string]**time.Time{"since": &query.Since, "until": &query.Until} {
+		value := c.Query(key)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			log.WarnfId(c, "ListAuditLogs: 无效的时间参数 %s: %s", key, value)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "无效的时间参数，请使用RFC3339格式",
+				"code":    http.StatusBadRequest,
+			})
+			return
+		}
+		*target = &parsed
+	}
+
+	logs, pagination, err := ac.auditService.ListAuditLogs(query)
+	if err != nil {
+		log.ErrorfId(c, "ListAuditLogs: 查询AI审计日志失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	log.InfofId(c, "ListAuditLogs: 成功查询AI审计日志，数量: %d", len(logs))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       logs,
+		"pagination": pagination,
+		"message":    "获取AI审计日志成功",
+		"code":       http.StatusOK,
+	})
+}
+
+// GetAuditLog 获取AI调用审计日志详情
+func (ac *AIAuditController) GetAuditLog(c *gin.Context) {
+	log.InfofId(c, "GetAuditLog: 开始获取AI审计日志详情")
+
+	logID, err := uuid.Parse(c.Param("logId"))
+	if err != nil {
+		log.WarnfId(c, "GetAuditLog: 无效的日志ID格式: %s", c.Param("logId"))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的日志ID格式",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	auditLog, err := ac.auditService.GetAuditLog(logID)
+	if err != nil {
+		log.ErrorfId(c, "GetAuditLog: 获取AI审计日志失败: %v", err)
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    auditLog,
+		"message": "获取AI审计日志成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// ReplayAuditLog 使用其他提供商或模型重放审计日志中的提示语
+func (ac *AIAuditController) ReplayAuditLog(c *gin.Context) {
+	log.InfofId(c, "ReplayAuditLog: 开始重放AI调用")
+
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.WarnfId(c, "ReplayAuditLog: 认证信息无效")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "认证信息无效",
+			"code":    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	logID, err := uuid.Parse(c.Param("logId"))
+	if err != nil {
+		log.WarnfId(c, "ReplayAuditLog: 无效的日志ID格式: %s", c.Param("logId"))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的日志ID格式",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req model.ReplayAIAuditLogRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Provider == "" {
+		log.WarnfId(c, "ReplayAuditLog: 请求数据解析失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求格式，provider不能为空",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	log.InfofId(c, "ReplayAuditLog: 用户 %s 重放日志 %s，提供商: %s, 模型: %s", user.UserID.String(), logID.String(), req.Provider, req.Model)
+
+	replay, err := ac.auditService.ReplayAuditLog(c.Request.Context(), logID, &req, user.UserID)
+	if err != nil {
+		log.ErrorfId(c, "ReplayAuditLog: 重放AI调用失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    replay,
+		"message": "重放AI调用成功",
+		"code":    http.StatusOK,
+	})
+}
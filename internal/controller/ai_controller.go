@@ -1,10 +1,15 @@
 package controller
 
 import (
+	"ai-dev-platform/internal/ai"
 	"ai-dev-platform/internal/log"
 	"ai-dev-platform/internal/model"
 	"ai-dev-platform/internal/service"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -261,10 +266,16 @@ func (ac *AIController) GeneratePUML(c *gin.Context) {
 	result, err := ac.aiService.GeneratePUMLWithUser(c.Request.Context(), &req, user.UserID)
 	if err != nil {
 		log.ErrorfId(c, "GeneratePUML: PUML生成失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "无权访问") {
+			statusCode = http.StatusForbidden
+		} else if strings.Contains(err.Error(), "项目不存在") {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{
 			"success": false,
 			"error":   err.Error(),
-			"code":    http.StatusInternalServerError,
+			"code":    statusCode,
 		})
 		return
 	}
@@ -391,7 +402,7 @@ func (ac *AIController) UpdatePUML(c *gin.Context) {
 		return
 	}
 
-	err = ac.aiService.UpdatePUMLDiagram(diagramUUID, &req)
+	err = ac.aiService.UpdatePUMLDiagram(diagramUUID, user.UserID, &req)
 	if err != nil {
 		log.ErrorfId(c, "UpdatePUML: PUML更新失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -411,6 +422,48 @@ func (ac *AIController) UpdatePUML(c *gin.Context) {
 	})
 }
 
+// SyncDataModel 把编辑后的数据模型图解析为数据实体和数据库设计，apply 为 true 时写回需求分析和开发文档
+func (ac *AIController) SyncDataModel(c *gin.Context) {
+	user, diagramID, ok := ac.glossaryRequest(c, "SyncDataModel", "id", "图表ID")
+	if !ok {
+		return
+	}
+
+	var req model.SyncDataModelRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.WarnfId(c, "SyncDataModel: 请求数据解析失败: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "无效的请求格式",
+				"code":    http.StatusBadRequest,
+			})
+			return
+		}
+	}
+
+	result, err := ac.aiService.SyncDataModel(user.UserID, diagramID, &req)
+	if err != nil {
+		log.ErrorfId(c, "SyncDataModel: 同步数据模型失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	log.InfofId(c, "SyncDataModel: 图表 %s 解析出 %d 个数据实体、%d 张表，写回: %t",
+		diagramID, len(result.DataEntities), len(result.DatabaseDesign.Tables), result.Applied)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+		"message": "同步数据模型成功",
+		"code":    http.StatusOK,
+	})
+}
+
 // GenerateDocument 生成技术文档
 func (ac *AIController) GenerateDocument(c *gin.Context) {
 	log.InfofId(c, "GenerateDocument: 开始处理文档生成请求")
@@ -604,6 +657,174 @@ func (ac *AIController) UpdateDocument(c *gin.Context) {
 	})
 }
 
+// GenerateDDL 由开发文档中的数据库设计生成SQL建表语句；format=sql 时以文件形式下载
+func (ac *AIController) GenerateDDL(c *gin.Context) {
+	user, documentID, ok := ac.glossaryRequest(c, "GenerateDDL", "id", "文档ID")
+	if !ok {
+		return
+	}
+
+	script, err := ac.aiService.GenerateDDL(user.UserID, documentID, c.Query("dialect"))
+	if err != nil {
+		log.ErrorfId(c, "GenerateDDL: 生成建表语句失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	log.InfofId(c, "GenerateDDL: 文档 %s 生成 %s 建表语句，检查发现 %d 个问题", documentID, script.Dialect, len(script.Findings))
+
+	if c.Query("format") == "sql" {
+		writeSQLFile(c, fmt.Sprintf("schema-%s.sql", script.Dialect), script.SQL)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    script,
+		"message": "生成建表语句成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// MigrateDDL 生成两份开发文档之间的数据库迁移脚本；format=sql 时以文件形式下载
+func (ac *AIController) MigrateDDL(c *gin.Context) {
+	user, documentID, ok := ac.glossaryRequest(c, "MigrateDDL", "id", "文档ID")
+	if !ok {
+		return
+	}
+
+	var req model.DDLMigrationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.WarnfId(c, "MigrateDDL: 请求数据解析失败: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "无效的请求格式",
+				"code":    http.StatusBadRequest,
+			})
+			return
+		}
+	}
+
+	migration, err := ac.aiService.MigrateDDL(user.UserID, documentID, &req)
+	if err != nil {
+		log.ErrorfId(c, "MigrateDDL: 生成迁移脚本失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	log.InfofId(c, "MigrateDDL: 文档 %s -> %s 生成 %s 迁移脚本，共 %d 项变更",
+		migration.FromDocumentID, migration.ToDocumentID, migration.Dialect, len(migration.Changes))
+
+	if c.Query("format") == "sql" {
+		writeSQLFile(c, fmt.Sprintf("migration-%s.sql", migration.Dialect), migration.SQL)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    migration,
+		"message": "生成迁移脚本成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// writeSQLFile 以附件形式返回SQL脚本
+func writeSQLFile(c *gin.Context, filename, sql string) {
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Data(http.StatusOK, "application/sql; charset=utf-8", []byte(sql))
+}
+
+// ImportSchema 导入现有数据库结构：上传 mysqldump --no-data 或 pg_dump --schema-only 的输出，
+// 支持 JSON 请求体或 multipart 表单（file 字段为SQL文件）
+func (ac *AIController) ImportSchema(c *gin.Context) {
+	user, projectID, ok := ac.glossaryRequest(c, "ImportSchema", "projectId", "项目ID")
+	if !ok {
+		return
+	}
+
+	req, err := bindSchemaImportRequest(c)
+	if err != nil {
+		log.WarnfId(c, "ImportSchema: 请求数据解析失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求格式: " + err.Error(),
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	result, err := ac.aiService.ImportSchema(user.UserID, projectID, req)
+	if err != nil {
+		log.ErrorfId(c, "ImportSchema: 导入数据库结构失败: %v", err)
+		// 校验和解析错误返回400，读写数据库失败返回500
+		statusCode := http.StatusBadRequest
+		switch msg := err.Error(); {
+		case strings.Contains(msg, "无权访问"):
+			statusCode = http.StatusForbidden
+		case strings.Contains(msg, "不存在"):
+			statusCode = http.StatusNotFound
+		case strings.HasPrefix(msg, "保存"), strings.HasPrefix(msg, "获取"):
+			statusCode = http.StatusInternalServerError
+		}
+		c.JSON(statusCode, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    statusCode,
+		})
+		return
+	}
+
+	log.InfofId(c, "ImportSchema: 项目 %s 导入 %s 数据库结构，%d 张表，新增 %d 个数据实体，跳过 %d 个对象",
+		projectID, result.Dialect, len(result.DatabaseDesign.Tables), len(result.AddedEntities), len(result.Skipped))
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    result,
+		"message": "导入数据库结构成功",
+		"code":    http.StatusCreated,
+	})
+}
+
+// bindSchemaImportRequest 从 JSON 请求体或 multipart 表单读取数据库结构导入请求
+func bindSchemaImportRequest(c *gin.Context) (*model.ImportSchemaRequest, error) {
+	var req model.ImportSchemaRequest
+	if c.ContentType() != "multipart/form-data" {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, err
+		}
+		return &req, nil
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("缺少SQL文件: %w", err)
+	}
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(string(content)) == "" {
+		return nil, fmt.Errorf("SQL文件为空")
+	}
+
+	req.SQL = string(content)
+	req.Dialect = c.PostForm("dialect")
+	req.AnalysisID = c.PostForm("analysis_id")
+	req.DataModelStyle = c.PostForm("data_model_style")
+	return &req, nil
+}
+
 // CreateChatSession 创建聊天会话 - 暂时不实现
 func (ac *AIController) CreateChatSession(c *gin.Context) {
 	log.InfofId(c, "CreateChatSession: 聊天功能暂时不可用")
@@ -882,3 +1103,906 @@ func (ac *AIController) GenerateStageDocumentList(c *gin.Context) {
 		"code":    http.StatusNotImplemented,
 	})
 }
+
+// GetCacheStats 获取AI缓存统计信息
+func (ac *AIController) GetCacheStats(c *gin.Context) {
+	log.InfofId(c, "GetCacheStats: 开始获取AI缓存统计")
+
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.WarnfId(c, "GetCacheStats: 认证信息无效")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "认证信息无效",
+			"code":    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	log.InfofId(c, "GetCacheStats: 用户 %s 请求获取AI缓存统计", user.UserID.String())
+
+	stats := ac.aiService.GetCacheStats()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    stats,
+		"message": "获取AI缓存统计成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// InvalidateProjectCache 使项目相关的AI缓存失效
+func (ac *AIController) InvalidateProjectCache(c *gin.Context) {
+	log.InfofId(c, "InvalidateProjectCache: 开始清除项目AI缓存")
+
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.WarnfId(c, "InvalidateProjectCache: 认证信息无效")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "认证信息无效",
+			"code":    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	projectID := c.Param("projectId")
+	projectUUID, err := uuid.Parse(projectID)
+	if err != nil {
+		log.WarnfId(c, "InvalidateProjectCache: 无效的项目ID格式: %s", projectID)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的项目ID格式",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	log.InfofId(c, "InvalidateProjectCache: 清除项目AI缓存，项目ID: %s, 用户ID: %s", projectID, user.UserID.String())
+
+	removed, err := ac.aiService.InvalidateProjectCache(projectUUID, user.UserID)
+	if err != nil {
+		log.ErrorfId(c, "InvalidateProjectCache: 清除项目AI缓存失败: %v", err)
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "无权访问") {
+			statusCode = http.StatusForbidden
+		} else if strings.Contains(err.Error(), "项目不存在") {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    statusCode,
+		})
+		return
+	}
+
+	log.InfofId(c, "InvalidateProjectCache: 成功清除项目AI缓存，条目数: %d", removed)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"removed": removed},
+		"message": "清除项目AI缓存成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// ListRequirementQuestions 获取需求分析的补充问题列表
+func (ac *AIController) ListRequirementQuestions(c *gin.Context) {
+	log.InfofId(c, "ListRequirementQuestions: 开始获取补充问题列表")
+
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.WarnfId(c, "ListRequirementQuestions: 认证信息无效")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "认证信息无效",
+			"code":    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	analysisUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.WarnfId(c, "ListRequirementQuestions: 无效的分析ID格式: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的分析ID格式",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	questions, err := ac.aiService.ListRequirementQuestions(analysisUUID, user.UserID)
+	if err != nil {
+		log.ErrorfId(c, "ListRequirementQuestions: 获取补充问题失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	log.InfofId(c, "ListRequirementQuestions: 成功获取补充问题，数量: %d", len(questions))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    questions,
+		"message": "获取补充问题成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// requirementErrorStatus 需求澄清和版本操作错误对应的状态码：无权访问为403，需求、问题或版本不存在为404，
+// 请求无法满足（如没有已回答的问题）为400，其余为500
+func requirementErrorStatus(err error) int {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "无权访问"):
+		return http.StatusForbidden
+	case strings.Contains(msg, "不存在"):
+		return http.StatusNotFound
+	case strings.Contains(msg, "无效的"), strings.Contains(msg, "不能为空"), strings.Contains(msg, "无法重新分析"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// AnswerQuestion 回答补充问题
+func (ac *AIController) AnswerQuestion(c *gin.Context) {
+	log.InfofId(c, "AnswerQuestion: 开始回答补充问题")
+
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.WarnfId(c, "AnswerQuestion: 认证信息无效")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "认证信息无效",
+			"code":    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	questionUUID, err := uuid.Parse(c.Param("questionId"))
+	if err != nil {
+		log.WarnfId(c, "AnswerQuestion: 无效的问题ID格式: %s", c.Param("questionId"))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的问题ID格式",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req model.AnswerQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Answer == "" {
+		log.WarnfId(c, "AnswerQuestion: 请求数据解析失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求格式，answer不能为空",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	question, err := ac.aiService.AnswerQuestionWithUser(questionUUID, req.Answer, user.UserID)
+	if err != nil {
+		log.ErrorfId(c, "AnswerQuestion: 回答补充问题失败: %v", err)
+		statusCode := requirementErrorStatus(err)
+		c.JSON(statusCode, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    statusCode,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    question,
+		"message": "回答补充问题成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// SkipQuestion 跳过补充问题
+func (ac *AIController) SkipQuestion(c *gin.Context) {
+	log.InfofId(c, "SkipQuestion: 开始跳过补充问题")
+
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.WarnfId(c, "SkipQuestion: 认证信息无效")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "认证信息无效",
+			"code":    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	questionUUID, err := uuid.Parse(c.Param("questionId"))
+	if err != nil {
+		log.WarnfId(c, "SkipQuestion: 无效的问题ID格式: %s", c.Param("questionId"))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的问题ID格式",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	question, err := ac.aiService.SkipQuestionWithUser(questionUUID, user.UserID)
+	if err != nil {
+		log.ErrorfId(c, "SkipQuestion: 跳过补充问题失败: %v", err)
+		statusCode := requirementErrorStatus(err)
+		c.JSON(statusCode, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    statusCode,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    question,
+		"message": "已跳过补充问题",
+		"code":    http.StatusOK,
+	})
+}
+
+// RefineRequirement 根据补充问题的回答重新分析需求
+func (ac *AIController) RefineRequirement(c *gin.Context) {
+	log.InfofId(c, "RefineRequirement: 开始根据问答重新分析需求")
+
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.WarnfId(c, "RefineRequirement: 认证信息无效")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "认证信息无效",
+			"code":    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	analysisUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.WarnfId(c, "RefineRequirement: 无效的分析ID格式: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的分析ID格式",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	// 请求体可选
+	var req model.RefineRequirementRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.WarnfId(c, "RefineRequirement: 请求数据解析失败: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "无效的请求格式",
+				"code":    http.StatusBadRequest,
+			})
+			return
+		}
+	}
+
+	log.InfofId(c, "RefineRequirement: 分析ID: %s, 用户ID: %s, 提供商: %s", analysisUUID.String(), user.UserID.String(), req.Provider)
+
+	result, err := ac.aiService.RefineRequirement(c.Request.Context(), analysisUUID, &req, user.UserID)
+	if err != nil {
+		log.ErrorfId(c, "RefineRequirement: 重新分析需求失败: %v", err)
+		statusCode := requirementErrorStatus(err)
+		c.JSON(statusCode, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    statusCode,
+		})
+		return
+	}
+
+	log.InfofId(c, "RefineRequirement: 第%d轮澄清完成，完整度 %.2f -> %.2f", result.Refinement.Round, result.Refinement.ScoreBefore, result.Refinement.ScoreAfter)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+		"message": "重新分析需求成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// GetRefinementHistory 获取需求的澄清历史
+func (ac *AIController) GetRefinementHistory(c *gin.Context) {
+	log.InfofId(c, "GetRefinementHistory: 开始获取需求澄清历史")
+
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.WarnfId(c, "GetRefinementHistory: 认证信息无效")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "认证信息无效",
+			"code":    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	analysisUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.WarnfId(c, "GetRefinementHistory: 无效的分析ID格式: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的分析ID格式",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	history, err := ac.aiService.GetRefinementHistory(analysisUUID, user.UserID)
+	if err != nil {
+		log.ErrorfId(c, "GetRefinementHistory: 获取需求澄清历史失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    history,
+		"message": "获取需求澄清历史成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// LintEARS 检查需求文本是否符合EARS句型（不调用AI、不保存结果）
+func (ac *AIController) LintEARS(c *gin.Context) {
+	log.InfofId(c, "LintEARS: 开始EARS需求检查")
+
+	var req model.EARSLintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WarnfId(c, "LintEARS: 请求数据解析失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求格式",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	report, err := ac.aiService.LintEARS(&req)
+	if err != nil {
+		log.WarnfId(c, "LintEARS: EARS检查失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	log.InfofId(c, "LintEARS: 检查完成，共%d条语句，%d条合规", report.Summary.Total, report.Summary.Compliant)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+		"message": "EARS检查完成",
+		"code":    http.StatusOK,
+	})
+}
+
+// GetRequirementEARSReport 获取需求分析的EARS检查报告，不存在时立即检查
+func (ac *AIController) GetRequirementEARSReport(c *gin.Context) {
+	ac.lintRequirementAnalysis(c, "GetRequirementEARSReport", false)
+}
+
+// LintRequirementAnalysis 重新对需求分析进行EARS检查并保存报告
+func (ac *AIController) LintRequirementAnalysis(c *gin.Context) {
+	ac.lintRequirementAnalysis(c, "LintRequirementAnalysis", true)
+}
+
+// lintRequirementAnalysis 需求分析EARS检查的公共处理
+func (ac *AIController) lintRequirementAnalysis(c *gin.Context, handler string, refresh bool) {
+	log.InfofId(c, "%s: 开始获取需求分析的EARS检查报告", handler)
+
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.WarnfId(c, "%s: 认证信息无效", handler)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "认证信息无效",
+			"code":    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	analysisUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.WarnfId(c, "%s: 无效的分析ID格式: %s", handler, c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的分析ID格式",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	result, err := ac.aiService.LintRequirementAnalysis(analysisUUID, user.UserID, refresh)
+	if err != nil {
+		log.ErrorfId(c, "%s: EARS检查失败: %v", handler, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+		"message": "获取EARS检查报告成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// ListRequirementVersions 获取需求分析的版本列表
+func (ac *AIController) ListRequirementVersions(c *gin.Context) {
+	log.InfofId(c, "ListRequirementVersions: 开始获取需求版本列表")
+
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.WarnfId(c, "ListRequirementVersions: 认证信息无效")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "认证信息无效",
+			"code":    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	analysisUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.WarnfId(c, "ListRequirementVersions: 无效的分析ID格式: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的分析ID格式",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	versions, err := ac.aiService.ListRequirementVersions(analysisUUID, user.UserID)
+	if err != nil {
+		log.ErrorfId(c, "ListRequirementVersions: 获取需求版本列表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    versions,
+		"message": "获取需求版本列表成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// GetRequirementVersion 获取需求分析的指定版本
+func (ac *AIController) GetRequirementVersion(c *gin.Context) {
+	log.InfofId(c, "GetRequirementVersion: 开始获取需求版本")
+
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.WarnfId(c, "GetRequirementVersion: 认证信息无效")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "认证信息无效",
+			"code":    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	analysisUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.WarnfId(c, "GetRequirementVersion: 无效的分析ID格式: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的分析ID格式",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		log.WarnfId(c, "GetRequirementVersion: 无效的版本号: %s", c.Param("version"))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的版本号",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	result, err := ac.aiService.GetRequirementVersion(analysisUUID, version, user.UserID)
+	if err != nil {
+		log.ErrorfId(c, "GetRequirementVersion: 获取需求版本失败: %v", err)
+		statusCode := requirementErrorStatus(err)
+		c.JSON(statusCode, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    statusCode,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+		"message": "获取需求版本成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// DiffRequirementVersions 比较需求分析的两个版本，默认比较当前版本与上一版本
+func (ac *AIController) DiffRequirementVersions(c *gin.Context) {
+	log.InfofId(c, "DiffRequirementVersions: 开始比较需求版本")
+
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.WarnfId(c, "DiffRequirementVersions: 认证信息无效")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "认证信息无效",
+			"code":    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	analysisUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.WarnfId(c, "DiffRequirementVersions: 无效的分析ID格式: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的分析ID格式",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	from, errFrom := strconv.Atoi(c.DefaultQuery("from", "0"))
+	to, errTo := strconv.Atoi(c.DefaultQuery("to", "0"))
+	if errFrom != nil || errTo != nil || from < 0 || to < 0 {
+		log.WarnfId(c, "DiffRequirementVersions: 无效的版本号: from=%s, to=%s", c.Query("from"), c.Query("to"))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的版本号",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	diff, err := ac.aiService.DiffRequirementVersions(analysisUUID, from, to, user.UserID)
+	if err != nil {
+		log.ErrorfId(c, "DiffRequirementVersions: 比较需求版本失败: %v", err)
+		statusCode := requirementErrorStatus(err)
+		c.JSON(statusCode, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    statusCode,
+		})
+		return
+	}
+
+	log.InfofId(c, "DiffRequirementVersions: 版本 %d -> %d，是否有变化: %v", diff.FromVersion, diff.ToVersion, diff.Diff.Changed)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    diff,
+		"message": "比较需求版本成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// GetRequirementCompleteness 获取需求分析的规则完整度评分及逐项明细，可通过 version 指定历史版本
+func (ac *AIController) GetRequirementCompleteness(c *gin.Context) {
+	log.InfofId(c, "GetRequirementCompleteness: 开始计算需求完整度")
+
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.WarnfId(c, "GetRequirementCompleteness: 认证信息无效")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "认证信息无效",
+			"code":    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	analysisUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.WarnfId(c, "GetRequirementCompleteness: 无效的分析ID格式: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的分析ID格式",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	version, err := strconv.Atoi(c.DefaultQuery("version", "0"))
+	if err != nil || version < 0 {
+		log.WarnfId(c, "GetRequirementCompleteness: 无效的版本号: %s", c.Query("version"))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的版本号",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	result, err := ac.aiService.GetRequirementCompleteness(analysisUUID, version, user.UserID)
+	if err != nil {
+		log.ErrorfId(c, "GetRequirementCompleteness: 计算需求完整度失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	log.InfofId(c, "GetRequirementCompleteness: 版本 %d 规则评分 %.2f，模型评分 %.2f", result.Version, result.Score, result.ModelScore)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+		"message": "计算需求完整度成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// ScoreCompleteness 为请求中的需求分析结果计算规则完整度评分（不调用AI、不保存结果）
+func (ac *AIController) ScoreCompleteness(c *gin.Context) {
+	log.InfofId(c, "ScoreCompleteness: 开始计算需求完整度")
+
+	var analysis ai.RequirementAnalysis
+	if err := c.ShouldBindJSON(&analysis); err != nil {
+		log.WarnfId(c, "ScoreCompleteness: 请求数据解析失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求格式",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	report := ac.aiService.ScoreCompleteness(&analysis)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+		"message": "计算需求完整度成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// glossaryRequest 解析术语表接口的用户信息和路径中的ID参数
+func (ac *AIController) glossaryRequest(c *gin.Context, handler, param, label string) (*model.User, uuid.UUID, bool) {
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.WarnfId(c, "%s: 认证信息无效", handler)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "认证信息无效",
+			"code":    http.StatusUnauthorized,
+		})
+		return nil, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		log.WarnfId(c, "%s: 无效的%s格式: %s", handler, label, c.Param(param))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的" + label + "格式",
+			"code":    http.StatusBadRequest,
+		})
+		return nil, uuid.Nil, false
+	}
+
+	return user, id, true
+}
+
+// bindGlossaryTerm 解析术语请求体
+func (ac *AIController) bindGlossaryTerm(c *gin.Context, handler string) (*model.GlossaryTermRequest, bool) {
+	var req model.GlossaryTermRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WarnfId(c, "%s: 请求数据解析失败: %v", handler, err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求格式",
+			"code":    http.StatusBadRequest,
+		})
+		return nil, false
+	}
+	return &req, true
+}
+
+// ListGlossaryTerms 获取项目术语表
+func (ac *AIController) ListGlossaryTerms(c *gin.Context) {
+	user, projectID, ok := ac.glossaryRequest(c, "ListGlossaryTerms", "projectId", "项目ID")
+	if !ok {
+		return
+	}
+
+	terms, err := ac.aiService.ListGlossaryTerms(projectID, user.UserID)
+	if err != nil {
+		log.ErrorfId(c, "ListGlossaryTerms: 获取术语表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    terms,
+		"message": "获取术语表成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// CreateGlossaryTerm 向项目术语表添加术语
+func (ac *AIController) CreateGlossaryTerm(c *gin.Context) {
+	user, projectID, ok := ac.glossaryRequest(c, "CreateGlossaryTerm", "projectId", "项目ID")
+	if !ok {
+		return
+	}
+	req, ok := ac.bindGlossaryTerm(c, "CreateGlossaryTerm")
+	if !ok {
+		return
+	}
+
+	term, err := ac.aiService.CreateGlossaryTerm(projectID, user.UserID, req)
+	if err != nil {
+		log.ErrorfId(c, "CreateGlossaryTerm: 添加术语失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	log.InfofId(c, "CreateGlossaryTerm: 添加术语成功: %s", term.Term)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    term,
+		"message": "添加术语成功",
+		"code":    http.StatusCreated,
+	})
+}
+
+// UpdateGlossaryTerm 更新术语的名称、定义和同义词
+func (ac *AIController) UpdateGlossaryTerm(c *gin.Context) {
+	user, termID, ok := ac.glossaryRequest(c, "UpdateGlossaryTerm", "termId", "术语ID")
+	if !ok {
+		return
+	}
+	req, ok := ac.bindGlossaryTerm(c, "UpdateGlossaryTerm")
+	if !ok {
+		return
+	}
+
+	term, err := ac.aiService.UpdateGlossaryTerm(termID, user.UserID, req)
+	if err != nil {
+		log.ErrorfId(c, "UpdateGlossaryTerm: 更新术语失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    term,
+		"message": "更新术语成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// DeleteGlossaryTerm 从项目术语表删除术语
+func (ac *AIController) DeleteGlossaryTerm(c *gin.Context) {
+	user, termID, ok := ac.glossaryRequest(c, "DeleteGlossaryTerm", "termId", "术语ID")
+	if !ok {
+		return
+	}
+
+	if err := ac.aiService.DeleteGlossaryTerm(termID, user.UserID); err != nil {
+		log.ErrorfId(c, "DeleteGlossaryTerm: 删除术语失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "删除术语成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// ExtractGlossaryCandidates 从数据实体、角色、文档和图表中提取候选术语
+func (ac *AIController) ExtractGlossaryCandidates(c *gin.Context) {
+	user, projectID, ok := ac.glossaryRequest(c, "ExtractGlossaryCandidates", "projectId", "项目ID")
+	if !ok {
+		return
+	}
+
+	candidates, err := ac.aiService.ExtractGlossaryCandidates(projectID, user.UserID)
+	if err != nil {
+		log.ErrorfId(c, "ExtractGlossaryCandidates: 提取候选术语失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	log.InfofId(c, "ExtractGlossaryCandidates: 提取到 %d 个候选术语", len(candidates))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    candidates,
+		"message": "提取候选术语成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// CheckTerminology 检查项目文档、图表和AI回复中使用的非规范同义词
+func (ac *AIController) CheckTerminology(c *gin.Context) {
+	user, projectID, ok := ac.glossaryRequest(c, "CheckTerminology", "projectId", "项目ID")
+	if !ok {
+		return
+	}
+
+	report, err := ac.aiService.CheckTerminology(projectID, user.UserID)
+	if err != nil {
+		log.ErrorfId(c, "CheckTerminology: 术语检查失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	log.InfofId(c, "CheckTerminology: 检查 %d 个产出物，发现 %d 处非规范术语", report.Artifacts, len(report.Issues))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+		"message": "术语检查完成",
+		"code":    http.StatusOK,
+	})
+}
@@ -2,10 +2,12 @@ package controller
 
 import (
 	"ai-dev-platform/internal/log"
+	"ai-dev-platform/internal/model"
 	"ai-dev-platform/internal/service"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // AsyncController 异步任务控制器
@@ -80,4 +82,58 @@ func (ac *AsyncController) GetStageDocuments(c *gin.Context) {
 		"error":   "异步任务功能暂时不可用",
 		"code":    http.StatusNotImplemented,
 	})
-}
\ No newline at end of file
+}
+
+// RegenerateArtifacts 启动过期产物重新生成任务
+func (ac *AsyncController) RegenerateArtifacts(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("projectId"))
+	if err != nil {
+		log.ErrorfId(c, "RegenerateArtifacts: 无效的项目ID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的项目ID",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.ErrorfId(c, "RegenerateArtifacts: 用户未认证")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "用户未认证",
+			"code":    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	var req model.RegenerateArtifactsRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Artifacts) == 0 {
+		log.ErrorfId(c, "RegenerateArtifacts: 请求参数错误: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请选择需要重新生成的产物",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	response, err := ac.asyncTaskService.StartArtifactRegeneration(c.Request.Context(), projectID, user.UserID, req.Artifacts)
+	if err != nil {
+		log.ErrorfId(c, "RegenerateArtifacts: 启动任务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	log.InfofId(c, "RegenerateArtifacts: 项目 %s 启动重新生成任务 %s，共 %d 个产物", projectID, response.TaskID, len(req.Artifacts))
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    response,
+		"message": response.Message,
+	})
+}
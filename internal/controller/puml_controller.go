@@ -4,7 +4,11 @@ import (
 	"ai-dev-platform/internal/log"
 	"ai-dev-platform/internal/model"
 	"ai-dev-platform/internal/service"
+	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -197,10 +201,11 @@ func (pc *PUMLController) UpdatePUMLDiagram(c *gin.Context) {
 	result, err := pc.pumlService.UpdatePUMLDiagram(user.UserID, pumlID, &req)
 	if err != nil {
 		log.ErrorfId(c, "UpdatePUMLDiagram: PUML图表更新失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
+		statusCode := pumlErrorStatus(err)
+		c.JSON(statusCode, gin.H{
 			"success": false,
 			"error":   err.Error(),
-			"code":    http.StatusInternalServerError,
+			"code":    statusCode,
 		})
 		return
 	}
@@ -247,10 +252,11 @@ func (pc *PUMLController) DeletePUML(c *gin.Context) {
 	err := pc.pumlService.DeletePUML(user.UserID, pumlID)
 	if err != nil {
 		log.ErrorfId(c, "DeletePUML: PUML图表删除失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
+		statusCode := pumlErrorStatus(err)
+		c.JSON(statusCode, gin.H{
 			"success": false,
 			"error":   err.Error(),
-			"code":    http.StatusInternalServerError,
+			"code":    statusCode,
 		})
 		return
 	}
@@ -264,6 +270,251 @@ func (pc *PUMLController) DeletePUML(c *gin.Context) {
 	})
 }
 
+// ListPUMLVersions 获取PUML图表的版本历史
+func (pc *PUMLController) ListPUMLVersions(c *gin.Context) {
+	user, pumlID, ok := pc.pumlRequest(c, "ListPUMLVersions")
+	if !ok {
+		return
+	}
+
+	versions, err := pc.pumlService.ListPUMLVersions(user.UserID, pumlID)
+	if err != nil {
+		log.ErrorfId(c, "ListPUMLVersions: 获取PUML图表版本失败: %v", err)
+		statusCode := pumlErrorStatus(err)
+		c.JSON(statusCode, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    statusCode,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    versions,
+		"message": "获取PUML图表版本成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// GetPUMLVersion 获取PUML图表的指定版本
+func (pc *PUMLController) GetPUMLVersion(c *gin.Context) {
+	user, pumlID, ok := pc.pumlRequest(c, "GetPUMLVersion")
+	if !ok {
+		return
+	}
+	version, ok := pumlVersionParam(c, "GetPUMLVersion")
+	if !ok {
+		return
+	}
+
+	result, err := pc.pumlService.GetPUMLVersion(user.UserID, pumlID, version)
+	if err != nil {
+		log.ErrorfId(c, "GetPUMLVersion: 获取PUML图表版本失败: %v", err)
+		statusCode := pumlErrorStatus(err)
+		c.JSON(statusCode, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    statusCode,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+		"message": "获取PUML图表版本成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// RestorePUMLVersion 将PUML图表恢复为指定版本，恢复操作本身会生成一个新版本
+func (pc *PUMLController) RestorePUMLVersion(c *gin.Context) {
+	user, pumlID, ok := pc.pumlRequest(c, "RestorePUMLVersion")
+	if !ok {
+		return
+	}
+	version, ok := pumlVersionParam(c, "RestorePUMLVersion")
+	if !ok {
+		return
+	}
+
+	// 请求体可省略
+	var req model.RestorePUMLVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		log.ErrorfId(c, "RestorePUMLVersion: 请求数据解析失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求格式",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	log.InfofId(c, "RestorePUMLVersion: 用户 %s 请求将PUML图表 %s 恢复为版本 %d", user.UserID.String(), pumlID, version)
+
+	result, err := pc.pumlService.RestorePUMLVersion(user.UserID, pumlID, version, &req)
+	if err != nil {
+		log.ErrorfId(c, "RestorePUMLVersion: 恢复PUML图表版本失败: %v", err)
+		statusCode := pumlErrorStatus(err)
+		c.JSON(statusCode, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    statusCode,
+		})
+		return
+	}
+
+	log.InfofId(c, "RestorePUMLVersion: PUML图表已恢复，当前版本: %d", result.Version)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+		"message": "PUML图表版本恢复成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// DiffPUMLVersions 比较PUML图表的两个版本，返回语义差异、标注变化的合并图源码和逐行对比
+func (pc *PUMLController) DiffPUMLVersions(c *gin.Context) {
+	user, pumlID, ok := pc.pumlRequest(c, "DiffPUMLVersions")
+	if !ok {
+		return
+	}
+	from, to, ok := pumlDiffParams(c, "DiffPUMLVersions")
+	if !ok {
+		return
+	}
+
+	result, err := pc.pumlService.DiffPUMLVersions(user.UserID, pumlID, from, to)
+	if err != nil {
+		log.ErrorfId(c, "DiffPUMLVersions: 比较PUML图表版本失败: %v", err)
+		statusCode := pumlErrorStatus(err)
+		c.JSON(statusCode, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    statusCode,
+		})
+		return
+	}
+	if result.Semantic == nil {
+		log.WarnfId(c, "DiffPUMLVersions: 改为逐行比较: %s", result.FallbackReason)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+		"message": "比较PUML图表版本成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// RenderPUMLVersionDiff 以SVG返回两个版本的差异图，新增为绿色，删除为红色
+func (pc *PUMLController) RenderPUMLVersionDiff(c *gin.Context) {
+	user, pumlID, ok := pc.pumlRequest(c, "RenderPUMLVersionDiff")
+	if !ok {
+		return
+	}
+	from, to, ok := pumlDiffParams(c, "RenderPUMLVersionDiff")
+	if !ok {
+		return
+	}
+
+	result, err := pc.pumlService.RenderPUMLVersionDiff(c.Request.Context(), user.UserID, pumlID, from, to)
+	if err != nil {
+		log.ErrorfId(c, "RenderPUMLVersionDiff: 渲染PUML差异图失败: %v", err)
+		statusCode := pumlErrorStatus(err)
+		c.JSON(statusCode, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    statusCode,
+		})
+		return
+	}
+
+	c.Data(http.StatusOK, "image/svg+xml", result.ImageData)
+}
+
+// pumlRequest 解析当前用户和PUML图表ID，失败时已写入响应
+func (pc *PUMLController) pumlRequest(c *gin.Context, action string) (*model.User, string, bool) {
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.WarnfId(c, "%s: 认证信息无效", action)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "认证信息无效",
+			"code":    http.StatusUnauthorized,
+		})
+		return nil, "", false
+	}
+
+	pumlID := c.Param("pumlId")
+	if pumlID == "" {
+		log.WarnfId(c, "%s: PUML图表ID不能为空", action)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "PUML图表ID不能为空",
+			"code":    http.StatusBadRequest,
+		})
+		return nil, "", false
+	}
+	return user, pumlID, true
+}
+
+// pumlErrorStatus 图表操作错误对应的状态码：无权访问为403，图表、版本或项目不存在为404，请求内容无效为400，
+// 读写存储失败为500
+func pumlErrorStatus(err error) int {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "无权访问"):
+		return http.StatusForbidden
+	case strings.Contains(msg, "不存在"):
+		return http.StatusNotFound
+	case strings.Contains(msg, "无效的"), strings.Contains(msg, "语法错误"), strings.Contains(msg, "不支持的"), strings.Contains(msg, "已是当前版本"),
+		strings.Contains(msg, "请选择要导出的图表"), strings.Contains(msg, "单次最多导出"), strings.Contains(msg, "无法导出为"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// pumlVersionParam 解析路径中的版本号，失败时已写入响应
+func pumlVersionParam(c *gin.Context, action string) (int, bool) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		log.WarnfId(c, "%s: 无效的版本号: %s", action, c.Param("version"))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的版本号",
+			"code":    http.StatusBadRequest,
+		})
+		return 0, false
+	}
+	return version, true
+}
+
+// pumlDiffParams 解析查询参数中可选的 from、to 版本号，失败时已写入响应
+func pumlDiffParams(c *gin.Context, action string) (int, int, bool) {
+	versions := make([]int, 2)
+	for i, key := range []string{"from", "to"} {
+		value := c.Query(key)
+		if value == "" {
+			continue
+		}
+		version, err := strconv.Atoi(value)
+		if err != nil || version < 1 {
+			log.WarnfId(c, "%s: 无效的版本号: %s=%s", action, key, value)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "无效的版本号",
+				"code":    http.StatusBadRequest,
+			})
+			return 0, 0, false
+		}
+		versions[i] = version
+	}
+	return versions[0], versions[1], true
+}
+
 // RenderPUMLImage 渲染PUML图片
 func (pc *PUMLController) RenderPUMLImage(c *gin.Context) {
 	log.InfofId(c, "RenderPUMLImage: 开始处理PUML图片渲染请求")
@@ -293,7 +544,7 @@ func (pc *PUMLController) RenderPUMLImage(c *gin.Context) {
 	log.InfofId(c, "RenderPUMLImage: 开始渲染PUML图片")
 
 	// 调用服务渲染PUML图片
-	result, err := pc.pumlService.RenderPUMLImage(&req)
+	result, err := pc.pumlService.RenderPUMLImage(c.Request.Context(), &req)
 	if err != nil {
 		log.ErrorfId(c, "RenderPUMLImage: PUML图片渲染失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -343,7 +594,7 @@ func (pc *PUMLController) RenderPUMLOnline(c *gin.Context) {
 	log.InfofId(c, "RenderPUMLOnline: 开始在线渲染PUML")
 
 	// 调用服务在线渲染PUML
-	result, err := pc.pumlService.RenderPUMLOnlineFromRequest(&req)
+	result, err := pc.pumlService.RenderPUMLOnlineFromRequest(c.Request.Context(), &req)
 	if err != nil {
 		log.ErrorfId(c, "RenderPUMLOnline: 在线PUML渲染失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -393,7 +644,7 @@ func (pc *PUMLController) GenerateImage(c *gin.Context) {
 	log.InfofId(c, "GenerateImage: 开始生成图片")
 
 	// 调用服务生成图片
-	result, err := pc.pumlService.GenerateImage(&req)
+	result, err := pc.pumlService.GenerateImage(c.Request.Context(), &req)
 	if err != nil {
 		log.ErrorfId(c, "GenerateImage: 图片生成失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -493,7 +744,7 @@ func (pc *PUMLController) PreviewPUML(c *gin.Context) {
 	log.InfofId(c, "PreviewPUML: 开始预览PUML")
 
 	// 调用服务预览PUML
-	result, err := pc.pumlService.PreviewPUML(&req)
+	result, err := pc.pumlService.PreviewPUML(c.Request.Context(), &req)
 	if err != nil {
 		log.ErrorfId(c, "PreviewPUML: PUML预览失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -514,7 +765,57 @@ func (pc *PUMLController) PreviewPUML(c *gin.Context) {
 	})
 }
 
-// ExportPUML 导出PUML
+// ConvertPUML 在 PlantUML 与 Mermaid 之间转换图表，返回转换后的源码和无法转换而丢弃的写法
+func (pc *PUMLController) ConvertPUML(c *gin.Context) {
+	log.InfofId(c, "ConvertPUML: 开始处理图表转换请求")
+
+	var req model.ConvertPUMLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.ErrorfId(c, "ConvertPUML: 请求数据解析失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求格式",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	// 参数验证
+	if req.Content == "" || req.To == "" {
+		log.WarnfId(c, "ConvertPUML: 图表内容和目标语法不能为空")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "图表内容和目标语法不能为空",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	result, err := pc.pumlService.ConvertPUML(&req)
+	if err != nil {
+		log.ErrorfId(c, "ConvertPUML: 图表转换失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+	if len(result.Dropped) > 0 {
+		log.WarnfId(c, "ConvertPUML: 转换时丢弃了 %d 处写法", len(result.Dropped))
+	}
+
+	log.InfofId(c, "ConvertPUML: 图表转换成功")
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+		"message": "图表转换成功",
+		"code":    http.StatusOK,
+	})
+}
+
+// ExportPUML 导出PUML：以附件形式流式返回 zip、Markdown、HTML、Mermaid 或 draw.io 文件
 func (pc *PUMLController) ExportPUML(c *gin.Context) {
 	log.InfofId(c, "ExportPUML: 开始处理PUML导出请求")
 
@@ -540,28 +841,30 @@ func (pc *PUMLController) ExportPUML(c *gin.Context) {
 		return
 	}
 
-	log.InfofId(c, "ExportPUML: 用户 %s 请求导出PUML", user.UserID.String())
+	log.InfofId(c, "ExportPUML: 用户 %s 请求导出 %d 个PUML图表，格式 %s", user.UserID.String(), len(req.PUMLIDs), req.Format)
 
-	// 调用服务导出PUML
-	result, err := pc.pumlService.ExportPUML(user.UserID, &req)
+	// 校验权限并准备导出文件，出错时还未写出任何内容，可以返回JSON错误
+	export, err := pc.pumlService.ExportPUML(c.Request.Context(), user.UserID, &req)
 	if err != nil {
 		log.ErrorfId(c, "ExportPUML: PUML导出失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
+		statusCode := pumlErrorStatus(err)
+		c.JSON(statusCode, gin.H{
 			"success": false,
 			"error":   err.Error(),
-			"code":    http.StatusInternalServerError,
+			"code":    statusCode,
 		})
 		return
 	}
 
-	log.InfofId(c, "ExportPUML: PUML导出成功")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": export.Filename}))
+	c.Header("Content-Type", export.ContentType)
+	c.Status(http.StatusOK)
+	if err := export.Write(c.Request.Context(), c.Writer); err != nil {
+		log.ErrorfId(c, "ExportPUML: 写出导出文件失败: %v", err)
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    result,
-		"message": "PUML导出成功",
-		"code":    http.StatusOK,
-	})
+	log.InfofId(c, "ExportPUML: PUML导出成功: %s", export.Filename)
 }
 
 // GetPUMLStats 获取PUML统计信息
@@ -1,11 +1,17 @@
 package controller
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	"ai-dev-platform/internal/log"
 	"ai-dev-platform/internal/model"
 	"ai-dev-platform/internal/service"
+	"ai-dev-platform/internal/storymap"
+	"ai-dev-platform/internal/traceability"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -165,4 +171,739 @@ func (sc *SpecController) DeleteSpec(c *gin.Context) {
 		"code":    http.StatusNotImplemented,
 		"message": "功能开发中，敬请期待",
 	})
-}
\ No newline at end of file
+}
+
+// LintRequirements 对需求文档的功能需求进行EARS检查
+func (sc *SpecController) LintRequirements(c *gin.Context) {
+	projectID, user, ok := sc.projectRequest(c)
+	if !ok {
+		return
+	}
+
+	requirementsID, err := uuid.Parse(c.Param("requirementsId"))
+	if err != nil {
+		log.ErrorfId(c, "Invalid requirements ID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid requirements ID",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	result, err := sc.specService.LintRequirementsDoc(c.Request.Context(), projectID, user.UserID, requirementsID)
+	if err != nil {
+		log.ErrorfId(c, "Failed to lint requirements: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	log.InfofId(c, "Linted requirements document %s: %d sentences, %d errors, %d warnings", requirementsID, result.Total, result.ErrorCount, result.WarningCount)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+		"message": "EARS lint completed",
+	})
+}
+
+// GetTraceability 获取项目的需求追踪矩阵，format 可选 json（默认）、csv、markdown
+func (sc *SpecController) GetTraceability(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("projectId"))
+	if err != nil {
+		log.ErrorfId(c, "Invalid project ID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid project ID",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.ErrorfId(c, "User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+			"code":    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" && format != "markdown" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Unsupported format, expected json, csv or markdown",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if sc.specService == nil {
+		log.ErrorfId(c, "SpecService not initialized")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Service not available",
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	matrix, err := sc.specService.GetTraceabilityMatrix(c.Request.Context(), projectID, user.UserID)
+	if err != nil {
+		log.ErrorfId(c, "Failed to build traceability matrix: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	log.InfofId(c, "Built traceability matrix for project %s: %d rows, end-to-end coverage %.2f%%", projectID, len(matrix.Rows), matrix.Coverage.RequirementsEndToEnd.Percent)
+
+	switch format {
+	case "csv":
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=traceability-%s.csv", projectID))
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := traceability.WriteCSV(c.Writer, matrix); err != nil {
+			log.ErrorfId(c, "Failed to write traceability CSV: %v", err)
+		}
+	case "markdown":
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=traceability-%s.md", projectID))
+		c.Header("Content-Type", "text/markdown; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := traceability.WriteMarkdown(c.Writer, matrix); err != nil {
+			log.ErrorfId(c, "Failed to write traceability markdown: %v", err)
+		}
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    matrix,
+			"message": "Traceability matrix built successfully",
+		})
+	}
+}
+
+// PreviewImport 预览外部需求导入：返回解析出的条目、字段映射结果和重复标记
+func (sc *SpecController) PreviewImport(c *gin.Context) {
+	sc.handleImport(c, true)
+}
+
+// ImportRequirements 导入外部需求，批量创建需求文档和用户故事
+func (sc *SpecController) ImportRequirements(c *gin.Context) {
+	sc.handleImport(c, false)
+}
+
+// handleImport 解析导入请求：支持 JSON 请求体或 multipart 上传（file 字段，其余字段同 JSON）
+func (sc *SpecController) handleImport(c *gin.Context, preview bool) {
+	projectID, err := uuid.Parse(c.Param("projectId"))
+	if err != nil {
+		log.ErrorfId(c, "Invalid project ID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid project ID",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.ErrorfId(c, "User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+			"code":    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	req, err := bindImportRequest(c)
+	if err != nil {
+		log.ErrorfId(c, "Invalid import request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request: " + err.Error(),
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if sc.specService == nil {
+		log.ErrorfId(c, "SpecService not initialized")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Service not available",
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	if preview {
+		result, err := sc.specService.PreviewImport(c.Request.Context(), projectID, user.UserID, req)
+		if err != nil {
+			log.ErrorfId(c, "Failed to preview import: %v", err)
+			statusCode := importErrorStatus(err)
+			c.JSON(statusCode, gin.H{
+				"success": false,
+				"error":   err.Error(),
+				"code":    statusCode,
+			})
+			return
+		}
+
+		log.InfofId(c, "Previewed %s import for project %s: %d items, %d duplicates", result.Format, projectID, result.Total, result.Duplicates)
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    result,
+			"message": "Import preview generated",
+		})
+		return
+	}
+
+	result, err := sc.specService.ImportRequirements(c.Request.Context(), projectID, user.UserID, req)
+	if err != nil {
+		log.ErrorfId(c, "Failed to import requirements: %v", err)
+		statusCode := importErrorStatus(err)
+		c.JSON(statusCode, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    statusCode,
+		})
+		return
+	}
+
+	log.InfofId(c, "Imported %d user stories into requirements document %s, skipped %d", len(result.Stories), result.RequirementsDoc.ID, len(result.Skipped))
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    result,
+		"message": "Requirements imported successfully",
+	})
+}
+
+// importErrorStatus 导入错误对应的状态码：内容解析和校验错误为400，读写数据库失败为500
+func importErrorStatus(err error) int {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "无权访问"):
+		return http.StatusForbidden
+	case strings.HasPrefix(msg, "项目不存在"):
+		return http.StatusNotFound
+	case strings.HasPrefix(msg, "查询"), strings.HasPrefix(msg, "保存"):
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// bindImportRequest 从 JSON 请求体或 multipart 表单读取导入请求
+func bindImportRequest(c *gin.Context) (*model.ImportRequirementsRequest, error) {
+	var req model.ImportRequirementsRequest
+	if c.ContentType() != "multipart/form-data" {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, err
+		}
+	} else {
+		file, err := c.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("missing file: %w", err)
+		}
+		f, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		content, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Content = string(content)
+		req.FileName = file.Filename
+		req.Format = c.PostForm("format")
+		req.Title = c.PostForm("title")
+		req.Selected = c.PostFormArray("selected")
+		if mapping := c.PostForm("mapping"); mapping != "" {
+			if err := json.Unmarshal([]byte(mapping), &req.Mapping); err != nil {
+				return nil, fmt.Errorf("invalid mapping: %w", err)
+			}
+		}
+	}
+
+	if strings.TrimSpace(req.Content) == "" {
+		return nil, fmt.Errorf("content is required")
+	}
+	return &req, nil
+}
+
+// AnalyzeConsistency 检测项目功能需求和用户故事之间的重复与冲突
+func (sc *SpecController) AnalyzeConsistency(c *gin.Context) {
+	projectID, user, ok := sc.projectRequest(c)
+	if !ok {
+		return
+	}
+
+	// 请求体可省略，全部使用默认值
+	var req model.AnalyzeConsistencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		log.ErrorfId(c, "Invalid consistency request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request: " + err.Error(),
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	result, err := sc.specService.AnalyzeConsistency(c.Request.Context(), projectID, user.UserID, &req)
+	if err != nil {
+		log.ErrorfId(c, "Failed to analyze requirement consistency: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	log.InfofId(c, "Analyzed %d statements for project %s: %d pairs, %d findings created, %d updated", result.Report.Statements, projectID, len(result.Report.Pairs), result.Created, result.Updated)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+		"message": "Consistency analysis completed",
+	})
+}
+
+// ListFindings 获取项目的需求检测结论，可通过 status 过滤
+func (sc *SpecController) ListFindings(c *gin.Context) {
+	projectID, user, ok := sc.projectRequest(c)
+	if !ok {
+		return
+	}
+
+	findings, err := sc.specService.ListFindings(c.Request.Context(), projectID, user.UserID, c.Query("status"))
+	if err != nil {
+		log.ErrorfId(c, "Failed to list findings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    findings,
+		"message": "Findings retrieved successfully",
+	})
+}
+
+// UpdateFinding 将需求检测结论标记为 open、resolved 或 ignored
+func (sc *SpecController) UpdateFinding(c *gin.Context) {
+	projectID, user, ok := sc.projectRequest(c)
+	if !ok {
+		return
+	}
+
+	findingID, err := uuid.Parse(c.Param("findingId"))
+	if err != nil {
+		log.ErrorfId(c, "Invalid finding ID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid finding ID",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req model.UpdateFindingRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Status == "" {
+		log.ErrorfId(c, "Invalid finding update request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request: status is required",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	finding, err := sc.specService.UpdateFinding(c.Request.Context(), projectID, user.UserID, findingID, &req)
+	if err != nil {
+		log.ErrorfId(c, "Failed to update finding: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	log.InfofId(c, "Finding %s marked as %s", findingID, finding.Status)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    finding,
+		"message": "Finding updated successfully",
+	})
+}
+
+// GetImpact 获取因上游变更而过期的产物及原因
+func (sc *SpecController) GetImpact(c *gin.Context) {
+	projectID, user, ok := sc.projectRequest(c)
+	if !ok {
+		return
+	}
+
+	report, err := sc.specService.GetImpactReport(c.Request.Context(), projectID, user.UserID)
+	if err != nil {
+		log.ErrorfId(c, "Failed to build impact report: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+		"message": "Impact report retrieved successfully",
+	})
+}
+
+// ReportUpstreamChange 登记上游产物变更，将其下游产物标记为过期
+func (sc *SpecController) ReportUpstreamChange(c *gin.Context) {
+	projectID, user, ok := sc.projectRequest(c)
+	if !ok {
+		return
+	}
+
+	var req model.ReportUpstreamChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Upstream.Type == "" || req.Upstream.ID == "" {
+		log.ErrorfId(c, "Invalid upstream change request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request: upstream type and id are required",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	report, err := sc.specService.ReportUpstreamChange(c.Request.Context(), projectID, user.UserID, &req)
+	if err != nil {
+		log.ErrorfId(c, "Failed to report upstream change: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	log.InfofId(c, "Upstream %s %s changed in project %s, %d artifacts stale", req.Upstream.Type, req.Upstream.ID, projectID, report.Total)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+		"message": "Upstream change recorded",
+	})
+}
+
+// GetPriorities 获取按优先级排序的用户故事待办列表，可通过 method（rice、wsjf、moscow）和 requirements_id 过滤
+func (sc *SpecController) GetPriorities(c *gin.Context) {
+	projectID, user, ok := sc.projectRequest(c)
+	if !ok {
+		return
+	}
+
+	requirementsID := uuid.Nil
+	if value := c.Query("requirements_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			log.ErrorfId(c, "Invalid requirements ID: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid requirements ID",
+				"code":    http.StatusBadRequest,
+			})
+			return
+		}
+		requirementsID = id
+	}
+
+	backlog, err := sc.specService.GetPrioritizedBacklog(c.Request.Context(), projectID, user.UserID, c.Query("method"), requirementsID)
+	if err != nil {
+		log.ErrorfId(c, "Failed to rank user stories: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    backlog,
+		"message": "Prioritized backlog retrieved successfully",
+	})
+}
+
+// UpdateStoryPriority 设置用户故事的 MoSCoW 分组和 RICE、WSJF 评估输入
+func (sc *SpecController) UpdateStoryPriority(c *gin.Context) {
+	projectID, user, storyID, ok := sc.storyRequest(c)
+	if !ok {
+		return
+	}
+
+	var req model.StoryPriorityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.ErrorfId(c, "Invalid story priority request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request: " + err.Error(),
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	priority, err := sc.specService.UpdateStoryPriority(c.Request.Context(), projectID, user.UserID, storyID, &req)
+	if err != nil {
+		log.ErrorfId(c, "Failed to update story priority: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    priority,
+		"message": "Story priority updated successfully",
+	})
+}
+
+// SuggestPriorities 由AI为尚未评估的用户故事给出初始评估值
+func (sc *SpecController) SuggestPriorities(c *gin.Context) {
+	projectID, user, ok := sc.projectRequest(c)
+	if !ok {
+		return
+	}
+
+	// 请求体可省略，全部使用默认值
+	var req model.SuggestPrioritiesRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		log.ErrorfId(c, "Invalid priority suggestion request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request: " + err.Error(),
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	result, err := sc.specService.SuggestPriorities(c.Request.Context(), projectID, user.UserID, &req)
+	if err != nil {
+		log.ErrorfId(c, "Failed to suggest priorities: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	log.InfofId(c, "AI suggested priorities for %d user stories in project %s", result.Suggested, projectID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+		"message": "Priorities suggested successfully",
+	})
+}
+
+// GetStoryPriorityHistory 获取用户故事的优先级变更历史
+func (sc *SpecController) GetStoryPriorityHistory(c *gin.Context) {
+	projectID, user, storyID, ok := sc.storyRequest(c)
+	if !ok {
+		return
+	}
+
+	changes, err := sc.specService.GetStoryPriorityHistory(c.Request.Context(), projectID, user.UserID, storyID)
+	if err != nil {
+		log.ErrorfId(c, "Failed to get story priority history: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    changes,
+		"message": "Story priority history retrieved successfully",
+	})
+}
+
+// SplitStory 按拆分模式（workflow_steps、business_rules、data_variations、crud）把用户故事拆成子故事
+func (sc *SpecController) SplitStory(c *gin.Context) {
+	projectID, user, storyID, ok := sc.storyRequest(c)
+	if !ok {
+		return
+	}
+
+	var req model.SplitStoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.ErrorfId(c, "Invalid split story request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request: " + err.Error(),
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	result, err := sc.specService.SplitStory(c.Request.Context(), projectID, user.UserID, storyID, &req)
+	if err != nil {
+		log.ErrorfId(c, "Failed to split user story: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	log.InfofId(c, "Split user story %s into %d stories by %s (saved: %v)", storyID, len(result.Stories), result.Pattern, result.Saved)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+		"message": "User story split successfully",
+	})
+}
+
+// GetStoryMap 获取用户故事地图，支持 format=json|markdown 导出，可通过 requirements_id 过滤
+func (sc *SpecController) GetStoryMap(c *gin.Context) {
+	projectID, user, ok := sc.projectRequest(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "markdown" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Unsupported format, expected json or markdown",
+			"code":    http.StatusBadRequest,
+		})
+		return
+	}
+
+	requirementsID := uuid.Nil
+	if value := c.Query("requirements_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			log.ErrorfId(c, "Invalid requirements ID: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid requirements ID",
+				"code":    http.StatusBadRequest,
+			})
+			return
+		}
+		requirementsID = id
+	}
+
+	storyMap, err := sc.specService.GetStoryMap(c.Request.Context(), projectID, user.UserID, requirementsID)
+	if err != nil {
+		log.ErrorfId(c, "Failed to build story map: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	if format == "markdown" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=story-map-%s.md", projectID))
+		c.Header("Content-Type", "text/markdown; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := storymap.WriteMarkdown(c.Writer, storyMap); err != nil {
+			log.ErrorfId(c, "Failed to write story map markdown: %v", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    storyMap,
+		"message": "Story map built successfully",
+	})
+}
+
+// storyRequest 在 projectRequest 的基础上解析用户故事ID，失败时已写入响应
+func (sc *SpecController) storyRequest(c *gin.Context) (uuid.UUID, *model.User, uuid.UUID, bool) {
+	projectID, user, ok := sc.projectRequest(c)
+	if !ok {
+		return uuid.Nil, nil, uuid.Nil, false
+	}
+
+	storyID, err := uuid.Parse(c.Param("storyId"))
+	if err != nil {
+		log.ErrorfId(c, "Invalid story ID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid story ID",
+			"code":    http.StatusBadRequest,
+		})
+		return uuid.Nil, nil, uuid.Nil, false
+	}
+	return projectID, user, storyID, true
+}
+
+// projectRequest 解析项目ID和当前用户并检查服务是否可用，失败时已写入响应
+func (sc *SpecController) projectRequest(c *gin.Context) (uuid.UUID, *model.User, bool) {
+	projectID, err := uuid.Parse(c.Param("projectId"))
+	if err != nil {
+		log.ErrorfId(c, "Invalid project ID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid project ID",
+			"code":    http.StatusBadRequest,
+		})
+		return uuid.Nil, nil, false
+	}
+
+	user, ok := ginUserFromContext(c)
+	if !ok {
+		log.ErrorfId(c, "User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+			"code":    http.StatusUnauthorized,
+		})
+		return uuid.Nil, nil, false
+	}
+
+	if sc.specService == nil {
+		log.ErrorfId(c, "SpecService not initialized")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Service not available",
+			"code":    http.StatusInternalServerError,
+		})
+		return uuid.Nil, nil, false
+	}
+
+	return projectID, user, true
+}
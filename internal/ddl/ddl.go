@@ -0,0 +1,411 @@
+// Package ddl 由开发文档中的数据库设计生成 MySQL、PostgreSQL、SQLite 建表语句，
+// 检查主键缺失、索引键过长等问题，生成两个设计版本之间的迁移脚本，
+// 并能从现有数据库的结构导出中反向解析出数据库设计，不依赖AI调用
+package ddl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"ai-dev-platform/internal/ai"
+)
+
+// Dialect SQL方言
+type Dialect string
+
+const (
+	MySQL      Dialect = "mysql"
+	PostgreSQL Dialect = "postgresql"
+	SQLite     Dialect = "sqlite"
+)
+
+// Dialects 支持的方言
+var Dialects = []Dialect{MySQL, PostgreSQL, SQLite}
+
+// ErrEmptyDesign 数据库设计中没有表
+var ErrEmptyDesign = errors.New("数据库设计中没有表")
+
+// ParseDialect 解析方言名称，缺省为 MySQL
+func ParseDialect(name string) (Dialect, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "mysql", "mariadb":
+		return MySQL, nil
+	case "postgresql", "postgres", "pg", "pgsql":
+		return PostgreSQL, nil
+	case "sqlite", "sqlite3":
+		return SQLite, nil
+	}
+	return "", fmt.Errorf("不支持的SQL方言: %s", name)
+}
+
+// String 方言的显示名称
+func (d Dialect) String() string {
+	switch d {
+	case MySQL:
+		return "MySQL"
+	case PostgreSQL:
+		return "PostgreSQL"
+	case SQLite:
+		return "SQLite"
+	}
+	return string(d)
+}
+
+// Script 生成的SQL脚本及检查结果
+type Script struct {
+	Dialect  Dialect   `json:"dialect"`
+	SQL      string    `json:"sql"`
+	Findings []Finding `json:"findings"`
+}
+
+// Generate 生成建表、索引和外键语句；引用不存在的表或列的外键和索引不生成，只在检查结果中报告
+func Generate(design *ai.DatabaseDesign, dialect Dialect) (*Script, error) {
+	if design == nil || len(design.Tables) == 0 {
+		return nil, ErrEmptyDesign
+	}
+	s := newSchema(design)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- 数据库：%s\n", dialect)
+	if dialect == SQLite {
+		b.WriteString("PRAGMA foreign_keys = ON;\n")
+	}
+	for _, t := range s.tables {
+		b.WriteString("\n")
+		b.WriteString(s.createTable(t, dialect, t.Name))
+		for _, idx := range s.indexes[t.Name] {
+			b.WriteString(createIndex(idx, dialect))
+		}
+	}
+	if dialect != SQLite {
+		var fks []string
+		for _, rel := range s.relations {
+			fks = append(fks, addForeignKey(rel, dialect))
+		}
+		if len(fks) > 0 {
+			b.WriteString("\n")
+			b.WriteString(strings.Join(fks, ""))
+		}
+	}
+
+	return &Script{Dialect: dialect, SQL: b.String(), Findings: Lint(design, dialect)}, nil
+}
+
+// schema 整理后的数据库设计：索引和外键按表归类，补全缺省名称，去掉引用不存在的表或列的项
+type schema struct {
+	tables    []*ai.TableDesign
+	byName    map[string]*ai.TableDesign
+	indexes   map[string][]ai.IndexDesign
+	relations []ai.RelationDesign
+	outgoing  map[string][]ai.RelationDesign
+}
+
+func newSchema(design *ai.DatabaseDesign) *schema {
+	s := &schema{
+		byName:   make(map[string]*ai.TableDesign),
+		indexes:  make(map[string][]ai.IndexDesign),
+		outgoing: make(map[string][]ai.RelationDesign),
+	}
+	for i := range design.Tables {
+		t := &design.Tables[i]
+		if _, dup := s.byName[t.Name]; dup || t.Name == "" {
+			continue
+		}
+		s.tables = append(s.tables, t)
+		s.byName[t.Name] = t
+	}
+	for _, idx := range design.Indexes {
+		t := s.byName[idx.Table]
+		if t == nil || len(idx.Columns) == 0 || !hasColumns(t, idx.Columns...) {
+			continue
+		}
+		if idx.Name == "" {
+			idx.Name = indexName(idx)
+		}
+		s.indexes[idx.Table] = append(s.indexes[idx.Table], idx)
+	}
+	for _, rel := range design.Relations {
+		rel, ok := s.resolveRelation(rel)
+		if !ok {
+			continue
+		}
+		s.relations = append(s.relations, rel)
+		s.outgoing[rel.FromTable] = append(s.outgoing[rel.FromTable], rel)
+	}
+	return s
+}
+
+// resolveRelation 补全外键名称和被引用列（缺省为被引用表的单列主键）
+func (s *schema) resolveRelation(rel ai.RelationDesign) (ai.RelationDesign, bool) {
+	from, to := s.byName[rel.FromTable], s.byName[rel.ToTable]
+	if from == nil || to == nil || !hasColumns(from, rel.FromColumn) {
+		return rel, false
+	}
+	if rel.ToColumn == "" {
+		pk := primaryKey(to)
+		if len(pk) != 1 {
+			return rel, false
+		}
+		rel.ToColumn = pk[0]
+	}
+	if !hasColumns(to, rel.ToColumn) {
+		return rel, false
+	}
+	if rel.Name == "" {
+		rel.Name = "fk_" + rel.FromTable + "_" + rel.FromColumn
+	}
+	return rel, true
+}
+
+// createTable 生成建表语句，name 为实际建表使用的表名（SQLite 重建表时使用临时表名）
+func (s *schema) createTable(t *ai.TableDesign, d Dialect, name string) string {
+	pk := primaryKey(t)
+	// SQLite 单列自增主键必须写成 INTEGER PRIMARY KEY AUTOINCREMENT
+	inlinePK := d == SQLite && len(pk) == 1 && column(t, pk[0]).AutoIncrement
+
+	type item struct{ def, comment string }
+	var items []item
+	for _, c := range t.Columns {
+		def := columnDefinition(c, d)
+		if inlinePK && c.Name == pk[0] {
+			def = quote(c.Name, d) + " INTEGER PRIMARY KEY AUTOINCREMENT"
+		}
+		comment := ""
+		if d == SQLite {
+			comment = oneLine(c.Comment)
+		}
+		items = append(items, item{def: def, comment: comment})
+	}
+	if len(pk) > 0 && !inlinePK {
+		items = append(items, item{def: "PRIMARY KEY (" + quoteList(pk, d) + ")"})
+	}
+	if d == SQLite {
+		for _, rel := range s.outgoing[t.Name] {
+			items = append(items, item{def: "CONSTRAINT " + quote(rel.Name, d) + " " + foreignKeyClause(rel, d)})
+		}
+	}
+
+	var b strings.Builder
+	if d == SQLite && t.Comment != "" {
+		fmt.Fprintf(&b, "-- %s\n", oneLine(t.Comment))
+	}
+	fmt.Fprintf(&b, "CREATE TABLE %s (\n", quote(name, d))
+	for i, it := range items {
+		b.WriteString("  " + it.def)
+		if i < len(items)-1 {
+			b.WriteString(",")
+		}
+		if it.comment != "" {
+			b.WriteString(" -- " + it.comment)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(")")
+	if d == MySQL {
+		b.WriteString(" ENGINE=InnoDB DEFAULT CHARSET=utf8mb4")
+		if t.Comment != "" {
+			b.WriteString(" COMMENT=" + literal(t.Comment, d))
+		}
+	}
+	b.WriteString(";\n")
+
+	if d == PostgreSQL {
+		if t.Comment != "" {
+			fmt.Fprintf(&b, "COMMENT ON TABLE %s IS %s;\n", quote(name, d), literal(t.Comment, d))
+		}
+		for _, c := range t.Columns {
+			if c.Comment != "" {
+				fmt.Fprintf(&b, "COMMENT ON COLUMN %s.%s IS %s;\n", quote(name, d), quote(c.Name, d), literal(c.Comment, d))
+			}
+		}
+	}
+	return b.String()
+}
+
+// columnDefinition 列定义，不含主键约束
+func columnDefinition(c ai.ColumnDesign, d Dialect) string {
+	t := parseType(c)
+	parts := []string{quote(c.Name, d), t.render(d)}
+	if !c.Nullable || c.PrimaryKey {
+		parts = append(parts, "NOT NULL")
+	}
+	if c.Default != "" {
+		parts = append(parts, "DEFAULT "+defaultValue(c.Default, d))
+	}
+	if c.AutoIncrement || t.serial {
+		switch d {
+		case MySQL:
+			parts = append(parts, "AUTO_INCREMENT")
+		case PostgreSQL:
+			parts = append(parts, "GENERATED BY DEFAULT AS IDENTITY")
+		}
+	}
+	if d == MySQL && c.Comment != "" {
+		parts = append(parts, "COMMENT "+literal(c.Comment, d))
+	}
+	return strings.Join(parts, " ")
+}
+
+func createIndex(idx ai.IndexDesign, d Dialect) string {
+	unique := ""
+	if idx.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s);\n", unique, quote(idx.Name, d), quote(idx.Table, d), quoteList(idx.Columns, d))
+}
+
+func dropIndex(idx ai.IndexDesign, d Dialect) string {
+	if d == MySQL {
+		return fmt.Sprintf("DROP INDEX %s ON %s;\n", quote(idx.Name, d), quote(idx.Table, d))
+	}
+	return fmt.Sprintf("DROP INDEX %s;\n", quote(idx.Name, d))
+}
+
+func addForeignKey(rel ai.RelationDesign, d Dialect) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s;\n", quote(rel.FromTable, d), quote(rel.Name, d), foreignKeyClause(rel, d))
+}
+
+func dropForeignKey(rel ai.RelationDesign, d Dialect) string {
+	if d == MySQL {
+		return fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s;\n", quote(rel.FromTable, d), quote(rel.Name, d))
+	}
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;\n", quote(rel.FromTable, d), quote(rel.Name, d))
+}
+
+func foreignKeyClause(rel ai.RelationDesign, d Dialect) string {
+	clause := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)", quote(rel.FromColumn, d), quote(rel.ToTable, d), quote(rel.ToColumn, d))
+	if action := referentialAction(rel.OnDelete); action != "" {
+		clause += " ON DELETE " + action
+	}
+	if action := referentialAction(rel.OnUpdate); action != "" {
+		clause += " ON UPDATE " + action
+	}
+	return clause
+}
+
+// referentialAction 规范化级联规则，无法识别时返回空（使用数据库默认行为）
+func referentialAction(action string) string {
+	action = strings.ToUpper(strings.Join(strings.Fields(strings.ReplaceAll(action, "_", " ")), " "))
+	switch action {
+	case "CASCADE", "SET NULL", "SET DEFAULT", "RESTRICT", "NO ACTION":
+		return action
+	}
+	return ""
+}
+
+// indexName 未命名索引的缺省名称
+func indexName(idx ai.IndexDesign) string {
+	prefix := "idx_"
+	if idx.Unique {
+		prefix = "uk_"
+	}
+	return prefix + idx.Table + "_" + strings.Join(idx.Columns, "_")
+}
+
+// quote 按方言给标识符加引号
+func quote(name string, d Dialect) string {
+	if d == MySQL {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteList(names []string, d Dialect) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quote(name, d)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// literal 字符串字面量；MySQL 默认把反斜杠当作转义符
+func literal(s string, d Dialect) string {
+	s = strings.ReplaceAll(s, "'", "''")
+	if d == MySQL {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return "'" + s + "'"
+}
+
+// defaultValue 默认值：数字、布尔、NULL、当前时间和函数调用原样输出，已加引号的保持不变，其余作为字符串
+func defaultValue(value string, d Dialect) string {
+	v := strings.TrimSpace(value)
+	upper := strings.ToUpper(v)
+	switch {
+	case upper == "NULL", upper == "TRUE", upper == "FALSE",
+		upper == "CURRENT_TIMESTAMP", upper == "CURRENT_DATE", upper == "CURRENT_TIME":
+		return upper
+	case len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'':
+		return v
+	case isNumber(v):
+		return v
+	case strings.HasSuffix(v, ")") && strings.Contains(v, "(") && isIdentifier(v[:strings.Index(v, "(")]):
+		if d == SQLite {
+			return "(" + v + ")"
+		}
+		return v
+	}
+	return literal(v, d)
+}
+
+func isNumber(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	if s == "" || s == "." {
+		return false
+	}
+	dot := false
+	for _, r := range s {
+		switch {
+		case r == '.' && !dot:
+			dot = true
+		case r < '0' || r > '9':
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func primaryKey(t *ai.TableDesign) []string {
+	var pk []string
+	for _, c := range t.Columns {
+		if c.PrimaryKey {
+			pk = append(pk, c.Name)
+		}
+	}
+	return pk
+}
+
+func column(t *ai.TableDesign, name string) *ai.ColumnDesign {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+func hasColumns(t *ai.TableDesign, names ...string) bool {
+	for _, name := range names {
+		if column(t, name) == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,342 @@
+package ddl
+
+import (
+	"testing"
+
+	"ai-dev-platform/internal/ai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleDesign() *ai.DatabaseDesign {
+	return &ai.DatabaseDesign{
+		Tables: []ai.TableDesign{
+			{Name: "users", Comment: "用户", Columns: []ai.ColumnDesign{
+				{Name: "id", Type: "bigint", PrimaryKey: true, AutoIncrement: true},
+				{Name: "email", Type: "varchar", Length: 100, Comment: "邮箱"},
+				{Name: "is_active", Type: "boolean", Default: "true"},
+				{Name: "created_at", Type: "datetime", Default: "CURRENT_TIMESTAMP"},
+			}},
+			{Name: "orders", Columns: []ai.ColumnDesign{
+				{Name: "id", Type: "bigint", PrimaryKey: true, AutoIncrement: true},
+				{Name: "user_id", Type: "bigint", Nullable: true},
+				{Name: "amount", Type: "decimal(10,2)", Default: "0"},
+				{Name: "status", Type: "varchar(20)", Default: "pending"},
+			}},
+		},
+		Indexes: []ai.IndexDesign{
+			{Name: "uk_users_email", Table: "users", Columns: []string{"email"}, Unique: true},
+			{Table: "orders", Columns: []string{"user_id", "status"}},
+		},
+		Relations: []ai.RelationDesign{
+			{FromTable: "orders", FromColumn: "user_id", ToTable: "users", OnDelete: "set_null", OnUpdate: "cascade"},
+		},
+	}
+}
+
+func TestGenerate_MySQL(t *testing.T) {
+	script, err := Generate(sampleDesign(), MySQL)
+	require.NoError(t, err)
+	assert.Equal(t, "-- 数据库：MySQL\n"+
+		"\n"+
+		"CREATE TABLE `users` (\n"+
+		"  `id` BIGINT NOT NULL AUTO_INCREMENT,\n"+
+		"  `email` VARCHAR(100) NOT NULL COMMENT '邮箱',\n"+
+		"  `is_active` TINYINT(1) NOT NULL DEFAULT TRUE,\n"+
+		"  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,\n"+
+		"  PRIMARY KEY (`id`)\n"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户';\n"+
+		"CREATE UNIQUE INDEX `uk_users_email` ON `users` (`email`);\n"+
+		"\n"+
+		"CREATE TABLE `orders` (\n"+
+		"  `id` BIGINT NOT NULL AUTO_INCREMENT,\n"+
+		"  `user_id` BIGINT,\n"+
+		"  `amount` DECIMAL(10,2) NOT NULL DEFAULT 0,\n"+
+		"  `status` VARCHAR(20) NOT NULL DEFAULT 'pending',\n"+
+		"  PRIMARY KEY (`id`)\n"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n"+
+		"CREATE INDEX `idx_orders_user_id_status` ON `orders` (`user_id`, `status`);\n"+
+		"\n"+
+		"ALTER TABLE `orders` ADD CONSTRAINT `fk_orders_user_id` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE SET NULL ON UPDATE CASCADE;\n",
+		script.SQL)
+	assert.Empty(t, script.Findings)
+}
+
+func TestGenerate_PostgreSQL(t *testing.T) {
+	script, err := Generate(sampleDesign(), PostgreSQL)
+	require.NoError(t, err)
+	assert.Contains(t, script.SQL, `  "id" BIGINT NOT NULL GENERATED BY DEFAULT AS IDENTITY,`+"\n")
+	assert.Contains(t, script.SQL, `  "is_active" BOOLEAN NOT NULL DEFAULT TRUE,`+"\n")
+	assert.Contains(t, script.SQL, `  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,`+"\n")
+	assert.Contains(t, script.SQL, `COMMENT ON TABLE "users" IS '用户';`+"\n"+`COMMENT ON COLUMN "users"."email" IS '邮箱';`+"\n")
+	assert.Contains(t, script.SQL, `ALTER TABLE "orders" ADD CONSTRAINT "fk_orders_user_id" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE SET NULL ON UPDATE CASCADE;`)
+	// 复合索引以外键列开头，外键列视为有索引
+	assert.Empty(t, script.Findings)
+}
+
+func TestGenerate_SQLite(t *testing.T) {
+	script, err := Generate(sampleDesign(), SQLite)
+	require.NoError(t, err)
+	assert.Contains(t, script.SQL, "PRAGMA foreign_keys = ON;\n")
+	assert.Contains(t, script.SQL, "-- 用户\nCREATE TABLE \"users\" (\n"+
+		"  \"id\" INTEGER PRIMARY KEY AUTOINCREMENT,\n"+
+		"  \"email\" TEXT NOT NULL, -- 邮箱\n"+
+		"  \"is_active\" INTEGER NOT NULL DEFAULT TRUE,\n")
+	assert.Contains(t, script.SQL, "  \"status\" TEXT NOT NULL DEFAULT 'pending',\n"+
+		"  CONSTRAINT \"fk_orders_user_id\" FOREIGN KEY (\"user_id\") REFERENCES \"users\" (\"id\") ON DELETE SET NULL ON UPDATE CASCADE\n);\n")
+	assert.NotContains(t, script.SQL, "ALTER TABLE")
+}
+
+func TestGenerate_QuotingAndTypes(t *testing.T) {
+	design := &ai.DatabaseDesign{Tables: []ai.TableDesign{{Name: "order", Comment: "it's", Columns: []ai.ColumnDesign{
+		{Name: "id", Type: "uuid", PrimaryKey: true},
+		{Name: "kind", Type: "enum('A','b')", Nullable: true},
+		{Name: "payload", Type: "jsonb", Nullable: true},
+		{Name: "path", Type: "varchar(20)", Default: `C:\tmp`},
+		{Name: "hits", Type: "int unsigned", Default: "0"},
+		{Name: "weird`name", Type: "geometry", Nullable: true},
+	}}}}
+
+	script, err := Generate(design, MySQL)
+	require.NoError(t, err)
+	assert.Contains(t, script.SQL, "  `id` CHAR(36) NOT NULL,\n")
+	assert.Contains(t, script.SQL, "  `kind` ENUM('A','b'),\n")
+	assert.Contains(t, script.SQL, "  `payload` JSON,\n")
+	assert.Contains(t, script.SQL, "  `path` VARCHAR(20) NOT NULL DEFAULT 'C:\\\\tmp',\n")
+	assert.Contains(t, script.SQL, "  `hits` INT UNSIGNED NOT NULL DEFAULT 0,\n")
+	assert.Contains(t, script.SQL, "  `weird``name` GEOMETRY,\n")
+	assert.Contains(t, script.SQL, "COMMENT='it''s';\n")
+	require.Len(t, script.Findings, 1)
+	assert.Equal(t, RuleUnknownType, script.Findings[0].Rule)
+
+	script, err = Generate(design, PostgreSQL)
+	require.NoError(t, err)
+	assert.Contains(t, script.SQL, `  "id" UUID NOT NULL,`)
+	assert.Contains(t, script.SQL, `  "kind" VARCHAR(255),`)
+	assert.Contains(t, script.SQL, `  "path" VARCHAR(20) NOT NULL DEFAULT 'C:\tmp',`)
+	assert.Contains(t, script.SQL, `  "hits" BIGINT NOT NULL DEFAULT 0,`)
+
+	_, err = Generate(&ai.DatabaseDesign{}, MySQL)
+	assert.ErrorIs(t, err, ErrEmptyDesign)
+}
+
+func TestGenerate_NonASCIIEnum(t *testing.T) {
+	design := &ai.DatabaseDesign{Tables: []ai.TableDesign{{Name: "places", Columns: []ai.ColumnDesign{
+		{Name: "id", Type: "bigint", PrimaryKey: true},
+		{Name: "city", Type: "ENUM('İstanbul','İzmir')", Nullable: true},
+		{Name: "code", Type: "ENUM('ȺȺ')", Nullable: true},
+	}}}}
+
+	script, err := Generate(design, MySQL)
+	require.NoError(t, err)
+	assert.Contains(t, script.SQL, "  `city` ENUM('İstanbul','İzmir'),\n")
+	assert.Contains(t, script.SQL, "  `code` ENUM('ȺȺ'),\n")
+	assert.Empty(t, Lint(design, MySQL))
+}
+
+func TestLint(t *testing.T) {
+	design := &ai.DatabaseDesign{
+		Tables: []ai.TableDesign{
+			{Name: "logs", Columns: []ai.ColumnDesign{{Name: "message", Type: "text"}}},
+			{Name: "tags", Columns: []ai.ColumnDesign{
+				{Name: "name", Type: "varchar", Length: 1000, PrimaryKey: true},
+				{Name: "owner_id", Type: "int", Nullable: false},
+			}},
+			{Name: "users", Columns: []ai.ColumnDesign{{Name: "id", Type: "bigint", PrimaryKey: true}}},
+		},
+		Indexes: []ai.IndexDesign{{Table: "logs", Columns: []string{"missing"}}},
+		Relations: []ai.RelationDesign{
+			{FromTable: "tags", FromColumn: "owner_id", ToTable: "users", OnDelete: "SET NULL"},
+		},
+	}
+
+	rules := func(findings []Finding) map[string]Severity {
+		got := make(map[string]Severity)
+		for _, f := range findings {
+			got[f.Rule+":"+f.Table+"."+f.Column] = f.Severity
+		}
+		return got
+	}
+
+	assert.Equal(t, map[string]Severity{
+		"missing_primary_key:logs.":      SeverityWarning,
+		"oversized_key:tags.name":        SeverityError,
+		"unknown_reference:logs.missing": SeverityError,
+		"type_mismatch:tags.owner_id":    SeverityWarning,
+		"unsupported:tags.owner_id":      SeverityError,
+	}, rules(Lint(design, MySQL)))
+
+	assert.Equal(t, map[string]Severity{
+		"missing_primary_key:logs.":         SeverityWarning,
+		"oversized_key:tags.name":           SeverityWarning,
+		"unknown_reference:logs.missing":    SeverityError,
+		"type_mismatch:tags.owner_id":       SeverityWarning,
+		"unsupported:tags.owner_id":         SeverityError,
+		"unindexed_reference:tags.owner_id": SeverityInfo,
+	}, rules(Lint(design, PostgreSQL)))
+
+	// SQLite 不限制索引键长度，整数类型都按 INTEGER 存储
+	assert.Equal(t, map[string]Severity{
+		"missing_primary_key:logs.":         SeverityWarning,
+		"unknown_reference:logs.missing":    SeverityError,
+		"unsupported:tags.owner_id":         SeverityError,
+		"unindexed_reference:tags.owner_id": SeverityInfo,
+	}, rules(Lint(design, SQLite)))
+}
+
+func evolvedDesign() *ai.DatabaseDesign {
+	design := sampleDesign()
+	users := &design.Tables[0]
+	users.Comment = "注册用户"
+	// 修改 email，删除 is_active，新增 nickname
+	users.Columns[1].Length = 200
+	users.Columns = append(users.Columns[:2], users.Columns[3:]...)
+	users.Columns = append(users.Columns, ai.ColumnDesign{Name: "nickname", Type: "varchar(50)", Nullable: true})
+	design.Tables = append(design.Tables, ai.TableDesign{Name: "payments", Columns: []ai.ColumnDesign{
+		{Name: "id", Type: "bigint", PrimaryKey: true},
+		{Name: "order_id", Type: "bigint"},
+	}})
+	design.Indexes = design.Indexes[:1]
+	design.Relations = append(design.Relations, ai.RelationDesign{FromTable: "payments", FromColumn: "order_id", ToTable: "orders", OnDelete: "CASCADE"})
+	return design
+}
+
+func TestMigrate_MySQL(t *testing.T) {
+	migration, err := Migrate(sampleDesign(), evolvedDesign(), MySQL)
+	require.NoError(t, err)
+	assert.Equal(t, "-- 数据库迁移：MySQL\n"+
+		"\n"+
+		"DROP INDEX `idx_orders_user_id_status` ON `orders`;\n"+
+		"\n"+
+		"CREATE TABLE `payments` (\n"+
+		"  `id` BIGINT NOT NULL,\n"+
+		"  `order_id` BIGINT NOT NULL,\n"+
+		"  PRIMARY KEY (`id`)\n"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n"+
+		"\n"+
+		"ALTER TABLE `users` ADD COLUMN `nickname` VARCHAR(50);\n"+
+		"ALTER TABLE `users` MODIFY COLUMN `email` VARCHAR(200) NOT NULL COMMENT '邮箱';\n"+
+		"ALTER TABLE `users` DROP COLUMN `is_active`;\n"+
+		"ALTER TABLE `users` COMMENT = '注册用户';\n"+
+		"\n"+
+		"ALTER TABLE `payments` ADD CONSTRAINT `fk_payments_order_id` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE;\n",
+		migration.SQL)
+
+	assert.Equal(t, []Change{
+		{Kind: ChangeModifyColumn, Table: "users", Name: "email"},
+		{Kind: ChangeAddColumn, Table: "users", Name: "nickname"},
+		{Kind: ChangeDropColumn, Table: "users", Name: "is_active"},
+		{Kind: ChangeTableComment, Table: "users"},
+		{Kind: ChangeCreateTable, Table: "payments"},
+		{Kind: ChangeAddForeignKey, Table: "payments", Name: "fk_payments_order_id"},
+		{Kind: ChangeDropIndex, Table: "orders", Name: "idx_orders_user_id_status"},
+	}, migration.Changes)
+	// email 由 VARCHAR(100) 加长为 VARCHAR(200) 不会丢失数据，只提示删除列
+	require.Len(t, migration.Findings, 1)
+	assert.Equal(t, RuleDestructiveChange, migration.Findings[0].Rule)
+	assert.Equal(t, "is_active", migration.Findings[0].Column)
+}
+
+func TestMigrate_DestructiveTypeChanges(t *testing.T) {
+	tests := []struct {
+		from, to    string
+		destructive bool
+	}{
+		{"varchar(255)", "varchar(320)", false},
+		{"varchar(320)", "varchar(255)", true},
+		{"char(2)", "varchar(10)", false},
+		{"varchar(100)", "text", false},
+		{"text", "varchar(100)", true},
+		{"int", "bigint", false},
+		{"bigint", "int", true},
+		{"int", "int unsigned", true},
+		{"int unsigned", "bigint", false},
+		{"decimal(10,2)", "decimal(12,4)", false},
+		{"decimal(10,2)", "decimal(10,4)", true},
+		{"float", "double", false},
+		{"enum('a','b')", "enum('a','b','c')", false},
+		{"enum('a','b')", "enum('a')", true},
+		{"varchar(20)", "int", true},
+	}
+	for _, tt := range tests {
+		from, to := sampleDesign(), sampleDesign()
+		from.Tables[0].Columns[1].Type, from.Tables[0].Columns[1].Length = tt.from, 0
+		to.Tables[0].Columns[1].Type, to.Tables[0].Columns[1].Length = tt.to, 0
+
+		migration, err := Migrate(from, to, MySQL)
+		require.NoError(t, err)
+		destructive := false
+		for _, f := range migration.Findings {
+			destructive = destructive || f.Rule == RuleDestructiveChange
+		}
+		assert.Equal(t, tt.destructive, destructive, "%s -> %s", tt.from, tt.to)
+	}
+}
+
+func TestMigrate_PostgreSQL(t *testing.T) {
+	from := sampleDesign()
+	to := sampleDesign()
+	to.Tables[1].Columns[1].Nullable = false
+	to.Tables[1].Columns[1].Type = "int"
+	to.Tables[1].Columns[3].Default = ""
+	to.Relations[0].OnDelete = "CASCADE"
+
+	migration, err := Migrate(from, to, PostgreSQL)
+	require.NoError(t, err)
+	assert.Equal(t, `-- 数据库迁移：PostgreSQL
+BEGIN;
+
+ALTER TABLE "orders" DROP CONSTRAINT "fk_orders_user_id";
+
+ALTER TABLE "orders" ALTER COLUMN "user_id" TYPE INTEGER USING "user_id"::INTEGER;
+ALTER TABLE "orders" ALTER COLUMN "user_id" SET NOT NULL;
+ALTER TABLE "orders" ALTER COLUMN "status" DROP DEFAULT;
+
+ALTER TABLE "orders" ADD CONSTRAINT "fk_orders_user_id" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+COMMIT;
+`, migration.SQL)
+
+	same, err := Migrate(from, sampleDesign(), PostgreSQL)
+	require.NoError(t, err)
+	assert.True(t, same.Empty())
+	assert.Equal(t, "-- 数据库迁移：PostgreSQL\n-- 两个版本的数据库设计没有差异\n", same.SQL)
+}
+
+func TestMigrate_SQLiteRebuild(t *testing.T) {
+	migration, err := Migrate(sampleDesign(), evolvedDesign(), SQLite)
+	require.NoError(t, err)
+	assert.Contains(t, migration.Changes, Change{Kind: ChangeRebuildTable, Table: "users"})
+	assert.Equal(t, `-- 数据库迁移：SQLite
+PRAGMA foreign_keys = OFF;
+BEGIN;
+
+DROP INDEX "idx_orders_user_id_status";
+
+CREATE TABLE "payments" (
+  "id" INTEGER NOT NULL,
+  "order_id" INTEGER NOT NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_payments_order_id" FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE
+);
+
+-- 重建表 users
+-- 注册用户
+CREATE TABLE "users__new" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "email" TEXT NOT NULL, -- 邮箱
+  "created_at" TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "nickname" TEXT
+);
+INSERT INTO "users__new" ("id", "email", "created_at") SELECT "id", "email", "created_at" FROM "users";
+DROP TABLE "users";
+ALTER TABLE "users__new" RENAME TO "users";
+
+CREATE UNIQUE INDEX "uk_users_email" ON "users" ("email");
+
+PRAGMA foreign_key_check;
+COMMIT;
+PRAGMA foreign_keys = ON;
+`, migration.SQL)
+}
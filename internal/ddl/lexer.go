@@ -0,0 +1,241 @@
+package ddl
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokWord   tokenKind = iota // 关键字或未加引号的标识符
+	tokQuoted                  // 加引号的标识符，text 不含引号
+	tokString                  // 字符串字面量，text 为去掉转义后的值
+	tokNumber
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// is 是否为指定的关键字（不区分大小写）
+func (t token) is(words ...string) bool {
+	if t.kind != tokWord {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(t.text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t token) punct(p string) bool {
+	return t.kind == tokPunct && t.text == p
+}
+
+// ident 可作为标识符的词法单元
+func (t token) ident() bool {
+	return t.kind == tokWord || t.kind == tokQuoted
+}
+
+// lex 把SQL切分为词法单元，跳过注释；backslash 为真时字符串中的反斜杠是转义符（MySQL）
+func lex(src string, backslash bool) []token {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case c == '-' && i+1 < len(src) && src[i+1] == '-', c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			// 包括 MySQL 的 /*!40101 ... */ 条件注释，其中只有会话设置、触发器等，不含表结构
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				i = len(src)
+			} else {
+				i += end + 4
+			}
+		case c == '\'':
+			text, n := lexString(src[i:], backslash)
+			tokens = append(tokens, token{kind: tokString, text: text})
+			i += n
+		case (c == 'E' || c == 'e') && i+1 < len(src) && src[i+1] == '\'' && !precededByWord(src, i):
+			text, n := lexString(src[i+1:], true)
+			tokens = append(tokens, token{kind: tokString, text: text})
+			i += n + 1
+		case c == '`' || c == '"':
+			text, n := lexQuoted(src[i:], c)
+			tokens = append(tokens, token{kind: tokQuoted, text: text})
+			i += n
+		case c == '$':
+			if tag, ok := dollarTag(src[i:]); ok {
+				end := strings.Index(src[i+len(tag):], tag)
+				if end < 0 {
+					end = len(src) - i - len(tag)
+				}
+				tokens = append(tokens, token{kind: tokString, text: src[i+len(tag) : i+len(tag)+end]})
+				i += len(tag) + end + len(tag)
+				if i > len(src) {
+					i = len(src)
+				}
+				continue
+			}
+			tokens = append(tokens, token{kind: tokPunct, text: "$"})
+			i++
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[i:j]})
+			i = j
+		case c == ':' && i+1 < len(src) && src[i+1] == ':':
+			tokens = append(tokens, token{kind: tokPunct, text: "::"})
+			i += 2
+		case isWordStart(src[i:]):
+			j := i
+			for j < len(src) {
+				r, size := utf8.DecodeRuneInString(src[j:])
+				if r != '_' && r != '$' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				j += size
+			}
+			tokens = append(tokens, token{kind: tokWord, text: src[i:j]})
+			i = j
+		default:
+			_, size := utf8.DecodeRuneInString(src[i:])
+			tokens = append(tokens, token{kind: tokPunct, text: src[i : i+size]})
+			i += size
+		}
+	}
+	return tokens
+}
+
+func isWordStart(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func precededByWord(src string, i int) bool {
+	if i == 0 {
+		return false
+	}
+	r, _ := utf8.DecodeLastRuneInString(src[:i])
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// lexString 读取单引号字符串，返回值和消耗的字节数
+func lexString(s string, backslash bool) (string, int) {
+	var b strings.Builder
+	i := 1
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == '\\' && backslash && i+1 < len(s):
+			switch s[i+1] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '0':
+				b.WriteByte(0)
+			default:
+				b.WriteByte(s[i+1])
+			}
+			i += 2
+		case c == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			b.WriteByte('\'')
+			i += 2
+		case c == '\'':
+			return b.String(), i + 1
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String(), len(s)
+}
+
+// lexQuoted 读取反引号或双引号标识符，引号重复两次表示引号本身
+func lexQuoted(s string, quote byte) (string, int) {
+	var b strings.Builder
+	i := 1
+	for i < len(s) {
+		if s[i] == quote {
+			if i+1 < len(s) && s[i+1] == quote {
+				b.WriteByte(quote)
+				i += 2
+				continue
+			}
+			return b.String(), i + 1
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String(), len(s)
+}
+
+// dollarTag PostgreSQL 的 $$ 或 $tag$ 引用开头
+func dollarTag(s string) (string, bool) {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c == '$' {
+			return s[:i+1], true
+		}
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (i == 1 || c < '0' || c > '9') {
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// splitStatements 按分号切分语句，去掉空语句
+func splitStatements(tokens []token) [][]token {
+	var statements [][]token
+	start := 0
+	for i, t := range tokens {
+		if t.punct(";") {
+			if i > start {
+				statements = append(statements, tokens[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(tokens) {
+		statements = append(statements, tokens[start:])
+	}
+	return statements
+}
+
+// rawText 把词法单元还原为SQL文本，用于默认值、枚举值等
+func rawText(tokens []token) string {
+	var b strings.Builder
+	for i, t := range tokens {
+		if i > 0 && spaced(tokens[i-1]) && spaced(t) {
+			b.WriteByte(' ')
+		}
+		switch t.kind {
+		case tokString:
+			b.WriteString("'" + strings.ReplaceAll(t.text, "'", "''") + "'")
+		default:
+			b.WriteString(t.text)
+		}
+	}
+	return b.String()
+}
+
+func spaced(t token) bool {
+	return t.kind == tokWord || t.kind == tokQuoted || t.kind == tokNumber
+}
@@ -0,0 +1,298 @@
+package ddl
+
+import (
+	"fmt"
+	"strings"
+
+	"ai-dev-platform/internal/ai"
+)
+
+// Severity 问题严重程度
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// 检查规则标识
+const (
+	RuleMissingPrimaryKey  = "missing_primary_key"
+	RuleOversizedKey       = "oversized_key"
+	RuleDuplicateName      = "duplicate_name"
+	RuleUnknownReference   = "unknown_reference"
+	RuleTypeMismatch       = "type_mismatch"
+	RuleUnknownType        = "unknown_type"
+	RuleUnsupported        = "unsupported"
+	RuleIdentifierTooLong  = "identifier_too_long"
+	RuleDestructiveChange  = "destructive_change"
+	RuleUnindexedReference = "unindexed_reference"
+)
+
+// Finding 单条检查结果
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Table    string   `json:"table,omitempty"`
+	Column   string   `json:"column,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// maxKeyBytes 索引键的最大字节数：InnoDB（DYNAMIC 行格式）为 3072，PostgreSQL B-tree 索引项约为 2704；SQLite 不限制
+var maxKeyBytes = map[Dialect]int{MySQL: 3072, PostgreSQL: 2704}
+
+// maxIdentifierLength 标识符的最大长度：MySQL 为 64 个字符，PostgreSQL 为 63 字节
+var maxIdentifierLength = map[Dialect]int{MySQL: 64, PostgreSQL: 63}
+
+// Lint 检查数据库设计在指定方言下的问题
+func Lint(design *ai.DatabaseDesign, dialect Dialect) []Finding {
+	l := &linter{dialect: dialect, findings: []Finding{}}
+	if design == nil {
+		return l.findings
+	}
+
+	tables := make(map[string]*ai.TableDesign)
+	for i := range design.Tables {
+		t := &design.Tables[i]
+		if _, dup := tables[t.Name]; dup {
+			l.add(RuleDuplicateName, SeverityError, t.Name, "", "表 %s 重复定义", t.Name)
+			continue
+		}
+		tables[t.Name] = t
+		l.lintTable(t)
+	}
+
+	indexed := make(map[string]bool) // 表.首列，外键列上是否有索引
+	unique := make(map[string]bool)  // 表.列，单列唯一索引
+	for _, t := range tables {
+		if pk := primaryKey(t); len(pk) > 0 {
+			indexed[t.Name+"."+pk[0]] = true
+		}
+	}
+	indexNames := make(map[string]bool)
+	for _, idx := range design.Indexes {
+		t := tables[idx.Table]
+		if t == nil {
+			l.add(RuleUnknownReference, SeverityError, idx.Table, "", "索引 %s 所在的表 %s 不存在", idx.Name, idx.Table)
+			continue
+		}
+		if len(idx.Columns) == 0 {
+			l.add(RuleUnknownReference, SeverityError, idx.Table, "", "索引 %s 没有指定列", idx.Name)
+			continue
+		}
+		if missing := missingColumns(t, idx.Columns); len(missing) > 0 {
+			l.add(RuleUnknownReference, SeverityError, idx.Table, missing[0], "索引 %s 引用的列 %s 不存在", idx.Name, strings.Join(missing, "、"))
+			continue
+		}
+		name := idx.Name
+		if name == "" {
+			name = indexName(idx)
+		}
+		// PostgreSQL、SQLite 的索引名在库内唯一，MySQL 只需在表内唯一
+		key := name
+		if dialect == MySQL {
+			key = idx.Table + "." + name
+		}
+		if indexNames[key] {
+			l.add(RuleDuplicateName, SeverityError, idx.Table, "", "索引名 %s 重复", name)
+		}
+		indexNames[key] = true
+		indexed[idx.Table+"."+idx.Columns[0]] = true
+		if idx.Unique && len(idx.Columns) == 1 {
+			unique[idx.Table+"."+idx.Columns[0]] = true
+		}
+		l.identifier(idx.Table, "", "索引名", name)
+		l.key(t, idx.Columns, "索引 "+name)
+	}
+
+	for _, rel := range design.Relations {
+		l.lintRelation(tables, rel, indexed, unique)
+	}
+	return l.findings
+}
+
+type linter struct {
+	dialect  Dialect
+	findings []Finding
+}
+
+func (l *linter) add(rule string, severity Severity, table, column, format string, args ...interface{}) {
+	l.findings = append(l.findings, Finding{
+		Rule:     rule,
+		Severity: severity,
+		Table:    table,
+		Column:   column,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) lintTable(t *ai.TableDesign) {
+	l.identifier(t.Name, "", "表名", t.Name)
+	if len(t.Columns) == 0 {
+		l.add(RuleMissingPrimaryKey, SeverityError, t.Name, "", "表 %s 没有任何列", t.Name)
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range t.Columns {
+		if seen[c.Name] {
+			l.add(RuleDuplicateName, SeverityError, t.Name, c.Name, "表 %s 的列 %s 重复定义", t.Name, c.Name)
+		}
+		seen[c.Name] = true
+		l.identifier(t.Name, c.Name, "列名", c.Name)
+
+		typ := parseType(c)
+		if !typ.known() {
+			l.add(RuleUnknownType, SeverityWarning, t.Name, c.Name, "列 %s.%s 的类型 %s 无法识别，将原样输出", t.Name, c.Name, c.Type)
+		}
+		if typ.kind == kindEnum && l.dialect != MySQL {
+			l.add(RuleUnsupported, SeverityInfo, t.Name, c.Name, "%s 不支持 %s 类型，列 %s.%s 按字符串生成", l.dialect, strings.ToUpper(typ.name), t.Name, c.Name)
+		}
+		if c.AutoIncrement && typ.kind != kindInteger {
+			l.add(RuleUnsupported, SeverityError, t.Name, c.Name, "自增列 %s.%s 必须是整数类型", t.Name, c.Name)
+		}
+	}
+
+	pk := primaryKey(t)
+	if len(pk) == 0 {
+		l.add(RuleMissingPrimaryKey, SeverityWarning, t.Name, "", "表 %s 没有主键", t.Name)
+		return
+	}
+	for _, c := range t.Columns {
+		if c.AutoIncrement && !c.PrimaryKey {
+			l.add(RuleUnsupported, SeverityError, t.Name, c.Name, "自增列 %s.%s 必须是主键", t.Name, c.Name)
+		}
+	}
+	if l.dialect == SQLite && len(pk) > 1 {
+		for _, name := range pk {
+			if column(t, name).AutoIncrement {
+				l.add(RuleUnsupported, SeverityError, t.Name, name, "SQLite 的自增列只能是单列主键，表 %s 使用了联合主键", t.Name)
+			}
+		}
+	}
+	l.key(t, pk, "主键")
+}
+
+// key 检查索引键长度：varchar 按 utf8mb4 每字符 4 字节计算
+func (l *linter) key(t *ai.TableDesign, columns []string, label string) {
+	limit := maxKeyBytes[l.dialect]
+	if limit == 0 {
+		return
+	}
+	total := 0
+	var widest string
+	widestBytes := 0
+	for _, name := range columns {
+		bytes := parseType(*column(t, name)).keyBytes()
+		total += bytes
+		if bytes > widestBytes {
+			widest, widestBytes = name, bytes
+		}
+	}
+	if total <= limit {
+		return
+	}
+	severity := SeverityError
+	if l.dialect == PostgreSQL {
+		// PostgreSQL 只在实际写入超长的值时报错
+		severity = SeverityWarning
+	}
+	l.add(RuleOversizedKey, severity, t.Name, widest,
+		"表 %s 的%s最长 %d 字节，超过 %s 的 %d 字节限制，请缩短 %s 的长度或改用前缀索引", t.Name, label, total, l.dialect, limit, widest)
+}
+
+func (l *linter) identifier(table, column, label, name string) {
+	limit := maxIdentifierLength[l.dialect]
+	length := len([]rune(name))
+	if l.dialect == PostgreSQL {
+		length = len(name)
+	}
+	if limit > 0 && length > limit {
+		l.add(RuleIdentifierTooLong, SeverityWarning, table, column, "%s %s 超过 %s 的 %d 长度限制", label, name, l.dialect, limit)
+	}
+}
+
+func (l *linter) lintRelation(tables map[string]*ai.TableDesign, rel ai.RelationDesign, indexed, unique map[string]bool) {
+	from, to := tables[rel.FromTable], tables[rel.ToTable]
+	switch {
+	case from == nil:
+		l.add(RuleUnknownReference, SeverityError, rel.FromTable, "", "外键 %s 所在的表 %s 不存在", rel.Name, rel.FromTable)
+		return
+	case to == nil:
+		l.add(RuleUnknownReference, SeverityError, rel.FromTable, rel.FromColumn, "外键 %s 引用的表 %s 不存在", rel.Name, rel.ToTable)
+		return
+	}
+	child := column(from, rel.FromColumn)
+	if child == nil {
+		l.add(RuleUnknownReference, SeverityError, rel.FromTable, rel.FromColumn, "外键列 %s.%s 不存在", rel.FromTable, rel.FromColumn)
+		return
+	}
+	toColumn := rel.ToColumn
+	if toColumn == "" {
+		if pk := primaryKey(to); len(pk) == 1 {
+			toColumn = pk[0]
+		} else {
+			l.add(RuleUnknownReference, SeverityError, rel.FromTable, rel.FromColumn, "外键 %s.%s 没有指定引用列，表 %s 也没有单列主键", rel.FromTable, rel.FromColumn, rel.ToTable)
+			return
+		}
+	}
+	parent := column(to, toColumn)
+	if parent == nil {
+		l.add(RuleUnknownReference, SeverityError, rel.FromTable, rel.FromColumn, "外键引用的列 %s.%s 不存在", rel.ToTable, toColumn)
+		return
+	}
+	if rel.Name != "" {
+		l.identifier(rel.FromTable, rel.FromColumn, "外键名", rel.Name)
+	}
+
+	childType, parentType := parseType(*child), parseType(*parent)
+	if !sameKeyType(childType, parentType, l.dialect) {
+		l.add(RuleTypeMismatch, SeverityWarning, rel.FromTable, rel.FromColumn,
+			"外键列 %s.%s（%s）与引用列 %s.%s（%s）类型不一致", rel.FromTable, rel.FromColumn, childType.render(l.dialect), rel.ToTable, toColumn, parentType.render(l.dialect))
+	}
+	if pk := primaryKey(to); !(len(pk) == 1 && pk[0] == toColumn) && !unique[rel.ToTable+"."+toColumn] {
+		l.add(RuleUnknownReference, SeverityError, rel.FromTable, rel.FromColumn, "外键引用的列 %s.%s 既不是主键也没有唯一索引", rel.ToTable, toColumn)
+	}
+
+	for _, action := range []struct{ label, value string }{{"ON DELETE", rel.OnDelete}, {"ON UPDATE", rel.OnUpdate}} {
+		if action.value == "" {
+			continue
+		}
+		normalized := referentialAction(action.value)
+		switch {
+		case normalized == "":
+			l.add(RuleUnsupported, SeverityWarning, rel.FromTable, rel.FromColumn, "外键 %s.%s 的 %s 规则 %s 无法识别，已忽略", rel.FromTable, rel.FromColumn, action.label, action.value)
+		case normalized == "SET NULL" && !child.Nullable:
+			l.add(RuleUnsupported, SeverityError, rel.FromTable, rel.FromColumn, "外键列 %s.%s 不允许为空，不能使用 %s SET NULL", rel.FromTable, rel.FromColumn, action.label)
+		case normalized == "SET DEFAULT" && l.dialect == MySQL:
+			l.add(RuleUnsupported, SeverityError, rel.FromTable, rel.FromColumn, "InnoDB 不支持 %s SET DEFAULT", action.label)
+		}
+	}
+
+	// MySQL 会为外键列自动建立索引
+	if l.dialect != MySQL && !indexed[rel.FromTable+"."+rel.FromColumn] {
+		l.add(RuleUnindexedReference, SeverityInfo, rel.FromTable, rel.FromColumn, "外键列 %s.%s 上没有索引，删除或更新被引用的行时需要全表扫描", rel.FromTable, rel.FromColumn)
+	}
+}
+
+// sameKeyType 外键两端的类型是否一致：整数比较取值范围和符号，字符串只比较类型族
+func sameKeyType(a, b sqlType, d Dialect) bool {
+	if a.kind != b.kind {
+		return false
+	}
+	if a.kind == kindInteger && d != SQLite {
+		return a.integerRank() == b.integerRank() && (d != MySQL || a.unsigned == b.unsigned)
+	}
+	return true
+}
+
+func missingColumns(t *ai.TableDesign, names []string) []string {
+	var missing []string
+	for _, name := range names {
+		if column(t, name) == nil {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
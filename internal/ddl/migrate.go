@@ -0,0 +1,503 @@
+package ddl
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"ai-dev-platform/internal/ai"
+)
+
+// 迁移中的变更类型
+const (
+	ChangeCreateTable    = "create_table"
+	ChangeDropTable      = "drop_table"
+	ChangeRebuildTable   = "rebuild_table" // SQLite 不支持的修改通过重建表完成
+	ChangeAddColumn      = "add_column"
+	ChangeDropColumn     = "drop_column"
+	ChangeModifyColumn   = "modify_column"
+	ChangePrimaryKey     = "primary_key"
+	ChangeTableComment   = "table_comment"
+	ChangeAddIndex       = "add_index"
+	ChangeDropIndex      = "drop_index"
+	ChangeAddForeignKey  = "add_foreign_key"
+	ChangeDropForeignKey = "drop_foreign_key"
+)
+
+// Change 迁移中的一项变更
+type Change struct {
+	Kind  string `json:"kind"`
+	Table string `json:"table"`
+	Name  string `json:"name,omitempty"` // 列、索引或外键名称
+}
+
+// Migration 从旧版本数据库设计迁移到新版本的脚本
+type Migration struct {
+	Dialect  Dialect   `json:"dialect"`
+	SQL      string    `json:"sql"`
+	Changes  []Change  `json:"changes"`
+	Findings []Finding `json:"findings"` // 可能丢失数据或执行失败的变更，以及新版本设计的检查结果
+}
+
+// Empty 两个版本之间没有差异
+func (m *Migration) Empty() bool {
+	return len(m.Changes) == 0
+}
+
+// Migrate 生成 ALTER 形式的迁移脚本；表和列按名称对应，改名视为删除后新增
+func Migrate(from, to *ai.DatabaseDesign, dialect Dialect) (*Migration, error) {
+	if to == nil || len(to.Tables) == 0 {
+		return nil, ErrEmptyDesign
+	}
+	if from == nil {
+		from = &ai.DatabaseDesign{}
+	}
+	m := &migrator{
+		dialect:   dialect,
+		old:       newSchema(from),
+		new:       newSchema(to),
+		migration: &Migration{Dialect: dialect, Changes: []Change{}},
+	}
+	m.plan()
+	m.migration.SQL = m.render()
+	m.migration.Findings = append(m.migration.Findings, Lint(to, dialect)...)
+	return m.migration, nil
+}
+
+// tableDiff 同名表的差异
+type tableDiff struct {
+	old, new   *ai.TableDesign
+	added      []ai.ColumnDesign
+	dropped    []ai.ColumnDesign
+	modified   [][2]ai.ColumnDesign // 旧、新
+	primaryKey bool
+	comment    bool
+	rebuild    bool // SQLite 需要重建表
+}
+
+type migrator struct {
+	dialect   Dialect
+	old, new  *schema
+	migration *Migration
+
+	dropForeignKeys []ai.RelationDesign
+	dropIndexes     []ai.IndexDesign
+	dropTables      []*ai.TableDesign
+	createTables    []*ai.TableDesign
+	alterTables     []*tableDiff
+	addIndexes      []ai.IndexDesign
+	addForeignKeys  []ai.RelationDesign
+}
+
+func (m *migrator) change(kind, table, name string) {
+	m.migration.Changes = append(m.migration.Changes, Change{Kind: kind, Table: table, Name: name})
+}
+
+func (m *migrator) warn(rule, table, column, format string, args ...interface{}) {
+	m.migration.Findings = append(m.migration.Findings, Finding{
+		Rule: rule, Severity: SeverityWarning, Table: table, Column: column, Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// plan 比较两个版本，确定各类语句
+func (m *migrator) plan() {
+	diffs := make(map[string]*tableDiff)
+	for _, t := range m.old.tables {
+		if m.new.byName[t.Name] == nil {
+			m.dropTables = append(m.dropTables, t)
+			m.change(ChangeDropTable, t.Name, "")
+			m.warn(RuleDestructiveChange, t.Name, "", "删除表 %s 会丢失其中的数据", t.Name)
+		}
+	}
+	for _, t := range m.new.tables {
+		old := m.old.byName[t.Name]
+		if old == nil {
+			m.createTables = append(m.createTables, t)
+			m.change(ChangeCreateTable, t.Name, "")
+			continue
+		}
+		if d := m.diffTable(old, t); d != nil {
+			diffs[t.Name] = d
+			m.alterTables = append(m.alterTables, d)
+		}
+	}
+
+	// 修改过的列上的外键在修改前删除、修改后重建；重建的 SQLite 表自带新版本的外键
+	touched := func(table, col string) bool {
+		d := diffs[table]
+		if d == nil {
+			return false
+		}
+		for _, pair := range d.modified {
+			if pair[1].Name == col {
+				return true
+			}
+		}
+		return false
+	}
+	oldRelations := relationsByKey(m.old.relations)
+	newRelations := relationsByKey(m.new.relations)
+	for _, rel := range m.old.relations {
+		key := rel.FromTable + "." + rel.Name
+		next, kept := newRelations[key]
+		if m.new.byName[rel.FromTable] == nil {
+			// 所在表被删除时外键随表删除，但要先于被引用的表删除，这里统一显式删除
+			if m.dialect != SQLite {
+				m.dropForeignKeys = append(m.dropForeignKeys, rel)
+			}
+			continue
+		}
+		if !kept || !reflect.DeepEqual(rel, next) || touched(rel.FromTable, rel.FromColumn) || touched(rel.ToTable, rel.ToColumn) {
+			if m.dialect == SQLite {
+				diffs[rel.FromTable] = m.requireRebuild(diffs, rel.FromTable)
+				continue
+			}
+			m.dropForeignKeys = append(m.dropForeignKeys, rel)
+			m.change(ChangeDropForeignKey, rel.FromTable, rel.Name)
+		}
+	}
+	for _, rel := range m.new.relations {
+		key := rel.FromTable + "." + rel.Name
+		prev, existed := oldRelations[key]
+		if m.old.byName[rel.FromTable] == nil {
+			// 新建的表：SQLite 的外键写在建表语句中，其余方言在建表后统一添加
+			if m.dialect != SQLite {
+				m.addForeignKeys = append(m.addForeignKeys, rel)
+				m.change(ChangeAddForeignKey, rel.FromTable, rel.Name)
+			}
+			continue
+		}
+		if !existed || !reflect.DeepEqual(rel, prev) || touched(rel.FromTable, rel.FromColumn) || touched(rel.ToTable, rel.ToColumn) {
+			if m.dialect == SQLite {
+				diffs[rel.FromTable] = m.requireRebuild(diffs, rel.FromTable)
+				continue
+			}
+			m.addForeignKeys = append(m.addForeignKeys, rel)
+			m.change(ChangeAddForeignKey, rel.FromTable, rel.Name)
+		}
+	}
+
+	for _, t := range m.new.tables {
+		if m.old.byName[t.Name] == nil {
+			continue
+		}
+		rebuilt := diffs[t.Name] != nil && diffs[t.Name].rebuild
+		oldIndexes := indexesByName(m.old.indexes[t.Name])
+		newIndexes := indexesByName(m.new.indexes[t.Name])
+		for _, idx := range m.old.indexes[t.Name] {
+			if next, ok := newIndexes[idx.Name]; !ok || !reflect.DeepEqual(idx, next) {
+				if !rebuilt {
+					m.dropIndexes = append(m.dropIndexes, idx)
+				}
+				m.change(ChangeDropIndex, t.Name, idx.Name)
+			}
+		}
+		for _, idx := range m.new.indexes[t.Name] {
+			if prev, ok := oldIndexes[idx.Name]; rebuilt || !ok || !reflect.DeepEqual(idx, prev) {
+				m.addIndexes = append(m.addIndexes, idx)
+				if !ok || !reflect.DeepEqual(idx, prev) {
+					m.change(ChangeAddIndex, t.Name, idx.Name)
+				}
+			}
+		}
+	}
+}
+
+// requireRebuild SQLite 修改外键时需要重建表
+func (m *migrator) requireRebuild(diffs map[string]*tableDiff, table string) *tableDiff {
+	d := diffs[table]
+	if d == nil {
+		d = &tableDiff{old: m.old.byName[table], new: m.new.byName[table]}
+		m.alterTables = append(m.alterTables, d)
+	}
+	if !d.rebuild {
+		d.rebuild = true
+		m.change(ChangeRebuildTable, table, "")
+	}
+	return d
+}
+
+// diffTable 比较同名表，没有差异时返回 nil
+func (m *migrator) diffTable(old, new *ai.TableDesign) *tableDiff {
+	d := &tableDiff{old: old, new: new}
+	for _, c := range new.Columns {
+		prev := column(old, c.Name)
+		switch {
+		case prev == nil:
+			d.added = append(d.added, c)
+			m.change(ChangeAddColumn, new.Name, c.Name)
+			if !c.Nullable && !c.PrimaryKey && c.Default == "" {
+				m.warn(RuleUnsupported, new.Name, c.Name, "新增的非空列 %s.%s 没有默认值，表中已有数据时迁移会失败", new.Name, c.Name)
+			}
+		case m.columnChanged(*prev, c):
+			d.modified = append(d.modified, [2]ai.ColumnDesign{*prev, c})
+			m.change(ChangeModifyColumn, new.Name, c.Name)
+			// 只有缩短长度、缩小范围或改变类型族时才可能丢失数据，加长 varchar 等放宽的修改不提示
+			if from, to := parseType(*prev), parseType(c); from.render(m.dialect) != to.render(m.dialect) && !to.widens(from) {
+				m.warn(RuleDestructiveChange, new.Name, c.Name, "列 %s.%s 的类型由 %s 改为 %s，可能导致数据截断或转换失败",
+					new.Name, c.Name, parseType(*prev).render(m.dialect), parseType(c).render(m.dialect))
+			}
+		}
+	}
+	for _, c := range old.Columns {
+		if column(new, c.Name) == nil {
+			d.dropped = append(d.dropped, c)
+			m.change(ChangeDropColumn, new.Name, c.Name)
+			m.warn(RuleDestructiveChange, new.Name, c.Name, "删除列 %s.%s 会丢失其中的数据；如果是改名，请手动改为重命名语句", new.Name, c.Name)
+		}
+	}
+	if !reflect.DeepEqual(primaryKey(old), primaryKey(new)) {
+		d.primaryKey = true
+		m.change(ChangePrimaryKey, new.Name, strings.Join(primaryKey(new), ","))
+	}
+	if old.Comment != new.Comment && m.dialect != SQLite {
+		d.comment = true
+		m.change(ChangeTableComment, new.Name, "")
+	}
+	if len(d.added) == 0 && len(d.dropped) == 0 && len(d.modified) == 0 && !d.primaryKey && !d.comment {
+		return nil
+	}
+
+	if m.dialect == SQLite {
+		// SQLite 只能安全地新增可空或有默认值的普通列，其余修改都要重建表
+		d.rebuild = len(d.dropped) > 0 || len(d.modified) > 0 || d.primaryKey
+		for _, c := range d.added {
+			if c.PrimaryKey || (!c.Nullable && c.Default == "") {
+				d.rebuild = true
+			}
+		}
+		if d.rebuild {
+			m.change(ChangeRebuildTable, new.Name, "")
+		}
+	}
+	return d
+}
+
+// columnChanged 列定义在该方言下是否有变化；SQLite 不保存列注释
+func (m *migrator) columnChanged(old, new ai.ColumnDesign) bool {
+	if m.dialect == SQLite {
+		old.Comment, new.Comment = "", ""
+	}
+	old.PrimaryKey, new.PrimaryKey = false, false
+	return columnDefinition(old, m.dialect) != columnDefinition(new, m.dialect) || old.Comment != new.Comment
+}
+
+// render 按依赖顺序输出：先删外键和索引，再删表、建表、改表，最后建索引和外键
+func (m *migrator) render() string {
+	d := m.dialect
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- 数据库迁移：%s\n", d)
+	if len(m.migration.Changes) == 0 {
+		b.WriteString("-- 两个版本的数据库设计没有差异\n")
+		return b.String()
+	}
+
+	rebuild := false
+	for _, t := range m.alterTables {
+		rebuild = rebuild || t.rebuild
+	}
+	switch {
+	case d == SQLite && rebuild:
+		// 重建表期间关闭外键检查；该设置在事务中无效，必须在 BEGIN 之前
+		b.WriteString("PRAGMA foreign_keys = OFF;\nBEGIN;\n")
+	case d != MySQL:
+		// MySQL 的 DDL 会隐式提交，不包在事务中
+		b.WriteString("BEGIN;\n")
+	}
+
+	section := func(stmts []string) {
+		if len(stmts) > 0 {
+			b.WriteString("\n")
+			b.WriteString(strings.Join(stmts, ""))
+		}
+	}
+	var stmts []string
+	for _, rel := range m.dropForeignKeys {
+		stmts = append(stmts, dropForeignKey(rel, d))
+	}
+	section(stmts)
+
+	stmts = nil
+	for _, idx := range m.dropIndexes {
+		stmts = append(stmts, dropIndex(idx, d))
+	}
+	section(stmts)
+
+	stmts = nil
+	for _, t := range m.dropTables {
+		stmts = append(stmts, fmt.Sprintf("DROP TABLE %s;\n", quote(t.Name, d)))
+	}
+	section(stmts)
+
+	for _, t := range m.createTables {
+		b.WriteString("\n")
+		b.WriteString(m.new.createTable(t, d, t.Name))
+		for _, idx := range m.new.indexes[t.Name] {
+			b.WriteString(createIndex(idx, d))
+		}
+	}
+
+	for _, t := range m.alterTables {
+		b.WriteString("\n")
+		if t.rebuild {
+			b.WriteString(m.rebuildTable(t))
+		} else {
+			b.WriteString(m.alterTable(t))
+		}
+	}
+
+	stmts = nil
+	for _, idx := range m.addIndexes {
+		stmts = append(stmts, createIndex(idx, d))
+	}
+	section(stmts)
+
+	stmts = nil
+	for _, rel := range m.addForeignKeys {
+		stmts = append(stmts, addForeignKey(rel, d))
+	}
+	section(stmts)
+
+	switch {
+	case d == SQLite && rebuild:
+		b.WriteString("\nPRAGMA foreign_key_check;\nCOMMIT;\nPRAGMA foreign_keys = ON;\n")
+	case d != MySQL:
+		b.WriteString("\nCOMMIT;\n")
+	}
+	return b.String()
+}
+
+// alterTable 用 ALTER TABLE 修改表
+func (m *migrator) alterTable(t *tableDiff) string {
+	d := m.dialect
+	table := quote(t.new.Name, d)
+	var b strings.Builder
+	if t.primaryKey && len(primaryKey(t.old)) > 0 {
+		if d == MySQL {
+			fmt.Fprintf(&b, "ALTER TABLE %s DROP PRIMARY KEY;\n", table)
+		} else {
+			// 按 PostgreSQL 的默认约束名删除
+			fmt.Fprintf(&b, "ALTER TABLE %s DROP CONSTRAINT %s;\n", table, quote(t.new.Name+"_pkey", d))
+		}
+	}
+	for _, c := range t.added {
+		fmt.Fprintf(&b, "ALTER TABLE %s ADD COLUMN %s;\n", table, columnDefinition(withoutPrimaryKey(c), d))
+		if d == PostgreSQL && c.Comment != "" {
+			fmt.Fprintf(&b, "COMMENT ON COLUMN %s.%s IS %s;\n", table, quote(c.Name, d), literal(c.Comment, d))
+		}
+	}
+	for _, pair := range t.modified {
+		b.WriteString(m.modifyColumn(t.new.Name, pair[0], pair[1]))
+	}
+	for _, c := range t.dropped {
+		fmt.Fprintf(&b, "ALTER TABLE %s DROP COLUMN %s;\n", table, quote(c.Name, d))
+	}
+	if t.primaryKey {
+		if pk := primaryKey(t.new); len(pk) > 0 {
+			fmt.Fprintf(&b, "ALTER TABLE %s ADD PRIMARY KEY (%s);\n", table, quoteList(pk, d))
+		}
+	}
+	if t.comment {
+		if d == MySQL {
+			fmt.Fprintf(&b, "ALTER TABLE %s COMMENT = %s;\n", table, literal(t.new.Comment, d))
+		} else if t.new.Comment == "" {
+			fmt.Fprintf(&b, "COMMENT ON TABLE %s IS NULL;\n", table)
+		} else {
+			fmt.Fprintf(&b, "COMMENT ON TABLE %s IS %s;\n", table, literal(t.new.Comment, d))
+		}
+	}
+	return b.String()
+}
+
+// modifyColumn 修改列：MySQL 重写整个列定义，PostgreSQL 逐项修改
+func (m *migrator) modifyColumn(tableName string, old, new ai.ColumnDesign) string {
+	d := m.dialect
+	table, col := quote(tableName, d), quote(new.Name, d)
+	if d == MySQL {
+		return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;\n", table, columnDefinition(withoutPrimaryKey(new), d))
+	}
+
+	var b strings.Builder
+	oldType, newType := parseType(old), parseType(new)
+	if oldType.render(d) != newType.render(d) {
+		fmt.Fprintf(&b, "ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s;\n", table, col, newType.render(d), col, newType.render(d))
+	}
+	oldNotNull, newNotNull := !old.Nullable || old.PrimaryKey, !new.Nullable || new.PrimaryKey
+	if oldNotNull != newNotNull {
+		if newNotNull {
+			fmt.Fprintf(&b, "ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;\n", table, col)
+		} else {
+			fmt.Fprintf(&b, "ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;\n", table, col)
+		}
+	}
+	if old.Default != new.Default {
+		if new.Default == "" {
+			fmt.Fprintf(&b, "ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;\n", table, col)
+		} else {
+			fmt.Fprintf(&b, "ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;\n", table, col, defaultValue(new.Default, d))
+		}
+	}
+	oldIdentity, newIdentity := old.AutoIncrement || oldType.serial, new.AutoIncrement || newType.serial
+	if oldIdentity != newIdentity {
+		if newIdentity {
+			fmt.Fprintf(&b, "ALTER TABLE %s ALTER COLUMN %s ADD GENERATED BY DEFAULT AS IDENTITY;\n", table, col)
+		} else {
+			fmt.Fprintf(&b, "ALTER TABLE %s ALTER COLUMN %s DROP IDENTITY IF EXISTS;\n", table, col)
+		}
+	}
+	if old.Comment != new.Comment {
+		comment := "NULL"
+		if new.Comment != "" {
+			comment = literal(new.Comment, d)
+		}
+		fmt.Fprintf(&b, "COMMENT ON COLUMN %s.%s IS %s;\n", table, col, comment)
+	}
+	return b.String()
+}
+
+// rebuildTable SQLite 重建表：按新定义建临时表，复制两个版本共有的列，删除旧表后改名
+func (m *migrator) rebuildTable(t *tableDiff) string {
+	d := m.dialect
+	temp := t.new.Name + "__new"
+	var common []string
+	for _, c := range t.new.Columns {
+		if column(t.old, c.Name) != nil {
+			common = append(common, c.Name)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- 重建表 %s\n", t.new.Name)
+	b.WriteString(m.new.createTable(t.new, d, temp))
+	if len(common) > 0 {
+		fmt.Fprintf(&b, "INSERT INTO %s (%s) SELECT %s FROM %s;\n", quote(temp, d), quoteList(common, d), quoteList(common, d), quote(t.old.Name, d))
+	}
+	fmt.Fprintf(&b, "DROP TABLE %s;\n", quote(t.old.Name, d))
+	fmt.Fprintf(&b, "ALTER TABLE %s RENAME TO %s;\n", quote(temp, d), quote(t.new.Name, d))
+	return b.String()
+}
+
+func withoutPrimaryKey(c ai.ColumnDesign) ai.ColumnDesign {
+	// 主键约束单独添加，列定义中只保留非空
+	if c.PrimaryKey {
+		c.PrimaryKey = false
+		c.Nullable = false
+	}
+	return c
+}
+
+func relationsByKey(relations []ai.RelationDesign) map[string]ai.RelationDesign {
+	byKey := make(map[string]ai.RelationDesign, len(relations))
+	for _, rel := range relations {
+		byKey[rel.FromTable+"."+rel.Name] = rel
+	}
+	return byKey
+}
+
+func indexesByName(indexes []ai.IndexDesign) map[string]ai.IndexDesign {
+	byName := make(map[string]ai.IndexDesign, len(indexes))
+	for _, idx := range indexes {
+		byName[idx.Name] = idx
+	}
+	return byName
+}
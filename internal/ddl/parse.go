@@ -0,0 +1,1008 @@
+package ddl
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ai-dev-platform/internal/ai"
+)
+
+// ===== 由现有数据库的结构导出反向生成数据库设计 =====
+
+// ErrNoTables SQL中没有可导入的建表语句
+var ErrNoTables = errors.New("SQL中没有找到建表语句")
+
+// ParsedSchema 从 mysqldump --no-data 或 pg_dump --schema-only 导出中解析出的数据库设计
+type ParsedSchema struct {
+	Dialect  Dialect            `json:"dialect"`
+	Design   *ai.DatabaseDesign `json:"design"`
+	Skipped  []string           `json:"skipped,omitempty"`  // 未导入的对象，如视图、函数、触发器
+	Warnings []string           `json:"warnings,omitempty"` // 只导入了一部分的定义
+}
+
+var (
+	mysqlMarkers    = regexp.MustCompile("(?i)mysqldump|mariadb dump|`|\\bENGINE\\s*=|\\bAUTO_INCREMENT\\b|/\\*!\\d+")
+	postgresMarkers = regexp.MustCompile(`(?i)pg_dump|PostgreSQL database dump|search_path|::|\bnextval\(|\bOWNER TO\b|\bcharacter varying\b|\bserial\b`)
+	sqliteMarkers   = regexp.MustCompile(`(?i)\bAUTOINCREMENT\b|\bPRAGMA\b|\bsqlite_sequence\b|\bWITHOUT ROWID\b`)
+)
+
+// DetectDialect 根据导出文件中的特征判断方言，无法判断时为 MySQL
+func DetectDialect(dump string) Dialect {
+	mysql := len(mysqlMarkers.FindAllStringIndex(dump, 20))
+	postgres := len(postgresMarkers.FindAllStringIndex(dump, 20))
+	sqlite := len(sqliteMarkers.FindAllStringIndex(dump, 20))
+	switch {
+	case postgres > mysql && postgres >= sqlite:
+		return PostgreSQL
+	case sqlite > mysql:
+		return SQLite
+	}
+	return MySQL
+}
+
+// ParseSchema 解析建表语句导出，dialect 为空时自动判断；
+// 只导入表、列、主键、索引、外键和注释，视图、函数、触发器等记入 Skipped
+func ParseSchema(dump string, dialect Dialect) (*ParsedSchema, error) {
+	if dialect == "" {
+		dialect = DetectDialect(dump)
+	}
+	p := &schemaParser{
+		result: &ParsedSchema{Dialect: dialect, Design: &ai.DatabaseDesign{}},
+		tables: make(map[string]*ai.TableDesign),
+		enums:  make(map[string]string),
+	}
+	source := p.stripBlocks(dump)
+	for _, statement := range splitStatements(lex(source, dialect == MySQL)) {
+		p.statement(statement)
+	}
+	if len(p.result.Design.Tables) == 0 {
+		return nil, ErrNoTables
+	}
+	p.finish()
+	return p.result, nil
+}
+
+type schemaParser struct {
+	result *ParsedSchema
+	tables map[string]*ai.TableDesign // 按小写表名索引，指向 Design.Tables 中的元素
+	enums  map[string]string          // PostgreSQL 枚举类型名 -> enum('a','b')
+}
+
+var (
+	delimiterLine = regexp.MustCompile(`(?i)^\s*DELIMITER\s+(\S+)\s*$`)
+	copyLine      = regexp.MustCompile(`(?i)^\s*COPY\s+.*\bFROM\s+stdin\s*;\s*$`)
+	routineStart  = regexp.MustCompile(`(?i)^\s*(?:/\*!\d+\s+)?CREATE\b.*?\b(PROCEDURE|FUNCTION|TRIGGER|EVENT)\s+([^\s(]+)`)
+)
+
+// stripBlocks 去掉按行定界的内容：mysqldump 中 DELIMITER 包裹的存储过程、触发器，以及 pg_dump 中 COPY 的数据
+func (p *schemaParser) stripBlocks(dump string) string {
+	lines := strings.Split(strings.ReplaceAll(dump, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	delimiter := ";"
+	inCopy := false
+	for _, line := range lines {
+		switch {
+		case inCopy:
+			inCopy = strings.TrimSpace(line) != `\.`
+			continue
+		case copyLine.MatchString(line):
+			inCopy = true
+			continue
+		}
+		if m := delimiterLine.FindStringSubmatch(line); m != nil {
+			delimiter = m[1]
+			continue
+		}
+		if delimiter != ";" {
+			if m := routineStart.FindStringSubmatch(line); m != nil {
+				p.skip(strings.ToLower(m[1]), unquote(m[2]))
+			}
+			continue
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func (p *schemaParser) skip(kind, name string) {
+	p.result.Skipped = append(p.result.Skipped, kind+" "+name)
+}
+
+func (p *schemaParser) warn(format string, args ...interface{}) {
+	p.result.Warnings = append(p.result.Warnings, fmt.Sprintf(format, args...))
+}
+
+// statement 分派一条语句，与表结构无关的语句（SET、DROP、INSERT、GRANT 等）直接忽略
+func (p *schemaParser) statement(tokens []token) {
+	s := &cursor{tokens: tokens}
+	switch {
+	case s.accept("CREATE"):
+		p.create(s)
+	case s.accept("ALTER"):
+		if s.accept("TABLE") {
+			p.alterTable(s)
+		}
+	case s.accept("COMMENT"):
+		if s.accept("ON") {
+			p.commentOn(s)
+		}
+	}
+}
+
+func (p *schemaParser) create(s *cursor) {
+	s.accept("OR")
+	s.accept("REPLACE")
+	for s.accept("TEMPORARY", "TEMP", "UNLOGGED", "GLOBAL", "LOCAL", "DEFINER", "ALGORITHM", "SQL") {
+		// MySQL 视图的 ALGORITHM=... DEFINER=... SQL SECURITY ...
+		if s.acceptPunct("=") {
+			s.next()
+			for s.acceptPunct("@") {
+				s.next()
+			}
+		}
+		s.accept("SECURITY")
+		s.accept("DEFINER", "INVOKER")
+	}
+	switch {
+	case s.accept("TABLE"):
+		p.createTable(s)
+	case s.accept("UNIQUE"):
+		if s.accept("INDEX") {
+			p.createIndex(s, true)
+		}
+	case s.accept("INDEX"):
+		p.createIndex(s, false)
+	case s.accept("TYPE"):
+		p.createType(s)
+	case s.accept("MATERIALIZED"):
+		s.accept("VIEW")
+		p.skip("materialized view", s.name())
+	case s.accept("VIEW"):
+		p.skip("view", s.name())
+	case s.accept("FUNCTION", "PROCEDURE", "TRIGGER", "EVENT", "RULE", "POLICY", "DOMAIN"):
+		kind := strings.ToLower(s.prev().text)
+		s.acceptIfNotExists()
+		p.skip(kind, s.name())
+	case s.accept("CONSTRAINT"):
+		// PostgreSQL 的 CREATE CONSTRAINT TRIGGER
+		s.accept("TRIGGER")
+		p.skip("trigger", s.name())
+	}
+}
+
+// createTable CREATE TABLE [IF NOT EXISTS] name (...) [表选项]
+func (p *schemaParser) createTable(s *cursor) {
+	s.acceptIfNotExists()
+	name := s.name()
+	if strings.HasPrefix(strings.ToLower(name), "sqlite_") {
+		// SQLite 的内部表
+		return
+	}
+	if name == "" || !s.peekPunct("(") {
+		// CREATE TABLE ... AS SELECT / LIKE / PARTITION OF 无法得到完整列定义
+		if name != "" {
+			p.warn("表 %s 不是以列定义创建的，已跳过", name)
+		}
+		return
+	}
+	if _, ok := p.tables[strings.ToLower(name)]; ok {
+		p.warn("表 %s 重复定义，只保留第一次的定义", name)
+		return
+	}
+	p.result.Design.Tables = append(p.result.Design.Tables, ai.TableDesign{Name: name})
+	p.reindex()
+	table := p.tables[strings.ToLower(name)]
+
+	for _, element := range s.group() {
+		p.tableElement(table, &cursor{tokens: element})
+	}
+	// 表选项：MySQL 的 COMMENT='...'
+	for !s.done() {
+		if s.accept("COMMENT") {
+			s.acceptPunct("=")
+			if t := s.next(); t.kind == tokString {
+				table.Comment = t.text
+			}
+			continue
+		}
+		s.next()
+	}
+}
+
+// reindex Design.Tables 追加元素后切片可能重新分配，重建索引
+func (p *schemaParser) reindex() {
+	for i := range p.result.Design.Tables {
+		p.tables[strings.ToLower(p.result.Design.Tables[i].Name)] = &p.result.Design.Tables[i]
+	}
+}
+
+// tableElement 建表语句括号中的一项：列定义或表级约束
+func (p *schemaParser) tableElement(table *ai.TableDesign, s *cursor) {
+	constraint := ""
+	if s.accept("CONSTRAINT") {
+		if !s.peek().is("PRIMARY", "UNIQUE", "FOREIGN", "CHECK", "EXCLUDE") {
+			constraint = s.name()
+		}
+	}
+	switch {
+	case s.accept("PRIMARY"):
+		s.accept("KEY")
+		p.primaryKey(table, s.identList())
+	case s.accept("UNIQUE"):
+		s.accept("KEY", "INDEX")
+		p.index(table, s, constraint, true)
+	case s.accept("KEY", "INDEX"):
+		p.index(table, s, constraint, false)
+	case s.accept("FULLTEXT", "SPATIAL"):
+		kind := strings.ToLower(s.prev().text)
+		s.accept("KEY", "INDEX")
+		p.warn("表 %s 的%s索引 %s 未导入", table.Name, kind, s.name())
+	case s.accept("FOREIGN"):
+		s.accept("KEY")
+		p.foreignKey(table, s, constraint)
+	case s.accept("CHECK", "EXCLUDE", "LIKE", "PERIOD"):
+	default:
+		if constraint == "" {
+			p.column(table, s)
+		}
+	}
+}
+
+// columnStops 列类型之后可能出现的关键字，遇到即类型结束
+var columnStops = []string{
+	"NOT", "NULL", "DEFAULT", "PRIMARY", "UNIQUE", "KEY", "AUTO_INCREMENT", "AUTOINCREMENT", "COMMENT",
+	"REFERENCES", "CHECK", "CONSTRAINT", "COLLATE", "GENERATED", "ON", "AS", "CHARSET", "STORED",
+	"VIRTUAL", "VISIBLE", "INVISIBLE", "SRID", "COLUMN_FORMAT", "STORAGE", "IDENTITY",
+}
+
+// column 列定义：列名、类型，以及 NOT NULL、DEFAULT、COMMENT 等列选项
+func (p *schemaParser) column(table *ai.TableDesign, s *cursor) {
+	name := s.ident()
+	if name == "" {
+		return
+	}
+	col := ai.ColumnDesign{Name: name, Nullable: true}
+	col.Type, col.Length = p.columnType(s)
+	if col.Type == "" {
+		return
+	}
+	if strings.HasSuffix(col.Type, "serial") {
+		col.AutoIncrement = true
+	}
+	for !s.done() {
+		switch {
+		case s.accept("NOT"):
+			s.accept("NULL")
+			col.Nullable = false
+		case s.accept("NULL"):
+			col.Nullable = true
+		case s.accept("DEFAULT"):
+			value, sequence := defaultExpression(s.until(columnStops...))
+			if sequence {
+				col.AutoIncrement = true
+			} else {
+				col.Default = value
+			}
+		case s.accept("PRIMARY"):
+			s.accept("KEY")
+			col.PrimaryKey, col.Nullable = true, false
+		case s.accept("KEY"):
+			// MySQL 中单独的 KEY 等同于 PRIMARY KEY
+			col.PrimaryKey, col.Nullable = true, false
+		case s.accept("UNIQUE"):
+			s.accept("KEY")
+			p.addIndex(ai.IndexDesign{Table: table.Name, Columns: []string{name}, Unique: true})
+		case s.accept("AUTO_INCREMENT", "AUTOINCREMENT"):
+			col.AutoIncrement = true
+		case s.accept("COMMENT"):
+			if t := s.next(); t.kind == tokString {
+				col.Comment = t.text
+			}
+		case s.accept("REFERENCES"):
+			p.references(table, s, "", []string{name})
+		case s.accept("GENERATED"):
+			s.accept("ALWAYS")
+			if s.accept("BY") {
+				s.accept("DEFAULT")
+			}
+			s.accept("AS")
+			if s.accept("IDENTITY") {
+				col.AutoIncrement = true
+				if s.peekPunct("(") {
+					s.group()
+				}
+			} else if s.peekPunct("(") {
+				s.group()
+			}
+		case s.accept("AS"):
+			// MySQL 生成列 AS (expr) [STORED|VIRTUAL]
+			if s.peekPunct("(") {
+				s.group()
+			}
+		case s.accept("ON"):
+			// ON UPDATE CURRENT_TIMESTAMP
+			s.accept("UPDATE")
+			s.until(columnStops...)
+		case s.accept("COLLATE", "CHARSET", "SRID", "COLUMN_FORMAT", "STORAGE"):
+			s.next()
+		case s.accept("CHARACTER"):
+			s.accept("SET")
+			s.next()
+		case s.accept("CHECK"):
+			s.group()
+		case s.accept("CONSTRAINT"):
+			s.name()
+		default:
+			s.next()
+		}
+	}
+	table.Columns = append(table.Columns, col)
+}
+
+// columnType 读取列类型，规范为 ai.ColumnDesign 的写法：varchar(50) 拆为类型和长度，
+// 去掉 MySQL 整数的显示宽度（tinyint(1) 除外），PostgreSQL 的长类型名换成常用别名
+func (p *schemaParser) columnType(s *cursor) (string, int) {
+	var words []string
+	args := ""
+	array := false
+	for !s.done() {
+		t := s.peek()
+		switch {
+		case t.punct("("):
+			var parts []string
+			for _, element := range s.group() {
+				parts = append(parts, rawText(element))
+			}
+			args = strings.Join(parts, ",")
+			continue
+		case t.punct("["):
+			s.next()
+			s.acceptNumber()
+			s.acceptPunct("]")
+			array = true
+			continue
+		case t.is("ARRAY"):
+			s.next()
+			array = true
+			continue
+		case t.punct("."):
+			// 带模式名的自定义类型
+			s.next()
+			words = nil
+			continue
+		case t.is("CHARACTER") && len(words) > 0:
+			// 类型之后的 CHARACTER SET
+		case t.ident() && !t.is(columnStops...):
+			words = append(words, strings.ToLower(t.text))
+			s.next()
+			continue
+		}
+		break
+	}
+	if len(words) == 0 {
+		return "", 0
+	}
+	unsigned := false
+	var base []string
+	for _, w := range words {
+		switch w {
+		case "unsigned":
+			unsigned = true
+		case "zerofill", "signed":
+		default:
+			base = append(base, w)
+		}
+	}
+	name := canonicalType(strings.Join(base, " "))
+	if enum, ok := p.enums[name]; ok {
+		name = enum
+	}
+	length := 0
+	switch {
+	case args == "":
+	case lengthTypes[name] && isNumber(args) && !strings.Contains(args, "."):
+		length, _ = strconv.Atoi(args)
+	case integerTypes[name] && !(name == "tinyint" && args == "1"):
+		// 显示宽度不影响取值范围
+	default:
+		name += "(" + args + ")"
+	}
+	if unsigned {
+		name += " unsigned"
+	}
+	if array {
+		name += "[]"
+	}
+	return name, length
+}
+
+var typeAliases = map[string]string{
+	"character varying":           "varchar",
+	"character":                   "char",
+	"timestamp without time zone": "timestamp",
+	"timestamp with time zone":    "timestamptz",
+	"time without time zone":      "time",
+	"int4":                        "integer",
+	"int8":                        "bigint",
+	"int2":                        "smallint",
+	"bool":                        "boolean",
+}
+
+// lengthTypes 单个长度参数拆到 ColumnDesign.Length 的类型
+var lengthTypes = map[string]bool{
+	"varchar": true, "char": true, "nvarchar": true, "nchar": true, "varbinary": true, "binary": true,
+}
+
+var integerTypes = map[string]bool{
+	"tinyint": true, "smallint": true, "mediumint": true, "int": true, "integer": true, "bigint": true,
+}
+
+func canonicalType(name string) string {
+	if alias, ok := typeAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// defaultExpression 解析 DEFAULT 后的表达式：字符串去掉引号和类型转换，NULL 为空，
+// nextval(...) 表示由序列生成（即自增列）
+func defaultExpression(tokens []token) (string, bool) {
+	for _, t := range tokens {
+		if t.is("nextval") {
+			return "", true
+		}
+	}
+	// 去掉 PostgreSQL 的 ::type 转换和外层括号
+	for {
+		trimmed := false
+		if depth, cast := 0, -1; len(tokens) > 0 {
+			for i, t := range tokens {
+				switch {
+				case t.punct("("):
+					depth++
+				case t.punct(")"):
+					depth--
+				case t.punct("::") && depth == 0 && cast < 0:
+					cast = i
+				}
+			}
+			if cast >= 0 {
+				tokens, trimmed = tokens[:cast], true
+			}
+		}
+		if len(tokens) >= 2 && tokens[0].punct("(") && tokens[len(tokens)-1].punct(")") && closes(tokens) {
+			tokens, trimmed = tokens[1:len(tokens)-1], true
+		}
+		if !trimmed {
+			break
+		}
+	}
+	// MySQL 的字符集前缀 _utf8mb4'...'
+	if len(tokens) == 2 && tokens[0].kind == tokWord && strings.HasPrefix(tokens[0].text, "_") && tokens[1].kind == tokString {
+		tokens = tokens[1:]
+	}
+	switch {
+	case len(tokens) == 0:
+		return "", false
+	case len(tokens) == 1 && tokens[0].is("NULL"):
+		return "", false
+	case len(tokens) == 1 && tokens[0].kind == tokString:
+		return tokens[0].text, false
+	}
+	return rawText(tokens), false
+}
+
+// closes 首个左括号是否与最后一个右括号配对
+func closes(tokens []token) bool {
+	depth := 0
+	for i, t := range tokens {
+		switch {
+		case t.punct("("):
+			depth++
+		case t.punct(")"):
+			depth--
+			if depth == 0 && i < len(tokens)-1 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+func (p *schemaParser) primaryKey(table *ai.TableDesign, columns []string) {
+	for _, name := range columns {
+		if c := column(table, name); c != nil {
+			c.PrimaryKey, c.Nullable = true, false
+		} else {
+			p.warn("表 %s 的主键列 %s 不存在", table.Name, name)
+		}
+	}
+}
+
+// index 表级索引定义 [name] [USING method] (cols)
+func (p *schemaParser) index(table *ai.TableDesign, s *cursor, name string, unique bool) {
+	if !s.peekPunct("(") && !s.peek().is("USING") {
+		name = s.name()
+	}
+	if s.accept("USING") {
+		s.next()
+	}
+	columns, ok := indexColumns(s.group())
+	if !ok {
+		p.warn("表 %s 的表达式索引 %s 未导入", table.Name, name)
+		return
+	}
+	p.addIndex(ai.IndexDesign{Name: name, Table: table.Name, Columns: columns, Unique: unique})
+}
+
+func (p *schemaParser) addIndex(idx ai.IndexDesign) {
+	for _, existing := range p.result.Design.Indexes {
+		if strings.EqualFold(existing.Table, idx.Table) && sameColumns(existing.Columns, idx.Columns) && existing.Unique == idx.Unique {
+			return
+		}
+	}
+	p.result.Design.Indexes = append(p.result.Design.Indexes, idx)
+}
+
+// indexColumns 索引列，允许前缀长度、排序方向、排序规则和操作符类；含表达式时返回 false
+func indexColumns(elements [][]token) ([]string, bool) {
+	var columns []string
+	for _, element := range elements {
+		if len(element) == 0 || !element[0].ident() {
+			return nil, false
+		}
+		for i := 1; i < len(element); i++ {
+			t := element[i]
+			if t.punct("(") {
+				// 前缀长度 col(10)；col(...) 中不是数字则是函数调用
+				if i+2 >= len(element) || element[i+1].kind != tokNumber || !element[i+2].punct(")") {
+					return nil, false
+				}
+				i += 2
+				continue
+			}
+			if !t.ident() && !t.punct(".") {
+				return nil, false
+			}
+		}
+		columns = append(columns, element[0].text)
+	}
+	return columns, len(columns) > 0
+}
+
+// foreignKey FOREIGN KEY (cols) REFERENCES ...
+func (p *schemaParser) foreignKey(table *ai.TableDesign, s *cursor, name string) {
+	if !s.peekPunct("(") {
+		name = s.name()
+	}
+	columns := s.identList()
+	if !s.accept("REFERENCES") {
+		return
+	}
+	p.references(table, s, name, columns)
+}
+
+// references REFERENCES table [(cols)] [ON DELETE ...] [ON UPDATE ...]，复合外键只保留第一列
+func (p *schemaParser) references(table *ai.TableDesign, s *cursor, name string, columns []string) {
+	rel := ai.RelationDesign{Name: name, FromTable: table.Name, ToTable: s.name()}
+	var targets []string
+	if s.peekPunct("(") {
+		targets = s.identList()
+	}
+	if len(columns) == 0 {
+		return
+	}
+	if len(columns) > 1 {
+		p.warn("表 %s 的复合外键 %s 只导入了第一列 %s", table.Name, strings.Join(columns, ", "), columns[0])
+	}
+	rel.FromColumn = columns[0]
+	if len(targets) > 0 {
+		rel.ToColumn = targets[0]
+	}
+	for !s.done() {
+		switch {
+		case s.accept("ON"):
+			event := s.next()
+			action := referenceAction(s)
+			if event.is("DELETE") {
+				rel.OnDelete = action
+			} else if event.is("UPDATE") {
+				rel.OnUpdate = action
+			}
+		case s.peek().is(columnStops...) && !s.peek().is("ON", "MATCH"):
+			// 列定义中 REFERENCES 之后的其他列选项
+			p.result.Design.Relations = append(p.result.Design.Relations, rel)
+			return
+		default:
+			s.next()
+		}
+	}
+	p.result.Design.Relations = append(p.result.Design.Relations, rel)
+}
+
+func referenceAction(s *cursor) string {
+	switch {
+	case s.accept("CASCADE"):
+		return "CASCADE"
+	case s.accept("RESTRICT"):
+		return "RESTRICT"
+	case s.accept("NO"):
+		s.accept("ACTION")
+		return "NO ACTION"
+	case s.accept("SET"):
+		if s.accept("DEFAULT") {
+			return "SET DEFAULT"
+		}
+		s.accept("NULL")
+		return "SET NULL"
+	}
+	return ""
+}
+
+// alterTable pg_dump 在建表之后用 ALTER TABLE 补充主键、唯一约束、外键和序列默认值
+func (p *schemaParser) alterTable(s *cursor) {
+	s.accept("ONLY")
+	if s.accept("IF") {
+		s.accept("EXISTS")
+	}
+	s.accept("ONLY")
+	table := p.tables[strings.ToLower(s.name())]
+	if table == nil {
+		return
+	}
+	for _, action := range s.split() {
+		a := &cursor{tokens: action}
+		switch {
+		case a.accept("ADD"):
+			constraint := ""
+			if a.accept("CONSTRAINT") {
+				constraint = a.name()
+			}
+			switch {
+			case a.accept("PRIMARY"):
+				a.accept("KEY")
+				p.primaryKey(table, a.identList())
+			case a.accept("UNIQUE"):
+				a.accept("KEY", "INDEX")
+				p.index(table, a, constraint, true)
+			case a.accept("KEY", "INDEX"):
+				p.index(table, a, constraint, false)
+			case a.accept("FOREIGN"):
+				a.accept("KEY")
+				p.foreignKey(table, a, constraint)
+			case constraint == "" && !a.peek().is("CHECK", "EXCLUDE", "FULLTEXT", "SPATIAL"):
+				a.accept("COLUMN")
+				a.acceptIfNotExists()
+				p.column(table, a)
+			}
+		case a.accept("ALTER"):
+			a.accept("COLUMN")
+			c := column(table, a.ident())
+			if c == nil {
+				continue
+			}
+			switch {
+			case a.accept("SET"):
+				if a.accept("DEFAULT") {
+					value, sequence := defaultExpression(a.rest())
+					if sequence {
+						c.AutoIncrement = true
+					} else {
+						c.Default = value
+					}
+				} else if a.accept("NOT") {
+					c.Nullable = false
+				}
+			case a.accept("ADD"):
+				if a.accept("GENERATED") {
+					c.AutoIncrement = true
+				}
+			}
+		case a.accept("MODIFY", "CHANGE"):
+			// mysqldump 不会生成，手写脚本中的列修改不导入
+			p.warn("表 %s 的列修改语句未导入", table.Name)
+		}
+	}
+}
+
+// createIndex CREATE [UNIQUE] INDEX [CONCURRENTLY] [IF NOT EXISTS] name ON [ONLY] table [USING method] (cols)
+func (p *schemaParser) createIndex(s *cursor, unique bool) {
+	s.accept("CONCURRENTLY")
+	s.acceptIfNotExists()
+	name := ""
+	if !s.peek().is("ON") {
+		name = s.name()
+	}
+	if !s.accept("ON") {
+		return
+	}
+	s.accept("ONLY")
+	table := p.tables[strings.ToLower(s.name())]
+	if table == nil {
+		return
+	}
+	p.index(table, s, name, unique)
+}
+
+// createType 只导入 PostgreSQL 的枚举类型，供列类型引用
+func (p *schemaParser) createType(s *cursor) {
+	name := s.name()
+	if !s.accept("AS") || !s.accept("ENUM") {
+		p.skip("type", name)
+		return
+	}
+	var values []string
+	for _, element := range s.group() {
+		if len(element) == 1 && element[0].kind == tokString {
+			values = append(values, rawText(element))
+		}
+	}
+	p.enums[strings.ToLower(name)] = "enum(" + strings.Join(values, ",") + ")"
+}
+
+// commentOn COMMENT ON TABLE t IS '...' / COMMENT ON COLUMN t.c IS '...'
+func (p *schemaParser) commentOn(s *cursor) {
+	kind := s.next()
+	if !kind.is("TABLE", "COLUMN") {
+		return
+	}
+	parts := s.qualifiedName()
+	if !s.accept("IS") {
+		return
+	}
+	text := s.next()
+	if text.kind != tokString || len(parts) == 0 {
+		return
+	}
+	if kind.is("TABLE") {
+		if table := p.tables[strings.ToLower(parts[len(parts)-1])]; table != nil {
+			table.Comment = text.text
+		}
+		return
+	}
+	if len(parts) < 2 {
+		return
+	}
+	if table := p.tables[strings.ToLower(parts[len(parts)-2])]; table != nil {
+		if c := column(table, parts[len(parts)-1]); c != nil {
+			c.Comment = text.text
+		}
+	}
+}
+
+// finish 补全外键引用的列，丢弃引用了不存在的表的外键
+func (p *schemaParser) finish() {
+	design := p.result.Design
+	relations := design.Relations[:0]
+	for _, rel := range design.Relations {
+		target := p.tables[strings.ToLower(rel.ToTable)]
+		if target == nil {
+			p.warn("外键 %s.%s 引用的表 %s 不在导出中，已忽略", rel.FromTable, rel.FromColumn, rel.ToTable)
+			continue
+		}
+		rel.ToTable = target.Name
+		if rel.ToColumn == "" {
+			if pk := primaryKey(target); len(pk) == 1 {
+				rel.ToColumn = pk[0]
+			}
+		}
+		relations = append(relations, rel)
+	}
+	design.Relations = relations
+}
+
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func unquote(name string) string {
+	name = strings.TrimSuffix(name, ";")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.Trim(name, "`\"")
+}
+
+// flatten 把按逗号拆分的各项重新拼接起来
+func flatten(elements [][]token) []token {
+	var tokens []token
+	for i, element := range elements {
+		if i > 0 {
+			tokens = append(tokens, token{kind: tokPunct, text: ","})
+		}
+		tokens = append(tokens, element...)
+	}
+	return tokens
+}
+
+// ===== 语句内的游标 =====
+
+type cursor struct {
+	tokens []token
+	pos    int
+}
+
+func (c *cursor) done() bool {
+	return c.pos >= len(c.tokens)
+}
+
+func (c *cursor) peek() token {
+	if c.done() {
+		return token{kind: tokPunct}
+	}
+	return c.tokens[c.pos]
+}
+
+func (c *cursor) prev() token {
+	return c.tokens[c.pos-1]
+}
+
+func (c *cursor) next() token {
+	t := c.peek()
+	if !c.done() {
+		c.pos++
+	}
+	return t
+}
+
+func (c *cursor) accept(words ...string) bool {
+	if c.peek().is(words...) {
+		c.pos++
+		return true
+	}
+	return false
+}
+
+func (c *cursor) acceptPunct(p string) bool {
+	if c.peek().punct(p) {
+		c.pos++
+		return true
+	}
+	return false
+}
+
+func (c *cursor) acceptNumber() bool {
+	if c.peek().kind == tokNumber {
+		c.pos++
+		return true
+	}
+	return false
+}
+
+func (c *cursor) peekPunct(p string) bool {
+	return c.peek().punct(p)
+}
+
+func (c *cursor) acceptIfNotExists() {
+	if c.accept("IF") {
+		c.accept("NOT")
+		c.accept("EXISTS")
+	}
+}
+
+func (c *cursor) rest() []token {
+	rest := c.tokens[c.pos:]
+	c.pos = len(c.tokens)
+	return rest
+}
+
+// ident 单个标识符
+func (c *cursor) ident() string {
+	if c.peek().ident() {
+		return c.next().text
+	}
+	return ""
+}
+
+// qualifiedName 可能带模式名的名称，返回各部分
+func (c *cursor) qualifiedName() []string {
+	var parts []string
+	if !c.peek().ident() {
+		return nil
+	}
+	parts = append(parts, c.next().text)
+	for c.peekPunct(".") {
+		c.next()
+		if !c.peek().ident() {
+			break
+		}
+		parts = append(parts, c.next().text)
+	}
+	return parts
+}
+
+// name 去掉模式名后的对象名
+func (c *cursor) name() string {
+	parts := c.qualifiedName()
+	if len(parts) == 0 {
+		return ""
+	}
+	return parts[len(parts)-1]
+}
+
+// group 读取一对括号，返回其中按顶层逗号拆分的各项；当前不是左括号时返回 nil
+func (c *cursor) group() [][]token {
+	if !c.acceptPunct("(") {
+		return nil
+	}
+	var elements [][]token
+	start, depth := c.pos, 0
+	for ; !c.done(); c.pos++ {
+		t := c.tokens[c.pos]
+		switch {
+		case t.punct("("):
+			depth++
+		case t.punct(")") && depth > 0:
+			depth--
+		case t.punct(")"):
+			elements = append(elements, c.tokens[start:c.pos])
+			c.pos++
+			return elements
+		case t.punct(",") && depth == 0:
+			elements = append(elements, c.tokens[start:c.pos])
+			start = c.pos + 1
+		}
+	}
+	return append(elements, c.tokens[start:])
+}
+
+// identList 括号中的列名列表
+func (c *cursor) identList() []string {
+	var names []string
+	for _, element := range c.group() {
+		if len(element) > 0 && element[0].ident() {
+			names = append(names, element[0].text)
+		}
+	}
+	return names
+}
+
+// split 按顶层逗号拆分剩余部分
+func (c *cursor) split() [][]token {
+	var parts [][]token
+	start, depth := c.pos, 0
+	for ; !c.done(); c.pos++ {
+		t := c.tokens[c.pos]
+		switch {
+		case t.punct("("):
+			depth++
+		case t.punct(")"):
+			depth--
+		case t.punct(",") && depth == 0:
+			parts = append(parts, c.tokens[start:c.pos])
+			start = c.pos + 1
+		}
+	}
+	return append(parts, c.tokens[start:])
+}
+
+// until 读取到顶层出现任一关键字为止，至少读取一个词法单元
+func (c *cursor) until(words ...string) []token {
+	start, depth := c.pos, 0
+	for !c.done() {
+		t := c.peek()
+		if depth == 0 && c.pos > start && t.is(words...) {
+			break
+		}
+		switch {
+		case t.punct("("):
+			depth++
+		case t.punct(")"):
+			depth--
+		}
+		c.pos++
+	}
+	return c.tokens[start:c.pos]
+}
@@ -0,0 +1,223 @@
+package ddl
+
+import (
+	"testing"
+
+	"ai-dev-platform/internal/ai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mysqlDump = "-- MySQL dump 10.13  Distrib 8.0.36, for Linux (x86_64)\n" +
+	"/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */;\n" +
+	"/*!40101 SET NAMES utf8mb4 */;\n" +
+	"DROP TABLE IF EXISTS `users`;\n" +
+	"/*!40101 SET @saved_cs_client     = @@character_set_client */;\n" +
+	"CREATE TABLE `users` (\n" +
+	"  `id` bigint unsigned NOT NULL AUTO_INCREMENT,\n" +
+	"  `email` varchar(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL COMMENT '邮箱; 登录用',\n" +
+	"  `nickname` varchar(50) DEFAULT NULL,\n" +
+	"  `is_active` tinyint(1) NOT NULL DEFAULT '1',\n" +
+	"  `role` enum('admin','member') NOT NULL DEFAULT 'member',\n" +
+	"  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,\n" +
+	"  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+	"  PRIMARY KEY (`id`),\n" +
+	"  UNIQUE KEY `uk_users_email` (`email`),\n" +
+	"  FULLTEXT KEY `ft_users_nickname` (`nickname`)\n" +
+	") ENGINE=InnoDB AUTO_INCREMENT=42 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='用户表';\n" +
+	"/*!40101 SET character_set_client = @saved_cs_client */;\n" +
+	"\n" +
+	"CREATE TABLE `orders` (\n" +
+	"  `id` int(11) NOT NULL AUTO_INCREMENT,\n" +
+	"  `user_id` bigint unsigned DEFAULT NULL,\n" +
+	"  `amount` decimal(10,2) NOT NULL DEFAULT '0.00',\n" +
+	"  `note` text,\n" +
+	"  PRIMARY KEY (`id`),\n" +
+	"  KEY `idx_orders_user` (`user_id`),\n" +
+	"  CONSTRAINT `fk_orders_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE SET NULL\n" +
+	") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n" +
+	"\n" +
+	"/*!50001 CREATE VIEW `v_orders` AS select 1 AS `id` */;\n" +
+	"DELIMITER ;;\n" +
+	"/*!50003 CREATE*/ /*!50017 DEFINER=`root`@`localhost`*/ /*!50003 TRIGGER `trg_orders` BEFORE INSERT ON `orders` FOR EACH ROW BEGIN\n" +
+	"  SET NEW.amount = 0;\n" +
+	"END */;;\n" +
+	"DELIMITER ;\n" +
+	"/*!40101 SET CHARACTER_SET_CLIENT=@OLD_CHARACTER_SET_CLIENT */;\n"
+
+const postgresDump = `--
+-- PostgreSQL database dump
+--
+
+SET statement_timeout = 0;
+SELECT pg_catalog.set_config('search_path', '', false);
+
+CREATE TYPE public.order_status AS ENUM (
+    'pending',
+    'paid'
+);
+
+CREATE FUNCTION public.touch() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+  NEW.updated_at := now(); -- 更新时间;
+  RETURN NEW;
+END;
+$$;
+
+CREATE TABLE public.users (
+    id bigint NOT NULL,
+    email character varying(100) NOT NULL,
+    settings jsonb DEFAULT '{}'::jsonb NOT NULL,
+    created_at timestamp(6) without time zone DEFAULT now() NOT NULL
+);
+
+ALTER TABLE public.users OWNER TO app;
+
+COMMENT ON TABLE public.users IS '用户表';
+COMMENT ON COLUMN public.users.email IS '登录邮箱';
+
+CREATE SEQUENCE public.users_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE public.users_id_seq OWNED BY public.users.id;
+
+CREATE TABLE public.orders (
+    id integer GENERATED BY DEFAULT AS IDENTITY,
+    user_id bigint,
+    status public.order_status DEFAULT 'pending'::public.order_status NOT NULL,
+    tags text[]
+);
+
+CREATE VIEW public.v_orders AS
+ SELECT orders.id FROM public.orders;
+
+ALTER TABLE ONLY public.users ALTER COLUMN id SET DEFAULT nextval('public.users_id_seq'::regclass);
+
+COPY public.users (id, email) FROM stdin;
+1	a@example.com;
+\.
+
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_email_key UNIQUE (email);
+
+ALTER TABLE ONLY public.orders
+    ADD CONSTRAINT orders_pkey PRIMARY KEY (id);
+
+CREATE INDEX idx_orders_user_id ON public.orders USING btree (user_id);
+
+CREATE INDEX idx_users_lower_email ON public.users USING btree (lower((email)::text));
+
+CREATE TRIGGER trg_users BEFORE UPDATE ON public.users FOR EACH ROW EXECUTE FUNCTION public.touch();
+
+ALTER TABLE ONLY public.orders
+    ADD CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+`
+
+func TestDetectDialect(t *testing.T) {
+	assert.Equal(t, MySQL, DetectDialect(mysqlDump))
+	assert.Equal(t, PostgreSQL, DetectDialect(postgresDump))
+	assert.Equal(t, SQLite, DetectDialect("CREATE TABLE t (id INTEGER PRIMARY KEY AUTOINCREMENT);"))
+	assert.Equal(t, MySQL, DetectDialect("CREATE TABLE t (id int);"))
+}
+
+func TestParseSchema_MySQL(t *testing.T) {
+	parsed, err := ParseSchema(mysqlDump, "")
+	require.NoError(t, err)
+	assert.Equal(t, MySQL, parsed.Dialect)
+
+	design := parsed.Design
+	require.Len(t, design.Tables, 2)
+	assert.Equal(t, ai.TableDesign{Name: "users", Comment: "用户表", Columns: []ai.ColumnDesign{
+		{Name: "id", Type: "bigint unsigned", PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: "varchar", Length: 100, Comment: "邮箱; 登录用"},
+		{Name: "nickname", Type: "varchar", Length: 50, Nullable: true},
+		{Name: "is_active", Type: "tinyint(1)", Default: "1"},
+		{Name: "role", Type: "enum('admin','member')", Default: "member"},
+		{Name: "created_at", Type: "datetime", Default: "CURRENT_TIMESTAMP"},
+		{Name: "updated_at", Type: "datetime", Nullable: true, Default: "CURRENT_TIMESTAMP"},
+	}}, design.Tables[0])
+	assert.Equal(t, []ai.ColumnDesign{
+		{Name: "id", Type: "int", PrimaryKey: true, AutoIncrement: true},
+		{Name: "user_id", Type: "bigint unsigned", Nullable: true},
+		{Name: "amount", Type: "decimal(10,2)", Default: "0.00"},
+		{Name: "note", Type: "text", Nullable: true},
+	}, design.Tables[1].Columns)
+
+	assert.Equal(t, []ai.IndexDesign{
+		{Name: "uk_users_email", Table: "users", Columns: []string{"email"}, Unique: true},
+		{Name: "idx_orders_user", Table: "orders", Columns: []string{"user_id"}},
+	}, design.Indexes)
+	assert.Equal(t, []ai.RelationDesign{
+		{Name: "fk_orders_user", FromTable: "orders", FromColumn: "user_id", ToTable: "users", ToColumn: "id", OnDelete: "SET NULL"},
+	}, design.Relations)
+
+	assert.Equal(t, []string{"trigger trg_orders"}, parsed.Skipped)
+	require.Len(t, parsed.Warnings, 1)
+	assert.Contains(t, parsed.Warnings[0], "ft_users_nickname")
+}
+
+func TestParseSchema_PostgreSQL(t *testing.T) {
+	parsed, err := ParseSchema(postgresDump, "")
+	require.NoError(t, err)
+	assert.Equal(t, PostgreSQL, parsed.Dialect)
+
+	design := parsed.Design
+	require.Len(t, design.Tables, 2)
+	assert.Equal(t, ai.TableDesign{Name: "users", Comment: "用户表", Columns: []ai.ColumnDesign{
+		{Name: "id", Type: "bigint", PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: "varchar", Length: 100, Comment: "登录邮箱"},
+		{Name: "settings", Type: "jsonb", Default: "{}"},
+		{Name: "created_at", Type: "timestamp(6)", Default: "now()"},
+	}}, design.Tables[0])
+	assert.Equal(t, []ai.ColumnDesign{
+		{Name: "id", Type: "integer", PrimaryKey: true, AutoIncrement: true},
+		{Name: "user_id", Type: "bigint", Nullable: true},
+		{Name: "status", Type: "enum('pending','paid')", Default: "pending"},
+		{Name: "tags", Type: "text[]", Nullable: true},
+	}, design.Tables[1].Columns)
+
+	assert.Equal(t, []ai.IndexDesign{
+		{Name: "users_email_key", Table: "users", Columns: []string{"email"}, Unique: true},
+		{Name: "idx_orders_user_id", Table: "orders", Columns: []string{"user_id"}},
+	}, design.Indexes)
+	assert.Equal(t, []ai.RelationDesign{
+		{Name: "orders_user_id_fkey", FromTable: "orders", FromColumn: "user_id", ToTable: "users", ToColumn: "id", OnDelete: "CASCADE"},
+	}, design.Relations)
+
+	assert.Equal(t, []string{"function touch", "view v_orders", "trigger trg_users"}, parsed.Skipped)
+	require.Len(t, parsed.Warnings, 1)
+	assert.Contains(t, parsed.Warnings[0], "idx_users_lower_email")
+}
+
+func TestParseSchema_RoundTrip(t *testing.T) {
+	// SQLite 的表和列注释生成为行注释，解析时会丢弃，不参与比较
+	for _, d := range []Dialect{MySQL, PostgreSQL} {
+		t.Run(string(d), func(t *testing.T) {
+			script, err := Generate(sampleDesign(), d)
+			require.NoError(t, err)
+			parsed, err := ParseSchema(script.SQL, d)
+			require.NoError(t, err)
+
+			// 由解析结果再生成的脚本与原脚本一致
+			again, err := Generate(parsed.Design, d)
+			require.NoError(t, err)
+			assert.Equal(t, script.SQL, again.SQL)
+			assert.Empty(t, parsed.Warnings)
+		})
+	}
+}
+
+func TestParseSchema_NoTables(t *testing.T) {
+	_, err := ParseSchema("SET NAMES utf8mb4;\nCREATE VIEW v AS SELECT 1;", MySQL)
+	assert.ErrorIs(t, err, ErrNoTables)
+}
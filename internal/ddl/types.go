@@ -0,0 +1,420 @@
+package ddl
+
+import (
+	"strconv"
+	"strings"
+
+	"ai-dev-platform/internal/ai"
+)
+
+// 类型族，决定各方言下的写法
+const (
+	kindInteger   = "integer"
+	kindDecimal   = "decimal"
+	kindFloat     = "float"
+	kindBool      = "bool"
+	kindChar      = "char"
+	kindVarchar   = "varchar"
+	kindText      = "text"
+	kindDate      = "date"
+	kindTime      = "time"
+	kindTimestamp = "timestamp"
+	kindJSON      = "json"
+	kindBinary    = "binary"
+	kindUUID      = "uuid"
+	kindEnum      = "enum"
+	kindUnknown   = "unknown"
+)
+
+// defaultVarcharLength 未指定长度的 varchar 使用的长度
+const defaultVarcharLength = 255
+
+// typeKinds 设计中常见的类型名称（小写）对应的类型族
+var typeKinds = map[string]string{
+	"tinyint": kindInteger, "smallint": kindInteger, "int2": kindInteger, "mediumint": kindInteger,
+	"int": kindInteger, "integer": kindInteger, "int4": kindInteger,
+	"bigint": kindInteger, "int8": kindInteger, "long": kindInteger,
+	"serial": kindInteger, "smallserial": kindInteger, "bigserial": kindInteger,
+	"decimal": kindDecimal, "numeric": kindDecimal, "number": kindDecimal, "money": kindDecimal,
+	"float": kindFloat, "real": kindFloat, "float4": kindFloat,
+	"double": kindFloat, "double precision": kindFloat, "float8": kindFloat,
+	"bool": kindBool, "boolean": kindBool, "bit": kindBool,
+	"char": kindChar, "character": kindChar, "nchar": kindChar,
+	"varchar": kindVarchar, "character varying": kindVarchar, "nvarchar": kindVarchar,
+	"varchar2": kindVarchar, "string": kindVarchar,
+	"text": kindText, "tinytext": kindText, "mediumtext": kindText, "longtext": kindText, "clob": kindText,
+	"date":     kindDate,
+	"time":     kindTime,
+	"datetime": kindTimestamp, "timestamp": kindTimestamp, "timestamptz": kindTimestamp,
+	"timestamp with time zone": kindTimestamp, "timestamp without time zone": kindTimestamp,
+	"json": kindJSON, "jsonb": kindJSON,
+	"blob": kindBinary, "tinyblob": kindBinary, "mediumblob": kindBinary, "longblob": kindBinary,
+	"binary": kindBinary, "varbinary": kindBinary, "bytea": kindBinary,
+	"uuid": kindUUID,
+	"enum": kindEnum, "set": kindEnum,
+}
+
+// sqlType 解析后的列类型
+type sqlType struct {
+	name     string   // 小写类型名，不含参数
+	kind     string   // 类型族
+	args     []string // 括号中的参数
+	raw      string   // 括号中的原始内容，枚举值原样保留
+	unsigned bool
+	serial   bool // serial 类型隐含自增
+}
+
+// parseType 解析列类型，Type 中带参数（如 varchar(50)）时优先于 Length
+func parseType(c ai.ColumnDesign) sqlType {
+	// 枚举值区分大小写，且小写转换可能改变字节长度，只对括号外的类型名转小写
+	name := strings.Join(strings.Fields(c.Type), " ")
+	t := sqlType{}
+	if open := strings.Index(name, "("); open >= 0 {
+		if end := strings.LastIndex(name, ")"); end > open {
+			t.raw = strings.TrimSpace(name[open+1 : end])
+			name = strings.TrimSpace(name[:open] + name[end+1:])
+			for _, arg := range strings.Split(t.raw, ",") {
+				t.args = append(t.args, strings.TrimSpace(arg))
+			}
+		}
+	}
+	name = strings.ToLower(name)
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, modifier := range []string{" unsigned", " zerofill", " signed"} {
+			if strings.HasSuffix(name, modifier) {
+				t.unsigned = t.unsigned || modifier == " unsigned"
+				name = strings.TrimSuffix(name, modifier)
+				trimmed = true
+			}
+		}
+	}
+	if strings.HasSuffix(name, "[]") {
+		// 数组只有 PostgreSQL 支持，其余方言按未知类型原样输出
+		t.name, t.kind = name, kindUnknown
+		return t
+	}
+	if len(t.args) == 0 && c.Length > 0 {
+		t.args = []string{strconv.Itoa(c.Length)}
+		t.raw = t.args[0]
+	}
+	t.name = name
+	t.kind = typeKinds[name]
+	if t.kind == "" {
+		t.kind = kindUnknown
+	}
+	t.serial = strings.HasSuffix(name, "serial")
+	return t
+}
+
+// known 类型是否能识别
+func (t sqlType) known() bool {
+	return t.kind != kindUnknown
+}
+
+// length varchar、char 的长度，未指定时为 0
+func (t sqlType) length() int {
+	if len(t.args) == 0 {
+		return 0
+	}
+	n, _ := strconv.Atoi(t.args[0])
+	return n
+}
+
+// keyBytes 作为索引键时占用的最大字节数（utf8mb4 每个字符最多 4 字节），无法确定时为 0
+func (t sqlType) keyBytes() int {
+	switch t.kind {
+	case kindVarchar:
+		n := t.length()
+		if n == 0 {
+			n = defaultVarcharLength
+		}
+		return n * 4
+	case kindChar:
+		n := t.length()
+		if n == 0 {
+			n = 1
+		}
+		return n * 4
+	case kindText:
+		return 65535
+	}
+	return 0
+}
+
+// render 方言下的类型写法
+func (t sqlType) render(d Dialect) string {
+	switch d {
+	case PostgreSQL:
+		return t.postgres()
+	case SQLite:
+		return t.sqlite()
+	}
+	return t.mysql()
+}
+
+func (t sqlType) mysql() string {
+	var s string
+	switch t.kind {
+	case kindInteger:
+		switch t.name {
+		case "tinyint", "smallint", "mediumint", "bigint":
+			s = strings.ToUpper(t.name)
+		case "int2", "smallserial":
+			s = "SMALLINT"
+		case "int8", "long", "bigserial":
+			s = "BIGINT"
+		default:
+			s = "INT"
+		}
+		if t.name == "tinyint" && len(t.args) > 0 {
+			// tinyint(1) 约定表示布尔值，保留显示宽度
+			s += "(" + t.args[0] + ")"
+		}
+	case kindDecimal:
+		s = "DECIMAL" + t.params()
+	case kindFloat:
+		if t.name == "float" || t.name == "real" || t.name == "float4" {
+			s = "FLOAT"
+		} else {
+			s = "DOUBLE"
+		}
+	case kindBool:
+		return "TINYINT(1)"
+	case kindChar:
+		return "CHAR" + t.params()
+	case kindVarchar:
+		return "VARCHAR(" + strconv.Itoa(t.varcharLength()) + ")"
+	case kindText:
+		if t.name == "tinytext" || t.name == "mediumtext" || t.name == "longtext" {
+			return strings.ToUpper(t.name)
+		}
+		return "TEXT"
+	case kindDate:
+		return "DATE"
+	case kindTime:
+		return "TIME" + t.params()
+	case kindTimestamp:
+		if t.name == "datetime" {
+			return "DATETIME" + t.params()
+		}
+		return "TIMESTAMP" + t.params()
+	case kindJSON:
+		return "JSON"
+	case kindBinary:
+		switch t.name {
+		case "binary", "varbinary":
+			if len(t.args) == 0 {
+				return strings.ToUpper(t.name) + "(" + strconv.Itoa(defaultVarcharLength) + ")"
+			}
+			return strings.ToUpper(t.name) + t.params()
+		case "tinyblob", "mediumblob", "longblob":
+			return strings.ToUpper(t.name)
+		}
+		return "BLOB"
+	case kindUUID:
+		return "CHAR(36)"
+	case kindEnum:
+		return strings.ToUpper(t.name) + "(" + t.raw + ")"
+	default:
+		return t.unknown()
+	}
+	if t.unsigned {
+		s += " UNSIGNED"
+	}
+	return s
+}
+
+func (t sqlType) postgres() string {
+	switch t.kind {
+	case kindInteger:
+		switch t.name {
+		case "tinyint", "smallint", "int2", "smallserial":
+			return "SMALLINT"
+		case "bigint", "int8", "long", "bigserial":
+			return "BIGINT"
+		case "int", "integer", "int4", "serial":
+			if t.unsigned {
+				// 无符号 int 的取值范围超出 INTEGER
+				return "BIGINT"
+			}
+		}
+		return "INTEGER"
+	case kindDecimal:
+		return "NUMERIC" + t.params()
+	case kindFloat:
+		if t.name == "float" || t.name == "real" || t.name == "float4" {
+			return "REAL"
+		}
+		return "DOUBLE PRECISION"
+	case kindBool:
+		return "BOOLEAN"
+	case kindChar:
+		return "CHAR" + t.params()
+	case kindVarchar:
+		return "VARCHAR(" + strconv.Itoa(t.varcharLength()) + ")"
+	case kindText:
+		return "TEXT"
+	case kindDate:
+		return "DATE"
+	case kindTime:
+		return "TIME" + t.params()
+	case kindTimestamp:
+		if t.name == "timestamptz" || t.name == "timestamp with time zone" {
+			return "TIMESTAMPTZ" + t.params()
+		}
+		return "TIMESTAMP" + t.params()
+	case kindJSON:
+		return "JSONB"
+	case kindBinary:
+		return "BYTEA"
+	case kindUUID:
+		return "UUID"
+	case kindEnum:
+		return "VARCHAR(" + strconv.Itoa(defaultVarcharLength) + ")"
+	}
+	return t.unknown()
+}
+
+// sqlite 按 SQLite 的类型亲和性映射
+func (t sqlType) sqlite() string {
+	switch t.kind {
+	case kindInteger, kindBool:
+		return "INTEGER"
+	case kindDecimal:
+		return "NUMERIC"
+	case kindFloat:
+		return "REAL"
+	case kindBinary:
+		return "BLOB"
+	case kindUnknown:
+		return t.unknown()
+	}
+	return "TEXT"
+}
+
+func (t sqlType) varcharLength() int {
+	if n := t.length(); n > 0 {
+		return n
+	}
+	return defaultVarcharLength
+}
+
+func (t sqlType) params() string {
+	if len(t.args) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(t.args, ",") + ")"
+}
+
+func (t sqlType) unknown() string {
+	return strings.ToUpper(t.name) + t.params()
+}
+
+// integerRank 整数类型的取值范围等级，用于比较外键两端的类型
+func (t sqlType) integerRank() int {
+	switch t.name {
+	case "tinyint":
+		return 1
+	case "smallint", "int2", "smallserial":
+		return 2
+	case "mediumint":
+		return 3
+	case "bigint", "int8", "long", "bigserial":
+		return 5
+	}
+	return 4
+}
+
+// textRank text、blob 类型的容量等级
+func (t sqlType) textRank() int {
+	switch t.name {
+	case "tinytext", "tinyblob":
+		return 1
+	case "mediumtext", "mediumblob":
+		return 3
+	case "longtext", "longblob", "clob", "bytea":
+		return 4
+	}
+	return 2
+}
+
+// widens 由 old 改为 t 是否只放宽了取值范围，现有数据不会被截断或转换失败：
+// 加长字符串、改为更大的整数或文本类型、增加小数位数或枚举值
+func (t sqlType) widens(old sqlType) bool {
+	switch {
+	case old.kind == kindChar && (t.kind == kindVarchar || t.kind == kindChar):
+		return t.charLength() >= old.charLength()
+	case old.kind == kindVarchar && t.kind == kindVarchar:
+		return t.charLength() >= old.charLength()
+	case (old.kind == kindChar || old.kind == kindVarchar) && t.kind == kindText:
+		return true
+	case old.kind != t.kind:
+		return false
+	}
+
+	switch t.kind {
+	case kindInteger:
+		// 有符号改为无符号会丢失负数，无符号改为有符号需要更大的整数类型
+		if old.unsigned != t.unsigned {
+			return old.unsigned && t.integerRank() > old.integerRank()
+		}
+		return t.integerRank() >= old.integerRank()
+	case kindFloat:
+		return t.floatRank() >= old.floatRank()
+	case kindDecimal:
+		oldPrecision, oldScale := old.decimalDigits()
+		precision, scale := t.decimalDigits()
+		return scale >= oldScale && precision-scale >= oldPrecision-oldScale
+	case kindText, kindBinary:
+		return t.textRank() >= old.textRank()
+	case kindEnum:
+		values := make(map[string]bool, len(t.args))
+		for _, v := range t.args {
+			values[v] = true
+		}
+		for _, v := range old.args {
+			if !values[v] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// charLength char、varchar 的长度，未指定时使用各自的默认长度
+func (t sqlType) charLength() int {
+	if n := t.length(); n > 0 {
+		return n
+	}
+	if t.kind == kindChar {
+		return 1
+	}
+	return defaultVarcharLength
+}
+
+// floatRank 浮点类型的精度等级
+func (t sqlType) floatRank() int {
+	switch t.name {
+	case "double", "double precision", "float8":
+		return 2
+	}
+	return 1
+}
+
+// decimalDigits decimal 的总位数和小数位数，未指定时为 MySQL 的默认值 (10, 0)
+func (t sqlType) decimalDigits() (int, int) {
+	precision, scale := 10, 0
+	if len(t.args) > 0 {
+		if n, err := strconv.Atoi(t.args[0]); err == nil {
+			precision = n
+		}
+	}
+	if len(t.args) > 1 {
+		if n, err := strconv.Atoi(t.args[1]); err == nil {
+			scale = n
+		}
+	}
+	return precision, scale
+}
@@ -0,0 +1,213 @@
+// Package ears 基于规则的 EARS（Easy Approach to Requirements Syntax）需求语句检查器，
+// 支持中英文需求的句型分类、歧义词检查和改写建议，不依赖AI调用
+package ears
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Pattern EARS句型
+type Pattern string
+
+const (
+	PatternUbiquitous Pattern = "ubiquitous"         // 通用型：The <system> shall <response>
+	PatternEvent      Pattern = "event_driven"       // 事件驱动：When <trigger>, the <system> shall <response>
+	PatternState      Pattern = "state_driven"       // 状态驱动：While <state>, the <system> shall <response>
+	PatternUnwanted   Pattern = "unwanted_behaviour" // 异常行为：If <condition>, then the <system> shall <response>
+	PatternOptional   Pattern = "optional"           // 可选特性：Where <feature>, the <system> shall <response>
+	PatternComplex    Pattern = "complex"            // 组合句型，如 While ... When ...
+	PatternUnknown    Pattern = "unknown"            // 无法识别
+)
+
+// Severity 问题严重程度
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// 检查规则标识
+const (
+	RuleAmbiguousTerm        = "ambiguous_term"
+	RuleWeakModal            = "weak_modal"
+	RuleMissingResponse      = "missing_response"
+	RuleMissingTrigger       = "missing_trigger"
+	RuleMissingThen          = "missing_then"
+	RuleMissingSystem        = "missing_system"
+	RuleMultipleRequirements = "multiple_requirements"
+	RuleTooLong              = "too_long"
+)
+
+// Finding 单条检查结果
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Term     string   `json:"term,omitempty"`
+}
+
+// Result 单条需求语句的检查结果
+type Result struct {
+	Text       string    `json:"text"`
+	Language   string    `json:"language"` // zh 或 en
+	Pattern    Pattern   `json:"pattern"`
+	Condition  string    `json:"condition,omitempty"` // 触发事件、状态、异常条件或可选特性
+	State      string    `json:"state,omitempty"`     // 组合句型中的状态前提
+	System     string    `json:"system,omitempty"`
+	Response   string    `json:"response,omitempty"`
+	Findings   []Finding `json:"findings"`
+	Suggestion string    `json:"suggestion,omitempty"` // 改写建议，语句合规时为空
+}
+
+// Summary 检查汇总
+type Summary struct {
+	Total     int             `json:"total"`
+	Compliant int             `json:"compliant"` // 无 error/warning 的语句数
+	Errors    int             `json:"errors"`
+	Warnings  int             `json:"warnings"`
+	Patterns  map[Pattern]int `json:"patterns"`
+}
+
+// Report 检查报告
+type Report struct {
+	Results []Result `json:"results"`
+	Summary Summary  `json:"summary"`
+}
+
+// 列表序号、项目符号等前缀
+var listMarkerRe = regexp.MustCompile(`^\s*(?:[-*•·]|\d+[.、)）]|[（(]\d+[)）]|[一二三四五六七八九十]+[、.])\s*`)
+
+// SplitSentences 将需求文本按行和句末标点拆分为需求语句
+func SplitSentences(text string) []string {
+	var sentences []string
+	for _, line := range strings.Split(text, "\n") {
+		line = listMarkerRe.ReplaceAllString(strings.TrimSpace(line), "")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var current strings.Builder
+		runes := []rune(line)
+		for i, r := range runes {
+			current.WriteRune(r)
+			end := false
+			switch r {
+			case '。', '；', ';', '！', '？', '!', '?':
+				end = true
+			case '.':
+				// 英文句点后需跟空白才视为句末，避免拆分小数和缩写 "etc."
+				if i+1 < len(runes) && unicode.IsSpace(runes[i+1]) && !strings.HasSuffix(strings.ToLower(current.String()), "etc.") {
+					end = true
+				}
+			}
+			if end {
+				if s := strings.TrimSpace(current.String()); s != "" {
+					sentences = append(sentences, s)
+				}
+				current.Reset()
+			}
+		}
+		if s := strings.TrimSpace(current.String()); s != "" {
+			sentences = append(sentences, s)
+		}
+	}
+	return sentences
+}
+
+// LintText 拆分并检查一段需求文本
+func LintText(text string) *Report {
+	return Lint(SplitSentences(text))
+}
+
+// Lint 检查一组需求语句
+func Lint(sentences []string) *Report {
+	report := &Report{
+		Results: make([]Result, 0, len(sentences)),
+		Summary: Summary{Patterns: make(map[Pattern]int)},
+	}
+
+	for _, sentence := range sentences {
+		sentence = strings.TrimSpace(sentence)
+		if sentence == "" {
+			continue
+		}
+
+		result := Check(sentence)
+		report.Results = append(report.Results, result)
+
+		report.Summary.Total++
+		report.Summary.Patterns[result.Pattern]++
+		compliant := true
+		for _, f := range result.Findings {
+			switch f.Severity {
+			case SeverityError:
+				report.Summary.Errors++
+				compliant = false
+			case SeverityWarning:
+				report.Summary.Warnings++
+				compliant = false
+			}
+		}
+		if compliant {
+			report.Summary.Compliant++
+		}
+	}
+
+	return report
+}
+
+// Check 检查单条需求语句：分类句型、检查问题并给出改写建议
+func Check(sentence string) Result {
+	sentence = strings.TrimSpace(sentence)
+
+	var result Result
+	if DetectLanguage(sentence) == "zh" {
+		result = parseChinese(sentence)
+	} else {
+		result = parseEnglish(sentence)
+	}
+	result.Text = sentence
+
+	result.Findings = append(result.Findings, structuralFindings(&result)...)
+	result.Findings = append(result.Findings, termFindings(sentence, result.Language)...)
+	if result.Findings == nil {
+		result.Findings = []Finding{}
+	}
+
+	if needsRewrite(result.Findings) {
+		result.Suggestion = suggest(&result)
+	}
+	return result
+}
+
+// DetectLanguage 根据汉字比例判断语言
+func DetectLanguage(text string) string {
+	han, letters := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.IsLetter(r):
+			letters++
+		}
+	}
+	// 一个汉字的信息量约等于若干字母，按 1:3 比较
+	if han > 0 && han*3 >= letters {
+		return "zh"
+	}
+	return "en"
+}
+
+// needsRewrite 存在 error 或 warning 时才给出改写建议
+func needsRewrite(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError || f.Severity == SeverityWarning {
+			return true
+		}
+	}
+	return false
+}
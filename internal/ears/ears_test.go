@@ -0,0 +1,159 @@
+package ears
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hasRule(result Result, rule string) bool {
+	for _, f := range result.Findings {
+		if f.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCheckEnglishPatterns(t *testing.T) {
+	tests := []struct {
+		sentence string
+		pattern  Pattern
+		cond     string
+		system   string
+	}{
+		{"The system shall log every login attempt.", PatternUbiquitous, "", "The system"},
+		{"When the user clicks submit, the system shall save the order.", PatternEvent, "the user clicks submit", "the system"},
+		{"While the device is offline, the app shall queue requests.", PatternState, "the device is offline", "the app"},
+		{"If the payment fails, then the system shall notify the user.", PatternUnwanted, "the payment fails", "the system"},
+		{"Where encryption is enabled, the system shall encrypt backups.", PatternOptional, "encryption is enabled", "the system"},
+		{"While in maintenance mode, when a request arrives, the gateway shall return 503.", PatternComplex, "a request arrives", "the gateway"},
+	}
+
+	for _, tt := range tests {
+		result := Check(tt.sentence)
+		assert.Equal(t, "en", result.Language, tt.sentence)
+		assert.Equal(t, tt.pattern, result.Pattern, tt.sentence)
+		assert.Equal(t, tt.cond, result.Condition, tt.sentence)
+		assert.Equal(t, tt.system, result.System, tt.sentence)
+		assert.NotEmpty(t, result.Response, tt.sentence)
+		assert.Empty(t, result.Suggestion, tt.sentence)
+	}
+}
+
+func TestCheckChinesePatterns(t *testing.T) {
+	tests := []struct {
+		sentence string
+		pattern  Pattern
+		cond     string
+		system   string
+		response string
+	}{
+		{"系统应记录所有登录尝试。", PatternUbiquitous, "", "系统", "记录所有登录尝试"},
+		{"当用户点击提交时，系统应保存订单。", PatternEvent, "用户点击提交", "系统", "保存订单"},
+		{"在设备离线期间，应用应缓存请求。", PatternState, "设备离线", "应用", "缓存请求"},
+		{"如果支付失败，那么系统应通知用户。", PatternUnwanted, "支付失败", "系统", "通知用户"},
+		{"在启用加密的情况下，系统应加密备份。", PatternOptional, "启用加密", "系统", "加密备份"},
+	}
+
+	for _, tt := range tests {
+		result := Check(tt.sentence)
+		assert.Equal(t, "zh", result.Language, tt.sentence)
+		assert.Equal(t, tt.pattern, result.Pattern, tt.sentence)
+		assert.Equal(t, tt.cond, result.Condition, tt.sentence)
+		assert.Equal(t, tt.system, result.System, tt.sentence)
+		assert.Equal(t, tt.response, result.Response, tt.sentence)
+		assert.Empty(t, result.Suggestion, tt.sentence)
+	}
+}
+
+func TestCheckChineseModalFalsePositive(t *testing.T) {
+	// “响应”中的“应”不是情态词
+	result := Check("当用户请求时，系统应在2秒内返回响应。")
+	assert.Equal(t, PatternEvent, result.Pattern)
+	assert.Equal(t, "系统", result.System)
+	assert.Equal(t, "在2秒内返回响应", result.Response)
+
+	result = Check("系统响应时间")
+	assert.Equal(t, PatternUnknown, result.Pattern)
+	assert.True(t, hasRule(result, RuleMissingResponse))
+}
+
+func TestCheckAmbiguousTerms(t *testing.T) {
+	result := Check("系统应提供快速、友好的查询功能等。")
+	var terms []string
+	for _, f := range result.Findings {
+		if f.Rule == RuleAmbiguousTerm {
+			terms = append(terms, f.Term)
+		}
+	}
+	assert.ElementsMatch(t, []string{"快速", "友好", "等"}, terms)
+	assert.Contains(t, result.Suggestion, "<量化“快速”>")
+
+	// 长词优先，被长词覆盖的位置不再报告短词
+	result = Check("系统应提供用户友好的界面，数据安全可靠。")
+	terms = nil
+	for _, f := range result.Findings {
+		if f.Rule == RuleAmbiguousTerm {
+			terms = append(terms, f.Term)
+		}
+	}
+	assert.ElementsMatch(t, []string{"用户友好", "安全可靠"}, terms)
+
+	result = Check("The system should be user-friendly and fast, etc.")
+	assert.True(t, hasRule(result, RuleWeakModal))
+	terms = nil
+	for _, f := range result.Findings {
+		if f.Rule == RuleAmbiguousTerm {
+			terms = append(terms, f.Term)
+		}
+	}
+	assert.ElementsMatch(t, []string{"user-friendly", "fast", "etc"}, terms)
+	assert.Contains(t, result.Suggestion, `<quantify "fast">`)
+}
+
+func TestCheckMissingParts(t *testing.T) {
+	result := Check("When, the system shall beep.")
+	assert.Equal(t, PatternEvent, result.Pattern)
+	assert.True(t, hasRule(result, RuleMissingTrigger))
+
+	result = Check("Users can export reports")
+	assert.Equal(t, PatternUbiquitous, result.Pattern)
+	assert.True(t, hasRule(result, RuleWeakModal))
+
+	result = Check("Export reports to PDF")
+	assert.Equal(t, PatternUnknown, result.Pattern)
+	assert.True(t, hasRule(result, RuleMissingResponse))
+	assert.Equal(t, "The system shall Export reports to PDF.", result.Suggestion)
+
+	result = Check("如果网络中断，系统应重试三次。")
+	assert.Equal(t, PatternUnwanted, result.Pattern)
+	assert.True(t, hasRule(result, RuleMissingThen))
+	assert.Equal(t, "如果网络中断，那么系统应重试三次。", result.Suggestion)
+}
+
+func TestCheckMultipleRequirements(t *testing.T) {
+	result := Check("The system shall save the draft and the system shall notify the author.")
+	assert.True(t, hasRule(result, RuleMultipleRequirements))
+}
+
+func TestSplitSentences(t *testing.T) {
+	text := "# 功能需求\n1. 系统应支持登录。系统应支持注销；\n- The system shall log errors, etc. and retry. The app shall sync 1.5 MB files."
+	sentences := SplitSentences(text)
+	require.Len(t, sentences, 4)
+	assert.Equal(t, "系统应支持登录。", sentences[0])
+	assert.Equal(t, "系统应支持注销；", sentences[1])
+	assert.True(t, strings.HasSuffix(sentences[2], "retry."))
+	assert.Equal(t, "The app shall sync 1.5 MB files.", sentences[3])
+}
+
+func TestLintSummary(t *testing.T) {
+	report := Lint([]string{"系统应记录日志。", "系统应该快速响应。", "  ", "Export data"})
+	assert.Equal(t, 3, report.Summary.Total)
+	assert.Equal(t, 1, report.Summary.Compliant)
+	assert.Equal(t, 1, report.Summary.Errors)
+	assert.Equal(t, 2, report.Summary.Patterns[PatternUbiquitous])
+	assert.Equal(t, 1, report.Summary.Patterns[PatternUnknown])
+}
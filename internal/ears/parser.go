@@ -0,0 +1,289 @@
+package ears
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// 语句成分两端需去除的标点
+const trimChars = " \t，,。.；;：:！!？?"
+
+// ===== 英文 =====
+
+var (
+	enComplexRe = regexp.MustCompile(`(?i)^while\s+(.+?),\s*when\s+(.+?),\s*(.*)$`)
+	enPrefixRe  = regexp.MustCompile(`(?i)^(when|while|if|where)\b\s*(.*)$`)
+	enModalRe   = regexp.MustCompile(`(?i)\b(shall|must|should|may|might|could|will|can)\b`)
+	enThenRe    = regexp.MustCompile(`(?i)^then\b\s*`)
+	enTheRe     = regexp.MustCompile(`(?i)\s(the|a|an)\s`)
+)
+
+// enPatterns 英文关键字对应的句型
+var enPatterns = map[string]Pattern{
+	"when":  PatternEvent,
+	"while": PatternState,
+	"if":    PatternUnwanted,
+	"where": PatternOptional,
+}
+
+// enStrongModals 符合EARS要求的情态动词
+var enStrongModals = map[string]bool{"shall": true, "must": true}
+
+// enWeakModals 语气过弱、会被理解为非强制的情态动词
+var enWeakModals = map[string]bool{"should": true, "may": true, "might": true, "could": true}
+
+// parseEnglish 解析英文需求语句
+func parseEnglish(sentence string) Result {
+	result := Result{Language: "en", Pattern: PatternUnknown}
+	text := strings.Trim(sentence, trimChars)
+
+	var main string
+	if m := enComplexRe.FindStringSubmatch(text); m != nil {
+		result.Pattern = PatternComplex
+		result.State = strings.Trim(m[1], trimChars)
+		result.Condition = strings.Trim(m[2], trimChars)
+		main = m[3]
+	} else if m := enPrefixRe.FindStringSubmatch(text); m != nil {
+		result.Pattern = enPatterns[strings.ToLower(m[1])]
+		result.Condition, main = splitEnglishClause(m[2])
+	} else {
+		main = text
+	}
+
+	if result.Pattern == PatternUnwanted {
+		if loc := enThenRe.FindStringIndex(main); loc != nil {
+			main = main[loc[1]:]
+		} else {
+			result.Findings = append(result.Findings, Finding{
+				Rule:     RuleMissingThen,
+				Severity: SeverityWarning,
+				Message:  "If 句型缺少 then，应写作 \"If <condition>, then the <system> shall <response>\"",
+			})
+		}
+	}
+
+	modals := enModalRe.FindAllStringIndex(main, -1)
+	if len(modals) == 0 {
+		return result
+	}
+	first := modals[0]
+	modal := strings.ToLower(main[first[0]:first[1]])
+	result.System = strings.Trim(main[:first[0]], trimChars)
+	result.Response = strings.Trim(main[first[1]:], trimChars)
+	if result.Pattern == PatternUnknown {
+		result.Pattern = PatternUbiquitous
+	}
+
+	switch {
+	case enWeakModals[modal]:
+		result.Findings = append(result.Findings, Finding{
+			Rule:     RuleWeakModal,
+			Severity: SeverityWarning,
+			Message:  "\"" + modal + "\" 表达的是可选或建议，强制性需求应使用 \"shall\"",
+			Term:     modal,
+		})
+	case !enStrongModals[modal]:
+		result.Findings = append(result.Findings, Finding{
+			Rule:     RuleWeakModal,
+			Severity: SeverityInfo,
+			Message:  "建议使用 \"shall\" 代替 \"" + modal + "\"",
+			Term:     modal,
+		})
+	}
+
+	if countModal(main, modals, "shall") > 1 {
+		result.Findings = append(result.Findings, multipleFinding())
+	}
+	return result
+}
+
+// splitEnglishClause 拆分条件从句与主句：优先按逗号，否则在情态动词前的冠词处拆分
+func splitEnglishClause(rest string) (string, string) {
+	if i := strings.Index(rest, ","); i >= 0 {
+		return strings.Trim(rest[:i], trimChars), strings.TrimSpace(rest[i+1:])
+	}
+
+	modal := enModalRe.FindStringIndex(rest)
+	if modal == nil {
+		return strings.Trim(rest, trimChars), ""
+	}
+	articles := enTheRe.FindAllStringIndex(rest[:modal[0]], -1)
+	if len(articles) == 0 {
+		return strings.Trim(rest, trimChars), ""
+	}
+	split := articles[len(articles)-1][0]
+	return strings.Trim(rest[:split], trimChars), strings.TrimSpace(rest[split:])
+}
+
+// countModal 统计指定情态动词出现次数
+func countModal(text string, locs [][]int, modal string) int {
+	count := 0
+	for _, loc := range locs {
+		if strings.EqualFold(text[loc[0]:loc[1]], modal) {
+			count++
+		}
+	}
+	return count
+}
+
+// ===== 中文 =====
+
+var (
+	zhComplexRe  = regexp.MustCompile(`^(?:在|处于)([^，,]+?)(?:期间|状态下|过程中|时段内)[，,]\s*当([^，,]+?)时[，,]?\s*(.*)$`)
+	zhStateRe    = regexp.MustCompile(`^(?:当处于|在|处于)([^，,]+?)(?:期间|状态下|状态时|过程中|时段内)[，,]?\s*(.*)$`)
+	zhOptionalRe = regexp.MustCompile(`^(?:(?:在|对于)([^，,]+?)的情况下|(?:如果|若)((?:启用|开启|配置|支持|具备|包含)[^，,]+?))[，,]\s*(.*)$`)
+	zhEventRe    = regexp.MustCompile(`^(?:当([^，,]+?)时[，,]?|一旦([^，,]+?)[，,]|在([^，,]+?)(?:时|之后|后)[，,])\s*(.*)$`)
+	zhUnwantedRe = regexp.MustCompile(`^(?:如果|若|假如|倘若)([^，,]+?)[，,]\s*(.*)$`)
+	zhThenRe     = regexp.MustCompile(`^(?:那么|则|就)\s*`)
+	zhModalRe    = regexp.MustCompile(`应当|应该|必须|需要|能够|可以|可能|尽量|应|须|要|将`)
+)
+
+// zhStrongModals 符合EARS要求的情态词
+var zhStrongModals = map[string]bool{"应": true, "应当": true, "必须": true, "须": true}
+
+// zhWeakModals 语气过弱的情态词
+var zhWeakModals = map[string]bool{"应该": true, "可以": true, "可能": true, "尽量": true}
+
+// 情态词误判排除：前一个字或后一个字命中时，不视为情态词（如“响应”“应用”“重要”“要求”）
+var (
+	zhModalBadPrev = map[string]string{
+		"应": "响反对供适相回感呼效答理",
+		"要": "主重摘概纲简首必需只",
+		"将": "即大",
+		"须": "胡必",
+	}
+	zhModalBadNext = map[string]string{
+		"应": "用对急答聘变付酬邀届试验",
+		"要": "求素点件闻领害",
+		"将": "来军",
+	}
+)
+
+// parseChinese 解析中文需求语句
+func parseChinese(sentence string) Result {
+	result := Result{Language: "zh", Pattern: PatternUnknown}
+	text := strings.Trim(sentence, trimChars)
+
+	var main string
+	switch {
+	case zhComplexRe.MatchString(text):
+		m := zhComplexRe.FindStringSubmatch(text)
+		result.Pattern = PatternComplex
+		result.State, result.Condition, main = m[1], m[2], m[3]
+	case zhStateRe.MatchString(text):
+		m := zhStateRe.FindStringSubmatch(text)
+		result.Pattern = PatternState
+		result.Condition, main = m[1], m[2]
+	case zhOptionalRe.MatchString(text):
+		m := zhOptionalRe.FindStringSubmatch(text)
+		result.Pattern = PatternOptional
+		result.Condition, main = firstNonEmpty(m[1], m[2]), m[3]
+	case zhEventRe.MatchString(text):
+		m := zhEventRe.FindStringSubmatch(text)
+		result.Pattern = PatternEvent
+		result.Condition, main = firstNonEmpty(m[1], m[2], m[3]), m[4]
+	case zhUnwantedRe.MatchString(text):
+		m := zhUnwantedRe.FindStringSubmatch(text)
+		result.Pattern = PatternUnwanted
+		result.Condition, main = m[1], m[2]
+	default:
+		main = text
+	}
+	result.State = strings.Trim(result.State, trimChars)
+	result.Condition = strings.Trim(result.Condition, trimChars)
+	main = strings.TrimSpace(main)
+
+	if result.Pattern == PatternUnwanted {
+		if loc := zhThenRe.FindStringIndex(main); loc != nil {
+			main = main[loc[1]:]
+		} else {
+			result.Findings = append(result.Findings, Finding{
+				Rule:     RuleMissingThen,
+				Severity: SeverityWarning,
+				Message:  "“如果”句型缺少“那么”，应写作“如果<条件>，那么<系统>应<响应>”",
+			})
+		}
+	}
+
+	modals := zhModals(main)
+	if len(modals) == 0 {
+		return result
+	}
+	first := modals[0]
+	modal := main[first[0]:first[1]]
+	result.System = strings.Trim(main[:first[0]], trimChars)
+	result.Response = strings.Trim(main[first[1]:], trimChars)
+	if result.Pattern == PatternUnknown {
+		result.Pattern = PatternUbiquitous
+	}
+
+	switch {
+	case zhWeakModals[modal]:
+		result.Findings = append(result.Findings, Finding{
+			Rule:     RuleWeakModal,
+			Severity: SeverityWarning,
+			Message:  "“" + modal + "”表达的是可选或建议，强制性需求应使用“应”",
+			Term:     modal,
+		})
+	case !zhStrongModals[modal]:
+		result.Findings = append(result.Findings, Finding{
+			Rule:     RuleWeakModal,
+			Severity: SeverityInfo,
+			Message:  "建议使用“应”代替“" + modal + "”",
+			Term:     modal,
+		})
+	}
+
+	strong := 0
+	for _, loc := range modals {
+		if zhStrongModals[main[loc[0]:loc[1]]] {
+			strong++
+		}
+	}
+	if strong > 1 {
+		result.Findings = append(result.Findings, multipleFinding())
+	}
+	return result
+}
+
+// zhModals 查找中文情态词位置，排除“响应”“重要”等词中的误判
+func zhModals(text string) [][]int {
+	var modals [][]int
+	for _, loc := range zhModalRe.FindAllStringIndex(text, -1) {
+		word := text[loc[0]:loc[1]]
+		if prev, ok := zhModalBadPrev[word]; ok && loc[0] > 0 {
+			r, _ := utf8.DecodeLastRuneInString(text[:loc[0]])
+			if strings.ContainsRune(prev, r) {
+				continue
+			}
+		}
+		if next, ok := zhModalBadNext[word]; ok && loc[1] < len(text) {
+			r, _ := utf8.DecodeRuneInString(text[loc[1]:])
+			if strings.ContainsRune(next, r) {
+				continue
+			}
+		}
+		modals = append(modals, loc)
+	}
+	return modals
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// multipleFinding 一句包含多条需求
+func multipleFinding() Finding {
+	return Finding{
+		Rule:     RuleMultipleRequirements,
+		Severity: SeverityWarning,
+		Message:  "一条语句包含多个需求，建议拆分为多条语句 / split into separate requirements",
+	}
+}
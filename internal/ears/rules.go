@@ -0,0 +1,256 @@
+package ears
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// 超过该长度的语句提示拆分
+const (
+	maxEnglishWords  = 50
+	maxChineseLength = 100
+)
+
+// zhAmbiguousTerms 中文歧义词：无法验证、需要量化或明确的表述
+var zhAmbiguousTerms = []string{
+	"快速", "迅速", "尽快", "及时", "友好", "用户友好", "方便", "便捷", "简单", "简洁", "易用", "容易",
+	"高效", "高性能", "灵活", "适当", "合理", "足够", "大约", "左右", "若干", "一些", "部分", "等等",
+	"良好", "稳定", "强大", "美观", "直观", "安全可靠", "尽可能", "必要时", "通常", "一般情况下",
+}
+
+// enAmbiguousTerms 英文歧义词
+var enAmbiguousTerms = []string{
+	"fast", "quick", "quickly", "rapid", "user-friendly", "user friendly", "friendly", "easy", "easily",
+	"simple", "efficient", "flexible", "appropriate", "adequate", "reasonable", "sufficient",
+	"as soon as possible", "asap", "approximately", "about", "some", "several", "various", "etc",
+	"and/or", "robust", "intuitive", "seamless", "if possible", "as appropriate", "as needed",
+	"usually", "normally", "typically", "minimal", "optimal", "state-of-the-art",
+}
+
+var (
+	enAmbiguousRe = buildEnglishTermsRe(enAmbiguousTerms)
+	// 长词优先匹配，“用户友好”先于“友好”、“安全可靠”先于“可靠”
+	zhAmbiguousByLength = longestFirst(zhAmbiguousTerms)
+	// “等”后跟标点或句末时才视为列举未尽，避免误判“等待”“等级”
+	zhEtcRe = regexp.MustCompile(`[^等]等([，,。；;]|$)`)
+)
+
+// buildEnglishTermsRe 构造按单词边界匹配的英文词表正则，长词优先
+func buildEnglishTermsRe(terms []string) *regexp.Regexp {
+	quoted := make([]string, 0, len(terms))
+	// 按长度降序，保证 "user-friendly" 优先于 "friendly"
+	for _, term := range longestFirst(terms) {
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b\.?`)
+}
+
+// longestFirst 返回按长度降序排列的词表副本，等长的词保持原顺序
+func longestFirst(terms []string) []string {
+	sorted := append([]string(nil), terms...)
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	return sorted
+}
+
+// structuralFindings 检查句型结构：触发条件、系统主体、响应和长度
+func structuralFindings(result *Result) []Finding {
+	var findings []Finding
+	zh := result.Language == "zh"
+
+	if result.Response == "" {
+		msg := "未找到 \"shall\" 及系统响应，需求应写作 \"the <system> shall <response>\""
+		if zh {
+			msg = "未找到“应”及系统响应，需求应写作“<系统>应<响应>”"
+		}
+		findings = append(findings, Finding{Rule: RuleMissingResponse, Severity: SeverityError, Message: msg})
+	}
+
+	switch result.Pattern {
+	case PatternEvent, PatternState, PatternUnwanted, PatternOptional, PatternComplex:
+		if result.Condition == "" {
+			msg := "缺少触发条件、状态或特性描述"
+			findings = append(findings, Finding{Rule: RuleMissingTrigger, Severity: SeverityError, Message: msg})
+		}
+	}
+
+	if result.Response != "" && result.System == "" {
+		msg := "缺少系统主体，应明确由哪个系统或组件响应（如 \"the system\"）"
+		if zh {
+			msg = "缺少系统主体，应明确由哪个系统或组件响应（如“系统”）"
+		}
+		findings = append(findings, Finding{Rule: RuleMissingSystem, Severity: SeverityWarning, Message: msg})
+	}
+
+	tooLong := false
+	if zh {
+		tooLong = utf8.RuneCountInString(result.Text) > maxChineseLength
+	} else {
+		tooLong = len(strings.Fields(result.Text)) > maxEnglishWords
+	}
+	if tooLong {
+		findings = append(findings, Finding{Rule: RuleTooLong, Severity: SeverityInfo, Message: "语句过长，建议拆分"})
+	}
+
+	return findings
+}
+
+// termFindings 检查歧义词
+func termFindings(sentence, language string) []Finding {
+	var findings []Finding
+	seen := make(map[string]bool)
+	add := func(term string) {
+		if seen[term] {
+			return
+		}
+		seen[term] = true
+		findings = append(findings, Finding{
+			Rule:     RuleAmbiguousTerm,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("“%s”含义模糊且不可验证，请使用可度量的标准替代", term),
+			Term:     term,
+		})
+	}
+
+	if language == "zh" {
+		// 已命中的位置不再匹配更短的词，“用户友好”不会再报告“友好”，但句中其他位置的“友好”仍会报告
+		var covered [][2]int
+		for _, term := range zhAmbiguousByLength {
+			for from := 0; ; {
+				i := strings.Index(sentence[from:], term)
+				if i < 0 {
+					break
+				}
+				start, end := from+i, from+i+len(term)
+				from = end
+				if overlaps(covered, start, end) {
+					continue
+				}
+				covered = append(covered, [2]int{start, end})
+				add(term)
+			}
+		}
+		if zhEtcRe.MatchString(sentence) {
+			add("等")
+		}
+	}
+
+	// 中文需求中也常夹杂英文词
+	for _, match := range enAmbiguousRe.FindAllString(sentence, -1) {
+		add(strings.ToLower(strings.TrimSuffix(match, ".")))
+	}
+
+	return findings
+}
+
+// overlaps 判断区间 [start, end) 是否与已命中的区间重叠
+func overlaps(covered [][2]int, start, end int) bool {
+	for _, c := range covered {
+		if start < c[1] && c[0] < end {
+			return true
+		}
+	}
+	return false
+}
+
+// suggest 按句型模板生成改写建议，歧义词替换为待量化占位符
+func suggest(result *Result) string {
+	zh := result.Language == "zh"
+
+	condition := result.Condition
+	state := result.State
+	system := result.System
+	response := result.Response
+	if result.Pattern == PatternUnknown {
+		response = strings.Trim(result.Text, trimChars)
+	}
+
+	if zh {
+		condition = placeholder(condition, "<触发条件>")
+		state = placeholder(state, "<状态>")
+		system = placeholder(system, "系统")
+		response = placeholder(response, "<系统响应>")
+	} else {
+		condition = placeholder(condition, "<trigger>")
+		state = placeholder(state, "<state>")
+		system = placeholder(system, "the system")
+		response = placeholder(response, "<system response>")
+	}
+
+	var rewritten string
+	if zh {
+		switch result.Pattern {
+		case PatternEvent:
+			rewritten = fmt.Sprintf("当%s时，%s应%s。", condition, system, response)
+		case PatternState:
+			rewritten = fmt.Sprintf("在%s期间，%s应%s。", condition, system, response)
+		case PatternUnwanted:
+			rewritten = fmt.Sprintf("如果%s，那么%s应%s。", condition, system, response)
+		case PatternOptional:
+			rewritten = fmt.Sprintf("在%s的情况下，%s应%s。", condition, system, response)
+		case PatternComplex:
+			rewritten = fmt.Sprintf("在%s期间，当%s时，%s应%s。", state, condition, system, response)
+		default:
+			rewritten = fmt.Sprintf("%s应%s。", system, response)
+		}
+	} else {
+		switch result.Pattern {
+		case PatternEvent:
+			rewritten = fmt.Sprintf("When %s, %s shall %s.", condition, lowerArticle(system), response)
+		case PatternState:
+			rewritten = fmt.Sprintf("While %s, %s shall %s.", condition, lowerArticle(system), response)
+		case PatternUnwanted:
+			rewritten = fmt.Sprintf("If %s, then %s shall %s.", condition, lowerArticle(system), response)
+		case PatternOptional:
+			rewritten = fmt.Sprintf("Where %s, %s shall %s.", condition, lowerArticle(system), response)
+		case PatternComplex:
+			rewritten = fmt.Sprintf("While %s, when %s, %s shall %s.", state, condition, lowerArticle(system), response)
+		default:
+			rewritten = fmt.Sprintf("%s shall %s.", upperFirst(system), response)
+		}
+	}
+
+	return replaceAmbiguous(rewritten, result.Findings, zh)
+}
+
+// placeholder 空值时使用占位符
+func placeholder(value, fallback string) string {
+	if strings.TrimSpace(value) == "" {
+		return fallback
+	}
+	return value
+}
+
+// replaceAmbiguous 将歧义词替换为待量化占位符
+func replaceAmbiguous(text string, findings []Finding, zh bool) string {
+	for _, f := range findings {
+		if f.Rule != RuleAmbiguousTerm || f.Term == "" || f.Term == "等" {
+			continue
+		}
+		if zh {
+			text = strings.ReplaceAll(text, f.Term, fmt.Sprintf("<量化“%s”>", f.Term))
+			continue
+		}
+		re := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(f.Term) + `\b`)
+		text = re.ReplaceAllString(text, fmt.Sprintf("<quantify \"%s\">", f.Term))
+	}
+	return text
+}
+
+// lowerArticle 句中的 "The system" 改为小写冠词
+func lowerArticle(system string) string {
+	if strings.HasPrefix(system, "The ") {
+		return "the " + system[4:]
+	}
+	return system
+}
+
+// upperFirst 句首字母大写
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,116 @@
+package glossary
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// 产出物类型
+const (
+	ArtifactDocument = "document"
+	ArtifactDiagram  = "puml_diagram"
+	ArtifactChat     = "chat_message"
+)
+
+// contextRadius 问题上下文在匹配位置前后各保留的字符数
+const contextRadius = 30
+
+// Artifact 参与术语检查的产出物
+type Artifact struct {
+	Type    string `json:"type"` // document, puml_diagram, chat_message
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// Issue 产出物中使用了非规范同义词的位置
+type Issue struct {
+	ArtifactType string `json:"artifact_type"`
+	ArtifactID   string `json:"artifact_id"`
+	Title        string `json:"title"`
+	Term         string `json:"term"`   // 应使用的规范术语
+	Found        string `json:"found"`  // 文本中出现的同义词
+	Line         int    `json:"line"`   // 从1开始的行号
+	Column       int    `json:"column"` // 从1开始的列号（按字符计）
+	Context      string `json:"context"`
+}
+
+// TermUsage 规范术语及其同义词在全部产出物中的使用次数
+type TermUsage struct {
+	Term      string         `json:"term"`
+	Canonical int            `json:"canonical"`
+	Synonyms  map[string]int `json:"synonyms"`
+}
+
+// Report 术语一致性检查结果
+type Report struct {
+	Artifacts int          `json:"artifacts"`
+	Issues    []*Issue     `json:"issues"`
+	Usage     []*TermUsage `json:"usage"`
+}
+
+// Check 扫描产出物中使用的非规范同义词，并统计各术语的使用情况
+func Check(terms []Term, artifacts []Artifact) *Report {
+	report := &Report{Artifacts: len(artifacts), Issues: []*Issue{}, Usage: []*TermUsage{}}
+
+	usage := make(map[*Term]*TermUsage)
+	for i := range terms {
+		u := &TermUsage{Term: terms[i].Term, Synonyms: map[string]int{}}
+		usage[&terms[i]] = u
+		report.Usage = append(report.Usage, u)
+	}
+
+	for _, artifact := range artifacts {
+		for _, m := range matchTerms(artifact.Content, terms) {
+			u := usage[m.term]
+			if m.canonical {
+				u.Canonical++
+				continue
+			}
+			u.Synonyms[m.found]++
+
+			line, column, context := locate(artifact.Content, m.start, m.end)
+			report.Issues = append(report.Issues, &Issue{
+				ArtifactType: artifact.Type,
+				ArtifactID:   artifact.ID,
+				Title:        artifact.Title,
+				Term:         m.term.Term,
+				Found:        m.found,
+				Line:         line,
+				Column:       column,
+				Context:      context,
+			})
+		}
+	}
+
+	sort.SliceStable(report.Usage, func(i, j int) bool {
+		return Normalize(report.Usage[i].Term) < Normalize(report.Usage[j].Term)
+	})
+	return report
+}
+
+// locate 计算匹配位置的行号、列号，并截取所在行的上下文
+func locate(content string, start, end int) (int, int, string) {
+	lineStart := strings.LastIndex(content[:start], "\n") + 1
+	lineEnd := len(content)
+	if i := strings.Index(content[end:], "\n"); i >= 0 {
+		lineEnd = end + i
+	}
+	line := strings.Count(content[:start], "\n") + 1
+	column := utf8.RuneCountInString(content[lineStart:start]) + 1
+
+	before := []rune(content[lineStart:start])
+	after := []rune(content[end:lineEnd])
+	prefix, suffix := "", ""
+	if len(before) > contextRadius {
+		before = before[len(before)-contextRadius:]
+		prefix = "..."
+	}
+	if len(after) > contextRadius {
+		after = after[:contextRadius]
+		suffix = "..."
+	}
+	context := prefix + string(before) + content[start:end] + string(after) + suffix
+	return line, column, strings.TrimSpace(strings.TrimSuffix(context, "\r"))
+}
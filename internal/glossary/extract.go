@@ -0,0 +1,124 @@
+package glossary
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"ai-dev-platform/internal/ai"
+)
+
+// 候选术语来源
+const (
+	SourceEntity   = "entity"
+	SourceRole     = "role"
+	SourceDocument = "document"
+	SourceDiagram  = "diagram"
+)
+
+// 候选术语长度范围（按字符计）
+const (
+	minTermLength = 2
+	maxTermLength = 40
+)
+
+var (
+	// boldTermRe 文档中加粗的词语，通常是作者强调的领域概念
+	boldTermRe = regexp.MustCompile(`\*\*([^*\n]+?)\*\*`)
+	// pumlElementRe PUML 中声明的类、实体、参与者等元素名称
+	pumlElementRe = regexp.MustCompile(`(?m)^\s*(?:abstract\s+class|class|entity|interface|enum|actor|participant|table|object)\s+(?:"([^"]+)"|([^\s{<"]+))`)
+)
+
+// Input 提取候选术语的数据来源
+type Input struct {
+	Entities  []ai.DataEntity `json:"entities"`
+	Roles     []string        `json:"roles"`
+	Documents []Artifact      `json:"documents"`
+	Diagrams  []Artifact      `json:"diagrams"`
+}
+
+// Candidate 候选术语
+type Candidate struct {
+	Term        string   `json:"term"`
+	Sources     []string `json:"sources"`     // entity, role, document, diagram
+	Occurrences int      `json:"occurrences"` // 在文档和图表中出现的次数
+	Existing    bool     `json:"existing"`    // 已是术语表中的术语或同义词
+}
+
+// ExtractCandidates 从数据实体名称、角色、文档加粗词语和图表元素名称中提取候选术语，
+// 按出现次数降序排列；已收录的术语标记为 Existing
+func ExtractCandidates(input Input, existing []Term) []*Candidate {
+	known := make(map[string]bool)
+	for _, term := range existing {
+		known[Normalize(term.Term)] = true
+		for _, synonym := range term.Synonyms {
+			known[Normalize(synonym)] = true
+		}
+	}
+
+	byKey := make(map[string]*Candidate)
+	var candidates []*Candidate
+	add := func(term, source string) {
+		term = strings.Trim(strings.TrimSpace(term), "`'\"：:，,。.")
+		if n := utf8.RuneCountInString(term); n < minTermLength || n > maxTermLength {
+			return
+		}
+		key := Normalize(term)
+		candidate, ok := byKey[key]
+		if !ok {
+			candidate = &Candidate{Term: term, Sources: []string{}, Existing: known[key]}
+			byKey[key] = candidate
+			candidates = append(candidates, candidate)
+		}
+		for _, s := range candidate.Sources {
+			if s == source {
+				return
+			}
+		}
+		candidate.Sources = append(candidate.Sources, source)
+	}
+
+	for _, entity := range input.Entities {
+		add(entity.Name, SourceEntity)
+	}
+	for _, role := range input.Roles {
+		add(role, SourceRole)
+	}
+	for _, doc := range input.Documents {
+		for _, m := range boldTermRe.FindAllStringSubmatch(doc.Content, -1) {
+			add(m[1], SourceDocument)
+		}
+	}
+	for _, diagram := range input.Diagrams {
+		for _, m := range pumlElementRe.FindAllStringSubmatch(diagram.Content, -1) {
+			name := m[1]
+			if name == "" {
+				name = m[2]
+			}
+			add(name, SourceDiagram)
+		}
+	}
+
+	texts := make([]string, 0, len(input.Documents)+len(input.Diagrams))
+	for _, artifact := range append(append([]Artifact(nil), input.Documents...), input.Diagrams...) {
+		texts = append(texts, artifact.Content)
+	}
+	for _, candidate := range candidates {
+		p := newPattern(candidate.Term)
+		for _, text := range texts {
+			candidate.Occurrences += len(p.find(text))
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Occurrences != candidates[j].Occurrences {
+			return candidates[i].Occurrences > candidates[j].Occurrences
+		}
+		return Normalize(candidates[i].Term) < Normalize(candidates[j].Term)
+	})
+	if candidates == nil {
+		candidates = []*Candidate{}
+	}
+	return candidates
+}
@@ -0,0 +1,88 @@
+// Package glossary 项目术语表：从数据实体、角色和文档中提取候选术语，
+// 检查文档、图表等产出物中使用的非规范同义词，并生成注入AI提示语的术语表段落
+package glossary
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Term 术语表中的一条规范术语
+type Term struct {
+	Term       string   `json:"term"`
+	Definition string   `json:"definition"`
+	Synonyms   []string `json:"synonyms"` // 应统一替换为规范术语的同义词
+}
+
+// Normalize 术语的比较键：去除首尾空白并转为小写
+func Normalize(term string) string {
+	return strings.ToLower(strings.TrimSpace(term))
+}
+
+// CleanSynonyms 去除空白、重复以及与规范术语相同的同义词，保持原有顺序
+func CleanSynonyms(term string, synonyms []string) []string {
+	seen := map[string]bool{Normalize(term): true}
+	cleaned := []string{}
+	for _, synonym := range synonyms {
+		synonym = strings.TrimSpace(synonym)
+		key := Normalize(synonym)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		cleaned = append(cleaned, synonym)
+	}
+	return cleaned
+}
+
+// Validate 校验术语及其同义词不与术语表中其他术语的规范名称或同义词重复
+func Validate(term Term, others []Term) error {
+	if Normalize(term.Term) == "" {
+		return fmt.Errorf("术语不能为空")
+	}
+
+	owners := make(map[string]string)
+	for _, other := range others {
+		owners[Normalize(other.Term)] = other.Term
+		for _, synonym := range other.Synonyms {
+			owners[Normalize(synonym)] = other.Term
+		}
+	}
+
+	if owner, ok := owners[Normalize(term.Term)]; ok {
+		return fmt.Errorf("术语 %q 已存在于术语 %q 中", term.Term, owner)
+	}
+	for _, synonym := range term.Synonyms {
+		if owner, ok := owners[Normalize(synonym)]; ok {
+			return fmt.Errorf("同义词 %q 已存在于术语 %q 中", synonym, owner)
+		}
+	}
+	return nil
+}
+
+// PromptSection 生成注入AI提示语的术语表段落，术语表为空时返回空字符串
+func PromptSection(terms []Term) string {
+	if len(terms) == 0 {
+		return ""
+	}
+
+	sorted := append([]Term(nil), terms...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return Normalize(sorted[i].Term) < Normalize(sorted[j].Term)
+	})
+
+	var b strings.Builder
+	b.WriteString("项目术语表（生成内容时请统一使用以下规范术语，不要使用括号中的同义词）：\n")
+	for _, term := range sorted {
+		b.WriteString("- " + term.Term)
+		if definition := strings.TrimSpace(term.Definition); definition != "" {
+			b.WriteString("：" + definition)
+		}
+		if len(term.Synonyms) > 0 {
+			b.WriteString("（同义词：" + strings.Join(term.Synonyms, "、") + "）")
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,118 @@
+package glossary
+
+import (
+	"testing"
+
+	"ai-dev-platform/internal/ai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var orderTerms = []Term{
+	{Term: "订单", Definition: "用户提交的购买记录", Synonyms: []string{"交易单", "order"}},
+	{Term: "用户", Synonyms: []string{"会员"}},
+}
+
+func TestCheckReportsSynonymLocations(t *testing.T) {
+	artifacts := []Artifact{
+		{Type: ArtifactDocument, ID: "doc-1", Title: "需求说明", Content: "# 概述\n会员提交交易单后生成订单。\nThe Order is paid."},
+		{Type: ArtifactDiagram, ID: "diagram-1", Content: "@startuml\nclass border\nclass 订单\n@enduml"},
+	}
+
+	report := Check(orderTerms, artifacts)
+	require.Len(t, report.Issues, 3)
+
+	assert.Equal(t, "会员", report.Issues[0].Found)
+	assert.Equal(t, "用户", report.Issues[0].Term)
+	assert.Equal(t, 2, report.Issues[0].Line)
+	assert.Equal(t, 1, report.Issues[0].Column)
+
+	assert.Equal(t, "交易单", report.Issues[1].Found)
+	assert.Equal(t, "订单", report.Issues[1].Term)
+	assert.Equal(t, 5, report.Issues[1].Column)
+	assert.Equal(t, "会员提交交易单后生成订单。", report.Issues[1].Context)
+
+	// 大小写不敏感，且不匹配 border 中的 order
+	assert.Equal(t, "Order", report.Issues[2].Found)
+	assert.Equal(t, 3, report.Issues[2].Line)
+	assert.Equal(t, "doc-1", report.Issues[2].ArtifactID)
+
+	require.Len(t, report.Usage, 2)
+	assert.Equal(t, "用户", report.Usage[0].Term)
+	assert.Equal(t, 1, report.Usage[0].Synonyms["会员"])
+	assert.Equal(t, "订单", report.Usage[1].Term)
+	assert.Equal(t, 2, report.Usage[1].Canonical)
+}
+
+func TestCheckPrefersLongerTerms(t *testing.T) {
+	terms := []Term{
+		{Term: "订单", Synonyms: []string{"单"}},
+		{Term: "退款订单", Synonyms: []string{"退单"}},
+	}
+	report := Check(terms, []Artifact{{Type: ArtifactChat, ID: "m1", Content: "退款订单和订单不同，单号另计，退单需审核"}})
+
+	require.Len(t, report.Issues, 2)
+	assert.Equal(t, "单", report.Issues[0].Found)
+	assert.Equal(t, "退单", report.Issues[1].Found)
+	assert.Equal(t, "退款订单", report.Issues[1].Term)
+}
+
+func TestLocateTruncatesLongLines(t *testing.T) {
+	prefix := "这是一段很长的前置文字用于测试截断效果这是一段很长的前置文字用于测试截断效果"
+	content := prefix + "交易单"
+	line, column, context := locate(content, len(prefix), len(content))
+
+	assert.Equal(t, 1, line)
+	assert.Equal(t, 39, column)
+	assert.Equal(t, "..."+string([]rune(prefix)[8:])+"交易单", context)
+}
+
+func TestExtractCandidates(t *testing.T) {
+	input := Input{
+		Entities: []ai.DataEntity{{Name: "Order"}, {Name: "订单"}},
+		Roles:    []string{"买家", "管理员", "x"},
+		Documents: []Artifact{
+			{Content: "**订单** 由 **买家** 创建，订单可取消。"},
+		},
+		Diagrams: []Artifact{
+			{Content: "@startuml\nclass Order {\n}\nactor \"仓库管理员\" as W\nparticipant 买家\n@enduml"},
+		},
+	}
+
+	candidates := ExtractCandidates(input, []Term{{Term: "订单"}})
+	byTerm := make(map[string]*Candidate)
+	for _, c := range candidates {
+		byTerm[c.Term] = c
+	}
+
+	require.Contains(t, byTerm, "订单")
+	assert.True(t, byTerm["订单"].Existing)
+	assert.Equal(t, []string{SourceEntity, SourceDocument}, byTerm["订单"].Sources)
+	assert.Equal(t, 2, byTerm["订单"].Occurrences)
+	assert.Equal(t, []string{SourceEntity, SourceDiagram}, byTerm["Order"].Sources)
+	assert.Equal(t, []string{SourceRole, SourceDocument, SourceDiagram}, byTerm["买家"].Sources)
+	assert.Contains(t, byTerm, "仓库管理员")
+	assert.NotContains(t, byTerm, "x")
+	assert.Equal(t, "买家", candidates[0].Term)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(Term{Term: "商品", Synonyms: []string{"货品"}}, orderTerms))
+	assert.Error(t, Validate(Term{Term: " "}, orderTerms))
+	assert.ErrorContains(t, Validate(Term{Term: "ORDER"}, orderTerms), "订单")
+	assert.ErrorContains(t, Validate(Term{Term: "成员", Synonyms: []string{"会员"}}, orderTerms), "用户")
+}
+
+func TestCleanSynonyms(t *testing.T) {
+	assert.Equal(t, []string{"交易单", "order"}, CleanSynonyms("订单", []string{" 交易单 ", "", "订单", "order", "Order"}))
+}
+
+func TestPromptSection(t *testing.T) {
+	assert.Empty(t, PromptSection(nil))
+
+	section := PromptSection(orderTerms)
+	assert.Contains(t, section, "项目术语表")
+	assert.Contains(t, section, "- 订单：用户提交的购买记录（同义词：交易单、order）")
+	assert.Contains(t, section, "- 用户（同义词：会员）")
+}
@@ -0,0 +1,110 @@
+package glossary
+
+import (
+	"regexp"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// span 文本中一次匹配的字节区间
+type span struct {
+	start, end int
+}
+
+// pattern 某个术语或同义词的匹配规则
+type pattern struct {
+	text string
+	re   *regexp.Regexp
+	// 首尾为拉丁字母或数字时需要单词边界，避免 order 匹配到 border
+	leftBoundary, rightBoundary bool
+}
+
+func newPattern(text string) *pattern {
+	first, _ := utf8.DecodeRuneInString(text)
+	last, _ := utf8.DecodeLastRuneInString(text)
+	return &pattern{
+		text:          text,
+		re:            regexp.MustCompile(`(?i)` + regexp.QuoteMeta(text)),
+		leftBoundary:  isWordRune(first),
+		rightBoundary: isWordRune(last),
+	}
+}
+
+// isWordRune 参与单词边界判断的字符：非汉字的字母和数字
+func isWordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') && !unicode.Is(unicode.Han, r)
+}
+
+// find 返回文本中所有满足边界条件的匹配
+func (p *pattern) find(content string) []span {
+	var spans []span
+	for _, loc := range p.re.FindAllStringIndex(content, -1) {
+		if p.leftBoundary && loc[0] > 0 {
+			if r, _ := utf8.DecodeLastRuneInString(content[:loc[0]]); isWordRune(r) {
+				continue
+			}
+		}
+		if p.rightBoundary && loc[1] < len(content) {
+			if r, _ := utf8.DecodeRuneInString(content[loc[1]:]); isWordRune(r) {
+				continue
+			}
+		}
+		spans = append(spans, span{loc[0], loc[1]})
+	}
+	return spans
+}
+
+// match 一次已确认归属的匹配
+type match struct {
+	span
+	term      *Term
+	found     string // 文本中实际出现的写法
+	canonical bool
+}
+
+// matchTerms 在文本中查找所有术语和同义词，较长的写法优先占用区间，
+// 因此被更长术语包含的同义词（如 "订单" 中的 "单"）不会被重复计入
+func matchTerms(content string, terms []Term) []match {
+	type entry struct {
+		pattern   *pattern
+		term      *Term
+		canonical bool
+	}
+	var entries []entry
+	for i := range terms {
+		term := &terms[i]
+		if Normalize(term.Term) != "" {
+			entries = append(entries, entry{newPattern(term.Term), term, true})
+		}
+		for _, synonym := range term.Synonyms {
+			if Normalize(synonym) != "" {
+				entries = append(entries, entry{newPattern(synonym), term, false})
+			}
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return utf8.RuneCountInString(entries[i].pattern.text) > utf8.RuneCountInString(entries[j].pattern.text)
+	})
+
+	var matches []match
+	for _, e := range entries {
+		for _, s := range e.pattern.find(content) {
+			if overlaps(matches, s) {
+				continue
+			}
+			matches = append(matches, match{span: s, term: e.term, found: content[s.start:s.end], canonical: e.canonical})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+	return matches
+}
+
+func overlaps(matches []match, s span) bool {
+	for _, m := range matches {
+		if s.start < m.end && m.start < s.end {
+			return true
+		}
+	}
+	return false
+}
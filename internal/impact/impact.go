@@ -0,0 +1,313 @@
+// Package impact 维护产物依赖图：记录图表、文档、设计、任务和测试用例派生自哪个需求分析版本、
+// 数据实体或上游文档；上游变更时将下游依赖标记为过期并沿依赖图传播，汇总受影响的产物及原因
+package impact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"ai-dev-platform/internal/ai"
+	"ai-dev-platform/internal/model"
+)
+
+// typeLabels 产物类型的中文名称
+var typeLabels = map[string]string{
+	model.ArtifactTypeRequirement:     "需求分析",
+	model.ArtifactTypeEntity:          "数据实体",
+	model.ArtifactTypeDocument:        "文档",
+	model.ArtifactTypePUMLDiagram:     "PUML图表",
+	model.ArtifactTypeRequirementsDoc: "需求文档",
+	model.ArtifactTypeDesignDoc:       "设计文档",
+	model.ArtifactTypeTaskListDoc:     "任务列表",
+	model.ArtifactTypeTestCase:        "测试用例",
+}
+
+// layers 产物所在层级，重新生成时按层级从上游到下游依次进行
+var layers = map[string]int{
+	model.ArtifactTypeRequirement:     0,
+	model.ArtifactTypeEntity:          0,
+	model.ArtifactTypeRequirementsDoc: 0,
+	model.ArtifactTypeDocument:        1,
+	model.ArtifactTypePUMLDiagram:     1,
+	model.ArtifactTypeDesignDoc:       1,
+	model.ArtifactTypeTaskListDoc:     2,
+	model.ArtifactTypeTestCase:        3,
+}
+
+// Label 产物类型的中文名称
+func Label(artifactType string) string {
+	if label, ok := typeLabels[artifactType]; ok {
+		return label
+	}
+	return artifactType
+}
+
+// Layer 产物所在层级，数值越小越靠上游
+func Layer(artifactType string) int {
+	if layer, ok := layers[artifactType]; ok {
+		return layer
+	}
+	return len(layers)
+}
+
+// Key 产物在依赖图中的唯一标识
+func Key(artifactType, id string) string {
+	return artifactType + ":" + id
+}
+
+// Artifact 依赖指向的下游产物
+func Artifact(dep *model.ArtifactDependency) model.ArtifactRef {
+	return model.ArtifactRef{Type: dep.ArtifactType, ID: dep.ArtifactID}
+}
+
+// Upstream 依赖指向的上游产物
+func Upstream(dep *model.ArtifactDependency) model.ArtifactRef {
+	return model.ArtifactRef{Type: dep.UpstreamType, ID: dep.UpstreamID, Key: dep.UpstreamKey}
+}
+
+// describe 用于过期原因的上游描述，如 "设计文档 1a2b3c4d"
+func describe(ref model.ArtifactRef) string {
+	if ref.Key != "" {
+		return fmt.Sprintf("%s %s", Label(ref.Type), ref.Key)
+	}
+	id := ref.ID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return fmt.Sprintf("%s %s", Label(ref.Type), id)
+}
+
+// normalizeName 实体名称的比较键
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// EntityHash 数据实体内容的哈希，名称大小写和空白不影响结果
+func EntityHash(entity ai.DataEntity) string {
+	entity.Name = normalizeName(entity.Name)
+	data, _ := json.Marshal(entity)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// Detector 判断一条依赖的上游是否发生了变更，返回过期原因
+type Detector func(dep *model.ArtifactDependency) (string, bool)
+
+// RequirementChanges 需求分析保存新版本后的变更判定：
+// 依赖旧版本需求分析的边过期；依赖数据实体的边在实体被修改或删除时过期
+func RequirementChanges(requirementID string, version int, entities []ai.DataEntity) Detector {
+	hashes := make(map[string]string, len(entities))
+	for _, entity := range entities {
+		hashes[normalizeName(entity.Name)] = EntityHash(entity)
+	}
+
+	return func(dep *model.ArtifactDependency) (string, bool) {
+		if dep.UpstreamID != requirementID {
+			return "", false
+		}
+		switch dep.UpstreamType {
+		case model.ArtifactTypeRequirement:
+			if dep.UpstreamVersion < version {
+				return fmt.Sprintf("需求分析已从 v%d 更新到 v%d", dep.UpstreamVersion, version), true
+			}
+		case model.ArtifactTypeEntity:
+			hash, ok := hashes[normalizeName(dep.UpstreamKey)]
+			if !ok {
+				return fmt.Sprintf("数据实体 %s 已删除", dep.UpstreamKey), true
+			}
+			if hash != dep.UpstreamHash {
+				return fmt.Sprintf("数据实体 %s 已修改", dep.UpstreamKey), true
+			}
+		}
+		return "", false
+	}
+}
+
+// UpstreamChanged 指定上游产物变更的判定；Key 为空时匹配该产物的全部依赖
+func UpstreamChanged(upstream model.ArtifactRef, reason string) Detector {
+	if reason == "" {
+		reason = describe(upstream) + " 已修改"
+	}
+	return func(dep *model.ArtifactDependency) (string, bool) {
+		if dep.UpstreamType != upstream.Type || dep.UpstreamID != upstream.ID {
+			return "", false
+		}
+		if upstream.Key != "" && normalizeName(dep.UpstreamKey) != normalizeName(upstream.Key) {
+			return "", false
+		}
+		return reason, true
+	}
+}
+
+// Propagate 按判定函数标记直接受影响的依赖，并沿依赖图将过期传播到所有下游产物
+// detect 为 nil 时只传播已有的过期状态；返回本次新标记或追加了原因的依赖，顺序与输入一致
+func Propagate(deps []*model.ArtifactDependency, detect Detector, now time.Time) []*model.ArtifactDependency {
+	changed := make(map[*model.ArtifactDependency]bool)
+	mark := func(dep *model.ArtifactDependency, reason string) {
+		if !dep.Stale {
+			dep.Stale = true
+			dep.StaleReason = reason
+			at := now
+			dep.StaleAt = &at
+			changed[dep] = true
+			return
+		}
+		for _, existing := range strings.Split(dep.StaleReason, "；") {
+			if existing == reason {
+				return
+			}
+		}
+		dep.StaleReason += "；" + reason
+		changed[dep] = true
+	}
+
+	if detect != nil {
+		for _, dep := range deps {
+			if reason, ok := detect(dep); ok {
+				mark(dep, reason)
+			}
+		}
+	}
+
+	byUpstream := make(map[string][]*model.ArtifactDependency)
+	for _, dep := range deps {
+		key := Key(dep.UpstreamType, dep.UpstreamID)
+		byUpstream[key] = append(byUpstream[key], dep)
+	}
+
+	visited := make(map[string]bool)
+	var queue []model.ArtifactRef
+	for _, dep := range deps {
+		key := Key(dep.ArtifactType, dep.ArtifactID)
+		if dep.Stale && !visited[key] {
+			visited[key] = true
+			queue = append(queue, Artifact(dep))
+		}
+	}
+	for len(queue) > 0 {
+		ref := queue[0]
+		queue = queue[1:]
+		for _, dep := range byUpstream[Key(ref.Type, ref.ID)] {
+			if !dep.Stale {
+				mark(dep, "上游"+describe(ref)+" 已过期")
+			}
+			key := Key(dep.ArtifactType, dep.ArtifactID)
+			if !visited[key] {
+				visited[key] = true
+				queue = append(queue, Artifact(dep))
+			}
+		}
+	}
+
+	var result []*model.ArtifactDependency
+	for _, dep := range deps {
+		if changed[dep] {
+			result = append(result, dep)
+		}
+	}
+	return result
+}
+
+// StaleArtifact 过期的产物及原因
+type StaleArtifact struct {
+	Type        string              `json:"type"`
+	ID          string              `json:"id"`
+	Title       string              `json:"title"`
+	Reasons     []string            `json:"reasons"`
+	Upstreams   []model.ArtifactRef `json:"upstreams"` // 已过期的上游
+	StaleSince  *time.Time          `json:"stale_since,omitempty"`
+	Regenerable bool                `json:"regenerable"`
+}
+
+// Report 项目的变更影响报告
+type Report struct {
+	Total     int              `json:"total"`
+	ByType    map[string]int   `json:"by_type"`
+	Artifacts []*StaleArtifact `json:"artifacts"`
+}
+
+// BuildReport 汇总依赖图中的过期产物，titles 以 Key(类型, ID) 为键提供产物名称
+func BuildReport(deps []*model.ArtifactDependency, titles map[string]string) *Report {
+	report := &Report{ByType: map[string]int{}, Artifacts: []*StaleArtifact{}}
+
+	byKey := make(map[string]*StaleArtifact)
+	upstreams := make(map[string][]model.ArtifactRef) // 每个产物的全部上游，用于判断能否重新生成
+	for _, dep := range deps {
+		key := Key(dep.ArtifactType, dep.ArtifactID)
+		upstreams[key] = append(upstreams[key], Upstream(dep))
+		if !dep.Stale {
+			continue
+		}
+
+		artifact, ok := byKey[key]
+		if !ok {
+			artifact = &StaleArtifact{
+				Type:      dep.ArtifactType,
+				ID:        dep.ArtifactID,
+				Title:     titles[key],
+				Reasons:   []string{},
+				Upstreams: []model.ArtifactRef{},
+			}
+			byKey[key] = artifact
+			report.Artifacts = append(report.Artifacts, artifact)
+		}
+		for _, reason := range strings.Split(dep.StaleReason, "；") {
+			if reason != "" && !contains(artifact.Reasons, reason) {
+				artifact.Reasons = append(artifact.Reasons, reason)
+			}
+		}
+		artifact.Upstreams = append(artifact.Upstreams, Upstream(dep))
+		if dep.StaleAt != nil && (artifact.StaleSince == nil || dep.StaleAt.Before(*artifact.StaleSince)) {
+			artifact.StaleSince = dep.StaleAt
+		}
+	}
+
+	for _, artifact := range report.Artifacts {
+		artifact.Regenerable = Regenerable(artifact.Type, upstreams[Key(artifact.Type, artifact.ID)])
+		report.ByType[artifact.Type]++
+	}
+	report.Total = len(report.Artifacts)
+
+	sort.SliceStable(report.Artifacts, func(i, j int) bool {
+		return Layer(report.Artifacts[i].Type) < Layer(report.Artifacts[j].Type)
+	})
+	return report
+}
+
+// Regenerable 产物能否根据已记录的上游重新生成
+func Regenerable(artifactType string, upstreams []model.ArtifactRef) bool {
+	has := func(t string) bool {
+		for _, ref := range upstreams {
+			if ref.Type == t {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch artifactType {
+	case model.ArtifactTypePUMLDiagram, model.ArtifactTypeDocument:
+		return has(model.ArtifactTypeRequirement)
+	case model.ArtifactTypeDesignDoc:
+		return has(model.ArtifactTypeRequirementsDoc)
+	case model.ArtifactTypeTaskListDoc:
+		return has(model.ArtifactTypeRequirementsDoc) && has(model.ArtifactTypeDesignDoc)
+	case model.ArtifactTypeTestCase:
+		return has(model.ArtifactTypeTaskListDoc)
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
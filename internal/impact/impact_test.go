@@ -0,0 +1,155 @@
+package impact
+
+import (
+	"testing"
+	"time"
+
+	"ai-dev-platform/internal/ai"
+	"ai-dev-platform/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func edge(artifactType, artifactID, upstreamType, upstreamID string) *model.ArtifactDependency {
+	return &model.ArtifactDependency{
+		ArtifactType: artifactType,
+		ArtifactID:   artifactID,
+		UpstreamType: upstreamType,
+		UpstreamID:   upstreamID,
+	}
+}
+
+func attrs(names ...string) []ai.EntityAttribute {
+	var attributes []ai.EntityAttribute
+	for _, name := range names {
+		attributes = append(attributes, ai.EntityAttribute{Name: name, Type: "string"})
+	}
+	return attributes
+}
+
+var (
+	book   = ai.DataEntity{Name: "Book", Attributes: attrs("id", "title")}
+	reader = ai.DataEntity{Name: "Reader", Attributes: attrs("id", "name")}
+)
+
+func TestEntityHash_IgnoresNameFormatting(t *testing.T) {
+	renamed := book
+	renamed.Name = "  book "
+	assert.Equal(t, EntityHash(book), EntityHash(renamed))
+
+	changed := book
+	changed.Attributes = attrs("id", "title", "isbn")
+	assert.NotEqual(t, EntityHash(book), EntityHash(changed))
+}
+
+func TestRequirementChanges(t *testing.T) {
+	flow := edge(model.ArtifactTypePUMLDiagram, "flow", model.ArtifactTypeRequirement, "req")
+	flow.UpstreamVersion = 1
+	current := edge(model.ArtifactTypePUMLDiagram, "arch", model.ArtifactTypeRequirement, "req")
+	current.UpstreamVersion = 2
+	other := edge(model.ArtifactTypePUMLDiagram, "other", model.ArtifactTypeRequirement, "other-req")
+
+	bookEdge := edge(model.ArtifactTypePUMLDiagram, "er", model.ArtifactTypeEntity, "req")
+	bookEdge.UpstreamKey, bookEdge.UpstreamHash = "Book", EntityHash(book)
+	readerEdge := edge(model.ArtifactTypePUMLDiagram, "er", model.ArtifactTypeEntity, "req")
+	readerEdge.UpstreamKey, readerEdge.UpstreamHash = "Reader", EntityHash(reader)
+	loanEdge := edge(model.ArtifactTypePUMLDiagram, "er", model.ArtifactTypeEntity, "req")
+	loanEdge.UpstreamKey, loanEdge.UpstreamHash = "Loan", "stale-hash"
+
+	changedReader := reader
+	changedReader.Attributes = attrs("id", "name", "email")
+	detect := RequirementChanges("req", 2, []ai.DataEntity{book, changedReader})
+
+	reason, ok := detect(flow)
+	assert.True(t, ok)
+	assert.Equal(t, "需求分析已从 v1 更新到 v2", reason)
+
+	_, ok = detect(current)
+	assert.False(t, ok)
+	_, ok = detect(other)
+	assert.False(t, ok)
+	_, ok = detect(bookEdge)
+	assert.False(t, ok, "未修改的实体不应过期")
+
+	reason, ok = detect(readerEdge)
+	assert.True(t, ok)
+	assert.Equal(t, "数据实体 Reader 已修改", reason)
+
+	reason, ok = detect(loanEdge)
+	assert.True(t, ok)
+	assert.Equal(t, "数据实体 Loan 已删除", reason)
+}
+
+func TestUpstreamChanged_MatchesKey(t *testing.T) {
+	whole := edge(model.ArtifactTypeDesignDoc, "design", model.ArtifactTypeRequirementsDoc, "rd")
+	entity := edge(model.ArtifactTypePUMLDiagram, "er", model.ArtifactTypeEntity, "req")
+	entity.UpstreamKey = "Book"
+
+	reason, ok := UpstreamChanged(model.ArtifactRef{Type: model.ArtifactTypeRequirementsDoc, ID: "rd"}, "")(whole)
+	assert.True(t, ok)
+	assert.Equal(t, "需求文档 rd 已修改", reason)
+
+	_, ok = UpstreamChanged(model.ArtifactRef{Type: model.ArtifactTypeEntity, ID: "req", Key: "Reader"}, "")(entity)
+	assert.False(t, ok)
+	_, ok = UpstreamChanged(model.ArtifactRef{Type: model.ArtifactTypeEntity, ID: "req", Key: "book"}, "")(entity)
+	assert.True(t, ok)
+}
+
+func TestPropagate_Transitive(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	design := edge(model.ArtifactTypeDesignDoc, "design", model.ArtifactTypeRequirementsDoc, "rd")
+	tasksFromReq := edge(model.ArtifactTypeTaskListDoc, "tasks", model.ArtifactTypeRequirementsDoc, "rd")
+	tasksFromDesign := edge(model.ArtifactTypeTaskListDoc, "tasks", model.ArtifactTypeDesignDoc, "design")
+	testCase := edge(model.ArtifactTypeTestCase, "tc", model.ArtifactTypeTaskListDoc, "tasks")
+	unrelated := edge(model.ArtifactTypeDesignDoc, "other", model.ArtifactTypeRequirementsDoc, "rd2")
+	deps := []*model.ArtifactDependency{testCase, tasksFromDesign, design, tasksFromReq, unrelated}
+
+	changed := Propagate(deps, UpstreamChanged(model.ArtifactRef{Type: model.ArtifactTypeRequirementsDoc, ID: "rd"}, "需求文档已修改"), now)
+
+	assert.Equal(t, []*model.ArtifactDependency{testCase, tasksFromDesign, design, tasksFromReq}, changed)
+	assert.Equal(t, "需求文档已修改", design.StaleReason)
+	assert.Equal(t, "需求文档已修改", tasksFromReq.StaleReason)
+	assert.Equal(t, "上游设计文档 design 已过期", tasksFromDesign.StaleReason)
+	assert.Equal(t, "上游任务列表 tasks 已过期", testCase.StaleReason)
+	require.NotNil(t, testCase.StaleAt)
+	assert.Equal(t, now, *testCase.StaleAt)
+	assert.False(t, unrelated.Stale)
+
+	// 同一原因不会重复追加，新原因追加在后
+	assert.Empty(t, Propagate(deps, UpstreamChanged(model.ArtifactRef{Type: model.ArtifactTypeRequirementsDoc, ID: "rd"}, "需求文档已修改"), now))
+	changed = Propagate(deps, UpstreamChanged(model.ArtifactRef{Type: model.ArtifactTypeRequirementsDoc, ID: "rd"}, "补充了验收标准"), now)
+	assert.Len(t, changed, 2)
+	assert.Equal(t, "需求文档已修改；补充了验收标准", design.StaleReason)
+}
+
+func TestBuildReport(t *testing.T) {
+	stale := func(dep *model.ArtifactDependency, reason string) *model.ArtifactDependency {
+		dep.Stale, dep.StaleReason = true, reason
+		return dep
+	}
+	deps := []*model.ArtifactDependency{
+		stale(edge(model.ArtifactTypeTestCase, "tc", model.ArtifactTypeTaskListDoc, "tasks"), "上游任务列表 tasks 已过期"),
+		stale(edge(model.ArtifactTypeTaskListDoc, "tasks", model.ArtifactTypeRequirementsDoc, "rd"), "需求文档已修改"),
+		stale(edge(model.ArtifactTypeTaskListDoc, "tasks", model.ArtifactTypeDesignDoc, "design"), "需求文档已修改；上游设计文档 design 已过期"),
+		edge(model.ArtifactTypePUMLDiagram, "fresh", model.ArtifactTypeRequirement, "req"),
+		stale(edge(model.ArtifactTypePUMLDiagram, "er", model.ArtifactTypeEntity, "req"), "数据实体 Book 已修改"),
+	}
+
+	report := BuildReport(deps, map[string]string{Key(model.ArtifactTypeTestCase, "tc"): "借书成功"})
+
+	require.Equal(t, 3, report.Total)
+	assert.Equal(t, map[string]int{model.ArtifactTypeTestCase: 1, model.ArtifactTypeTaskListDoc: 1, model.ArtifactTypePUMLDiagram: 1}, report.ByType)
+
+	diagram, tasks, testCase := report.Artifacts[0], report.Artifacts[1], report.Artifacts[2]
+	assert.Equal(t, "er", diagram.ID)
+	assert.False(t, diagram.Regenerable, "只依赖数据实体的图表没有来源需求分析")
+
+	assert.Equal(t, "tasks", tasks.ID)
+	assert.Equal(t, []string{"需求文档已修改", "上游设计文档 design 已过期"}, tasks.Reasons)
+	assert.Len(t, tasks.Upstreams, 2)
+	assert.True(t, tasks.Regenerable)
+
+	assert.Equal(t, "借书成功", testCase.Title)
+	assert.True(t, testCase.Regenerable)
+}
@@ -0,0 +1,163 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 可映射的条目字段
+const (
+	FieldTitle              = "title"
+	FieldDescription        = "description"
+	FieldAcceptanceCriteria = "acceptance_criteria"
+	FieldPriority           = "priority"
+	FieldStoryPoints        = "story_points"
+	FieldLabels             = "labels"
+	FieldKey                = "key"
+)
+
+// columnAliases 各字段可自动识别的列名（小写、去空白后比较）
+var columnAliases = map[string][]string{
+	FieldTitle:              {"title", "summary", "name", "story", "userstory", "requirement", "标题", "名称", "摘要", "需求", "需求名称", "用户故事", "主题"},
+	FieldDescription:        {"description", "desc", "details", "body", "描述", "详情", "说明", "需求描述", "详细描述"},
+	FieldAcceptanceCriteria: {"acceptancecriteria", "acceptance", "ac", "验收标准", "验收条件"},
+	FieldPriority:           {"priority", "优先级"},
+	FieldStoryPoints:        {"storypoints", "storypoint", "points", "estimate", "故事点", "估算", "工作量"},
+	FieldLabels:             {"labels", "label", "tags", "标签"},
+	FieldKey:                {"key", "id", "issuekey", "issueid", "编号", "需求编号", "序号"},
+}
+
+// parseCSV 解析CSV（自动识别逗号、分号、制表符分隔），首行为表头
+func parseCSV(content string, mapping map[string]string) ([]*Item, []string, error) {
+	firstLine := content
+	if i := strings.IndexByte(content, '\n'); i >= 0 {
+		firstLine = content[:i]
+	}
+
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.Comma = detectDelimiter(firstLine)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析CSV失败: %w", err)
+	}
+	if len(records) < 2 {
+		return nil, nil, fmt.Errorf("CSV至少需要表头和一行数据")
+	}
+
+	columns, warnings := resolveColumns(records[0], mapping)
+	if _, ok := columns[FieldTitle]; !ok {
+		return nil, warnings, fmt.Errorf("CSV中未找到标题列，请通过 mapping 指定 title 对应的列名")
+	}
+
+	var items []*Item
+	for i, record := range records[1:] {
+		item := recordToItem(record, columns)
+		if item == nil {
+			continue
+		}
+		if item.SourceRef == "" {
+			item.SourceRef = "row:" + strconv.Itoa(i+2)
+		}
+		items = append(items, item)
+	}
+	return items, warnings, nil
+}
+
+// detectDelimiter 按表头中出现最多的分隔符判断
+func detectDelimiter(header string) rune {
+	best, bestCount := ',', 0
+	for _, delimiter := range []rune{',', ';', '\t'} {
+		if count := strings.Count(header, string(delimiter)); count > bestCount {
+			best, bestCount = delimiter, count
+		}
+	}
+	return best
+}
+
+// normalizeColumn 列名规范化：小写并去除空白、下划线和连字符
+func normalizeColumn(name string) string {
+	name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+	return strings.NewReplacer(" ", "", "_", "", "-", "", "\t", "").Replace(name)
+}
+
+// resolveColumns 确定各字段对应的列下标：显式映射优先，其次按别名自动识别
+func resolveColumns(header []string, mapping map[string]string) (map[string]int, []string) {
+	index := make(map[string]int, len(header))
+	for i, column := range header {
+		key := normalizeColumn(column)
+		if _, exists := index[key]; !exists {
+			index[key] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	var warnings []string
+	for field, column := range mapping {
+		if _, known := columnAliases[field]; !known {
+			warnings = append(warnings, fmt.Sprintf("未知的映射字段: %s", field))
+			continue
+		}
+		if i, ok := index[normalizeColumn(column)]; ok {
+			columns[field] = i
+		} else {
+			warnings = append(warnings, fmt.Sprintf("映射的列 %q 不存在", column))
+		}
+	}
+
+	for field, aliases := range columnAliases {
+		if _, mapped := columns[field]; mapped {
+			continue
+		}
+		for _, alias := range aliases {
+			if i, ok := index[alias]; ok {
+				columns[field] = i
+				break
+			}
+		}
+	}
+	return columns, warnings
+}
+
+// recordToItem 按列映射将一行转换为条目，标题为空时返回 nil
+func recordToItem(record []string, columns map[string]int) *Item {
+	cell := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	title := cell(FieldTitle)
+	if title == "" {
+		return nil
+	}
+
+	item := &Item{Title: title, SourceRef: cell(FieldKey)}
+	description, criteria := splitAcceptanceCriteria(cell(FieldDescription))
+	item.Description = description
+	item.AcceptanceCriteria = append(splitCriteriaCell(cell(FieldAcceptanceCriteria)), criteria...)
+
+	if value := cell(FieldPriority); value != "" {
+		if p := NormalizePriority(value); p != "" {
+			item.Priority = p
+		} else {
+			item.Warnings = append(item.Warnings, "无法识别的优先级: "+value)
+		}
+	}
+	if value := cell(FieldStoryPoints); value != "" {
+		if points, ok := parsePoints(value); ok {
+			item.StoryPoints = &points
+		} else {
+			item.Warnings = append(item.Warnings, "无法识别的故事点: "+value)
+		}
+	}
+	item.Labels = splitLabels(cell(FieldLabels))
+	return item
+}
@@ -0,0 +1,297 @@
+// Package importer 解析外部需求来源（Confluence导出的Markdown、Excel另存的CSV、Jira/GitHub问题导出JSON），
+// 统一转换为用户故事条目，并按规范化标题哈希去重
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Format 导入格式
+type Format string
+
+const (
+	FormatAuto     Format = "auto"
+	FormatMarkdown Format = "markdown"
+	FormatCSV      Format = "csv"
+	FormatJira     Format = "jira"
+	FormatGitHub   Format = "github"
+)
+
+// 标准优先级，与 UserStory.Priority 一致
+const (
+	PriorityHigh   = "high"
+	PriorityMedium = "medium"
+	PriorityLow    = "low"
+)
+
+// Item 解析出的需求条目，对应一个用户故事
+type Item struct {
+	Title              string   `json:"title"`
+	Description        string   `json:"description"`
+	AcceptanceCriteria []string `json:"acceptance_criteria"`
+	Priority           string   `json:"priority"`
+	StoryPoints        *int     `json:"story_points,omitempty"`
+	Labels             []string `json:"labels,omitempty"`
+	SourceRef          string   `json:"source_ref,omitempty"` // 来源编号，如 Jira key、GitHub issue 编号、CSV 行号
+	TitleHash          string   `json:"title_hash"`
+	Duplicate          bool     `json:"duplicate"`              // 与本批次或已有故事重复
+	DuplicateOf        string   `json:"duplicate_of,omitempty"` // 重复的来源：batch:<source_ref> 或 existing
+	Warnings           []string `json:"warnings,omitempty"`     // 字段映射问题
+}
+
+// Options 解析选项
+type Options struct {
+	Format   Format
+	FileName string            // 用于按扩展名判断格式
+	Mapping  map[string]string // CSV列映射：字段名 -> 列名，字段见 FieldTitle 等
+}
+
+// Result 解析结果
+type Result struct {
+	Format     Format   `json:"format"`
+	Items      []*Item  `json:"items"`
+	Total      int      `json:"total"`
+	Duplicates int      `json:"duplicates"`
+	Warnings   []string `json:"warnings,omitempty"` // 整体问题，如无法识别的列
+}
+
+// Parse 按格式解析导入内容，格式为空或 auto 时自动识别
+func Parse(content string, opts Options) (*Result, error) {
+	content = strings.TrimPrefix(content, "\ufeff")
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("导入内容不能为空")
+	}
+
+	format := opts.Format
+	if format == "" || format == FormatAuto {
+		format = DetectFormat(content, opts.FileName)
+	}
+
+	result := &Result{Format: format}
+	var err error
+	switch format {
+	case FormatMarkdown:
+		result.Items = parseMarkdown(content)
+	case FormatCSV:
+		result.Items, result.Warnings, err = parseCSV(content, opts.Mapping)
+	case FormatJira:
+		result.Items, err = parseJira(content)
+	case FormatGitHub:
+		result.Items, err = parseGitHub(content)
+	default:
+		return nil, fmt.Errorf("不支持的导入格式: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items := result.Items[:0]
+	for _, item := range result.Items {
+		item.Title = strings.TrimSpace(item.Title)
+		if item.Title == "" {
+			continue
+		}
+		item.Description = strings.TrimSpace(item.Description)
+		if item.AcceptanceCriteria == nil {
+			item.AcceptanceCriteria = []string{}
+		}
+		if item.Priority == "" {
+			item.Priority = PriorityMedium
+		}
+		item.TitleHash = TitleHash(item.Title)
+		items = append(items, item)
+	}
+	result.Items = items
+	result.Total = len(items)
+	result.Duplicates = MarkDuplicates(items, nil)
+
+	return result, nil
+}
+
+// DetectFormat 根据文件扩展名和内容识别导入格式
+func DetectFormat(content, fileName string) Format {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".md", ".markdown":
+		return FormatMarkdown
+	case ".csv", ".tsv":
+		return FormatCSV
+	}
+
+	trimmed := strings.TrimSpace(content)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var probe interface{}
+		if json.Unmarshal([]byte(trimmed), &probe) == nil {
+			if isJiraExport(probe) {
+				return FormatJira
+			}
+			return FormatGitHub
+		}
+	}
+
+	if looksLikeCSV(trimmed) {
+		return FormatCSV
+	}
+	return FormatMarkdown
+}
+
+// isJiraExport Jira导出包含 issues 数组或带 fields 的问题对象
+func isJiraExport(probe interface{}) bool {
+	switch v := probe.(type) {
+	case map[string]interface{}:
+		if _, ok := v["issues"]; ok {
+			return true
+		}
+		_, ok := v["fields"]
+		return ok
+	case []interface{}:
+		if len(v) > 0 {
+			if first, ok := v[0].(map[string]interface{}); ok {
+				_, hasFields := first["fields"]
+				return hasFields
+			}
+		}
+	}
+	return false
+}
+
+// looksLikeCSV 前几行分隔符数量一致且大于0，且不以Markdown标记开头
+func looksLikeCSV(content string) bool {
+	lines := strings.Split(content, "\n")
+	if len(lines) < 2 {
+		return false
+	}
+	first := strings.TrimSpace(lines[0])
+	if strings.HasPrefix(first, "#") || strings.HasPrefix(first, "|") || strings.HasPrefix(first, "-") {
+		return false
+	}
+	delimiter := detectDelimiter(first)
+	count := strings.Count(first, string(delimiter))
+	return count > 0 && strings.Count(lines[1], string(delimiter)) >= count
+}
+
+// MarkDuplicates 按标题哈希标记重复项：与 existing 中的哈希或本批次中更早的条目重复，返回重复数量
+func MarkDuplicates(items []*Item, existing map[string]bool) int {
+	seen := make(map[string]*Item, len(items))
+	duplicates := 0
+	for _, item := range items {
+		item.Duplicate = false
+		item.DuplicateOf = ""
+		switch {
+		case existing[item.TitleHash]:
+			item.Duplicate = true
+			item.DuplicateOf = "existing"
+		case seen[item.TitleHash] != nil:
+			item.Duplicate = true
+			item.DuplicateOf = "batch:" + seen[item.TitleHash].SourceRef
+		default:
+			seen[item.TitleHash] = item
+		}
+		if item.Duplicate {
+			duplicates++
+		}
+	}
+	return duplicates
+}
+
+// 标题中常见的编号前缀，如 "PROJ-12:"、"#34"、"US-1 "
+var titlePrefixRe = regexp.MustCompile(`^(?:[A-Za-z]+-\d+|#\d+|\d+[.、)])[\s:：.-]*`)
+
+// NormalizeTitle 规范化标题：去除编号前缀、标点和空白，转小写
+func NormalizeTitle(title string) string {
+	title = titlePrefixRe.ReplaceAllString(strings.TrimSpace(title), "")
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// TitleHash 规范化标题的SHA-256哈希（前16字节）
+func TitleHash(title string) string {
+	sum := sha256.Sum256([]byte(NormalizeTitle(title)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// NormalizePriority 将各来源的优先级统一为 high/medium/low，无法识别时返回空
+func NormalizePriority(value string) string {
+	v := strings.ToLower(strings.TrimSpace(value))
+	v = strings.TrimPrefix(v, "priority")
+	v = strings.Trim(v, " :：/-_")
+	switch v {
+	case "highest", "high", "critical", "blocker", "urgent", "major", "p0", "p1", "must", "高", "紧急", "最高":
+		return PriorityHigh
+	case "medium", "normal", "moderate", "p2", "should", "中", "一般":
+		return PriorityMedium
+	case "low", "lowest", "minor", "trivial", "p3", "p4", "could", "低", "最低":
+		return PriorityLow
+	}
+	return ""
+}
+
+// 验收标准章节标题
+var acceptanceHeadingRe = regexp.MustCompile(`(?i)^(?:#{1,6}\s*|\*\*|h\d\.\s*)?(acceptance criteria|验收标准|验收条件|ac)\s*[:：]?\s*(?:\*\*)?\s*[:：]?\s*$`)
+
+// 列表项前缀，含任务列表 "- [ ]"
+var listItemRe = regexp.MustCompile(`^\s*(?:[-*+•]|\d+[.、)])\s+(?:\[[ xX]\]\s+)?`)
+
+// splitAcceptanceCriteria 从描述中拆出验收标准章节（标题后的列表或 Given/When/Then 行），返回剩余描述和验收标准
+func splitAcceptanceCriteria(text string) (string, []string) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var description []string
+	var criteria []string
+	inSection := false
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if acceptanceHeadingRe.MatchString(trimmed) {
+			inSection = true
+			continue
+		}
+		if inSection {
+			// 遇到下一个标题时结束验收标准章节
+			if strings.HasPrefix(trimmed, "#") || (strings.HasPrefix(trimmed, "**") && strings.HasSuffix(trimmed, "**") && len(trimmed) > 4) {
+				inSection = false
+				description = append(description, line)
+				continue
+			}
+			if trimmed == "" {
+				continue
+			}
+			if item := strings.TrimSpace(listItemRe.ReplaceAllString(trimmed, "")); item != "" {
+				criteria = append(criteria, item)
+			}
+			continue
+		}
+		// 章节之外的任务列表项也视为验收标准
+		if strings.HasPrefix(trimmed, "- [ ]") || strings.HasPrefix(trimmed, "- [x]") || strings.HasPrefix(trimmed, "- [X]") {
+			criteria = append(criteria, strings.TrimSpace(listItemRe.ReplaceAllString(trimmed, "")))
+			continue
+		}
+		description = append(description, line)
+	}
+
+	return strings.TrimSpace(strings.Join(description, "\n")), criteria
+}
+
+// 单元格中验收标准的分隔符
+var criteriaSeparatorRe = regexp.MustCompile(`\r?\n|[;；]`)
+
+// splitCriteriaCell 拆分单元格中的多条验收标准（换行或分号分隔）
+func splitCriteriaCell(value string) []string {
+	var criteria []string
+	for _, part := range criteriaSeparatorRe.Split(value, -1) {
+		if part = strings.TrimSpace(listItemRe.ReplaceAllString(strings.TrimSpace(part), "")); part != "" {
+			criteria = append(criteria, part)
+		}
+	}
+	return criteria
+}
@@ -0,0 +1,170 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMarkdownHeadings(t *testing.T) {
+	content := `# 图书管理系统需求
+
+## 借阅图书
+作为读者，我希望在线借书。
+优先级: 高
+故事点: 3
+
+### 验收标准
+- 库存为0时提示无法借阅
+- 借阅成功后库存减1
+
+## 归还图书
+**Priority**: low
+- [ ] 逾期归还时计算罚金
+
+## 借阅图书
+重复的条目
+`
+	result, err := Parse(content, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, FormatMarkdown, result.Format)
+	require.Len(t, result.Items, 3)
+
+	first := result.Items[0]
+	assert.Equal(t, "借阅图书", first.Title)
+	assert.Equal(t, "作为读者，我希望在线借书。", first.Description)
+	assert.Equal(t, []string{"库存为0时提示无法借阅", "借阅成功后库存减1"}, first.AcceptanceCriteria)
+	assert.Equal(t, PriorityHigh, first.Priority)
+	require.NotNil(t, first.StoryPoints)
+	assert.Equal(t, 3, *first.StoryPoints)
+
+	second := result.Items[1]
+	assert.Equal(t, PriorityLow, second.Priority)
+	assert.Equal(t, []string{"逾期归还时计算罚金"}, second.AcceptanceCriteria)
+
+	assert.True(t, result.Items[2].Duplicate)
+	assert.Equal(t, "batch:line:3", result.Items[2].DuplicateOf)
+	assert.Equal(t, 1, result.Duplicates)
+}
+
+func TestParseMarkdownTableAndList(t *testing.T) {
+	table := "| 编号 | 标题 | 验收标准 | 优先级 |\n|---|---|---|---|\n| US-1 | 用户登录 | 密码错误提示<br>登录成功跳转 | P1 |\n| US-2 | 用户注销 \\| 退出 | | 低 |\n"
+	result, err := Parse(table, Options{Format: FormatMarkdown})
+	require.NoError(t, err)
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, "US-1", result.Items[0].SourceRef)
+	assert.Equal(t, []string{"密码错误提示", "登录成功跳转"}, result.Items[0].AcceptanceCriteria)
+	assert.Equal(t, PriorityHigh, result.Items[0].Priority)
+	assert.Equal(t, "用户注销 | 退出", result.Items[1].Title)
+
+	list := "- 导出报表\n  - 支持PDF\n  - 优先级: 低\n- 导入数据\n"
+	result, err = Parse(list, Options{Format: FormatMarkdown})
+	require.NoError(t, err)
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, []string{"支持PDF"}, result.Items[0].AcceptanceCriteria)
+	assert.Equal(t, PriorityLow, result.Items[0].Priority)
+	assert.Equal(t, PriorityMedium, result.Items[1].Priority)
+}
+
+func TestParseCSV(t *testing.T) {
+	content := "\ufeffSummary;Description;Acceptance Criteria;Priority;Story Points;Owner\n" +
+		"Search books;Readers search by title;\"Results within 1s\nEmpty query shows hint\";Highest;2.5;alice\n" +
+		";no title;;;;\n" +
+		"search   BOOKS!;dup;;;;\n"
+	result, err := Parse(content, Options{FileName: "export.csv"})
+	require.NoError(t, err)
+	assert.Equal(t, FormatCSV, result.Format)
+	require.Len(t, result.Items, 2)
+
+	item := result.Items[0]
+	assert.Equal(t, "Search books", item.Title)
+	assert.Equal(t, "row:2", item.SourceRef)
+	assert.Equal(t, []string{"Results within 1s", "Empty query shows hint"}, item.AcceptanceCriteria)
+	assert.Equal(t, PriorityHigh, item.Priority)
+	require.NotNil(t, item.StoryPoints)
+	assert.Equal(t, 3, *item.StoryPoints)
+	assert.True(t, result.Items[1].Duplicate)
+}
+
+func TestParseCSVMapping(t *testing.T) {
+	content := "Feature,Notes\nExport,as pdf\n"
+	_, err := Parse(content, Options{Format: FormatCSV})
+	assert.Error(t, err)
+
+	result, err := Parse(content, Options{Format: FormatCSV, Mapping: map[string]string{"title": "Feature", "description": "Notes", "owner": "x"}})
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, "Export", result.Items[0].Title)
+	assert.Equal(t, "as pdf", result.Items[0].Description)
+	assert.Contains(t, result.Warnings, "未知的映射字段: owner")
+}
+
+func TestParseJira(t *testing.T) {
+	content := `{"issues":[
+	  {"key":"LIB-1","fields":{"summary":"Borrow book","priority":{"name":"High"},"customfield_10016":5,
+	    "labels":["circulation"],"issuetype":{"name":"Story"},
+	    "description":{"type":"doc","content":[
+	      {"type":"paragraph","content":[{"type":"text","text":"As a reader I want to borrow."}]},
+	      {"type":"heading","attrs":{"level":3},"content":[{"type":"text","text":"Acceptance Criteria"}]},
+	      {"type":"bulletList","content":[
+	        {"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"Stock decreases"}]}]}
+	      ]}
+	    ]}}},
+	  {"key":"LIB-2","fields":{"summary":"Return book","description":"Plain text","priority":{"name":"Trivial"}}}
+	]}`
+	result, err := Parse(content, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, FormatJira, result.Format)
+	require.Len(t, result.Items, 2)
+
+	item := result.Items[0]
+	assert.Equal(t, "LIB-1", item.SourceRef)
+	assert.Equal(t, "As a reader I want to borrow.", item.Description)
+	assert.Equal(t, []string{"Stock decreases"}, item.AcceptanceCriteria)
+	assert.Equal(t, PriorityHigh, item.Priority)
+	require.NotNil(t, item.StoryPoints)
+	assert.Equal(t, 5, *item.StoryPoints)
+	assert.Equal(t, []string{"circulation", "type:Story"}, item.Labels)
+	assert.Equal(t, PriorityLow, result.Items[1].Priority)
+}
+
+func TestParseGitHub(t *testing.T) {
+	content := `[
+	  {"number":12,"title":"Add dark mode","body":"Users want dark mode.\n\n## Acceptance Criteria\n- Toggle in settings\n- Persist choice","labels":[{"name":"priority: high"},{"name":"ui"}]},
+	  {"number":13,"title":"Fix typo","body":"","pull_request":{"url":"x"}},
+	  {"number":14,"title":"Offline mode","body":"- [ ] Cache pages","labels":["P3"]}
+	]`
+	result, err := Parse(content, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, FormatGitHub, result.Format)
+	require.Len(t, result.Items, 2)
+
+	item := result.Items[0]
+	assert.Equal(t, "#12", item.SourceRef)
+	assert.Equal(t, "Users want dark mode.", item.Description)
+	assert.Equal(t, []string{"Toggle in settings", "Persist choice"}, item.AcceptanceCriteria)
+	assert.Equal(t, PriorityHigh, item.Priority)
+	assert.Equal(t, []string{"ui"}, item.Labels)
+
+	assert.Equal(t, []string{"Cache pages"}, result.Items[1].AcceptanceCriteria)
+	assert.Equal(t, PriorityLow, result.Items[1].Priority)
+}
+
+func TestTitleHashAndExisting(t *testing.T) {
+	assert.Equal(t, TitleHash("PROJ-12: User Login"), TitleHash("user login"))
+	assert.Equal(t, TitleHash("#3 用户 登录。"), TitleHash("用户登录"))
+	assert.NotEqual(t, TitleHash("用户登录"), TitleHash("用户注销"))
+
+	items := []*Item{{Title: "登录", TitleHash: TitleHash("登录")}, {Title: "注册", TitleHash: TitleHash("注册")}}
+	assert.Equal(t, 1, MarkDuplicates(items, map[string]bool{TitleHash("登录"): true}))
+	assert.Equal(t, "existing", items[0].DuplicateOf)
+	assert.False(t, items[1].Duplicate)
+}
+
+func TestParseEmpty(t *testing.T) {
+	_, err := Parse("   ", Options{})
+	assert.Error(t, err)
+	_, err = Parse("x", Options{Format: "xml"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,240 @@
+package importer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	headingRe = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+	// Confluence 导出中常见的 "h2. 标题" 写法
+	confluenceHeadingRe = regexp.MustCompile(`^h([1-6])\.\s+(.+)$`)
+	// 元数据行，如 "优先级: 高"、"**Story Points**: 5"
+	metaLineRe = regexp.MustCompile(`(?i)^[-*]?\s*(?:\*\*)?(优先级|priority|故事点|story\s*points?|points|估算|标签|labels?)(?:\*\*)?\s*[:：]\s*(?:\*\*)?\s*(.+?)\s*$`)
+	tableSepRe = regexp.MustCompile(`^\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?$`)
+)
+
+// heading Markdown标题
+type heading struct {
+	line  int
+	level int
+	text  string
+}
+
+// parseMarkdown 解析Markdown：优先识别需求表格，其次按标题拆分条目，都没有时按顶层列表项拆分
+func parseMarkdown(content string) []*Item {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	if items := parseMarkdownTable(lines); len(items) > 0 {
+		return items
+	}
+	if items := parseMarkdownHeadings(lines); len(items) > 0 {
+		return items
+	}
+	return parseMarkdownList(lines)
+}
+
+// parseMarkdownHeadings 按标题拆分条目
+// 只出现一次的最高级标题视为文档标题，其下一级标题为条目；验收标准等章节标题不作为条目
+func parseMarkdownHeadings(lines []string) []*Item {
+	var headings []heading
+	levels := make(map[int]int)
+	for i, line := range lines {
+		h, ok := parseHeading(line)
+		if !ok || acceptanceHeadingRe.MatchString(strings.TrimSpace(line)) {
+			continue
+		}
+		h.line = i
+		headings = append(headings, h)
+		levels[h.level]++
+	}
+	if len(headings) == 0 {
+		return nil
+	}
+
+	itemLevel := 7
+	for level := range levels {
+		if level < itemLevel {
+			itemLevel = level
+		}
+	}
+	if levels[itemLevel] == 1 && len(levels) > 1 {
+		next := 7
+		for level := range levels {
+			if level > itemLevel && level < next {
+				next = level
+			}
+		}
+		itemLevel = next
+	}
+
+	var items []*Item
+	for i, h := range headings {
+		if h.level != itemLevel {
+			continue
+		}
+		end := len(lines)
+		for _, next := range headings[i+1:] {
+			if next.level <= itemLevel {
+				end = next.line
+				break
+			}
+		}
+		item := &Item{Title: h.text, SourceRef: "line:" + strconv.Itoa(h.line+1)}
+		applyBody(item, strings.Join(lines[h.line+1:end], "\n"))
+		items = append(items, item)
+	}
+	return items
+}
+
+// parseHeading 解析 Markdown 或 Confluence 标题
+func parseHeading(line string) (heading, bool) {
+	trimmed := strings.TrimSpace(line)
+	if m := headingRe.FindStringSubmatch(trimmed); m != nil {
+		return heading{level: len(m[1]), text: strings.TrimSpace(m[2])}, true
+	}
+	if m := confluenceHeadingRe.FindStringSubmatch(trimmed); m != nil {
+		level, _ := strconv.Atoi(m[1])
+		return heading{level: level, text: strings.TrimSpace(m[2])}, true
+	}
+	return heading{}, false
+}
+
+// parseMarkdownList 按顶层列表项拆分条目，缩进的子项作为验收标准
+func parseMarkdownList(lines []string) []*Item {
+	var items []*Item
+	var current *Item
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" || !listItemRe.MatchString(line) {
+			continue
+		}
+		text := strings.TrimSpace(listItemRe.ReplaceAllString(line, ""))
+		indented := strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+		if indented && current != nil {
+			if !applyMeta(current, text) {
+				current.AcceptanceCriteria = append(current.AcceptanceCriteria, text)
+			}
+			continue
+		}
+		current = &Item{Title: text, SourceRef: "line:" + strconv.Itoa(i+1)}
+		items = append(items, current)
+	}
+	return items
+}
+
+// parseMarkdownTable 解析第一个包含标题列的Markdown表格
+func parseMarkdownTable(lines []string) []*Item {
+	for i := 0; i+1 < len(lines); i++ {
+		header := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(header, "|") || !tableSepRe.MatchString(strings.TrimSpace(lines[i+1])) {
+			continue
+		}
+
+		columns := splitTableRow(header)
+		mapping, _ := resolveColumns(columns, nil)
+		if _, ok := mapping[FieldTitle]; !ok {
+			continue
+		}
+
+		var items []*Item
+		for j := i + 2; j < len(lines); j++ {
+			row := strings.TrimSpace(lines[j])
+			if !strings.HasPrefix(row, "|") {
+				break
+			}
+			cells := splitTableRow(row)
+			for k := range cells {
+				cells[k] = strings.ReplaceAll(cells[k], "<br>", "\n")
+			}
+			if item := recordToItem(cells, mapping); item != nil {
+				if item.SourceRef == "" {
+					item.SourceRef = "line:" + strconv.Itoa(j+1)
+				}
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	return nil
+}
+
+// splitTableRow 拆分Markdown表格行，支持转义的竖线
+func splitTableRow(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	row = strings.ReplaceAll(row, `\|`, "\x00")
+	cells := strings.Split(row, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(strings.ReplaceAll(cell, "\x00", "|"))
+	}
+	return cells
+}
+
+// applyBody 解析条目正文：提取元数据行、验收标准，其余作为描述
+func applyBody(item *Item, body string) {
+	var rest []string
+	for _, line := range strings.Split(body, "\n") {
+		if applyMeta(item, strings.TrimSpace(line)) {
+			continue
+		}
+		rest = append(rest, line)
+	}
+	description, criteria := splitAcceptanceCriteria(strings.Join(rest, "\n"))
+	item.Description = description
+	item.AcceptanceCriteria = append(item.AcceptanceCriteria, criteria...)
+}
+
+// applyMeta 识别优先级、故事点和标签元数据行
+func applyMeta(item *Item, line string) bool {
+	m := metaLineRe.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+	key := strings.ToLower(strings.Join(strings.Fields(m[1]), " "))
+	value := strings.TrimSpace(strings.Trim(m[2], "*"))
+	switch {
+	case key == "优先级" || key == "priority":
+		if p := NormalizePriority(value); p != "" {
+			item.Priority = p
+		} else {
+			item.Warnings = append(item.Warnings, "无法识别的优先级: "+value)
+		}
+	case key == "标签" || strings.HasPrefix(key, "label"):
+		item.Labels = append(item.Labels, splitLabels(value)...)
+	default:
+		if points, ok := parsePoints(value); ok {
+			item.StoryPoints = &points
+		} else {
+			item.Warnings = append(item.Warnings, "无法识别的故事点: "+value)
+		}
+	}
+	return true
+}
+
+// parsePoints 解析故事点，支持小数（向上取整）
+func parsePoints(value string) (int, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return 0, false
+	}
+	points := int(f)
+	if float64(points) < f {
+		points++
+	}
+	return points, true
+}
+
+// splitLabels 拆分逗号分隔的标签
+func splitLabels(value string) []string {
+	var labels []string
+	for _, label := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '，' || r == ';' }) {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
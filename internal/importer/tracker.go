@@ -0,0 +1,246 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Jira 中常见的故事点自定义字段
+var jiraStoryPointFields = []string{"story_points", "storyPoints", "customfield_10016", "customfield_10026", "customfield_10002", "customfield_10004"}
+
+// Jira 中常见的验收标准自定义字段
+var jiraAcceptanceFields = []string{"acceptance_criteria", "acceptanceCriteria", "customfield_10035", "customfield_10500"}
+
+// parseJira 解析Jira导出JSON：{"issues":[...]}、问题数组或单个问题
+func parseJira(content string) ([]*Item, error) {
+	var raw interface{}
+	if err := json.Unmarshal([]byte(content), &raw); err != nil {
+		return nil, fmt.Errorf("解析Jira导出失败: %w", err)
+	}
+
+	var issues []interface{}
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		if list, ok := v["issues"].([]interface{}); ok {
+			issues = list
+		} else {
+			issues = []interface{}{v}
+		}
+	case []interface{}:
+		issues = v
+	}
+
+	var items []*Item
+	for _, entry := range issues {
+		issue, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		fields, _ := issue["fields"].(map[string]interface{})
+		if fields == nil {
+			fields = issue
+		}
+
+		item := &Item{
+			Title:     stringValue(fields["summary"]),
+			SourceRef: stringValue(issue["key"]),
+		}
+		description, criteria := splitAcceptanceCriteria(jiraText(fields["description"]))
+		item.Description = description
+		for _, field := range jiraAcceptanceFields {
+			if text := jiraText(fields[field]); text != "" {
+				item.AcceptanceCriteria = append(item.AcceptanceCriteria, splitCriteriaCell(text)...)
+			}
+		}
+		item.AcceptanceCriteria = append(item.AcceptanceCriteria, criteria...)
+
+		if priority := nestedName(fields["priority"]); priority != "" {
+			item.Priority = NormalizePriority(priority)
+			if item.Priority == "" {
+				item.Warnings = append(item.Warnings, "无法识别的优先级: "+priority)
+			}
+		}
+		for _, field := range jiraStoryPointFields {
+			if points, ok := numberValue(fields[field]); ok {
+				item.StoryPoints = &points
+				break
+			}
+		}
+		if labels, ok := fields["labels"].([]interface{}); ok {
+			for _, label := range labels {
+				if name := stringValue(label); name != "" {
+					item.Labels = append(item.Labels, name)
+				}
+			}
+		}
+		if issueType := nestedName(fields["issuetype"]); issueType != "" {
+			item.Labels = append(item.Labels, "type:"+issueType)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// githubIssue GitHub问题导出（REST API 或 gh issue list --json）
+type githubIssue struct {
+	Number      int               `json:"number"`
+	Title       string            `json:"title"`
+	Body        string            `json:"body"`
+	Labels      []json.RawMessage `json:"labels"`
+	PullRequest json.RawMessage   `json:"pull_request"`
+}
+
+// parseGitHub 解析GitHub问题导出JSON，跳过 Pull Request
+func parseGitHub(content string) ([]*Item, error) {
+	var issues []githubIssue
+	trimmed := strings.TrimSpace(content)
+	if strings.HasPrefix(trimmed, "{") {
+		var wrapper struct {
+			Items  []githubIssue `json:"items"` // 搜索API
+			Issues []githubIssue `json:"issues"`
+		}
+		if err := json.Unmarshal([]byte(trimmed), &wrapper); err != nil {
+			return nil, fmt.Errorf("解析GitHub导出失败: %w", err)
+		}
+		issues = append(wrapper.Items, wrapper.Issues...)
+		if len(issues) == 0 {
+			var single githubIssue
+			if err := json.Unmarshal([]byte(trimmed), &single); err == nil && single.Title != "" {
+				issues = append(issues, single)
+			}
+		}
+	} else if err := json.Unmarshal([]byte(trimmed), &issues); err != nil {
+		return nil, fmt.Errorf("解析GitHub导出失败: %w", err)
+	}
+
+	var items []*Item
+	for _, issue := range issues {
+		if len(issue.PullRequest) > 0 && string(issue.PullRequest) != "null" {
+			continue
+		}
+
+		item := &Item{Title: issue.Title}
+		if issue.Number > 0 {
+			item.SourceRef = "#" + strconv.Itoa(issue.Number)
+		}
+
+		var body []string
+		for _, line := range strings.Split(issue.Body, "\n") {
+			if !applyMeta(item, strings.TrimSpace(line)) {
+				body = append(body, line)
+			}
+		}
+		item.Description, item.AcceptanceCriteria = splitAcceptanceCriteria(strings.Join(body, "\n"))
+
+		for _, rawLabel := range issue.Labels {
+			name := githubLabelName(rawLabel)
+			if name == "" {
+				continue
+			}
+			// 优先级标签，如 "priority: high"、"P1"
+			if p := NormalizePriority(name); p != "" {
+				item.Priority = p
+				continue
+			}
+			item.Labels = append(item.Labels, name)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// githubLabelName 标签可能是字符串或 {"name": ...} 对象
+func githubLabelName(raw json.RawMessage) string {
+	var name string
+	if json.Unmarshal(raw, &name) == nil {
+		return name
+	}
+	var label struct {
+		Name string `json:"name"`
+	}
+	if json.Unmarshal(raw, &label) == nil {
+		return label.Name
+	}
+	return ""
+}
+
+// jiraText 将 Jira 字段转为纯文本，支持字符串和 Atlassian Document Format
+func jiraText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		var b strings.Builder
+		writeADF(&b, v)
+		return strings.TrimSpace(b.String())
+	}
+	return ""
+}
+
+// writeADF 递归展开 ADF 节点：段落和标题换行，列表项加 "- " 前缀
+func writeADF(b *strings.Builder, node map[string]interface{}) {
+	nodeType, _ := node["type"].(string)
+	switch nodeType {
+	case "text":
+		b.WriteString(stringValue(node["text"]))
+		return
+	case "hardBreak":
+		b.WriteString("\n")
+		return
+	case "listItem":
+		b.WriteString("- ")
+	case "heading":
+		level := 2
+		if attrs, ok := node["attrs"].(map[string]interface{}); ok {
+			if l, ok := numberValue(attrs["level"]); ok {
+				level = l
+			}
+		}
+		b.WriteString(strings.Repeat("#", level) + " ")
+	}
+
+	if children, ok := node["content"].([]interface{}); ok {
+		for _, child := range children {
+			if childNode, ok := child.(map[string]interface{}); ok {
+				writeADF(b, childNode)
+			}
+		}
+	}
+
+	switch nodeType {
+	case "paragraph", "heading":
+		if !strings.HasSuffix(b.String(), "\n") {
+			b.WriteString("\n")
+		}
+	}
+}
+
+// nestedName 取 {"name": ...} 对象的名称，或直接返回字符串
+func nestedName(value interface{}) string {
+	if m, ok := value.(map[string]interface{}); ok {
+		return stringValue(m["name"])
+	}
+	return stringValue(value)
+}
+
+func stringValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+func numberValue(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return parsePoints(strconv.FormatFloat(v, 'f', -1, 64))
+	case string:
+		return parsePoints(v)
+	}
+	return 0, false
+}
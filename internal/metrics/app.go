@@ -0,0 +1,87 @@
+package metrics
+
+import "net/http"
+
+// Default 全局默认注册表
+var Default = NewRegistry()
+
+// HTTP请求指标
+var (
+	HTTPRequestsTotal = Default.NewCounterVec("http_requests_total",
+		"HTTP请求总数", "method", "route", "status")
+	HTTPRequestDuration = Default.NewHistogramVec("http_request_duration_seconds",
+		"HTTP请求耗时（秒）", nil, "method", "route", "status")
+)
+
+// AI调用指标
+var (
+	AIRequestsTotal = Default.NewCounterVec("ai_requests_total",
+		"AI接口调用总数", "provider", "model", "operation", "status")
+	AIRequestDuration = Default.NewHistogramVec("ai_request_duration_seconds",
+		"AI接口调用耗时（秒）", []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120}, "provider", "model", "operation")
+	AITokensTotal = Default.NewCounterVec("ai_tokens_total",
+		"AI接口消耗的Token数", "provider", "model", "operation", "type")
+)
+
+// 缓存指标，cache 标签取值：ai、puml_render
+var (
+	CacheRequestsTotal = Default.NewCounterVec("cache_requests_total",
+		"缓存查询次数", "cache", "result")
+	CacheEntries = Default.NewGaugeVec("cache_entries",
+		"缓存条目数", "cache")
+	_ = Default.NewGaugeFunc("cache_hit_ratio",
+		"缓存命中率（命中次数/查询次数）", cacheHitRatios, "cache")
+)
+
+// PlantUML渲染指标
+var (
+	PlantUMLRenderDuration = Default.NewHistogramVec("plantuml_render_duration_seconds",
+		"PlantUML服务渲染耗时（秒）", nil, "mode", "format", "status")
+)
+
+// 异步任务指标
+var (
+	AsyncTaskQueueDepth = Default.NewGaugeVec("async_task_queue_depth",
+		"等待或正在执行的异步任务数", "task_type")
+	AsyncTasksTotal = Default.NewCounterVec("async_tasks_total",
+		"异步任务执行结果总数", "task_type", "status")
+	AsyncTaskDuration = Default.NewHistogramVec("async_task_duration_seconds",
+		"异步任务执行耗时（秒）", []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800}, "task_type", "status")
+)
+
+// RecordCacheLookup 记录一次缓存查询
+func RecordCacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	CacheRequestsTotal.Inc(cache, result)
+}
+
+// cacheHitRatios 按缓存计算命中率
+func cacheHitRatios() []Sample {
+	totals := make(map[string][2]float64) // [命中, 总数]
+	for _, s := range CacheRequestsTotal.samples() {
+		cache, result := s.LabelValues[0], s.LabelValues[1]
+		t := totals[cache]
+		if result == "hit" {
+			t[0] += s.Value
+		}
+		t[1] += s.Value
+		totals[cache] = t
+	}
+
+	samples := make([]Sample, 0, len(totals))
+	for cache, t := range totals {
+		if t[1] == 0 {
+			continue
+		}
+		samples = append(samples, Sample{LabelValues: []string{cache}, Value: t[0] / t[1]})
+	}
+	return samples
+}
+
+// Handler 返回默认注册表的HTTP处理器
+func Handler() http.Handler {
+	return Default.Handler()
+}
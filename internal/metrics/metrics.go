@@ -0,0 +1,439 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets 默认直方图分桶（秒）
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Sample 单个带标签的采样值，用于 GaugeFunc
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// collector 可导出为Prometheus文本格式的指标
+type collector interface {
+	writeTo(w io.Writer)
+}
+
+// Registry 指标注册表
+type Registry struct {
+	mutex      sync.RWMutex
+	collectors []collector
+	names      map[string]bool
+}
+
+// NewRegistry 创建指标注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		names: make(map[string]bool),
+	}
+}
+
+// register 注册指标，重复名称视为编程错误
+func (r *Registry) register(name string, c collector) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.names[name] {
+		panic(fmt.Sprintf("指标 %s 重复注册", name))
+	}
+	r.names[name] = true
+	r.collectors = append(r.collectors, c)
+}
+
+// Write 以Prometheus文本格式输出所有指标
+func (r *Registry) Write(w io.Writer) {
+	r.mutex.RLock()
+	collectors := make([]collector, len(r.collectors))
+	copy(collectors, r.collectors)
+	r.mutex.RUnlock()
+
+	for _, c := range collectors {
+		c.writeTo(w)
+	}
+}
+
+// Handler 返回输出指标的HTTP处理器
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// metricDesc 指标描述
+type metricDesc struct {
+	name       string
+	help       string
+	labelNames []string
+}
+
+func (d *metricDesc) writeHeader(w io.Writer, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, metricType)
+}
+
+// checkLabels 校验标签值数量
+func (d *metricDesc) checkLabels(labelValues []string) {
+	if len(labelValues) != len(d.labelNames) {
+		panic(fmt.Sprintf("指标 %s 需要 %d 个标签值，实际为 %d", d.name, len(d.labelNames), len(labelValues)))
+	}
+}
+
+// labelKey 标签值组合的唯一键
+func labelKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+// ===== Counter =====
+
+// CounterVec 按标签区分的计数器
+type CounterVec struct {
+	metricDesc
+	mutex  sync.Mutex
+	values map[string]*labeledValue
+}
+
+type labeledValue struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounterVec 在注册表中创建计数器
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{
+		metricDesc: metricDesc{name: name, help: help, labelNames: labelNames},
+		values:     make(map[string]*labeledValue),
+	}
+	r.register(name, c)
+	return c
+}
+
+// Inc 计数加一
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 计数增加指定值，负数将被忽略
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	c.checkLabels(labelValues)
+	if delta < 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := labelKey(labelValues)
+	v, exists := c.values[key]
+	if !exists {
+		v = &labeledValue{labelValues: append([]string(nil), labelValues...)}
+		c.values[key] = v
+	}
+	v.value += delta
+}
+
+// Value 获取指定标签的当前计数
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if v, exists := c.values[labelKey(labelValues)]; exists {
+		return v.value
+	}
+	return 0
+}
+
+// samples 返回按标签排序的采样值
+func (c *CounterVec) samples() []Sample {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return sortedSamples(c.values)
+}
+
+func (c *CounterVec) writeTo(w io.Writer) {
+	c.writeHeader(w, "counter")
+	for _, s := range c.samples() {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labelNames, s.LabelValues), formatFloat(s.Value))
+	}
+}
+
+// ===== Gauge =====
+
+// GaugeVec 按标签区分的仪表盘
+type GaugeVec struct {
+	metricDesc
+	mutex  sync.Mutex
+	values map[string]*labeledValue
+}
+
+// NewGaugeVec 在注册表中创建仪表盘
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	g := &GaugeVec{
+		metricDesc: metricDesc{name: name, help: help, labelNames: labelNames},
+		values:     make(map[string]*labeledValue),
+	}
+	r.register(name, g)
+	return g
+}
+
+// Set 设置当前值
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.update(labelValues, func(v *labeledValue) { v.value = value })
+}
+
+// Add 增加指定值（可为负数）
+func (g *GaugeVec) Add(delta float64, labelValues ...string) {
+	g.update(labelValues, func(v *labeledValue) { v.value += delta })
+}
+
+// Inc 加一
+func (g *GaugeVec) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec 减一
+func (g *GaugeVec) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+// Value 获取指定标签的当前值
+func (g *GaugeVec) Value(labelValues ...string) float64 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if v, exists := g.values[labelKey(labelValues)]; exists {
+		return v.value
+	}
+	return 0
+}
+
+func (g *GaugeVec) update(labelValues []string, fn func(v *labeledValue)) {
+	g.checkLabels(labelValues)
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	key := labelKey(labelValues)
+	v, exists := g.values[key]
+	if !exists {
+		v = &labeledValue{labelValues: append([]string(nil), labelValues...)}
+		g.values[key] = v
+	}
+	fn(v)
+}
+
+func (g *GaugeVec) writeTo(w io.Writer) {
+	g.mutex.Lock()
+	samples := sortedSamples(g.values)
+	g.mutex.Unlock()
+
+	g.writeHeader(w, "gauge")
+	for _, s := range samples {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, formatLabels(g.labelNames, s.LabelValues), formatFloat(s.Value))
+	}
+}
+
+// GaugeFunc 抓取时通过回调计算的仪表盘
+type GaugeFunc struct {
+	metricDesc
+	fn func() []Sample
+}
+
+// NewGaugeFunc 在注册表中创建回调仪表盘
+func (r *Registry) NewGaugeFunc(name, help string, fn func() []Sample, labelNames ...string) *GaugeFunc {
+	g := &GaugeFunc{
+		metricDesc: metricDesc{name: name, help: help, labelNames: labelNames},
+		fn:         fn,
+	}
+	r.register(name, g)
+	return g
+}
+
+func (g *GaugeFunc) writeTo(w io.Writer) {
+	samples := g.fn()
+	sort.Slice(samples, func(i, j int) bool {
+		return labelKey(samples[i].LabelValues) < labelKey(samples[j].LabelValues)
+	})
+
+	g.writeHeader(w, "gauge")
+	for _, s := range samples {
+		if len(s.LabelValues) != len(g.labelNames) {
+			continue
+		}
+		fmt.Fprintf(w, "%s%s %s\n", g.name, formatLabels(g.labelNames, s.LabelValues), formatFloat(s.Value))
+	}
+}
+
+// ===== Histogram =====
+
+// HistogramVec 按标签区分的直方图
+type HistogramVec struct {
+	metricDesc
+	buckets []float64
+	mutex   sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labelValues []string
+	counts      []uint64 // 各分桶计数（非累计）
+	count       uint64
+	sum         float64
+}
+
+// NewHistogramVec 在注册表中创建直方图，buckets为空时使用 DefBuckets
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	h := &HistogramVec{
+		metricDesc: metricDesc{name: name, help: help, labelNames: labelNames},
+		buckets:    sorted,
+		values:     make(map[string]*histogramValue),
+	}
+	r.register(name, h)
+	return h
+}
+
+// Observe 记录一次观测值
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.checkLabels(labelValues)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	key := labelKey(labelValues)
+	v, exists := h.values[key]
+	if !exists {
+		v = &histogramValue{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.values[key] = v
+	}
+
+	for i, upper := range h.buckets {
+		if value <= upper {
+			v.counts[i]++
+			break
+		}
+	}
+	v.count++
+	v.sum += value
+}
+
+// Count 获取指定标签的观测次数
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if v, exists := h.values[labelKey(labelValues)]; exists {
+		return v.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) writeTo(w io.Writer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	keys := make([]string, 0, len(h.values))
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h.writeHeader(w, "histogram")
+	bucketLabels := append(append([]string(nil), h.labelNames...), "le")
+	for _, key := range keys {
+		v := h.values[key]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += v.counts[i]
+			labels := append(append([]string(nil), v.labelValues...), formatFloat(upper))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(bucketLabels, labels), cumulative)
+		}
+		labels := append(append([]string(nil), v.labelValues...), "+Inf")
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(bucketLabels, labels), v.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labelNames, v.labelValues), formatFloat(v.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labelNames, v.labelValues), v.count)
+	}
+}
+
+// ===== 格式化工具 =====
+
+// sortedSamples 将标签值映射按键排序后转换为采样列表
+func sortedSamples(values map[string]*labeledValue) []Sample {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	samples := make([]Sample, 0, len(keys))
+	for _, key := range keys {
+		samples = append(samples, Sample{LabelValues: values[key].labelValues, Value: values[key].value})
+	}
+	return samples
+}
+
+// formatLabels 格式化标签，如 {a="1",b="2"}
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// formatFloat 按Prometheus格式输出浮点数
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func scrape(t *testing.T, r *Registry) string {
+	server := httptest.NewServer(r.Handler())
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return string(body)
+}
+
+func TestCounterVec(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_requests_total", "测试计数", "route", "status")
+
+	c.Inc("/a", "200")
+	c.Inc("/a", "200")
+	c.Add(3, "/b", "500")
+	c.Add(-1, "/b", "500") // 计数器不允许减少
+
+	assert.Equal(t, float64(2), c.Value("/a", "200"))
+	assert.Equal(t, float64(3), c.Value("/b", "500"))
+
+	body := scrape(t, r)
+	assert.Contains(t, body, "# TYPE test_requests_total counter")
+	assert.Contains(t, body, `test_requests_total{route="/a",status="200"} 2`)
+	assert.Contains(t, body, `test_requests_total{route="/b",status="500"} 3`)
+}
+
+func TestGaugeVec(t *testing.T) {
+	r := NewRegistry()
+	g := r.NewGaugeVec("test_queue_depth", "测试仪表盘", "task_type")
+
+	g.Inc("doc")
+	g.Inc("doc")
+	g.Dec("doc")
+	g.Set(7, "puml")
+
+	body := scrape(t, r)
+	assert.Contains(t, body, "# TYPE test_queue_depth gauge")
+	assert.Contains(t, body, `test_queue_depth{task_type="doc"} 1`)
+	assert.Contains(t, body, `test_queue_depth{task_type="puml"} 7`)
+}
+
+func TestHistogramVec(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("test_duration_seconds", "测试直方图", []float64{0.1, 1}, "op")
+
+	h.Observe(0.05, "render")
+	h.Observe(0.5, "render")
+	h.Observe(5, "render")
+
+	body := scrape(t, r)
+	assert.Contains(t, body, "# TYPE test_duration_seconds histogram")
+	assert.Contains(t, body, `test_duration_seconds_bucket{op="render",le="0.1"} 1`)
+	assert.Contains(t, body, `test_duration_seconds_bucket{op="render",le="1"} 2`)
+	assert.Contains(t, body, `test_duration_seconds_bucket{op="render",le="+Inf"} 3`)
+	assert.Contains(t, body, `test_duration_seconds_sum{op="render"} 5.55`)
+	assert.Contains(t, body, `test_duration_seconds_count{op="render"} 3`)
+}
+
+func TestLabelEscaping(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_escape_total", "转义测试", "value")
+	c.Inc("a\"b\\c\nd")
+
+	body := scrape(t, r)
+	assert.Contains(t, body, `test_escape_total{value="a\"b\\c\nd"} 1`)
+}
+
+func TestCacheHitRatio(t *testing.T) {
+	RecordCacheLookup("test_cache", true)
+	RecordCacheLookup("test_cache", true)
+	RecordCacheLookup("test_cache", true)
+	RecordCacheLookup("test_cache", false)
+
+	body := scrape(t, Default)
+	assert.Contains(t, body, `cache_hit_ratio{cache="test_cache"} 0.75`)
+	assert.True(t, strings.Contains(body, `cache_requests_total{cache="test_cache",result="miss"} 1`))
+}
+
+func TestRegistry_DuplicateName(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("dup_total", "重复")
+	assert.Panics(t, func() { r.NewGaugeVec("dup_total", "重复") })
+}
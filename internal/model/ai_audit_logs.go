@@ -0,0 +1,54 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// AIAuditLog AI调用审计日志表
+type AIAuditLog struct {
+	LogID            uuid.UUID  `json:"log_id" gorm:"type:char(36);primaryKey;column:log_id" db:"log_id"`
+	RequestID        string     `json:"request_id" gorm:"type:varchar(64);index;column:request_id" db:"request_id"`
+	UserID           string     `json:"user_id" gorm:"type:varchar(36);index;column:user_id" db:"user_id"`
+	ProjectID        string     `json:"project_id" gorm:"type:varchar(36);index;column:project_id" db:"project_id"`
+	Operation        string     `json:"operation" gorm:"type:varchar(50);index;column:operation" db:"operation"` // analyze, questions, puml, document, stage_doc, chat, raw, replay
+	Provider         string     `json:"provider" gorm:"type:varchar(20);column:provider" db:"provider"`
+	Model            string     `json:"model" gorm:"type:varchar(100);column:model" db:"model"`
+	Prompt           string     `json:"prompt" gorm:"type:longtext;column:prompt" db:"prompt"`
+	Response         string     `json:"response" gorm:"type:longtext;column:response" db:"response"`
+	ParseResult      string     `json:"parse_result,omitempty" gorm:"type:longtext;column:parse_result" db:"parse_result"`
+	ErrorMessage     string     `json:"error_message,omitempty" gorm:"type:text;column:error_message" db:"error_message"`
+	PromptTokens     int        `json:"prompt_tokens" gorm:"default:0;column:prompt_tokens" db:"prompt_tokens"`
+	CompletionTokens int        `json:"completion_tokens" gorm:"default:0;column:completion_tokens" db:"completion_tokens"`
+	TotalTokens      int        `json:"total_tokens" gorm:"default:0;column:total_tokens" db:"total_tokens"`
+	DurationMs       int64      `json:"duration_ms" gorm:"default:0;column:duration_ms" db:"duration_ms"`
+	Truncated        bool       `json:"truncated" gorm:"default:false;column:truncated" db:"truncated"` // 是否因大小限制被截断
+	ReplayOf         *uuid.UUID `json:"replay_of,omitempty" gorm:"type:char(36);column:replay_of" db:"replay_of"`
+	CreatedAt        time.Time  `json:"created_at" gorm:"autoCreateTime;index;column:created_at" db:"created_at"`
+}
+
+// TableName 指定表名
+func (AIAuditLog) TableName() string {
+	return "ai_audit_logs"
+}
+
+// AIAuditLogQuery AI审计日志查询条件
+type AIAuditLogQuery struct {
+	RequestID string
+	UserID    string
+	ProjectID string
+	Operation string
+	Provider  string
+	Keyword   string // 在提示语、响应和错误信息中搜索
+	HasError  *bool
+	Since     *time.Time
+	Until     *time.Time
+	Page      int
+	PageSize  int
+}
+
+// ReplayAIAuditLogRequest 重放AI审计日志请求
+type ReplayAIAuditLogRequest struct {
+	Provider string `json:"provider" validate:"required"`
+	Model    string `json:"model,omitempty"`
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// 依赖图中的产物类型
+const (
+	ArtifactTypeRequirement     = "requirement"      // 需求分析（按版本）
+	ArtifactTypeEntity          = "entity"           // 需求分析中的数据实体，UpstreamKey 为实体名称
+	ArtifactTypeDocument        = "document"         // 生成的文档
+	ArtifactTypePUMLDiagram     = "puml_diagram"     // PUML图表
+	ArtifactTypeRequirementsDoc = "requirements_doc" // Spec 需求文档
+	ArtifactTypeDesignDoc       = "design_doc"       // Spec 设计文档
+	ArtifactTypeTaskListDoc     = "task_list_doc"    // Spec 任务列表文档
+	ArtifactTypeTestCase        = "test_case"        // 测试用例，通过所属任务列表关联上游
+)
+
+// ArtifactDependency 产物依赖图的一条边：下游产物由上游产物的某个版本派生
+// 上游变更后边被标记为过期，下游产物的任意一条边过期即视为该产物过期
+type ArtifactDependency struct {
+	DependencyID    uuid.UUID  `json:"dependency_id" gorm:"type:char(36);primaryKey;column:dependency_id" db:"dependency_id"`
+	ProjectID       uuid.UUID  `json:"project_id" gorm:"type:char(36);not null;index;column:project_id" db:"project_id"`
+	ArtifactType    string     `json:"artifact_type" gorm:"type:varchar(30);not null;index:idx_dependency_artifact;column:artifact_type" db:"artifact_type"`
+	ArtifactID      string     `json:"artifact_id" gorm:"type:char(36);not null;index:idx_dependency_artifact;column:artifact_id" db:"artifact_id"`
+	UpstreamType    string     `json:"upstream_type" gorm:"type:varchar(30);not null;index:idx_dependency_upstream;column:upstream_type" db:"upstream_type"`
+	UpstreamID      string     `json:"upstream_id" gorm:"type:char(36);not null;index:idx_dependency_upstream;column:upstream_id" db:"upstream_id"`
+	UpstreamKey     string     `json:"upstream_key,omitempty" gorm:"type:varchar(200);column:upstream_key" db:"upstream_key"` // 数据实体名称
+	UpstreamVersion int        `json:"upstream_version" gorm:"default:0;column:upstream_version" db:"upstream_version"`
+	UpstreamHash    string     `json:"upstream_hash,omitempty" gorm:"type:varchar(64);column:upstream_hash" db:"upstream_hash"` // 派生时上游内容的哈希
+	Stale           bool       `json:"stale" gorm:"default:false;index;column:stale" db:"stale"`
+	StaleReason     string     `json:"stale_reason,omitempty" gorm:"type:text;column:stale_reason" db:"stale_reason"`
+	StaleAt         *time.Time `json:"stale_at,omitempty" gorm:"column:stale_at" db:"stale_at"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"autoCreateTime;column:created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" gorm:"autoUpdateTime;column:updated_at" db:"updated_at"`
+}
+
+// TableName 指定表名
+func (ArtifactDependency) TableName() string {
+	return "artifact_dependencies"
+}
@@ -19,7 +19,8 @@ type AsyncTask struct {
 	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime;column:created_at" db:"created_at"`
 	StartedAt    *time.Time `json:"started_at,omitempty" gorm:"column:started_at" db:"started_at"`
 	CompletedAt  *time.Time `json:"completed_at,omitempty" gorm:"column:completed_at" db:"completed_at"`
-	Metadata     string     `json:"metadata,omitempty" gorm:"type:json;column:metadata" db:"metadata"` // JSON格式的任务元数据
+	Metadata     string     `json:"metadata,omitempty" gorm:"type:json;column:metadata" db:"metadata"`              // JSON格式的任务元数据
+	TraceID      string     `json:"trace_id,omitempty" gorm:"type:varchar(32);index;column:trace_id" db:"trace_id"` // 创建任务时的链路ID
 }
 
 // TableName 指定表名
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// EARS检查报告来源类型
+const (
+	EARSSourceRequirementAnalysis = "requirement_analysis" // 需求分析的原始需求文本
+	EARSSourceRequirementsDoc     = "requirements_doc"     // Spec需求文档的功能需求
+)
+
+// EARSLintReport EARS需求语句检查报告
+type EARSLintReport struct {
+	ReportID     uuid.UUID `json:"report_id" gorm:"type:char(36);primaryKey;column:report_id" db:"report_id"`
+	ProjectID    uuid.UUID `json:"project_id" gorm:"type:char(36);not null;index;column:project_id" db:"project_id"`
+	SourceType   string    `json:"source_type" gorm:"type:varchar(30);not null;index:idx_ears_source;column:source_type" db:"source_type"`
+	SourceID     uuid.UUID `json:"source_id" gorm:"type:char(36);not null;index:idx_ears_source;column:source_id" db:"source_id"`
+	Total        int       `json:"total" gorm:"default:0;column:total" db:"total"`
+	Compliant    int       `json:"compliant" gorm:"default:0;column:compliant" db:"compliant"`
+	ErrorCount   int       `json:"error_count" gorm:"default:0;column:error_count" db:"error_count"`
+	WarningCount int       `json:"warning_count" gorm:"default:0;column:warning_count" db:"warning_count"`
+	ReportData   string    `json:"-" gorm:"type:longtext;column:report_data" db:"report_data"` // JSON，完整检查结果
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime;column:created_at" db:"created_at"`
+}
+
+// TableName 指定表名
+func (EARSLintReport) TableName() string {
+	return "ears_lint_reports"
+}
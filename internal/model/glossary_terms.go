@@ -0,0 +1,30 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// 术语来源
+const (
+	GlossarySourceManual    = "manual"
+	GlossarySourceExtracted = "extracted"
+)
+
+// GlossaryTerm 项目术语表中的规范术语
+type GlossaryTerm struct {
+	TermID     uuid.UUID `json:"term_id" gorm:"type:char(36);primaryKey;column:term_id" db:"term_id"`
+	ProjectID  uuid.UUID `json:"project_id" gorm:"type:char(36);not null;uniqueIndex:idx_glossary_term;column:project_id" db:"project_id"`
+	Term       string    `json:"term" gorm:"type:varchar(100);not null;uniqueIndex:idx_glossary_term;column:term" db:"term"`
+	Definition string    `json:"definition" gorm:"type:text;column:definition" db:"definition"`
+	Synonyms   string    `json:"synonyms" gorm:"type:json;column:synonyms" db:"synonyms"`                   // JSON字符串数组
+	Source     string    `json:"source" gorm:"type:varchar(20);default:'manual';column:source" db:"source"` // manual, extracted
+	CreatedBy  uuid.UUID `json:"created_by" gorm:"type:char(36);not null;column:created_by" db:"created_by"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime;column:created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"autoUpdateTime;column:updated_at" db:"updated_at"`
+}
+
+// TableName 指定表名
+func (GlossaryTerm) TableName() string {
+	return "glossary_terms"
+}
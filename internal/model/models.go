@@ -105,13 +105,39 @@ type AnswerQuestionRequest struct {
 	Answer     string    `json:"answer" validate:"required"`
 }
 
+// RefineRequirementRequest 根据补充问题回答重新分析需求的请求
+type RefineRequirementRequest struct {
+	Provider string `json:"provider,omitempty"`
+}
+
+// RefineRequirementResult 重新分析结果
+type RefineRequirementResult struct {
+	Requirement *Requirement           `json:"requirement"`
+	Refinement  *RequirementRefinement `json:"refinement"`
+}
+
+// EARSLintRequest EARS需求语句检查请求，Text 与 Sentences 至少提供一个
+type EARSLintRequest struct {
+	Text      string   `json:"text,omitempty"`      // 需求文本，按句拆分后检查
+	Sentences []string `json:"sentences,omitempty"` // 已拆分的需求语句
+}
+
+// GlossaryTermRequest 创建或更新项目术语请求
+type GlossaryTermRequest struct {
+	Term       string   `json:"term" validate:"required"`
+	Definition string   `json:"definition,omitempty"`
+	Synonyms   []string `json:"synonyms,omitempty"`
+	Source     string   `json:"source,omitempty"` // manual（默认）或 extracted
+}
+
 // ===== 第二阶段新增模型和请求类型 =====
 
 // AIAnalysisRequest AI分析请求
 type AIAnalysisRequest struct {
-	ProjectID   uuid.UUID `json:"project_id" validate:"required"`
-	Requirement string    `json:"requirement" validate:"required,min=10"`
-	Provider    string    `json:"provider,omitempty"`
+	ProjectID     uuid.UUID  `json:"project_id" validate:"required"`
+	Requirement   string     `json:"requirement" validate:"required,min=10"`
+	Provider      string     `json:"provider,omitempty"`
+	RequirementID *uuid.UUID `json:"requirement_id,omitempty"` // 指定时重新分析已有需求并保存为新版本
 }
 
 // GeneratePUMLRequest 生成PUML请求
@@ -119,6 +145,15 @@ type GeneratePUMLRequest struct {
 	AnalysisID  string `json:"analysis_id" validate:"required"`
 	DiagramType string `json:"diagram_type" validate:"required"`
 	Provider    string `json:"provider,omitempty"`
+	// DataModelStyle 数据模型图的写法：entity（默认，ER图）或 class（类图）
+	DataModelStyle string `json:"data_model_style,omitempty"`
+	// Syntax 生成的图表语法：plantuml（默认）或 mermaid
+	Syntax string `json:"syntax,omitempty"`
+}
+
+// SyncDataModelRequest 把编辑后的数据模型图同步回数据实体和数据库设计
+type SyncDataModelRequest struct {
+	Apply bool `json:"apply"` // false 时只返回解析与合并结果
 }
 
 // GenerateDocumentRequest 生成文档请求
@@ -127,6 +162,20 @@ type GenerateDocumentRequest struct {
 	Provider   string `json:"provider,omitempty"`
 }
 
+// DDLMigrationRequest 生成数据库迁移脚本请求
+type DDLMigrationRequest struct {
+	Dialect        string `json:"dialect,omitempty"`          // mysql（默认）、postgresql、sqlite
+	FromDocumentID string `json:"from_document_id,omitempty"` // 旧版本所在的开发文档，缺省为同一项目中上一份开发文档
+}
+
+// ImportSchemaRequest 导入现有数据库结构请求，SQL 为 mysqldump --no-data 或 pg_dump --schema-only 的输出
+type ImportSchemaRequest struct {
+	SQL            string `json:"sql" binding:"required"`
+	Dialect        string `json:"dialect,omitempty"`          // mysql、postgresql、sqlite，缺省时根据内容判断
+	AnalysisID     string `json:"analysis_id,omitempty"`      // 合并数据实体的需求分析，缺省为项目最新的需求分析
+	DataModelStyle string `json:"data_model_style,omitempty"` // 生成的数据模型图表示法：entity（默认）或 class
+}
+
 // ChatSessionCreateRequest 创建对话会话请求
 type ChatSessionCreateRequest struct {
 	ProjectID string `json:"project_id" validate:"required"`
@@ -145,6 +194,8 @@ type UpdatePUMLRequest struct {
 	Title       string `json:"title,omitempty"`
 	Content     string `json:"content" validate:"required"`
 	Description string `json:"description,omitempty"`
+	Note        string `json:"note,omitempty"`   // 版本说明
+	Syntax      string `json:"syntax,omitempty"` // plantuml 或 mermaid，默认沿用图表原来的语法
 }
 
 // UpdateDocumentRequest 更新文档请求
@@ -275,6 +326,7 @@ const (
 	TaskTypeDocumentGeneration       = "document_generation"
 	TaskTypeRequirementAnalysis      = "requirement_analysis"
 	TaskTypeCompleteProjectDocuments = "complete_project_documents" // 新增：一键生成完整项目文档
+	TaskTypeArtifactRegeneration     = "artifact_regeneration"      // 重新生成过期产物
 
 	// 阶段状态
 	StageStatusNotStarted = "not_started"
@@ -299,6 +351,7 @@ type AsyncTaskResponse struct {
 	Status   string    `json:"status"`
 	Progress int       `json:"progress"`
 	Message  string    `json:"message,omitempty"`
+	TraceID  string    `json:"trace_id,omitempty"`
 }
 
 // GetStageProgressRequest 获取阶段进度请求
@@ -706,6 +759,7 @@ type GenerateTasksRequest struct {
 	DesignID       uuid.UUID `json:"design_id" validate:"required"`
 	TeamSize       *int      `json:"team_size,omitempty"`
 	SprintDuration *int      `json:"sprint_duration,omitempty"`
+	PriorityMethod string    `json:"priority_method,omitempty"` // 用户故事排序方法：rice（默认）、wsjf、moscow
 }
 
 // UpdateSpecStageRequest 更新 Spec 阶段请求
@@ -714,6 +768,82 @@ type UpdateSpecStageRequest struct {
 	Stage     string    `json:"stage" validate:"required"`
 }
 
+// ImportRequirementsRequest 导入外部需求请求（Markdown、CSV、Jira/GitHub 导出 JSON）
+type ImportRequirementsRequest struct {
+	Format   string            `json:"format,omitempty"`    // auto（默认）、markdown、csv、jira、github
+	FileName string            `json:"file_name,omitempty"` // 用于按扩展名识别格式
+	Content  string            `json:"content" validate:"required"`
+	Mapping  map[string]string `json:"mapping,omitempty"`  // CSV 列映射：字段名 -> 列名
+	Title    string            `json:"title,omitempty"`    // 生成的需求文档标题
+	Selected []string          `json:"selected,omitempty"` // 只导入这些 title_hash 对应的条目，为空时导入全部非重复条目
+}
+
+// AnalyzeConsistencyRequest 需求一致性检测请求，阈值为0时使用默认值
+type AnalyzeConsistencyRequest struct {
+	UseAI              bool    `json:"use_ai"`             // 是否由AI对候选对进行分类
+	Provider           string  `json:"provider,omitempty"` // AI提供商，默认 openai
+	DuplicateThreshold float64 `json:"duplicate_threshold,omitempty"`
+	ConflictThreshold  float64 `json:"conflict_threshold,omitempty"`
+	CandidateThreshold float64 `json:"candidate_threshold,omitempty"`
+}
+
+// UpdateFindingRequest 处理需求检测结论请求
+type UpdateFindingRequest struct {
+	Status string `json:"status" validate:"required,oneof=open resolved ignored"`
+	Note   string `json:"note,omitempty"`
+}
+
+// ArtifactRef 依赖图中的产物引用
+type ArtifactRef struct {
+	Type string `json:"type" validate:"required"` // ArtifactType* 常量
+	ID   string `json:"id" validate:"required"`
+	Key  string `json:"key,omitempty"` // 数据实体名称
+}
+
+// ReportUpstreamChangeRequest 登记上游产物变更请求，用于系统无法自动感知的修改
+type ReportUpstreamChangeRequest struct {
+	Upstream ArtifactRef `json:"upstream" validate:"required"`
+	Reason   string      `json:"reason,omitempty"`
+}
+
+// RegenerateArtifactsRequest 重新生成过期产物请求
+type RegenerateArtifactsRequest struct {
+	Artifacts []ArtifactRef `json:"artifacts" validate:"required,min=1"`
+}
+
+// StoryPriorityRequest 设置用户故事优先级评估请求，整体替换已有的评估输入，未填写的项视为清空
+type StoryPriorityRequest struct {
+	MoSCoW          string   `json:"moscow,omitempty"` // must, should, could, wont
+	Reach           *float64 `json:"reach,omitempty"`
+	Impact          *float64 `json:"impact,omitempty"`
+	Confidence      *float64 `json:"confidence,omitempty"`
+	Effort          *float64 `json:"effort,omitempty"`
+	BusinessValue   *float64 `json:"business_value,omitempty"`
+	TimeCriticality *float64 `json:"time_criticality,omitempty"`
+	RiskReduction   *float64 `json:"risk_reduction,omitempty"`
+	JobSize         *float64 `json:"job_size,omitempty"`
+	Note            string   `json:"note,omitempty"` // 变更说明，记入历史
+}
+
+// SuggestPrioritiesRequest AI建议优先级评估请求
+// 默认只为尚未评估的用户故事生成建议；Overwrite 时同时覆盖此前由AI生成的评估，人工评估始终保留
+type SuggestPrioritiesRequest struct {
+	Provider  string `json:"provider,omitempty"` // AI提供商，默认 openai
+	Overwrite bool   `json:"overwrite"`
+}
+
+// SplitStoryRequest 拆分用户故事请求
+// workflow_steps 未提供 steps 时使用与故事最匹配的业务流程步骤，crud 未提供 entity 时使用故事中提到的数据实体
+type SplitStoryRequest struct {
+	Pattern    string   `json:"pattern" binding:"required"` // workflow_steps, business_rules, data_variations, crud
+	Steps      []string `json:"steps,omitempty"`
+	Variations []string `json:"variations,omitempty"`
+	Entity     string   `json:"entity,omitempty"`
+	UseAI      bool     `json:"use_ai"`             // 由AI按所选模式拆分，验收标准仍按规则校正
+	Provider   string   `json:"provider,omitempty"` // AI提供商，默认 openai
+	Preview    bool     `json:"preview"`            // 只返回拆分结果，不保存
+}
+
 // SpecResponse Spec 响应基础结构
 type SpecResponse struct {
 	Success bool   `json:"success"`
@@ -722,35 +852,54 @@ type SpecResponse struct {
 }
 
 type CreatePUMLRequest struct {
-	ProjectID string `json:"project_id" validate:"required"`
-	Title     string `json:"title" validate:"required,min=1,max=100"`
-	Content   string `json:"content" validate:"required"`
+	ProjectID   string `json:"project_id" validate:"required"`
+	Title       string `json:"title" validate:"required,min=1,max=100"`
+	Content     string `json:"content" validate:"required"`
+	DiagramType string `json:"diagram_type,omitempty"` // 默认 custom
+	Note        string `json:"note,omitempty"`         // 版本说明
+	Syntax      string `json:"syntax,omitempty"`       // plantuml 或 mermaid，默认由内容推断
+}
+
+// RestorePUMLVersionRequest 恢复PUML图表版本请求
+type RestorePUMLVersionRequest struct {
+	Note string `json:"note,omitempty"` // 版本说明，默认为「恢复自版本 N」
 }
 
 // RenderPUMLRequest 渲染PUML请求
 type RenderPUMLRequest struct {
 	Content string `json:"content" validate:"required"`
 	Format  string `json:"format,omitempty"` // png, svg, txt
+	Syntax  string `json:"syntax,omitempty"` // plantuml 或 mermaid，默认由内容推断
 }
 
 // GenerateImageRequest 生成图片请求
 type GenerateImageRequest struct {
 	Content string `json:"content" validate:"required"`
 	Format  string `json:"format,omitempty"` // png, svg
+	Syntax  string `json:"syntax,omitempty"` // plantuml 或 mermaid，默认由内容推断
 }
 
 // ValidatePUMLRequest 验证PUML请求
 type ValidatePUMLRequest struct {
 	Content string `json:"content" validate:"required"`
+	Syntax  string `json:"syntax,omitempty"` // plantuml 或 mermaid，默认由内容推断
 }
 
 // PreviewPUMLRequest 预览PUML请求
 type PreviewPUMLRequest struct {
 	Content string `json:"content" validate:"required"`
+	Syntax  string `json:"syntax,omitempty"` // plantuml 或 mermaid，默认由内容推断
+}
+
+// ConvertPUMLRequest 在 PlantUML 与 Mermaid 之间转换图表请求
+type ConvertPUMLRequest struct {
+	Content string `json:"content" validate:"required"`
+	From    string `json:"from,omitempty"` // 默认由内容推断
+	To      string `json:"to" validate:"required"`
 }
 
 // ExportPUMLRequest 导出PUML请求
 type ExportPUMLRequest struct {
 	PUMLIDs []string `json:"puml_ids" validate:"required"`
-	Format  string   `json:"format" validate:"required"`
+	Format  string   `json:"format" validate:"required"` // zip, markdown, html, mermaid, drawio
 }
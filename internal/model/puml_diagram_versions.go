@@ -0,0 +1,26 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// PUMLDiagramVersion PUML图表的版本快照，每次创建、更新或恢复图表都会生成一个新版本，生成后不再修改
+type PUMLDiagramVersion struct {
+	VersionID          uuid.UUID `json:"version_id" gorm:"type:char(36);primaryKey;column:version_id" db:"version_id"`
+	DiagramID          uuid.UUID `json:"diagram_id" gorm:"type:char(36);not null;uniqueIndex:idx_puml_diagram_version;column:diagram_id" db:"diagram_id"`
+	Version            int       `json:"version" gorm:"not null;uniqueIndex:idx_puml_diagram_version;column:version" db:"version"` // 与图表的 version 一致
+	DiagramName        string    `json:"diagram_name" gorm:"type:varchar(200);not null;column:diagram_name" db:"diagram_name"`
+	PUMLContent        string    `json:"puml_content" gorm:"type:text;not null;column:puml_content" db:"puml_content"`
+	Syntax             string    `json:"syntax" gorm:"type:varchar(20);default:'plantuml';column:syntax" db:"syntax"`
+	IsValidated        bool      `json:"is_validated" gorm:"default:false;column:is_validated" db:"is_validated"`
+	ValidationFeedback string    `json:"validation_feedback" gorm:"type:text;column:validation_feedback" db:"validation_feedback"`
+	AuthorID           uuid.UUID `json:"author_id" gorm:"type:char(36);column:author_id" db:"author_id"` // 版本功能上线前的内容补存时为空
+	Note               string    `json:"note" gorm:"type:varchar(500);column:note" db:"note"`
+	CreatedAt          time.Time `json:"created_at" gorm:"autoCreateTime;column:created_at" db:"created_at"`
+}
+
+// TableName 指定表名
+func (PUMLDiagramVersion) TableName() string {
+	return "puml_diagram_versions"
+}
@@ -12,6 +12,7 @@ type PUMLDiagram struct {
 	DiagramType        string     `json:"diagram_type" gorm:"type:varchar(50);not null;column:diagram_type" db:"diagram_type"` // business_flow, architecture, data_model
 	DiagramName        string     `json:"diagram_name" gorm:"type:varchar(200);not null;column:diagram_name" db:"diagram_name"`
 	PUMLContent        string     `json:"puml_content" gorm:"type:text;not null;column:puml_content" db:"puml_content"`
+	Syntax             string     `json:"syntax" gorm:"type:varchar(20);default:'plantuml';column:syntax" db:"syntax"` // plantuml, mermaid
 	RenderedURL        string     `json:"rendered_url" gorm:"type:varchar(255);column:rendered_url" db:"rendered_url"`
 	Version            int        `json:"version" gorm:"default:1;column:version" db:"version"`
 	Stage              int        `json:"stage" gorm:"default:1;column:stage" db:"stage"`                     // 新增：所属阶段 1,2,3
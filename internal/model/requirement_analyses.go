@@ -12,8 +12,10 @@ type Requirement struct {
 	RawRequirement        string    `json:"raw_requirement" gorm:"type:text;not null;column:raw_requirement" db:"raw_requirement"`
 	StructuredRequirement string    `json:"structured_requirement" gorm:"type:json;column:structured_requirement" db:"structured_requirement"` // JSON
 	CompletenessScore     float64   `json:"completeness_score" gorm:"type:decimal(5,2);default:0;column:completeness_score" db:"completeness_score"`
+	RuleScore             float64   `json:"rule_score" gorm:"type:decimal(5,2);default:0;column:rule_score" db:"rule_score"` // 规则完整度评分，见 ai.ScoreCompleteness
 	AnalysisStatus        string    `json:"analysis_status" gorm:"type:varchar(50);default:'pending';column:analysis_status" db:"analysis_status"`
 	MissingInfoTypes      string    `json:"missing_info_types" gorm:"type:json;column:missing_info_types" db:"missing_info_types"` // JSON
+	CurrentVersion        int       `json:"current_version" gorm:"default:0;column:current_version" db:"current_version"`          // 当前版本号，见 RequirementVersion
 	CreatedAt             time.Time `json:"created_at" gorm:"autoCreateTime;column:created_at" db:"created_at"`
 	UpdatedAt             time.Time `json:"updated_at" gorm:"autoUpdateTime;column:updated_at" db:"updated_at"`
 }
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// 需求一致性检测结论的处理状态
+const (
+	FindingStatusOpen     = "open"
+	FindingStatusResolved = "resolved"
+	FindingStatusIgnored  = "ignored"
+)
+
+// RequirementFinding 功能需求和用户故事之间的重复或冲突检测结论
+// Ref 格式为 requirements_doc:<需求文档ID>#<功能需求下标> 或 user_story:<用户故事ID>
+type RequirementFinding struct {
+	FindingID      uuid.UUID  `json:"finding_id" gorm:"type:char(36);primaryKey;column:finding_id" db:"finding_id"`
+	ProjectID      uuid.UUID  `json:"project_id" gorm:"type:char(36);not null;uniqueIndex:idx_finding_fingerprint;index:idx_finding_status;column:project_id" db:"project_id"`
+	Fingerprint    string     `json:"fingerprint" gorm:"type:varchar(32);not null;uniqueIndex:idx_finding_fingerprint;column:fingerprint" db:"fingerprint"` // 与顺序无关的需求对标识
+	Kind           string     `json:"kind" gorm:"type:varchar(20);not null;column:kind" db:"kind"`                                                          // duplicate, conflict, refinement
+	Method         string     `json:"method" gorm:"type:varchar(20);not null;column:method" db:"method"`                                                    // lexical, ai
+	Status         string     `json:"status" gorm:"type:varchar(20);default:'open';index:idx_finding_status;column:status" db:"status"`
+	Similarity     float64    `json:"similarity" gorm:"type:decimal(5,3);default:0;column:similarity" db:"similarity"`
+	RefA           string     `json:"ref_a" gorm:"type:varchar(120);not null;column:ref_a" db:"ref_a"`
+	TextA          string     `json:"text_a" gorm:"type:text;column:text_a" db:"text_a"`
+	RefB           string     `json:"ref_b" gorm:"type:varchar(120);not null;column:ref_b" db:"ref_b"`
+	TextB          string     `json:"text_b" gorm:"type:text;column:text_b" db:"text_b"`
+	Reason         string     `json:"reason" gorm:"type:text;column:reason" db:"reason"`
+	ResolutionNote string     `json:"resolution_note" gorm:"type:text;column:resolution_note" db:"resolution_note"`
+	ResolvedBy     *uuid.UUID `json:"resolved_by,omitempty" gorm:"type:char(36);column:resolved_by" db:"resolved_by"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty" gorm:"column:resolved_at" db:"resolved_at"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime;column:created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime;column:updated_at" db:"updated_at"`
+}
+
+// TableName 指定表名
+func (RequirementFinding) TableName() string {
+	return "requirement_findings"
+}
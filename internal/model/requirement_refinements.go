@@ -0,0 +1,30 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// RequirementRefinement 需求澄清（根据问答再次分析）的历史记录
+type RequirementRefinement struct {
+	RefinementID      uuid.UUID `json:"refinement_id" gorm:"type:char(36);primaryKey;column:refinement_id" db:"refinement_id"`
+	RequirementID     uuid.UUID `json:"requirement_id" gorm:"type:char(36);not null;index;column:requirement_id" db:"requirement_id"`
+	Round             int       `json:"round" gorm:"not null;column:round" db:"round"` // 第几轮澄清，从1开始
+	Provider          string    `json:"provider" gorm:"type:varchar(20);column:provider" db:"provider"`
+	AnsweredCount     int       `json:"answered_count" gorm:"default:0;column:answered_count" db:"answered_count"`
+	SkippedCount      int       `json:"skipped_count" gorm:"default:0;column:skipped_count" db:"skipped_count"`
+	QAPairs           string    `json:"qa_pairs" gorm:"type:json;column:qa_pairs" db:"qa_pairs"` // JSON，本轮使用的问答
+	ScoreBefore       float64   `json:"score_before" gorm:"type:decimal(5,2);default:0;column:score_before" db:"score_before"`
+	ScoreAfter        float64   `json:"score_after" gorm:"type:decimal(5,2);default:0;column:score_after" db:"score_after"`
+	MissingInfoBefore string    `json:"missing_info_before" gorm:"type:json;column:missing_info_before" db:"missing_info_before"` // JSON
+	MissingInfoAfter  string    `json:"missing_info_after" gorm:"type:json;column:missing_info_after" db:"missing_info_after"`    // JSON
+	AddedItems        string    `json:"added_items" gorm:"type:json;column:added_items" db:"added_items"`                         // JSON，本轮合并新增的功能、角色、流程和实体
+	Version           int       `json:"version" gorm:"default:0;column:version" db:"version"`                                     // 本轮澄清生成的需求版本号
+	CreatedBy         uuid.UUID `json:"created_by" gorm:"type:char(36);column:created_by" db:"created_by"`
+	CreatedAt         time.Time `json:"created_at" gorm:"autoCreateTime;column:created_at" db:"created_at"`
+}
+
+// TableName 指定表名
+func (RequirementRefinement) TableName() string {
+	return "requirement_refinements"
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// RequirementVersion 需求分析的版本快照，每次创建或更新分析都会生成一个新版本
+type RequirementVersion struct {
+	VersionID             uuid.UUID `json:"version_id" gorm:"type:char(36);primaryKey;column:version_id" db:"version_id"`
+	RequirementID         uuid.UUID `json:"requirement_id" gorm:"type:char(36);not null;uniqueIndex:idx_requirement_version;column:requirement_id" db:"requirement_id"`
+	Version               int       `json:"version" gorm:"not null;uniqueIndex:idx_requirement_version;column:version" db:"version"` // 版本号，从1开始
+	RawRequirement        string    `json:"raw_requirement" gorm:"type:text;column:raw_requirement" db:"raw_requirement"`
+	StructuredRequirement string    `json:"structured_requirement" gorm:"type:json;column:structured_requirement" db:"structured_requirement"` // JSON
+	CompletenessScore     float64   `json:"completeness_score" gorm:"type:decimal(5,2);default:0;column:completeness_score" db:"completeness_score"`
+	RuleScore             float64   `json:"rule_score" gorm:"type:decimal(5,2);default:0;column:rule_score" db:"rule_score"`       // 规则完整度评分，见 ai.ScoreCompleteness
+	MissingInfoTypes      string    `json:"missing_info_types" gorm:"type:json;column:missing_info_types" db:"missing_info_types"` // JSON
+	CreatedAt             time.Time `json:"created_at" gorm:"autoCreateTime;column:created_at" db:"created_at"`
+}
+
+// TableName 指定表名
+func (RequirementVersion) TableName() string {
+	return "requirement_versions"
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// 优先级评估来源
+const (
+	PrioritySourceManual = "manual"
+	PrioritySourceAI     = "ai"
+)
+
+// StoryPriority 用户故事的优先级评估输入及计算出的 RICE、WSJF 分数，未填写的输入为 NULL
+type StoryPriority struct {
+	StoryID         uuid.UUID `json:"story_id" gorm:"type:char(36);primaryKey;column:story_id" db:"story_id"`
+	ProjectID       uuid.UUID `json:"project_id" gorm:"type:char(36);not null;index;column:project_id" db:"project_id"`
+	MoSCoW          string    `json:"moscow" gorm:"type:varchar(10);column:moscow" db:"moscow"` // must, should, could, wont
+	Reach           *float64  `json:"reach" gorm:"type:decimal(12,2);column:reach" db:"reach"`
+	Impact          *float64  `json:"impact" gorm:"type:decimal(6,2);column:impact" db:"impact"`
+	Confidence      *float64  `json:"confidence" gorm:"type:decimal(4,2);column:confidence" db:"confidence"`
+	Effort          *float64  `json:"effort" gorm:"type:decimal(8,2);column:effort" db:"effort"`
+	BusinessValue   *float64  `json:"business_value" gorm:"type:decimal(8,2);column:business_value" db:"business_value"`
+	TimeCriticality *float64  `json:"time_criticality" gorm:"type:decimal(8,2);column:time_criticality" db:"time_criticality"`
+	RiskReduction   *float64  `json:"risk_reduction" gorm:"type:decimal(8,2);column:risk_reduction" db:"risk_reduction"`
+	JobSize         *float64  `json:"job_size" gorm:"type:decimal(8,2);column:job_size" db:"job_size"`
+	RICEScore       *float64  `json:"rice_score" gorm:"type:decimal(14,2);column:rice_score" db:"rice_score"`
+	WSJFScore       *float64  `json:"wsjf_score" gorm:"type:decimal(10,2);column:wsjf_score" db:"wsjf_score"`
+	Source          string    `json:"source" gorm:"type:varchar(20);default:'manual';column:source" db:"source"` // manual, ai
+	Rationale       string    `json:"rationale" gorm:"type:text;column:rationale" db:"rationale"`                // AI给出的评估依据
+	UpdatedBy       uuid.UUID `json:"updated_by" gorm:"type:char(36);not null;column:updated_by" db:"updated_by"`
+	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime;column:created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" gorm:"autoUpdateTime;column:updated_at" db:"updated_at"`
+}
+
+// TableName 指定表名
+func (StoryPriority) TableName() string {
+	return "story_priorities"
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// StoryPriorityChange 用户故事优先级评估的一次变更记录
+type StoryPriorityChange struct {
+	ChangeID  uuid.UUID `json:"change_id" gorm:"type:char(36);primaryKey;column:change_id" db:"change_id"`
+	StoryID   uuid.UUID `json:"story_id" gorm:"type:char(36);not null;index;column:story_id" db:"story_id"`
+	ProjectID uuid.UUID `json:"project_id" gorm:"type:char(36);not null;index;column:project_id" db:"project_id"`
+	Source    string    `json:"source" gorm:"type:varchar(20);not null;column:source" db:"source"`      // manual, ai
+	Changes   string    `json:"changes" gorm:"type:json;column:changes" db:"changes"`                   // JSON数组，每项为 {field, from, to}
+	RICEScore *float64  `json:"rice_score" gorm:"type:decimal(14,2);column:rice_score" db:"rice_score"` // 变更后的分数
+	WSJFScore *float64  `json:"wsjf_score" gorm:"type:decimal(10,2);column:wsjf_score" db:"wsjf_score"`
+	Note      string    `json:"note" gorm:"type:text;column:note" db:"note"`
+	ChangedBy uuid.UUID `json:"changed_by" gorm:"type:char(36);not null;column:changed_by" db:"changed_by"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime;column:created_at" db:"created_at"`
+}
+
+// TableName 指定表名
+func (StoryPriorityChange) TableName() string {
+	return "story_priority_changes"
+}
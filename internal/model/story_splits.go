@@ -0,0 +1,22 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// StorySplit 用户故事拆分关系，记录子故事来自哪个父故事以及使用的拆分模式
+type StorySplit struct {
+	ChildStoryID  uuid.UUID `json:"child_story_id" gorm:"type:char(36);primaryKey;column:child_story_id" db:"child_story_id"`
+	ParentStoryID uuid.UUID `json:"parent_story_id" gorm:"type:char(36);not null;index;column:parent_story_id" db:"parent_story_id"`
+	ProjectID     uuid.UUID `json:"project_id" gorm:"type:char(36);not null;index;column:project_id" db:"project_id"`
+	Pattern       string    `json:"pattern" gorm:"type:varchar(30);not null;column:pattern" db:"pattern"` // workflow_steps, business_rules, data_variations, crud
+	Position      int       `json:"position" gorm:"not null;default:0;column:position" db:"position"`     // 在父故事的子故事中的顺序
+	CreatedBy     uuid.UUID `json:"created_by" gorm:"type:char(36);not null;column:created_by" db:"created_by"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime;column:created_at" db:"created_at"`
+}
+
+// TableName 指定表名
+func (StorySplit) TableName() string {
+	return "story_splits"
+}
@@ -0,0 +1,166 @@
+package plantuml
+
+import "strings"
+
+// DiagramType 图表类型
+type DiagramType string
+
+const (
+	DiagramSequence  DiagramType = "sequence"
+	DiagramActivity  DiagramType = "activity"
+	DiagramClass     DiagramType = "class"
+	DiagramComponent DiagramType = "component"
+	DiagramUseCase   DiagramType = "usecase"
+	DiagramEntity    DiagramType = "entity" // ER 图：entity 声明或使用鸦脚关系的表
+	DiagramUnknown   DiagramType = "unknown"
+)
+
+// Severity 诊断级别
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// 诊断代码
+const (
+	CodeMissingStart         = "missing_start"
+	CodeMissingEnd           = "missing_end"
+	CodeDuplicateMarker      = "duplicate_marker"
+	CodeOutsideDiagram       = "outside_diagram"
+	CodeUnclosedBlock        = "unclosed_block"
+	CodeUnmatchedEnd         = "unmatched_end"
+	CodeUnknownKeyword       = "unknown_keyword"
+	CodeSyntaxError          = "syntax_error"
+	CodeDuplicateAlias       = "duplicate_alias"
+	CodeDuplicateElement     = "duplicate_element"
+	CodeUndefinedParticipant = "undefined_participant"
+	CodeUndefinedElement     = "undefined_element"
+	CodeUnterminatedAction   = "unterminated_action"
+	CodeUnsupportedDiagram   = "unsupported_diagram" // Mermaid 中暂不检查语法的图表类型
+)
+
+// Position 源码位置，行和列都从 1 开始，列按字符（rune）计数
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Diagnostic 一条诊断，EndColumn 为问题片段之后的列，便于编辑器标注下划线
+type Diagnostic struct {
+	Severity  Severity `json:"severity"`
+	Code      string   `json:"code"`
+	Message   string   `json:"message"`
+	Line      int      `json:"line"`
+	Column    int      `json:"column"`
+	EndColumn int      `json:"end_column"`
+}
+
+// Member 类或实体的成员（属性、方法或字段）
+type Member struct {
+	Text       string   `json:"text"` // 原始文本
+	Name       string   `json:"name"`
+	Type       string   `json:"type,omitempty"`
+	Visibility string   `json:"visibility,omitempty"` // + - # ~
+	Method     bool     `json:"method,omitempty"`
+	PrimaryKey bool     `json:"primary_key,omitempty"`
+	ForeignKey bool     `json:"foreign_key,omitempty"`
+	Pos        Position `json:"pos"`
+}
+
+// Element 图表元素：参与者、类、实体、组件、用例、容器等
+type Element struct {
+	Kind       string    `json:"kind"` // participant, actor, class, interface, enum, entity, component, usecase, package ...
+	Name       string    `json:"name"`
+	Alias      string    `json:"alias,omitempty"`
+	Stereotype string    `json:"stereotype,omitempty"`
+	Parent     string    `json:"parent,omitempty"` // 所在容器的名称
+	Members    []*Member `json:"members,omitempty"`
+	Implicit   bool      `json:"implicit,omitempty"` // 未显式声明，由关系中的引用隐式创建
+	Pos        Position  `json:"pos"`
+}
+
+// ID 元素在关系中被引用的标识，有别名时为别名
+func (e *Element) ID() string {
+	if e.Alias != "" {
+		return e.Alias
+	}
+	return e.Name
+}
+
+// Relation 元素之间的关系，序列图中为消息
+type Relation struct {
+	From            string   `json:"from"`
+	To              string   `json:"to"`
+	Arrow           string   `json:"arrow"`
+	Label           string   `json:"label,omitempty"`
+	FromCardinality string   `json:"from_cardinality,omitempty"`
+	ToCardinality   string   `json:"to_cardinality,omitempty"`
+	Pos             Position `json:"pos"`
+}
+
+// ActivityNode 活动图中的一个节点，按源码顺序排列
+type ActivityNode struct {
+	Kind  string   `json:"kind"`            // start, stop, end, action, if, elseif, else, endif, while, endwhile, repeat, repeat_while, fork, fork_again, end_fork, split, split_again, end_split, switch, case, endswitch, partition, swimlane, arrow, detach, kill, break
+	Text  string   `json:"text,omitempty"`  // 活动内容或条件
+	Label string   `json:"label,omitempty"` // 分支标签，如 then (是)、else (否)、is (是)
+	Depth int      `json:"depth"`           // 嵌套层级
+	Pos   Position `json:"pos"`
+}
+
+// SequenceStep 序列图中的一步，按源码顺序排列，保留消息与分组的嵌套关系
+type SequenceStep struct {
+	Kind     string    `json:"kind"`              // message, group, else, end, activate, deactivate, destroy, note, divider, delay, autonumber, return, box, end_box
+	Keyword  string    `json:"keyword,omitempty"` // 分组关键字：alt、opt、loop、par、break、critical、group
+	Text     string    `json:"text,omitempty"`
+	Target   string    `json:"target,omitempty"` // activate 等语句的参与者；note 的位置，如 left of A、over A, B
+	Relation *Relation `json:"relation,omitempty"`
+	Pos      Position  `json:"pos"`
+}
+
+// Document 解析结果
+type Document struct {
+	Type        DiagramType     `json:"type"`
+	Title       string          `json:"title,omitempty"`
+	Elements    []*Element      `json:"elements"`
+	Relations   []*Relation     `json:"relations"`
+	Activity    []*ActivityNode `json:"activity,omitempty"`
+	Sequence    []*SequenceStep `json:"sequence,omitempty"`
+	Diagnostics []Diagnostic    `json:"diagnostics"`
+}
+
+// Element 按名称或别名查找元素，忽略引号
+func (d *Document) Element(ref string) *Element {
+	ref = unquote(ref)
+	for _, e := range d.Elements {
+		if e.Alias != "" && e.Alias == ref {
+			return e
+		}
+	}
+	for _, e := range d.Elements {
+		if e.Name == ref {
+			return e
+		}
+	}
+	return nil
+}
+
+// HasErrors 是否有错误级别的诊断
+func (d *Document) HasErrors() bool {
+	for _, diag := range d.Diagnostics {
+		if diag.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
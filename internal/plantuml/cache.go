@@ -0,0 +1,344 @@
+package plantuml
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 默认缓存容量
+const (
+	DefaultCacheEntries = 500
+	DefaultCacheBytes   = 64 << 20
+)
+
+// CacheConfig 渲染缓存配置
+type CacheConfig struct {
+	MaxEntries   int    // 内存中最多缓存的条目数
+	MaxBytes     int64  // 内存中缓存内容的总字节数上限
+	Dir          string // 磁盘缓存目录，为空时只使用内存缓存
+	MaxDiskBytes int64  // 磁盘缓存的总字节数上限，0 表示不限制
+}
+
+// CacheEntry 一次渲染的结果
+type CacheEntry struct {
+	Data       []byte
+	Format     string
+	RenderedAt time.Time
+}
+
+// CacheStats 缓存统计
+type CacheStats struct {
+	Entries     int    `json:"entries"`
+	Bytes       int64  `json:"bytes"`
+	MaxEntries  int    `json:"max_entries"`
+	MaxBytes    int64  `json:"max_bytes"`
+	Hits        int64  `json:"hits"`
+	Misses      int64  `json:"misses"`
+	Evictions   int64  `json:"evictions"`
+	Disk        bool   `json:"disk"`
+	DiskDir     string `json:"disk_dir,omitempty"`
+	DiskEntries int    `json:"disk_entries"`
+	DiskBytes   int64  `json:"disk_bytes"`
+	DiskHits    int64  `json:"disk_hits"` // 内存未命中、从磁盘读取的次数，也计入 Hits
+	DiskErrors  int64  `json:"disk_errors"`
+}
+
+// CacheKey 根据图表源码和渲染参数计算缓存键（SHA-256 十六进制）
+func CacheKey(source, format string, dpi int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d", source, format, dpi)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Cache 并发安全的渲染缓存：内存中按 LRU 淘汰，配置了目录时同时按缓存键保存到磁盘，重启后仍可命中
+type Cache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	items      map[string]*list.Element
+	lru        *list.List // 队首为最近使用
+	bytes      int64
+
+	dir          string
+	maxDiskBytes int64
+	disk         map[string]*diskFile
+	diskBytes    int64
+
+	hits, misses, evictions, diskHits, diskErrors int64
+}
+
+type memoryItem struct {
+	key   string
+	entry CacheEntry
+}
+
+type diskFile struct {
+	path    string
+	format  string
+	size    int64
+	modTime time.Time
+}
+
+// NewCache 创建渲染缓存；配置了磁盘目录时创建目录并加载已有的缓存文件
+func NewCache(cfg CacheConfig) (*Cache, error) {
+	c := &Cache{
+		maxEntries:   cfg.MaxEntries,
+		maxBytes:     cfg.MaxBytes,
+		items:        make(map[string]*list.Element),
+		lru:          list.New(),
+		dir:          cfg.Dir,
+		maxDiskBytes: cfg.MaxDiskBytes,
+		disk:         make(map[string]*diskFile),
+	}
+	if c.maxEntries <= 0 {
+		c.maxEntries = DefaultCacheEntries
+	}
+	if c.maxBytes <= 0 {
+		c.maxBytes = DefaultCacheBytes
+	}
+	if c.dir == "" {
+		return c, nil
+	}
+
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建渲染缓存目录失败: %w", err)
+	}
+	err := filepath.Walk(c.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if strings.HasSuffix(info.Name(), ".tmp") {
+			// 上次退出时未完成的写入
+			os.Remove(path)
+			return nil
+		}
+		key, format := splitCacheFile(info.Name())
+		if key == "" {
+			return nil
+		}
+		c.disk[key] = &diskFile{path: path, format: format, size: info.Size(), modTime: info.ModTime()}
+		c.diskBytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("加载渲染缓存目录失败: %w", err)
+	}
+	c.mu.Lock()
+	c.trimDisk()
+	c.mu.Unlock()
+	return c, nil
+}
+
+// Get 查找缓存，内存未命中时从磁盘读取并放回内存；返回的 Data 与缓存共享，调用方不能修改
+func (c *Cache) Get(key string) (CacheEntry, bool) {
+	c.mu.Lock()
+	if elem, ok := c.items[key]; ok {
+		c.lru.MoveToFront(elem)
+		c.hits++
+		entry := elem.Value.(*memoryItem).entry
+		c.mu.Unlock()
+		return entry, true
+	}
+	file := c.disk[key]
+	if file == nil {
+		c.misses++
+		c.mu.Unlock()
+		return CacheEntry{}, false
+	}
+	path, format, modTime := file.path, file.format, file.modTime
+	c.mu.Unlock()
+
+	data, err := os.ReadFile(path)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		c.diskErrors++
+		c.misses++
+		c.forgetDisk(key)
+		return CacheEntry{}, false
+	}
+	now := time.Now()
+	if current := c.disk[key]; current != nil {
+		current.modTime = now
+	}
+	_ = os.Chtimes(path, now, now)
+	entry := CacheEntry{Data: data, Format: format, RenderedAt: modTime}
+	c.store(key, entry)
+	c.hits++
+	c.diskHits++
+	return entry, true
+}
+
+// Put 写入缓存；配置了磁盘目录时同时写入磁盘，写入失败只计入统计，不影响内存缓存
+func (c *Cache) Put(key string, entry CacheEntry) {
+	c.mu.Lock()
+	c.store(key, entry)
+	_, onDisk := c.disk[key]
+	c.mu.Unlock()
+
+	if c.dir == "" || onDisk || len(key) < 2 || !ValidFormat(entry.Format) {
+		return
+	}
+	path, err := c.write(key, entry)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		c.diskErrors++
+		return
+	}
+	if old := c.disk[key]; old != nil {
+		c.diskBytes -= old.size
+	}
+	c.disk[key] = &diskFile{path: path, format: entry.Format, size: int64(len(entry.Data)), modTime: time.Now()}
+	c.diskBytes += int64(len(entry.Data))
+	c.trimDisk()
+}
+
+// Clear 清空内存和磁盘缓存，统计计数保留
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
+	for key := range c.disk {
+		c.removeDisk(key)
+	}
+}
+
+// Len 内存中的条目数
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Stats 返回缓存统计
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Entries:     c.lru.Len(),
+		Bytes:       c.bytes,
+		MaxEntries:  c.maxEntries,
+		MaxBytes:    c.maxBytes,
+		Hits:        c.hits,
+		Misses:      c.misses,
+		Evictions:   c.evictions,
+		Disk:        c.dir != "",
+		DiskDir:     c.dir,
+		DiskEntries: len(c.disk),
+		DiskBytes:   c.diskBytes,
+		DiskHits:    c.diskHits,
+		DiskErrors:  c.diskErrors,
+	}
+}
+
+// store 写入内存并淘汰最久未使用的条目，调用方持有锁；超过内存上限的单个结果不放入内存
+func (c *Cache) store(key string, entry CacheEntry) {
+	size := int64(len(entry.Data))
+	if elem, ok := c.items[key]; ok {
+		item := elem.Value.(*memoryItem)
+		c.bytes += size - int64(len(item.entry.Data))
+		item.entry = entry
+		c.lru.MoveToFront(elem)
+	} else if size <= c.maxBytes {
+		c.items[key] = c.lru.PushFront(&memoryItem{key: key, entry: entry})
+		c.bytes += size
+	}
+
+	for c.lru.Len() > c.maxEntries || c.bytes > c.maxBytes {
+		oldest := c.lru.Back()
+		if oldest == nil {
+			break
+		}
+		item := c.lru.Remove(oldest).(*memoryItem)
+		delete(c.items, item.key)
+		c.bytes -= int64(len(item.entry.Data))
+		c.evictions++
+	}
+}
+
+// write 先写临时文件再重命名，避免并发读取到不完整的内容
+func (c *Cache) write(key string, entry CacheEntry) (string, error) {
+	dir := filepath.Join(c.dir, key[:2])
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(dir, key+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(entry.Data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	path := filepath.Join(dir, key+"."+entry.Format)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return path, nil
+}
+
+// trimDisk 磁盘缓存超过上限时删除最久未使用的文件，调用方持有锁
+func (c *Cache) trimDisk() {
+	if c.maxDiskBytes <= 0 || c.diskBytes <= c.maxDiskBytes {
+		return
+	}
+	keys := make([]string, 0, len(c.disk))
+	for key := range c.disk {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return c.disk[keys[i]].modTime.Before(c.disk[keys[j]].modTime) })
+	for _, key := range keys {
+		if c.diskBytes <= c.maxDiskBytes {
+			break
+		}
+		c.removeDisk(key)
+		c.evictions++
+	}
+}
+
+func (c *Cache) removeDisk(key string) {
+	if file := c.disk[key]; file != nil {
+		if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+			c.diskErrors++
+		}
+	}
+	c.forgetDisk(key)
+}
+
+func (c *Cache) forgetDisk(key string) {
+	if file := c.disk[key]; file != nil {
+		c.diskBytes -= file.size
+		delete(c.disk, key)
+	}
+}
+
+// splitCacheFile 从缓存文件名 <key>.<format> 中取出缓存键和格式，不是缓存文件时返回空
+func splitCacheFile(name string) (string, string) {
+	key, format, ok := strings.Cut(name, ".")
+	if !ok || len(key) != sha256.Size*2 || !ValidFormat(format) {
+		return "", ""
+	}
+	if _, err := hex.DecodeString(key); err != nil {
+		return "", ""
+	}
+	return key, format
+}
@@ -0,0 +1,150 @@
+package plantuml
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func entry(data string) CacheEntry {
+	return CacheEntry{Data: []byte(data), Format: FormatSVG, RenderedAt: time.Now()}
+}
+
+func TestCacheKey(t *testing.T) {
+	key := CacheKey("@startuml\nA -> B\n@enduml", FormatPNG, 0)
+	assert.Len(t, key, 64)
+	assert.Equal(t, key, CacheKey("@startuml\nA -> B\n@enduml", FormatPNG, 0))
+	assert.NotEqual(t, key, CacheKey("@startuml\nA -> B\n@enduml", FormatSVG, 0))
+	assert.NotEqual(t, key, CacheKey("@startuml\nA -> B\n@enduml", FormatPNG, 300))
+}
+
+func TestCache_EvictsLeastRecentlyUsedByEntries(t *testing.T) {
+	cache, err := NewCache(CacheConfig{MaxEntries: 2})
+	require.NoError(t, err)
+
+	cache.Put("a", entry("1"))
+	cache.Put("b", entry("2"))
+	_, ok := cache.Get("a")
+	require.True(t, ok)
+	cache.Put("c", entry("3"))
+
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+	got, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", string(got.Data))
+
+	stats := cache.Stats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, int64(2), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, int64(1), stats.Evictions)
+}
+
+func TestCache_EvictsByBytes(t *testing.T) {
+	cache, err := NewCache(CacheConfig{MaxEntries: 10, MaxBytes: 10})
+	require.NoError(t, err)
+
+	cache.Put("a", entry("12345"))
+	cache.Put("b", entry("12345"))
+	cache.Put("c", entry("123"))
+	assert.Equal(t, 2, cache.Len())
+	assert.Equal(t, int64(8), cache.Stats().Bytes)
+
+	// 超过内存上限的单个结果不放入内存
+	cache.Put("big", entry("12345678901"))
+	_, ok := cache.Get("big")
+	assert.False(t, ok)
+	assert.Equal(t, 2, cache.Len())
+
+	// 更新已有条目时按新大小计算
+	cache.Put("c", entry("1234567"))
+	assert.Equal(t, 1, cache.Len())
+	assert.Equal(t, int64(7), cache.Stats().Bytes)
+}
+
+func TestCache_DiskPersistence(t *testing.T) {
+	dir := t.TempDir()
+	key := CacheKey("@startuml\nA -> B\n@enduml", FormatSVG, 0)
+
+	cache, err := NewCache(CacheConfig{Dir: dir})
+	require.NoError(t, err)
+	cache.Put(key, entry("<svg/>"))
+	assert.FileExists(t, filepath.Join(dir, key[:2], key+".svg"))
+
+	// 未完成的写入和无关文件在加载时被忽略
+	require.NoError(t, os.WriteFile(filepath.Join(dir, key[:2], key+".123.tmp"), []byte("partial"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("x"), 0o644))
+
+	restarted, err := NewCache(CacheConfig{Dir: dir})
+	require.NoError(t, err)
+	assert.Equal(t, 1, restarted.Stats().DiskEntries)
+	assert.NoFileExists(t, filepath.Join(dir, key[:2], key+".123.tmp"))
+
+	got, ok := restarted.Get(key)
+	require.True(t, ok)
+	assert.Equal(t, "<svg/>", string(got.Data))
+	assert.Equal(t, FormatSVG, got.Format)
+	stats := restarted.Stats()
+	assert.Equal(t, int64(1), stats.DiskHits)
+	assert.Equal(t, 1, stats.Entries)
+
+	restarted.Clear()
+	assert.NoFileExists(t, filepath.Join(dir, key[:2], key+".svg"))
+	_, ok = restarted.Get(key)
+	assert.False(t, ok)
+}
+
+func TestCache_DiskLimit(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewCache(CacheConfig{Dir: dir, MaxDiskBytes: 10})
+	require.NoError(t, err)
+
+	first := CacheKey("first", FormatPNG, 0)
+	second := CacheKey("second", FormatPNG, 0)
+	cache.Put(first, CacheEntry{Data: []byte("123456"), Format: FormatPNG})
+	// 保证两个文件的修改时间不同
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, first[:2], first+".png"), old, old))
+	cache.mu.Lock()
+	cache.disk[first].modTime = old
+	cache.mu.Unlock()
+	cache.Put(second, CacheEntry{Data: []byte("123456"), Format: FormatPNG})
+
+	stats := cache.Stats()
+	assert.Equal(t, 1, stats.DiskEntries)
+	assert.Equal(t, int64(6), stats.DiskBytes)
+	assert.NoFileExists(t, filepath.Join(dir, first[:2], first+".png"))
+	assert.FileExists(t, filepath.Join(dir, second[:2], second+".png"))
+}
+
+func TestCache_ConcurrentAccess(t *testing.T) {
+	cache, err := NewCache(CacheConfig{MaxEntries: 16, Dir: t.TempDir()})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := CacheKey(fmt.Sprintf("diagram-%d", (worker+j)%32), FormatSVG, 0)
+				if _, ok := cache.Get(key); !ok {
+					cache.Put(key, entry(key))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	stats := cache.Stats()
+	assert.LessOrEqual(t, stats.Entries, 16)
+	assert.Equal(t, int64(800), stats.Hits+stats.Misses)
+	assert.Equal(t, 32, stats.DiskEntries)
+}
@@ -0,0 +1,106 @@
+package plantuml
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrUnsupportedConversion 图表类型不支持转换为目标格式
+var ErrUnsupportedConversion = errors.New("不支持转换该类型的图表")
+
+// arrowPartsRe 拆分箭头的左端、线型和右端，中间的 [#颜色]、up 等修饰丢弃
+var arrowPartsRe = regexp.MustCompile(`^(<\|?|<<|\\{1,2}|/{1,2}|[*o#x+}{|]{1,2})?(-+|\.+)(?:\[[^\]]*\]|up|down|left|right)?(?:-*|\.*)(\|?>>?|\\{1,2}|/{1,2}|[*o#x+}{|]{1,2})?$`)
+
+// Conversion 转换结果，Dropped 为目标格式无法表示而丢弃的结构
+type Conversion struct {
+	Source  string   `json:"source"`
+	Dropped []string `json:"dropped"`
+}
+
+// arrow 拆分后的箭头
+type arrow struct {
+	left   string
+	dashed bool
+	right  string
+}
+
+// parseArrow 拆分箭头，无法识别时按普通实线处理
+func parseArrow(s string) arrow {
+	m := arrowPartsRe.FindStringSubmatch(s)
+	if m == nil {
+		return arrow{dashed: strings.Contains(s, ".")}
+	}
+	return arrow{left: m[1], dashed: strings.HasPrefix(m[2], "."), right: m[3]}
+}
+
+// idMapper 为元素分配目标格式可用的标识：元素标识本身合法且不重复时沿用，否则按顺序生成
+type idMapper struct {
+	doc    *Document
+	prefix string
+	ids    map[*Element]string
+	used   map[string]bool
+}
+
+var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func newIDMapper(doc *Document, prefix string) *idMapper {
+	m := &idMapper{doc: doc, prefix: prefix, ids: make(map[*Element]string), used: make(map[string]bool)}
+	for _, e := range doc.Elements {
+		if id := e.ID(); identRe.MatchString(id) && !m.used[id] {
+			m.ids[e] = id
+			m.used[id] = true
+		}
+	}
+	return m
+}
+
+// id 元素的标识；引用的元素不存在时返回空
+func (m *idMapper) id(ref string) string {
+	e := m.doc.Element(ref)
+	if e == nil {
+		return ""
+	}
+	return m.element(e)
+}
+
+func (m *idMapper) element(e *Element) string {
+	if id, ok := m.ids[e]; ok {
+		return id
+	}
+	var id string
+	for i := 1; ; i++ {
+		id = fmt.Sprintf("%s%d", m.prefix, i)
+		if !m.used[id] {
+			break
+		}
+	}
+	m.ids[e] = id
+	m.used[id] = true
+	return id
+}
+
+// dropList 记录丢弃的结构，同一描述只记录一次
+type dropList struct {
+	items []string
+	seen  map[string]bool
+}
+
+func (d *dropList) add(format string, args ...interface{}) {
+	what := fmt.Sprintf(format, args...)
+	if d.seen == nil {
+		d.seen = make(map[string]bool)
+	}
+	if !d.seen[what] {
+		d.seen[what] = true
+		d.items = append(d.items, what)
+	}
+}
+
+func (d *dropList) list() []string {
+	if d.items == nil {
+		return []string{}
+	}
+	return d.items
+}
@@ -0,0 +1,739 @@
+package plantuml
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrUnsupportedDiff 图表类型不支持语义比较，只能逐行比较
+var ErrUnsupportedDiff = errors.New("不支持语义比较该类型的图表")
+
+// 变化类型
+const (
+	ChangeAdded     = "added"
+	ChangeRemoved   = "removed"
+	ChangeModified  = "modified"
+	ChangeReordered = "reordered" // 序列图中的消息顺序调整
+)
+
+// 合并图中的高亮颜色：线条和文字用深色，元素背景用浅色
+const (
+	colorAdded     = "#2E7D32"
+	colorRemoved   = "#C62828"
+	colorModified  = "#EF6C00"
+	colorReordered = "#1565C0"
+	fillAdded      = "#C8E6C9"
+	fillRemoved    = "#FFCDD2"
+	fillModified   = "#FFE0B2"
+)
+
+// maxLCSCells 最长公共子序列动态规划表的上限，超过时只匹配公共前缀和后缀
+const maxLCSCells = 4 << 20
+
+// plainNameRe 不需要加引号的名称
+var plainNameRe = regexp.MustCompile(`^[\p{L}\p{N}_.]+$`)
+
+// MemberChange 类或实体成员的变化，Old、New 为成员原文
+type MemberChange struct {
+	Change string `json:"change"`
+	Name   string `json:"name"`
+	Old    string `json:"old,omitempty"`
+	New    string `json:"new,omitempty"`
+
+	old, new *Member
+}
+
+// ElementChange 元素的变化，按名称对应两个版本的元素
+type ElementChange struct {
+	Change  string         `json:"change"`
+	Kind    string         `json:"kind"`
+	Name    string         `json:"name"`
+	Details []string       `json:"details,omitempty"` // 类型、别名、构造型、所在容器的变化
+	Members []MemberChange `json:"members,omitempty"`
+	Line    int            `json:"line"` // 删除的元素为旧版本中的行号，其余为新版本中的行号
+
+	old, new *Element
+}
+
+// RelationChange 关系或消息的变化，端点为元素名称
+type RelationChange struct {
+	Change  string `json:"change"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Arrow   string `json:"arrow"`
+	Label   string `json:"label,omitempty"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+
+	old, new *Relation
+	after    int // 删除的消息在合并图中插到新版本该行之后，0 表示放在图表末尾
+}
+
+// DiffSummary 各类变化的数量
+type DiffSummary struct {
+	ElementsAdded      int `json:"elements_added"`
+	ElementsRemoved    int `json:"elements_removed"`
+	ElementsModified   int `json:"elements_modified"`
+	RelationsAdded     int `json:"relations_added"`
+	RelationsRemoved   int `json:"relations_removed"`
+	RelationsReordered int `json:"relations_reordered"`
+}
+
+// Diff 同一图表两个版本之间的语义差异
+type Diff struct {
+	Type      DiagramType      `json:"type"`
+	Elements  []ElementChange  `json:"elements"`
+	Relations []RelationChange `json:"relations"`
+	Summary   DiffSummary      `json:"summary"`
+
+	old, new *Document
+}
+
+// Empty 是否没有语义变化
+func (d *Diff) Empty() bool {
+	return len(d.Elements) == 0 && len(d.Relations) == 0
+}
+
+// Compare 比较同一图表的两个版本：元素按名称对应，成员按名称对应；序列图的消息按最长公共子序列对齐，
+// 两个版本都有但未对齐的消息视为顺序调整，其他图表的关系不考虑顺序
+func Compare(old, new *Document) (*Diff, error) {
+	switch new.Type {
+	case DiagramSequence, DiagramClass, DiagramEntity, DiagramComponent, DiagramUseCase:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDiff, new.Type)
+	}
+	if old.Type != new.Type {
+		return nil, fmt.Errorf("%w: 图表类型由 %s 变为 %s", ErrUnsupportedDiff, old.Type, new.Type)
+	}
+
+	d := &Diff{Type: new.Type, Elements: []ElementChange{}, Relations: []RelationChange{}, old: old, new: new}
+	d.compareElements()
+	d.compareRelations()
+	for _, c := range d.Elements {
+		switch c.Change {
+		case ChangeAdded:
+			d.Summary.ElementsAdded++
+		case ChangeRemoved:
+			d.Summary.ElementsRemoved++
+		case ChangeModified:
+			d.Summary.ElementsModified++
+		}
+	}
+	for _, c := range d.Relations {
+		switch c.Change {
+		case ChangeAdded:
+			d.Summary.RelationsAdded++
+		case ChangeRemoved:
+			d.Summary.RelationsRemoved++
+		case ChangeReordered:
+			d.Summary.RelationsReordered++
+		}
+	}
+	return d, nil
+}
+
+func (d *Diff) compareElements() {
+	oldByName := make(map[string]*Element, len(d.old.Elements))
+	for _, e := range d.old.Elements {
+		if _, ok := oldByName[e.Name]; !ok {
+			oldByName[e.Name] = e
+		}
+	}
+	seen := make(map[string]bool, len(d.new.Elements))
+	for _, e := range d.new.Elements {
+		if seen[e.Name] {
+			continue
+		}
+		seen[e.Name] = true
+		o := oldByName[e.Name]
+		if o == nil {
+			d.Elements = append(d.Elements, ElementChange{Change: ChangeAdded, Kind: e.Kind, Name: e.Name, Line: e.Pos.Line, new: e})
+			continue
+		}
+		c := ElementChange{Change: ChangeModified, Kind: e.Kind, Name: e.Name, Line: e.Pos.Line, old: o, new: e}
+		c.Details = elementDetails(o, e)
+		c.Members = compareMembers(o.Members, e.Members)
+		if len(c.Details) > 0 || len(c.Members) > 0 {
+			d.Elements = append(d.Elements, c)
+		}
+	}
+	for _, e := range d.old.Elements {
+		if !seen[e.Name] {
+			seen[e.Name] = true
+			d.Elements = append(d.Elements, ElementChange{Change: ChangeRemoved, Kind: e.Kind, Name: e.Name, Line: e.Pos.Line, old: e})
+		}
+	}
+}
+
+// elementDetails 元素本身（不含成员）的变化说明
+func elementDetails(o, e *Element) []string {
+	var details []string
+	field := func(what, a, b string) {
+		if a == b {
+			return
+		}
+		if a == "" {
+			a = "无"
+		}
+		if b == "" {
+			b = "无"
+		}
+		details = append(details, fmt.Sprintf("%s %s → %s", what, a, b))
+	}
+	field("类型", o.Kind, e.Kind)
+	field("别名", o.Alias, e.Alias)
+	field("构造型", o.Stereotype, e.Stereotype)
+	field("所在容器", o.Parent, e.Parent)
+	return details
+}
+
+// compareMembers 成员按名称对应，同名的方法按出现顺序配对
+func compareMembers(old, new []*Member) []MemberChange {
+	key := func(m *Member) string {
+		if m.Method {
+			return m.Name + "()"
+		}
+		return m.Name
+	}
+	oldByKey := make(map[string][]*Member)
+	for _, m := range old {
+		oldByKey[key(m)] = append(oldByKey[key(m)], m)
+	}
+	var changes []MemberChange
+	for _, m := range new {
+		k := key(m)
+		if len(oldByKey[k]) == 0 {
+			changes = append(changes, MemberChange{Change: ChangeAdded, Name: m.Name, New: m.Text, new: m})
+			continue
+		}
+		o := oldByKey[k][0]
+		oldByKey[k] = oldByKey[k][1:]
+		if o.Type != m.Type || o.Visibility != m.Visibility || o.PrimaryKey != m.PrimaryKey || o.ForeignKey != m.ForeignKey {
+			changes = append(changes, MemberChange{Change: ChangeModified, Name: m.Name, Old: o.Text, New: m.Text, old: o, new: m})
+		}
+	}
+	for _, m := range old {
+		if rest := oldByKey[key(m)]; len(rest) > 0 && rest[0] == m {
+			oldByKey[key(m)] = rest[1:]
+			changes = append(changes, MemberChange{Change: ChangeRemoved, Name: m.Name, Old: m.Text, old: m})
+		}
+	}
+	return changes
+}
+
+func (d *Diff) compareRelations() {
+	oldKeys := relationKeys(d.old)
+	newKeys := relationKeys(d.new)
+	counterpart := make([]int, len(d.old.Relations)) // 旧版本关系在新版本中的下标，未对应时为 -1
+	for i := range counterpart {
+		counterpart[i] = -1
+	}
+	matchedNew := make([]bool, len(d.new.Relations))
+
+	if d.Type == DiagramSequence {
+		for _, p := range lcs(oldKeys, newKeys) {
+			counterpart[p[0]] = p[1]
+			matchedNew[p[1]] = true
+		}
+	}
+	// 剩余的按键配对：序列图中为顺序调整，其他图表中视为未变化
+	unmatched := make(map[string][]int)
+	for j, k := range newKeys {
+		if !matchedNew[j] {
+			unmatched[k] = append(unmatched[k], j)
+		}
+	}
+	reordered := make(map[int]int) // 顺序调整的消息：新版本下标 -> 旧版本下标
+	for i, k := range oldKeys {
+		if counterpart[i] >= 0 || len(unmatched[k]) == 0 {
+			continue
+		}
+		j := unmatched[k][0]
+		unmatched[k] = unmatched[k][1:]
+		counterpart[i], matchedNew[j] = j, true
+		if d.Type == DiagramSequence {
+			reordered[j] = i
+		}
+	}
+
+	for j, r := range d.new.Relations {
+		if i, ok := reordered[j]; ok {
+			d.Relations = append(d.Relations, d.relationChange(ChangeReordered, d.new, r, d.old.Relations[i], r))
+		} else if !matchedNew[j] {
+			d.Relations = append(d.Relations, d.relationChange(ChangeAdded, d.new, r, nil, r))
+		}
+	}
+	for i, r := range d.old.Relations {
+		if counterpart[i] >= 0 {
+			continue
+		}
+		c := d.relationChange(ChangeRemoved, d.old, r, r, nil)
+		if d.Type == DiagramSequence {
+			c.after = d.anchor(counterpart, i)
+		}
+		d.Relations = append(d.Relations, c)
+	}
+}
+
+// anchor 删除的消息在新版本中的插入位置：前一条仍保留的消息之后；前面没有保留的消息时放在第一条消息之前
+func (d *Diff) anchor(counterpart []int, i int) int {
+	for k := i - 1; k >= 0; k-- {
+		if counterpart[k] >= 0 {
+			return d.new.Relations[counterpart[k]].Pos.Line
+		}
+	}
+	if len(d.new.Relations) > 0 {
+		return d.new.Relations[0].Pos.Line - 1
+	}
+	return 0
+}
+
+func (d *Diff) relationChange(change string, doc *Document, r, old, new *Relation) RelationChange {
+	c := RelationChange{
+		Change: change,
+		From:   elementName(doc, r.From),
+		To:     elementName(doc, r.To),
+		Arrow:  r.Arrow,
+		Label:  r.Label,
+		old:    old,
+		new:    new,
+	}
+	if old != nil {
+		c.OldLine = old.Pos.Line
+	}
+	if new != nil {
+		c.NewLine = new.Pos.Line
+	}
+	return c
+}
+
+// relationKeys 用于对应两个版本关系的键：端点取元素名称，别名变化不影响对应
+func relationKeys(doc *Document) []string {
+	keys := make([]string, len(doc.Relations))
+	for i, r := range doc.Relations {
+		keys[i] = strings.Join([]string{elementName(doc, r.From), r.Arrow, elementName(doc, r.To), r.Label, r.FromCardinality, r.ToCardinality}, "\x00")
+	}
+	return keys
+}
+
+func elementName(doc *Document, ref string) string {
+	if e := doc.Element(ref); e != nil {
+		return e.Name
+	}
+	return unquote(ref)
+}
+
+// Highlight 在新版本源码的基础上生成合并图：新增的元素、关系和成员标为绿色，删除的以红色补回，
+// 修改的标为橙色，顺序调整的消息标为蓝色，并注入图例及其 skinparam；source 为 d 的新版本源码
+func Highlight(source string, d *Diff) string {
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	edits := make(map[int][]func(string) string)
+	before := make(map[int][]string)
+	after := make(map[int][]string)
+	var header, footer []string
+
+	// 删除的元素：序列图中放在开头以保持参与者先声明，其他图表放在末尾
+	removedElements := &footer
+	if d.Type == DiagramSequence {
+		removedElements = &header
+	}
+	for _, c := range d.Elements {
+		switch c.Change {
+		case ChangeAdded:
+			if c.new.Implicit {
+				before[c.new.Pos.Line] = append(before[c.new.Pos.Line], declaration(c.new, fillAdded))
+			} else {
+				edits[c.new.Pos.Line] = append(edits[c.new.Pos.Line], colorDeclaration(fillAdded))
+			}
+		case ChangeRemoved:
+			*removedElements = append(*removedElements, declaration(c.old, fillRemoved))
+		case ChangeModified:
+			if len(c.Details) > 0 && !c.new.Implicit {
+				edits[c.new.Pos.Line] = append(edits[c.new.Pos.Line], colorDeclaration(fillModified))
+			}
+			for _, m := range c.Members {
+				switch m.Change {
+				case ChangeAdded:
+					edits[m.new.Pos.Line] = append(edits[m.new.Pos.Line], colorMember(m.new.Pos.Column, colorAdded))
+				case ChangeModified:
+					edits[m.new.Pos.Line] = append(edits[m.new.Pos.Line], colorMember(m.new.Pos.Column, colorModified))
+				case ChangeRemoved:
+					footer = append(footer, fmt.Sprintf("%s : <color:%s><s>%s</s></color>", quoteRef(c.new.ID()), colorRemoved, strings.TrimSpace(m.old.Text)))
+				}
+			}
+		}
+	}
+
+	for _, c := range d.Relations {
+		switch c.Change {
+		case ChangeAdded:
+			edits[c.new.Pos.Line] = append(edits[c.new.Pos.Line], colorRelation(c.new, colorAdded))
+		case ChangeReordered:
+			edits[c.new.Pos.Line] = append(edits[c.new.Pos.Line], colorRelation(c.new, colorReordered))
+		case ChangeRemoved:
+			r := c.old
+			text := quoteRef(d.newRef(r.From))
+			if r.FromCardinality != "" {
+				text += fmt.Sprintf(" %q", r.FromCardinality)
+			}
+			text += " " + colorArrow(r.Arrow, colorRemoved) + " "
+			if r.ToCardinality != "" {
+				text += fmt.Sprintf("%q ", r.ToCardinality)
+			}
+			text += quoteRef(d.newRef(r.To))
+			if r.Label != "" {
+				text += " : " + r.Label
+			}
+			if c.after > 0 {
+				after[c.after] = append(after[c.after], text)
+			} else {
+				footer = append(footer, text)
+			}
+		}
+	}
+
+	header = append([]string{
+		"skinparam legendBackgroundColor #FFFFFF",
+		"skinparam legendBorderColor #9E9E9E",
+		"skinparam legendFontSize 11",
+	}, header...)
+	footer = append(footer, d.legend()...)
+
+	start, end := -1, -1
+	for i, line := range lines {
+		lower := strings.ToLower(strings.TrimSpace(line))
+		if start < 0 && strings.HasPrefix(lower, "@startuml") {
+			start = i
+		} else if start >= 0 && strings.HasPrefix(lower, "@enduml") {
+			end = i
+			break
+		}
+	}
+
+	var b strings.Builder
+	write := func(s string) {
+		b.WriteString(s)
+		b.WriteString("\n")
+	}
+	if start < 0 {
+		for _, s := range header {
+			write(s)
+		}
+	}
+	for i, line := range lines {
+		n := i + 1
+		if i == end {
+			for _, s := range footer {
+				write(s)
+			}
+		}
+		// 插入的语句沿用所在位置的缩进
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		for _, s := range before[n] {
+			write(indent + s)
+		}
+		for _, edit := range edits[n] {
+			line = edit(line)
+		}
+		if i < len(lines)-1 || line != "" {
+			write(line)
+		}
+		if i == start {
+			for _, s := range header {
+				write(s)
+			}
+		}
+		for _, s := range after[n] {
+			write(indent + s)
+		}
+	}
+	if end < 0 {
+		for _, s := range footer {
+			write(s)
+		}
+	}
+	return b.String()
+}
+
+// newRef 删除的关系端点在合并图中的引用：元素仍存在时用新版本的标识，否则用补回的旧元素的标识
+func (d *Diff) newRef(ref string) string {
+	if o := d.old.Element(ref); o != nil {
+		if e := d.new.Element(o.Name); e != nil {
+			return e.ID()
+		}
+		return o.ID()
+	}
+	return unquote(ref)
+}
+
+// legend 颜色说明和变化数量
+func (d *Diff) legend() []string {
+	s := d.Summary
+	lines := []string{"legend top right"}
+	item := func(color, what string, count int) {
+		if count > 0 {
+			lines = append(lines, fmt.Sprintf("<color:%s>■</color> %s %d", color, what, count))
+		}
+	}
+	item(colorAdded, "新增", s.ElementsAdded+s.RelationsAdded)
+	item(colorRemoved, "删除", s.ElementsRemoved+s.RelationsRemoved)
+	item(colorModified, "修改", s.ElementsModified)
+	item(colorReordered, "顺序调整", s.RelationsReordered)
+	if len(lines) == 1 {
+		lines = append(lines, "无语义变化")
+	}
+	return append(lines, "endlegend")
+}
+
+// declaration 补回或显式声明元素的语句，类和实体带上成员
+func declaration(e *Element, fill string) string {
+	text := e.Kind + " "
+	switch {
+	case e.Alias != "":
+		text += fmt.Sprintf("%q as %s", e.Name, quoteRef(e.Alias))
+	default:
+		text += quoteRef(e.Name)
+	}
+	if e.Stereotype != "" {
+		text += " <<" + e.Stereotype + ">>"
+	}
+	text += " " + fill
+	if !classKinds[e.Kind] || len(e.Members) == 0 {
+		return text
+	}
+	lines := []string{text + " {"}
+	for _, m := range e.Members {
+		lines = append(lines, "  "+strings.TrimSpace(m.Text))
+	}
+	return strings.Join(append(lines, "}"), "\n")
+}
+
+// colorDeclaration 在声明行末尾（有主体时在 { 之前）加上背景色
+func colorDeclaration(fill string) func(string) string {
+	return func(line string) string {
+		trimmed := strings.TrimRight(line, " \t")
+		for _, body := range []string{"{}", "{"} {
+			if strings.HasSuffix(trimmed, body) {
+				head := strings.TrimRight(strings.TrimSuffix(trimmed, body), " \t")
+				return head + " " + fill + " " + body
+			}
+		}
+		return trimmed + " " + fill
+	}
+}
+
+// colorMember 给成员文字着色，保留开头的 * 和可见性标记；宏形式的成员保持原样
+func colorMember(column int, color string) func(string) string {
+	return func(line string) string {
+		i := byteOffset(line, column)
+		rest := line[i:]
+		if memberMacroRe.MatchString(rest) {
+			return line
+		}
+		j := 0
+		for j < len(rest) && strings.ContainsRune("*+-#~ \t", rune(rest[j])) {
+			j++
+		}
+		text := strings.TrimRight(rest[j:], " \t")
+		if text == "" {
+			return line
+		}
+		return line[:i] + rest[:j] + "<color:" + color + ">" + text + "</color>"
+	}
+}
+
+// colorRelation 给新版本源码中的关系箭头着色
+func colorRelation(r *Relation, color string) func(string) string {
+	return func(line string) string {
+		i := byteOffset(line, r.Pos.Column)
+		j := strings.Index(line[i:], r.Arrow)
+		if j < 0 {
+			return line
+		}
+		j += i
+		return line[:j] + colorArrow(r.Arrow, color) + line[j+len(r.Arrow):]
+	}
+}
+
+// colorArrow 在箭头的第一段线之后插入颜色，如 -> 变为 -[#C62828]>；已带修饰的箭头保持原样
+func colorArrow(arrow, color string) string {
+	if strings.Contains(arrow, "[") {
+		return arrow
+	}
+	i := strings.IndexAny(arrow, "-.")
+	if i < 0 {
+		return arrow
+	}
+	return arrow[:i+1] + "[" + color + "]" + arrow[i+1:]
+}
+
+// quoteRef 名称含空格等字符时加引号
+func quoteRef(ref string) string {
+	if plainNameRe.MatchString(ref) {
+		return ref
+	}
+	return fmt.Sprintf("%q", ref)
+}
+
+// byteOffset 按字符计数的列（从 1 开始）在行中的字节偏移
+func byteOffset(line string, column int) int {
+	n := 1
+	for i := range line {
+		if n == column {
+			return i
+		}
+		n++
+	}
+	return len(line)
+}
+
+// LineDiff 逐行比较的一行：equal 两侧相同，changed 两侧不同，removed 只在旧版本，added 只在新版本
+type LineDiff struct {
+	Op      string `json:"op"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+	Old     string `json:"old,omitempty"`
+	New     string `json:"new,omitempty"`
+}
+
+// DiffLines 逐行比较两个版本的源码，两次匹配之间的删除行和新增行依次配对为 changed
+func DiffLines(oldSource, newSource string) []LineDiff {
+	a := splitLines(oldSource)
+	b := splitLines(newSource)
+	var rows []LineDiff
+	i, j := 0, 0
+	gap := func(toI, toJ int) {
+		for ; i < toI && j < toJ; i, j = i+1, j+1 {
+			rows = append(rows, LineDiff{Op: "changed", OldLine: i + 1, NewLine: j + 1, Old: a[i], New: b[j]})
+		}
+		for ; i < toI; i++ {
+			rows = append(rows, LineDiff{Op: "removed", OldLine: i + 1, Old: a[i]})
+		}
+		for ; j < toJ; j++ {
+			rows = append(rows, LineDiff{Op: "added", NewLine: j + 1, New: b[j]})
+		}
+	}
+	for _, p := range lcs(a, b) {
+		gap(p[0], p[1])
+		rows = append(rows, LineDiff{Op: "equal", OldLine: i + 1, NewLine: j + 1, Old: a[i], New: b[j]})
+		i, j = i+1, j+1
+	}
+	gap(len(a), len(b))
+	return rows
+}
+
+// SideBySide 把逐行比较结果排成左右两栏的纯文本，width 为每栏的显示宽度（中文按两个字符计）
+func SideBySide(rows []LineDiff, width int) string {
+	if width < 10 {
+		width = 10
+	}
+	number := func(n int) string {
+		if n == 0 {
+			return "    "
+		}
+		return fmt.Sprintf("%4d", n)
+	}
+	var b strings.Builder
+	for _, r := range rows {
+		marker := " "
+		switch r.Op {
+		case "changed":
+			marker = "|"
+		case "removed":
+			marker = "<"
+		case "added":
+			marker = ">"
+		}
+		left := fitWidth(strings.ReplaceAll(r.Old, "\t", "    "), width)
+		right := strings.ReplaceAll(r.New, "\t", "    ")
+		line := fmt.Sprintf("%s %s %s %s %s", number(r.OldLine), left, marker, number(r.NewLine), right)
+		b.WriteString(strings.TrimRight(line, " "))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// fitWidth 截断或补齐到指定显示宽度，截断时以省略号结尾
+func fitWidth(s string, width int) string {
+	w := 0
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+	if w > width {
+		var b strings.Builder
+		w = 1
+		for _, r := range s {
+			if w+runeWidth(r) > width {
+				break
+			}
+			b.WriteRune(r)
+			w += runeWidth(r)
+		}
+		s = b.String() + "…"
+	}
+	return s + strings.Repeat(" ", width-w)
+}
+
+// runeWidth 中日韩文字和全角符号占两列
+func runeWidth(r rune) int {
+	if r >= 0x1100 && (r <= 0x115F || r >= 0x2E80 && r <= 0xA4CF || r >= 0xAC00 && r <= 0xD7A3 || r >= 0xF900 && r <= 0xFAFF || r >= 0xFE30 && r <= 0xFE4F || r >= 0xFF00 && r <= 0xFF60 || r >= 0xFFE0 && r <= 0xFFE6) {
+		return 2
+	}
+	return 1
+}
+
+func splitLines(source string) []string {
+	source = strings.TrimSuffix(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	if source == "" {
+		return nil
+	}
+	return strings.Split(source, "\n")
+}
+
+// lcs 最长公共子序列中配对的下标，先去掉公共前缀和后缀；中间部分过大时不再对齐
+func lcs(a, b []string) [][2]int {
+	var pairs [][2]int
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		pairs = append(pairs, [2]int{prefix, prefix})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	n, m := len(ma), len(mb)
+	if n > 0 && m > 0 && n*m <= maxLCSCells {
+		// table[i*(m+1)+j] 为 ma[i:] 与 mb[j:] 的最长公共子序列长度
+		table := make([]int32, (n+1)*(m+1))
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if ma[i] == mb[j] {
+					table[i*(m+1)+j] = table[(i+1)*(m+1)+j+1] + 1
+				} else {
+					table[i*(m+1)+j] = max(table[(i+1)*(m+1)+j], table[i*(m+1)+j+1])
+				}
+			}
+		}
+		for i, j := 0, 0; i < n && j < m; {
+			switch {
+			case ma[i] == mb[j]:
+				pairs = append(pairs, [2]int{prefix + i, prefix + j})
+				i, j = i+1, j+1
+			case table[(i+1)*(m+1)+j] >= table[i*(m+1)+j+1]:
+				i++
+			default:
+				j++
+			}
+		}
+	}
+
+	for k := suffix; k > 0; k-- {
+		pairs = append(pairs, [2]int{len(a) - k, len(b) - k})
+	}
+	return pairs
+}
@@ -0,0 +1,163 @@
+package plantuml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const diffSequenceOld = `@startuml
+participant 用户
+participant 订单服务 as OS
+participant 库存
+用户 -> OS : 提交订单
+OS -> 库存 : 扣减库存
+库存 --> OS : 成功
+OS --> 用户 : 下单成功
+@enduml`
+
+const diffSequenceNew = `@startuml
+participant 用户
+participant 订单服务 as OS
+用户 -> OS : 提交订单
+OS --> 用户 : 下单成功
+OS -> 支付 : 发起支付
+OS -> 库存 : 扣减库存
+@enduml`
+
+func TestCompare_Sequence(t *testing.T) {
+	d, err := Compare(Parse(diffSequenceOld), Parse(diffSequenceNew))
+	require.NoError(t, err)
+
+	assert.Equal(t, DiffSummary{ElementsAdded: 1, ElementsRemoved: 0, RelationsAdded: 1, RelationsRemoved: 1, RelationsReordered: 1}, d.Summary)
+	require.Len(t, d.Elements, 1)
+	assert.Equal(t, ElementChange{Change: ChangeAdded, Kind: "participant", Name: "支付", Line: 6, new: d.Elements[0].new}, d.Elements[0])
+
+	require.Len(t, d.Relations, 3)
+	assert.Equal(t, ChangeAdded, d.Relations[0].Change)
+	assert.Equal(t, "发起支付", d.Relations[0].Label)
+	assert.Equal(t, ChangeReordered, d.Relations[1].Change)
+	assert.Equal(t, "订单服务", d.Relations[1].From)
+	assert.Equal(t, 6, d.Relations[1].OldLine)
+	assert.Equal(t, 7, d.Relations[1].NewLine)
+	assert.Equal(t, ChangeRemoved, d.Relations[2].Change)
+	assert.Equal(t, "成功", d.Relations[2].Label)
+	assert.Equal(t, 7, d.Relations[2].OldLine)
+}
+
+func TestHighlight_Sequence(t *testing.T) {
+	d, err := Compare(Parse(diffSequenceOld), Parse(diffSequenceNew))
+	require.NoError(t, err)
+
+	merged := Highlight(diffSequenceNew, d)
+	assert.Equal(t, `@startuml
+skinparam legendBackgroundColor #FFFFFF
+skinparam legendBorderColor #9E9E9E
+skinparam legendFontSize 11
+participant 用户
+participant 订单服务 as OS
+用户 -> OS : 提交订单
+OS --> 用户 : 下单成功
+participant 支付 #C8E6C9
+OS -[#2E7D32]> 支付 : 发起支付
+OS -[#1565C0]> 库存 : 扣减库存
+库存 -[#C62828]-> OS : 成功
+legend top right
+<color:#2E7D32>■</color> 新增 2
+<color:#C62828>■</color> 删除 1
+<color:#1565C0>■</color> 顺序调整 1
+endlegend
+@enduml
+`, merged)
+
+	// 合并图本身能被解析且没有错误
+	assert.False(t, Parse(merged).HasErrors())
+}
+
+func TestCompare_EntityMembers(t *testing.T) {
+	old := `@startuml
+entity 用户 {
+  * id : bigint <<PK>>
+  name : varchar(50)
+  phone : varchar(20)
+}
+entity 地址 {
+  * id : bigint <<PK>>
+}
+用户 ||--o{ 地址
+@enduml`
+	new := `@startuml
+entity 用户 {
+  * id : bigint <<PK>>
+  name : varchar(100)
+  email : varchar(100)
+}
+entity 订单 {
+  * id : bigint <<PK>>
+}
+用户 ||--o{ 订单 : 下单
+@enduml`
+
+	d, err := Compare(Parse(old), Parse(new))
+	require.NoError(t, err)
+	require.Len(t, d.Elements, 3)
+
+	user := d.Elements[0]
+	assert.Equal(t, ChangeModified, user.Change)
+	assert.Empty(t, user.Details)
+	require.Len(t, user.Members, 3)
+	assert.Equal(t, MemberChange{Change: ChangeModified, Name: "name", Old: "name : varchar(50)", New: "name : varchar(100)"}, stripMember(user.Members[0]))
+	assert.Equal(t, MemberChange{Change: ChangeAdded, Name: "email", New: "email : varchar(100)"}, stripMember(user.Members[1]))
+	assert.Equal(t, MemberChange{Change: ChangeRemoved, Name: "phone", Old: "phone : varchar(20)"}, stripMember(user.Members[2]))
+	assert.Equal(t, ChangeAdded, d.Elements[1].Change)
+	assert.Equal(t, "订单", d.Elements[1].Name)
+	assert.Equal(t, ChangeRemoved, d.Elements[2].Change)
+	assert.Equal(t, "地址", d.Elements[2].Name)
+
+	// 非序列图的关系不考虑顺序
+	require.Len(t, d.Relations, 2)
+	assert.Equal(t, ChangeAdded, d.Relations[0].Change)
+	assert.Equal(t, ChangeRemoved, d.Relations[1].Change)
+
+	merged := Highlight(new, d)
+	assert.Contains(t, merged, "  <color:#EF6C00>name : varchar(100)</color>\n")
+	assert.Contains(t, merged, "  <color:#2E7D32>email : varchar(100)</color>\n")
+	assert.Contains(t, merged, "entity 订单 #C8E6C9 {\n")
+	assert.Contains(t, merged, "用户 ||-[#2E7D32]-o{ 订单 : 下单\n")
+	assert.Contains(t, merged, "用户 : <color:#C62828><s>phone : varchar(20)</s></color>\n")
+	assert.Contains(t, merged, "entity 地址 #FFCDD2 {\n  * id : bigint <<PK>>\n}\n用户 ||-[#C62828]-o{ 地址\n")
+	assert.False(t, Parse(merged).HasErrors())
+}
+
+func stripMember(m MemberChange) MemberChange {
+	m.old, m.new = nil, nil
+	return m
+}
+
+func TestCompare_Unsupported(t *testing.T) {
+	_, err := Compare(Parse("@startuml\nstart\n:a;\nstop\n@enduml"), Parse("@startuml\nstart\n:b;\nstop\n@enduml"))
+	assert.ErrorIs(t, err, ErrUnsupportedDiff)
+
+	_, err = Compare(Parse("@startuml\nA -> B\n@enduml"), Parse("@startuml\nclass A\n@enduml"))
+	assert.ErrorIs(t, err, ErrUnsupportedDiff)
+}
+
+func TestDiffLines_SideBySide(t *testing.T) {
+	rows := DiffLines("@startuml\nA -> B : 你好\nB -> C\n@enduml\n", "@startuml\nA -> B : 您好\n@enduml\nnote")
+	assert.Equal(t, []LineDiff{
+		{Op: "equal", OldLine: 1, NewLine: 1, Old: "@startuml", New: "@startuml"},
+		{Op: "changed", OldLine: 2, NewLine: 2, Old: "A -> B : 你好", New: "A -> B : 您好"},
+		{Op: "removed", OldLine: 3, Old: "B -> C"},
+		{Op: "equal", OldLine: 4, NewLine: 3, Old: "@enduml", New: "@enduml"},
+		{Op: "added", NewLine: 4, New: "note"},
+	}, rows)
+
+	text := SideBySide(rows, 12)
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, "   2 A -> B : 你… |    2 A -> B : 您好", lines[1])
+	assert.Equal(t, "   3 B -> C       <", lines[2])
+	assert.Equal(t, "                  >    4 note", lines[4])
+}
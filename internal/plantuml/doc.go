@@ -0,0 +1,9 @@
+// Package plantuml 图表源码的解析、转换、比较与渲染：
+//
+//   - 解析：Parse 和 ParseAs 把 PlantUML 或 Mermaid 源码解析为语法树，返回带行列位置的诊断，供编辑器标注问题
+//   - 转换：ToMermaid、FromMermaid 在 PlantUML 与 Mermaid 之间互相转换，ToDrawio 生成 draw.io 页面，
+//     无法转换的写法记录在结果中而不是静默丢弃
+//   - 比较：Compare 对同一图表的两个版本做语义比较，不支持的图表类型用 DiffLines 逐行比较
+//   - 渲染：Renderer 通过受管理的工作池调用本地 PlantUML 可执行文件或 jar，不依赖外部渲染服务器；
+//     Cache 按 LRU 缓存渲染结果，可同时保存到磁盘
+package plantuml
@@ -0,0 +1,263 @@
+package plantuml
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+// draw.io 布局参数
+const (
+	drawioGapX       = 60
+	drawioGapY       = 60
+	drawioLineHeight = 20
+)
+
+// drawio 端点对应的 draw.io 箭头样式
+var drawioArrows = map[string]string{
+	"|>": "block;endFill=0", "<|": "block;endFill=0",
+	">": "open", "<": "open", ">>": "open", "<<": "open",
+	"*": "diamondThin;endFill=1", "o": "diamondThin;endFill=0",
+	"||": "ERmandOne", "|o": "ERzeroToOne", "o|": "ERzeroToOne",
+	"}o": "ERzeroToMany", "o{": "ERzeroToMany", "}|": "ERoneToMany", "|{": "ERoneToMany",
+}
+
+type drawioVertex struct {
+	id     string
+	value  string
+	style  string
+	width  int
+	height int
+	x, y   int
+}
+
+type drawioWriter struct {
+	doc      *Document
+	b        strings.Builder
+	vertices []*drawioVertex
+	dropped  dropList
+}
+
+// ToDrawio 把 PlantUML 图表转换为 draw.io 的一个页面（<diagram> 元素），支持类图、ER 图、组件图、
+// 用例图和活动图；多个页面用 DrawioFile 合并为 .drawio 文件
+func ToDrawio(doc *Document, name string) (*Conversion, error) {
+	w := &drawioWriter{doc: doc}
+	var edges []*Relation
+	switch doc.Type {
+	case DiagramClass, DiagramEntity, DiagramComponent, DiagramUseCase:
+		edges = w.elements()
+	case DiagramActivity:
+		flow, err := BuildFlow(doc)
+		if err != nil {
+			return nil, err
+		}
+		edges = w.flow(flow)
+	default:
+		return nil, fmt.Errorf("%w: %s 图无法转换为 draw.io", ErrUnsupportedConversion, doc.Type)
+	}
+
+	if name == "" {
+		name = doc.Title
+	}
+	fmt.Fprintf(&w.b, "  <diagram name=\"%s\">\n    <mxGraphModel>\n      <root>\n", html.EscapeString(name))
+	w.b.WriteString("        <mxCell id=\"0\"/>\n        <mxCell id=\"1\" parent=\"0\"/>\n")
+	for _, v := range w.vertices {
+		fmt.Fprintf(&w.b, "        <mxCell id=\"%s\" value=\"%s\" style=\"%s\" vertex=\"1\" parent=\"1\">\n",
+			v.id, html.EscapeString(v.value), v.style)
+		fmt.Fprintf(&w.b, "          <mxGeometry x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" as=\"geometry\"/>\n        </mxCell>\n",
+			v.x, v.y, v.width, v.height)
+	}
+	for i, r := range edges {
+		w.edge(fmt.Sprintf("r%d", i+1), r)
+	}
+	w.b.WriteString("      </root>\n    </mxGraphModel>\n  </diagram>\n")
+	return &Conversion{Source: w.b.String(), Dropped: w.dropped.list()}, nil
+}
+
+// DrawioFile 把 ToDrawio 生成的页面合并为 .drawio 文件
+func DrawioFile(pages ...string) string {
+	return "<mxfile host=\"ai-dev-platform\">\n" + strings.Join(pages, "") + "</mxfile>\n"
+}
+
+// elements 类图、ER 图等：每个元素一个节点，按网格排列；只作为容器的元素不单独转换
+func (w *drawioWriter) elements() []*Relation {
+	ids := make(map[*Element]string)
+	parents := make(map[string]bool)
+	for _, e := range w.doc.Elements {
+		if e.Parent != "" {
+			parents[e.Parent] = true
+		}
+	}
+	for _, e := range w.doc.Elements {
+		if parents[e.Name] {
+			w.dropped.add("容器 %s", e.Name)
+			continue
+		}
+		v := &drawioVertex{id: fmt.Sprintf("v%d", len(w.vertices)+1)}
+		switch {
+		case classKinds[e.Kind] || e.Kind == "abstract":
+			v.value, v.style, v.width, v.height = classValue(e)
+		case e.Kind == "actor" || e.Kind == "person":
+			v.value, v.style, v.width, v.height = drawioText(e.Name), "shape=umlActor;verticalLabelPosition=bottom;verticalAlign=top;html=1;", 30, 60
+		case e.Kind == "usecase":
+			v.value, v.style, v.width, v.height = drawioText(e.Name), "ellipse;whiteSpace=wrap;html=1;", 140, 70
+		case e.Kind == "database":
+			v.value, v.style, v.width, v.height = drawioText(e.Name), "shape=cylinder3;whiteSpace=wrap;html=1;", 80, 80
+		default:
+			v.value, v.style, v.width, v.height = drawioText(e.Name), "rounded=0;whiteSpace=wrap;html=1;", 140, 60
+		}
+		ids[e] = v.id
+		w.vertices = append(w.vertices, v)
+	}
+	w.grid()
+
+	var edges []*Relation
+	for _, r := range w.doc.Relations {
+		from, to := w.doc.Element(r.From), w.doc.Element(r.To)
+		if from == nil || to == nil || ids[from] == "" || ids[to] == "" {
+			w.dropped.add("关系 %s %s %s", r.From, r.Arrow, r.To)
+			continue
+		}
+		edge := *r
+		edge.From, edge.To = ids[from], ids[to]
+		edges = append(edges, &edge)
+	}
+	return edges
+}
+
+// classValue 类和实体节点：名称下方用分隔线列出成员，主键加下划线
+func classValue(e *Element) (string, string, int, int) {
+	name := html.EscapeString(e.Name)
+	if e.Stereotype != "" {
+		name = html.EscapeString("«"+e.Stereotype+"»") + "<br>" + name
+	}
+	longest := runeCount(e.Name)
+	lines := make([]string, 0, len(e.Members))
+	for _, m := range e.Members {
+		text := strings.TrimSpace(memberTagRe.ReplaceAllString(m.Text, ""))
+		if runeCount(text) > longest {
+			longest = runeCount(text)
+		}
+		text = drawioText(text)
+		if m.PrimaryKey {
+			text = "<u>" + text + "</u>"
+		}
+		lines = append(lines, text)
+	}
+	value := "<b>" + name + "</b>"
+	if len(lines) > 0 {
+		value += "<hr>" + strings.Join(lines, "<br>")
+	}
+	width := min(max(longest*8+20, 120), 360)
+	height := 40 + len(lines)*drawioLineHeight
+	return value, "rounded=0;whiteSpace=wrap;html=1;align=left;verticalAlign=top;spacingLeft=6;", width, height
+}
+
+// grid 按近似正方形的网格排列节点，每列宽度取列中最宽的节点
+func (w *drawioWriter) grid() {
+	n := len(w.vertices)
+	if n == 0 {
+		return
+	}
+	columns := int(math.Ceil(math.Sqrt(float64(n))))
+	widths := make([]int, columns)
+	heights := make([]int, (n+columns-1)/columns)
+	for i, v := range w.vertices {
+		widths[i%columns] = max(widths[i%columns], v.width)
+		heights[i/columns] = max(heights[i/columns], v.height)
+	}
+	for i, v := range w.vertices {
+		col, row := i%columns, i/columns
+		v.x, v.y = drawioGapX, drawioGapY
+		for c := 0; c < col; c++ {
+			v.x += widths[c] + drawioGapX
+		}
+		for r := 0; r < row; r++ {
+			v.y += heights[r] + drawioGapY
+		}
+	}
+}
+
+// flow 活动图：节点按最长路径分层自上而下排列，回到前面节点的连线（循环）不参与分层
+func (w *drawioWriter) flow(flow *Flow) []*Relation {
+	index := make(map[string]int, len(flow.Nodes))
+	for i, n := range flow.Nodes {
+		index[n.ID] = i
+	}
+	ranks := make([]int, len(flow.Nodes))
+	for changed := true; changed; {
+		changed = false
+		for _, e := range flow.Edges {
+			if from, to := index[e.From], index[e.To]; from < to && ranks[to] < ranks[from]+1 {
+				ranks[to] = ranks[from] + 1
+				changed = true
+			}
+		}
+	}
+
+	columns := make(map[int]int)
+	for i, n := range flow.Nodes {
+		v := &drawioVertex{id: n.ID, value: drawioText(n.Text)}
+		switch n.Shape {
+		case ShapeStart:
+			v.style, v.width, v.height = "ellipse;fillColor=#000000;html=1;", 30, 30
+		case ShapeStop:
+			v.style, v.width, v.height = "ellipse;shape=doubleEllipse;fillColor=#000000;html=1;", 30, 30
+		case ShapeDecision:
+			v.style, v.width, v.height = "rhombus;whiteSpace=wrap;html=1;", 140, 80
+		default:
+			v.style, v.width, v.height = "rounded=1;whiteSpace=wrap;html=1;", 160, 50
+		}
+		column := columns[ranks[i]]
+		columns[ranks[i]]++
+		v.x = drawioGapX + column*(160+drawioGapX) + (160-v.width)/2
+		v.y = drawioGapY + ranks[i]*(80+drawioGapY)
+		w.vertices = append(w.vertices, v)
+	}
+	for _, what := range flow.Dropped {
+		w.dropped.add("%s", what)
+	}
+
+	edges := make([]*Relation, 0, len(flow.Edges))
+	for _, e := range flow.Edges {
+		edges = append(edges, &Relation{From: e.From, To: e.To, Arrow: "-->", Label: e.Label})
+	}
+	return edges
+}
+
+// edge 输出连线，箭头两端按 PlantUML 写法转换为 draw.io 样式，基数作为端点标签
+func (w *drawioWriter) edge(id string, r *Relation) {
+	a := parseArrow(r.Arrow)
+	start, end := "none", "none"
+	if s, ok := drawioArrows[a.left]; ok {
+		start = s
+	}
+	if s, ok := drawioArrows[a.right]; ok {
+		end = s
+	}
+	style := "html=1;rounded=0;startArrow=" + strings.Replace(start, "endFill", "startFill", 1) + ";endArrow=" + end + ";"
+	if a.dashed {
+		style += "dashed=1;"
+	}
+	fmt.Fprintf(&w.b, "        <mxCell id=\"%s\" value=\"%s\" style=\"%s\" edge=\"1\" parent=\"1\" source=\"%s\" target=\"%s\">\n",
+		id, html.EscapeString(drawioText(r.Label)), style, r.From, r.To)
+	w.b.WriteString("          <mxGeometry relative=\"1\" as=\"geometry\"/>\n        </mxCell>\n")
+	for i, card := range []string{r.FromCardinality, r.ToCardinality} {
+		if card == "" {
+			continue
+		}
+		x, align := -1, "left"
+		if i == 1 {
+			x, align = 1, "right"
+		}
+		fmt.Fprintf(&w.b, "        <mxCell id=\"%s-%d\" value=\"%s\" style=\"edgeLabel;resizable=0;html=1;align=%s;verticalAlign=bottom;\" vertex=\"1\" connectable=\"0\" parent=\"%s\">\n",
+			id, i+1, html.EscapeString(drawioText(card)), align, id)
+		fmt.Fprintf(&w.b, "          <mxGeometry x=\"%d\" relative=\"1\" as=\"geometry\"/>\n        </mxCell>\n", x)
+	}
+}
+
+// drawioText 节点使用 HTML 标签，文本需要转义，换行转换为 <br>
+func drawioText(s string) string {
+	return strings.NewReplacer("\n", "<br>", `\n`, "<br>").Replace(html.EscapeString(s))
+}
@@ -0,0 +1,130 @@
+package plantuml
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drawioCell 测试中解析 .drawio 文件用的单元格
+type drawioCell struct {
+	ID     string `xml:"id,attr"`
+	Value  string `xml:"value,attr"`
+	Style  string `xml:"style,attr"`
+	Vertex string `xml:"vertex,attr"`
+	Edge   string `xml:"edge,attr"`
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+type drawioFile struct {
+	Diagrams []struct {
+		Name  string       `xml:"name,attr"`
+		Cells []drawioCell `xml:"mxGraphModel>root>mxCell"`
+	} `xml:"diagram"`
+}
+
+func parseDrawio(t *testing.T, pages ...string) drawioFile {
+	var file drawioFile
+	require.NoError(t, xml.Unmarshal([]byte(DrawioFile(pages...)), &file))
+	return file
+}
+
+func TestToDrawio_Entity(t *testing.T) {
+	doc := Parse(`@startuml
+entity 用户 {
+  * id : bigint <<PK>>
+  name : varchar(50)
+}
+entity 订单 {
+  * id : bigint <<PK>>
+  user_id : bigint <<FK>>
+}
+用户 ||--o{ 订单 : 下单
+@enduml`)
+
+	result, err := ToDrawio(doc, "数据模型")
+	require.NoError(t, err)
+	assert.Empty(t, result.Dropped)
+
+	file := parseDrawio(t, result.Source)
+	require.Len(t, file.Diagrams, 1)
+	assert.Equal(t, "数据模型", file.Diagrams[0].Name)
+	cells := file.Diagrams[0].Cells
+	require.Len(t, cells, 5)
+	assert.Equal(t, "<b>用户</b><hr><u>* id : bigint</u><br>name : varchar(50)", cells[2].Value)
+	edge := cells[4]
+	assert.Equal(t, "1", edge.Edge)
+	assert.Equal(t, cells[2].ID, edge.Source)
+	assert.Equal(t, cells[3].ID, edge.Target)
+	assert.Equal(t, "下单", edge.Value)
+	assert.Contains(t, edge.Style, "startArrow=ERmandOne;")
+	assert.Contains(t, edge.Style, "endArrow=ERzeroToMany;")
+}
+
+func TestToDrawio_ClassInContainer(t *testing.T) {
+	doc := Parse(`@startuml
+package 领域 {
+  class A
+  class B
+}
+A <|-- B
+A "1" o.. "*" C
+@enduml`)
+
+	result, err := ToDrawio(doc, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"容器 领域"}, result.Dropped)
+
+	cells := parseDrawio(t, result.Source).Diagrams[0].Cells
+	var edges []drawioCell
+	for _, c := range cells {
+		if c.Edge == "1" {
+			edges = append(edges, c)
+		}
+	}
+	require.Len(t, edges, 2)
+	assert.Contains(t, edges[0].Style, "startArrow=block;startFill=0;endArrow=none;")
+	assert.Contains(t, edges[1].Style, "startArrow=diamondThin;startFill=0;")
+	assert.Contains(t, edges[1].Style, "dashed=1;")
+	// 基数作为连线的子标签
+	assert.Contains(t, result.Source, `value="*" style="edgeLabel;`)
+}
+
+func TestToDrawio_ActivityLayout(t *testing.T) {
+	doc := Parse(`@startuml
+start
+if (a?) then (是)
+  :A;
+else (否)
+  :B;
+endif
+:C;
+stop
+@enduml`)
+
+	result, err := ToDrawio(doc, "流程")
+	require.NoError(t, err)
+	cells := parseDrawio(t, result.Source).Diagrams[0].Cells
+
+	geometry := func(id string) string {
+		start := strings.Index(result.Source, `id="`+id+`"`)
+		require.GreaterOrEqual(t, start, 0)
+		line := result.Source[start:]
+		line = line[strings.Index(line, "<mxGeometry"):]
+		return line[:strings.Index(line, "/>")]
+	}
+	// A、B 在同一层，C 在两者之下
+	assert.Contains(t, geometry("n3"), `y="340"`)
+	assert.Contains(t, geometry("n4"), `y="340"`)
+	assert.Contains(t, geometry("n5"), `y="480"`)
+	assert.Equal(t, "rhombus;whiteSpace=wrap;html=1;", cells[3].Style)
+}
+
+func TestToDrawio_SequenceUnsupported(t *testing.T) {
+	_, err := ToDrawio(Parse("@startuml\nparticipant A\nA -> B : hi\n@enduml"), "")
+	assert.ErrorIs(t, err, ErrUnsupportedConversion)
+}
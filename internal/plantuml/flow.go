@@ -0,0 +1,233 @@
+package plantuml
+
+import "fmt"
+
+// 流程图节点形状
+const (
+	ShapeStart    = "start"
+	ShapeStop     = "stop"
+	ShapeAction   = "action"
+	ShapeDecision = "decision"
+)
+
+// FlowNode 流程图节点
+type FlowNode struct {
+	ID    string
+	Shape string
+	Text  string
+}
+
+// FlowEdge 流程图连线
+type FlowEdge struct {
+	From  string
+	To    string
+	Label string
+}
+
+// Flow 由活动图节点序列还原出的流程图
+type Flow struct {
+	Nodes   []*FlowNode
+	Edges   []*FlowEdge
+	Dropped []string // 无法表示而丢弃的结构
+}
+
+// flowTail 尚未连接到下一个节点的出口
+type flowTail struct {
+	id    string
+	label string
+}
+
+// flowFrame 尚未结束的条件、循环或并行块
+type flowFrame struct {
+	kind     string
+	decision string     // if、while、switch 的判断节点
+	origin   []flowTail // fork、split 各分支的起点
+	ends     []flowTail // 已结束分支的出口
+	hasElse  bool
+	target   string // repeat 循环体的第一个节点
+}
+
+type flowBuilder struct {
+	flow    *Flow
+	tails   []flowTail
+	frames  []*flowFrame
+	label   string // 箭头语句上的标签，用于下一条连线
+	dropped dropList
+}
+
+// BuildFlow 把活动图的节点序列还原为节点和连线；分区、泳道等无法表示的结构记录在 Dropped 中
+func BuildFlow(doc *Document) (*Flow, error) {
+	if doc.Type != DiagramActivity {
+		return nil, fmt.Errorf("%w: %s 不是活动图", ErrUnsupportedConversion, doc.Type)
+	}
+	b := &flowBuilder{flow: &Flow{}}
+	if hasNewSyntax(doc.Activity) {
+		for _, n := range doc.Activity {
+			b.node(n)
+		}
+	} else {
+		b.legacy(doc)
+	}
+	b.flow.Dropped = b.dropped.list()
+	return b.flow, nil
+}
+
+// hasNewSyntax 是否使用 :活动; 形式的新语法；旧语法只有 (*) --> "活动" 形式的连线
+func hasNewSyntax(nodes []*ActivityNode) bool {
+	for _, n := range nodes {
+		if n.Kind != "arrow" {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *flowBuilder) node(n *ActivityNode) {
+	switch n.Kind {
+	case "start":
+		b.connect(b.add(ShapeStart, ""))
+	case "stop", "end":
+		b.connect(b.add(ShapeStop, ""))
+		b.tails = nil
+	case "kill", "detach":
+		b.tails = nil
+	case "action":
+		b.connect(b.add(ShapeAction, n.Text))
+	case "arrow":
+		b.label = n.Text
+	case "if", "while", "switch":
+		id := b.add(ShapeDecision, n.Text)
+		b.connect(id)
+		b.frames = append(b.frames, &flowFrame{kind: n.Kind, decision: id})
+		b.tails = nil
+		if n.Kind != "switch" {
+			b.tails = []flowTail{{id: id, label: n.Label}}
+		}
+	case "elseif":
+		if f := b.frame("if"); f != nil {
+			f.ends = append(f.ends, b.tails...)
+			id := b.add(ShapeDecision, n.Text)
+			b.flow.Edges = append(b.flow.Edges, &FlowEdge{From: f.decision, To: id})
+			f.decision = id
+			b.tails = []flowTail{{id: id, label: n.Label}}
+		}
+	case "else":
+		if f := b.frame("if"); f != nil {
+			f.ends = append(f.ends, b.tails...)
+			f.hasElse = true
+			b.tails = []flowTail{{id: f.decision, label: n.Label}}
+		}
+	case "endif":
+		if f := b.pop("if"); f != nil {
+			b.tails = append(f.ends, b.tails...)
+			if !f.hasElse {
+				b.tails = append(b.tails, flowTail{id: f.decision})
+			}
+		}
+	case "endwhile":
+		if f := b.pop("while"); f != nil {
+			b.connect(f.decision)
+			b.tails = []flowTail{{id: f.decision, label: n.Label}}
+		}
+	case "case":
+		if f := b.frame("switch"); f != nil {
+			f.ends = append(f.ends, b.tails...)
+			b.tails = []flowTail{{id: f.decision, label: n.Text}}
+		}
+	case "endswitch":
+		if f := b.pop("switch"); f != nil {
+			b.tails = append(f.ends, b.tails...)
+		}
+	case "repeat":
+		b.frames = append(b.frames, &flowFrame{kind: "repeat"})
+	case "repeat_while":
+		if f := b.pop("repeat"); f != nil {
+			id := b.add(ShapeDecision, n.Text)
+			b.connect(id)
+			if f.target != "" {
+				b.flow.Edges = append(b.flow.Edges, &FlowEdge{From: id, To: f.target, Label: n.Label})
+			}
+			b.tails = []flowTail{{id: id}}
+		}
+	case "fork", "split":
+		b.frames = append(b.frames, &flowFrame{kind: n.Kind, origin: b.tails})
+	case "fork_again", "split_again":
+		if f := b.frame(n.Kind[:len(n.Kind)-len("_again")]); f != nil {
+			f.ends = append(f.ends, b.tails...)
+			b.tails = f.origin
+		}
+	case "end_fork", "end_split":
+		if f := b.pop(n.Kind[len("end_"):]); f != nil {
+			b.tails = append(f.ends, b.tails...)
+		}
+	case "partition":
+		b.dropped.add("分区 %s", n.Text)
+	case "swimlane":
+		b.dropped.add("泳道 %s", n.Text)
+	case "break":
+		b.dropped.add("break 语句")
+	}
+}
+
+// legacy 旧语法的活动图：按连线逐条还原，(*) 在左侧为开始、在右侧为结束
+func (b *flowBuilder) legacy(doc *Document) {
+	ids := make(map[string]string)
+	endpoint := func(name string, left bool) string {
+		key, shape, text := name, ShapeAction, name
+		if name == "(*)" || name == "(*top)" {
+			key, shape, text = "(*)stop", ShapeStop, ""
+			if left {
+				key, shape = "(*)start", ShapeStart
+			}
+		}
+		if ids[key] == "" {
+			ids[key] = b.add(shape, text)
+		}
+		return ids[key]
+	}
+	for _, r := range doc.Relations {
+		from := endpoint(r.From, true)
+		to := endpoint(r.To, false)
+		b.flow.Edges = append(b.flow.Edges, &FlowEdge{From: from, To: to, Label: r.Label})
+	}
+}
+
+// add 新增节点，作为尚未确定起点的 repeat 循环体的第一个节点
+func (b *flowBuilder) add(shape, text string) string {
+	id := fmt.Sprintf("n%d", len(b.flow.Nodes)+1)
+	b.flow.Nodes = append(b.flow.Nodes, &FlowNode{ID: id, Shape: shape, Text: text})
+	for _, f := range b.frames {
+		if f.kind == "repeat" && f.target == "" {
+			f.target = id
+		}
+	}
+	return id
+}
+
+// connect 把所有出口连接到节点 id，箭头语句上的标签用于没有分支标签的连线
+func (b *flowBuilder) connect(id string) {
+	for _, t := range b.tails {
+		label := t.label
+		if label == "" {
+			label = b.label
+		}
+		b.flow.Edges = append(b.flow.Edges, &FlowEdge{From: t.id, To: id, Label: label})
+	}
+	b.label = ""
+	b.tails = []flowTail{{id: id}}
+}
+
+func (b *flowBuilder) frame(kind string) *flowFrame {
+	if len(b.frames) == 0 || b.frames[len(b.frames)-1].kind != kind {
+		return nil
+	}
+	return b.frames[len(b.frames)-1]
+}
+
+func (b *flowBuilder) pop(kind string) *flowFrame {
+	f := b.frame(kind)
+	if f != nil {
+		b.frames = b.frames[:len(b.frames)-1]
+	}
+	return f
+}
@@ -0,0 +1,406 @@
+package plantuml
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// generatedIDRe ToMermaid 为不能作为 Mermaid 标识的名称生成的标识
+var generatedIDRe = regexp.MustCompile(`^e[0-9]+$`)
+
+type plantumlWriter struct {
+	doc     *Document
+	b       strings.Builder
+	ids     *idMapper
+	dropped *dropList
+}
+
+// FromMermaid 把 Mermaid 源码转换为 PlantUML，支持序列图、类图、ER 图和流程图（转换为活动图）；
+// PlantUML 无法表示的结构记录在 Dropped 中
+func FromMermaid(source string) (*Conversion, error) {
+	doc, p := parseMermaid(source)
+	w := &plantumlWriter{doc: doc, ids: newIDMapper(doc, "e"), dropped: &p.dropped}
+	// 从 PlantUML 转换来的 e1、e2 等标识还原为原来的名称，数据模型图同步回实体时以标识为表名
+	for _, e := range doc.Elements {
+		if generatedIDRe.MatchString(e.Alias) && plainNameRe.MatchString(e.Name) && !w.ids.used[e.Name] {
+			w.ids.ids[e] = e.Name
+			w.ids.used[e.Name] = true
+		}
+	}
+	w.line(0, "@startuml")
+	if doc.Title != "" {
+		w.line(0, "title %s", plantumlText(doc.Title))
+	}
+
+	switch doc.Type {
+	case DiagramSequence:
+		w.sequence()
+	case DiagramClass:
+		w.class(p.direction)
+	case DiagramEntity:
+		w.entity()
+	case DiagramActivity:
+		w.activity()
+	default:
+		return nil, fmt.Errorf("%w: 只支持 Mermaid 的序列图、类图、ER 图和流程图", ErrUnsupportedConversion)
+	}
+	w.line(0, "@enduml")
+	return &Conversion{Source: w.b.String(), Dropped: w.dropped.list()}, nil
+}
+
+func (w *plantumlWriter) line(indent int, format string, args ...interface{}) {
+	w.b.WriteString(strings.Repeat("  ", indent))
+	w.b.WriteString(strings.TrimRight(fmt.Sprintf(format, args...), " "))
+	w.b.WriteByte('\n')
+}
+
+// decl 元素的声明：标识与名称相同时只写标识，否则为 "名称" as 标识
+func (w *plantumlWriter) decl(e *Element) string {
+	id := w.ids.element(e)
+	if id == e.Name {
+		return id
+	}
+	return quoted(e.Name) + " as " + id
+}
+
+// sequence 序列图：先声明参与者，再按源码顺序输出消息、分组和注释
+func (w *plantumlWriter) sequence() {
+	for _, e := range w.doc.Elements {
+		w.line(0, "%s %s", e.Kind, w.decl(e))
+	}
+	depth := 0
+	for _, s := range w.doc.Sequence {
+		switch s.Kind {
+		case "message":
+			r := s.Relation
+			msg := fmt.Sprintf("%s %s %s", w.ids.id(r.From), r.Arrow, w.ids.id(r.To))
+			if r.Label != "" {
+				msg += " : " + plantumlText(r.Label)
+			}
+			w.line(depth, "%s", msg)
+		case "group":
+			w.line(depth, "%s %s", s.Keyword, plantumlText(s.Text))
+			depth++
+		case "else":
+			w.line(depth-1, "else %s", plantumlText(s.Text))
+		case "end":
+			depth--
+			w.line(depth, "end")
+		case "activate", "deactivate", "destroy":
+			w.line(depth, "%s %s", s.Kind, w.ids.id(s.Target))
+		case "note":
+			position, refs, _ := strings.Cut(s.Target, " of ")
+			if refs == "" {
+				position, refs, _ = strings.Cut(s.Target, " ")
+			} else {
+				position += " of"
+			}
+			var ids []string
+			for _, ref := range strings.Split(refs, ",") {
+				ids = append(ids, w.ids.id(strings.TrimSpace(ref)))
+			}
+			w.line(depth, "note %s %s : %s", position, strings.Join(ids, ", "), plantumlText(s.Text))
+		case "autonumber":
+			w.line(depth, "autonumber")
+		}
+	}
+}
+
+// class 类图：命名空间转换为 package
+func (w *plantumlWriter) class(direction string) {
+	if direction == "LR" || direction == "RL" {
+		w.line(0, "left to right direction")
+	}
+	var packages []string
+	seen := make(map[string]bool)
+	for _, e := range w.doc.Elements {
+		if e.Parent == "" {
+			w.classDecl(0, e)
+		} else if !seen[e.Parent] {
+			seen[e.Parent] = true
+			packages = append(packages, e.Parent)
+		}
+	}
+	for _, name := range packages {
+		w.line(0, "package %s {", name)
+		for _, e := range w.doc.Elements {
+			if e.Parent == name {
+				w.classDecl(1, e)
+			}
+		}
+		w.line(0, "}")
+	}
+
+	for _, r := range w.doc.Relations {
+		var b strings.Builder
+		b.WriteString(w.ids.id(r.From))
+		if r.FromCardinality != "" {
+			b.WriteString(" " + quoted(r.FromCardinality))
+		}
+		b.WriteString(" " + r.Arrow + " ")
+		if r.ToCardinality != "" {
+			b.WriteString(quoted(r.ToCardinality) + " ")
+		}
+		b.WriteString(w.ids.id(r.To))
+		if r.Label != "" {
+			b.WriteString(" : " + plantumlText(r.Label))
+		}
+		w.line(0, "%s", b.String())
+	}
+}
+
+func (w *plantumlWriter) classDecl(indent int, e *Element) {
+	keyword := e.Kind
+	if keyword == "abstract" {
+		keyword = "abstract class"
+	}
+	decl := keyword + " " + w.decl(e)
+	if e.Stereotype != "" {
+		decl += " <<" + e.Stereotype + ">>"
+	}
+	if len(e.Members) == 0 {
+		w.line(indent, "%s", decl)
+		return
+	}
+	w.line(indent, "%s {", decl)
+	for _, m := range e.Members {
+		w.line(indent+1, "%s", m.Text)
+	}
+	w.line(indent, "}")
+}
+
+// entity ER 图：没有属性的实体也输出花括号，保证按 ER 图解析
+func (w *plantumlWriter) entity() {
+	for _, e := range w.doc.Elements {
+		w.line(0, "entity %s {", w.decl(e))
+		for _, m := range e.Members {
+			w.line(1, "%s", m.Text)
+		}
+		w.line(0, "}")
+	}
+	for _, r := range w.doc.Relations {
+		w.line(0, "%s %s %s : %s", w.ids.id(r.From), r.Arrow, w.ids.id(r.To), plantumlText(r.Label))
+	}
+}
+
+// activity 流程图转换为活动图：分支能嵌套还原时使用新语法，含循环或交叉分支时使用 (*) --> "活动" 的旧语法
+func (w *plantumlWriter) activity() {
+	a := &activityWriter{doc: w.doc, out: make(map[*Element][]*Relation), in: make(map[*Element]int), done: make(map[*Element]bool)}
+	for _, r := range w.doc.Relations {
+		from, to := w.doc.Element(r.From), w.doc.Element(r.To)
+		a.out[from] = append(a.out[from], r)
+		a.in[to]++
+	}
+	if a.structured() {
+		w.b.WriteString(a.b.String())
+		return
+	}
+
+	// 旧语法的活动以文字区分，同名节点会合并
+	names := make(map[string]*Element)
+	for _, e := range w.doc.Elements {
+		if e.Kind == ShapeDecision {
+			w.dropped.add("判断节点 %s 的菱形（流程图含循环或交叉分支，按旧版活动图语法转换）", e.Name)
+		}
+		if len(a.out[e]) == 0 && a.in[e] == 0 {
+			w.dropped.add("孤立节点 %s", e.Name)
+		}
+		if e.Kind != ShapeStart && e.Kind != ShapeStop {
+			if other := names[e.Name]; other != nil && other != e {
+				w.dropped.add("同名节点 %s 合并为一个", e.Name)
+			}
+			names[e.Name] = e
+		}
+	}
+	// 没有入口的节点从 (*) 开始，没有出口的节点连到 (*) 结束
+	for _, e := range w.doc.Elements {
+		if a.in[e] == 0 && len(a.out[e]) > 0 && e.Kind != ShapeStart {
+			w.line(0, "(*) --> %s", quoted(e.Name))
+		}
+	}
+	for _, r := range w.doc.Relations {
+		arrow := "-->"
+		if r.Label != "" {
+			arrow = "-->[" + plantumlText(r.Label) + "]"
+		}
+		w.line(0, "%s %s %s", legacyRef(w.doc.Element(r.From), true), arrow, legacyRef(w.doc.Element(r.To), false))
+	}
+	for _, e := range w.doc.Elements {
+		if len(a.out[e]) == 0 && a.in[e] > 0 && e.Kind != ShapeStop {
+			w.line(0, "%s --> (*)", quoted(e.Name))
+		}
+	}
+}
+
+// legacyRef 旧语法中的节点：左侧的开始节点和右侧的结束节点写作 (*)
+func legacyRef(e *Element, left bool) string {
+	if left && e.Kind == ShapeStart || !left && e.Kind == ShapeStop {
+		return "(*)"
+	}
+	return quoted(e.Name)
+}
+
+// activityWriter 把流程图还原为嵌套的 if、switch、fork 块
+type activityWriter struct {
+	doc  *Document
+	out  map[*Element][]*Relation
+	in   map[*Element]int
+	done map[*Element]bool
+	b    strings.Builder
+}
+
+// structured 从唯一的起点遍历流程图，每个节点只输出一次且全部输出时成功
+func (a *activityWriter) structured() bool {
+	var start *Element
+	for _, e := range a.doc.Elements {
+		if a.in[e] == 0 {
+			if start != nil {
+				return false
+			}
+			start = e
+		}
+	}
+	return start != nil && a.walk(start, nil, 0) && len(a.done) == len(a.doc.Elements)
+}
+
+// walk 从节点 n 开始顺序输出，直到汇合点 stop；再次遇到已输出的节点说明存在循环或交叉分支
+func (a *activityWriter) walk(n, stop *Element, indent int) bool {
+	for n != nil && n != stop {
+		if a.done[n] {
+			return false
+		}
+		a.done[n] = true
+		edges := a.out[n]
+		switch {
+		case n.Kind == ShapeStart:
+			a.line(indent, "start")
+		case n.Kind == ShapeStop:
+			a.line(indent, "stop")
+			return len(edges) == 0
+		case n.Kind == ShapeDecision && len(edges) > 1:
+			join := a.join(edges)
+			if !a.decision(n, edges, join, indent) {
+				return false
+			}
+			n = join
+			continue
+		default:
+			a.line(indent, ":%s;", plantumlText(n.Name))
+		}
+
+		switch len(edges) {
+		case 0:
+			return true
+		case 1:
+			if edges[0].Label != "" {
+				a.line(indent, "-> %s;", plantumlText(edges[0].Label))
+			}
+			n = a.doc.Element(edges[0].To)
+		default:
+			// 普通节点的多个出口为并行分支
+			join := a.join(edges)
+			for i, e := range edges {
+				keyword := "fork again"
+				if i == 0 {
+					keyword = "fork"
+				}
+				a.line(indent, "%s", keyword)
+				if !a.walk(a.doc.Element(e.To), join, indent+1) {
+					return false
+				}
+			}
+			a.line(indent, "end fork")
+			n = join
+		}
+	}
+	return true
+}
+
+// decision 判断节点：两个出口为 if/else，更多出口为 switch
+func (a *activityWriter) decision(n *Element, edges []*Relation, join *Element, indent int) bool {
+	if len(edges) == 2 {
+		a.line(indent, "if (%s) then%s", plantumlText(n.Name), branchText(edges[0].Label))
+		if !a.walk(a.doc.Element(edges[0].To), join, indent+1) {
+			return false
+		}
+		a.line(indent, "else%s", branchText(edges[1].Label))
+		if !a.walk(a.doc.Element(edges[1].To), join, indent+1) {
+			return false
+		}
+		a.line(indent, "endif")
+		return true
+	}
+
+	a.line(indent, "switch (%s)", plantumlText(n.Name))
+	for i, e := range edges {
+		label := e.Label
+		if label == "" {
+			label = fmt.Sprintf("分支%d", i+1)
+		}
+		a.line(indent, "case (%s)", plantumlText(label))
+		if !a.walk(a.doc.Element(e.To), join, indent+1) {
+			return false
+		}
+	}
+	a.line(indent, "endswitch")
+	return true
+}
+
+// join 各分支的汇合点：按第一个分支的广度优先顺序，第一个所有分支都能到达的节点；分支各自结束时为 nil
+func (a *activityWriter) join(edges []*Relation) *Element {
+	var order []*Element
+	reach := make([]map[*Element]bool, len(edges))
+	for i, e := range edges {
+		reach[i] = make(map[*Element]bool)
+		queue := []*Element{a.doc.Element(e.To)}
+		reach[i][queue[0]] = true
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			if i == 0 {
+				order = append(order, n)
+			}
+			for _, r := range a.out[n] {
+				if next := a.doc.Element(r.To); !reach[i][next] {
+					reach[i][next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+	for _, n := range order {
+		common := true
+		for _, r := range reach[1:] {
+			common = common && r[n]
+		}
+		if common {
+			return n
+		}
+	}
+	return nil
+}
+
+func (a *activityWriter) line(indent int, format string, args ...interface{}) {
+	a.b.WriteString(strings.Repeat("  ", indent))
+	a.b.WriteString(fmt.Sprintf(format, args...))
+	a.b.WriteByte('\n')
+}
+
+// branchText 分支标签，如 then (是)；没有标签时为空
+func branchText(label string) string {
+	if label == "" {
+		return ""
+	}
+	return " (" + plantumlText(label) + ")"
+}
+
+// plantumlText 转义文本：换行写作 \n
+func plantumlText(s string) string {
+	return strings.NewReplacer("\r\n", `\n`, "\n", `\n`).Replace(strings.TrimSpace(s))
+}
+
+// quoted 加引号的名称，名称中的双引号改为单引号
+func quoted(s string) string {
+	return `"` + strings.ReplaceAll(plantumlText(s), `"`, "'") + `"`
+}
@@ -0,0 +1,201 @@
+package plantuml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromMermaid_Sequence(t *testing.T) {
+	result, err := FromMermaid(`sequenceDiagram
+    actor U as 用户
+    U->>+OS: 提交订单
+    alt 库存充足
+        OS-->>DB: 扣减
+    else 库存不足
+        OS-)U: 失败
+    end
+    OS-->>-U: 完成
+    Note over U,OS: 结束
+    rect rgb(0,0,0)
+    U->>OS: 重试
+    end`)
+
+	require.NoError(t, err)
+	assert.Equal(t, `@startuml
+actor "用户" as U
+participant OS
+participant DB
+U -> OS : 提交订单
+activate OS
+alt 库存充足
+  OS --> DB : 扣减
+else 库存不足
+  OS ->> U : 失败
+end
+OS --> U : 完成
+deactivate OS
+note over U, OS : 结束
+U -> OS : 重试
+@enduml
+`, result.Source)
+	assert.Equal(t, []string{"rect 背景色"}, result.Dropped)
+	assert.Empty(t, Parse(result.Source).Diagnostics)
+}
+
+func TestFromMermaid_LostMessage(t *testing.T) {
+	result, err := FromMermaid("sequenceDiagram\n    Alice-xJohn: 丢失")
+	require.NoError(t, err)
+	assert.Contains(t, result.Source, "Alice ->x John : 丢失")
+
+	doc := Parse(result.Source)
+	assert.Empty(t, doc.Diagnostics)
+	require.Len(t, doc.Relations, 1)
+	assert.Equal(t, "John", doc.Relations[0].To)
+
+	back, err := Convert(result.Source, SyntaxPlantUML, SyntaxMermaid)
+	require.NoError(t, err)
+	assert.Contains(t, back.Source, "Alice-xJohn: 丢失")
+	assert.NotContains(t, back.Source, "x John")
+}
+
+func TestFromMermaid_Class(t *testing.T) {
+	result, err := FromMermaid(`classDiagram
+    class Animal {
+        <<abstract>>
+        +speak()* String
+    }
+    namespace domain {
+        class Dog
+    }
+    Dog --|> Animal
+    Animal "1" *-- "many" Leg : has
+    style Dog fill:#f9f`)
+
+	require.NoError(t, err)
+	assert.Equal(t, `@startuml
+abstract class Animal {
+  +{abstract} speak() : String
+}
+class Leg
+package domain {
+  class Dog
+}
+Dog --|> Animal
+Animal "1" *-- "many" Leg : has
+@enduml
+`, result.Source)
+	assert.Equal(t, []string{"style 语句"}, result.Dropped)
+
+	doc := Parse(result.Source)
+	assert.Empty(t, doc.Diagnostics)
+	assert.Equal(t, DiagramClass, doc.Type)
+}
+
+func TestFromMermaid_Entity(t *testing.T) {
+	result, err := FromMermaid(`erDiagram
+    CUSTOMER ||--o{ ORDER : places
+    CUSTOMER {
+        string id PK "编号"
+    }`)
+
+	require.NoError(t, err)
+	assert.Equal(t, `@startuml
+entity CUSTOMER {
+  * id : string <<PK>>
+}
+entity ORDER {
+}
+CUSTOMER ||--o{ ORDER : places
+@enduml
+`, result.Source)
+	assert.Equal(t, []string{"属性注释 CUSTOMER.id"}, result.Dropped)
+	assert.Equal(t, DiagramEntity, Parse(result.Source).Type)
+}
+
+func TestFromMermaid_Flowchart(t *testing.T) {
+	result, err := FromMermaid(`flowchart TD
+    A((开始)) --> B[提交订单] --> C{库存充足?}
+    C -->|是| D[发货]
+    C -->|否| E[退款]
+    D --> F((结束))
+    E --> F`)
+
+	require.NoError(t, err)
+	assert.Equal(t, `@startuml
+start
+:提交订单;
+if (库存充足?) then (是)
+  :发货;
+else (否)
+  :退款;
+endif
+stop
+@enduml
+`, result.Source)
+	assert.Empty(t, result.Dropped)
+}
+
+func TestFromMermaid_FlowchartLoop(t *testing.T) {
+	result, err := FromMermaid(`flowchart TD
+    A[读取] --> B{还有?}
+    B -->|Yes| A
+    B -->|No| C[结束]`)
+
+	require.NoError(t, err)
+	assert.Equal(t, `@startuml
+"读取" --> "还有?"
+"还有?" -->[Yes] "读取"
+"还有?" -->[No] "结束"
+"结束" --> (*)
+@enduml
+`, result.Source)
+	assert.Equal(t, []string{"判断节点 还有? 的菱形（流程图含循环或交叉分支，按旧版活动图语法转换）"}, result.Dropped)
+
+	doc := Parse(result.Source)
+	require.Empty(t, doc.Diagnostics)
+	assert.Equal(t, DiagramActivity, doc.Type)
+	assert.Equal(t, "Yes", doc.Relations[1].Label)
+	assert.Equal(t, "No", doc.Relations[2].Label)
+}
+
+func TestFromMermaid_Unsupported(t *testing.T) {
+	_, err := FromMermaid("gantt\n  title 计划")
+	assert.ErrorIs(t, err, ErrUnsupportedConversion)
+}
+
+func TestConvert(t *testing.T) {
+	result, err := Convert("@startuml\nA -> B : hi\n@enduml", SyntaxPlantUML, SyntaxMermaid)
+	require.NoError(t, err)
+	assert.Equal(t, "sequenceDiagram\n    participant A\n    participant B\n    A->>B: hi\n", result.Source)
+
+	result, err = Convert(result.Source, SyntaxMermaid, SyntaxPlantUML)
+	require.NoError(t, err)
+	assert.Equal(t, "@startuml\nparticipant A\nparticipant B\nA -> B : hi\n@enduml\n", result.Source)
+
+	result, err = Convert("flowchart LR\n  A --> B", SyntaxMermaid, SyntaxMermaid)
+	require.NoError(t, err)
+	assert.Equal(t, "flowchart LR\n  A --> B", result.Source)
+}
+
+func TestConvert_RestoresNames(t *testing.T) {
+	source := `@startuml
+entity 用户 {
+  * id : bigint <<PK>>
+}
+entity 订单 {
+  * id : bigint <<PK>>
+  user_id : bigint <<FK>>
+}
+用户 ||--o{ 订单 : 下单
+@enduml
+`
+	mermaid, err := Convert(source, SyntaxPlantUML, SyntaxMermaid)
+	require.NoError(t, err)
+	assert.Contains(t, mermaid.Source, `e1["用户"] {`)
+
+	back, err := Convert(mermaid.Source, SyntaxMermaid, SyntaxPlantUML)
+	require.NoError(t, err)
+	assert.Equal(t, source, back.Source)
+}
@@ -0,0 +1,230 @@
+package plantuml
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// 支持的输出格式
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+	FormatTXT = "txt"
+)
+
+// 默认配置
+const (
+	DefaultWorkers = 2
+	DefaultTimeout = 30 * time.Second
+)
+
+// ErrUnavailable 本地渲染不可用（进程无法启动、排队或渲染超时、渲染器已关闭），调用方可以改用服务器渲染
+var ErrUnavailable = errors.New("本地PlantUML渲染不可用")
+
+// maxStderr 错误信息中保留的标准错误输出长度
+const maxStderr = 2048
+
+// Config 本地渲染配置
+type Config struct {
+	Executable string        // plantuml 可执行文件，优先于 JarPath
+	JarPath    string        // plantuml.jar 路径
+	JavaPath   string        // 运行 jar 的 java，默认 java
+	Workers    int           // 同时运行的渲染进程数
+	Timeout    time.Duration // 单次渲染超时，包含排队时间
+}
+
+// RenderError PlantUML 进程执行完成但渲染失败（通常是图表语法错误），重试或改用服务器渲染不会得到不同的结果
+type RenderError struct {
+	ExitCode int
+	Stderr   string
+}
+
+func (e *RenderError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("PlantUML渲染失败，退出码 %d", e.ExitCode)
+	}
+	return fmt.Sprintf("PlantUML渲染失败，退出码 %d: %s", e.ExitCode, e.Stderr)
+}
+
+// Stats 工作池状态
+type Stats struct {
+	Workers  int   `json:"workers"`
+	Busy     int64 `json:"busy"`     // 正在渲染的进程数
+	Waiting  int64 `json:"waiting"`  // 排队等待的请求数
+	Rendered int64 `json:"rendered"` // 成功渲染的次数
+	Failed   int64 `json:"failed"`   // 失败的次数（含超时）
+}
+
+// Renderer 本地渲染器，经标准输入输出传递图表源码和渲染结果；最多同时运行 Workers 个 PlantUML 进程，超出的请求排队等待
+type Renderer struct {
+	command []string
+	timeout time.Duration
+	slots   chan struct{}
+	done    chan struct{}
+	closed  int32
+
+	busy     int64
+	waiting  int64
+	rendered int64
+	failed   int64
+}
+
+// NewRenderer 创建本地渲染器，可执行文件或 jar 不存在时返回错误
+func NewRenderer(cfg Config) (*Renderer, error) {
+	var command []string
+	switch {
+	case cfg.Executable != "":
+		path, err := exec.LookPath(cfg.Executable)
+		if err != nil {
+			return nil, fmt.Errorf("找不到PlantUML可执行文件 %s: %w", cfg.Executable, err)
+		}
+		command = []string{path}
+	case cfg.JarPath != "":
+		if _, err := os.Stat(cfg.JarPath); err != nil {
+			return nil, fmt.Errorf("找不到PlantUML jar %s: %w", cfg.JarPath, err)
+		}
+		java := cfg.JavaPath
+		if java == "" {
+			java = "java"
+		}
+		path, err := exec.LookPath(java)
+		if err != nil {
+			return nil, fmt.Errorf("找不到java %s: %w", java, err)
+		}
+		command = []string{path, "-Djava.awt.headless=true", "-jar", cfg.JarPath}
+	default:
+		return nil, fmt.Errorf("未配置PlantUML可执行文件或jar")
+	}
+
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Renderer{
+		command: command,
+		timeout: timeout,
+		slots:   make(chan struct{}, workers),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// ValidFormat 是否为支持的输出格式
+func ValidFormat(format string) bool {
+	return format == FormatPNG || format == FormatSVG || format == FormatTXT
+}
+
+// Render 渲染图表源码，返回输出格式的完整内容
+func (r *Renderer) Render(ctx context.Context, source, format string) ([]byte, error) {
+	var out bytes.Buffer
+	if err := r.Stream(ctx, strings.NewReader(source), format, &out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Stream 从 source 读取图表源码写入 PlantUML 进程的标准输入，并把标准输出直接写入 w；
+// 失败时 w 中可能已有部分内容
+func (r *Renderer) Stream(ctx context.Context, source io.Reader, format string, w io.Writer) (err error) {
+	if !ValidFormat(format) {
+		return fmt.Errorf("不支持的输出格式: %s", format)
+	}
+	defer func() {
+		if err != nil {
+			atomic.AddInt64(&r.failed, 1)
+		} else {
+			atomic.AddInt64(&r.rendered, 1)
+		}
+	}()
+	if atomic.LoadInt32(&r.closed) == 1 {
+		return fmt.Errorf("%w: 渲染器已关闭", ErrUnavailable)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	atomic.AddInt64(&r.waiting, 1)
+	select {
+	case r.slots <- struct{}{}:
+		atomic.AddInt64(&r.waiting, -1)
+	case <-ctx.Done():
+		atomic.AddInt64(&r.waiting, -1)
+		return fmt.Errorf("%w: 等待渲染进程超时: %v", ErrUnavailable, ctx.Err())
+	case <-r.done:
+		atomic.AddInt64(&r.waiting, -1)
+		return fmt.Errorf("%w: 渲染器已关闭", ErrUnavailable)
+	}
+	atomic.AddInt64(&r.busy, 1)
+	defer func() {
+		atomic.AddInt64(&r.busy, -1)
+		<-r.slots
+	}()
+
+	args := append(append([]string{}, r.command[1:]...), "-pipe", "-t"+format, "-charset", "UTF-8")
+	cmd := exec.CommandContext(ctx, r.command[0], args...)
+	cmd.Stdin = source
+	cmd.Stdout = w
+	var stderr bytes.Buffer
+	cmd.Stderr = &limitedWriter{buf: &stderr, limit: maxStderr}
+	// 超时被终止后，子进程遗留的管道最多再等待这么久
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w: 渲染超时: %v", ErrUnavailable, ctx.Err())
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return &RenderError{ExitCode: exitErr.ExitCode(), Stderr: strings.TrimSpace(stderr.String())}
+		}
+		return fmt.Errorf("%w: 启动PlantUML失败: %v", ErrUnavailable, err)
+	}
+	return nil
+}
+
+// Stats 返回工作池当前状态
+func (r *Renderer) Stats() Stats {
+	return Stats{
+		Workers:  cap(r.slots),
+		Busy:     atomic.LoadInt64(&r.busy),
+		Waiting:  atomic.LoadInt64(&r.waiting),
+		Rendered: atomic.LoadInt64(&r.rendered),
+		Failed:   atomic.LoadInt64(&r.failed),
+	}
+}
+
+// Close 关闭渲染器，排队中的请求立即返回 ErrUnavailable，正在运行的进程不受影响
+func (r *Renderer) Close() {
+	if atomic.CompareAndSwapInt32(&r.closed, 0, 1) {
+		close(r.done)
+	}
+}
+
+// limitedWriter 只保留前 limit 个字节，超出部分丢弃但不报错，避免进程因管道写入失败而退出
+type limitedWriter struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if remaining := l.limit - l.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			l.buf.Write(p[:remaining])
+		} else {
+			l.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
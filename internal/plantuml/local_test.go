@@ -0,0 +1,127 @@
+package plantuml
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePlantUML 生成模拟 PlantUML 的脚本：输出参数和标准输入，源码包含指令时模拟失败、超时或检查并发
+func fakePlantUML(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	script := `#!/bin/sh
+input=$(cat)
+case "$input" in
+  *SLOW*) sleep 5 ;;
+  *ERROR*) echo "Syntax Error? (line 2)" >&2; exit 200 ;;
+  *EXCLUSIVE*)
+    mkdir "` + dir + `/lock" 2>/dev/null || { echo "concurrent render" >&2; exit 3; }
+    sleep 0.2
+    rmdir "` + dir + `/lock" ;;
+esac
+printf 'args=%s\n%s' "$*" "$input"
+`
+	path := filepath.Join(dir, "plantuml")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o755))
+	return path
+}
+
+func TestNewRenderer_Validation(t *testing.T) {
+	_, err := NewRenderer(Config{})
+	assert.Error(t, err)
+	_, err = NewRenderer(Config{Executable: filepath.Join(t.TempDir(), "missing")})
+	assert.Error(t, err)
+	_, err = NewRenderer(Config{JarPath: filepath.Join(t.TempDir(), "plantuml.jar")})
+	assert.Error(t, err)
+
+	r, err := NewRenderer(Config{Executable: fakePlantUML(t)})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultWorkers, r.Stats().Workers)
+}
+
+func TestRender_StreamsThroughPipe(t *testing.T) {
+	r, err := NewRenderer(Config{Executable: fakePlantUML(t), Workers: 1})
+	require.NoError(t, err)
+
+	for _, format := range []string{FormatPNG, FormatSVG, FormatTXT} {
+		out, err := r.Render(context.Background(), "@startuml\nA -> B: 你好\n@enduml", format)
+		require.NoError(t, err)
+		assert.Equal(t, "args=-pipe -t"+format+" -charset UTF-8\n@startuml\nA -> B: 你好\n@enduml", string(out))
+	}
+
+	_, err = r.Render(context.Background(), "@startuml\n@enduml", "pdf")
+	assert.Error(t, err)
+	assert.Equal(t, int64(3), r.Stats().Rendered)
+}
+
+func TestRender_Errors(t *testing.T) {
+	r, err := NewRenderer(Config{Executable: fakePlantUML(t), Timeout: 300 * time.Millisecond})
+	require.NoError(t, err)
+
+	_, err = r.Render(context.Background(), "ERROR", FormatSVG)
+	var renderErr *RenderError
+	require.True(t, errors.As(err, &renderErr))
+	assert.Equal(t, 200, renderErr.ExitCode)
+	assert.Equal(t, "Syntax Error? (line 2)", renderErr.Stderr)
+	assert.False(t, errors.Is(err, ErrUnavailable), "语法错误不应回退到服务器渲染")
+
+	started := time.Now()
+	_, err = r.Render(context.Background(), "SLOW", FormatSVG)
+	assert.True(t, errors.Is(err, ErrUnavailable))
+	assert.Less(t, time.Since(started), 3*time.Second, "超时后进程被终止")
+
+	r.Close()
+	_, err = r.Render(context.Background(), "@startuml\n@enduml", FormatSVG)
+	assert.True(t, errors.Is(err, ErrUnavailable))
+	assert.Equal(t, int64(3), r.Stats().Failed)
+}
+
+func TestRender_ConcurrencyLimit(t *testing.T) {
+	r, err := NewRenderer(Config{Executable: fakePlantUML(t), Workers: 1})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = r.Render(context.Background(), "EXCLUSIVE", FormatTXT)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.NoError(t, err, "单个工作进程时渲染依次执行")
+	}
+	stats := r.Stats()
+	assert.Equal(t, int64(0), stats.Busy)
+	assert.Equal(t, int64(0), stats.Waiting)
+}
+
+func TestRender_QueueTimeout(t *testing.T) {
+	r, err := NewRenderer(Config{Executable: fakePlantUML(t), Workers: 1, Timeout: 200 * time.Millisecond})
+	require.NoError(t, err)
+
+	slow := make(chan error, 1)
+	go func() {
+		_, err := r.Render(context.Background(), "SLOW", FormatTXT)
+		slow <- err
+	}()
+	require.Eventually(t, func() bool { return r.Stats().Busy == 1 }, time.Second, 5*time.Millisecond)
+
+	_, err = r.Render(context.Background(), "@startuml\n@enduml", FormatTXT)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrUnavailable))
+	assert.True(t, strings.Contains(err.Error(), "等待渲染进程超时"))
+	assert.True(t, errors.Is(<-slow, ErrUnavailable))
+}
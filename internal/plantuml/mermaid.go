@@ -0,0 +1,492 @@
+package plantuml
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 转换为 Mermaid ER 图属性时类型和名称允许的字符
+var erTokenRe = regexp.MustCompile(`[^A-Za-z0-9_\-\[\]()]+`)
+
+// ER 图鸦脚端点：PlantUML 和 Mermaid 写法相同
+var (
+	erLeftEnds  = set("|o", "||", "}o", "}|")
+	erRightEnds = set("o|", "||", "o{", "|{")
+)
+
+// 类图关系两端 Mermaid 支持的符号
+var (
+	classLeftEnds  = set("<|", "*", "o", "<")
+	classRightEnds = set("|>", "*", "o", ">")
+)
+
+type mermaidWriter struct {
+	doc     *Document
+	b       strings.Builder
+	ids     *idMapper
+	dropped dropList
+}
+
+// ToMermaid 把 PlantUML 图表转换为 Mermaid 源码，支持序列图、类图、ER 图和活动图（转换为流程图）；
+// Mermaid 无法表示的结构记录在 Dropped 中
+func ToMermaid(doc *Document) (*Conversion, error) {
+	w := &mermaidWriter{doc: doc, ids: newIDMapper(doc, "e")}
+	if doc.Title != "" {
+		fmt.Fprintf(&w.b, "---\ntitle: %s\n---\n", doc.Title)
+	}
+
+	switch doc.Type {
+	case DiagramSequence:
+		w.sequence()
+	case DiagramClass:
+		w.class()
+	case DiagramEntity:
+		w.entity()
+	case DiagramActivity:
+		flow, err := BuildFlow(doc)
+		if err != nil {
+			return nil, err
+		}
+		w.flowchart(flow)
+	default:
+		return nil, fmt.Errorf("%w: %s 图无法转换为 Mermaid", ErrUnsupportedConversion, doc.Type)
+	}
+	return &Conversion{Source: w.b.String(), Dropped: w.dropped.list()}, nil
+}
+
+func (w *mermaidWriter) line(indent int, format string, args ...interface{}) {
+	w.b.WriteString(strings.Repeat("    ", indent))
+	w.b.WriteString(strings.TrimRight(fmt.Sprintf(format, args...), " "))
+	w.b.WriteByte('\n')
+}
+
+// sequence 序列图：先按声明顺序列出参与者，再按源码顺序输出消息和分组
+func (w *mermaidWriter) sequence() {
+	w.line(0, "sequenceDiagram")
+	for _, e := range w.doc.Elements {
+		keyword := "participant"
+		switch e.Kind {
+		case "actor":
+			keyword = "actor"
+		case "participant":
+		default:
+			w.dropped.add("参与者 %s 的类型 %s", e.Name, e.Kind)
+		}
+		if id := w.ids.element(e); id != e.Name {
+			w.line(1, "%s %s as %s", keyword, id, mermaidText(e.Name))
+		} else {
+			w.line(1, "%s %s", keyword, id)
+		}
+	}
+
+	var groups []string
+	for _, s := range w.doc.Sequence {
+		indent := len(groups) + 1
+		switch s.Kind {
+		case "message":
+			w.message(indent, s.Relation)
+		case "group":
+			keyword := s.Keyword
+			switch keyword {
+			case "par2":
+				keyword = "par"
+			case "group":
+				keyword = "opt"
+				w.dropped.add("group 分组（按 opt 转换）")
+			}
+			w.line(indent, "%s %s", keyword, mermaidText(s.Text))
+			groups = append(groups, keyword)
+		case "else":
+			if len(groups) == 0 {
+				continue
+			}
+			switch groups[len(groups)-1] {
+			case "alt":
+				w.line(indent-1, "else %s", mermaidText(s.Text))
+			case "par":
+				w.line(indent-1, "and %s", mermaidText(s.Text))
+			case "critical":
+				w.line(indent-1, "option %s", mermaidText(s.Text))
+			default:
+				w.dropped.add("%s 分组中的 else 分支", groups[len(groups)-1])
+			}
+		case "end":
+			if len(groups) > 0 {
+				groups = groups[:len(groups)-1]
+				w.line(indent-1, "end")
+			}
+		case "activate", "deactivate":
+			if id := w.ids.id(s.Target); id != "" {
+				w.line(indent, "%s %s", s.Kind, id)
+			}
+		case "note":
+			w.note(indent, s)
+		case "autonumber":
+			w.line(indent, "autonumber")
+		case "destroy":
+			w.dropped.add("destroy 语句")
+		case "divider":
+			w.dropped.add("分隔线 == %s ==", s.Text)
+		case "delay":
+			w.dropped.add("延迟 ...")
+		case "return":
+			w.dropped.add("return 语句")
+		case "box":
+			w.dropped.add("box %s", s.Text)
+		}
+	}
+}
+
+// message 序列图消息：<- 等反向箭头交换两端，>> 为异步消息，x 为丢失的消息
+func (w *mermaidWriter) message(indent int, r *Relation) {
+	from, to := w.ids.id(r.From), w.ids.id(r.To)
+	if from == "" || to == "" {
+		w.dropped.add("来自或发往图外的消息")
+		return
+	}
+
+	a := r.Arrow
+	dotted := strings.Contains(a, "--")
+	trimmed := strings.TrimRight(a, "ox")
+	forward := strings.HasSuffix(trimmed, ">") || strings.HasSuffix(trimmed, "\\") || strings.HasSuffix(trimmed, "/")
+	backward := strings.HasPrefix(a, "<")
+	if backward && !forward {
+		from, to = to, from
+	}
+
+	line := "-"
+	if dotted {
+		line = "--"
+	}
+	var op string
+	switch {
+	case backward && forward:
+		op = "<<" + line + ">>"
+	case strings.HasSuffix(a, "x") || strings.HasPrefix(a, "x"):
+		op = line + "x"
+	case strings.HasSuffix(a, ">>") || strings.HasPrefix(a, "<<"):
+		op = line + ")"
+	default:
+		op = line + ">>"
+	}
+	w.line(indent, "%s%s%s: %s", from, op, to, mermaidText(r.Label))
+}
+
+// note 序列图注释，只支持 left of、right of 和 over 指定参与者的注释
+func (w *mermaidWriter) note(indent int, s *SequenceStep) {
+	lower := strings.ToLower(s.Target)
+	for _, position := range []string{"left of", "right of", "over"} {
+		if !strings.HasPrefix(lower, position+" ") {
+			continue
+		}
+		var ids []string
+		for _, ref := range strings.Split(s.Target[len(position):], ",") {
+			ref = strings.TrimSpace(ref)
+			if i := strings.IndexByte(ref, '#'); i >= 0 {
+				ref = strings.TrimSpace(ref[:i])
+			}
+			id := w.ids.id(ref)
+			if id == "" {
+				w.dropped.add("注释 %s", s.Target)
+				return
+			}
+			ids = append(ids, id)
+		}
+		w.line(indent, "Note %s %s: %s", position, strings.Join(ids, ","), mermaidText(s.Text))
+		return
+	}
+	w.dropped.add("未指定参与者的注释")
+}
+
+// class 类图：容器转换为 namespace，类的种类转换为注解
+func (w *mermaidWriter) class() {
+	w.line(0, "classDiagram")
+	classes, namespaces := w.classElements()
+	for _, e := range classes {
+		if e.Parent == "" {
+			w.classDecl(1, e)
+		}
+	}
+	for i, name := range namespaces {
+		id := name
+		if !identRe.MatchString(id) {
+			id = fmt.Sprintf("ns%d", i+1)
+		}
+		w.line(1, "namespace %s {", id)
+		for _, e := range classes {
+			if e.Parent == name {
+				w.classDecl(2, e)
+			}
+		}
+		w.line(1, "}")
+	}
+
+	for _, r := range w.doc.Relations {
+		from, to := w.ids.id(r.From), w.ids.id(r.To)
+		if from == "" || to == "" {
+			continue
+		}
+		a := parseArrow(r.Arrow)
+		left, right := a.left, a.right
+		if left != "" && !classLeftEnds[left] || right != "" && !classRightEnds[right] {
+			w.dropped.add("关系 %s %s %s 的端点样式", r.From, r.Arrow, r.To)
+			left, right = "", ">"
+		}
+		link := "--"
+		if a.dashed {
+			link = ".."
+		}
+		var b strings.Builder
+		b.WriteString(from)
+		if r.FromCardinality != "" {
+			fmt.Fprintf(&b, " %q", r.FromCardinality)
+		}
+		fmt.Fprintf(&b, " %s%s%s ", left, link, right)
+		if r.ToCardinality != "" {
+			fmt.Fprintf(&b, "%q ", r.ToCardinality)
+		}
+		b.WriteString(to)
+		if r.Label != "" {
+			fmt.Fprintf(&b, " : %s", mermaidText(r.Label))
+		}
+		w.line(1, "%s", b.String())
+	}
+}
+
+// classElements 类和作为 namespace 输出的容器；Mermaid 的 namespace 不能嵌套，只保留类的直接容器
+func (w *mermaidWriter) classElements() ([]*Element, []string) {
+	var classes []*Element
+	var namespaces []string
+	seen := make(map[string]bool)
+	for _, e := range w.doc.Elements {
+		if !classKinds[e.Kind] && e.Kind != "abstract" {
+			if !containerKinds[e.Kind] {
+				w.dropped.add("%s %s", e.Kind, e.Name)
+			}
+			continue
+		}
+		classes = append(classes, e)
+		if e.Parent != "" && !seen[e.Parent] {
+			seen[e.Parent] = true
+			namespaces = append(namespaces, e.Parent)
+		}
+	}
+	return classes, namespaces
+}
+
+func (w *mermaidWriter) classDecl(indent int, e *Element) {
+	id := w.ids.element(e)
+	decl := "class " + id
+	if id != e.Name {
+		decl += fmt.Sprintf("[\"%s\"]", mermaidText(e.Name))
+	}
+
+	var annotation string
+	switch {
+	case e.Stereotype != "":
+		annotation = e.Stereotype
+	case e.Kind == "interface", e.Kind == "abstract", e.Kind == "entity":
+		annotation = e.Kind
+	case e.Kind == "enum":
+		annotation = "enumeration"
+	}
+	if annotation == "" && len(e.Members) == 0 {
+		w.line(indent, "%s", decl)
+		return
+	}
+
+	w.line(indent, "%s {", decl)
+	if annotation != "" {
+		w.line(indent+1, "<<%s>>", annotation)
+	}
+	for _, m := range e.Members {
+		w.line(indent+1, "%s", mermaidMember(m))
+	}
+	w.line(indent, "}")
+}
+
+// mermaidMember 类成员：字段为 类型 名称，方法为 名称(参数) 返回类型，泛型 <T> 写作 ~T~
+func mermaidMember(m *Member) string {
+	var b strings.Builder
+	b.WriteString(m.Visibility)
+	if m.Method {
+		args := ""
+		if i, j := strings.IndexByte(m.Text, '('), strings.LastIndexByte(m.Text, ')'); i >= 0 && j > i {
+			args = m.Text[i+1 : j]
+		}
+		fmt.Fprintf(&b, "%s(%s)", m.Name, args)
+		if m.Type != "" {
+			b.WriteString(" " + m.Type)
+		}
+	} else {
+		if m.Type != "" {
+			b.WriteString(m.Type + " ")
+		}
+		b.WriteString(m.Name)
+	}
+
+	lower := strings.ToLower(m.Text)
+	switch {
+	case strings.Contains(lower, "{static}") || strings.Contains(lower, "{classifier}"):
+		b.WriteString("$")
+	case strings.Contains(lower, "{abstract}"):
+		b.WriteString("*")
+	}
+	return strings.NewReplacer("<", "~", ">", "~").Replace(b.String())
+}
+
+// entity ER 图：实体属性转换为 类型 名称 PK/FK，关系保留鸦脚基数
+func (w *mermaidWriter) entity() {
+	w.line(0, "erDiagram")
+	for _, e := range w.doc.Elements {
+		if !classKinds[e.Kind] {
+			w.dropped.add("%s %s", e.Kind, e.Name)
+			continue
+		}
+		id := w.ids.element(e)
+		decl := id
+		if id != e.Name {
+			decl += fmt.Sprintf("[\"%s\"]", mermaidText(e.Name))
+		}
+		var attributes []*Member
+		for _, m := range e.Members {
+			if !m.Method && m.Name != "" {
+				attributes = append(attributes, m)
+			}
+		}
+		if len(attributes) == 0 {
+			w.line(1, "%s", decl)
+			continue
+		}
+		w.line(1, "%s {", decl)
+		for _, m := range attributes {
+			w.line(2, "%s", erAttribute(m))
+		}
+		w.line(1, "}")
+	}
+
+	for _, r := range w.doc.Relations {
+		from, to := w.ids.id(r.From), w.ids.id(r.To)
+		if from == "" || to == "" {
+			continue
+		}
+		a := parseArrow(r.Arrow)
+		left, right := a.left, a.right
+		if !erLeftEnds[left] {
+			left = erCardinality(r.FromCardinality, true)
+		}
+		if !erRightEnds[right] {
+			right = erCardinality(r.ToCardinality, false)
+		}
+		if left == "" || right == "" {
+			w.dropped.add("关系 %s %s %s 的基数（按多对多转换）", r.From, r.Arrow, r.To)
+			if left == "" {
+				left = "}o"
+			}
+			if right == "" {
+				right = "o{"
+			}
+		}
+		link := "--"
+		if a.dashed {
+			link = ".."
+		}
+		w.line(1, "%s %s%s%s %s : \"%s\"", from, left, link, right, to, mermaidText(r.Label))
+	}
+}
+
+// erAttribute ER 图属性，没有类型时使用 string
+func erAttribute(m *Member) string {
+	typ := erToken(m.Type)
+	if typ == "" {
+		typ = "string"
+	}
+	attr := typ + " " + erToken(m.Name)
+	var keys []string
+	if m.PrimaryKey {
+		keys = append(keys, "PK")
+	}
+	if m.ForeignKey {
+		keys = append(keys, "FK")
+	}
+	if len(keys) > 0 {
+		attr += " " + strings.Join(keys, ", ")
+	}
+	return attr
+}
+
+func erToken(s string) string {
+	s = strings.Trim(erTokenRe.ReplaceAllString(strings.TrimSpace(s), "_"), "_")
+	if s != "" && (s[0] >= '0' && s[0] <= '9' || s[0] == '-') {
+		s = "_" + s
+	}
+	return s
+}
+
+// erCardinality 由 "1"、"0..*" 等基数文字推断鸦脚端点
+func erCardinality(card string, left bool) string {
+	var end string
+	switch strings.ReplaceAll(card, " ", "") {
+	case "1", "1..1":
+		end = "||"
+	case "0..1":
+		end = "|o"
+	case "*", "0..*", "n", "N", "0..n":
+		end = "}o"
+	case "1..*", "1..n":
+		end = "}|"
+	default:
+		return ""
+	}
+	if left {
+		return end
+	}
+	return strings.ReplaceAll(reverse(end), "}", "{")
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+// flowchart 活动图转换为自上而下的流程图
+func (w *mermaidWriter) flowchart(flow *Flow) {
+	w.line(0, "flowchart TD")
+	for _, n := range flow.Nodes {
+		switch n.Shape {
+		case ShapeStart:
+			w.line(1, "%s((开始))", n.ID)
+		case ShapeStop:
+			w.line(1, "%s(((结束)))", n.ID)
+		case ShapeDecision:
+			w.line(1, "%s{\"%s\"}", n.ID, mermaidText(n.Text))
+		default:
+			w.line(1, "%s[\"%s\"]", n.ID, mermaidText(n.Text))
+		}
+	}
+	for _, e := range flow.Edges {
+		if e.Label != "" {
+			w.line(1, "%s -->|\"%s\"| %s", e.From, mermaidText(e.Label), e.To)
+		} else {
+			w.line(1, "%s --> %s", e.From, e.To)
+		}
+	}
+	for _, what := range flow.Dropped {
+		w.dropped.add("%s", what)
+	}
+}
+
+// mermaidText 转义文本：换行转换为 <br/>，引号和分号使用实体编码
+func mermaidText(s string) string {
+	return strings.NewReplacer(
+		"\r\n", "<br/>",
+		"\n", "<br/>",
+		`\n`, "<br/>",
+		`"`, "#quot;",
+		";", "#59;",
+	).Replace(strings.TrimSpace(s))
+}
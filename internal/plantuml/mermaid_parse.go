@@ -0,0 +1,1067 @@
+package plantuml
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// mermaidHeaders 可解析和转换的 Mermaid 图表类型声明（小写）
+var mermaidHeaders = map[string]DiagramType{
+	"sequencediagram": DiagramSequence,
+	"classdiagram":    DiagramClass,
+	"classdiagram-v2": DiagramClass,
+	"erdiagram":       DiagramEntity,
+	"flowchart":       DiagramActivity,
+	"flowchart-elk":   DiagramActivity,
+	"graph":           DiagramActivity,
+}
+
+// otherMermaidHeaders 其余 Mermaid 图表类型，只检查类型声明
+var otherMermaidHeaders = set("statediagram", "statediagram-v2", "gantt", "pie", "journey", "gitgraph", "mindmap", "timeline",
+	"quadrantchart", "requirementdiagram", "c4context", "c4container", "c4component", "c4dynamic", "c4deployment",
+	"sankey-beta", "xychart-beta", "block-beta", "packet-beta", "architecture-beta", "kanban", "zenuml")
+
+// mermaidHeaderNames 图表类型声明的标准写法，用于拼写建议
+var mermaidHeaderNames = []string{"sequenceDiagram", "classDiagram", "erDiagram", "flowchart", "graph", "stateDiagram-v2", "gantt", "pie", "journey", "gitGraph", "mindmap", "timeline"}
+
+// mermaidKeywords 各类图可以出现在语句开头的关键字，用于拼写建议
+var mermaidKeywords = map[DiagramType][]string{
+	DiagramSequence: {"participant", "actor", "activate", "deactivate", "note", "loop", "alt", "else", "opt", "par", "and", "critical", "option", "break", "rect", "box", "end", "autonumber", "create", "destroy", "title"},
+	DiagramClass:    {"class", "namespace", "note", "click", "link", "callback", "style", "classDef", "cssClass", "direction", "title"},
+	DiagramEntity:   {"title"},
+	DiagramActivity: {"subgraph", "end", "direction", "style", "classDef", "class", "click", "linkStyle", "title"},
+}
+
+// 序列图分组：else 类语句只能出现在对应的分组中
+var (
+	mermaidGroups  = set("loop", "alt", "opt", "par", "critical", "break")
+	mermaidBranchs = map[string]string{"else": "alt", "and": "par", "option": "critical"}
+)
+
+// mermaidSequenceArrows Mermaid 消息箭头对应的 PlantUML 箭头
+var mermaidSequenceArrows = map[string]string{
+	"->>": "->", "-->>": "-->", "->": "->", "-->": "-->",
+	"-x": "->x", "--x": "-->x", "-)": "->>", "--)": "-->>",
+	"<<->>": "<->", "<<-->>": "<-->",
+}
+
+var (
+	mermaidMessageRe     = regexp.MustCompile(`^([^<>:,;+\-]+?)\s*(<<-->>|<<->>|-->>|->>|--x|-x|--\)|-\)|-->|->)\s*([+-]?)\s*([^<>:,;+\-]+?)\s*(?::(.*))?$`)
+	mermaidNoteRe        = regexp.MustCompile(`(?i)^note\s+(left of|right of|over)\s+([^:]+?)\s*(?::(.*))?$`)
+	mermaidParticipantRe = regexp.MustCompile(`(?i)^(?:create\s+)?(participant|actor)\s+(.+?)(?:\s+as\s+(.+))?$`)
+	mermaidClassRelRe    = regexp.MustCompile(`^(\S+?)\s*(?:"([^"]*)"\s*)?(<\||\*|o|<|\(\))?(--|\.\.)(\|>|\*|o|>|\(\))?\s*(?:"([^"]*)"\s*)?(\S+?)\s*(?::\s*(.*))?$`)
+	mermaidAnnotationRe  = regexp.MustCompile(`^<<\s*([^>]+?)\s*>>\s*(\S+)?$`)
+	mermaidClassIDRe     = regexp.MustCompile("^(?:[\\p{L}\\w.\\-]+|`[^`]+`)$")
+	mermaidEntityRe      = regexp.MustCompile(`^("[^"]+"|[\p{L}\w\-]+)(?:\s*\[\s*"?([^"\]]*)"?\s*\])?\s*(\{)?\s*(\})?$`)
+	mermaidERRelationRe  = regexp.MustCompile(`^("[^"]+"|[\p{L}\w\-]+)\s*(\|o|\|\||\}o|\}\|)(--|\.\.)(o\||\|\||o\{|\|\{)\s*("[^"]+"|[\p{L}\w\-]+)\s*(?::\s*(.*))?$`)
+	mermaidAttributeRe   = regexp.MustCompile(`^([\p{L}\w\-\[\]()]+)\s+([\p{L}\w\-\[\]()]+)((?:\s+(?:PK|FK|UK)\s*,?)*)\s*(?:"([^"]*)")?$`)
+	mermaidFlowLinkRe    = regexp.MustCompile(`^(<|o|x)?(-{2,}|={2,}|-\.+-)(>|o|x)?(?:\s*\|([^|]*)\|)?`)
+	mermaidFlowTextRe    = regexp.MustCompile(`^(<|o|x)?(--|==|-\.)\s*([^\s|>][^|]*?)\s*(-{2,}|={2,}|\.+-)(>|o|x)?`)
+	mermaidBreakRe       = regexp.MustCompile(`(?i)<br\s*/?>`)
+	mermaidEntityCodeRe  = regexp.MustCompile(`#(\d+|quot|amp|lt|gt|nbsp);`)
+	mermaidSizedTypeRe   = regexp.MustCompile(`^\S+\(\s*\d+(?:\s*,\s*\d+)?\s*\)\s+\S+$`) // 带长度的字段类型，如 varchar(50) name，不是方法
+)
+
+// flowShape 流程图节点的形状写法
+type flowShape struct {
+	open, close, name string
+}
+
+// 同一开头的形状按结束符依次尝试，较长的开头在前
+var mermaidFlowShapes = []flowShape{
+	{"(((", ")))", "double_circle"},
+	{"((", "))", "circle"},
+	{"([", "])", "stadium"},
+	{"[[", "]]", "subroutine"},
+	{"[(", ")]", "cylinder"},
+	{"[/", "/]", "parallelogram"},
+	{"[/", "\\]", "trapezoid"},
+	{"[\\", "\\]", "parallelogram_alt"},
+	{"[\\", "/]", "trapezoid_alt"},
+	{"{{", "}}", "hexagon"},
+	{"[", "]", "rect"},
+	{"(", ")", "round"},
+	{"{", "}", "rhombus"},
+	{">", "]", "asymmetric"},
+}
+
+// terminalTexts 开始、结束节点的常见文字，转换为 start、stop 时不算丢弃
+var terminalTexts = set("", "开始", "结束", "start", "end", "stop", "begin", "finish")
+
+// mermaidBlock 尚未闭合的块
+type mermaidBlock struct {
+	kind    string // 序列图分组关键字、rect、box；类主体 class、命名空间 namespace；实体主体 entity；流程图 subgraph
+	st      statement
+	width   int
+	element *Element
+	name    string // 命名空间名称
+}
+
+type mermaidParser struct {
+	doc       *Document
+	byID      map[string]*Element
+	blocks    []*mermaidBlock
+	shapes    map[*Element]string // 流程图节点的形状
+	direction string              // 类图的方向：TB、TD、BT、LR、RL
+	dropped   dropList            // 转换为 PlantUML 时无法表示的结构
+}
+
+// ParseMermaid 解析 Mermaid 源码，返回带行列位置的诊断和与 PlantUML 相同结构的语法树：
+// 箭头、类成员等统一为 PlantUML 写法，流程图按活动图处理，节点和连线保存在 Elements 和 Relations 中，
+// 节点的 Kind 为 start、stop、action 或 decision
+func ParseMermaid(source string) *Document {
+	doc, _ := parseMermaid(source)
+	return doc
+}
+
+func parseMermaid(source string) (*Document, *mermaidParser) {
+	p := &mermaidParser{
+		doc: &Document{
+			Type:        DiagramUnknown,
+			Elements:    []*Element{},
+			Relations:   []*Relation{},
+			Diagnostics: []Diagnostic{},
+		},
+		byID:   make(map[string]*Element),
+		shapes: make(map[*Element]string),
+	}
+
+	body, front := mermaidLines(source)
+	for _, st := range front {
+		if key, value, ok := strings.Cut(st.text, ":"); ok && strings.TrimSpace(key) == "title" {
+			p.doc.Title = unquote(strings.TrimSpace(value))
+		}
+	}
+	if len(body) == 0 {
+		p.doc.Diagnostics = append(p.doc.Diagnostics, Diagnostic{
+			Severity: SeverityError, Code: CodeMissingStart, Message: "缺少图表类型声明，如 sequenceDiagram、classDiagram、erDiagram 或 flowchart TD",
+			Line: 1, Column: 1, EndColumn: 2,
+		})
+		return p.doc, p
+	}
+
+	if word := mermaidHeaderWord(body[0].text); word == "sequencediagram" || mermaidHeaders[word] == DiagramActivity {
+		body = append(splitMermaidStatement(body[0]), body[1:]...)
+	}
+	if p.header(body[0]) {
+		for _, st := range body[1:] {
+			if p.doc.Type == DiagramSequence || p.doc.Type == DiagramActivity {
+				for _, part := range splitMermaidStatement(st) {
+					p.statement(part)
+				}
+				continue
+			}
+			p.statement(st)
+		}
+		p.finish()
+	}
+
+	sort.SliceStable(p.doc.Diagnostics, func(i, j int) bool {
+		a, b := p.doc.Diagnostics[i], p.doc.Diagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return p.doc, p
+}
+
+// mermaidLines 切分 Mermaid 源码的语句，跳过空行和 %% 注释；开头 --- 之间的前言块单独返回
+func mermaidLines(source string) (body, front []statement) {
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	inFront, started := false, false
+	for i, raw := range lines {
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" {
+			continue
+		}
+		if !started {
+			started = true
+			if trimmed == "---" {
+				inFront = true
+				continue
+			}
+		}
+		indent := len(raw) - len(strings.TrimLeftFunc(raw, unicode.IsSpace))
+		st := statement{line: i + 1, col: runeCount(raw[:indent]) + 1, text: trimmed, orig: trimmed}
+		if inFront {
+			if trimmed == "---" {
+				inFront = false
+			} else {
+				front = append(front, st)
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "%%") {
+			continue
+		}
+		body = append(body, st)
+	}
+	return body, front
+}
+
+// splitMermaidStatement 按引号外的分号切分一行中的多条语句，保持列位置
+func splitMermaidStatement(st statement) []statement {
+	var parts []statement
+	text, start, inQuote := st.text, 0, false
+	for i := 0; i <= len(text); i++ {
+		if i < len(text) && text[i] == '"' {
+			inQuote = !inQuote
+		}
+		if i < len(text) && (text[i] != ';' || inQuote || entityCodeEnd(text[start:i])) {
+			continue
+		}
+		part := text[start:i]
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			offset := start + strings.Index(part, trimmed)
+			parts = append(parts, statement{line: st.line, col: st.col + runeCount(text[:offset]), text: trimmed, orig: trimmed})
+		}
+		start = i + 1
+	}
+	return parts
+}
+
+// entityCodeEnd 分号是否为 #59; 等实体编码的结尾
+func entityCodeEnd(before string) bool {
+	i := strings.LastIndexByte(before, '#')
+	if i < 0 || i == len(before)-1 {
+		return false
+	}
+	for _, c := range before[i+1:] {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+// mermaidHeaderWord 语句的第一个单词（小写），图表类型声明中可以包含 - 号
+func mermaidHeaderWord(text string) string {
+	end := strings.IndexFunc(text, func(r rune) bool { return unicode.IsSpace(r) || r == ';' })
+	if end < 0 {
+		end = len(text)
+	}
+	return strings.ToLower(text[:end])
+}
+
+// header 检查图表类型声明，返回是否继续解析正文
+func (p *mermaidParser) header(st statement) bool {
+	word := mermaidHeaderWord(st.text)
+	if t, ok := mermaidHeaders[word]; ok {
+		p.doc.Type = t
+		rest := strings.TrimSpace(st.text[len(word):])
+		if t == DiagramActivity && rest != "" {
+			switch strings.ToUpper(rest) {
+			case "TB", "TD", "BT", "RL", "LR":
+				p.direction = strings.ToUpper(rest)
+			default:
+				p.diag(SeverityError, CodeSyntaxError, st, len(st.text)-len(rest), len(st.text), fmt.Sprintf("无效的流程图方向 %s，应为 TB、TD、BT、RL 或 LR", rest))
+			}
+		}
+		return true
+	}
+	if otherMermaidHeaders[word] {
+		p.diag(SeverityWarning, CodeUnsupportedDiagram, st, 0, len(word), fmt.Sprintf("暂不检查 %s 图的语法，也不支持与 PlantUML 互相转换", st.text[:len(word)]))
+		return false
+	}
+
+	msg := "缺少图表类型声明，如 sequenceDiagram、classDiagram、erDiagram 或 flowchart TD"
+	code := CodeMissingStart
+	if suggestion := closest(word, mermaidHeaderNames); suggestion != "" {
+		msg = fmt.Sprintf("未知的图表类型 %s，是否为 %s？", st.text[:len(word)], suggestion)
+		code = CodeUnknownKeyword
+	}
+	p.diag(SeverityError, code, st, 0, len(word), msg)
+	return false
+}
+
+// statement 解析一条正文语句
+func (p *mermaidParser) statement(st statement) {
+	lower := strings.ToLower(st.text)
+	first := firstWord(lower)
+	switch {
+	case first == "title" && hasWord(lower, "title"):
+		p.doc.Title = strings.TrimSpace(st.text[len("title"):])
+		return
+	case first == "acctitle" || first == "accdescr":
+		return
+	}
+
+	switch p.doc.Type {
+	case DiagramSequence:
+		p.sequence(st, first)
+	case DiagramClass:
+		p.class(st, first)
+	case DiagramEntity:
+		p.entity(st)
+	case DiagramActivity:
+		p.flowchart(st, first)
+	}
+}
+
+// ===== 序列图 =====
+
+func (p *mermaidParser) sequence(st statement, first string) {
+	text := st.text
+	rest := strings.TrimSpace(text[len(first):])
+	switch {
+	case (first == "participant" || first == "actor" || first == "create") && hasWord(strings.ToLower(text), first):
+		p.participantDecl(st)
+	case first == "activate" || first == "deactivate" || first == "destroy":
+		if rest == "" {
+			p.diag(SeverityError, CodeSyntaxError, st, 0, len(text), fmt.Sprintf("%s 缺少参与者", first))
+			return
+		}
+		e := p.participant(st, rest, len(text)-len(rest))
+		p.step(st, &SequenceStep{Kind: first, Target: e.ID()})
+	case first == "autonumber":
+		p.step(st, &SequenceStep{Kind: "autonumber"})
+	case mermaidGroups[first] && hasWord(strings.ToLower(text), first):
+		p.push(st, first, len(first))
+		p.step(st, &SequenceStep{Kind: "group", Keyword: first, Text: mermaidUnescape(rest)})
+	case first == "rect" && hasWord(strings.ToLower(text), first):
+		p.push(st, first, len(first))
+		p.dropped.add("rect 背景色")
+	case first == "box" && hasWord(strings.ToLower(text), first):
+		p.push(st, first, len(first))
+		p.dropped.add("box 分组 %s", rest)
+	case mermaidBranchs[first] != "" && hasWord(strings.ToLower(text), first):
+		group := mermaidBranchs[first]
+		if top := p.top(); top == nil || top.kind != group {
+			p.diag(SeverityError, CodeSyntaxError, st, 0, len(first), fmt.Sprintf("%s 只能用于 %s 分组", first, group))
+			return
+		}
+		p.step(st, &SequenceStep{Kind: "else", Text: mermaidUnescape(rest)})
+	case first == "end" && rest == "":
+		if len(p.blocks) == 0 {
+			p.diag(SeverityError, CodeUnmatchedEnd, st, 0, len(text), "没有与 end 对应的分组")
+			return
+		}
+		if b := p.pop(); b.kind != "rect" && b.kind != "box" {
+			p.step(st, &SequenceStep{Kind: "end"})
+		}
+	case first == "note" && hasWord(strings.ToLower(text), first):
+		p.note(st)
+	case (first == "link" || first == "links") && hasWord(strings.ToLower(text), first):
+		p.dropped.add("参与者菜单 %s", first)
+	default:
+		if !p.message(st) {
+			p.unknown(st)
+		}
+	}
+}
+
+// participantDecl participant 或 actor 声明，as 之后为显示名称
+func (p *mermaidParser) participantDecl(st statement) {
+	m := mermaidParticipantRe.FindStringSubmatch(st.text)
+	if m == nil {
+		p.diag(SeverityError, CodeSyntaxError, st, 0, len(st.text), "参与者声明缺少名称")
+		return
+	}
+	id, label := strings.TrimSpace(m[2]), strings.TrimSpace(m[3])
+	if i := strings.Index(id, "@{"); i >= 0 {
+		p.dropped.add("参与者 %s 的类型配置", strings.TrimSpace(id[:i]))
+		id = strings.TrimSpace(id[:i])
+	}
+	e := p.participant(st, id, strings.Index(st.text, id))
+	e.Kind = strings.ToLower(m[1])
+	e.Implicit = false
+	if label != "" {
+		e.Name, e.Alias = mermaidUnescape(label), id
+	}
+}
+
+// participant 按标识查找参与者，不存在时隐式创建
+func (p *mermaidParser) participant(st statement, id string, idx int) *Element {
+	id = strings.TrimSpace(id)
+	if e := p.byID[id]; e != nil {
+		return e
+	}
+	e := &Element{Kind: "participant", Name: id, Implicit: true, Pos: p.pos(st, idx)}
+	p.byID[id] = e
+	p.doc.Elements = append(p.doc.Elements, e)
+	return e
+}
+
+// message 消息语句；+ 和 - 简写在消息之后激活接收方、结束发送方的激活
+func (p *mermaidParser) message(st statement) bool {
+	text := st.text
+	m := mermaidMessageRe.FindStringSubmatchIndex(text)
+	if m == nil {
+		return false
+	}
+	from := p.participant(st, text[m[2]:m[3]], m[2])
+	op := text[m[4]:m[5]]
+	to := p.participant(st, text[m[8]:m[9]], m[8])
+	if op == "->" || op == "-->" {
+		p.dropped.add("无箭头的消息线 %s（按带箭头的消息转换）", op)
+	}
+
+	r := &Relation{From: from.ID(), To: to.ID(), Arrow: mermaidSequenceArrows[op], Pos: p.pos(st, m[2])}
+	if m[10] >= 0 {
+		r.Label = mermaidUnescape(text[m[10]:m[11]])
+	} else {
+		p.diag(SeverityError, CodeSyntaxError, st, m[4], len(text), "消息缺少 : 和消息内容")
+	}
+	p.doc.Relations = append(p.doc.Relations, r)
+	p.step(st, &SequenceStep{Kind: "message", Relation: r})
+
+	switch text[m[6]:m[7]] {
+	case "+":
+		p.step(st, &SequenceStep{Kind: "activate", Target: to.ID()})
+	case "-":
+		p.step(st, &SequenceStep{Kind: "deactivate", Target: from.ID()})
+	}
+	return true
+}
+
+// note 注释，Target 为小写的位置和参与者标识，如 over A, B
+func (p *mermaidParser) note(st statement) {
+	m := mermaidNoteRe.FindStringSubmatchIndex(st.text)
+	if m == nil {
+		p.diag(SeverityError, CodeSyntaxError, st, 0, len(st.text), "注释应为 Note left of|right of|over 参与者: 内容")
+		return
+	}
+	var ids []string
+	for _, ref := range strings.Split(st.text[m[4]:m[5]], ",") {
+		ids = append(ids, p.participant(st, ref, m[4]).ID())
+	}
+	s := &SequenceStep{Kind: "note", Target: strings.ToLower(st.text[m[2]:m[3]]) + " " + strings.Join(ids, ", ")}
+	if m[6] >= 0 {
+		s.Text = mermaidUnescape(st.text[m[6]:m[7]])
+	} else {
+		p.diag(SeverityError, CodeSyntaxError, st, 0, len(st.text), "注释缺少 : 和注释内容")
+	}
+	p.step(st, s)
+}
+
+func (p *mermaidParser) step(st statement, s *SequenceStep) {
+	s.Pos = p.pos(st, 0)
+	p.doc.Sequence = append(p.doc.Sequence, s)
+}
+
+// ===== 类图 =====
+
+func (p *mermaidParser) class(st statement, first string) {
+	text := st.text
+	if top := p.top(); top != nil && top.kind == "class" {
+		switch {
+		case text == "}":
+			p.pop()
+		case strings.HasPrefix(text, "<<"):
+			if m := mermaidAnnotationRe.FindStringSubmatch(text); m != nil && m[2] == "" {
+				annotate(top.element, m[1])
+			} else {
+				p.diag(SeverityError, CodeSyntaxError, st, 0, len(text), "无法识别的注解")
+			}
+		default:
+			top.element.Members = append(top.element.Members, classMember(text, p.pos(st, 0)))
+		}
+		return
+	}
+
+	lower := strings.ToLower(text)
+	switch {
+	case text == "}":
+		if top := p.top(); top != nil && top.kind == "namespace" {
+			p.pop()
+			return
+		}
+		p.diag(SeverityError, CodeUnmatchedEnd, st, 0, 1, "没有与 } 对应的 {")
+	case first == "class" && hasWord(lower, "class"):
+		p.classDecl(st)
+	case first == "namespace" && hasWord(lower, "namespace"):
+		name := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text[len(first):]), "{"))
+		if name == "" || !strings.HasSuffix(text, "{") {
+			p.diag(SeverityError, CodeSyntaxError, st, 0, len(text), "命名空间应为 namespace 名称 {")
+			return
+		}
+		p.push(st, "namespace", len(first)).name = name
+	case first == "direction" && hasWord(lower, "direction"):
+		p.direction = strings.ToUpper(strings.TrimSpace(text[len(first):]))
+	case first == "note" && hasWord(lower, "note"):
+		p.dropped.add("注释 note")
+	case (first == "click" || first == "link" || first == "callback" || first == "style" || first == "classdef" || first == "cssclass") && hasWord(lower, first):
+		p.dropped.add("%s 语句", first)
+	case strings.HasPrefix(text, "<<"):
+		m := mermaidAnnotationRe.FindStringSubmatch(text)
+		if m == nil || m[2] == "" {
+			p.diag(SeverityError, CodeSyntaxError, st, 0, len(text), "注解应为 <<注解>> 类名")
+			return
+		}
+		annotate(p.classElement(st, m[2], strings.LastIndex(text, m[2])), m[1])
+	default:
+		if p.classRelation(st) {
+			return
+		}
+		if i := strings.Index(text, ":"); i > 0 && mermaidClassIDRe.MatchString(strings.TrimSpace(text[:i])) {
+			if member := strings.TrimSpace(text[i+1:]); member != "" {
+				e := p.classElement(st, strings.TrimSpace(text[:i]), 0)
+				e.Members = append(e.Members, classMember(member, p.pos(st, strings.Index(text[i:], member)+i)))
+				return
+			}
+		}
+		p.unknown(st)
+	}
+}
+
+// classDecl class 声明：class 名称~泛型~["显示名称"]:::样式 {
+func (p *mermaidParser) classDecl(st statement) {
+	rest := strings.TrimSpace(st.text[len("class"):])
+	open := strings.HasSuffix(rest, "{")
+	if open {
+		rest = strings.TrimSpace(strings.TrimSuffix(rest, "{"))
+	} else if strings.HasSuffix(rest, "{}") || strings.HasSuffix(rest, "{ }") {
+		rest = strings.TrimSpace(rest[:strings.LastIndex(rest, "{")])
+	}
+	if i := strings.Index(rest, ":::"); i >= 0 {
+		p.dropped.add("样式类 %s", rest[i:])
+		rest = strings.TrimSpace(rest[:i])
+	}
+	label := ""
+	if i := strings.Index(rest, "[\""); i > 0 && strings.HasSuffix(rest, "\"]") {
+		label, rest = rest[i+2:len(rest)-2], strings.TrimSpace(rest[:i])
+	}
+	generic := ""
+	if i := strings.IndexByte(rest, '~'); i > 0 && strings.HasSuffix(rest, "~") {
+		generic, rest = "<"+genericTypes(rest[i+1:len(rest)-1])+">", rest[:i]
+	}
+	if !mermaidClassIDRe.MatchString(rest) {
+		p.diag(SeverityError, CodeSyntaxError, st, 0, len(st.text), "无效的类名")
+		return
+	}
+
+	e := p.classElement(st, rest, strings.Index(st.text, rest))
+	e.Implicit = false
+	switch {
+	case label != "":
+		e.Name, e.Alias = mermaidUnescape(label), e.ID()
+	case generic != "":
+		e.Name, e.Alias = e.ID()+generic, e.ID()
+	}
+	if open {
+		p.push(st, "class", len("class")).element = e
+	}
+}
+
+// classElement 按标识查找类，不存在时隐式创建；在命名空间中首次出现的类属于该命名空间
+func (p *mermaidParser) classElement(st statement, id string, idx int) *Element {
+	id = strings.Trim(strings.TrimSpace(id), "`")
+	if e := p.byID[id]; e != nil {
+		return e
+	}
+	e := &Element{Kind: "class", Name: id, Implicit: true, Pos: p.pos(st, idx)}
+	for i := len(p.blocks) - 1; i >= 0; i-- {
+		if p.blocks[i].kind == "namespace" {
+			e.Parent = p.blocks[i].name
+			break
+		}
+	}
+	p.byID[id] = e
+	p.doc.Elements = append(p.doc.Elements, e)
+	return e
+}
+
+// classRelation 类图关系，两端符号与 PlantUML 相同；棒棒糖接口 () 按普通连线转换
+func (p *mermaidParser) classRelation(st statement) bool {
+	m := mermaidClassRelRe.FindStringSubmatchIndex(st.text)
+	if m == nil {
+		return false
+	}
+	text := st.text
+	group := func(i int) string {
+		if m[2*i] < 0 {
+			return ""
+		}
+		return text[m[2*i]:m[2*i+1]]
+	}
+	if !mermaidClassIDRe.MatchString(group(1)) || !mermaidClassIDRe.MatchString(group(7)) {
+		return false
+	}
+	from := p.classElement(st, group(1), m[2])
+	to := p.classElement(st, group(7), m[14])
+	left, right := group(3), group(5)
+	if left == "()" || right == "()" {
+		p.dropped.add("棒棒糖接口 %s %s%s%s %s", group(1), left, group(4), right, group(7))
+		left, right = strings.TrimSuffix(left, "()"), strings.TrimSuffix(right, "()")
+	}
+	p.doc.Relations = append(p.doc.Relations, &Relation{
+		From:            from.ID(),
+		To:              to.ID(),
+		Arrow:           left + group(4) + right,
+		Label:           mermaidUnescape(group(8)),
+		FromCardinality: group(2),
+		ToCardinality:   group(6),
+		Pos:             p.pos(st, m[2]),
+	})
+	return true
+}
+
+// annotate 把注解转换为类的种类或构造型
+func annotate(e *Element, annotation string) {
+	switch strings.ToLower(annotation) {
+	case "interface":
+		e.Kind = "interface"
+	case "abstract":
+		e.Kind = "abstract"
+	case "enumeration", "enum":
+		e.Kind = "enum"
+	default:
+		e.Stereotype = annotation
+	}
+}
+
+// classMember 把 Mermaid 类成员转换为 PlantUML 写法：类型 名称 改为 名称 : 类型，~T~ 改为 <T>，
+// 结尾的 $ 和 * 改为 {static} 和 {abstract}
+func classMember(text string, pos Position) *Member {
+	s := strings.TrimSpace(text)
+	modifier := ""
+	switch {
+	case strings.HasSuffix(s, "$"):
+		modifier, s = "{static} ", strings.TrimSpace(strings.TrimSuffix(s, "$"))
+	case strings.HasSuffix(s, "*"):
+		modifier, s = "{abstract} ", strings.TrimSpace(strings.TrimSuffix(s, "*"))
+	}
+	m := &Member{Pos: pos}
+	if s != "" && strings.ContainsRune("+-#~", rune(s[0])) && !(s[0] == '~' && strings.Count(s, "~")%2 == 0) {
+		m.Visibility, s = s[:1], strings.TrimSpace(s[1:])
+	}
+	s = genericTypes(s)
+
+	if i := strings.IndexByte(s, '('); i > 0 && !mermaidSizedTypeRe.MatchString(s) {
+		m.Method = true
+		m.Name = strings.TrimSpace(s[:i])
+		args := ""
+		if j := strings.LastIndexByte(s, ')'); j > i {
+			args, m.Type = s[i+1:j], strings.TrimSpace(s[j+1:])
+			// 修饰符也可以紧跟在括号之后，如 speak()* String
+			if modifier == "" && m.Type != "" && strings.ContainsRune("$*", rune(m.Type[0])) {
+				modifier = map[byte]string{'$': "{static} ", '*': "{abstract} "}[m.Type[0]]
+				m.Type = strings.TrimSpace(m.Type[1:])
+			}
+		}
+		m.Text = m.Visibility + modifier + m.Name + "(" + args + ")"
+	} else {
+		if name, typ, ok := strings.Cut(s, ":"); ok {
+			m.Name, m.Type = strings.TrimSpace(name), strings.TrimSpace(typ)
+		} else if fields := strings.Fields(s); len(fields) > 1 {
+			m.Type, m.Name = strings.Join(fields[:len(fields)-1], " "), fields[len(fields)-1]
+		} else {
+			m.Name = s
+		}
+		m.Text = m.Visibility + modifier + m.Name
+	}
+	if m.Type != "" {
+		m.Text += " : " + m.Type
+	}
+	return m
+}
+
+// genericTypes 把 Mermaid 泛型写法 List~int~ 转换为 List<int>：前后都是标识符字符的 ~ 为开括号
+func genericTypes(s string) string {
+	if !strings.Contains(s, "~") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '~' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i > 0 && isIdentByte(s[i-1]) && i+1 < len(s) && isIdentByte(s[i+1]) {
+			b.WriteByte('<')
+		} else {
+			b.WriteByte('>')
+		}
+	}
+	return b.String()
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= utf8.RuneSelf
+}
+
+// ===== ER 图 =====
+
+func (p *mermaidParser) entity(st statement) {
+	text := st.text
+	if top := p.top(); top != nil && top.kind == "entity" {
+		if text == "}" {
+			p.pop()
+			return
+		}
+		p.attribute(st, top.element)
+		return
+	}
+
+	if m := mermaidERRelationRe.FindStringSubmatchIndex(text); m != nil {
+		from := p.entityElement(st, text[m[2]:m[3]], m[2])
+		to := p.entityElement(st, text[m[10]:m[11]], m[10])
+		r := &Relation{From: from.ID(), To: to.ID(), Arrow: text[m[4]:m[5]] + text[m[6]:m[7]] + text[m[8]:m[9]], Pos: p.pos(st, m[2])}
+		if m[12] >= 0 && strings.TrimSpace(text[m[12]:m[13]]) != "" {
+			r.Label = mermaidUnescape(unquote(text[m[12]:m[13]]))
+		} else {
+			p.diag(SeverityError, CodeSyntaxError, st, m[4], len(text), "关系缺少 : 和关系名称")
+		}
+		p.doc.Relations = append(p.doc.Relations, r)
+		return
+	}
+	if m := mermaidEntityRe.FindStringSubmatch(text); m != nil {
+		e := p.entityElement(st, m[1], 0)
+		e.Implicit = false
+		if label := strings.TrimSpace(m[2]); label != "" {
+			e.Name, e.Alias = mermaidUnescape(label), e.ID()
+		}
+		if m[3] != "" && m[4] == "" {
+			p.push(st, "entity", len(m[1])).element = e
+		}
+		return
+	}
+	if strings.Contains(text, "--") || strings.Contains(text, "..") {
+		p.diag(SeverityError, CodeSyntaxError, st, 0, len(text), "无法识别的关系，两端应为 |o、||、}o、}| 和 o|、||、o{、|{")
+		return
+	}
+	p.unknown(st)
+}
+
+// entityElement 按名称查找实体，不存在时隐式创建
+func (p *mermaidParser) entityElement(st statement, name string, idx int) *Element {
+	name = unquote(name)
+	if e := p.byID[name]; e != nil {
+		return e
+	}
+	e := &Element{Kind: "entity", Name: name, Implicit: true, Pos: p.pos(st, idx)}
+	p.byID[name] = e
+	p.doc.Elements = append(p.doc.Elements, e)
+	return e
+}
+
+// attribute 实体属性：类型 名称 [PK|FK|UK] ["注释"]，转换为 PlantUML 的 名称 : 类型 <<PK>> 写法，主键前加 *
+func (p *mermaidParser) attribute(st statement, e *Element) {
+	m := mermaidAttributeRe.FindStringSubmatch(st.text)
+	if m == nil {
+		p.diag(SeverityError, CodeSyntaxError, st, 0, len(st.text), `无法识别的属性，应为 类型 名称 [PK|FK|UK] ["注释"]`)
+		return
+	}
+	member := &Member{Name: m[2], Type: m[1], Pos: p.pos(st, 0)}
+	var tags []string
+	for _, key := range strings.FieldsFunc(m[3], func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		switch key {
+		case "PK":
+			member.PrimaryKey = true
+		case "FK":
+			member.ForeignKey = true
+		}
+		tags = append(tags, " <<"+key+">>")
+	}
+	if m[4] != "" {
+		p.dropped.add("属性注释 %s.%s", e.ID(), member.Name)
+	}
+	member.Text = member.Name + " : " + member.Type + strings.Join(tags, "")
+	if member.PrimaryKey {
+		member.Text = "* " + member.Text
+	}
+	e.Members = append(e.Members, member)
+}
+
+// ===== 流程图 =====
+
+func (p *mermaidParser) flowchart(st statement, first string) {
+	text := st.text
+	lower := strings.ToLower(text)
+	switch {
+	case first == "subgraph" && hasWord(lower, first):
+		p.push(st, "subgraph", len(first))
+		p.dropped.add("子图 %s", strings.TrimSpace(text[len(first):]))
+	case first == "end" && strings.TrimSpace(text[len(first):]) == "":
+		if top := p.top(); top == nil || top.kind != "subgraph" {
+			p.diag(SeverityError, CodeUnmatchedEnd, st, 0, len(text), "没有与 end 对应的 subgraph")
+			return
+		}
+		p.pop()
+	case first == "direction" && hasWord(lower, first):
+	case (first == "style" || first == "classdef" || first == "class" || first == "click" || first == "linkstyle") && hasWord(lower, first):
+		p.dropped.add("%s 语句", first)
+	default:
+		p.chain(st)
+	}
+}
+
+// chain 解析 A --> B & C -->|标签| D 形式的节点和连线
+func (p *mermaidParser) chain(st statement) {
+	text := st.text
+	var prev []*Element
+	var link *Relation
+	for i := 0; ; {
+		group, next, ok := p.nodeGroup(st, i)
+		if !ok {
+			return
+		}
+		if link != nil {
+			for _, from := range prev {
+				for _, to := range group {
+					p.doc.Relations = append(p.doc.Relations, &Relation{From: from.ID(), To: to.ID(), Arrow: "-->", Label: link.Label, Pos: link.Pos})
+				}
+			}
+		}
+		prev = group
+
+		i = skipSpaces(text, next)
+		if i >= len(text) {
+			return
+		}
+		arrow, label, n := flowLink(text[i:])
+		if n == 0 {
+			p.diag(SeverityError, CodeSyntaxError, st, i, len(text), "无法识别的连线或节点")
+			return
+		}
+		if arrow != "-->" {
+			p.dropped.add("连线样式 %s", arrow)
+		}
+		link = &Relation{Label: mermaidUnescape(unquote(label)), Pos: p.pos(st, i)}
+		if i = skipSpaces(text, i+n); i >= len(text) {
+			p.diag(SeverityError, CodeSyntaxError, st, 0, len(text), "连线缺少目标节点")
+			return
+		}
+	}
+}
+
+// flowLink 识别连线，返回规范化的箭头（普通箭头为 -->）、标签和消耗的字节数；不是连线时返回 0
+func flowLink(s string) (string, string, int) {
+	if m := mermaidFlowLinkRe.FindStringSubmatch(s); m != nil && !((m[2] == "--" || m[2] == "==") && m[3] == "") {
+		return normalizeFlowArrow(m[1], m[2], m[3]), m[4], len(m[0])
+	}
+	if m := mermaidFlowTextRe.FindStringSubmatch(s); m != nil {
+		return normalizeFlowArrow(m[1], m[2], m[5]), m[3], len(m[0])
+	}
+	return "", "", 0
+}
+
+func normalizeFlowArrow(head, line, tail string) string {
+	if head == "" && tail == ">" && strings.Trim(line, "-") == "" {
+		return "-->"
+	}
+	return head + line + tail
+}
+
+// nodeGroup 以 & 连接的一组节点
+func (p *mermaidParser) nodeGroup(st statement, i int) ([]*Element, int, bool) {
+	var group []*Element
+	for {
+		e, next, ok := p.flowNode(st, i)
+		if !ok {
+			return nil, 0, false
+		}
+		group = append(group, e)
+		j := skipSpaces(st.text, next)
+		if j >= len(st.text) || st.text[j] != '&' {
+			return group, next, true
+		}
+		i = skipSpaces(st.text, j+1)
+	}
+}
+
+// flowNode 节点引用或定义：标识后可跟形状和文字，如 A[文字]、B{"判断"}、C((开始))
+func (p *mermaidParser) flowNode(st statement, i int) (*Element, int, bool) {
+	text := st.text
+	j := i
+	for j < len(text) {
+		r, size := utf8.DecodeRuneInString(text[j:])
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			break
+		}
+		j += size
+	}
+	if j == i {
+		p.diag(SeverityError, CodeSyntaxError, st, i, len(text), "缺少节点标识")
+		return nil, 0, false
+	}
+	id := text[i:j]
+
+	shape, label, labeled := "", "", false
+	for _, s := range mermaidFlowShapes {
+		if !strings.HasPrefix(text[j:], s.open) {
+			continue
+		}
+		start := j + len(s.open)
+		content, end := "", -1
+		if start < len(text) && text[start] == '"' {
+			if q := strings.IndexByte(text[start+1:], '"'); q >= 0 && strings.HasPrefix(text[start+q+2:], s.close) {
+				content, end = text[start+1:start+1+q], start+q+2+len(s.close)
+			}
+		} else if k := strings.Index(text[start:], s.close); k >= 0 {
+			content, end = text[start:start+k], start+k+len(s.close)
+		}
+		if end >= 0 {
+			shape, label, labeled, j = s.name, content, true, end
+			break
+		}
+	}
+	if !labeled && j < len(text) && strings.ContainsAny(text[j:j+1], "([{>") {
+		p.diag(SeverityError, CodeUnclosedBlock, st, j, len(text), fmt.Sprintf("节点 %s 的形状缺少结束符", id))
+		return nil, 0, false
+	}
+	if strings.HasPrefix(text[j:], ":::") {
+		k := j + 3
+		for k < len(text) && (isIdentByte(text[k]) || text[k] == '-') {
+			k++
+		}
+		p.dropped.add("样式类 %s", text[j:k])
+		j = k
+	}
+
+	e := p.byID[id]
+	if e == nil {
+		e = &Element{Kind: ShapeAction, Name: id, Alias: id, Pos: p.pos(st, i)}
+		p.byID[id] = e
+		p.doc.Elements = append(p.doc.Elements, e)
+	}
+	if labeled {
+		if label = mermaidUnescape(label); label != "" {
+			e.Name = label
+		}
+		p.shapes[e] = shape
+	}
+	return e, j, true
+}
+
+// flowKinds 由形状和连线确定流程图节点的种类：菱形为判断，圆形在起点为开始、在终点为结束
+func (p *mermaidParser) flowKinds() {
+	in := make(map[string]int)
+	out := make(map[string]int)
+	for _, r := range p.doc.Relations {
+		out[r.From]++
+		in[r.To]++
+	}
+	for _, e := range p.doc.Elements {
+		shape := p.shapes[e]
+		terminal := shape == "circle" || shape == "double_circle" ||
+			shape == "stadium" && terminalTexts[strings.ToLower(e.Name)]
+		switch {
+		case shape == "rhombus":
+			e.Kind = ShapeDecision
+		case terminal && in[e.ID()] == 0 && out[e.ID()] > 0:
+			e.Kind = ShapeStart
+		case terminal && out[e.ID()] == 0 && in[e.ID()] > 0:
+			e.Kind = ShapeStop
+		case shape != "" && shape != "rect" && shape != "round" && shape != "stadium":
+			p.dropped.add("节点 %s 的形状 %s", e.Name, shape)
+		}
+		if (e.Kind == ShapeStart || e.Kind == ShapeStop) && e.Name != e.Alias && !terminalTexts[strings.ToLower(e.Name)] {
+			p.dropped.add("%s节点的文字 %s", map[string]string{ShapeStart: "开始", ShapeStop: "结束"}[e.Kind], e.Name)
+		}
+	}
+}
+
+// ===== 通用 =====
+
+// finish 报告未闭合的块，确定流程图节点的种类
+func (p *mermaidParser) finish() {
+	for _, b := range p.blocks {
+		closer := "end"
+		if b.kind == "class" || b.kind == "namespace" || b.kind == "entity" {
+			closer = "}"
+		}
+		p.doc.Diagnostics = append(p.doc.Diagnostics, Diagnostic{
+			Severity:  SeverityError,
+			Code:      CodeUnclosedBlock,
+			Message:   fmt.Sprintf("%s 缺少对应的 %s", b.st.text[:b.width], closer),
+			Line:      b.st.line,
+			Column:    b.st.col,
+			EndColumn: b.st.col + runeCount(b.st.text[:b.width]),
+		})
+	}
+	if p.doc.Type == DiagramActivity {
+		p.flowKinds()
+	}
+}
+
+// unknown 报告无法识别的语句，开头的单词与关键字相近时给出拼写建议
+func (p *mermaidParser) unknown(st statement) {
+	text := st.text
+	word := 0
+	for word < len(text) && (text[word] >= 'a' && text[word] <= 'z' || text[word] >= 'A' && text[word] <= 'Z') {
+		word++
+	}
+	if word > 0 && word < len(text) && text[word] == ' ' {
+		if suggestion := closest(strings.ToLower(text[:word]), mermaidKeywords[p.doc.Type]); suggestion != "" {
+			p.diag(SeverityError, CodeUnknownKeyword, st, 0, word, fmt.Sprintf("未知的关键字 %s，是否为 %s？", text[:word], suggestion))
+			return
+		}
+	}
+	p.diag(SeverityError, CodeSyntaxError, st, 0, len(text), "无法识别的语句")
+}
+
+func (p *mermaidParser) push(st statement, kind string, width int) *mermaidBlock {
+	b := &mermaidBlock{kind: kind, st: st, width: width}
+	p.blocks = append(p.blocks, b)
+	return b
+}
+
+func (p *mermaidParser) top() *mermaidBlock {
+	if len(p.blocks) == 0 {
+		return nil
+	}
+	return p.blocks[len(p.blocks)-1]
+}
+
+func (p *mermaidParser) pop() *mermaidBlock {
+	b := p.top()
+	p.blocks = p.blocks[:len(p.blocks)-1]
+	return b
+}
+
+// pos 语句中字节偏移 idx 处的位置
+func (p *mermaidParser) pos(st statement, idx int) Position {
+	if idx < 0 || idx > len(st.text) {
+		idx = 0
+	}
+	return Position{Line: st.line, Column: st.col + runeCount(st.text[:idx])}
+}
+
+// diag 记录一条诊断，start 和 end 为语句中的字节区间
+func (p *mermaidParser) diag(severity Severity, code string, st statement, start, end int, msg string) {
+	from := p.pos(st, start)
+	endColumn := from.Column + 1
+	if start >= 0 && end > start && end <= len(st.text) {
+		endColumn = from.Column + runeCount(st.text[start:end])
+	}
+	p.doc.Diagnostics = append(p.doc.Diagnostics, Diagnostic{
+		Severity:  severity,
+		Code:      code,
+		Message:   msg,
+		Line:      st.line,
+		Column:    from.Column,
+		EndColumn: endColumn,
+	})
+}
+
+// mermaidUnescape 还原 mermaidText 的转义：<br/> 为换行，#quot;、#59; 等实体编码为对应字符
+func mermaidUnescape(s string) string {
+	s = mermaidBreakRe.ReplaceAllString(strings.TrimSpace(s), "\n")
+	return mermaidEntityCodeRe.ReplaceAllStringFunc(s, func(code string) string {
+		switch name := code[1 : len(code)-1]; name {
+		case "quot":
+			return `"`
+		case "amp":
+			return "&"
+		case "lt":
+			return "<"
+		case "gt":
+			return ">"
+		case "nbsp":
+			return " "
+		default:
+			n, err := strconv.Atoi(name)
+			if err != nil || n <= 0 || n > unicode.MaxRune {
+				return code
+			}
+			return string(rune(n))
+		}
+	})
+}
@@ -0,0 +1,204 @@
+package plantuml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectSyntax(t *testing.T) {
+	assert.Equal(t, SyntaxPlantUML, DetectSyntax("@startuml\nA -> B\n@enduml"))
+	assert.Equal(t, SyntaxMermaid, DetectSyntax("%% 注释\nsequenceDiagram\n  A->>B: hi"))
+	assert.Equal(t, SyntaxMermaid, DetectSyntax("---\ntitle: 流程\n---\nflowchart LR\n  A --> B"))
+	assert.Equal(t, SyntaxMermaid, DetectSyntax("stateDiagram-v2\n  [*] --> A"))
+	assert.Equal(t, SyntaxPlantUML, DetectSyntax(""))
+}
+
+func TestParseSyntax(t *testing.T) {
+	for name, want := range map[string]Syntax{"": SyntaxPlantUML, "PUML": SyntaxPlantUML, "mermaid": SyntaxMermaid, " mmd ": SyntaxMermaid} {
+		got, err := ParseSyntax(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, name)
+	}
+	_, err := ParseSyntax("graphviz")
+	assert.Error(t, err)
+}
+
+func TestParseMermaid_Sequence(t *testing.T) {
+	doc := ParseMermaid(`---
+title: 下单
+---
+sequenceDiagram
+    actor U as 用户
+    U->>+OS: 提交订单#59; 加急
+    alt 库存充足
+        OS-->>DB: 扣减<br/>库存
+    else 库存不足
+        OS-)U: 失败
+    end
+    OS-->>-U: 完成
+    Note over U,OS: 结束`)
+
+	require.Empty(t, doc.Diagnostics)
+	assert.Equal(t, DiagramSequence, doc.Type)
+	assert.Equal(t, "下单", doc.Title)
+	require.Len(t, doc.Elements, 3)
+	assert.Equal(t, &Element{Kind: "actor", Name: "用户", Alias: "U", Pos: Position{Line: 5, Column: 11}}, doc.Elements[0])
+	assert.True(t, doc.Elements[1].Implicit)
+
+	var kinds []string
+	for _, s := range doc.Sequence {
+		kinds = append(kinds, s.Kind)
+	}
+	assert.Equal(t, []string{"message", "activate", "group", "message", "else", "message", "end", "message", "deactivate", "note"}, kinds)
+	assert.Equal(t, "提交订单; 加急", doc.Relations[0].Label)
+	assert.Equal(t, "->", doc.Relations[0].Arrow)
+	assert.Equal(t, "扣减\n库存", doc.Relations[1].Label)
+	assert.Equal(t, "-->", doc.Relations[1].Arrow)
+	assert.Equal(t, "->>", doc.Relations[2].Arrow)
+	assert.Equal(t, "OS", doc.Sequence[8].Target)
+	assert.Equal(t, "over U, OS", doc.Sequence[9].Target)
+}
+
+func TestParseMermaid_SequenceDiagnostics(t *testing.T) {
+	doc := ParseMermaid(`sequenceDiagram
+    Alice->>Bob hello
+    alt 正常
+    else 异常
+    and 并行
+    participnt C
+    loop 重试`)
+
+	require.Len(t, doc.Diagnostics, 5)
+	assert.Equal(t, Diagnostic{Severity: SeverityError, Code: CodeSyntaxError, Message: "消息缺少 : 和消息内容", Line: 2, Column: 10, EndColumn: 22}, doc.Diagnostics[0])
+	assert.Equal(t, CodeUnclosedBlock, doc.Diagnostics[1].Code)
+	assert.Equal(t, 3, doc.Diagnostics[1].Line)
+	assert.Equal(t, "and 只能用于 par 分组", doc.Diagnostics[2].Message)
+	assert.Equal(t, Diagnostic{Severity: SeverityError, Code: CodeUnknownKeyword, Message: "未知的关键字 participnt，是否为 participant？", Line: 6, Column: 5, EndColumn: 15}, doc.Diagnostics[3])
+	assert.Equal(t, "loop 缺少对应的 end", doc.Diagnostics[4].Message)
+}
+
+func TestParseMermaid_Class(t *testing.T) {
+	doc := ParseMermaid(`classDiagram
+    class Animal {
+        <<abstract>>
+        #String name
+        +speak()* String
+        +List~int~ ids
+        +create(String name)$ Animal
+        decimal(10, 2) weight
+    }
+    <<interface>> Pet
+    namespace domain {
+        class Dog["狗"]
+    }
+    Dog --|> Animal
+    Animal "1" *-- "many" Leg : has
+    Animal : +int age`)
+
+	require.Empty(t, doc.Diagnostics)
+	assert.Equal(t, DiagramClass, doc.Type)
+	animal := doc.Element("Animal")
+	require.NotNil(t, animal)
+	assert.Equal(t, "abstract", animal.Kind)
+	var members []string
+	for _, m := range animal.Members {
+		members = append(members, m.Text)
+	}
+	assert.Equal(t, []string{"#name : String", "+{abstract} speak() : String", "+ids : List<int>", "+{static} create(String name) : Animal", "weight : decimal(10, 2)", "+age : int"}, members)
+	assert.True(t, animal.Members[1].Method)
+
+	assert.Equal(t, "interface", doc.Element("Pet").Kind)
+	dog := doc.Element("Dog")
+	assert.Equal(t, "狗", dog.Name)
+	assert.Equal(t, "domain", dog.Parent)
+	assert.True(t, doc.Element("Leg").Implicit)
+	assert.Equal(t, &Relation{From: "Animal", To: "Leg", Arrow: "*--", Label: "has", FromCardinality: "1", ToCardinality: "many", Pos: Position{Line: 15, Column: 5}}, doc.Relations[1])
+}
+
+func TestParseMermaid_Entity(t *testing.T) {
+	doc := ParseMermaid(`erDiagram
+    CUSTOMER ||--o{ ORDER : places
+    CUSTOMER {
+        string id PK "客户编号"
+        string name
+    }
+    ORDER {
+        int id PK
+        string customer_id FK
+    }
+    ORDER }|..|{ LINE-ITEM
+    ORDER {
+        bad
+    }`)
+
+	assert.Equal(t, DiagramEntity, doc.Type)
+	require.Len(t, doc.Elements, 3)
+	customer := doc.Elements[0]
+	assert.Equal(t, "CUSTOMER", customer.Name)
+	assert.Equal(t, &Member{Text: "* id : string <<PK>>", Name: "id", Type: "string", PrimaryKey: true, Pos: Position{Line: 4, Column: 9}}, customer.Members[0])
+	assert.True(t, doc.Elements[1].Members[1].ForeignKey)
+	assert.Equal(t, "||--o{", doc.Relations[0].Arrow)
+	assert.Equal(t, "places", doc.Relations[0].Label)
+
+	require.Len(t, doc.Diagnostics, 2)
+	assert.Equal(t, "关系缺少 : 和关系名称", doc.Diagnostics[0].Message)
+	assert.Equal(t, 11, doc.Diagnostics[0].Line)
+	assert.Equal(t, 13, doc.Diagnostics[1].Line)
+}
+
+func TestParseMermaid_Flowchart(t *testing.T) {
+	doc := ParseMermaid(`flowchart TD
+    A((开始)) --> B[提交订单] --> C{"库存充足?"}
+    C -->|是| D & E
+    C -- 否 --> F(((结束)))
+    D -.-> F; E --> F`)
+
+	require.Empty(t, doc.Diagnostics)
+	assert.Equal(t, DiagramActivity, doc.Type)
+	var nodes []string
+	for _, e := range doc.Elements {
+		nodes = append(nodes, e.Kind+":"+e.Name)
+	}
+	assert.Equal(t, []string{"start:开始", "action:提交订单", "decision:库存充足?", "action:D", "action:E", "stop:结束"}, nodes)
+
+	var edges []string
+	for _, r := range doc.Relations {
+		edges = append(edges, r.From+">"+r.To+":"+r.Label)
+	}
+	assert.Equal(t, []string{"A>B:", "B>C:", "C>D:是", "C>E:是", "C>F:否", "D>F:", "E>F:"}, edges)
+}
+
+func TestParseMermaid_FlowchartDiagnostics(t *testing.T) {
+	doc := ParseMermaid(`flowchart XY
+    A[提交 --> B
+    C -->
+    subgraph 子流程
+    D --> E`)
+
+	var messages []string
+	for _, d := range doc.Diagnostics {
+		messages = append(messages, d.Message)
+	}
+	assert.Equal(t, []string{
+		"无效的流程图方向 XY，应为 TB、TD、BT、RL 或 LR",
+		"节点 A 的形状缺少结束符",
+		"连线缺少目标节点",
+		"subgraph 缺少对应的 end",
+	}, messages)
+}
+
+func TestParseMermaid_Header(t *testing.T) {
+	doc := ParseMermaid("sequenceDiagam\n  A->>B: hi")
+	require.Len(t, doc.Diagnostics, 1)
+	assert.Equal(t, Diagnostic{Severity: SeverityError, Code: CodeUnknownKeyword, Message: "未知的图表类型 sequenceDiagam，是否为 sequenceDiagram？", Line: 1, Column: 1, EndColumn: 15}, doc.Diagnostics[0])
+
+	doc = ParseMermaid("gantt\n  title 计划")
+	require.Len(t, doc.Diagnostics, 1)
+	assert.Equal(t, SeverityWarning, doc.Diagnostics[0].Severity)
+	assert.False(t, doc.HasErrors())
+
+	doc = ParseMermaid("%% 只有注释")
+	assert.Equal(t, CodeMissingStart, doc.Diagnostics[0].Code)
+}
@@ -0,0 +1,167 @@
+package plantuml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToMermaid_Sequence(t *testing.T) {
+	doc := Parse(`@startuml
+title 下单
+actor 用户
+participant "订单服务" as OS
+database DB
+用户 -> OS : 提交订单
+activate OS
+alt 库存充足
+  OS -> DB : 扣减库存
+  DB --> OS
+else 库存不足
+  OS ->> 用户 : 失败
+end
+OS <- DB : 回调
+note right of OS : 异步处理
+== 结束 ==
+@enduml`)
+
+	result, err := ToMermaid(doc)
+	require.NoError(t, err)
+	assert.Equal(t, `---
+title: 下单
+---
+sequenceDiagram
+    actor e1 as 用户
+    participant OS as 订单服务
+    participant DB
+    e1->>OS: 提交订单
+    activate OS
+    alt 库存充足
+        OS->>DB: 扣减库存
+        DB-->>OS:
+    else 库存不足
+        OS-)e1: 失败
+    end
+    DB->>OS: 回调
+    Note right of OS: 异步处理
+`, result.Source)
+	assert.Equal(t, []string{"参与者 DB 的类型 database", "分隔线 == 结束 =="}, result.Dropped)
+}
+
+func TestToMermaid_Class(t *testing.T) {
+	doc := Parse(`@startuml
+package domain {
+  abstract class Animal {
+    #name : String
+    +{abstract} speak() : String
+  }
+  class Dog
+}
+interface Pet
+Dog --|> Animal
+Dog ..|> Pet
+Animal "1" *-- "many" Leg : has
+@enduml`)
+
+	result, err := ToMermaid(doc)
+	require.NoError(t, err)
+	assert.Equal(t, `classDiagram
+    class Pet {
+        <<interface>>
+    }
+    class Leg
+    namespace domain {
+        class Animal {
+            <<abstract>>
+            #String name
+            +speak() String*
+        }
+        class Dog
+    }
+    Dog --|> Animal
+    Dog ..|> Pet
+    Animal "1" *-- "many" Leg : has
+`, result.Source)
+	assert.Empty(t, result.Dropped)
+}
+
+func TestToMermaid_Entity(t *testing.T) {
+	doc := Parse(`@startuml
+entity 用户 as user {
+  * id : bigint <<PK>>
+  name : varchar(50)
+}
+entity order {
+  * id : bigint
+  user_id : bigint <<FK>>
+}
+user ||--o{ order : 下单
+user "1" -- "0..*" order
+@enduml`)
+
+	result, err := ToMermaid(doc)
+	require.NoError(t, err)
+	assert.Equal(t, `erDiagram
+    user["用户"] {
+        bigint id PK
+        varchar(50) name
+    }
+    order {
+        bigint id
+        bigint user_id FK
+    }
+    user ||--o{ order : "下单"
+    user ||--o{ order : ""
+`, result.Source)
+}
+
+func TestToMermaid_Activity(t *testing.T) {
+	doc := Parse(`@startuml
+start
+:提交订单;
+if (库存充足?) then (是)
+  :扣减库存;
+else (否)
+  :取消订单;
+  stop
+endif
+while (还有商品?) is (是)
+  :打包;
+endwhile (否)
+|仓库|
+:发货;
+stop
+@enduml`)
+
+	result, err := ToMermaid(doc)
+	require.NoError(t, err)
+	assert.Equal(t, `flowchart TD
+    n1((开始))
+    n2["提交订单"]
+    n3{"库存充足?"}
+    n4["扣减库存"]
+    n5["取消订单"]
+    n6(((结束)))
+    n7{"还有商品?"}
+    n8["打包"]
+    n9["发货"]
+    n10(((结束)))
+    n1 --> n2
+    n2 --> n3
+    n3 -->|"是"| n4
+    n3 -->|"否"| n5
+    n5 --> n6
+    n4 --> n7
+    n7 -->|"是"| n8
+    n8 --> n7
+    n7 -->|"否"| n9
+    n9 --> n10
+`, result.Source)
+	assert.Equal(t, []string{"泳道 仓库"}, result.Dropped)
+}
+
+func TestToMermaid_Unsupported(t *testing.T) {
+	_, err := ToMermaid(Parse("@startuml\n[API] --> [DB]\n@enduml"))
+	assert.ErrorIs(t, err, ErrUnsupportedConversion)
+}
@@ -0,0 +1,1580 @@
+package plantuml
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	defineRe       = regexp.MustCompile(`^!define\s+(\w+)(?:\(([^)]*)\))?\s+(.+)$`)
+	macroCallRe    = regexp.MustCompile(`^(\w+)\(([^)]*)\)`)
+	separatorRe    = regexp.MustCompile(`^(==.*==|\.\.\..*|\|\|\|?|\|\|\d+\|\|)$`)
+	swimlaneRe     = regexp.MustCompile(`^\|(?:[^|]*\|)?([^|]+)\|$`)
+	actorTokenRe   = regexp.MustCompile(`^:[^:;]+:(\s|$)`)
+	activityArrow  = regexp.MustCompile(`^-+(?:\[[^\]]*\])?-*>\s*(.*?);?$`)
+	arrowRe        = regexp.MustCompile(`^(?:<\|?|<<|\\{1,2}|/{1,2}|[*o#x+}{|]{1,2})?(?:-+|\.+)(?:\[[^\]]*\]|up|down|left|right)?(?:-*|\.*)(?:(?:\|?>>?|\\{1,2}|/{1,2})[ox]?|[*o#x+}{|]{1,2})?`)
+	crowFootRe     = regexp.MustCompile(`\|o|o\||\}o|o\{|\|\||\}\||\|\{`)
+	memberSepRe    = regexp.MustCompile(`^(--|\.\.|==|__)`)
+	memberMacroRe  = regexp.MustCompile(`^(\w+)\(([^)]*)\)\s*(.*)$`)
+	memberTagRe    = regexp.MustCompile(`</?[a-zA-Z]+[^>]*>|<<[^>]*>>|\{(?i:static|abstract|field|method|classifier|pk|fk)\}`)
+	arrowStartings = "<\\/*o#x+}{|-."
+)
+
+// 参与者、类、容器等声明关键字
+var (
+	participantKinds = set("participant", "actor", "boundary", "control", "entity", "database", "collections", "queue")
+	classKinds       = set("class", "abstract", "interface", "enum", "annotation", "struct", "exception", "protocol", "metaclass", "object", "map", "entity", "circle", "diamond")
+	containerKinds   = set("package", "namespace", "node", "folder", "frame", "cloud", "database", "rectangle", "component", "card", "storage", "artifact", "stack", "file", "hexagon", "queue", "agent", "person", "boundary", "control", "collections", "label", "actor", "usecase", "port", "portin", "portout")
+	sequenceGroups   = set("alt", "opt", "loop", "par", "par2", "break", "critical", "group")
+	activityBlocks   = set("if", "while", "repeat", "fork", "split", "switch", "partition", "group")
+	keyMacros        = set("primary_key", "foreign_key", "pk", "fk", "unique", "not_null", "column")
+)
+
+// 推断图表类型时各类图的特征关键字
+var (
+	activityStarts     = set("start", "stop", "if", "endif", "elseif", "while", "endwhile", "repeat", "fork", "split", "partition", "detach", "kill", "switch", "endswitch")
+	sequenceStarts     = set("participant", "boundary", "control", "collections", "queue")
+	sequenceStatements = set("alt", "opt", "loop", "par", "critical", "activate", "deactivate", "autonumber", "return", "ref", "box", "destroy")
+	classStarts        = set("class", "interface", "enum", "abstract", "annotation", "struct")
+	componentStarts    = set("component", "node", "cloud", "folder", "frame", "artifact", "storage", "card")
+)
+
+// keywords 可以出现在语句开头的关键字，未单独建模的语句按关键字放行
+var keywords = set(
+	"participant", "actor", "boundary", "control", "entity", "database", "collections", "queue",
+	"activate", "deactivate", "destroy", "create", "return", "alt", "else", "opt", "loop", "par", "par2",
+	"break", "critical", "group", "end", "ref", "box", "autonumber", "autoactivate", "newpage", "delay",
+	"hide", "show", "skinparam", "skin", "scale", "title", "header", "footer", "caption", "legend",
+	"endlegend", "note", "hnote", "rnote", "endnote", "left", "right", "top", "bottom", "center",
+	"together", "set", "remove", "allowmixing", "allow_mixing", "mainframe", "start", "stop", "if",
+	"then", "elseif", "endif", "while", "endwhile", "repeat", "backward", "fork", "split", "detach",
+	"kill", "partition", "switch", "case", "endswitch", "class", "abstract", "interface", "enum",
+	"annotation", "struct", "exception", "protocol", "metaclass", "object", "map", "json", "package",
+	"namespace", "node", "folder", "frame", "cloud", "rectangle", "component", "card", "storage",
+	"artifact", "stack", "file", "hexagon", "agent", "person", "label", "port", "portin", "portout",
+	"usecase", "circle", "diamond", "sprite", "url", "footbox", "page", "ignore",
+)
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
+
+// statement 去掉注释和首尾空白后的一条语句
+type statement struct {
+	line     int
+	col      int // 语句首字符所在列
+	text     string
+	orig     string // 宏展开前的文本
+	expanded bool
+}
+
+// block 尚未闭合的块
+type block struct {
+	kind    string
+	label   string // 开始语句的关键字，用于诊断信息
+	pos     Position
+	width   int
+	brace   bool          // 以 } 闭合
+	closers []string      // 文本块（note、legend 等）的结束语句，小写且去掉空白
+	depth   int           // skinparam 块内嵌套的花括号层数
+	element *Element      // 类主体或容器对应的元素
+	step    *SequenceStep // 序列图多行注释对应的步骤，收集注释内容
+}
+
+type macro struct {
+	params []*regexp.Regexp
+	body   string
+}
+
+// reference 关系中对元素的裸名引用，用于检查未声明的参与者
+type reference struct {
+	element   *Element
+	pos       Position
+	endColumn int
+}
+
+// operand 关系一端
+type operand struct {
+	name     string
+	card     string
+	kind     string // 由 [X]、(X)、:X: 写法确定的元素类型，裸名为空
+	idx      int    // name 在语句中的字节偏移
+	external bool   // 序列图的 [ ]、活动图的 (*) 等不对应元素的端点
+}
+
+type parser struct {
+	doc      *Document
+	stack    []*block
+	macros   map[string]macro
+	byName   map[string]*Element
+	byAlias  map[string]*Element
+	refs     []reference
+	explicit int        // 显式声明的元素数
+	pending  *statement // 尚未遇到结束符的多行活动
+}
+
+// Parse 解析 PlantUML 源码，返回语法树和带行列位置的诊断；文件包含多个图表时只解析第一个
+func Parse(source string) *Document {
+	p := &parser{
+		doc: &Document{
+			Type:        DiagramUnknown,
+			Elements:    []*Element{},
+			Relations:   []*Relation{},
+			Diagnostics: []Diagnostic{},
+		},
+		macros:  make(map[string]macro),
+		byName:  make(map[string]*Element),
+		byAlias: make(map[string]*Element),
+	}
+
+	statements := p.scan(source)
+	if st, name := unsupportedDiagram(statements); name != "" {
+		p.diag(SeverityWarning, CodeUnsupportedDiagram, st, 0, len(st.text), fmt.Sprintf("暂不检查%s的语法", name))
+		statements = nil
+	}
+	p.doc.Type = detectType(statements)
+	for _, st := range statements {
+		p.statement(st)
+	}
+	p.finish()
+
+	sort.SliceStable(p.doc.Diagnostics, func(i, j int) bool {
+		a, b := p.doc.Diagnostics[i], p.doc.Diagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return p.doc
+}
+
+// scan 切分语句，去掉注释，检查 @startuml/@enduml 并展开 !define 宏
+func (p *parser) scan(source string) []statement {
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	var before, body, after []statement
+	started, ended, inComment := false, false, false
+	lastLine, lastColumn := 1, 1
+
+	for i, raw := range lines {
+		text := stripComments(raw, &inComment)
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "'") {
+			continue
+		}
+		indent := len(text) - len(strings.TrimLeftFunc(text, unicode.IsSpace))
+		st := statement{line: i + 1, col: runeCount(text[:indent]) + 1, text: trimmed, orig: trimmed}
+		lastLine, lastColumn = st.line, st.col+runeCount(trimmed)
+
+		lower := strings.ToLower(trimmed)
+		if strings.HasPrefix(lower, "@startuml") {
+			if ended {
+				p.diag(SeverityWarning, CodeDuplicateMarker, st, 0, len(trimmed), "文件包含多个图表，只解析第一个")
+				break
+			}
+			if started {
+				p.diag(SeverityError, CodeDuplicateMarker, st, 0, len(trimmed), "重复的 @startuml")
+			}
+			started = true
+			continue
+		}
+		if strings.HasPrefix(lower, "@enduml") {
+			if ended {
+				p.diag(SeverityError, CodeDuplicateMarker, st, 0, len(trimmed), "重复的 @enduml")
+			}
+			ended = true
+			continue
+		}
+		switch {
+		case ended:
+			after = append(after, st)
+		case started:
+			body = append(body, st)
+		default:
+			before = append(before, st)
+		}
+	}
+
+	if !started {
+		p.doc.Diagnostics = append(p.doc.Diagnostics, Diagnostic{
+			Severity: SeverityError, Code: CodeMissingStart, Message: "缺少 @startuml",
+			Line: 1, Column: 1, EndColumn: 2,
+		})
+		body = before
+	} else if len(before) > 0 {
+		p.diag(SeverityWarning, CodeOutsideDiagram, before[0], 0, len(before[0].text), "@startuml 之前的内容会被忽略")
+	}
+	if !ended {
+		p.doc.Diagnostics = append(p.doc.Diagnostics, Diagnostic{
+			Severity: SeverityError, Code: CodeMissingEnd, Message: "缺少 @enduml",
+			Line: lastLine, Column: lastColumn, EndColumn: lastColumn + 1,
+		})
+	}
+	if len(after) > 0 {
+		p.diag(SeverityWarning, CodeOutsideDiagram, after[0], 0, len(after[0].text), "@enduml 之后的内容会被忽略")
+	}
+
+	for i := range body {
+		body[i] = p.expand(body[i])
+	}
+	return body
+}
+
+// stripComments 把块注释 /' '/ 替换为空格，保持列位置不变
+func stripComments(line string, inComment *bool) string {
+	var b strings.Builder
+	for i := 0; i < len(line); {
+		if *inComment {
+			j := strings.Index(line[i:], "'/")
+			if j < 0 {
+				b.WriteString(strings.Repeat(" ", runeCount(line[i:])))
+				break
+			}
+			b.WriteString(strings.Repeat(" ", runeCount(line[i:i+j+2])))
+			i += j + 2
+			*inComment = false
+			continue
+		}
+		j := strings.Index(line[i:], "/'")
+		if j < 0 {
+			b.WriteString(line[i:])
+			break
+		}
+		b.WriteString(line[i : i+j])
+		b.WriteString("  ")
+		i += j + 2
+		*inComment = true
+	}
+	return b.String()
+}
+
+// expand 记录 !define 宏；语句以宏调用开头且宏展开为声明时（如 table(用户) 展开为 class）替换为展开结果
+func (p *parser) expand(st statement) statement {
+	if m := defineRe.FindStringSubmatch(st.text); m != nil {
+		var params []*regexp.Regexp
+		if m[2] != "" {
+			for _, name := range strings.Split(m[2], ",") {
+				var param *regexp.Regexp
+				if name = strings.TrimSpace(name); name != "" {
+					param = regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+				}
+				params = append(params, param)
+			}
+		}
+		p.macros[m[1]] = macro{params: params, body: strings.TrimSpace(m[3])}
+		return st
+	}
+
+	m := macroCallRe.FindStringSubmatch(st.text)
+	if m == nil {
+		return st
+	}
+	mac, ok := p.macros[m[1]]
+	if !ok {
+		return st
+	}
+	if first := firstWord(strings.ToLower(mac.body)); !classKinds[first] && !containerKinds[first] {
+		return st
+	}
+	args := strings.Split(m[2], ",")
+	body := mac.body
+	for i, param := range mac.params {
+		if param != nil && i < len(args) {
+			body = param.ReplaceAllLiteralString(body, strings.TrimSpace(args[i]))
+		}
+	}
+	st.text = body + st.text[len(m[0]):]
+	st.expanded = true
+	return st
+}
+
+// unsupportedDiagram 查找状态图等尚未建模的图表类型的特征语句，返回该语句和图表名称
+func unsupportedDiagram(statements []statement) (statement, string) {
+	for _, st := range statements {
+		if first := firstWord(strings.ToLower(st.text)); first == "state" || strings.HasPrefix(st.text, "[*]") || strings.HasSuffix(st.text, "[*]") {
+			return st, "状态图"
+		}
+	}
+	return statement{}, ""
+}
+
+// detectType 根据语句特征打分推断图表类型
+func detectType(statements []statement) DiagramType {
+	scores := make(map[DiagramType]int)
+	for _, st := range statements {
+		text := st.text
+		lower := strings.ToLower(text)
+		first := firstWord(lower)
+		brace := strings.HasSuffix(text, "{")
+
+		switch {
+		case strings.HasPrefix(text, "!"):
+			continue
+		case activityStarts[first],
+			strings.Contains(text, "(*)"), swimlaneRe.MatchString(text) && !separatorRe.MatchString(text):
+			scores[DiagramActivity] += 2
+		case strings.HasPrefix(text, ":"):
+			if actorTokenRe.MatchString(text) {
+				scores[DiagramUseCase] += 2
+			} else {
+				scores[DiagramActivity] += 2
+			}
+		case sequenceStarts[first]:
+			scores[DiagramSequence] += 3
+		case sequenceStatements[first]:
+			scores[DiagramSequence] += 2
+		case classStarts[first]:
+			scores[DiagramClass] += 3
+		case first == "entity":
+			if brace {
+				scores[DiagramEntity] += 3
+			} else {
+				scores[DiagramSequence]++
+			}
+		case first == "actor":
+			scores[DiagramSequence]++
+			scores[DiagramUseCase]++
+		case first == "usecase", strings.HasPrefix(text, "("):
+			scores[DiagramUseCase] += 3
+		case first == "database":
+			if brace {
+				scores[DiagramComponent]++
+			} else {
+				scores[DiagramSequence]++
+			}
+		case strings.HasPrefix(text, "["), componentStarts[first]:
+			scores[DiagramComponent] += 2
+		}
+
+		if start, end := findArrow(text); start >= 0 {
+			arrow := text[start:end]
+			switch {
+			case crowFootRe.MatchString(arrow):
+				scores[DiagramEntity] += 3
+			case arrow == "->" || arrow == "->>" || arrow == "<-":
+				scores[DiagramSequence]++
+			}
+		}
+	}
+
+	// table() 宏展开后是 class，出现鸦脚关系时整体按 ER 图处理
+	if scores[DiagramEntity] > 0 {
+		scores[DiagramEntity] += scores[DiagramClass]
+		scores[DiagramClass] = 0
+	}
+
+	best, bestScore := DiagramUnknown, 0
+	for _, t := range []DiagramType{DiagramActivity, DiagramSequence, DiagramEntity, DiagramClass, DiagramComponent, DiagramUseCase} {
+		if scores[t] > bestScore {
+			best, bestScore = t, scores[t]
+		}
+	}
+	return best
+}
+
+// statement 解析一条语句
+func (p *parser) statement(st statement) {
+	if p.pending != nil {
+		p.pending.text += "\n" + st.text
+		if actionTerminated(st.text) {
+			p.action(*p.pending)
+			p.pending = nil
+		}
+		return
+	}
+
+	text := st.text
+	lower := strings.ToLower(text)
+	compact := strings.Join(strings.Fields(lower), "")
+
+	if top := p.top(); top != nil {
+		switch {
+		case top.closers != nil:
+			for _, closer := range top.closers {
+				if compact == closer {
+					p.pop()
+					return
+				}
+			}
+			if top.step != nil {
+				top.step.Text = strings.TrimPrefix(top.step.Text+"\n"+text, "\n")
+			}
+			return
+		case top.kind == "skinparam":
+			top.depth += strings.Count(text, "{") - strings.Count(text, "}")
+			if top.depth < 0 {
+				p.pop()
+			}
+			return
+		case top.kind == "body":
+			if strings.HasPrefix(text, "}") {
+				p.pop()
+				return
+			}
+			if m := p.member(st); m != nil {
+				top.element.Members = append(top.element.Members, m)
+			}
+			return
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(text, "!"):
+		return
+	case text == "}":
+		p.close(st, "}", func(b *block) bool { return b.brace })
+		return
+	case lower == "<style>":
+		p.pushText(st, "style", "</style>")
+		return
+	case separatorRe.MatchString(text):
+		switch {
+		case strings.HasPrefix(text, "=="):
+			p.step(st, &SequenceStep{Kind: "divider", Text: strings.TrimSpace(strings.Trim(text, "="))})
+		case strings.HasPrefix(text, "..."):
+			p.step(st, &SequenceStep{Kind: "delay", Text: strings.TrimSpace(strings.Trim(text, "."))})
+		}
+		return
+	}
+
+	first := firstWord(lower)
+	if p.common(st, first, compact) || p.control(st, first, lower, compact) || p.declaration(st, first) {
+		return
+	}
+
+	if strings.HasPrefix(text, ":") && !actorTokenRe.MatchString(text) {
+		if actionTerminated(text) {
+			p.action(st)
+		} else {
+			pending := st
+			p.pending = &pending
+		}
+		return
+	}
+	if p.doc.Type == DiagramActivity {
+		if m := activityArrow.FindStringSubmatch(text); m != nil {
+			p.node(st, "arrow", strings.TrimSpace(m[1]), p.activityDepth())
+			return
+		}
+		if m := swimlaneRe.FindStringSubmatch(text); m != nil {
+			p.node(st, "swimlane", strings.TrimSpace(m[1]), 0)
+			return
+		}
+	}
+	if start, end := findArrow(text); start >= 0 && p.relation(st, start, end) {
+		return
+	}
+	if p.memberStatement(st) {
+		return
+	}
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "(") || actorTokenRe.MatchString(text) {
+		if p.shorthand(st) {
+			return
+		}
+	}
+	if keywords[first] {
+		return
+	}
+	p.unknown(st)
+}
+
+// unknown 报告无法识别的语句：以未知单词开头且后面还有内容时视为拼错的关键字
+func (p *parser) unknown(st statement) {
+	text := st.text
+	word := 0
+	for word < len(text) && (text[word] >= 'a' && text[word] <= 'z' || text[word] >= 'A' && text[word] <= 'Z' || text[word] == '_') {
+		word++
+	}
+	if word > 0 && word < len(text) && strings.ContainsRune(" \t(\"", rune(text[word])) {
+		msg := fmt.Sprintf("未知的关键字 %s", text[:word])
+		if suggestion := suggest(strings.ToLower(text[:word])); suggestion != "" {
+			msg += fmt.Sprintf("，是否为 %s？", suggestion)
+		}
+		// 未建模的关键字可能是合法的新语法，只提示不阻止保存；带 { 的块照常入栈以匹配后面的 }
+		p.diag(SeverityWarning, CodeUnknownKeyword, st, 0, word, msg)
+		if strings.HasSuffix(text, "{") {
+			p.push(st, "unknown", true, nil)
+		}
+		return
+	}
+	p.diag(SeverityError, CodeSyntaxError, st, 0, len(text), "无法识别的语句")
+}
+
+// common 标题、注释、图例、skinparam 等各类图通用的语句
+func (p *parser) common(st statement, first, compact string) bool {
+	text := st.text
+	switch first {
+	case "title":
+		if rest := strings.TrimSpace(text[len(first):]); rest != "" {
+			p.doc.Title = unquote(rest)
+		} else {
+			p.pushText(st, "title", "endtitle")
+		}
+	case "note", "hnote", "rnote":
+		step := &SequenceStep{Kind: "note", Target: strings.TrimSpace(text[len(first):])}
+		if i := strings.IndexByte(text, ':'); i >= 0 {
+			step.Target, step.Text = strings.TrimSpace(text[len(first):i]), strings.TrimSpace(text[i+1:])
+		} else if !strings.Contains(text, "\"") {
+			p.push(st, "note", false, nil).closers = []string{"endnote", "endhnote", "endrnote"}
+			if p.doc.Type == DiagramSequence {
+				p.top().step = step
+			}
+		}
+		p.step(st, step)
+	case "legend":
+		p.pushText(st, "legend", "endlegend")
+	case "header", "footer":
+		if compact == first {
+			p.pushText(st, first, "end"+first)
+		}
+	case "skinparam":
+		if strings.HasSuffix(text, "{") {
+			p.push(st, "skinparam", false, nil)
+		}
+	case "ref":
+		if !strings.Contains(text, ":") {
+			p.pushText(st, "ref", "endref")
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// control 分组、条件、循环等控制结构
+func (p *parser) control(st statement, first, lower, compact string) bool {
+	text := st.text
+	depth := p.activityDepth()
+	isElseIf := first == "elseif" || first == "else" && firstWord(strings.TrimSpace(lower[len("else"):])) == "if"
+
+	switch {
+	case compact == "start" || compact == "stop" || compact == "detach" || compact == "kill":
+		p.node(st, compact, "", depth)
+	case compact == "end":
+		switch {
+		case p.nearest(func(b *block) bool { return sequenceGroups[b.kind] }) != nil:
+			if p.close(st, "end", func(b *block) bool { return sequenceGroups[b.kind] }) {
+				p.step(st, &SequenceStep{Kind: "end"})
+			}
+		case p.doc.Type == DiagramActivity:
+			p.node(st, "end", "", depth)
+		default:
+			p.diag(SeverityError, CodeUnmatchedEnd, st, 0, len(text), "end 没有对应的 alt、opt、loop 等分组")
+		}
+	case first == "if":
+		p.labeled(st, "if", condition(text), branchLabel(text, "then"), depth)
+		p.push(st, "if", false, nil)
+	case isElseIf:
+		if p.expectTop(st, "elseif 不在 if 块中", "if") {
+			p.labeled(st, "elseif", condition(text), branchLabel(text, "then"), depth-1)
+		}
+	case first == "else":
+		if top := p.top(); top != nil && top.kind == "if" {
+			p.labeled(st, "else", "", condition(text), depth-1)
+		} else if p.expectTop(st, "else 不在 if 或 alt 等分组中", "alt", "opt", "loop", "par", "par2", "break", "critical", "group") {
+			p.step(st, &SequenceStep{Kind: "else", Text: strings.TrimSpace(text[len(first):])})
+		}
+	case compact == "endif":
+		if p.close(st, "endif", kindIs("if")) {
+			p.node(st, "endif", "", p.activityDepth())
+		}
+	case first == "while":
+		p.labeled(st, "while", condition(text), branchLabel(text, "is"), depth)
+		p.push(st, "while", false, nil)
+	case strings.HasPrefix(compact, "endwhile"):
+		if p.close(st, "endwhile", kindIs("while")) {
+			p.labeled(st, "endwhile", "", condition(text), p.activityDepth())
+		}
+	case first == "repeat":
+		if strings.HasPrefix(compact, "repeatwhile") {
+			if p.close(st, "repeat while", kindIs("repeat")) {
+				p.labeled(st, "repeat_while", condition(text), branchLabel(text, "is"), p.activityDepth())
+			}
+			return true
+		}
+		p.node(st, "repeat", "", depth)
+		p.push(st, "repeat", false, nil)
+	case compact == "fork" || compact == "split":
+		p.node(st, compact, "", depth)
+		p.push(st, compact, false, nil)
+	case compact == "forkagain" || compact == "splitagain":
+		kind := strings.TrimSuffix(compact, "again")
+		if p.expectTop(st, fmt.Sprintf("%s again 不在 %s 块中", kind, kind), kind) {
+			p.node(st, kind+"_again", "", depth-1)
+		}
+	case strings.HasPrefix(compact, "endfork") || compact == "forkend" || strings.HasPrefix(compact, "endmerge"):
+		if p.close(st, "end fork", kindIs("fork")) {
+			p.node(st, "end_fork", "", p.activityDepth())
+		}
+	case compact == "endsplit":
+		if p.close(st, "end split", kindIs("split")) {
+			p.node(st, "end_split", "", p.activityDepth())
+		}
+	case first == "switch":
+		p.node(st, "switch", condition(text), depth)
+		p.push(st, "switch", false, nil)
+	case first == "case":
+		if p.expectTop(st, "case 不在 switch 块中", "switch") {
+			p.node(st, "case", condition(text), depth-1)
+		}
+	case compact == "endswitch":
+		if p.close(st, "endswitch", kindIs("switch")) {
+			p.node(st, "endswitch", "", p.activityDepth())
+		}
+	case first == "partition":
+		name := unquote(strings.TrimSuffix(strings.TrimSpace(text[len(first):]), "{"))
+		p.node(st, "partition", name, depth)
+		if strings.HasSuffix(text, "{") {
+			p.push(st, "partition", true, nil)
+		}
+	case compact == "endgroup":
+		p.close(st, "end group", kindIs("group"))
+	case sequenceGroups[first]:
+		if first == "break" && p.doc.Type == DiagramActivity {
+			p.node(st, "break", "", depth)
+			return true
+		}
+		p.push(st, first, false, nil)
+		p.step(st, &SequenceStep{Kind: "group", Keyword: first, Text: strings.TrimSpace(text[len(first):])})
+	case first == "box":
+		p.push(st, "box", false, nil)
+		p.step(st, &SequenceStep{Kind: "box", Text: unquote(strings.TrimSpace(text[len(first):]))})
+	case compact == "endbox":
+		if p.close(st, "end box", kindIs("box")) {
+			p.step(st, &SequenceStep{Kind: "end_box"})
+		}
+	case first == "together" && strings.HasSuffix(text, "{"):
+		p.push(st, "together", true, nil)
+	case (first == "activate" || first == "deactivate" || first == "destroy") && p.doc.Type == DiagramSequence:
+		rest := text[len(first):]
+		name := strings.TrimSpace(rest)
+		if i := strings.IndexAny(name, " \t#"); i >= 0 {
+			name = name[:i]
+		}
+		if name != "" {
+			p.reference(st, operand{name: unquote(name), idx: len(first) + len(rest) - len(strings.TrimLeft(rest, " \t"))})
+			p.step(st, &SequenceStep{Kind: first, Target: unquote(name)})
+		}
+	case (first == "autonumber" || first == "return") && p.doc.Type == DiagramSequence:
+		p.step(st, &SequenceStep{Kind: first, Text: strings.TrimSpace(text[len(first):])})
+	default:
+		return false
+	}
+	return true
+}
+
+// declaration 参与者、类、实体、组件、容器等的关键字声明
+func (p *parser) declaration(st statement, first string) bool {
+	text := st.text
+	kind, from := first, len(first)
+	switch first {
+	case "abstract":
+		rest := strings.TrimLeft(text[from:], " \t")
+		if firstWord(strings.ToLower(rest)) == "class" {
+			from = len(text) - len(rest) + len("class")
+		}
+	case "create":
+		// create [participant] B 在消息中途创建参与者，同样是声明
+		if p.doc.Type != DiagramSequence {
+			return false
+		}
+		rest := strings.TrimLeft(text[from:], " \t")
+		kind = "participant"
+		if word := firstWord(strings.ToLower(rest)); participantKinds[word] {
+			kind, from = word, len(text)-len(rest)+len(word)
+		}
+	}
+	if from >= len(text) || text[from] != ' ' && text[from] != '\t' && text[from] != '{' {
+		return false
+	}
+
+	var body bool
+	switch {
+	case p.doc.Type == DiagramSequence && participantKinds[kind]:
+	case classKinds[kind] && !(kind == "entity" && p.doc.Type == DiagramSequence):
+		body = true
+	case containerKinds[kind]:
+	default:
+		return false
+	}
+
+	d := parseDecl(text, from)
+	if d.name == "" {
+		// cloud { ... } 这样的匿名容器只用于分组
+		if d.brace && !body {
+			p.push(st, kind, true, nil)
+			return true
+		}
+		p.diag(SeverityError, CodeSyntaxError, st, 0, len(text), fmt.Sprintf("%s 缺少名称", first))
+		return true
+	}
+	if body {
+		d.name = stripGeneric(d.name)
+	}
+	e := p.declare(st, kind, d)
+	for _, parent := range d.parents {
+		p.doc.Relations = append(p.doc.Relations, &Relation{From: e.ID(), To: parent.name, Arrow: parent.arrow, Pos: p.pos(st, 0)})
+		p.reference(st, operand{name: parent.name, idx: parent.idx})
+	}
+	if d.brace {
+		if body {
+			p.push(st, "body", true, e)
+		} else {
+			p.push(st, kind, true, e)
+		}
+	}
+	return true
+}
+
+// shorthand [组件]、(用例)、:参与者: 形式的声明
+func (p *parser) shorthand(st statement) bool {
+	d := parseDecl(st.text, 0)
+	if d.name == "" {
+		return false
+	}
+	kind := "component"
+	switch st.text[0] {
+	case '(':
+		kind = "usecase"
+	case ':':
+		kind = "actor"
+	}
+	e := p.declare(st, kind, d)
+	if d.brace {
+		p.push(st, kind, true, e)
+	}
+	return true
+}
+
+// declare 登记显式声明的元素，检查别名和名称重复
+func (p *parser) declare(st statement, kind string, d decl) *Element {
+	if d.alias != "" {
+		if other := p.byAlias[d.alias]; other != nil {
+			p.diag(SeverityError, CodeDuplicateAlias, st, d.aliasIdx, d.aliasIdx+len(d.alias),
+				fmt.Sprintf("别名 %s 重复，已在第 %d 行用于 %s", d.alias, other.Pos.Line, other.Name))
+		} else if other := p.byName[d.alias]; other != nil && other.Name != d.name && !other.Implicit {
+			p.diag(SeverityError, CodeDuplicateAlias, st, d.aliasIdx, d.aliasIdx+len(d.alias),
+				fmt.Sprintf("别名 %s 与第 %d 行声明的元素重名", d.alias, other.Pos.Line))
+		}
+	}
+
+	existing := p.byAlias[d.name]
+	if existing == nil && (d.alias == "" || p.byName[d.name] != nil && p.byName[d.name].Alias == d.alias) {
+		existing = p.byName[d.name]
+	}
+	if existing == nil && d.alias != "" {
+		// 先以别名被引用、后声明的元素
+		if e := p.byName[d.alias]; e != nil && e.Implicit {
+			existing = e
+			delete(p.byName, d.alias)
+			e.Name = d.name
+			p.byName[d.name] = e
+		}
+	}
+	if existing != nil {
+		if !existing.Implicit {
+			p.diag(SeverityWarning, CodeDuplicateElement, st, d.nameIdx, d.nameIdx+len(d.name),
+				fmt.Sprintf("%s 重复声明，首次声明在第 %d 行", d.name, existing.Pos.Line))
+			return existing
+		}
+		existing.Kind, existing.Stereotype, existing.Implicit = kind, d.stereotype, false
+		existing.Pos = p.pos(st, d.nameIdx)
+		if d.alias != "" && existing.Alias == "" {
+			existing.Alias = d.alias
+			p.byAlias[d.alias] = existing
+		}
+		p.explicit++
+		return existing
+	}
+
+	e := &Element{
+		Kind:       kind,
+		Name:       d.name,
+		Alias:      d.alias,
+		Stereotype: d.stereotype,
+		Parent:     p.parent(),
+		Pos:        p.pos(st, d.nameIdx),
+	}
+	p.add(e)
+	p.explicit++
+	return e
+}
+
+// reference 关系或 activate 等语句引用的元素，不存在时隐式创建
+func (p *parser) reference(st statement, op operand) {
+	if op.external || op.name == "" || p.doc.Type == DiagramActivity {
+		return
+	}
+	if p.lookup(op.name) != nil {
+		return
+	}
+	kind := op.kind
+	if kind == "" {
+		kind = p.defaultKind()
+	}
+	e := &Element{Kind: kind, Name: op.name, Parent: p.parent(), Implicit: true, Pos: p.pos(st, op.idx)}
+	p.add(e)
+	if op.kind == "" {
+		p.refs = append(p.refs, reference{element: e, pos: e.Pos, endColumn: e.Pos.Column + runeCount(op.name)})
+	}
+}
+
+func (p *parser) add(e *Element) {
+	p.doc.Elements = append(p.doc.Elements, e)
+	if _, ok := p.byName[e.Name]; !ok {
+		p.byName[e.Name] = e
+	}
+	if e.Alias != "" {
+		p.byAlias[e.Alias] = e
+	}
+}
+
+func (p *parser) lookup(ref string) *Element {
+	if e := p.byAlias[ref]; e != nil {
+		return e
+	}
+	return p.byName[ref]
+}
+
+func (p *parser) defaultKind() string {
+	switch p.doc.Type {
+	case DiagramSequence:
+		return "participant"
+	case DiagramClass:
+		return "class"
+	case DiagramEntity:
+		return "entity"
+	case DiagramComponent:
+		return "component"
+	case DiagramUseCase:
+		return "usecase"
+	}
+	return "element"
+}
+
+// parent 当前所在容器的名称
+func (p *parser) parent() string {
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		if b := p.stack[i]; b.element != nil && b.kind != "body" {
+			return b.element.Name
+		}
+	}
+	return ""
+}
+
+// relation 解析关系或消息，arrow 为箭头在语句中的字节区间
+func (p *parser) relation(st statement, start, end int) bool {
+	text := st.text
+	rightRaw, label := splitLabel(text[end:])
+	rightIdx := end
+	// 旧语法的活动图用紧跟箭头的 -->[标签] 标注连线
+	if p.doc.Type == DiagramActivity && label == "" && strings.HasPrefix(rightRaw, "[") {
+		if j := strings.IndexByte(rightRaw, ']'); j > 0 {
+			label, rightRaw, rightIdx = rightRaw[1:j], rightRaw[j+1:], end+j+1
+		}
+	}
+	from := parseOperand(text[:start], 0, true)
+	to := parseOperand(rightRaw, rightIdx, false)
+	if from.name == "" || to.name == "" {
+		return false
+	}
+
+	rel := &Relation{
+		From:            from.name,
+		To:              to.name,
+		Arrow:           text[start:end],
+		Label:           label,
+		FromCardinality: from.card,
+		ToCardinality:   to.card,
+		Pos:             p.pos(st, 0),
+	}
+	p.doc.Relations = append(p.doc.Relations, rel)
+	p.step(st, &SequenceStep{Kind: "message", Relation: rel})
+	p.reference(st, from)
+	p.reference(st, to)
+	if p.doc.Type == DiagramActivity {
+		p.node(st, "arrow", label, p.activityDepth())
+	}
+	return true
+}
+
+// action 活动图中的 :活动; 语句，可能跨多行
+func (p *parser) action(st statement) {
+	text := st.text
+	p.node(st, "action", strings.TrimSpace(text[1:len(text)-1]), p.activityDepth())
+}
+
+// member 类或实体主体中的一行
+func (p *parser) member(st statement) *Member {
+	text := st.text
+	if memberSepRe.MatchString(text) {
+		return nil
+	}
+	m := &Member{Text: text, Pos: p.pos(st, 0)}
+	lower := strings.ToLower(text)
+	m.PrimaryKey = strings.Contains(lower, "<<pk>>") || strings.Contains(lower, "{pk}") || strings.Contains(lower, "<u>")
+	m.ForeignKey = strings.Contains(lower, "<<fk>>") || strings.Contains(lower, "{fk}")
+
+	s := text
+	if mm := memberMacroRe.FindStringSubmatch(s); mm != nil {
+		name := strings.ToLower(mm[1])
+		if _, ok := p.macros[mm[1]]; ok || keyMacros[name] {
+			switch name {
+			case "primary_key", "pk":
+				m.PrimaryKey = true
+			case "foreign_key", "fk":
+				m.ForeignKey = true
+			}
+			s = mm[2] + " " + mm[3]
+		}
+	}
+	s = strings.TrimSpace(memberTagRe.ReplaceAllString(s, ""))
+	s = strings.TrimSpace(strings.TrimPrefix(s, "*"))
+	if s != "" && strings.ContainsRune("+-#~", rune(s[0])) {
+		m.Visibility = s[:1]
+		s = strings.TrimSpace(s[1:])
+	}
+
+	// varchar(50) 等类型中的括号不表示方法
+	if i := strings.Index(s, "("); i > 0 && !strings.Contains(s[:i], ":") {
+		m.Method = true
+		m.Name = strings.TrimSpace(s[:i])
+		if j := strings.LastIndex(s, ")"); j > i {
+			if after := strings.TrimSpace(s[j+1:]); strings.HasPrefix(after, ":") {
+				m.Type = strings.TrimSpace(after[1:])
+			}
+		}
+		if fields := strings.Fields(m.Name); len(fields) > 1 {
+			m.Type, m.Name = strings.Join(fields[:len(fields)-1], " "), fields[len(fields)-1]
+		}
+		return m
+	}
+	if i := strings.Index(s, ":"); i >= 0 {
+		m.Name, m.Type = strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+	} else if fields := strings.Fields(s); len(fields) == 2 {
+		m.Type, m.Name = fields[0], fields[1]
+	} else {
+		m.Name = s
+	}
+	return m
+}
+
+// memberStatement 类主体之外以 类名 : 成员 形式添加的成员
+func (p *parser) memberStatement(st statement) bool {
+	i := strings.Index(st.text, ":")
+	if i <= 0 {
+		return false
+	}
+	e := p.lookup(unquote(st.text[:i]))
+	if e == nil || !classKinds[e.Kind] {
+		return false
+	}
+	rest := strings.TrimSpace(st.text[i+1:])
+	if rest == "" {
+		return false
+	}
+	if !st.expanded {
+		st.col += runeCount(st.text[:strings.Index(st.text[i+1:], rest)+i+1])
+	}
+	st.text = rest
+	if m := p.member(st); m != nil {
+		e.Members = append(e.Members, m)
+	}
+	return true
+}
+
+// finish 报告未闭合的块、未结束的活动和未声明的参与者
+func (p *parser) finish() {
+	if p.pending != nil {
+		first := strings.SplitN(p.pending.text, "\n", 2)[0]
+		p.doc.Diagnostics = append(p.doc.Diagnostics, Diagnostic{
+			Severity: SeverityError, Code: CodeUnterminatedAction, Message: "活动缺少结束符 ;",
+			Line: p.pending.line, Column: p.pending.col, EndColumn: p.pending.col + runeCount(first),
+		})
+	}
+	for _, b := range p.stack {
+		p.unclosed(b)
+	}
+	p.stack = nil
+
+	if p.explicit == 0 {
+		return
+	}
+	code, label := CodeUndefinedElement, "元素"
+	switch p.doc.Type {
+	case DiagramSequence:
+		code, label = CodeUndefinedParticipant, "参与者"
+	case DiagramClass, DiagramEntity:
+	default:
+		return
+	}
+	seen := make(map[*Element]bool)
+	for _, ref := range p.refs {
+		if !ref.element.Implicit || seen[ref.element] {
+			continue
+		}
+		seen[ref.element] = true
+		p.doc.Diagnostics = append(p.doc.Diagnostics, Diagnostic{
+			Severity: SeverityWarning, Code: code,
+			Message: fmt.Sprintf("%s %s 未声明，将被隐式创建", label, ref.element.Name),
+			Line:    ref.pos.Line, Column: ref.pos.Column, EndColumn: ref.endColumn,
+		})
+	}
+}
+
+// node 记录活动图节点，其他类型的图忽略
+func (p *parser) node(st statement, kind, text string, depth int) {
+	p.labeled(st, kind, text, "", depth)
+}
+
+// labeled 记录带分支标签的活动图节点
+func (p *parser) labeled(st statement, kind, text, label string, depth int) {
+	if p.doc.Type != DiagramActivity {
+		return
+	}
+	if depth < 0 {
+		depth = 0
+	}
+	p.doc.Activity = append(p.doc.Activity, &ActivityNode{Kind: kind, Text: text, Label: label, Depth: depth, Pos: p.pos(st, 0)})
+}
+
+// step 记录序列图步骤，其他类型的图忽略
+func (p *parser) step(st statement, s *SequenceStep) {
+	if p.doc.Type != DiagramSequence {
+		return
+	}
+	s.Pos = p.pos(st, 0)
+	p.doc.Sequence = append(p.doc.Sequence, s)
+}
+
+func (p *parser) activityDepth() int {
+	depth := 0
+	for _, b := range p.stack {
+		if activityBlocks[b.kind] {
+			depth++
+		}
+	}
+	return depth
+}
+
+func (p *parser) push(st statement, kind string, brace bool, e *Element) *block {
+	label := kind
+	if fields := strings.Fields(st.orig); len(fields) > 0 {
+		label = fields[0]
+	}
+	b := &block{kind: kind, label: label, pos: p.pos(st, 0), width: runeCount(st.orig), brace: brace, element: e}
+	p.stack = append(p.stack, b)
+	return b
+}
+
+func (p *parser) pushText(st statement, kind string, closers ...string) {
+	p.push(st, kind, false, nil).closers = closers
+}
+
+func (p *parser) top() *block {
+	if len(p.stack) == 0 {
+		return nil
+	}
+	return p.stack[len(p.stack)-1]
+}
+
+func (p *parser) pop() {
+	p.stack = p.stack[:len(p.stack)-1]
+}
+
+func (p *parser) nearest(match func(*block) bool) *block {
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		if match(p.stack[i]) {
+			return p.stack[i]
+		}
+	}
+	return nil
+}
+
+// close 关闭最近的匹配块，其上未闭合的块报告错误；没有匹配的块时报告多余的结束语句
+func (p *parser) close(st statement, label string, match func(*block) bool) bool {
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		if match(p.stack[i]) {
+			for _, b := range p.stack[i+1:] {
+				p.unclosed(b)
+			}
+			p.stack = p.stack[:i]
+			return true
+		}
+	}
+	p.diag(SeverityError, CodeUnmatchedEnd, st, 0, len(st.text), fmt.Sprintf("%s 没有匹配的开始", label))
+	return false
+}
+
+// expectTop else、case 等中间语句只能直接出现在指定块中
+func (p *parser) expectTop(st statement, msg string, kinds ...string) bool {
+	if top := p.top(); top != nil {
+		for _, kind := range kinds {
+			if top.kind == kind {
+				return true
+			}
+		}
+	}
+	p.diag(SeverityError, CodeUnmatchedEnd, st, 0, len(st.text), msg)
+	return false
+}
+
+func (p *parser) unclosed(b *block) {
+	p.doc.Diagnostics = append(p.doc.Diagnostics, Diagnostic{
+		Severity:  SeverityError,
+		Code:      CodeUnclosedBlock,
+		Message:   fmt.Sprintf("%s 缺少对应的 %s", b.label, closerOf(b)),
+		Line:      b.pos.Line,
+		Column:    b.pos.Column,
+		EndColumn: b.pos.Column + b.width,
+	})
+}
+
+func closerOf(b *block) string {
+	if b.brace || b.kind == "skinparam" {
+		return "}"
+	}
+	switch b.kind {
+	case "if":
+		return "endif"
+	case "while":
+		return "endwhile"
+	case "repeat":
+		return "repeat while"
+	case "fork", "split", "box", "note", "ref", "title":
+		return "end " + b.kind
+	case "switch", "legend", "header", "footer":
+		return "end" + b.kind
+	case "style":
+		return "</style>"
+	}
+	return "end"
+}
+
+func kindIs(kind string) func(*block) bool {
+	return func(b *block) bool { return b.kind == kind }
+}
+
+// pos 语句中字节偏移 idx 处的位置；宏展开后的语句无法对应到源码，统一指向语句开头
+func (p *parser) pos(st statement, idx int) Position {
+	if st.expanded || idx > len(st.text) {
+		return Position{Line: st.line, Column: st.col}
+	}
+	return Position{Line: st.line, Column: st.col + runeCount(st.text[:idx])}
+}
+
+// diag 记录一条诊断，start 和 end 为语句中的字节区间
+func (p *parser) diag(severity Severity, code string, st statement, start, end int, msg string) {
+	from := p.pos(st, start)
+	endColumn := st.col + runeCount(st.orig)
+	if !st.expanded && end <= len(st.text) {
+		endColumn = from.Column + runeCount(st.text[start:end])
+	}
+	if endColumn <= from.Column {
+		endColumn = from.Column + 1
+	}
+	p.doc.Diagnostics = append(p.doc.Diagnostics, Diagnostic{
+		Severity:  severity,
+		Code:      code,
+		Message:   msg,
+		Line:      st.line,
+		Column:    from.Column,
+		EndColumn: endColumn,
+	})
+}
+
+// findArrow 查找语句中第一个箭头，跳过引号、[组件]、(用例) 和开头的 :参与者:；
+// 遇到标签分隔符 : 仍未找到时返回 -1
+func findArrow(s string) (int, int) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			j := strings.IndexByte(s[i+1:], '"')
+			if j < 0 {
+				return -1, -1
+			}
+			i += j + 1
+			continue
+		case (c == '[' || c == '(') && (i == 0 || s[i-1] != '-' && s[i-1] != '.'):
+			closer := byte(']')
+			if c == '(' {
+				closer = ')'
+			}
+			if j := strings.IndexByte(s[i+1:], closer); j >= 0 {
+				i += j + 1
+			}
+			continue
+		case c == ':':
+			if i > 0 {
+				return -1, -1
+			}
+			j := strings.IndexByte(s[1:], ':')
+			if j < 0 {
+				return -1, -1
+			}
+			i += j + 1
+			continue
+		case strings.IndexByte(arrowStartings, c) < 0:
+			continue
+		}
+
+		// o、x 修饰只能紧跟在空白或名称的界定符之后，避免把 Foo--> 中的 o 当作箭头的一部分
+		if (c == 'o' || c == 'x') && (i == 0 || !strings.ContainsRune(" \t\"])", rune(s[i-1]))) {
+			continue
+		}
+		m := arrowRe.FindString(s[i:])
+		if m == "" || !strings.ContainsAny(m, "-.") {
+			continue
+		}
+		end := i + len(m)
+		for end > i && (s[end-1] == 'o' || s[end-1] == 'x') && end < len(s) && !strings.ContainsRune(" \t\"[(", rune(s[end])) {
+			end--
+		}
+		if validArrow(s, i, end) {
+			return i, end
+		}
+	}
+	return -1, -1
+}
+
+// validArrow 单个 - 只有两侧都是空白时才算连线，单个 . 不算
+func validArrow(s string, start, end int) bool {
+	arrow := s[start:end]
+	dashes, dots := strings.Count(arrow, "-"), strings.Count(arrow, ".")
+	switch {
+	case dashes >= 2 || dots >= 2:
+		return true
+	case dashes == 0:
+		return false
+	case len(arrow) > 1:
+		return true
+	}
+	return start > 0 && end < len(s) && s[start-1] == ' ' && s[end] == ' '
+}
+
+// splitLabel 拆分关系右侧的端点和 : 之后的标签
+func splitLabel(rest string) (string, string) {
+	i := 0
+	for i < len(rest) && (rest[i] == ' ' || rest[i] == '\t') {
+		i++
+	}
+	for ; i < len(rest); i++ {
+		c := rest[i]
+		var closer byte
+		switch c {
+		case '"':
+			closer = '"'
+		case '[':
+			closer = ']'
+		case '(':
+			closer = ')'
+		case ':':
+			if strings.TrimSpace(rest[:i]) != "" {
+				return rest[:i], strings.TrimSpace(rest[i+1:])
+			}
+			closer = ':'
+		}
+		if closer != 0 {
+			if j := strings.IndexByte(rest[i+1:], closer); j >= 0 {
+				i += j + 1
+			}
+		}
+	}
+	return rest, ""
+}
+
+// parseOperand 解析关系一端的元素引用和基数，base 为 raw 在语句中的字节偏移
+func parseOperand(raw string, base int, left bool) operand {
+	s := strings.TrimRight(raw, " \t")
+	trimmed := strings.TrimLeft(s, " \t")
+	op := operand{idx: base + len(s) - len(trimmed)}
+	s = trimmed
+
+	if left {
+		if strings.HasSuffix(s, "\"") {
+			if q := strings.LastIndex(s[:len(s)-1], "\""); q > 0 && strings.TrimSpace(s[:q]) != "" {
+				op.card = s[q+1 : len(s)-1]
+				s = strings.TrimSpace(s[:q])
+			}
+		}
+	} else {
+		// 序列图消息右侧的激活标记
+		for _, suffix := range []string{"++", "--", "**", "!!"} {
+			s = strings.TrimSpace(strings.TrimSuffix(s, suffix))
+		}
+		if strings.HasPrefix(s, "\"") {
+			if q := strings.IndexByte(s[1:], '"'); q >= 0 && strings.TrimSpace(s[q+2:]) != "" {
+				op.card = s[1 : q+1]
+				rest := s[q+2:]
+				op.idx += q + 2 + len(rest) - len(strings.TrimLeft(rest, " \t"))
+				s = strings.TrimSpace(rest)
+			}
+		}
+	}
+
+	n := len(s)
+	switch {
+	case s == "[" || s == "]" || s == "(*)" || s == "(*top)":
+		op.name, op.external = s, true
+	case n > 2 && s[0] == '[' && s[n-1] == ']':
+		op.name, op.kind = s[1:n-1], "component"
+		op.idx++
+	case n > 2 && s[0] == '(' && s[n-1] == ')':
+		op.name, op.kind = s[1:n-1], "usecase"
+		op.idx++
+	case n > 2 && s[0] == ':' && s[n-1] == ':':
+		op.name, op.kind = s[1:n-1], "actor"
+		op.idx++
+	case n >= 2 && s[0] == '"' && s[n-1] == '"':
+		op.name = s[1 : n-1]
+		op.idx++
+	default:
+		op.name = s
+	}
+	return op
+}
+
+// declParent extends/implements 的父类型
+type declParent struct {
+	name  string
+	idx   int
+	arrow string
+}
+
+// decl 声明语句中关键字之后的部分
+type decl struct {
+	name       string
+	nameIdx    int
+	alias      string
+	aliasIdx   int
+	stereotype string
+	parents    []declParent
+	brace      bool
+}
+
+// parseDecl 解析 名称 [as 别名] [<<构造型>>] [extends X] [implements Y] [#颜色] [{]
+func parseDecl(text string, from int) decl {
+	var d decl
+	i := skipSpaces(text, from)
+	name, nameIdx, next, nameQuoted := declToken(text, i)
+	d.name, d.nameIdx = name, nameIdx
+	aliasQuoted := false
+	i = next
+
+	for {
+		i = skipSpaces(text, i)
+		if i >= len(text) {
+			break
+		}
+		rest := text[i:]
+		lowerRest := strings.ToLower(rest)
+		switch {
+		case rest[0] == '{':
+			d.brace = true
+			i++
+		case strings.HasPrefix(rest, "<<"):
+			end := strings.Index(rest, ">>")
+			if end < 0 {
+				i = len(text)
+				continue
+			}
+			d.stereotype = strings.TrimSpace(rest[2:end])
+			i += end + 2
+		case hasWord(lowerRest, "as"):
+			alias, aliasIdx, next, quoted := declToken(text, skipSpaces(text, i+2))
+			d.alias, d.aliasIdx, aliasQuoted = alias, aliasIdx, quoted
+			i = next
+		case hasWord(lowerRest, "extends"), hasWord(lowerRest, "implements"):
+			arrow := "--|>"
+			if hasWord(lowerRest, "implements") {
+				arrow = "..|>"
+			}
+			i += strings.IndexAny(rest, " \t")
+			for {
+				parent, idx, next, _ := declToken(text, skipSpaces(text, i))
+				if parent == "" {
+					break
+				}
+				d.parents = append(d.parents, declParent{name: strings.TrimSuffix(parent, ","), idx: idx, arrow: arrow})
+				i = skipSpaces(text, next)
+				if i < len(text) && text[i] == ',' {
+					i++
+				} else if !strings.HasSuffix(parent, ",") {
+					break
+				}
+			}
+		default:
+			if j := strings.IndexAny(rest, " \t"); j >= 0 {
+				i += j
+			} else {
+				i = len(text)
+			}
+		}
+	}
+
+	// participant L as "长名称" 的反向写法
+	if d.alias != "" && aliasQuoted && !nameQuoted {
+		d.name, d.alias = d.alias, d.name
+		d.nameIdx, d.aliasIdx = d.aliasIdx, d.nameIdx
+	}
+	return d
+}
+
+// declToken 读取一个名称：引号、[ ]、( )、: : 包围的内容，或直到空白、{、<< 的连续字符
+func declToken(text string, i int) (string, int, int, bool) {
+	if i >= len(text) {
+		return "", i, i, false
+	}
+	closers := map[byte]byte{'"': '"', '[': ']', '(': ')', ':': ':'}
+	if closer, ok := closers[text[i]]; ok {
+		if j := strings.IndexByte(text[i+1:], closer); j >= 0 {
+			return text[i+1 : i+1+j], i + 1, i + 2 + j, text[i] == '"'
+		}
+	}
+	j := i
+	for j < len(text) && text[j] != ' ' && text[j] != '\t' && text[j] != '{' && !strings.HasPrefix(text[j:], "<<") {
+		// Map<K, V> 这样的泛型参数中可以有空白
+		if text[j] == '<' && j > i {
+			if k := strings.IndexByte(text[j:], '>'); k > 0 {
+				j += k
+			}
+		}
+		j++
+	}
+	return text[i:j], i, j, false
+}
+
+// stripGeneric 去掉类名后的泛型参数，List<T> 登记为 List
+func stripGeneric(name string) string {
+	if i := strings.IndexByte(name, '<'); i > 0 && strings.HasSuffix(name, ">") {
+		return strings.TrimSpace(name[:i])
+	}
+	return name
+}
+
+func skipSpaces(text string, i int) int {
+	for i < len(text) && (text[i] == ' ' || text[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+func hasWord(s, word string) bool {
+	return strings.HasPrefix(s, word) && (len(s) == len(word) || s[len(word)] == ' ' || s[len(word)] == '\t')
+}
+
+// actionTerminated 活动语句是否已以结束符收尾
+func actionTerminated(text string) bool {
+	return text != "" && strings.IndexByte(";|<>/\\]}", text[len(text)-1]) >= 0
+}
+
+// condition 提取 if (条件)、while (条件) 等语句中第一对括号内的文本
+func condition(text string) string {
+	start := strings.IndexByte(text, '(')
+	if start < 0 {
+		return ""
+	}
+	depth := 0
+	for i := start; i < len(text); i++ {
+		switch text[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return strings.TrimSpace(text[start+1 : i])
+			}
+		}
+	}
+	return strings.TrimSpace(text[start+1:])
+}
+
+// branchLabel 提取条件之后 then (是)、is (是) 等分支标签
+func branchLabel(text, word string) string {
+	start := strings.IndexByte(text, '(')
+	if start < 0 {
+		return ""
+	}
+	depth := 0
+	for i := start; i < len(text); i++ {
+		switch text[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				rest := strings.TrimSpace(text[i+1:])
+				if hasWord(strings.ToLower(rest), word) {
+					return condition(rest)
+				}
+				return ""
+			}
+		}
+	}
+	return ""
+}
+
+// firstWord 开头的 ASCII 单词
+func firstWord(lower string) string {
+	i := 0
+	for i < len(lower) && (lower[i] >= 'a' && lower[i] <= 'z' || lower[i] >= '0' && lower[i] <= '9' || lower[i] == '_') {
+		i++
+	}
+	return lower[:i]
+}
+
+// suggest 编辑距离不超过 2 的最接近的关键字
+func suggest(word string) string {
+	if len(word) < 4 {
+		return ""
+	}
+	candidates := make([]string, 0, len(keywords))
+	for keyword := range keywords {
+		candidates = append(candidates, keyword)
+	}
+	sort.Strings(candidates)
+	return closest(word, candidates)
+}
+
+// closest 候选词中与 word 编辑距离不超过 2 的最接近的一个，比较时忽略大小写
+func closest(word string, candidates []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range candidates {
+		if d := editDistance(word, strings.ToLower(candidate)); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func runeCount(s string) int {
+	return utf8.RuneCountInString(s)
+}
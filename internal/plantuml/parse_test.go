@@ -0,0 +1,493 @@
+package plantuml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func diagnosticCodes(doc *Document) []string {
+	var codes []string
+	for _, d := range doc.Diagnostics {
+		codes = append(codes, d.Code)
+	}
+	return codes
+}
+
+func findDiagnostic(doc *Document, code string) *Diagnostic {
+	for i := range doc.Diagnostics {
+		if doc.Diagnostics[i].Code == code {
+			return &doc.Diagnostics[i]
+		}
+	}
+	return nil
+}
+
+func TestParse_GeneratedDiagramsHaveNoDiagnostics(t *testing.T) {
+	sources := map[DiagramType]string{
+		DiagramSequence: `@startuml 用户登录
+actor 用户
+participant "前端" as FE
+participant 后端
+database 数据库
+用户 -> FE: 输入账号密码
+FE -> 后端: 登录请求
+activate 后端
+alt 验证成功
+    后端 -> 数据库: 查询用户
+    数据库 --> 后端: 用户信息
+    后端 --> FE: 令牌
+else 验证失败
+    后端 --> FE: 错误信息
+end
+deactivate 后端
+@enduml`,
+		DiagramActivity: `@startuml
+start
+:打开登录页;
+if (已注册?) then (是)
+  :输入密码;
+else (否)
+  :注册账号;
+endif
+while (密码错误?)
+  :重新输入;
+endwhile
+stop
+@enduml`,
+		DiagramClass: `@startuml
+class 用户 {
+  +用户名 : String
+  -密码 : String
+  +登录() : bool
+}
+class 订单
+用户 --> 订单 : 使用
+@enduml`,
+		DiagramComponent: `@startuml
+package "前端" {
+  [Web界面]
+}
+package "后端" {
+  [API服务]
+}
+database "MySQL" {
+  [用户表]
+}
+[Web界面] --> [API服务]
+[API服务] --> [用户表]
+@enduml`,
+		DiagramEntity: `@startuml
+!define table(x) class x << (T,#FFAAAA) >>
+table(用户) {
+  primary_key(用户ID) : bigint
+  用户名 : varchar
+}
+table(项目) {
+  primary_key(项目ID) : bigint
+  foreign_key(用户ID) : bigint
+}
+用户 ||--o{ 项目 : 拥有
+@enduml`,
+		DiagramUseCase: `@startuml
+left to right direction
+actor 管理员
+rectangle 系统 {
+  (管理用户) as UC1
+  usecase "查看报表" as UC2
+}
+管理员 --> UC1
+管理员 --> UC2
+@enduml`,
+	}
+
+	for want, source := range sources {
+		doc := Parse(source)
+		assert.Equal(t, want, doc.Type, source)
+		assert.Empty(t, doc.Diagnostics, "%s: %v", want, doc.Diagnostics)
+	}
+}
+
+func TestParse_SequenceAST(t *testing.T) {
+	doc := Parse(`@startuml
+participant "Web 前端" as FE <<UI>>
+participant 后端
+FE -> 后端 ++ : 请求
+后端 --> FE : 响应
+@enduml`)
+
+	require.Len(t, doc.Elements, 2)
+	fe := doc.Element("FE")
+	require.NotNil(t, fe)
+	assert.Equal(t, "Web 前端", fe.Name)
+	assert.Equal(t, "UI", fe.Stereotype)
+	assert.Equal(t, Position{Line: 2, Column: 14}, fe.Pos)
+
+	require.Len(t, doc.Relations, 2)
+	assert.Equal(t, &Relation{From: "FE", To: "后端", Arrow: "->", Label: "请求", Pos: Position{Line: 4, Column: 1}}, doc.Relations[0])
+	assert.Equal(t, "-->", doc.Relations[1].Arrow)
+}
+
+func TestParse_SequenceSteps(t *testing.T) {
+	doc := Parse(`@startuml
+participant A
+participant B
+A -> B : 请求
+activate B
+alt 成功
+  B --> A : 数据
+else 失败
+  B --> A : 错误
+end
+note over A, B
+  两行
+  注释
+end note
+== 结束 ==
+@enduml`)
+
+	require.Empty(t, doc.Diagnostics)
+	var kinds []string
+	for _, s := range doc.Sequence {
+		kinds = append(kinds, s.Kind)
+	}
+	assert.Equal(t, []string{"message", "activate", "group", "message", "else", "message", "end", "note", "divider"}, kinds)
+	assert.Same(t, doc.Relations[0], doc.Sequence[0].Relation)
+	assert.Equal(t, "B", doc.Sequence[1].Target)
+	assert.Equal(t, &SequenceStep{Kind: "group", Keyword: "alt", Text: "成功", Pos: Position{Line: 6, Column: 1}}, doc.Sequence[2])
+	assert.Equal(t, "失败", doc.Sequence[4].Text)
+	assert.Equal(t, "over A, B", doc.Sequence[7].Target)
+	assert.Equal(t, "两行\n注释", doc.Sequence[7].Text)
+	assert.Equal(t, "结束", doc.Sequence[8].Text)
+}
+
+func TestParse_UndefinedParticipant(t *testing.T) {
+	doc := Parse(`@startuml
+participant A
+participant B
+A -> B: ok
+  A -> 支付服务: 扣款
+支付服务 --> A: 结果
+@enduml`)
+
+	require.Equal(t, []string{CodeUndefinedParticipant}, diagnosticCodes(doc))
+	d := doc.Diagnostics[0]
+	assert.Equal(t, SeverityWarning, d.Severity)
+	assert.Equal(t, 5, d.Line)
+	assert.Equal(t, 8, d.Column)
+	assert.Equal(t, 12, d.EndColumn)
+	assert.True(t, doc.Element("支付服务").Implicit)
+}
+
+func TestParse_ImplicitParticipantsWithoutDeclarations(t *testing.T) {
+	doc := Parse("@startuml\nAlice -> Bob: hi\nBob --> Alice: hey\n@enduml")
+	assert.Empty(t, doc.Diagnostics)
+	assert.Len(t, doc.Elements, 2)
+}
+
+func TestParse_DuplicateAlias(t *testing.T) {
+	doc := Parse(`@startuml
+participant "订单服务" as S
+participant "库存服务" as S
+participant "订单服务" as O
+participant O
+@enduml`)
+
+	alias := findDiagnostic(doc, CodeDuplicateAlias)
+	require.NotNil(t, alias)
+	assert.Equal(t, SeverityError, alias.Severity)
+	assert.Equal(t, 3, alias.Line)
+	assert.Equal(t, 23, alias.Column)
+	assert.Contains(t, alias.Message, "第 2 行")
+
+	duplicate := findDiagnostic(doc, CodeDuplicateElement)
+	require.NotNil(t, duplicate)
+	assert.Equal(t, 5, duplicate.Line)
+	assert.True(t, doc.HasErrors())
+}
+
+func TestParse_UnmatchedBlocks(t *testing.T) {
+	t.Run("alt without end", func(t *testing.T) {
+		doc := Parse("@startuml\nA -> B: x\nalt 成功\n  B -> A: y\n@enduml")
+		require.Equal(t, []string{CodeUnclosedBlock}, diagnosticCodes(doc))
+		assert.Equal(t, 3, doc.Diagnostics[0].Line)
+		assert.Equal(t, "alt 缺少对应的 end", doc.Diagnostics[0].Message)
+	})
+
+	t.Run("stray end", func(t *testing.T) {
+		doc := Parse("@startuml\nA -> B: x\nend\n@enduml")
+		require.Equal(t, []string{CodeUnmatchedEnd}, diagnosticCodes(doc))
+		assert.Equal(t, 3, doc.Diagnostics[0].Line)
+	})
+
+	t.Run("if closed by end", func(t *testing.T) {
+		doc := Parse("@startuml\nstart\nif (a) then\n  :x;\nendwhile\nstop\n@enduml")
+		assert.Equal(t, []string{CodeUnclosedBlock, CodeUnmatchedEnd}, diagnosticCodes(doc))
+	})
+
+	t.Run("endif closes inner while", func(t *testing.T) {
+		doc := Parse("@startuml\nstart\nif (a) then\nwhile (b)\n:x;\nendif\nstop\n@enduml")
+		require.Equal(t, []string{CodeUnclosedBlock}, diagnosticCodes(doc))
+		assert.Equal(t, 4, doc.Diagnostics[0].Line)
+		assert.Equal(t, "while 缺少对应的 endwhile", doc.Diagnostics[0].Message)
+	})
+
+	t.Run("package brace", func(t *testing.T) {
+		doc := Parse("@startuml\npackage \"后端\" {\n  [API]\n@enduml")
+		require.Equal(t, []string{CodeUnclosedBlock}, diagnosticCodes(doc))
+		assert.Equal(t, "package 缺少对应的 }", doc.Diagnostics[0].Message)
+		assert.Equal(t, 2, doc.Diagnostics[0].Line)
+	})
+
+	t.Run("extra brace", func(t *testing.T) {
+		doc := Parse("@startuml\nclass A {\n}\n}\n@enduml")
+		require.Equal(t, []string{CodeUnmatchedEnd}, diagnosticCodes(doc))
+		assert.Equal(t, 4, doc.Diagnostics[0].Line)
+	})
+
+	t.Run("else outside if", func(t *testing.T) {
+		doc := Parse("@startuml\nstart\n:x;\nelse\nstop\n@enduml")
+		assert.Equal(t, []string{CodeUnmatchedEnd}, diagnosticCodes(doc))
+	})
+}
+
+func TestParse_UnknownKeyword(t *testing.T) {
+	doc := Parse("@startuml\n  partcipant A\nA -> B: x\n@enduml")
+
+	require.Equal(t, []string{CodeUnknownKeyword}, diagnosticCodes(doc))
+	d := doc.Diagnostics[0]
+	assert.Equal(t, SeverityWarning, d.Severity)
+	assert.False(t, doc.HasErrors())
+	assert.Equal(t, 2, d.Line)
+	assert.Equal(t, 3, d.Column)
+	assert.Equal(t, 13, d.EndColumn)
+	assert.Contains(t, d.Message, "participant")
+}
+
+// 未建模的语法只给出警告，不能阻止保存合法的 PlantUML
+func TestParse_UnsupportedSyntax(t *testing.T) {
+	doc := Parse("@startuml\n[*] --> 待支付\nstate 待支付 {\n  [*] --> 锁定库存\n}\n待支付 --> [*]\n@enduml")
+	assert.Equal(t, []string{CodeUnsupportedDiagram}, diagnosticCodes(doc))
+	assert.False(t, doc.HasErrors())
+
+	doc = Parse("@startuml\ncloud {\n  [网关]\n}\n[网关] --> [服务]\n@enduml")
+	assert.Empty(t, doc.Diagnostics)
+	assert.Empty(t, doc.Element("网关").Parent)
+
+	doc = Parse("@startuml\nA -> B\nsequencebox 分组 {\nB -> C\n}\n@enduml")
+	assert.Equal(t, []string{CodeUnknownKeyword}, diagnosticCodes(doc))
+	assert.False(t, doc.HasErrors())
+}
+
+func TestParse_SequenceDeclarations(t *testing.T) {
+	doc := Parse("@startuml\nparticipant A\nparticipant B\nA ->x B\nA ->o B\ncreate C\nA -> C : new\ncreate actor D\nA -> D\n@enduml")
+	assert.Empty(t, doc.Diagnostics)
+	var names []string
+	for _, e := range doc.Elements {
+		names = append(names, e.Name)
+	}
+	assert.Equal(t, []string{"A", "B", "C", "D"}, names)
+	assert.Equal(t, "->x", doc.Relations[0].Arrow)
+	assert.Equal(t, "->o", doc.Relations[1].Arrow)
+	assert.Equal(t, "actor", doc.Element("D").Kind)
+}
+
+func TestParse_GenericClass(t *testing.T) {
+	doc := Parse("@startuml\nclass List<T>\nclass Map<K, V> {\n  +get(K key) : V\n}\nList --> Map\n@enduml")
+	assert.Empty(t, doc.Diagnostics)
+	require.Len(t, doc.Elements, 2)
+	assert.Equal(t, "List", doc.Elements[0].Name)
+	assert.Equal(t, "Map", doc.Elements[1].Name)
+	assert.Len(t, doc.Elements[1].Members, 1)
+}
+
+func TestParse_Markers(t *testing.T) {
+	doc := Parse("A -> B: x")
+	assert.Equal(t, []string{CodeMissingStart, CodeMissingEnd}, diagnosticCodes(doc))
+	assert.Len(t, doc.Relations, 1)
+
+	doc = Parse("@startuml\n@startuml\nA -> B\n@enduml\nextra\n")
+	assert.Equal(t, []string{CodeDuplicateMarker, CodeOutsideDiagram}, diagnosticCodes(doc))
+
+	doc = Parse("@startuml\nA -> B\n@enduml\n@startuml\nC -> D\n@enduml")
+	assert.Equal(t, []string{CodeDuplicateMarker}, diagnosticCodes(doc))
+	assert.Equal(t, SeverityWarning, doc.Diagnostics[0].Severity)
+	assert.Len(t, doc.Relations, 1)
+}
+
+func TestParse_CommentsAndNotes(t *testing.T) {
+	doc := Parse(`@startuml
+' 单行注释
+/' 块注释
+   partcipant 不会被检查 '/
+participant A
+note left of A
+  alt 不是分组
+end note
+/' 行内 '/ A -> A: self
+title 自调用
+@enduml`)
+
+	assert.Empty(t, doc.Diagnostics)
+	assert.Equal(t, "自调用", doc.Title)
+	require.Len(t, doc.Relations, 1)
+	assert.Equal(t, Position{Line: 9, Column: 10}, doc.Relations[0].Pos)
+}
+
+func TestParse_ActivityNodes(t *testing.T) {
+	doc := Parse(`@startuml
+start
+:提交订单;
+if (库存充足?) then (是)
+  :扣减库存
+  并生成发货单;
+elseif (可预订?) then (是)
+  :预订;
+else (否)
+  :取消订单;
+endif
+stop
+@enduml`)
+
+	require.Empty(t, doc.Diagnostics)
+	var kinds []string
+	for _, n := range doc.Activity {
+		kinds = append(kinds, n.Kind)
+	}
+	assert.Equal(t, []string{"start", "action", "if", "action", "elseif", "action", "else", "action", "endif", "stop"}, kinds)
+	assert.Equal(t, "库存充足?", doc.Activity[2].Text)
+	assert.Equal(t, "是", doc.Activity[2].Label)
+	assert.Equal(t, "否", doc.Activity[6].Label)
+	assert.Equal(t, "扣减库存\n并生成发货单", doc.Activity[3].Text)
+	assert.Equal(t, 1, doc.Activity[3].Depth)
+	assert.Equal(t, 0, doc.Activity[4].Depth)
+}
+
+func TestParse_UnterminatedAction(t *testing.T) {
+	doc := Parse("@startuml\nstart\n:没有结束符\nstop\n@enduml")
+	require.Equal(t, []string{CodeUnterminatedAction}, diagnosticCodes(doc))
+	assert.Equal(t, 3, doc.Diagnostics[0].Line)
+}
+
+func TestParse_ClassMembersAndRelations(t *testing.T) {
+	doc := Parse(`@startuml
+abstract class 动物 <<实体>> {
+  #名称 : String
+  +{abstract} 叫() : void
+  --
+  int 年龄
+}
+interface 可训练
+class 狗 extends 动物 implements 可训练
+狗 "1" *-- "0..*" 玩具 : 拥有
+狗 : +bark() : void
+@enduml`)
+
+	animal := doc.Element("动物")
+	require.NotNil(t, animal)
+	assert.Equal(t, "abstract", animal.Kind)
+	assert.Equal(t, "实体", animal.Stereotype)
+	require.Len(t, animal.Members, 3)
+	assert.Equal(t, &Member{Text: "#名称 : String", Name: "名称", Type: "String", Visibility: "#", Pos: Position{Line: 3, Column: 3}}, animal.Members[0])
+	assert.True(t, animal.Members[1].Method)
+	assert.Equal(t, "叫", animal.Members[1].Name)
+	assert.Equal(t, "void", animal.Members[1].Type)
+	assert.Equal(t, "年龄", animal.Members[2].Name)
+	assert.Equal(t, "int", animal.Members[2].Type)
+
+	// 类主体之外以 类名 : 成员 添加
+	dog := doc.Element("狗")
+	require.Len(t, dog.Members, 1)
+	assert.Equal(t, &Member{Text: "+bark() : void", Name: "bark", Type: "void", Visibility: "+", Method: true, Pos: Position{Line: 11, Column: 5}}, dog.Members[0])
+
+	require.Len(t, doc.Relations, 3)
+	assert.Equal(t, "--|>", doc.Relations[0].Arrow)
+	assert.Equal(t, "..|>", doc.Relations[1].Arrow)
+	composition := doc.Relations[2]
+	assert.Equal(t, "*--", composition.Arrow)
+	assert.Equal(t, "1", composition.FromCardinality)
+	assert.Equal(t, "0..*", composition.ToCardinality)
+	assert.Equal(t, "玩具", composition.To)
+
+	require.Equal(t, []string{CodeUndefinedElement}, diagnosticCodes(doc))
+	assert.Equal(t, 10, doc.Diagnostics[0].Line)
+	assert.Equal(t, 18, doc.Diagnostics[0].Column)
+}
+
+func TestParse_EntityDiagram(t *testing.T) {
+	doc := Parse(`@startuml
+entity 用户 {
+  * 用户ID : bigint <<PK>>
+  --
+  邮箱 : varchar(100)
+}
+entity 订单 {
+  * 订单ID : bigint <<PK>>
+  用户ID : bigint <<FK>>
+}
+用户 ||--o{ 订单
+@enduml`)
+
+	assert.Equal(t, DiagramEntity, doc.Type)
+	assert.Empty(t, doc.Diagnostics)
+	order := doc.Element("订单")
+	require.NotNil(t, order)
+	require.Len(t, order.Members, 2)
+	assert.True(t, order.Members[0].PrimaryKey)
+	assert.Equal(t, "订单ID", order.Members[0].Name)
+	assert.True(t, order.Members[1].ForeignKey)
+	assert.Equal(t, "bigint", order.Members[1].Type)
+	assert.Equal(t, "||--o{", doc.Relations[0].Arrow)
+	email := doc.Element("用户").Members[1]
+	assert.False(t, email.Method)
+	assert.Equal(t, "varchar(100)", email.Type)
+}
+
+func TestParse_ComponentParents(t *testing.T) {
+	doc := Parse(`@startuml
+node "服务器" {
+  component [订单服务] as OS
+  [支付服务]
+}
+OS ..> [支付服务] : 调用
+@enduml`)
+
+	assert.Empty(t, doc.Diagnostics)
+	assert.Equal(t, "服务器", doc.Element("OS").Parent)
+	assert.Equal(t, "服务器", doc.Element("支付服务").Parent)
+	assert.Equal(t, "node", doc.Element("服务器").Kind)
+}
+
+func TestFindArrow(t *testing.T) {
+	tests := []struct {
+		text  string
+		arrow string
+	}{
+		{"Alice->Bob: hi", "->"},
+		{"Foo-->Bar", "-->"},
+		{"Foo o-- Bar", "o--"},
+		{"A -[#red]-> B", "-[#red]->"},
+		{"A -up-> B", "-up->"},
+		{"A <|-- B", "<|--"},
+		{"A }o..|| B", "}o..||"},
+		{"[user-service] --> [db]", "-->"},
+		{"user-service -> db", "->"},
+		{"A - B", "-"},
+		{"e-mail: x -> y", ""},
+		{"a.b", ""},
+		{":User: --> (登录)", "-->"},
+		{"A ->x B", "->x"},
+		{"A ->>o B", "->>o"},
+		{"A ->xB", "->"},
+	}
+	for _, tt := range tests {
+		start, end := findArrow(tt.text)
+		if tt.arrow == "" {
+			assert.Equal(t, -1, start, tt.text)
+			continue
+		}
+		require.GreaterOrEqual(t, start, 0, tt.text)
+		assert.Equal(t, tt.arrow, tt.text[start:end], tt.text)
+	}
+}
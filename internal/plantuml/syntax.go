@@ -0,0 +1,59 @@
+package plantuml
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Syntax 图表源码的语法
+type Syntax string
+
+const (
+	SyntaxPlantUML Syntax = "plantuml"
+	SyntaxMermaid  Syntax = "mermaid"
+)
+
+// ParseSyntax 解析语法名称，缺省为 PlantUML
+func ParseSyntax(name string) (Syntax, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "plantuml", "puml":
+		return SyntaxPlantUML, nil
+	case "mermaid", "mmd":
+		return SyntaxMermaid, nil
+	}
+	return "", fmt.Errorf("不支持的图表语法: %s", name)
+}
+
+// DetectSyntax 由源码的第一条语句推断语法：以 Mermaid 图表类型声明开头的为 Mermaid，其余按 PlantUML 处理
+func DetectSyntax(source string) Syntax {
+	body, _ := mermaidLines(source)
+	if len(body) == 0 {
+		return SyntaxPlantUML
+	}
+	word := mermaidHeaderWord(body[0].text)
+	if _, ok := mermaidHeaders[word]; ok || otherMermaidHeaders[word] {
+		return SyntaxMermaid
+	}
+	return SyntaxPlantUML
+}
+
+// ParseAs 按指定语法解析源码
+func ParseAs(source string, syntax Syntax) *Document {
+	if syntax == SyntaxMermaid {
+		return ParseMermaid(source)
+	}
+	return Parse(source)
+}
+
+// Convert 在 PlantUML 与 Mermaid 之间转换图表源码，语法相同时原样返回
+func Convert(source string, from, to Syntax) (*Conversion, error) {
+	switch {
+	case from == to:
+		return &Conversion{Source: source, Dropped: []string{}}, nil
+	case from == SyntaxPlantUML && to == SyntaxMermaid:
+		return ToMermaid(Parse(source))
+	case from == SyntaxMermaid && to == SyntaxPlantUML:
+		return FromMermaid(source)
+	}
+	return nil, fmt.Errorf("%w: %s 转换为 %s", ErrUnsupportedConversion, from, to)
+}
@@ -0,0 +1,251 @@
+// Package prioritization 用户故事优先级评估：MoSCoW 分组、RICE 与 WSJF 打分，并据此生成排序后的待办列表
+package prioritization
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Method 排序方法
+type Method string
+
+const (
+	MethodRICE   Method = "rice"   // (覆盖人数 × 影响 × 信心) / 工作量
+	MethodWSJF   Method = "wsjf"   // (业务价值 + 时间紧迫性 + 风险降低) / 工作规模
+	MethodMoSCoW Method = "moscow" // 按 must/should/could/wont 分组，组内按 RICE、WSJF 排序
+)
+
+// MoSCoW 分组
+const (
+	MoSCoWMust   = "must"
+	MoSCoWShould = "should"
+	MoSCoWCould  = "could"
+	MoSCoWWont   = "wont"
+)
+
+// moscowOrder 分组的排序位置，未分组的条目排在 could 之后、wont 之前
+var moscowOrder = map[string]int{
+	MoSCoWMust:   0,
+	MoSCoWShould: 1,
+	MoSCoWCould:  2,
+	"":           3,
+	MoSCoWWont:   4,
+}
+
+// ParseMethod 解析排序方法，为空时使用 RICE
+func ParseMethod(method string) (Method, error) {
+	switch m := Method(strings.ToLower(strings.TrimSpace(method))); m {
+	case "":
+		return MethodRICE, nil
+	case MethodRICE, MethodWSJF, MethodMoSCoW:
+		return m, nil
+	}
+	return "", fmt.Errorf("不支持的排序方法: %s", method)
+}
+
+// NormalizeMoSCoW 规范化 MoSCoW 分组名称，支持 won't、won_t 等写法
+func NormalizeMoSCoW(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch strings.NewReplacer("'", "", "’", "", "_", "", "-", "", " ", "").Replace(value) {
+	case "must", "musthave":
+		return MoSCoWMust
+	case "should", "shouldhave":
+		return MoSCoWShould
+	case "could", "couldhave":
+		return MoSCoWCould
+	case "wont", "wonthave":
+		return MoSCoWWont
+	}
+	return value
+}
+
+// Inputs 单个用户故事的评估输入，未填写的项为 nil
+type Inputs struct {
+	Reach           *float64 `json:"reach,omitempty"`            // RICE：单位周期内覆盖的用户数
+	Impact          *float64 `json:"impact,omitempty"`           // RICE：0.25 极小、0.5 小、1 中、2 大、3 极大
+	Confidence      *float64 `json:"confidence,omitempty"`       // RICE：信心，0-1
+	Effort          *float64 `json:"effort,omitempty"`           // RICE：工作量（人月）
+	BusinessValue   *float64 `json:"business_value,omitempty"`   // WSJF：业务价值
+	TimeCriticality *float64 `json:"time_criticality,omitempty"` // WSJF：时间紧迫性
+	RiskReduction   *float64 `json:"risk_reduction,omitempty"`   // WSJF：风险降低/机会促成，可省略
+	JobSize         *float64 `json:"job_size,omitempty"`         // WSJF：工作规模
+	MoSCoW          string   `json:"moscow,omitempty"`
+}
+
+// Validate 校验输入取值范围
+func (in Inputs) Validate() error {
+	nonNegative := []struct {
+		field string
+		v     *float64
+	}{
+		{"reach", in.Reach},
+		{"impact", in.Impact},
+		{"business_value", in.BusinessValue},
+		{"time_criticality", in.TimeCriticality},
+		{"risk_reduction", in.RiskReduction},
+	}
+	for _, n := range nonNegative {
+		if n.v != nil && (*n.v < 0 || math.IsNaN(*n.v) || math.IsInf(*n.v, 0)) {
+			return fmt.Errorf("%s 必须为非负数", n.field)
+		}
+	}
+	if in.Confidence != nil && (*in.Confidence < 0 || *in.Confidence > 1) {
+		return fmt.Errorf("confidence 必须在 0 到 1 之间")
+	}
+	if in.Effort != nil && !(*in.Effort > 0) {
+		return fmt.Errorf("effort 必须大于 0")
+	}
+	if in.JobSize != nil && !(*in.JobSize > 0) {
+		return fmt.Errorf("job_size 必须大于 0")
+	}
+	if _, ok := moscowOrder[in.MoSCoW]; !ok {
+		return fmt.Errorf("无效的 MoSCoW 分组: %s", in.MoSCoW)
+	}
+	return nil
+}
+
+// Empty 是否没有填写任何评估输入
+func (in Inputs) Empty() bool {
+	return in.Reach == nil && in.Impact == nil && in.Confidence == nil && in.Effort == nil &&
+		in.BusinessValue == nil && in.TimeCriticality == nil && in.RiskReduction == nil && in.JobSize == nil &&
+		in.MoSCoW == ""
+}
+
+// RICE 计算 RICE 分数，输入不完整时返回 false
+func RICE(in Inputs) (float64, bool) {
+	if in.Reach == nil || in.Impact == nil || in.Confidence == nil || in.Effort == nil || *in.Effort <= 0 {
+		return 0, false
+	}
+	return round(*in.Reach * *in.Impact * *in.Confidence / *in.Effort), true
+}
+
+// WSJF 计算 WSJF 分数（延迟成本 / 工作规模），风险降低未填写时按 0 计算，其余输入不完整时返回 false
+func WSJF(in Inputs) (float64, bool) {
+	if in.BusinessValue == nil || in.TimeCriticality == nil || in.JobSize == nil || *in.JobSize <= 0 {
+		return 0, false
+	}
+	costOfDelay := *in.BusinessValue + *in.TimeCriticality
+	if in.RiskReduction != nil {
+		costOfDelay += *in.RiskReduction
+	}
+	return round(costOfDelay / *in.JobSize), true
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Item 待排序的用户故事
+type Item struct {
+	ID          string
+	Title       string
+	Description string // 仅用于AI评估建议的提示词
+	Inputs      Inputs
+}
+
+// Ranked 排序后的用户故事
+type Ranked struct {
+	Rank   int      `json:"rank"`
+	ID     string   `json:"id"`
+	Title  string   `json:"title"`
+	Score  *float64 `json:"score"` // 所选方法的分数，MoSCoW 排序时为 RICE 分数（无则 WSJF）
+	RICE   *float64 `json:"rice"`
+	WSJF   *float64 `json:"wsjf"`
+	MoSCoW string   `json:"moscow,omitempty"`
+	Inputs Inputs   `json:"inputs"`
+}
+
+// Scored 是否有可用于排序的评估信息
+func (r *Ranked) Scored() bool {
+	return r.Score != nil || (r.MoSCoW != "" && r.MoSCoW != MoSCoWWont)
+}
+
+// Rank 按指定方法排序：分数高的在前，没有分数的排在有分数的之后，分数相同时按 MoSCoW 分组；
+// wont 分组的条目始终排在最后，其余完全相同的条目保持输入顺序
+func Rank(items []Item, method Method) []*Ranked {
+	ranked := make([]*Ranked, 0, len(items))
+	for _, item := range items {
+		r := &Ranked{ID: item.ID, Title: item.Title, MoSCoW: item.Inputs.MoSCoW, Inputs: item.Inputs}
+		if v, ok := RICE(item.Inputs); ok {
+			r.RICE = &v
+		}
+		if v, ok := WSJF(item.Inputs); ok {
+			r.WSJF = &v
+		}
+		switch method {
+		case MethodWSJF:
+			r.Score = r.WSJF
+		default:
+			r.Score = r.RICE
+			if r.Score == nil && method == MethodMoSCoW {
+				r.Score = r.WSJF
+			}
+		}
+		ranked = append(ranked, r)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if wa, wb := a.MoSCoW == MoSCoWWont, b.MoSCoW == MoSCoWWont; wa != wb {
+			return wb
+		}
+		if method == MethodMoSCoW && moscowOrder[a.MoSCoW] != moscowOrder[b.MoSCoW] {
+			return moscowOrder[a.MoSCoW] < moscowOrder[b.MoSCoW]
+		}
+		if (a.Score == nil) != (b.Score == nil) {
+			return a.Score != nil
+		}
+		if a.Score != nil && *a.Score != *b.Score {
+			return *a.Score > *b.Score
+		}
+		return moscowOrder[a.MoSCoW] < moscowOrder[b.MoSCoW]
+	})
+
+	for i, r := range ranked {
+		r.Rank = i + 1
+	}
+	return ranked
+}
+
+// FieldChange 一项评估输入的变更
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// Diff 比较两次评估输入，返回发生变化的项
+func Diff(before, after Inputs) []FieldChange {
+	var changes []FieldChange
+	numbers := []struct {
+		field string
+		a, b  *float64
+	}{
+		{"reach", before.Reach, after.Reach},
+		{"impact", before.Impact, after.Impact},
+		{"confidence", before.Confidence, after.Confidence},
+		{"effort", before.Effort, after.Effort},
+		{"business_value", before.BusinessValue, after.BusinessValue},
+		{"time_criticality", before.TimeCriticality, after.TimeCriticality},
+		{"risk_reduction", before.RiskReduction, after.RiskReduction},
+		{"job_size", before.JobSize, after.JobSize},
+	}
+	for _, n := range numbers {
+		if (n.a == nil) != (n.b == nil) || (n.a != nil && *n.a != *n.b) {
+			changes = append(changes, FieldChange{Field: n.field, From: value(n.a), To: value(n.b)})
+		}
+	}
+	if before.MoSCoW != after.MoSCoW {
+		changes = append(changes, FieldChange{Field: "moscow", From: before.MoSCoW, To: after.MoSCoW})
+	}
+	return changes
+}
+
+func value(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
@@ -0,0 +1,127 @@
+package prioritization
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func f(v float64) *float64 { return &v }
+
+func rice(reach, impact, confidence, effort float64) Inputs {
+	return Inputs{Reach: f(reach), Impact: f(impact), Confidence: f(confidence), Effort: f(effort)}
+}
+
+func TestScores(t *testing.T) {
+	score, ok := RICE(rice(1000, 2, 0.8, 4))
+	require.True(t, ok)
+	assert.Equal(t, 400.0, score)
+
+	_, ok = RICE(Inputs{Reach: f(1000), Impact: f(2), Confidence: f(0.8)})
+	assert.False(t, ok, "缺少工作量时不计算 RICE")
+
+	score, ok = WSJF(Inputs{BusinessValue: f(8), TimeCriticality: f(5), RiskReduction: f(3), JobSize: f(3)})
+	require.True(t, ok)
+	assert.Equal(t, 5.33, score)
+
+	score, ok = WSJF(Inputs{BusinessValue: f(8), TimeCriticality: f(2), JobSize: f(5)})
+	require.True(t, ok)
+	assert.Equal(t, 2.0, score, "风险降低未填写时按 0 计算")
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, rice(10, 1, 1, 0.5).Validate())
+	assert.NoError(t, Inputs{MoSCoW: MoSCoWWont}.Validate())
+
+	assert.Error(t, rice(10, 1, 1.5, 1).Validate())
+	assert.Error(t, rice(-1, 1, 1, 1).Validate())
+	assert.Error(t, rice(10, 1, 1, 0).Validate())
+	assert.Error(t, Inputs{JobSize: f(0)}.Validate())
+	assert.Error(t, Inputs{MoSCoW: "maybe"}.Validate())
+
+	assert.Equal(t, MoSCoWWont, NormalizeMoSCoW("Won't have"))
+	assert.Equal(t, MoSCoWMust, NormalizeMoSCoW(" MUST "))
+}
+
+func TestRank(t *testing.T) {
+	items := []Item{
+		{ID: "unscored", Title: "未评估"},
+		{ID: "low", Title: "低分", Inputs: rice(100, 1, 0.5, 1)},
+		{ID: "high", Title: "高分", Inputs: rice(1000, 2, 0.8, 4)},
+		{ID: "wont", Title: "暂不做", Inputs: withMoSCoW(rice(5000, 3, 1, 1), MoSCoWWont)},
+		{ID: "must", Title: "必须", Inputs: Inputs{MoSCoW: MoSCoWMust}},
+		{ID: "tie", Title: "同分", Inputs: withMoSCoW(rice(100, 1, 0.5, 1), MoSCoWShould)},
+	}
+
+	assert.Equal(t, []string{"high", "tie", "low", "must", "unscored", "wont"}, ids(Rank(items, MethodRICE)))
+	assert.Equal(t, []string{"must", "tie", "high", "low", "unscored", "wont"}, ids(Rank(items, MethodMoSCoW)))
+
+	ranked := Rank(items, MethodWSJF)
+	assert.Equal(t, []string{"must", "tie", "unscored", "low", "high", "wont"}, ids(ranked), "没有 WSJF 分数时按 MoSCoW 分组，其余保持输入顺序")
+	assert.Equal(t, 1, ranked[0].Rank)
+	assert.Nil(t, ranked[0].Score)
+	require.NotNil(t, ranked[4].RICE)
+	assert.Equal(t, 400.0, *ranked[4].RICE)
+}
+
+func withMoSCoW(in Inputs, moscow string) Inputs {
+	in.MoSCoW = moscow
+	return in
+}
+
+func ids(ranked []*Ranked) []string {
+	var result []string
+	for _, r := range ranked {
+		result = append(result, r.ID)
+	}
+	return result
+}
+
+func TestDiff(t *testing.T) {
+	before := rice(100, 1, 0.5, 1)
+	after := rice(100, 2, 0.5, 1)
+	after.Effort = nil
+	after.MoSCoW = MoSCoWMust
+
+	assert.Equal(t, []FieldChange{
+		{Field: "impact", From: 1.0, To: 2.0},
+		{Field: "effort", From: 1.0, To: nil},
+		{Field: "moscow", From: "", To: MoSCoWMust},
+	}, Diff(before, after))
+	assert.Empty(t, Diff(before, rice(100, 1, 0.5, 1)))
+}
+
+func TestSuggestions(t *testing.T) {
+	items := []Item{{ID: "a", Title: "借书", Description: "读者可以借书"}, {ID: "b", Title: "还书"}}
+	prompt := BuildSuggestionPrompt(items)
+	assert.Contains(t, prompt, "1. 借书：读者可以借书")
+	assert.Contains(t, prompt, "2. 还书\n")
+
+	suggestions, err := ParseSuggestions(`{"results":[
+		{"story": 1, "moscow": "Must", "reach": 500, "impact": 2, "confidence": 0.8, "effort": 1, "rationale": "核心流程"},
+		{"story": 2, "confidence": 3},
+		{"story": 9, "moscow": "could"}
+	]}`, len(items))
+	require.NoError(t, err)
+	require.Len(t, suggestions, 1, "越界或取值不合法的建议被忽略")
+	assert.Equal(t, MoSCoWMust, suggestions[0].Inputs.MoSCoW)
+	assert.Equal(t, "核心流程", suggestions[0].Rationale)
+	score, ok := RICE(suggestions[0].Inputs)
+	require.True(t, ok)
+	assert.Equal(t, 800.0, score)
+
+	_, err = ParseSuggestions("not json", 1)
+	assert.Error(t, err)
+}
+
+func TestPromptSection(t *testing.T) {
+	assert.Empty(t, PromptSection(Rank([]Item{{ID: "a", Title: "借书"}}, MethodRICE), MethodRICE))
+
+	section := PromptSection(Rank([]Item{
+		{ID: "a", Title: "还书"},
+		{ID: "b", Title: "借书", Inputs: withMoSCoW(rice(1000, 2, 0.8, 4), MoSCoWMust)},
+	}, MethodRICE), MethodRICE)
+	assert.Contains(t, section, "按 RICE 排序")
+	assert.Contains(t, section, "1. 借书（must，RICE 400）\n2. 还书\n")
+}
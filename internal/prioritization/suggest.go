@@ -0,0 +1,113 @@
+package prioritization
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaxAIStories 单次AI建议最多提交的用户故事数量
+const MaxAIStories = 50
+
+// Suggestion AI给出的初始评估值
+type Suggestion struct {
+	Inputs    Inputs
+	Rationale string
+}
+
+// BuildSuggestionPrompt 构建评估建议的提示词，用户故事按 1 开始编号
+func BuildSuggestionPrompt(items []Item) string {
+	var b strings.Builder
+	b.WriteString(`
+你是一个资深的产品负责人。请为下面的用户故事给出初始的优先级评估，供团队在此基础上调整：
+
+- MoSCoW：must / should / could / wont
+- RICE：reach（每季度覆盖的用户数）、impact（0.25、0.5、1、2、3）、confidence（0-1）、effort（人月，大于0）
+- WSJF：business_value、time_criticality、risk_reduction 使用 1、2、3、5、8、13、20 的相对值，job_size 同样使用相对值（大于0）
+
+**用户故事：**
+`)
+	for i, item := range items {
+		fmt.Fprintf(&b, "%d. %s", i+1, strings.TrimSpace(item.Title))
+		if description := strings.TrimSpace(item.Description); description != "" {
+			fmt.Fprintf(&b, "：%s", description)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(`
+**输出格式（JSON）：**
+` + "```json" + `
+{
+  "results": [
+    {"story": 1, "moscow": "must", "reach": 500, "impact": 2, "confidence": 0.8, "effort": 1,
+     "business_value": 8, "time_criticality": 5, "risk_reduction": 3, "job_size": 5, "rationale": "简要说明评估依据"}
+  ]
+}
+` + "```" + `
+`)
+	return b.String()
+}
+
+// ParseSuggestions 解析AI返回的评估建议，键为用户故事下标（从 0 开始）
+// 编号越界或取值不合法的建议会被忽略
+func ParseSuggestions(jsonStr string, count int) (map[int]*Suggestion, error) {
+	var parsed struct {
+		Results []struct {
+			Story int `json:"story"`
+			Inputs
+			Rationale string `json:"rationale"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(jsonStr), &parsed); err != nil {
+		return nil, fmt.Errorf("解析AI评估建议失败: %w", err)
+	}
+
+	suggestions := make(map[int]*Suggestion)
+	for _, result := range parsed.Results {
+		if result.Story < 1 || result.Story > count {
+			continue
+		}
+		inputs := result.Inputs
+		inputs.MoSCoW = NormalizeMoSCoW(inputs.MoSCoW)
+		if inputs.Empty() || inputs.Validate() != nil {
+			continue
+		}
+		suggestions[result.Story-1] = &Suggestion{Inputs: inputs, Rationale: strings.TrimSpace(result.Rationale)}
+	}
+	return suggestions, nil
+}
+
+// PromptSection 生成任务分解提示词中的优先级排序段落，没有任何评估信息时返回空字符串
+func PromptSection(ranked []*Ranked, method Method) string {
+	scored := false
+	for _, r := range ranked {
+		if r.Scored() {
+			scored = true
+			break
+		}
+	}
+	if !scored {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n**用户故事优先级（按 %s 排序，请按此顺序分解任务并安排里程碑，wont 的故事不要生成任务）：**\n", strings.ToUpper(string(method)))
+	for _, r := range ranked {
+		fmt.Fprintf(&b, "%d. %s", r.Rank, r.Title)
+		var meta []string
+		if r.MoSCoW != "" {
+			meta = append(meta, r.MoSCoW)
+		}
+		if r.RICE != nil {
+			meta = append(meta, fmt.Sprintf("RICE %g", *r.RICE))
+		}
+		if r.WSJF != nil {
+			meta = append(meta, fmt.Sprintf("WSJF %g", *r.WSJF))
+		}
+		if len(meta) > 0 {
+			fmt.Fprintf(&b, "（%s）", strings.Join(meta, "，"))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateAIAuditLog 创建AI调用审计日志
+func (r *MySQLRepository) CreateAIAuditLog(auditLog *model.AIAuditLog) error {
+	if auditLog.CreatedAt.IsZero() {
+		auditLog.CreatedAt = time.Now()
+	}
+
+	if err := r.db.GORM.Create(auditLog).Error; err != nil {
+		return fmt.Errorf("创建AI审计日志失败: %w", err)
+	}
+
+	return nil
+}
+
+// GetAIAuditLog 根据ID获取AI调用审计日志
+func (r *MySQLRepository) GetAIAuditLog(logID uuid.UUID) (*model.AIAuditLog, error) {
+	var auditLog model.AIAuditLog
+
+	err := r.db.GORM.Where("log_id = ?", logID).First(&auditLog).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("AI审计日志不存在")
+		}
+		return nil, fmt.Errorf("查询AI审计日志失败: %w", err)
+	}
+
+	return &auditLog, nil
+}
+
+// ListAIAuditLogs 按条件分页查询AI调用审计日志
+func (r *MySQLRepository) ListAIAuditLogs(query *model.AIAuditLogQuery) ([]*model.AIAuditLog, int64, error) {
+	var logs []*model.AIAuditLog
+	var total int64
+
+	db := r.db.GORM.Model(&model.AIAuditLog{})
+	if query.RequestID != "" {
+		db = db.Where("request_id = ?", query.RequestID)
+	}
+	if query.UserID != "" {
+		db = db.Where("user_id = ?", query.UserID)
+	}
+	if query.ProjectID != "" {
+		db = db.Where("project_id = ?", query.ProjectID)
+	}
+	if query.Operation != "" {
+		db = db.Where("operation = ?", query.Operation)
+	}
+	if query.Provider != "" {
+		db = db.Where("provider = ?", query.Provider)
+	}
+	if query.Keyword != "" {
+		like := "%" + query.Keyword + "%"
+		db = db.Where("prompt LIKE ? OR response LIKE ? OR error_message LIKE ?", like, like, like)
+	}
+	if query.HasError != nil {
+		if *query.HasError {
+			db = db.Where("error_message <> ''")
+		} else {
+			db = db.Where("(error_message = '' OR error_message IS NULL)")
+		}
+	}
+	if query.Since != nil {
+		db = db.Where("created_at >= ?", *query.Since)
+	}
+	if query.Until != nil {
+		db = db.Where("created_at <= ?", *query.Until)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询AI审计日志总数失败: %w", err)
+	}
+
+	offset := (query.Page - 1) * query.PageSize
+	if err := db.Order("created_at DESC").Offset(offset).Limit(query.PageSize).Find(&logs).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询AI审计日志列表失败: %w", err)
+	}
+
+	return logs, total, nil
+}
+
+// DeleteAIAuditLogsBefore 删除指定时间之前的AI调用审计日志
+func (r *MySQLRepository) DeleteAIAuditLogsBefore(before time.Time) (int64, error) {
+	result := r.db.GORM.Where("created_at < ?", before).Delete(&model.AIAuditLog{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("清理AI审计日志失败: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
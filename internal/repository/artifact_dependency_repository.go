@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"fmt"
+
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// CreateArtifactDependencies 批量记录产物的派生来源
+func (r *MySQLRepository) CreateArtifactDependencies(dependencies []*model.ArtifactDependency) error {
+	if len(dependencies) == 0 {
+		return nil
+	}
+	if err := r.db.GORM.Create(&dependencies).Error; err != nil {
+		return fmt.Errorf("记录产物依赖失败: %w", err)
+	}
+
+	return nil
+}
+
+// GetArtifactDependencies 获取项目的全部产物依赖
+func (r *MySQLRepository) GetArtifactDependencies(projectID uuid.UUID) ([]*model.ArtifactDependency, error) {
+	var dependencies []*model.ArtifactDependency
+
+	if err := r.db.GORM.Where("project_id = ?", projectID).Order("created_at ASC").Find(&dependencies).Error; err != nil {
+		return nil, fmt.Errorf("查询产物依赖失败: %w", err)
+	}
+
+	return dependencies, nil
+}
+
+// SaveArtifactDependency 更新产物依赖（过期状态、上游引用）
+func (r *MySQLRepository) SaveArtifactDependency(dependency *model.ArtifactDependency) error {
+	if err := r.db.GORM.Save(dependency).Error; err != nil {
+		return fmt.Errorf("保存产物依赖失败: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteArtifactDependencies 删除产物的全部派生来源记录
+func (r *MySQLRepository) DeleteArtifactDependencies(artifactType, artifactID string) error {
+	if err := r.db.GORM.Where("artifact_type = ? AND artifact_id = ?", artifactType, artifactID).
+		Delete(&model.ArtifactDependency{}).Error; err != nil {
+		return fmt.Errorf("删除产物依赖失败: %w", err)
+	}
+
+	return nil
+}
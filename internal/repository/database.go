@@ -44,6 +44,11 @@ func NewDatabase(cfg *config.Config) (*Database, error) {
 		return nil, fmt.Errorf("GORM MySQL连接失败: %w", err)
 	}
 
+	// 注册链路追踪回调
+	if err := registerTracingCallbacks(gormDB); err != nil {
+		return nil, fmt.Errorf("注册GORM链路追踪回调失败: %w", err)
+	}
+
 	// 获取底层的 *sql.DB 以配置连接池
 	sqlDB, err := gormDB.DB()
 	if err != nil {
@@ -131,6 +136,17 @@ func (db *Database) CreateTables() error {
 		&model.StageProgress{},
 		&model.UserAIConfig{},
 		&model.AsyncTask{},
+		&model.AIAuditLog{},
+		&model.RequirementRefinement{},
+		&model.EARSLintReport{},
+		&model.RequirementVersion{},
+		&model.RequirementFinding{},
+		&model.GlossaryTerm{},
+		&model.ArtifactDependency{},
+		&model.StoryPriority{},
+		&model.StoryPriorityChange{},
+		&model.StorySplit{},
+		&model.PUMLDiagramVersion{},
 	)
 	if err != nil {
 		return fmt.Errorf("GORM 自动迁移失败: %w", err)
@@ -193,6 +209,8 @@ type Repository interface {
 	CreateRequirementAnalysis(requirement *model.Requirement) error
 	GetRequirementByProjectID(projectID uuid.UUID) (*model.Requirement, error)
 	UpdateRequirementAnalysis(requirement *model.Requirement) error
+	GetRequirementVersions(requirementID uuid.UUID) ([]*model.RequirementVersion, error)
+	GetRequirementVersion(requirementID uuid.UUID, version int) (*model.RequirementVersion, error)
 
 	// 对话相关
 	CreateChatSession(session *model.ChatSession) error
@@ -205,12 +223,57 @@ type Repository interface {
 	CreateQuestion(question *model.Question) error
 	GetQuestionsByRequirementID(requirementID uuid.UUID) ([]*model.Question, error)
 	AnswerQuestion(questionID uuid.UUID, answer string) error
+	GetQuestionByID(questionID uuid.UUID) (*model.Question, error)
+	SkipQuestion(questionID uuid.UUID) error
+
+	// 需求澄清相关
+	CreateRequirementRefinement(refinement *model.RequirementRefinement) error
+	GetRequirementRefinements(requirementID uuid.UUID) ([]*model.RequirementRefinement, error)
+
+	// EARS检查相关
+	CreateEARSLintReport(report *model.EARSLintReport) error
+	GetLatestEARSLintReport(sourceType string, sourceID uuid.UUID) (*model.EARSLintReport, error)
+
+	// 需求一致性检测相关
+	GetRequirementFindings(projectID uuid.UUID, status string) ([]*model.RequirementFinding, error)
+	GetRequirementFinding(findingID uuid.UUID) (*model.RequirementFinding, error)
+	SaveRequirementFinding(finding *model.RequirementFinding) error
+
+	// 项目术语表相关
+	CreateGlossaryTerm(term *model.GlossaryTerm) error
+	GetGlossaryTermsByProject(projectID uuid.UUID) ([]*model.GlossaryTerm, error)
+	GetGlossaryTerm(termID uuid.UUID) (*model.GlossaryTerm, error)
+	UpdateGlossaryTerm(term *model.GlossaryTerm) error
+	DeleteGlossaryTerm(termID uuid.UUID) error
+
+	// 产物依赖图相关
+	CreateArtifactDependencies(dependencies []*model.ArtifactDependency) error
+	GetArtifactDependencies(projectID uuid.UUID) ([]*model.ArtifactDependency, error)
+	SaveArtifactDependency(dependency *model.ArtifactDependency) error
+	DeleteArtifactDependencies(artifactType, artifactID string) error
+
+	// 用户故事优先级相关
+	GetStoryPriorities(projectID uuid.UUID) ([]*model.StoryPriority, error)
+	GetStoryPriority(storyID uuid.UUID) (*model.StoryPriority, error)
+	SaveStoryPriority(priority *model.StoryPriority, change *model.StoryPriorityChange) error
+	GetStoryPriorityChanges(storyID uuid.UUID) ([]*model.StoryPriorityChange, error)
+
+	// 用户故事拆分相关
+	CreateStorySplits(splits []*model.StorySplit) error
+	GetStorySplits(projectID uuid.UUID) ([]*model.StorySplit, error)
+
+	// 导入数据库结构相关
+	SaveSchemaImport(document *model.Document, diagram *model.PUMLDiagram, requirement *model.Requirement, authorID uuid.UUID) error
 
 	// PUML图表相关
 	CreatePUMLDiagram(diagram *model.PUMLDiagram) error
 	GetPUMLDiagramsByProjectID(projectID uuid.UUID) ([]*model.PUMLDiagram, error)
 	UpdatePUMLDiagram(diagram *model.PUMLDiagram) error
 	DeletePUMLDiagram(diagramID uuid.UUID) error
+	CreateVersionedPUMLDiagram(diagram *model.PUMLDiagram, authorID uuid.UUID, note string) error
+	UpdateVersionedPUMLDiagram(diagram *model.PUMLDiagram, authorID uuid.UUID, note string) error
+	GetPUMLDiagramVersions(diagramID uuid.UUID) ([]*model.PUMLDiagramVersion, error)
+	GetPUMLDiagramVersion(diagramID uuid.UUID, version int) (*model.PUMLDiagramVersion, error)
 
 	// 文档相关
 	CreateDocument(document *model.Document) error
@@ -259,6 +322,15 @@ type Repository interface {
 	GetDocument(documentID uuid.UUID) (*model.Document, error)
 	GetQuestions(requirementID uuid.UUID) ([]*model.Question, error)
 
+	// AI调用审计相关
+	CreateAIAuditLog(auditLog *model.AIAuditLog) error
+	GetAIAuditLog(logID uuid.UUID) (*model.AIAuditLog, error)
+	ListAIAuditLogs(query *model.AIAuditLogQuery) ([]*model.AIAuditLog, int64, error)
+	DeleteAIAuditLogsBefore(before time.Time) (int64, error)
+
+	// WithContext 返回绑定上下文的仓库，数据库调用将沿用上下文中的链路信息
+	WithContext(ctx context.Context) Repository
+
 	// 健康检查
 	Health() error
 }
@@ -272,3 +344,14 @@ type MySQLRepository struct {
 func NewMySQLRepository(db *Database) Repository {
 	return &MySQLRepository{db: db}
 }
+
+// WithContext 返回使用指定上下文执行查询的仓库副本
+func (r *MySQLRepository) WithContext(ctx context.Context) Repository {
+	if ctx == nil || r.db.GORM == nil {
+		return r
+	}
+	return &MySQLRepository{db: &Database{
+		GORM:  r.db.GORM.WithContext(ctx),
+		Redis: r.db.Redis,
+	}}
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// CreateEARSLintReport 保存EARS检查报告
+func (r *MySQLRepository) CreateEARSLintReport(report *model.EARSLintReport) error {
+	if report.CreatedAt.IsZero() {
+		report.CreatedAt = time.Now()
+	}
+
+	if err := r.db.GORM.Create(report).Error; err != nil {
+		return fmt.Errorf("保存EARS检查报告失败: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestEARSLintReport 获取来源对象最近一次的EARS检查报告
+func (r *MySQLRepository) GetLatestEARSLintReport(sourceType string, sourceID uuid.UUID) (*model.EARSLintReport, error) {
+	var report model.EARSLintReport
+
+	if err := r.db.GORM.Where("source_type = ? AND source_id = ?", sourceType, sourceID).
+		Order("created_at DESC").
+		First(&report).Error; err != nil {
+		return nil, fmt.Errorf("查询EARS检查报告失败: %w", err)
+	}
+
+	return &report, nil
+}
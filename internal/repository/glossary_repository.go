@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"fmt"
+
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// CreateGlossaryTerm 创建术语
+func (r *MySQLRepository) CreateGlossaryTerm(term *model.GlossaryTerm) error {
+	if err := r.db.GORM.Create(term).Error; err != nil {
+		return fmt.Errorf("创建术语失败: %w", err)
+	}
+
+	return nil
+}
+
+// GetGlossaryTermsByProject 获取项目的术语表，按术语排序
+func (r *MySQLRepository) GetGlossaryTermsByProject(projectID uuid.UUID) ([]*model.GlossaryTerm, error) {
+	var terms []*model.GlossaryTerm
+
+	if err := r.db.GORM.Where("project_id = ?", projectID).Order("term ASC").Find(&terms).Error; err != nil {
+		return nil, fmt.Errorf("查询术语表失败: %w", err)
+	}
+
+	return terms, nil
+}
+
+// GetGlossaryTerm 获取单条术语
+func (r *MySQLRepository) GetGlossaryTerm(termID uuid.UUID) (*model.GlossaryTerm, error) {
+	var term model.GlossaryTerm
+
+	if err := r.db.GORM.Where("term_id = ?", termID).First(&term).Error; err != nil {
+		return nil, fmt.Errorf("查询术语失败: %w", err)
+	}
+
+	return &term, nil
+}
+
+// UpdateGlossaryTerm 更新术语
+func (r *MySQLRepository) UpdateGlossaryTerm(term *model.GlossaryTerm) error {
+	if err := r.db.GORM.Save(term).Error; err != nil {
+		return fmt.Errorf("更新术语失败: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteGlossaryTerm 删除术语
+func (r *MySQLRepository) DeleteGlossaryTerm(termID uuid.UUID) error {
+	if err := r.db.GORM.Where("term_id = ?", termID).Delete(&model.GlossaryTerm{}).Error; err != nil {
+		return fmt.Errorf("删除术语失败: %w", err)
+	}
+
+	return nil
+}
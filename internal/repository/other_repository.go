@@ -8,15 +8,23 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// CreateRequirementAnalysis 创建需求分析
+// CreateRequirementAnalysis 创建需求分析，并保存为版本1
 func (r *MySQLRepository) CreateRequirementAnalysis(requirement *model.Requirement) error {
 	now := time.Now()
 	requirement.CreatedAt = now
 	requirement.UpdatedAt = now
+	requirement.CurrentVersion = 1
 
-	if err := r.db.GORM.Create(requirement).Error; err != nil {
+	err := r.db.GORM.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(requirement).Error; err != nil {
+			return err
+		}
+		return tx.Create(newRequirementVersion(requirement)).Error
+	})
+	if err != nil {
 		return fmt.Errorf("创建需求分析失败: %w", err)
 	}
 
@@ -41,27 +49,58 @@ func (r *MySQLRepository) GetRequirementByProjectID(projectID uuid.UUID) (*model
 	return &requirement, nil
 }
 
-// UpdateRequirementAnalysis 更新需求分析
+// UpdateRequirementAnalysis 更新需求分析，并保存为新版本
 func (r *MySQLRepository) UpdateRequirementAnalysis(requirement *model.Requirement) error {
+	err := r.db.GORM.Transaction(func(tx *gorm.DB) error {
+		return updateRequirementAnalysis(tx, requirement)
+	})
+	if err != nil {
+		return fmt.Errorf("更新需求分析失败: %w", err)
+	}
+
+	return nil
+}
+
+// updateRequirementAnalysis 在事务中更新需求分析并保存为新版本
+func updateRequirementAnalysis(tx *gorm.DB, requirement *model.Requirement) error {
 	requirement.UpdatedAt = time.Now()
 
-	result := r.db.GORM.Model(requirement).Where("requirement_id = ?", requirement.RequirementID).Updates(map[string]interface{}{
+	var current model.Requirement
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("requirement_id = ?", requirement.RequirementID).
+		First(&current).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("需求分析不存在或未更新")
+		}
+		return err
+	}
+
+	// 版本功能上线前的分析没有快照，先把当前内容补存为版本1
+	if current.CurrentVersion == 0 {
+		current.CurrentVersion = 1
+		if err := tx.Create(newRequirementVersion(&current)).Error; err != nil {
+			return err
+		}
+	}
+
+	requirement.CurrentVersion = current.CurrentVersion + 1
+	if requirement.RawRequirement == "" {
+		requirement.RawRequirement = current.RawRequirement
+	}
+	if err := tx.Model(&model.Requirement{}).Where("requirement_id = ?", requirement.RequirementID).Updates(map[string]interface{}{
+		"raw_requirement":        requirement.RawRequirement,
 		"structured_requirement": requirement.StructuredRequirement,
 		"completeness_score":     requirement.CompletenessScore,
+		"rule_score":             requirement.RuleScore,
 		"analysis_status":        requirement.AnalysisStatus,
 		"missing_info_types":     requirement.MissingInfoTypes,
+		"current_version":        requirement.CurrentVersion,
 		"updated_at":             requirement.UpdatedAt,
-	})
-
-	if result.Error != nil {
-		return fmt.Errorf("更新需求分析失败: %w", result.Error)
+	}).Error; err != nil {
+		return err
 	}
 
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("需求分析不存在或未更新")
-	}
-
-	return nil
+	return tx.Create(newRequirementVersion(requirement)).Error
 }
 
 // CreateChatSession 创建对话会话
@@ -194,6 +233,42 @@ func (r *MySQLRepository) AnswerQuestion(questionID uuid.UUID, answer string) er
 	return nil
 }
 
+// GetQuestionByID 根据ID获取问题
+func (r *MySQLRepository) GetQuestionByID(questionID uuid.UUID) (*model.Question, error) {
+	var question model.Question
+
+	err := r.db.GORM.Where("question_id = ?", questionID).First(&question).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("问题不存在")
+		}
+		return nil, fmt.Errorf("查询问题失败: %w", err)
+	}
+
+	return &question, nil
+}
+
+// SkipQuestion 跳过问题
+func (r *MySQLRepository) SkipQuestion(questionID uuid.UUID) error {
+	now := time.Now()
+
+	result := r.db.GORM.Model(&model.Question{}).Where("question_id = ?", questionID).Updates(map[string]interface{}{
+		"answer_text":   "",
+		"answer_status": model.AnswerStatusSkipped,
+		"answered_at":   &now,
+	})
+
+	if result.Error != nil {
+		return fmt.Errorf("跳过问题失败: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("问题不存在")
+	}
+
+	return nil
+}
+
 // CreatePUMLDiagram 创建PUML图表
 func (r *MySQLRepository) CreatePUMLDiagram(diagram *model.PUMLDiagram) error {
 	now := time.Now()
@@ -227,6 +302,7 @@ func (r *MySQLRepository) UpdatePUMLDiagram(diagram *model.PUMLDiagram) error {
 	result := r.db.GORM.Model(diagram).Where("diagram_id = ?", diagram.DiagramID).Updates(map[string]interface{}{
 		"diagram_name":         diagram.DiagramName,
 		"puml_content":         diagram.PUMLContent,
+		"syntax":               diagram.Syntax,
 		"rendered_url":         diagram.RenderedURL,
 		"version":              diagram.Version,
 		"is_validated":         diagram.IsValidated,
@@ -245,12 +321,19 @@ func (r *MySQLRepository) UpdatePUMLDiagram(diagram *model.PUMLDiagram) error {
 	return nil
 }
 
-// DeletePUMLDiagram 删除PUML图表
+// DeletePUMLDiagram 删除PUML图表及其版本快照
 func (r *MySQLRepository) DeletePUMLDiagram(diagramID uuid.UUID) error {
-	result := r.db.GORM.Delete(&model.PUMLDiagram{}, "diagram_id = ?", diagramID)
+	var result *gorm.DB
+	err := r.db.GORM.Transaction(func(tx *gorm.DB) error {
+		result = tx.Delete(&model.PUMLDiagram{}, "diagram_id = ?", diagramID)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Delete(&model.PUMLDiagramVersion{}, "diagram_id = ?", diagramID).Error
+	})
 
-	if result.Error != nil {
-		return fmt.Errorf("删除PUML图表失败: %w", result.Error)
+	if err != nil {
+		return fmt.Errorf("删除PUML图表失败: %w", err)
 	}
 
 	if result.RowsAffected == 0 {
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// newPUMLDiagramVersion 根据PUML图表当前内容生成版本快照
+func newPUMLDiagramVersion(diagram *model.PUMLDiagram, authorID uuid.UUID, note string) *model.PUMLDiagramVersion {
+	return &model.PUMLDiagramVersion{
+		VersionID:          uuid.New(),
+		DiagramID:          diagram.DiagramID,
+		Version:            diagram.Version,
+		DiagramName:        diagram.DiagramName,
+		PUMLContent:        diagram.PUMLContent,
+		Syntax:             diagram.Syntax,
+		IsValidated:        diagram.IsValidated,
+		ValidationFeedback: diagram.ValidationFeedback,
+		AuthorID:           authorID,
+		Note:               note,
+		CreatedAt:          diagram.UpdatedAt,
+	}
+}
+
+// CreateVersionedPUMLDiagram 创建PUML图表，并保存为版本1
+func (r *MySQLRepository) CreateVersionedPUMLDiagram(diagram *model.PUMLDiagram, authorID uuid.UUID, note string) error {
+	err := r.db.GORM.Transaction(func(tx *gorm.DB) error {
+		return createVersionedPUMLDiagram(tx, diagram, authorID, note)
+	})
+	if err != nil {
+		return fmt.Errorf("创建PUML图表失败: %w", err)
+	}
+
+	return nil
+}
+
+// createVersionedPUMLDiagram 在事务中创建PUML图表并保存版本1快照
+func createVersionedPUMLDiagram(tx *gorm.DB, diagram *model.PUMLDiagram, authorID uuid.UUID, note string) error {
+	now := time.Now()
+	diagram.CreatedAt = now
+	diagram.UpdatedAt = now
+	diagram.Version = 1
+
+	if err := tx.Create(diagram).Error; err != nil {
+		return err
+	}
+	return tx.Create(newPUMLDiagramVersion(diagram, authorID, note)).Error
+}
+
+// UpdateVersionedPUMLDiagram 更新PUML图表，并保存为新版本
+func (r *MySQLRepository) UpdateVersionedPUMLDiagram(diagram *model.PUMLDiagram, authorID uuid.UUID, note string) error {
+	diagram.UpdatedAt = time.Now()
+
+	err := r.db.GORM.Transaction(func(tx *gorm.DB) error {
+		var current model.PUMLDiagram
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("diagram_id = ?", diagram.DiagramID).
+			First(&current).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("PUML图表不存在或未更新")
+			}
+			return err
+		}
+
+		// 版本功能上线前的图表没有快照，先把当前内容补存为当前版本
+		var count int64
+		if err := tx.Model(&model.PUMLDiagramVersion{}).Where("diagram_id = ?", diagram.DiagramID).Count(&count).Error; err != nil {
+			return err
+		}
+		if current.Version == 0 {
+			current.Version = 1
+		}
+		if count == 0 {
+			if err := tx.Create(newPUMLDiagramVersion(&current, uuid.Nil, "")).Error; err != nil {
+				return err
+			}
+		}
+
+		diagram.Version = current.Version + 1
+		if err := tx.Model(&model.PUMLDiagram{}).Where("diagram_id = ?", diagram.DiagramID).Updates(map[string]interface{}{
+			"diagram_name":        diagram.DiagramName,
+			"puml_content":        diagram.PUMLContent,
+			"syntax":              diagram.Syntax,
+			"rendered_url":        diagram.RenderedURL,
+			"version":             diagram.Version,
+			"is_validated":        diagram.IsValidated,
+			"validation_feedback": diagram.ValidationFeedback,
+			"updated_at":          diagram.UpdatedAt,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(newPUMLDiagramVersion(diagram, authorID, note)).Error
+	})
+	if err != nil {
+		return fmt.Errorf("更新PUML图表失败: %w", err)
+	}
+
+	return nil
+}
+
+// GetPUMLDiagramVersions 获取PUML图表的版本列表，按版本号升序
+func (r *MySQLRepository) GetPUMLDiagramVersions(diagramID uuid.UUID) ([]*model.PUMLDiagramVersion, error) {
+	var versions []*model.PUMLDiagramVersion
+
+	if err := r.db.GORM.Where("diagram_id = ?", diagramID).
+		Order("version ASC").
+		Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("查询PUML图表版本失败: %w", err)
+	}
+
+	return versions, nil
+}
+
+// GetPUMLDiagramVersion 获取PUML图表的指定版本
+func (r *MySQLRepository) GetPUMLDiagramVersion(diagramID uuid.UUID, version int) (*model.PUMLDiagramVersion, error) {
+	var diagramVersion model.PUMLDiagramVersion
+
+	err := r.db.GORM.Where("diagram_id = ? AND version = ?", diagramID, version).First(&diagramVersion).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("PUML图表版本 %d 不存在", version)
+		}
+		return nil, fmt.Errorf("查询PUML图表版本失败: %w", err)
+	}
+
+	return &diagramVersion, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"testing"
+
+	"ai-dev-platform/internal/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// newSQLMockRepository 创建连接到 sqlmock 的仓库
+func newSQLMockRepository(t *testing.T) (Repository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return NewMySQLRepository(&Database{GORM: gormDB}), mock
+}
+
+func TestUpdateVersionedPUMLDiagram_IncrementsVersion(t *testing.T) {
+	repo, mock := newSQLMockRepository(t)
+	diagramID := uuid.New()
+	authorID := uuid.New()
+	diagram := &model.PUMLDiagram{
+		DiagramID:   diagramID,
+		ProjectID:   uuid.New(),
+		DiagramName: "登录流程",
+		PUMLContent: "@startuml\nA -> B\n@enduml",
+		Syntax:      "plantuml",
+		Version:     2,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `puml_diagrams` WHERE diagram_id = \\? .* FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"diagram_id", "version"}).AddRow(diagramID.String(), 2))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `puml_diagram_versions`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectExec("UPDATE `puml_diagrams` SET").
+		WithArgs(diagram.DiagramName, false, diagram.PUMLContent, "", "plantuml", sqlmock.AnyArg(), "", 3, diagramID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// 只追加新版本快照，已有版本不被修改或删除
+	mock.ExpectExec("INSERT INTO `puml_diagram_versions`").
+		WithArgs(sqlmock.AnyArg(), diagramID, 3, diagram.DiagramName, diagram.PUMLContent, "plantuml", false, "", authorID, "修改", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.UpdateVersionedPUMLDiagram(diagram, authorID, "修改")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, diagram.Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateVersionedPUMLDiagram_BackfillsLegacyVersion(t *testing.T) {
+	repo, mock := newSQLMockRepository(t)
+	diagramID := uuid.New()
+	diagram := &model.PUMLDiagram{DiagramID: diagramID, DiagramName: "旧图表", PUMLContent: "新内容"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `puml_diagrams`").
+		WillReturnRows(sqlmock.NewRows([]string{"diagram_id", "diagram_name", "puml_content", "version"}).
+			AddRow(diagramID.String(), "旧图表", "旧内容", 0))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `puml_diagram_versions`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	// 版本功能上线前的内容先补存为版本1
+	mock.ExpectExec("INSERT INTO `puml_diagram_versions`").
+		WithArgs(sqlmock.AnyArg(), diagramID, 1, "旧图表", "旧内容", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), uuid.Nil, "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE `puml_diagrams` SET").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `puml_diagram_versions`").
+		WithArgs(sqlmock.AnyArg(), diagramID, 2, "旧图表", "新内容", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.UpdateVersionedPUMLDiagram(diagram, uuid.New(), "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, diagram.Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
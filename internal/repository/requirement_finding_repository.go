@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"fmt"
+
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// GetRequirementFindings 获取项目的需求一致性检测结论，status 为空时返回全部
+func (r *MySQLRepository) GetRequirementFindings(projectID uuid.UUID, status string) ([]*model.RequirementFinding, error) {
+	var findings []*model.RequirementFinding
+
+	query := r.db.GORM.Where("project_id = ?", projectID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("created_at ASC").Find(&findings).Error; err != nil {
+		return nil, fmt.Errorf("查询需求检测结论失败: %w", err)
+	}
+
+	return findings, nil
+}
+
+// GetRequirementFinding 获取单条需求一致性检测结论
+func (r *MySQLRepository) GetRequirementFinding(findingID uuid.UUID) (*model.RequirementFinding, error) {
+	var finding model.RequirementFinding
+
+	if err := r.db.GORM.Where("finding_id = ?", findingID).First(&finding).Error; err != nil {
+		return nil, fmt.Errorf("查询需求检测结论失败: %w", err)
+	}
+
+	return &finding, nil
+}
+
+// SaveRequirementFinding 创建或更新需求一致性检测结论
+func (r *MySQLRepository) SaveRequirementFinding(finding *model.RequirementFinding) error {
+	if err := r.db.GORM.Save(finding).Error; err != nil {
+		return fmt.Errorf("保存需求检测结论失败: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// CreateRequirementRefinement 创建需求澄清记录
+func (r *MySQLRepository) CreateRequirementRefinement(refinement *model.RequirementRefinement) error {
+	if refinement.CreatedAt.IsZero() {
+		refinement.CreatedAt = time.Now()
+	}
+
+	if err := r.db.GORM.Create(refinement).Error; err != nil {
+		return fmt.Errorf("创建需求澄清记录失败: %w", err)
+	}
+
+	return nil
+}
+
+// GetRequirementRefinements 获取需求的澄清历史，按轮次升序
+func (r *MySQLRepository) GetRequirementRefinements(requirementID uuid.UUID) ([]*model.RequirementRefinement, error) {
+	var refinements []*model.RequirementRefinement
+
+	if err := r.db.GORM.Where("requirement_id = ?", requirementID).
+		Order("round ASC").
+		Find(&refinements).Error; err != nil {
+		return nil, fmt.Errorf("查询需求澄清历史失败: %w", err)
+	}
+
+	return refinements, nil
+}
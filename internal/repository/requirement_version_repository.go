@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"fmt"
+
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// newRequirementVersion 根据需求分析当前内容生成版本快照
+func newRequirementVersion(requirement *model.Requirement) *model.RequirementVersion {
+	return &model.RequirementVersion{
+		VersionID:             uuid.New(),
+		RequirementID:         requirement.RequirementID,
+		Version:               requirement.CurrentVersion,
+		RawRequirement:        requirement.RawRequirement,
+		StructuredRequirement: requirement.StructuredRequirement,
+		CompletenessScore:     requirement.CompletenessScore,
+		RuleScore:             requirement.RuleScore,
+		MissingInfoTypes:      requirement.MissingInfoTypes,
+		CreatedAt:             requirement.UpdatedAt,
+	}
+}
+
+// GetRequirementVersions 获取需求分析的版本列表，按版本号升序
+func (r *MySQLRepository) GetRequirementVersions(requirementID uuid.UUID) ([]*model.RequirementVersion, error) {
+	var versions []*model.RequirementVersion
+
+	if err := r.db.GORM.Where("requirement_id = ?", requirementID).
+		Order("version ASC").
+		Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("查询需求版本失败: %w", err)
+	}
+
+	return versions, nil
+}
+
+// GetRequirementVersion 获取需求分析的指定版本
+func (r *MySQLRepository) GetRequirementVersion(requirementID uuid.UUID, version int) (*model.RequirementVersion, error) {
+	var requirementVersion model.RequirementVersion
+
+	err := r.db.GORM.Where("requirement_id = ? AND version = ?", requirementID, version).First(&requirementVersion).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("需求版本 %d 不存在", version)
+		}
+		return nil, fmt.Errorf("查询需求版本失败: %w", err)
+	}
+
+	return &requirementVersion, nil
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SaveSchemaImport 在同一事务中保存导入的数据库结构文档、数据模型图（版本1）以及合并了数据实体的需求分析；
+// requirement 为 nil 时不更新需求分析
+func (r *MySQLRepository) SaveSchemaImport(document *model.Document, diagram *model.PUMLDiagram, requirement *model.Requirement, authorID uuid.UUID) error {
+	document.GeneratedAt = time.Now()
+
+	err := r.db.GORM.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(document).Error; err != nil {
+			return err
+		}
+		if err := createVersionedPUMLDiagram(tx, diagram, authorID, "导入数据库结构"); err != nil {
+			return err
+		}
+		if requirement == nil {
+			return nil
+		}
+		return updateRequirementAnalysis(tx, requirement)
+	})
+	if err != nil {
+		return fmt.Errorf("保存导入的数据库结构失败: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"fmt"
+
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetStoryPriorities 获取项目中所有用户故事的优先级评估
+func (r *MySQLRepository) GetStoryPriorities(projectID uuid.UUID) ([]*model.StoryPriority, error) {
+	var priorities []*model.StoryPriority
+
+	if err := r.db.GORM.Where("project_id = ?", projectID).Find(&priorities).Error; err != nil {
+		return nil, fmt.Errorf("查询优先级评估失败: %w", err)
+	}
+
+	return priorities, nil
+}
+
+// GetStoryPriority 获取用户故事的优先级评估，尚未评估时返回 nil
+func (r *MySQLRepository) GetStoryPriority(storyID uuid.UUID) (*model.StoryPriority, error) {
+	var priority model.StoryPriority
+
+	if err := r.db.GORM.Where("story_id = ?", storyID).First(&priority).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询优先级评估失败: %w", err)
+	}
+
+	return &priority, nil
+}
+
+// SaveStoryPriority 在同一事务中保存优先级评估及其变更记录
+func (r *MySQLRepository) SaveStoryPriority(priority *model.StoryPriority, change *model.StoryPriorityChange) error {
+	err := r.db.GORM.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(priority).Error; err != nil {
+			return err
+		}
+		return tx.Create(change).Error
+	})
+	if err != nil {
+		return fmt.Errorf("保存优先级评估失败: %w", err)
+	}
+
+	return nil
+}
+
+// GetStoryPriorityChanges 获取用户故事的优先级变更历史，最新的在前
+func (r *MySQLRepository) GetStoryPriorityChanges(storyID uuid.UUID) ([]*model.StoryPriorityChange, error) {
+	var changes []*model.StoryPriorityChange
+
+	if err := r.db.GORM.Where("story_id = ?", storyID).Order("created_at DESC").Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("查询优先级变更历史失败: %w", err)
+	}
+
+	return changes, nil
+}
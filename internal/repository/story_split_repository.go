@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"fmt"
+
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// CreateStorySplits 批量保存用户故事拆分关系
+func (r *MySQLRepository) CreateStorySplits(splits []*model.StorySplit) error {
+	if len(splits) == 0 {
+		return nil
+	}
+	if err := r.db.GORM.Create(&splits).Error; err != nil {
+		return fmt.Errorf("保存用户故事拆分关系失败: %w", err)
+	}
+	return nil
+}
+
+// GetStorySplits 获取项目中的用户故事拆分关系，按父故事和子故事顺序排列
+func (r *MySQLRepository) GetStorySplits(projectID uuid.UUID) ([]*model.StorySplit, error) {
+	var splits []*model.StorySplit
+
+	if err := r.db.GORM.Where("project_id = ?", projectID).
+		Order("parent_story_id, position").Find(&splits).Error; err != nil {
+		return nil, fmt.Errorf("查询用户故事拆分关系失败: %w", err)
+	}
+
+	return splits, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"ai-dev-platform/internal/tracing"
+
+	"gorm.io/gorm"
+)
+
+const tracingSpanKey = "tracing:span"
+
+// registerTracingCallbacks 为GORM的增删改查注册链路追踪回调，
+// 仅在上下文中已有链路（如HTTP请求或异步任务）时创建Span，避免启动迁移等调用产生孤立Span
+func registerTracingCallbacks(db *gorm.DB) error {
+	callbacks := db.Callback()
+	register := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		{"query", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		{"update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		{"delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		{"row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		{"raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	}
+
+	for _, r := range register {
+		if err := r.before("tracing:before_"+r.operation, startDBSpan(r.operation)); err != nil {
+			return fmt.Errorf("注册%s前置回调失败: %w", r.operation, err)
+		}
+		if err := r.after("tracing:after_"+r.operation, endDBSpan); err != nil {
+			return fmt.Errorf("注册%s后置回调失败: %w", r.operation, err)
+		}
+	}
+	return nil
+}
+
+// startDBSpan 在SQL执行前开始Span
+func startDBSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil || !tracing.SpanContextFromContext(ctx).IsValid() {
+			return
+		}
+
+		_, span := tracing.Start(ctx, "db."+operation)
+		if span == nil {
+			return
+		}
+		span.SetAttribute("db.system", "mysql")
+		span.SetAttribute("db.operation", operation)
+		db.InstanceSet(tracingSpanKey, span)
+	}
+}
+
+// endDBSpan 在SQL执行后结束Span，记录表名、SQL和影响行数
+func endDBSpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(tracingSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(*tracing.Span)
+	if !ok {
+		return
+	}
+
+	if db.Statement.Table != "" {
+		span.SetAttribute("db.table", db.Statement.Table)
+	}
+	if sql := db.Statement.SQL.String(); sql != "" {
+		span.SetAttribute("db.statement", sql)
+	}
+	span.SetAttribute("db.rows_affected", db.Statement.RowsAffected)
+	// 记录未找到不视为失败
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+	}
+	span.End()
+}
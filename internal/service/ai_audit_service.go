@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"ai-dev-platform/internal/ai"
+	"ai-dev-platform/internal/config"
+	"ai-dev-platform/internal/model"
+	"ai-dev-platform/internal/repository"
+	"ai-dev-platform/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// AIAuditService AI调用审计服务，实现 ai.AuditRecorder
+type AIAuditService struct {
+	repo repository.Repository
+	cfg  config.AIAuditConfig
+	ai   config.AIConfig
+}
+
+// NewAIAuditService 创建AI调用审计服务
+func NewAIAuditService(repo repository.Repository, cfg *config.Config) *AIAuditService {
+	service := &AIAuditService{
+		repo: repo,
+		cfg:  cfg.AI.Audit,
+		ai:   cfg.AI,
+	}
+
+	// 启动过期记录清理协程
+	if service.cfg.Enabled && service.cfg.RetentionDays > 0 {
+		go service.cleanup()
+	}
+
+	return service
+}
+
+// Record 保存一条AI调用审计记录
+func (s *AIAuditService) Record(ctx context.Context, record *ai.AuditRecord) {
+	if _, err := s.save(record, nil); err != nil {
+		log.Printf("保存AI审计日志失败: %v", err)
+	}
+}
+
+// save 按大小限制截断后持久化审计记录
+func (s *AIAuditService) save(record *ai.AuditRecord, replayOf *uuid.UUID) (*model.AIAuditLog, error) {
+	if !s.cfg.Enabled {
+		return nil, nil
+	}
+
+	auditLog := &model.AIAuditLog{
+		LogID:            uuid.New(),
+		RequestID:        record.RequestID,
+		UserID:           record.UserID,
+		ProjectID:        record.ProjectID,
+		Operation:        record.Operation,
+		Provider:         string(record.Provider),
+		Model:            record.Model,
+		PromptTokens:     record.PromptTokens,
+		CompletionTokens: record.CompletionTokens,
+		TotalTokens:      record.TotalTokens,
+		DurationMs:       record.Duration.Milliseconds(),
+		ReplayOf:         replayOf,
+		CreatedAt:        record.CreatedAt,
+	}
+
+	var truncated [4]bool
+	auditLog.Prompt, truncated[0] = truncateUTF8(record.Prompt, s.cfg.MaxFieldBytes)
+	auditLog.Response, truncated[1] = truncateUTF8(record.Response, s.cfg.MaxFieldBytes)
+	auditLog.ParseResult, truncated[2] = truncateUTF8(record.ParseResult, s.cfg.MaxFieldBytes)
+	auditLog.ErrorMessage, truncated[3] = truncateUTF8(record.Error, s.cfg.MaxFieldBytes)
+	auditLog.Truncated = truncated[0] || truncated[1] || truncated[2] || truncated[3]
+
+	if err := s.repo.CreateAIAuditLog(auditLog); err != nil {
+		return nil, err
+	}
+
+	return auditLog, nil
+}
+
+// ListAuditLogs 分页查询AI调用审计日志
+func (s *AIAuditService) ListAuditLogs(query *model.AIAuditLogQuery) ([]*model.AIAuditLog, utils.PaginationInfo, error) {
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = 20
+	}
+
+	logs, total, err := s.repo.ListAIAuditLogs(query)
+	if err != nil {
+		return nil, utils.PaginationInfo{}, fmt.Errorf("获取AI审计日志失败: %w", err)
+	}
+
+	return logs, utils.CalculatePagination(query.Page, query.PageSize, total), nil
+}
+
+// GetAuditLog 获取AI调用审计日志详情
+func (s *AIAuditService) GetAuditLog(logID uuid.UUID) (*model.AIAuditLog, error) {
+	return s.repo.GetAIAuditLog(logID)
+}
+
+// ReplayAuditLog 使用指定提供商和模型重新执行审计日志中的提示语
+func (s *AIAuditService) ReplayAuditLog(ctx context.Context, logID uuid.UUID, req *model.ReplayAIAuditLogRequest, userID uuid.UUID) (*model.AIAuditLog, error) {
+	original, err := s.repo.GetAIAuditLog(logID)
+	if err != nil {
+		return nil, err
+	}
+	if original.Truncated {
+		return nil, fmt.Errorf("审计日志内容已被截断，无法重放")
+	}
+
+	provider := ai.AIProvider(req.Provider)
+	modelName := req.Model
+	if modelName == "" && req.Provider == original.Provider {
+		modelName = original.Model
+	}
+
+	recorder := &replayRecorder{service: s, replayOf: &original.LogID}
+	ctx = ai.WithAuditScope(ctx, ai.AuditScope{
+		UserID:    userID.String(),
+		ProjectID: original.ProjectID,
+		Operation: "replay",
+	})
+
+	switch provider {
+	case ai.ProviderOpenAI:
+		if s.ai.OpenAIConfig == nil || s.ai.OpenAIConfig.APIKey == "" {
+			return nil, fmt.Errorf("未配置%s的API密钥", provider)
+		}
+		if modelName == "" {
+			modelName = s.ai.OpenAIConfig.DefaultModel
+		}
+		client := ai.NewOpenAIClient(ai.OpenAIConfig{
+			APIKey:  s.ai.OpenAIConfig.APIKey,
+			BaseURL: os.Getenv("OPENAI_BASE_URL"),
+			Model:   modelName,
+		})
+		client.SetAuditRecorder(recorder)
+		_, err = client.CallOpenAI(ctx, original.Prompt)
+	case ai.ProviderGemini:
+		if s.ai.GeminiConfig == nil || s.ai.GeminiConfig.APIKey == "" {
+			return nil, fmt.Errorf("未配置%s的API密钥", provider)
+		}
+		if modelName == "" {
+			modelName = s.ai.GeminiConfig.DefaultModel
+		}
+		client := ai.NewGeminiClient(ai.GeminiConfig{
+			APIKey: s.ai.GeminiConfig.APIKey,
+			Model:  modelName,
+		})
+		client.SetAuditRecorder(recorder)
+		_, err = client.CallGemini(ctx, original.Prompt)
+	default:
+		return nil, fmt.Errorf("不支持的AI提供商: %s", req.Provider)
+	}
+
+	if recorder.saved != nil {
+		return recorder.saved, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("重放AI调用失败: %w", err)
+	}
+	return nil, fmt.Errorf("重放记录保存失败，请确认审计功能已启用")
+}
+
+// cleanup 定期清理超过保留期限的审计日志
+func (s *AIAuditService) cleanup() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		before := time.Now().AddDate(0, 0, -s.cfg.RetentionDays)
+		deleted, err := s.repo.DeleteAIAuditLogsBefore(before)
+		if err != nil {
+			log.Printf("清理AI审计日志失败: %v", err)
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("已清理过期AI审计日志 %d 条", deleted)
+		}
+	}
+}
+
+// replayRecorder 重放调用的审计记录器，保存时关联原始记录
+type replayRecorder struct {
+	service  *AIAuditService
+	replayOf *uuid.UUID
+	saved    *model.AIAuditLog
+}
+
+// Record 保存重放产生的审计记录
+func (r *replayRecorder) Record(ctx context.Context, record *ai.AuditRecord) {
+	saved, err := r.service.save(record, r.replayOf)
+	if err != nil {
+		log.Printf("保存AI重放审计日志失败: %v", err)
+		return
+	}
+	r.saved = saved
+}
+
+// truncateUTF8 按字节数截断字符串，保证不截断多字节字符
+func truncateUTF8(s string, maxBytes int) (string, bool) {
+	if maxBytes <= 0 || len(s) <= maxBytes {
+		return s, false
+	}
+
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut], true
+}
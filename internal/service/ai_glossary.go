@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai-dev-platform/internal/ai"
+	"ai-dev-platform/internal/glossary"
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// ===== 项目术语表相关服务 =====
+
+// glossaryTermSource 可查询项目术语表的仓库
+type glossaryTermSource interface {
+	GetGlossaryTermsByProject(projectID uuid.UUID) ([]*model.GlossaryTerm, error)
+}
+
+// withProjectGlossary 加载项目术语表并附加到上下文，此后的AI调用会在提示语前注入规范术语
+// 术语表为空或加载失败时原样返回上下文
+func withProjectGlossary(ctx context.Context, repo glossaryTermSource, projectID uuid.UUID) context.Context {
+	if repo == nil || projectID == uuid.Nil {
+		return ctx
+	}
+
+	records, err := repo.GetGlossaryTermsByProject(projectID)
+	if err != nil {
+		log.Printf("加载项目术语表失败: %v", err)
+		return ctx
+	}
+	section := glossary.PromptSection(glossaryTerms(records))
+	if section == "" {
+		return ctx
+	}
+	return ai.WithGlossary(ctx, section)
+}
+
+// glossaryTerms 将数据库记录转换为术语表条目
+func glossaryTerms(records []*model.GlossaryTerm) []glossary.Term {
+	terms := make([]glossary.Term, 0, len(records))
+	for _, record := range records {
+		terms = append(terms, glossaryTerm(record))
+	}
+	return terms
+}
+
+func glossaryTerm(record *model.GlossaryTerm) glossary.Term {
+	term := glossary.Term{Term: record.Term, Definition: record.Definition, Synonyms: []string{}}
+	if record.Synonyms != "" {
+		if err := json.Unmarshal([]byte(record.Synonyms), &term.Synonyms); err != nil {
+			log.Printf("解析术语 %s 的同义词失败: %v", record.TermID, err)
+		}
+	}
+	return term
+}
+
+// projectForUser 校验项目存在且属于该用户
+func (s *AIService) projectForUser(projectID, userID uuid.UUID) (*model.Project, error) {
+	project, err := s.repo.GetProjectByID(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("项目不存在: %w", err)
+	}
+	if project.UserID != userID {
+		return nil, fmt.Errorf("无权访问该项目")
+	}
+	return project, nil
+}
+
+// glossaryTermForUser 获取术语并校验其所属项目属于该用户
+func (s *AIService) glossaryTermForUser(termID, userID uuid.UUID) (*model.GlossaryTerm, error) {
+	term, err := s.repo.GetGlossaryTerm(termID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.projectForUser(term.ProjectID, userID); err != nil {
+		return nil, err
+	}
+	return term, nil
+}
+
+// ListGlossaryTerms 获取项目术语表
+func (s *AIService) ListGlossaryTerms(projectID, userID uuid.UUID) ([]*model.GlossaryTerm, error) {
+	if _, err := s.projectForUser(projectID, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetGlossaryTermsByProject(projectID)
+}
+
+// CreateGlossaryTerm 向项目术语表添加术语，术语及同义词不能与已有术语重复
+func (s *AIService) CreateGlossaryTerm(projectID, userID uuid.UUID, req *model.GlossaryTermRequest) (*model.GlossaryTerm, error) {
+	if _, err := s.projectForUser(projectID, userID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record := &model.GlossaryTerm{
+		TermID:    uuid.New(),
+		ProjectID: projectID,
+		CreatedBy: userID,
+		CreatedAt: now,
+	}
+	if err := s.applyGlossaryTermRequest(record, req); err != nil {
+		return nil, err
+	}
+	record.UpdatedAt = now
+
+	if err := s.repo.CreateGlossaryTerm(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// UpdateGlossaryTerm 更新术语的名称、定义和同义词
+func (s *AIService) UpdateGlossaryTerm(termID, userID uuid.UUID, req *model.GlossaryTermRequest) (*model.GlossaryTerm, error) {
+	record, err := s.glossaryTermForUser(termID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyGlossaryTermRequest(record, req); err != nil {
+		return nil, err
+	}
+	record.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdateGlossaryTerm(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// DeleteGlossaryTerm 从项目术语表删除术语
+func (s *AIService) DeleteGlossaryTerm(termID, userID uuid.UUID) error {
+	if _, err := s.glossaryTermForUser(termID, userID); err != nil {
+		return err
+	}
+	return s.repo.DeleteGlossaryTerm(termID)
+}
+
+// applyGlossaryTermRequest 校验请求并写入术语记录，校验时排除记录自身
+func (s *AIService) applyGlossaryTermRequest(record *model.GlossaryTerm, req *model.GlossaryTermRequest) error {
+	term := glossary.Term{
+		Term:       strings.TrimSpace(req.Term),
+		Definition: strings.TrimSpace(req.Definition),
+	}
+	term.Synonyms = glossary.CleanSynonyms(term.Term, req.Synonyms)
+
+	existing, err := s.repo.GetGlossaryTermsByProject(record.ProjectID)
+	if err != nil {
+		return err
+	}
+	others := make([]glossary.Term, 0, len(existing))
+	for _, other := range existing {
+		if other.TermID != record.TermID {
+			others = append(others, glossaryTerm(other))
+		}
+	}
+	if err := glossary.Validate(term, others); err != nil {
+		return err
+	}
+
+	synonyms, err := json.Marshal(term.Synonyms)
+	if err != nil {
+		return fmt.Errorf("序列化同义词失败: %w", err)
+	}
+
+	record.Term = term.Term
+	record.Definition = term.Definition
+	record.Synonyms = string(synonyms)
+	switch req.Source {
+	case "":
+		if record.Source == "" {
+			record.Source = model.GlossarySourceManual
+		}
+	case model.GlossarySourceManual, model.GlossarySourceExtracted:
+		record.Source = req.Source
+	default:
+		return fmt.Errorf("无效的术语来源: %s", req.Source)
+	}
+	return nil
+}
+
+// ExtractGlossaryCandidates 从项目需求分析的数据实体和角色、文档及PUML图表中提取候选术语
+func (s *AIService) ExtractGlossaryCandidates(projectID, userID uuid.UUID) ([]*glossary.Candidate, error) {
+	if _, err := s.projectForUser(projectID, userID); err != nil {
+		return nil, err
+	}
+
+	var input glossary.Input
+	requirements, err := s.repo.GetRequirementAnalysesByProject(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("获取需求分析失败: %w", err)
+	}
+	for _, requirement := range requirements {
+		analysis, err := requirementToAnalysis(requirement)
+		if err != nil {
+			log.Printf("跳过需求分析 %s: %v", requirement.RequirementID, err)
+			continue
+		}
+		input.Entities = append(input.Entities, analysis.DataEntities...)
+		input.Roles = append(input.Roles, analysis.Roles...)
+	}
+
+	if input.Documents, err = s.documentArtifacts(projectID); err != nil {
+		return nil, err
+	}
+	if input.Diagrams, err = s.diagramArtifacts(projectID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetGlossaryTermsByProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+	return glossary.ExtractCandidates(input, glossaryTerms(existing)), nil
+}
+
+// CheckTerminology 扫描项目文档、PUML图表和AI对话回复中使用的非规范同义词
+func (s *AIService) CheckTerminology(projectID, userID uuid.UUID) (*glossary.Report, error) {
+	if _, err := s.projectForUser(projectID, userID); err != nil {
+		return nil, err
+	}
+
+	records, err := s.repo.GetGlossaryTermsByProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := s.documentArtifacts(projectID)
+	if err != nil {
+		return nil, err
+	}
+	diagrams, err := s.diagramArtifacts(projectID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := s.chatArtifacts(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := append(append(documents, diagrams...), messages...)
+	return glossary.Check(glossaryTerms(records), artifacts), nil
+}
+
+func (s *AIService) documentArtifacts(projectID uuid.UUID) ([]glossary.Artifact, error) {
+	documents, err := s.repo.GetDocumentsByProjectID(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("获取项目文档失败: %w", err)
+	}
+	artifacts := make([]glossary.Artifact, 0, len(documents))
+	for _, doc := range documents {
+		artifacts = append(artifacts, glossary.Artifact{
+			Type:    glossary.ArtifactDocument,
+			ID:      doc.DocumentID.String(),
+			Title:   doc.DocumentName,
+			Content: doc.Content,
+		})
+	}
+	return artifacts, nil
+}
+
+func (s *AIService) diagramArtifacts(projectID uuid.UUID) ([]glossary.Artifact, error) {
+	diagrams, err := s.repo.GetPUMLDiagramsByProjectID(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("获取PUML图表失败: %w", err)
+	}
+	artifacts := make([]glossary.Artifact, 0, len(diagrams))
+	for _, diagram := range diagrams {
+		artifacts = append(artifacts, glossary.Artifact{
+			Type:    glossary.ArtifactDiagram,
+			ID:      diagram.DiagramID.String(),
+			Title:   diagram.DiagramName,
+			Content: diagram.PUMLContent,
+		})
+	}
+	return artifacts, nil
+}
+
+// chatArtifacts 项目所有对话会话中由AI生成的回复
+func (s *AIService) chatArtifacts(projectID uuid.UUID) ([]glossary.Artifact, error) {
+	sessions, err := s.repo.GetChatSessionsByProject(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("获取对话会话失败: %w", err)
+	}
+
+	var artifacts []glossary.Artifact
+	for _, session := range sessions {
+		messages, err := s.repo.GetChatMessages(session.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("获取对话消息失败: %w", err)
+		}
+		for _, message := range messages {
+			if message.SenderType != model.MessageRoleAssistant {
+				continue
+			}
+			artifacts = append(artifacts, glossary.Artifact{
+				Type:    glossary.ArtifactChat,
+				ID:      message.MessageID.String(),
+				Title:   message.Timestamp.Format("2006-01-02 15:04:05"),
+				Content: message.MessageContent,
+			})
+		}
+	}
+	return artifacts, nil
+}
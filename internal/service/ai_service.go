@@ -9,8 +9,11 @@ import (
 	"time"
 
 	"ai-dev-platform/internal/ai"
+	"ai-dev-platform/internal/ears"
 	"ai-dev-platform/internal/model"
+	"ai-dev-platform/internal/plantuml"
 	"ai-dev-platform/internal/repository"
+	"ai-dev-platform/internal/tracing"
 
 	"github.com/google/uuid"
 )
@@ -33,12 +36,23 @@ func NewAIService(aiManager *ai.AIManager, repo repository.Repository) *AIServic
 
 // AnalyzeRequirementWithUser 基于用户AI配置分析业务需求
 func (s *AIService) AnalyzeRequirementWithUser(ctx context.Context, req *model.AIAnalysisRequest, userID uuid.UUID) (*model.Requirement, error) {
+	ctx = ai.WithAuditScope(ctx, ai.AuditScope{UserID: userID.String(), ProjectID: req.ProjectID.String()})
+	ctx = withProjectGlossary(ctx, s.repo, req.ProjectID)
+	ctx = withProjectSchema(ctx, s.repo, req.ProjectID)
+
 	// 验证项目是否存在
 	_, err := s.repo.GetProjectByID(req.ProjectID)
 	if err != nil {
 		return nil, fmt.Errorf("项目不存在: %w", err)
 	}
 
+	// 重新分析已有需求时校验权限
+	if req.RequirementID != nil {
+		if _, err := s.requirementForUser(*req.RequirementID, userID); err != nil {
+			return nil, err
+		}
+	}
+
 	// 使用默认配置进行需求分析
 	log.Printf("使用默认配置进行需求分析")
 	return s.AnalyzeRequirement(ctx, req)
@@ -46,6 +60,10 @@ func (s *AIService) AnalyzeRequirementWithUser(ctx context.Context, req *model.A
 
 // AnalyzeRequirement 分析业务需求（原有方法，作为兼容性保留）
 func (s *AIService) AnalyzeRequirement(ctx context.Context, req *model.AIAnalysisRequest) (*model.Requirement, error) {
+	ctx = ai.WithAuditScope(ctx, ai.AuditScope{ProjectID: req.ProjectID.String()})
+	ctx = withProjectGlossary(ctx, s.repo, req.ProjectID)
+	ctx = withProjectSchema(ctx, s.repo, req.ProjectID)
+
 	// 验证项目是否存在
 	_, err := s.repo.GetProjectByID(req.ProjectID)
 	if err != nil {
@@ -69,7 +87,12 @@ func (s *AIService) AnalyzeRequirement(ctx context.Context, req *model.AIAnalysi
 }
 
 // saveAnalysisResult 保存分析结果到数据库的公共方法
+// 请求指定了 RequirementID 时更新该需求分析并生成新版本，否则创建新的需求分析
 func (s *AIService) saveAnalysisResult(req *model.AIAnalysisRequest, analysis *ai.RequirementAnalysis, aiManager *ai.AIManager, provider ai.AIProvider) (*model.Requirement, error) {
+	if req.RequirementID != nil {
+		return s.saveReanalysisResult(req, analysis, aiManager, provider)
+	}
+
 	// 转换为数据库模型
 	dbAnalysis := &model.Requirement{
 		RequirementID:     uuid.New(),
@@ -81,6 +104,76 @@ func (s *AIService) saveAnalysisResult(req *model.AIAnalysisRequest, analysis *a
 		UpdatedAt:         time.Now(),
 	}
 
+	if err := applyAnalysis(dbAnalysis, analysis); err != nil {
+		return nil, err
+	}
+
+	// 保存到数据库
+	err := s.repo.CreateRequirementAnalysis(dbAnalysis)
+	if err != nil {
+		return nil, fmt.Errorf("保存需求分析失败: %w", err)
+	}
+
+	// EARS检查结果随分析一并保存，失败不影响分析结果
+	if _, err := s.lintRequirement(dbAnalysis); err != nil {
+		log.Printf("EARS检查失败: %v", err)
+	}
+
+	// 如果有缺失信息，生成补充问题
+	if len(analysis.MissingInfo) > 0 {
+		ctx := ai.WithAuditScope(context.Background(), ai.AuditScope{ProjectID: req.ProjectID.String()})
+		ctx = withProjectGlossary(ctx, s.repo, req.ProjectID)
+		ctx = withProjectSchema(ctx, s.repo, req.ProjectID)
+		go s.generateQuestions(ctx, dbAnalysis.RequirementID, analysis, aiManager, provider)
+	}
+
+	return dbAnalysis, nil
+}
+
+// saveReanalysisResult 将重新分析的结果保存为已有需求分析的新版本
+func (s *AIService) saveReanalysisResult(req *model.AIAnalysisRequest, analysis *ai.RequirementAnalysis, aiManager *ai.AIManager, provider ai.AIProvider) (*model.Requirement, error) {
+	dbAnalysis, err := s.repo.GetRequirementAnalysis(*req.RequirementID)
+	if err != nil {
+		return nil, err
+	}
+	if dbAnalysis.ProjectID != req.ProjectID {
+		return nil, fmt.Errorf("需求分析不属于该项目")
+	}
+
+	dbAnalysis.RawRequirement = req.Requirement
+	dbAnalysis.AnalysisStatus = model.AnalysisStatusCompleted
+	if err := applyAnalysis(dbAnalysis, analysis); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateRequirementAnalysis(dbAnalysis); err != nil {
+		return nil, err
+	}
+	s.notifyRequirementChanged(dbAnalysis)
+
+	if _, err := s.lintRequirement(dbAnalysis); err != nil {
+		log.Printf("EARS检查失败: %v", err)
+	}
+
+	if len(analysis.MissingInfo) > 0 {
+		ctx := ai.WithAuditScope(context.Background(), ai.AuditScope{ProjectID: req.ProjectID.String()})
+		ctx = withProjectGlossary(ctx, s.repo, req.ProjectID)
+		ctx = withProjectSchema(ctx, s.repo, req.ProjectID)
+		go s.generateQuestions(ctx, dbAnalysis.RequirementID, analysis, aiManager, provider)
+	}
+
+	return dbAnalysis, nil
+}
+
+// applyAnalysis 将AI分析结果写入需求分析模型（结构化需求、缺失信息、模型评分和规则评分）
+func applyAnalysis(dbAnalysis *model.Requirement, analysis *ai.RequirementAnalysis) error {
+	dbAnalysis.CompletenessScore = analysis.CompletionScore
+
+	scored := *analysis
+	if scored.OriginalText == "" {
+		scored.OriginalText = dbAnalysis.RawRequirement
+	}
+	dbAnalysis.RuleScore = ai.ScoreCompleteness(&scored).Score
+
 	// 序列化结构化需求
 	structuredReq := map[string]interface{}{
 		"core_functions":     analysis.CoreFunctions,
@@ -90,52 +183,55 @@ func (s *AIService) saveAnalysisResult(req *model.AIAnalysisRequest, analysis *a
 	}
 	structuredJSON, err := json.Marshal(structuredReq)
 	if err != nil {
-		return nil, fmt.Errorf("序列化结构化需求失败: %w", err)
+		return fmt.Errorf("序列化结构化需求失败: %w", err)
 	}
 	dbAnalysis.StructuredRequirement = string(structuredJSON)
 
 	// 序列化缺失信息
 	missingInfoJSON, err := json.Marshal(analysis.MissingInfo)
 	if err != nil {
-		return nil, fmt.Errorf("序列化缺失信息失败: %w", err)
+		return fmt.Errorf("序列化缺失信息失败: %w", err)
 	}
 	dbAnalysis.MissingInfoTypes = string(missingInfoJSON)
 
-	// 保存到数据库
-	err = s.repo.CreateRequirementAnalysis(dbAnalysis)
+	return nil
+}
+
+// generateQuestions 根据缺失信息生成补充问题并保存，已存在的同文本问题不会重复创建
+func (s *AIService) generateQuestions(ctx context.Context, requirementID uuid.UUID, analysis *ai.RequirementAnalysis, aiManager *ai.AIManager, provider ai.AIProvider) {
+	questions, err := aiManager.GenerateQuestions(ctx, analysis, provider)
 	if err != nil {
-		return nil, fmt.Errorf("保存需求分析失败: %w", err)
+		log.Printf("生成补充问题失败: %v", err)
+		return
 	}
 
-	// 如果有缺失信息，生成补充问题
-	if len(analysis.MissingInfo) > 0 {
-		go func() {
-			questions, err := aiManager.GenerateQuestions(context.Background(), analysis, provider)
-			if err != nil {
-				log.Printf("生成补充问题失败: %v", err)
-				return
-			}
-
-			// 保存问题到数据库
-			for _, question := range questions {
-				dbQuestion := &model.Question{
-					QuestionID:       uuid.New(),
-					RequirementID:    dbAnalysis.RequirementID,
-					QuestionText:     question.Content,
-					QuestionCategory: question.Category,
-					PriorityLevel:    question.Priority,
-					AnswerStatus:     model.QuestionStatusPending,
-					CreatedAt:        time.Now(),
-				}
-
-				if err := s.repo.CreateQuestion(dbQuestion); err != nil {
-					log.Printf("保存问题失败: %v", err)
-				}
-			}
-		}()
+	existing := make(map[string]bool)
+	if current, err := s.repo.GetQuestionsByRequirementID(requirementID); err == nil {
+		for _, q := range current {
+			existing[strings.TrimSpace(q.QuestionText)] = true
+		}
 	}
 
-	return dbAnalysis, nil
+	// 保存问题到数据库
+	for _, question := range questions {
+		if existing[strings.TrimSpace(question.Content)] {
+			continue
+		}
+
+		dbQuestion := &model.Question{
+			QuestionID:       uuid.New(),
+			RequirementID:    requirementID,
+			QuestionText:     question.Content,
+			QuestionCategory: question.Category,
+			PriorityLevel:    question.Priority,
+			AnswerStatus:     model.QuestionStatusPending,
+			CreatedAt:        time.Now(),
+		}
+
+		if err := s.repo.CreateQuestion(dbQuestion); err != nil {
+			log.Printf("保存问题失败: %v", err)
+		}
+	}
 }
 
 // GetRequirementAnalysis 获取需求分析
@@ -158,10 +254,403 @@ func (s *AIService) AnswerQuestion(questionID uuid.UUID, answer string) error {
 	return s.repo.AnswerQuestion(questionID, answer)
 }
 
+// ===== 需求澄清相关服务 =====
+
+// requirementForUser 获取需求分析并校验用户是否有权访问其所属项目
+func (s *AIService) requirementForUser(requirementID, userID uuid.UUID) (*model.Requirement, error) {
+	requirement, err := s.repo.GetRequirementAnalysis(requirementID)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := s.repo.GetProjectByID(requirement.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("项目不存在: %w", err)
+	}
+	if project.UserID != userID {
+		return nil, fmt.Errorf("无权访问该需求分析")
+	}
+
+	return requirement, nil
+}
+
+// questionForUser 获取补充问题并校验用户权限
+func (s *AIService) questionForUser(questionID, userID uuid.UUID) (*model.Question, error) {
+	question, err := s.repo.GetQuestionByID(questionID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.requirementForUser(question.RequirementID, userID); err != nil {
+		return nil, err
+	}
+	return question, nil
+}
+
+// ListRequirementQuestions 获取需求分析的补充问题列表
+func (s *AIService) ListRequirementQuestions(requirementID, userID uuid.UUID) ([]*model.Question, error) {
+	if _, err := s.requirementForUser(requirementID, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetQuestionsByRequirementID(requirementID)
+}
+
+// AnswerQuestionWithUser 回答补充问题，返回更新后的问题
+func (s *AIService) AnswerQuestionWithUser(questionID uuid.UUID, answer string, userID uuid.UUID) (*model.Question, error) {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return nil, fmt.Errorf("回答内容不能为空")
+	}
+	if _, err := s.questionForUser(questionID, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AnswerQuestion(questionID, answer); err != nil {
+		return nil, err
+	}
+	return s.repo.GetQuestionByID(questionID)
+}
+
+// SkipQuestionWithUser 跳过补充问题，返回更新后的问题
+func (s *AIService) SkipQuestionWithUser(questionID, userID uuid.UUID) (*model.Question, error) {
+	if _, err := s.questionForUser(questionID, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SkipQuestion(questionID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetQuestionByID(questionID)
+}
+
+// RefineRequirement 结合已回答的补充问题重新分析需求，合并分析结果并记录本轮澄清历史
+func (s *AIService) RefineRequirement(ctx context.Context, requirementID uuid.UUID, req *model.RefineRequirementRequest, userID uuid.UUID) (*model.RefineRequirementResult, error) {
+	requirement, err := s.requirementForUser(requirementID, userID)
+	if err != nil {
+		return nil, err
+	}
+	ctx = ai.WithAuditScope(ctx, ai.AuditScope{UserID: userID.String(), ProjectID: requirement.ProjectID.String()})
+	ctx = withProjectGlossary(ctx, s.repo, requirement.ProjectID)
+	ctx = withProjectSchema(ctx, s.repo, requirement.ProjectID)
+
+	questions, err := s.repo.GetQuestionsByRequirementID(requirementID)
+	if err != nil {
+		return nil, err
+	}
+
+	var answers []ai.ClarificationAnswer
+	skipped := 0
+	for _, q := range questions {
+		switch q.AnswerStatus {
+		case model.AnswerStatusAnswered:
+			answers = append(answers, ai.ClarificationAnswer{Question: q.QuestionText, Answer: q.AnswerText})
+		case model.AnswerStatusSkipped:
+			skipped++
+		}
+	}
+	if len(answers) == 0 {
+		return nil, fmt.Errorf("没有已回答的补充问题，无法重新分析")
+	}
+
+	base, err := requirementToAnalysis(requirement)
+	if err != nil {
+		return nil, err
+	}
+
+	// 确定AI提供商
+	provider := ai.ProviderOpenAI
+	if req != nil && req.Provider != "" {
+		provider = ai.AIProvider(req.Provider)
+	}
+
+	// 使用原始需求与问答重新分析
+	refined, err := s.aiManager.AnalyzeRequirement(ctx, ai.BuildClarifiedRequirement(requirement.RawRequirement, answers), provider)
+	if err != nil {
+		return nil, fmt.Errorf("AI分析失败: %w", err)
+	}
+	merged, summary := ai.MergeAnalysis(base, refined)
+
+	history, err := s.repo.GetRequirementRefinements(requirementID)
+	if err != nil {
+		return nil, err
+	}
+
+	refinement := &model.RequirementRefinement{
+		RefinementID:      uuid.New(),
+		RequirementID:     requirementID,
+		Round:             len(history) + 1,
+		Provider:          string(provider),
+		AnsweredCount:     len(answers),
+		SkippedCount:      skipped,
+		ScoreBefore:       requirement.CompletenessScore,
+		MissingInfoBefore: requirement.MissingInfoTypes,
+		CreatedBy:         userID,
+		CreatedAt:         time.Now(),
+	}
+
+	// 更新需求分析
+	if err := applyAnalysis(requirement, merged); err != nil {
+		return nil, err
+	}
+	requirement.AnalysisStatus = model.AnalysisStatusCompleted
+	if err := s.repo.UpdateRequirementAnalysis(requirement); err != nil {
+		return nil, err
+	}
+	s.notifyRequirementChanged(requirement)
+
+	refinement.ScoreAfter = requirement.CompletenessScore
+	refinement.Version = requirement.CurrentVersion
+	refinement.MissingInfoAfter = requirement.MissingInfoTypes
+	qaJSON, err := json.Marshal(answers)
+	if err != nil {
+		return nil, fmt.Errorf("序列化问答失败: %w", err)
+	}
+	refinement.QAPairs = string(qaJSON)
+	addedJSON, err := json.Marshal(summary)
+	if err != nil {
+		return nil, fmt.Errorf("序列化新增内容失败: %w", err)
+	}
+	refinement.AddedItems = string(addedJSON)
+
+	if err := s.repo.CreateRequirementRefinement(refinement); err != nil {
+		return nil, err
+	}
+
+	// 仍有缺失信息时继续生成下一轮补充问题
+	if len(merged.MissingInfo) > 0 {
+		go s.generateQuestions(tracing.Detach(ctx), requirementID, merged, s.aiManager, provider)
+	}
+
+	return &model.RefineRequirementResult{
+		Requirement: requirement,
+		Refinement:  refinement,
+	}, nil
+}
+
+// GetRefinementHistory 获取需求的澄清历史
+func (s *AIService) GetRefinementHistory(requirementID, userID uuid.UUID) ([]*model.RequirementRefinement, error) {
+	if _, err := s.requirementForUser(requirementID, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRequirementRefinements(requirementID)
+}
+
+// ===== 需求版本相关服务 =====
+
+// RequirementVersionDiff 需求分析两个版本之间的结构化差异
+type RequirementVersionDiff struct {
+	RequirementID uuid.UUID        `json:"requirement_id"`
+	FromVersion   int              `json:"from_version"`
+	ToVersion     int              `json:"to_version"`
+	FromCreatedAt time.Time        `json:"from_created_at"`
+	ToCreatedAt   time.Time        `json:"to_created_at"`
+	RawChanged    bool             `json:"raw_changed"` // 原始需求文本是否变化
+	Diff          *ai.AnalysisDiff `json:"diff"`
+}
+
+// ListRequirementVersions 获取需求分析的版本列表
+func (s *AIService) ListRequirementVersions(requirementID, userID uuid.UUID) ([]*model.RequirementVersion, error) {
+	if _, err := s.requirementForUser(requirementID, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRequirementVersions(requirementID)
+}
+
+// GetRequirementVersion 获取需求分析的指定版本
+func (s *AIService) GetRequirementVersion(requirementID uuid.UUID, version int, userID uuid.UUID) (*model.RequirementVersion, error) {
+	if _, err := s.requirementForUser(requirementID, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRequirementVersion(requirementID, version)
+}
+
+// DiffRequirementVersions 比较需求分析的两个版本
+// to 为0时取当前版本，from 为0时取 to 的上一个版本
+func (s *AIService) DiffRequirementVersions(requirementID uuid.UUID, from, to int, userID uuid.UUID) (*RequirementVersionDiff, error) {
+	requirement, err := s.requirementForUser(requirementID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if to == 0 {
+		to = requirement.CurrentVersion
+	}
+	if from == 0 {
+		from = to - 1
+	}
+	if from < 1 || to < 1 {
+		return nil, fmt.Errorf("无效的版本号: %d..%d", from, to)
+	}
+
+	fromVersion, err := s.repo.GetRequirementVersion(requirementID, from)
+	if err != nil {
+		return nil, err
+	}
+	toVersion, err := s.repo.GetRequirementVersion(requirementID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	fromAnalysis, err := versionToAnalysis(requirement, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	toAnalysis, err := versionToAnalysis(requirement, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RequirementVersionDiff{
+		RequirementID: requirementID,
+		FromVersion:   fromVersion.Version,
+		ToVersion:     toVersion.Version,
+		FromCreatedAt: fromVersion.CreatedAt,
+		ToCreatedAt:   toVersion.CreatedAt,
+		RawChanged:    strings.TrimSpace(fromVersion.RawRequirement) != strings.TrimSpace(toVersion.RawRequirement),
+		Diff:          ai.DiffAnalysis(fromAnalysis, toAnalysis),
+	}, nil
+}
+
+// versionToAnalysis 将版本快照还原为AI分析对象
+func versionToAnalysis(requirement *model.Requirement, version *model.RequirementVersion) (*ai.RequirementAnalysis, error) {
+	snapshot := *requirement
+	snapshot.RawRequirement = version.RawRequirement
+	snapshot.StructuredRequirement = version.StructuredRequirement
+	snapshot.CompletenessScore = version.CompletenessScore
+	snapshot.MissingInfoTypes = version.MissingInfoTypes
+	snapshot.UpdatedAt = version.CreatedAt
+	return requirementToAnalysis(&snapshot)
+}
+
+// requirementToAnalysis 将数据库中的需求分析还原为AI分析对象
+func requirementToAnalysis(requirement *model.Requirement) (*ai.RequirementAnalysis, error) {
+	analysis := &ai.RequirementAnalysis{
+		ID:              requirement.RequirementID.String(),
+		ProjectID:       requirement.ProjectID.String(),
+		OriginalText:    requirement.RawRequirement,
+		CompletionScore: requirement.CompletenessScore,
+		CreatedAt:       requirement.CreatedAt,
+		UpdatedAt:       requirement.UpdatedAt,
+	}
+
+	if requirement.StructuredRequirement != "" {
+		var structured struct {
+			CoreFunctions     []string             `json:"core_functions"`
+			Roles             []string             `json:"roles"`
+			BusinessProcesses []ai.BusinessProcess `json:"business_processes"`
+			DataEntities      []ai.DataEntity      `json:"data_entities"`
+		}
+		if err := json.Unmarshal([]byte(requirement.StructuredRequirement), &structured); err != nil {
+			return nil, fmt.Errorf("解析结构化需求失败: %w", err)
+		}
+		analysis.CoreFunctions = structured.CoreFunctions
+		analysis.Roles = structured.Roles
+		analysis.BusinessProcesses = structured.BusinessProcesses
+		analysis.DataEntities = structured.DataEntities
+	}
+
+	if requirement.MissingInfoTypes != "" {
+		if err := json.Unmarshal([]byte(requirement.MissingInfoTypes), &analysis.MissingInfo); err != nil {
+			return nil, fmt.Errorf("解析缺失信息失败: %w", err)
+		}
+	}
+
+	return analysis, nil
+}
+
+// ===== EARS检查相关服务 =====
+
+// RequirementCompleteness 需求分析（或其某个版本）的规则完整度评分
+type RequirementCompleteness struct {
+	RequirementID uuid.UUID `json:"requirement_id"`
+	Version       int       `json:"version"`
+	*ai.CompletenessReport
+}
+
+// GetRequirementCompleteness 按规则检查清单为需求分析评分，version 为0时使用当前内容
+func (s *AIService) GetRequirementCompleteness(requirementID uuid.UUID, version int, userID uuid.UUID) (*RequirementCompleteness, error) {
+	requirement, err := s.requirementForUser(requirementID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var analysis *ai.RequirementAnalysis
+	if version == 0 {
+		version = requirement.CurrentVersion
+		analysis, err = requirementToAnalysis(requirement)
+	} else {
+		var snapshot *model.RequirementVersion
+		if snapshot, err = s.repo.GetRequirementVersion(requirementID, version); err != nil {
+			return nil, err
+		}
+		analysis, err = versionToAnalysis(requirement, snapshot)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &RequirementCompleteness{
+		RequirementID:      requirementID,
+		Version:            version,
+		CompletenessReport: ai.ScoreCompleteness(analysis),
+	}, nil
+}
+
+// ScoreCompleteness 为任意需求分析结果计算规则完整度评分，不调用AI也不保存结果
+func (s *AIService) ScoreCompleteness(analysis *ai.RequirementAnalysis) *ai.CompletenessReport {
+	return ai.ScoreCompleteness(analysis)
+}
+
+// LintEARS 检查需求文本或语句是否符合EARS句型，不调用AI也不保存结果
+func (s *AIService) LintEARS(req *model.EARSLintRequest) (*ears.Report, error) {
+	if req == nil || (strings.TrimSpace(req.Text) == "" && len(req.Sentences) == 0) {
+		return nil, fmt.Errorf("需求文本和需求语句不能同时为空")
+	}
+
+	sentences := append([]string(nil), req.Sentences...)
+	if strings.TrimSpace(req.Text) != "" {
+		sentences = append(sentences, ears.SplitSentences(req.Text)...)
+	}
+	return ears.Lint(sentences), nil
+}
+
+// LintRequirementAnalysis 获取需求分析原始需求的EARS检查报告
+// refresh 为 false 时优先返回最近一次保存的报告，否则重新检查并保存
+func (s *AIService) LintRequirementAnalysis(requirementID, userID uuid.UUID, refresh bool) (*EARSLintResult, error) {
+	requirement, err := s.requirementForUser(requirementID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !refresh {
+		if record, err := s.repo.GetLatestEARSLintReport(model.EARSSourceRequirementAnalysis, requirementID); err == nil && record != nil {
+			return loadEARSLintReport(record)
+		}
+	}
+
+	return s.lintRequirement(requirement)
+}
+
+// lintRequirement 检查需求分析的原始需求并保存报告
+func (s *AIService) lintRequirement(requirement *model.Requirement) (*EARSLintResult, error) {
+	return saveEARSLintReport(s.repo, requirement.ProjectID, model.EARSSourceRequirementAnalysis,
+		requirement.RequirementID, ears.SplitSentences(requirement.RawRequirement))
+}
+
 // ===== PUML图表生成相关服务 =====
 
-// GeneratePUML 生成PUML图表
+// GeneratePUML 生成PUML图表，指定 Mermaid 语法时使用 Mermaid 的提示语
 func (s *AIService) GeneratePUML(ctx context.Context, req *model.GeneratePUMLRequest) (*model.PUMLDiagram, error) {
+	syntax, err := plantuml.ParseSyntax(req.Syntax)
+	if err != nil {
+		return nil, err
+	}
+	ctx = ai.WithDiagramSyntax(ctx, syntax)
+
+	// 数据模型图由数据实体和数据库设计直接生成，不调用AI
+	if diagram, err := s.saveDataModelDiagram(req, uuid.Nil); err != nil || diagram != nil {
+		return diagram, err
+	}
+
 	// 获取需求分析
 	analysisUUID, err := uuid.Parse(req.AnalysisID)
 	if err != nil {
@@ -172,6 +661,9 @@ func (s *AIService) GeneratePUML(ctx context.Context, req *model.GeneratePUMLReq
 	if err != nil {
 		return nil, fmt.Errorf("获取需求分析失败: %w", err)
 	}
+	ctx = ai.WithAuditScope(ctx, ai.AuditScope{ProjectID: dbAnalysis.ProjectID.String()})
+	ctx = withProjectGlossary(ctx, s.repo, dbAnalysis.ProjectID)
+	ctx = withProjectSchema(ctx, s.repo, dbAnalysis.ProjectID)
 
 	// 解析结构化需求
 	var structuredReq map[string]interface{}
@@ -215,17 +707,19 @@ func (s *AIService) GeneratePUML(ctx context.Context, req *model.GeneratePUMLReq
 		DiagramType: req.DiagramType,
 		DiagramName: diagram.Title,
 		PUMLContent: diagram.Content,
+		Syntax:      string(syntax),
 		Version:     1,
 		IsValidated: false,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	// 保存到数据库
-	err = s.repo.CreatePUMLDiagram(dbDiagram)
+	// 保存到数据库，同时保存版本1快照
+	err = s.repo.CreateVersionedPUMLDiagram(dbDiagram, uuid.Nil, "")
 	if err != nil {
 		return nil, fmt.Errorf("保存PUML图表失败: %w", err)
 	}
+	s.recordRequirementDerivation(model.ArtifactTypePUMLDiagram, dbDiagram.DiagramID, dbAnalysis, diagramUsesEntities(req.DiagramType))
 
 	return dbDiagram, nil
 }
@@ -240,26 +734,35 @@ func (s *AIService) GetPUMLDiagramsByProjectID(projectID uuid.UUID) ([]*model.PU
 	return s.repo.GetPUMLDiagramsByProjectID(projectID)
 }
 
-// UpdatePUMLDiagram 更新PUML图表
-func (s *AIService) UpdatePUMLDiagram(diagramID uuid.UUID, req *model.UpdatePUMLRequest) error {
+// UpdatePUMLDiagram 更新PUML图表，并保存为新版本
+func (s *AIService) UpdatePUMLDiagram(diagramID, userID uuid.UUID, req *model.UpdatePUMLRequest) error {
 	// 获取现有图表
 	diagram, err := s.repo.GetPUMLDiagram(diagramID)
 	if err != nil {
 		return fmt.Errorf("获取PUML图表失败: %w", err)
 	}
+	if _, err := s.projectForUser(diagram.ProjectID, userID); err != nil {
+		return err
+	}
 
 	// 更新字段
 	if req.Title != "" {
 		diagram.DiagramName = req.Title
 	}
 	diagram.PUMLContent = req.Content
+	if req.Syntax != "" {
+		syntax, err := plantuml.ParseSyntax(req.Syntax)
+		if err != nil {
+			return err
+		}
+		diagram.Syntax = string(syntax)
+	}
 	if req.Description != "" {
 		diagram.ValidationFeedback = req.Description
 	}
-	diagram.Version++
 
 	// 保存更新
-	return s.repo.UpdatePUMLDiagram(diagram)
+	return s.repo.UpdateVersionedPUMLDiagram(diagram, userID, req.Note)
 }
 
 // CreatePUML 创建PUML图表
@@ -269,7 +772,10 @@ func (s *AIService) CreatePUML(diagram *model.PUMLDiagram) error {
 
 // DeletePUMLDiagram 删除PUML图表
 func (s *AIService) DeletePUMLDiagram(diagramID uuid.UUID) error {
-	return s.repo.DeletePUMLDiagram(diagramID)
+	if err := s.repo.DeletePUMLDiagram(diagramID); err != nil {
+		return err
+	}
+	return s.repo.DeleteArtifactDependencies(model.ArtifactTypePUMLDiagram, diagramID.String())
 }
 
 // ===== 文档生成相关服务 =====
@@ -286,6 +792,9 @@ func (s *AIService) GenerateDocument(ctx context.Context, req *model.GenerateDoc
 	if err != nil {
 		return nil, fmt.Errorf("获取需求分析失败: %w", err)
 	}
+	ctx = ai.WithAuditScope(ctx, ai.AuditScope{ProjectID: dbAnalysis.ProjectID.String()})
+	ctx = withProjectGlossary(ctx, s.repo, dbAnalysis.ProjectID)
+	ctx = withProjectSchema(ctx, s.repo, dbAnalysis.ProjectID)
 
 	// 解析结构化需求
 	var structuredReq map[string]interface{}
@@ -345,6 +854,7 @@ func (s *AIService) GenerateDocument(ctx context.Context, req *model.GenerateDoc
 	if err != nil {
 		return nil, fmt.Errorf("保存生成文档失败: %w", err)
 	}
+	s.recordRequirementDerivation(model.ArtifactTypeDocument, dbDocument.DocumentID, dbAnalysis, true)
 
 	return dbDocument, nil
 }
@@ -375,7 +885,13 @@ func (s *AIService) UpdateDocument(documentID uuid.UUID, req *model.UpdateDocume
 	document.Version++
 
 	// 保存更新
-	return s.repo.UpdateDocument(document)
+	if err := s.repo.UpdateDocument(document); err != nil {
+		return err
+	}
+
+	ref := model.ArtifactRef{Type: model.ArtifactTypeDocument, ID: documentID.String()}
+	notifyUpstreamChanged(s.repo, document.ProjectID, ref, fmt.Sprintf("文档《%s》已修改", document.DocumentName))
+	return nil
 }
 
 // ===== 对话相关服务 =====
@@ -522,6 +1038,16 @@ func (s *AIService) ClearCache() {
 	s.aiManager.ClearCache()
 }
 
+// InvalidateProjectCache 使项目相关的AI缓存失效，返回删除的缓存条目数
+func (s *AIService) InvalidateProjectCache(projectID, userID uuid.UUID) (int, error) {
+	// 验证项目存在且属于该用户
+	if _, err := s.projectForUser(projectID, userID); err != nil {
+		return 0, err
+	}
+
+	return s.aiManager.InvalidateProjectCache(projectID.String()), nil
+}
+
 // ===== 用户AI配置管理相关服务 =====
 
 // GetUserAIConfig 获取用户AI配置
@@ -741,6 +1267,10 @@ type ProjectChatResponse struct {
 
 // ProjectChat 项目上下文AI对话 - 使用用户AI配置
 func (s *AIService) ProjectChat(ctx context.Context, projectID uuid.UUID, message, context string, userID uuid.UUID) (*ProjectChatResponse, error) {
+	ctx = ai.WithAuditScope(ctx, ai.AuditScope{UserID: userID.String(), ProjectID: projectID.String()})
+	ctx = withProjectGlossary(ctx, s.repo, projectID)
+	ctx = withProjectSchema(ctx, s.repo, projectID)
+
 	// 验证项目是否存在
 	project, err := s.repo.GetProjectByID(projectID)
 	if err != nil {
@@ -777,6 +1307,7 @@ func (s *AIService) ProjectChat(ctx context.Context, projectID uuid.UUID, messag
 		DefaultProvider: provider,
 		EnableCache:     true,
 		CacheTTL:        time.Hour,
+		AuditRecorder:   s.aiManager.GetAuditRecorder(),
 	}
 
 	// 根据provider设置对应的客户端配置
@@ -873,6 +1404,10 @@ func (s *AIService) ProjectChat(ctx context.Context, projectID uuid.UUID, messag
 
 // GenerateStageDocuments 分阶段生成项目文档
 func (s *AIService) GenerateStageDocuments(ctx context.Context, req *model.GenerateStageDocumentsRequest, userID uuid.UUID) (*model.StageDocumentsResult, error) {
+	ctx = ai.WithAuditScope(ctx, ai.AuditScope{UserID: userID.String(), ProjectID: req.ProjectID.String()})
+	ctx = withProjectGlossary(ctx, s.repo, req.ProjectID)
+	ctx = withProjectSchema(ctx, s.repo, req.ProjectID)
+
 	// 验证项目是否存在
 	project, err := s.repo.GetProjectByID(req.ProjectID)
 	if err != nil {
@@ -917,6 +1452,7 @@ func (s *AIService) GenerateStageDocuments(ctx context.Context, req *model.Gener
 		DefaultProvider: provider,
 		EnableCache:     true,
 		CacheTTL:        time.Hour,
+		AuditRecorder:   s.aiManager.GetAuditRecorder(),
 	}
 
 	// 根据provider设置对应的客户端配置
@@ -1009,6 +1545,18 @@ func (s *AIService) GenerateSpecificStageDocuments(ctx context.Context, req *mod
 
 // GeneratePUMLWithUser 使用用户配置生成PUML图表
 func (s *AIService) GeneratePUMLWithUser(ctx context.Context, req *model.GeneratePUMLRequest, userID uuid.UUID) (*model.PUMLDiagram, error) {
+	ctx = ai.WithAuditScope(ctx, ai.AuditScope{UserID: userID.String()})
+	syntax, err := plantuml.ParseSyntax(req.Syntax)
+	if err != nil {
+		return nil, err
+	}
+	ctx = ai.WithDiagramSyntax(ctx, syntax)
+
+	// 数据模型图由数据实体和数据库设计直接生成，不需要AI配置
+	if diagram, err := s.saveDataModelDiagram(req, userID); err != nil || diagram != nil {
+		return diagram, err
+	}
+
 	// 获取用户AI配置
 	userConfig, err := s.repo.GetUserAIConfig(userID)
 	if err != nil {
@@ -1039,6 +1587,7 @@ func (s *AIService) GeneratePUMLWithUser(ctx context.Context, req *model.Generat
 		DefaultProvider: provider,
 		EnableCache:     true,
 		CacheTTL:        time.Hour,
+		AuditRecorder:   s.aiManager.GetAuditRecorder(),
 	}
 
 	// 根据provider设置对应的客户端配置
@@ -1074,6 +1623,9 @@ func (s *AIService) GeneratePUMLWithUser(ctx context.Context, req *model.Generat
 	if err != nil {
 		return nil, fmt.Errorf("获取需求分析失败: %w", err)
 	}
+	ctx = ai.WithAuditScope(ctx, ai.AuditScope{ProjectID: analysis.ProjectID.String()})
+	ctx = withProjectGlossary(ctx, s.repo, analysis.ProjectID)
+	ctx = withProjectSchema(ctx, s.repo, analysis.ProjectID)
 
 	// 构建AI分析对象
 	var structuredReq map[string]interface{}
@@ -1109,22 +1661,26 @@ func (s *AIService) GeneratePUMLWithUser(ctx context.Context, req *model.Generat
 		DiagramType: req.DiagramType,
 		DiagramName: fmt.Sprintf("%s图表", req.DiagramType),
 		PUMLContent: pumlDiagram.Content,
+		Syntax:      string(syntax),
 		Version:     1,
 		Stage:       1,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	// 保存到数据库
-	if err := s.repo.CreatePUMLDiagram(diagram); err != nil {
+	// 保存到数据库，同时保存版本1快照
+	if err := s.repo.CreateVersionedPUMLDiagram(diagram, userID, ""); err != nil {
 		return nil, fmt.Errorf("保存PUML图表失败: %w", err)
 	}
+	s.recordRequirementDerivation(model.ArtifactTypePUMLDiagram, diagram.DiagramID, analysis, diagramUsesEntities(req.DiagramType))
 
 	return diagram, nil
 }
 
 // GenerateDocumentWithUser 使用用户配置生成开发文档
 func (s *AIService) GenerateDocumentWithUser(ctx context.Context, req *model.GenerateDocumentRequest, userID uuid.UUID) (*model.Document, error) {
+	ctx = ai.WithAuditScope(ctx, ai.AuditScope{UserID: userID.String()})
+
 	// 获取用户AI配置
 	userConfig, err := s.repo.GetUserAIConfig(userID)
 	if err != nil {
@@ -1155,6 +1711,7 @@ func (s *AIService) GenerateDocumentWithUser(ctx context.Context, req *model.Gen
 		DefaultProvider: provider,
 		EnableCache:     true,
 		CacheTTL:        time.Hour,
+		AuditRecorder:   s.aiManager.GetAuditRecorder(),
 	}
 
 	// 根据provider设置对应的客户端配置
@@ -1190,6 +1747,9 @@ func (s *AIService) GenerateDocumentWithUser(ctx context.Context, req *model.Gen
 	if err != nil {
 		return nil, fmt.Errorf("获取需求分析失败: %w", err)
 	}
+	ctx = ai.WithAuditScope(ctx, ai.AuditScope{ProjectID: analysis.ProjectID.String()})
+	ctx = withProjectGlossary(ctx, s.repo, analysis.ProjectID)
+	ctx = withProjectSchema(ctx, s.repo, analysis.ProjectID)
 
 	// 构建AI分析对象
 	var structuredReq map[string]interface{}
@@ -1241,6 +1801,7 @@ func (s *AIService) GenerateDocumentWithUser(ctx context.Context, req *model.Gen
 	if err := s.repo.CreateDocument(document); err != nil {
 		return nil, fmt.Errorf("保存文档失败: %w", err)
 	}
+	s.recordRequirementDerivation(model.ArtifactTypeDocument, document.DocumentID, analysis, true)
 
 	return document, nil
 }
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"ai-dev-platform/internal/ai"
+	"ai-dev-platform/internal/impact"
+	"ai-dev-platform/internal/model"
+	"ai-dev-platform/internal/plantuml"
+
+	"github.com/google/uuid"
+)
+
+// ===== 变更影响分析相关服务 =====
+
+// artifactDependencyStore 产物依赖图的存储
+type artifactDependencyStore interface {
+	CreateArtifactDependencies(dependencies []*model.ArtifactDependency) error
+	GetArtifactDependencies(projectID uuid.UUID) ([]*model.ArtifactDependency, error)
+	SaveArtifactDependency(dependency *model.ArtifactDependency) error
+	DeleteArtifactDependencies(artifactType, artifactID string) error
+}
+
+// recordDerivation 记录产物的派生来源，替换该产物此前的依赖记录
+func recordDerivation(store artifactDependencyStore, projectID uuid.UUID, artifact model.ArtifactRef, upstreams []*model.ArtifactDependency) error {
+	if err := store.DeleteArtifactDependencies(artifact.Type, artifact.ID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, dep := range upstreams {
+		dep.DependencyID = uuid.New()
+		dep.ProjectID = projectID
+		dep.ArtifactType = artifact.Type
+		dep.ArtifactID = artifact.ID
+		dep.CreatedAt = now
+		dep.UpdatedAt = now
+	}
+	return store.CreateArtifactDependencies(upstreams)
+}
+
+// markStale 按判定函数标记过期依赖并传播到下游，保存状态有变化的依赖
+func markStale(store artifactDependencyStore, projectID uuid.UUID, detect impact.Detector) ([]*model.ArtifactDependency, error) {
+	deps, err := store.GetArtifactDependencies(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := impact.Propagate(deps, detect, time.Now())
+	for _, dep := range changed {
+		if err := store.SaveArtifactDependency(dep); err != nil {
+			return nil, err
+		}
+	}
+	return changed, nil
+}
+
+// requirementUpstreams 产物对需求分析当前版本的依赖；withEntities 时同时依赖每个数据实体的当前内容
+func requirementUpstreams(requirement *model.Requirement, withEntities bool) ([]*model.ArtifactDependency, error) {
+	requirementID := requirement.RequirementID.String()
+	upstreams := []*model.ArtifactDependency{{
+		UpstreamType:    model.ArtifactTypeRequirement,
+		UpstreamID:      requirementID,
+		UpstreamVersion: requirement.CurrentVersion,
+	}}
+	if !withEntities {
+		return upstreams, nil
+	}
+
+	analysis, err := requirementToAnalysis(requirement)
+	if err != nil {
+		return nil, err
+	}
+	for _, entity := range analysis.DataEntities {
+		upstreams = append(upstreams, &model.ArtifactDependency{
+			UpstreamType:    model.ArtifactTypeEntity,
+			UpstreamID:      requirementID,
+			UpstreamKey:     entity.Name,
+			UpstreamVersion: requirement.CurrentVersion,
+			UpstreamHash:    impact.EntityHash(entity),
+		})
+	}
+	return upstreams, nil
+}
+
+// diagramUsesEntities 数据模型图和类图由数据实体派生
+func diagramUsesEntities(diagramType string) bool {
+	return diagramType == string(ai.PUMLTypeDataModel) || diagramType == string(ai.PUMLTypeClass)
+}
+
+// recordRequirementDerivation 记录图表或文档由需求分析派生，失败时只记录日志
+func (s *AIService) recordRequirementDerivation(artifactType string, artifactID uuid.UUID, requirement *model.Requirement, withEntities bool) {
+	upstreams, err := requirementUpstreams(requirement, withEntities)
+	if err == nil {
+		ref := model.ArtifactRef{Type: artifactType, ID: artifactID.String()}
+		err = recordDerivation(s.repo, requirement.ProjectID, ref, upstreams)
+	}
+	if err != nil {
+		log.Printf("记录产物依赖失败: %v", err)
+	}
+}
+
+// notifyRequirementChanged 需求分析保存新版本后，标记由旧版本或已变更数据实体派生的产物为过期
+func (s *AIService) notifyRequirementChanged(requirement *model.Requirement) {
+	analysis, err := requirementToAnalysis(requirement)
+	if err != nil {
+		log.Printf("标记过期产物失败: %v", err)
+		return
+	}
+
+	detect := impact.RequirementChanges(requirement.RequirementID.String(), requirement.CurrentVersion, analysis.DataEntities)
+	changed, err := markStale(s.repo, requirement.ProjectID, detect)
+	if err != nil {
+		log.Printf("标记过期产物失败: %v", err)
+		return
+	}
+	if len(changed) > 0 {
+		log.Printf("需求分析 %s 更新到 v%d，%d 条产物依赖已过期", requirement.RequirementID, requirement.CurrentVersion, len(changed))
+	}
+}
+
+// notifyUpstreamChanged 上游产物被修改后标记其下游产物为过期，失败时只记录日志
+func notifyUpstreamChanged(store artifactDependencyStore, projectID uuid.UUID, upstream model.ArtifactRef, reason string) {
+	if _, err := markStale(store, projectID, impact.UpstreamChanged(upstream, reason)); err != nil {
+		log.Printf("标记过期产物失败: %v", err)
+	}
+}
+
+// sourceRequirement 产物依赖记录中的来源需求分析
+func (s *AIService) sourceRequirement(projectID uuid.UUID, artifact model.ArtifactRef) (*model.Requirement, error) {
+	deps, err := s.repo.GetArtifactDependencies(projectID)
+	if err != nil {
+		return nil, err
+	}
+	for _, dep := range deps {
+		if dep.ArtifactType == artifact.Type && dep.ArtifactID == artifact.ID && dep.UpstreamType == model.ArtifactTypeRequirement {
+			requirementID, err := uuid.Parse(dep.UpstreamID)
+			if err != nil {
+				return nil, fmt.Errorf("无效的来源需求分析ID: %s", dep.UpstreamID)
+			}
+			return s.repo.GetRequirementAnalysis(requirementID)
+		}
+	}
+	return nil, fmt.Errorf("%s没有记录来源需求分析，无法重新生成", impact.Label(artifact.Type))
+}
+
+// RegenerateDiagram 基于来源需求分析的当前版本重新生成PUML图表，原地更新内容并递增版本
+func (s *AIService) RegenerateDiagram(ctx context.Context, diagramID, userID uuid.UUID) (*model.PUMLDiagram, error) {
+	diagram, err := s.repo.GetPUMLDiagram(diagramID)
+	if err != nil {
+		return nil, fmt.Errorf("获取PUML图表失败: %w", err)
+	}
+	if _, err := s.projectForUser(diagram.ProjectID, userID); err != nil {
+		return nil, err
+	}
+	ctx = ai.WithAuditScope(ctx, ai.AuditScope{UserID: userID.String(), ProjectID: diagram.ProjectID.String()})
+	ctx = withProjectGlossary(ctx, s.repo, diagram.ProjectID)
+	ctx = withProjectSchema(ctx, s.repo, diagram.ProjectID)
+	syntax := DiagramSyntax(diagram)
+	ctx = ai.WithDiagramSyntax(ctx, syntax)
+
+	ref := model.ArtifactRef{Type: model.ArtifactTypePUMLDiagram, ID: diagramID.String()}
+	requirement, err := s.sourceRequirement(diagram.ProjectID, ref)
+	if err != nil {
+		return nil, err
+	}
+	analysis, err := requirementToAnalysis(requirement)
+	if err != nil {
+		return nil, err
+	}
+
+	// 数据模型图确定性生成，沿用现有图表的写法
+	style := ai.DataModelEntity
+	if plantuml.ParseAs(diagram.PUMLContent, syntax).Type == plantuml.DiagramClass {
+		style = ai.DataModelClass
+	}
+	generated, err := s.dataModelPUML(requirement, diagram.DiagramType, style)
+	if err != nil {
+		return nil, err
+	}
+	if generated != nil {
+		if err := convertGenerated(generated, syntax); err != nil {
+			return nil, err
+		}
+	} else {
+		generated, err = s.aiManager.GeneratePUML(ctx, analysis, ai.PUMLType(diagram.DiagramType), ai.ProviderOpenAI)
+		if err != nil {
+			return nil, fmt.Errorf("AI生成PUML失败: %w", err)
+		}
+	}
+
+	diagram.PUMLContent = generated.Content
+	diagram.IsValidated = false
+	if err := s.repo.UpdateVersionedPUMLDiagram(diagram, userID, "根据需求分析重新生成"); err != nil {
+		return nil, fmt.Errorf("保存PUML图表失败: %w", err)
+	}
+
+	s.recordRequirementDerivation(ref.Type, diagramID, requirement, diagramUsesEntities(diagram.DiagramType))
+	return diagram, nil
+}
+
+// RegenerateDocument 基于来源需求分析的当前版本重新生成开发文档，原地更新内容并递增版本
+func (s *AIService) RegenerateDocument(ctx context.Context, documentID, userID uuid.UUID) (*model.Document, error) {
+	document, err := s.repo.GetDocument(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("获取文档失败: %w", err)
+	}
+	if _, err := s.projectForUser(document.ProjectID, userID); err != nil {
+		return nil, err
+	}
+	ctx = ai.WithAuditScope(ctx, ai.AuditScope{UserID: userID.String(), ProjectID: document.ProjectID.String()})
+	ctx = withProjectGlossary(ctx, s.repo, document.ProjectID)
+	ctx = withProjectSchema(ctx, s.repo, document.ProjectID)
+
+	ref := model.ArtifactRef{Type: model.ArtifactTypeDocument, ID: documentID.String()}
+	requirement, err := s.sourceRequirement(document.ProjectID, ref)
+	if err != nil {
+		return nil, err
+	}
+	analysis, err := requirementToAnalysis(requirement)
+	if err != nil {
+		return nil, err
+	}
+
+	generated, err := s.aiManager.GenerateDocument(ctx, analysis, ai.ProviderOpenAI)
+	if err != nil {
+		return nil, fmt.Errorf("AI生成文档失败: %w", err)
+	}
+	contentJSON, err := json.Marshal(generated)
+	if err != nil {
+		return nil, fmt.Errorf("序列化文档内容失败: %w", err)
+	}
+
+	document.Content = string(contentJSON)
+	document.Format = "json"
+	document.Version++
+	document.GeneratedAt = time.Now()
+	if err := s.repo.UpdateDocument(document); err != nil {
+		return nil, fmt.Errorf("保存生成文档失败: %w", err)
+	}
+
+	s.recordRequirementDerivation(ref.Type, documentID, requirement, true)
+	return document, nil
+}
@@ -5,12 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
 	"time"
 
 	"ai-dev-platform/internal/ai"
+	"ai-dev-platform/internal/impact"
+	"ai-dev-platform/internal/metrics"
 	"ai-dev-platform/internal/model"
 	"ai-dev-platform/internal/repository"
+	"ai-dev-platform/internal/tracing"
 
 	"github.com/google/uuid"
 )
@@ -19,6 +23,7 @@ import (
 type AsyncTaskService struct {
 	repo         repository.Repository
 	aiService    *AIService
+	specService  *SpecService
 	executors    map[string]TaskExecutor
 	mu           sync.RWMutex
 	activeAIManager *ai.AIManager // 默认AI管理器
@@ -66,10 +71,22 @@ func (s *AsyncTaskService) registerExecutors() {
 		service:   s,
 		aiService: s.aiService,
 	}
+	s.executors[model.TaskTypeArtifactRegeneration] = &ArtifactRegenerationExecutor{
+		service:   s,
+		aiService: s.aiService,
+	}
+}
+
+// SetSpecService 设置 Spec 服务，用于重新生成设计文档和任务列表
+func (s *AsyncTaskService) SetSpecService(specService *SpecService) {
+	s.specService = specService
 }
 
 // StartStageDocumentGeneration 启动阶段文档生成任务
-func (s *AsyncTaskService) StartStageDocumentGeneration(projectID uuid.UUID, userID uuid.UUID, stage int) (*model.AsyncTaskResponse, error) {
+func (s *AsyncTaskService) StartStageDocumentGeneration(ctx context.Context, projectID uuid.UUID, userID uuid.UUID, stage int) (*model.AsyncTaskResponse, error) {
+	ctx, span := tracing.Start(ctx, "task.enqueue")
+	defer span.End()
+
 	// 创建任务
 	task := &model.AsyncTask{
 		TaskID:    uuid.New(),
@@ -81,10 +98,14 @@ func (s *AsyncTaskService) StartStageDocumentGeneration(projectID uuid.UUID, use
 		Progress:  0,
 		CreatedAt: time.Now(),
 		Metadata:  fmt.Sprintf(`{"stage": %d}`, stage),
+		TraceID:   tracing.TraceID(ctx),
 	}
+	span.SetAttribute("task.id", task.TaskID.String())
+	span.SetAttribute("task.type", task.TaskType)
 
 	// 保存任务到数据库
-	if err := s.repo.CreateAsyncTask(task); err != nil {
+	if err := s.repo.WithContext(ctx).CreateAsyncTask(task); err != nil {
+		span.RecordError(err)
 		return nil, fmt.Errorf("创建任务失败: %w", err)
 	}
 
@@ -93,19 +114,23 @@ func (s *AsyncTaskService) StartStageDocumentGeneration(projectID uuid.UUID, use
 		log.Printf("初始化阶段进度失败: %v", err)
 	}
 
-	// 异步执行任务
-	go s.executeTask(context.Background(), task)
+	// 异步执行任务，脱离请求的取消信号但保留链路信息
+	go s.executeTask(tracing.Detach(ctx), task)
 
 	return &model.AsyncTaskResponse{
 		TaskID:   task.TaskID,
 		Status:   task.Status,
 		Progress: task.Progress,
+		TraceID:  task.TraceID,
 		Message:  "任务已启动",
 	}, nil
 }
 
 // StartCompleteProjectDocumentGeneration 启动完整项目文档生成任务
-func (s *AsyncTaskService) StartCompleteProjectDocumentGeneration(projectID uuid.UUID, userID uuid.UUID) (*model.AsyncTaskResponse, error) {
+func (s *AsyncTaskService) StartCompleteProjectDocumentGeneration(ctx context.Context, projectID uuid.UUID, userID uuid.UUID) (*model.AsyncTaskResponse, error) {
+	ctx, span := tracing.Start(ctx, "task.enqueue")
+	defer span.End()
+
 	// 创建任务
 	task := &model.AsyncTask{
 		TaskID:    uuid.New(),
@@ -117,10 +142,14 @@ func (s *AsyncTaskService) StartCompleteProjectDocumentGeneration(projectID uuid
 		Progress:  0,
 		CreatedAt: time.Now(),
 		Metadata:  `{"stages": [1, 2, 3]}`,
+		TraceID:   tracing.TraceID(ctx),
 	}
+	span.SetAttribute("task.id", task.TaskID.String())
+	span.SetAttribute("task.type", task.TaskType)
 
 	// 保存任务到数据库
-	if err := s.repo.CreateAsyncTask(task); err != nil {
+	if err := s.repo.WithContext(ctx).CreateAsyncTask(task); err != nil {
+		span.RecordError(err)
 		return nil, fmt.Errorf("创建任务失败: %w", err)
 	}
 
@@ -131,17 +160,73 @@ func (s *AsyncTaskService) StartCompleteProjectDocumentGeneration(projectID uuid
 		}
 	}
 
-	// 异步执行任务
-	go s.executeTask(context.Background(), task)
+	// 异步执行任务，脱离请求的取消信号但保留链路信息
+	go s.executeTask(tracing.Detach(ctx), task)
 
 	return &model.AsyncTaskResponse{
 		TaskID:   task.TaskID,
 		Status:   task.Status,
 		Progress: task.Progress,
+		TraceID:  task.TraceID,
 		Message:  "完整项目文档生成任务已启动",
 	}, nil
 }
 
+// StartArtifactRegeneration 启动过期产物重新生成任务
+func (s *AsyncTaskService) StartArtifactRegeneration(ctx context.Context, projectID uuid.UUID, userID uuid.UUID, artifacts []model.ArtifactRef) (*model.AsyncTaskResponse, error) {
+	if len(artifacts) == 0 {
+		return nil, fmt.Errorf("请选择需要重新生成的产物")
+	}
+	project, err := s.repo.GetProjectByID(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("项目不存在: %w", err)
+	}
+	if project.UserID != userID {
+		return nil, fmt.Errorf("无权访问该项目")
+	}
+
+	metadata, err := json.Marshal(map[string]interface{}{"artifacts": artifacts})
+	if err != nil {
+		return nil, fmt.Errorf("序列化任务元数据失败: %w", err)
+	}
+
+	ctx, span := tracing.Start(ctx, "task.enqueue")
+	defer span.End()
+
+	// 创建任务
+	task := &model.AsyncTask{
+		TaskID:    uuid.New(),
+		UserID:    userID,
+		ProjectID: projectID,
+		TaskType:  model.TaskTypeArtifactRegeneration,
+		TaskName:  fmt.Sprintf("重新生成%d个过期产物", len(artifacts)),
+		Status:    model.TaskStatusPending,
+		Progress:  0,
+		CreatedAt: time.Now(),
+		Metadata:  string(metadata),
+		TraceID:   tracing.TraceID(ctx),
+	}
+	span.SetAttribute("task.id", task.TaskID.String())
+	span.SetAttribute("task.type", task.TaskType)
+
+	// 保存任务到数据库
+	if err := s.repo.WithContext(ctx).CreateAsyncTask(task); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("创建任务失败: %w", err)
+	}
+
+	// 异步执行任务，脱离请求的取消信号但保留链路信息
+	go s.executeTask(tracing.Detach(ctx), task)
+
+	return &model.AsyncTaskResponse{
+		TaskID:   task.TaskID,
+		Status:   task.Status,
+		Progress: task.Progress,
+		TraceID:  task.TraceID,
+		Message:  "过期产物重新生成任务已启动",
+	}, nil
+}
+
 // GetTaskStatus 获取任务状态
 func (s *AsyncTaskService) GetTaskStatus(taskID uuid.UUID) (*model.AsyncTaskResponse, error) {
 	task, err := s.repo.GetAsyncTask(taskID)
@@ -153,6 +238,7 @@ func (s *AsyncTaskService) GetTaskStatus(taskID uuid.UUID) (*model.AsyncTaskResp
 		TaskID:   task.TaskID,
 		Status:   task.Status,
 		Progress: task.Progress,
+		TraceID:  task.TraceID,
 	}
 
 	if task.Status == model.TaskStatusCompleted && task.ResultData != "" {
@@ -227,13 +313,36 @@ func (s *AsyncTaskService) GetStageProgress(projectID uuid.UUID) (*model.StagePr
 
 // executeTask 执行任务
 func (s *AsyncTaskService) executeTask(ctx context.Context, task *model.AsyncTask) {
+	ctx, span := tracing.Start(ctx, "task.execute")
+	span.SetAttribute("task.id", task.TaskID.String())
+	span.SetAttribute("task.type", task.TaskType)
+
+	metrics.AsyncTaskQueueDepth.Inc(task.TaskType)
+	started := time.Now()
+	defer func() {
+		metrics.AsyncTaskQueueDepth.Dec(task.TaskType)
+		metrics.AsyncTasksTotal.Inc(task.TaskType, task.Status)
+		metrics.AsyncTaskDuration.Observe(time.Since(started).Seconds(), task.TaskType, task.Status)
+
+		span.SetAttribute("task.status", task.Status)
+		if task.Status == model.TaskStatusFailed {
+			span.RecordError(fmt.Errorf("%s", task.ErrorMessage))
+		}
+		span.End()
+	}()
+
+	// 未经请求创建的任务（如重试）在执行时补记链路ID
+	if task.TraceID == "" {
+		task.TraceID = tracing.TraceID(ctx)
+	}
+
 	// 更新任务状态为运行中
 	task.Status = model.TaskStatusRunning
 	task.Progress = 0
 	now := time.Now()
 	task.StartedAt = &now
 
-	if err := s.repo.UpdateAsyncTask(task); err != nil {
+	if err := s.repo.WithContext(ctx).UpdateAsyncTask(task); err != nil {
 		log.Printf("更新任务状态失败: %v", err)
 		return
 	}
@@ -241,29 +350,32 @@ func (s *AsyncTaskService) executeTask(ctx context.Context, task *model.AsyncTas
 	// 获取对应的执行器
 	executor, exists := s.executors[task.TaskType]
 	if !exists {
-		s.markTaskFailed(task, fmt.Sprintf("不支持的任务类型: %s", task.TaskType))
+		s.markTaskFailed(ctx, task, fmt.Sprintf("不支持的任务类型: %s", task.TaskType))
 		return
 	}
 
 	// 执行任务
+	ctx = ai.WithAuditScope(ctx, ai.AuditScope{UserID: task.UserID.String(), ProjectID: task.ProjectID.String()})
+	ctx = withProjectGlossary(ctx, s.repo, task.ProjectID)
+	ctx = withProjectSchema(ctx, s.repo, task.ProjectID)
 	if err := executor.Execute(ctx, task); err != nil {
-		s.markTaskFailed(task, err.Error())
+		s.markTaskFailed(ctx, task, err.Error())
 		return
 	}
 
 	// 标记任务完成
-	s.markTaskCompleted(task)
+	s.markTaskCompleted(ctx, task)
 }
 
 // markTaskFailed 标记任务失败
-func (s *AsyncTaskService) markTaskFailed(task *model.AsyncTask, errorMsg string) {
+func (s *AsyncTaskService) markTaskFailed(ctx context.Context, task *model.AsyncTask, errorMsg string) {
 	task.Status = model.TaskStatusFailed
 	task.Progress = 0
 	task.ErrorMessage = errorMsg
 	now := time.Now()
 	task.CompletedAt = &now
 
-	if err := s.repo.UpdateAsyncTask(task); err != nil {
+	if err := s.repo.WithContext(ctx).UpdateAsyncTask(task); err != nil {
 		log.Printf("更新失败任务状态失败: %v", err)
 	}
 
@@ -272,13 +384,13 @@ func (s *AsyncTaskService) markTaskFailed(task *model.AsyncTask, errorMsg string
 }
 
 // markTaskCompleted 标记任务完成
-func (s *AsyncTaskService) markTaskCompleted(task *model.AsyncTask) {
+func (s *AsyncTaskService) markTaskCompleted(ctx context.Context, task *model.AsyncTask) {
 	task.Status = model.TaskStatusCompleted
 	task.Progress = 100
 	now := time.Now()
 	task.CompletedAt = &now
 
-	if err := s.repo.UpdateAsyncTask(task); err != nil {
+	if err := s.repo.WithContext(ctx).UpdateAsyncTask(task); err != nil {
 		log.Printf("更新完成任务状态失败: %v", err)
 	}
 
@@ -450,7 +562,7 @@ func (e *StageDocumentExecutor) Execute(ctx context.Context, task *model.AsyncTa
 
 	// 更新进度到50%
 	task.Progress = 50
-	if err := e.service.repo.UpdateAsyncTask(task); err != nil {
+	if err := e.service.repo.WithContext(ctx).UpdateAsyncTask(task); err != nil {
 		log.Printf("更新任务进度失败: %v", err)
 	}
 
@@ -464,7 +576,7 @@ func (e *StageDocumentExecutor) Execute(ctx context.Context, task *model.AsyncTa
 	for _, doc := range result.Documents {
 		doc.Stage = int(stage)
 		doc.TaskID = &task.TaskID
-		if err := e.service.repo.UpdateDocument(doc); err != nil {
+		if err := e.service.repo.WithContext(ctx).UpdateDocument(doc); err != nil {
 			log.Printf("更新文档阶段信息失败: %v", err)
 		}
 	}
@@ -472,7 +584,7 @@ func (e *StageDocumentExecutor) Execute(ctx context.Context, task *model.AsyncTa
 	for _, diagram := range result.PUMLDiagrams {
 		diagram.Stage = int(stage)
 		diagram.TaskID = &task.TaskID
-		if err := e.service.repo.UpdatePUMLDiagram(diagram); err != nil {
+		if err := e.service.repo.WithContext(ctx).UpdatePUMLDiagram(diagram); err != nil {
 			log.Printf("更新PUML图表阶段信息失败: %v", err)
 		}
 	}
@@ -536,7 +648,7 @@ func (e *CompleteProjectDocumentsExecutor) Execute(ctx context.Context, task *mo
 	
 	// 第一阶段：项目需求文档 + 系统架构图 + 交互流程图 + 业务流程图 (4份)
 	task.Progress = 10
-	if err := e.service.repo.UpdateAsyncTask(task); err != nil {
+	if err := e.service.repo.WithContext(ctx).UpdateAsyncTask(task); err != nil {
 		log.Printf("更新任务进度失败: %v", err)
 	}
 	
@@ -546,12 +658,15 @@ func (e *CompleteProjectDocumentsExecutor) Execute(ctx context.Context, task *mo
 	}
 	
 	log.Printf("生成第一阶段文档...")
-	stage1Result, err := e.aiService.GenerateSpecificStageDocuments(ctx, stage1Req, task.UserID, []string{
+	stage1Ctx, stage1Span := startStageSpan(ctx, 1)
+	stage1Result, err := e.aiService.GenerateSpecificStageDocuments(stage1Ctx, stage1Req, task.UserID, []string{
 		"项目需求文档.md",
 		"系统架构图.puml", 
 		"交互流程图.puml",
 		"业务流程图.puml",
 	})
+	stage1Span.RecordError(err)
+	stage1Span.End()
 	if err != nil {
 		return fmt.Errorf("生成第一阶段文档失败: %w", err)
 	}
@@ -560,7 +675,7 @@ func (e *CompleteProjectDocumentsExecutor) Execute(ctx context.Context, task *mo
 	for _, doc := range stage1Result.Documents {
 		doc.Stage = 1
 		doc.TaskID = &task.TaskID
-		if err := e.service.repo.UpdateDocument(doc); err != nil {
+		if err := e.service.repo.WithContext(ctx).UpdateDocument(doc); err != nil {
 			log.Printf("更新第一阶段文档信息失败: %v", err)
 		}
 	}
@@ -568,14 +683,14 @@ func (e *CompleteProjectDocumentsExecutor) Execute(ctx context.Context, task *mo
 	for _, diagram := range stage1Result.PUMLDiagrams {
 		diagram.Stage = 1
 		diagram.TaskID = &task.TaskID
-		if err := e.service.repo.UpdatePUMLDiagram(diagram); err != nil {
+		if err := e.service.repo.WithContext(ctx).UpdatePUMLDiagram(diagram); err != nil {
 			log.Printf("更新第一阶段PUML图表信息失败: %v", err)
 		}
 	}
 	
 	// 第二阶段：技术规范文档 + API设计 + 数据库设计 (3份)
 	task.Progress = 40
-	if err := e.service.repo.UpdateAsyncTask(task); err != nil {
+	if err := e.service.repo.WithContext(ctx).UpdateAsyncTask(task); err != nil {
 		log.Printf("更新任务进度失败: %v", err)
 	}
 	
@@ -585,11 +700,14 @@ func (e *CompleteProjectDocumentsExecutor) Execute(ctx context.Context, task *mo
 	}
 	
 	log.Printf("生成第二阶段文档...")
-	stage2Result, err := e.aiService.GenerateSpecificStageDocuments(ctx, stage2Req, task.UserID, []string{
+	stage2Ctx, stage2Span := startStageSpan(ctx, 2)
+	stage2Result, err := e.aiService.GenerateSpecificStageDocuments(stage2Ctx, stage2Req, task.UserID, []string{
 		"技术规范文档.md",
 		"API设计.md",
 		"数据库设计.md",
 	})
+	stage2Span.RecordError(err)
+	stage2Span.End()
 	if err != nil {
 		return fmt.Errorf("生成第二阶段文档失败: %w", err)
 	}
@@ -598,14 +716,14 @@ func (e *CompleteProjectDocumentsExecutor) Execute(ctx context.Context, task *mo
 	for _, doc := range stage2Result.Documents {
 		doc.Stage = 2
 		doc.TaskID = &task.TaskID
-		if err := e.service.repo.UpdateDocument(doc); err != nil {
+		if err := e.service.repo.WithContext(ctx).UpdateDocument(doc); err != nil {
 			log.Printf("更新第二阶段文档信息失败: %v", err)
 		}
 	}
 	
 	// 第三阶段：开发流程文档 + 测试用例文档 + 部署文档 (3份)
 	task.Progress = 70
-	if err := e.service.repo.UpdateAsyncTask(task); err != nil {
+	if err := e.service.repo.WithContext(ctx).UpdateAsyncTask(task); err != nil {
 		log.Printf("更新任务进度失败: %v", err)
 	}
 	
@@ -615,11 +733,14 @@ func (e *CompleteProjectDocumentsExecutor) Execute(ctx context.Context, task *mo
 	}
 	
 	log.Printf("生成第三阶段文档...")
-	stage3Result, err := e.aiService.GenerateSpecificStageDocuments(ctx, stage3Req, task.UserID, []string{
+	stage3Ctx, stage3Span := startStageSpan(ctx, 3)
+	stage3Result, err := e.aiService.GenerateSpecificStageDocuments(stage3Ctx, stage3Req, task.UserID, []string{
 		"开发流程文档.md",
 		"测试用例文档.md",
 		"部署文档.md",
 	})
+	stage3Span.RecordError(err)
+	stage3Span.End()
 	if err != nil {
 		return fmt.Errorf("生成第三阶段文档失败: %w", err)
 	}
@@ -628,7 +749,7 @@ func (e *CompleteProjectDocumentsExecutor) Execute(ctx context.Context, task *mo
 	for _, doc := range stage3Result.Documents {
 		doc.Stage = 3
 		doc.TaskID = &task.TaskID
-		if err := e.service.repo.UpdateDocument(doc); err != nil {
+		if err := e.service.repo.WithContext(ctx).UpdateDocument(doc); err != nil {
 			log.Printf("更新第三阶段文档信息失败: %v", err)
 		}
 	}
@@ -657,4 +778,152 @@ func (e *CompleteProjectDocumentsExecutor) Execute(ctx context.Context, task *mo
 		task.TaskID, totalResult.TotalDocuments, totalResult.TotalPUMLDiagrams)
 	
 	return nil
-} 
\ No newline at end of file
+} 
+
+// ArtifactRegenerationExecutor 过期产物重新生成执行器
+type ArtifactRegenerationExecutor struct {
+	service   *AsyncTaskService
+	aiService *AIService
+}
+
+// ArtifactRegenerationItem 单个产物的重新生成结果
+type ArtifactRegenerationItem struct {
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+	NewID string `json:"new_id,omitempty"` // 设计文档和任务列表重新生成为新文档
+	Error string `json:"error,omitempty"`
+}
+
+// ArtifactRegenerationResult 过期产物重新生成任务的结果
+type ArtifactRegenerationResult struct {
+	Succeeded int                         `json:"succeeded"`
+	Failed    int                         `json:"failed"`
+	Items     []*ArtifactRegenerationItem `json:"items"`
+}
+
+func (e *ArtifactRegenerationExecutor) Execute(ctx context.Context, task *model.AsyncTask) error {
+	var metadata struct {
+		Artifacts []model.ArtifactRef `json:"artifacts"`
+	}
+	if err := json.Unmarshal([]byte(task.Metadata), &metadata); err != nil {
+		return fmt.Errorf("解析任务元数据失败: %w", err)
+	}
+
+	artifacts, err := e.regenerationOrder(ctx, task.ProjectID, metadata.Artifacts)
+	if err != nil {
+		return err
+	}
+	if len(artifacts) == 0 {
+		return fmt.Errorf("任务元数据中没有需要重新生成的产物")
+	}
+
+	result := &ArtifactRegenerationResult{Items: []*ArtifactRegenerationItem{}}
+	for i, artifact := range artifacts {
+		item := &ArtifactRegenerationItem{Type: artifact.Type, ID: artifact.ID}
+		if newID, err := e.regenerate(ctx, task, artifact); err != nil {
+			log.Printf("重新生成%s %s失败: %v", impact.Label(artifact.Type), artifact.ID, err)
+			item.Error = err.Error()
+			result.Failed++
+		} else {
+			item.NewID = newID
+			result.Succeeded++
+		}
+		result.Items = append(result.Items, item)
+
+		task.Progress = (i + 1) * 100 / len(artifacts)
+		if err := e.service.repo.WithContext(ctx).UpdateAsyncTask(task); err != nil {
+			log.Printf("更新任务进度失败: %v", err)
+		}
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("序列化结果失败: %w", err)
+	}
+	task.ResultData = string(resultJSON)
+
+	if result.Succeeded == 0 {
+		return fmt.Errorf("全部%d个产物重新生成失败: %s", result.Failed, result.Items[0].Error)
+	}
+	return nil
+}
+
+// regenerationOrder 去重并按依赖层级从上游到下游排序；测试用例随所属任务列表一起重新生成
+func (e *ArtifactRegenerationExecutor) regenerationOrder(ctx context.Context, projectID uuid.UUID, artifacts []model.ArtifactRef) ([]model.ArtifactRef, error) {
+	taskLists := make(map[string]string)
+	for _, artifact := range artifacts {
+		if artifact.Type == model.ArtifactTypeTestCase && e.service.specService != nil {
+			testCases, err := e.service.specService.listTestCases(ctx, projectID)
+			if err != nil {
+				return nil, fmt.Errorf("查询测试用例失败: %w", err)
+			}
+			for _, testCase := range testCases {
+				taskLists[testCase.ID.String()] = testCase.TaskListID.String()
+			}
+			break
+		}
+	}
+
+	seen := make(map[string]bool)
+	var ordered []model.ArtifactRef
+	for _, artifact := range artifacts {
+		if artifact.Type == model.ArtifactTypeTestCase {
+			if taskListID, ok := taskLists[artifact.ID]; ok {
+				artifact = model.ArtifactRef{Type: model.ArtifactTypeTaskListDoc, ID: taskListID}
+			}
+		}
+		key := impact.Key(artifact.Type, artifact.ID)
+		if !seen[key] {
+			seen[key] = true
+			ordered = append(ordered, artifact)
+		}
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return impact.Layer(ordered[i].Type) < impact.Layer(ordered[j].Type)
+	})
+	return ordered, nil
+}
+
+// regenerate 重新生成单个产物，设计文档和任务列表返回新文档ID
+func (e *ArtifactRegenerationExecutor) regenerate(ctx context.Context, task *model.AsyncTask, artifact model.ArtifactRef) (string, error) {
+	id, err := uuid.Parse(artifact.ID)
+	if err != nil {
+		return "", fmt.Errorf("无效的产物ID: %s", artifact.ID)
+	}
+
+	switch artifact.Type {
+	case model.ArtifactTypePUMLDiagram:
+		_, err = e.aiService.RegenerateDiagram(ctx, id, task.UserID)
+		return "", err
+	case model.ArtifactTypeDocument:
+		_, err = e.aiService.RegenerateDocument(ctx, id, task.UserID)
+		return "", err
+	case model.ArtifactTypeDesignDoc:
+		if e.service.specService == nil {
+			return "", fmt.Errorf("Spec服务未启用，无法重新生成设计文档")
+		}
+		designDoc, err := e.service.specService.RegenerateDesign(ctx, task.ProjectID, task.UserID, id)
+		if err != nil {
+			return "", err
+		}
+		return designDoc.ID.String(), nil
+	case model.ArtifactTypeTaskListDoc:
+		if e.service.specService == nil {
+			return "", fmt.Errorf("Spec服务未启用，无法重新生成任务列表")
+		}
+		taskDoc, err := e.service.specService.RegenerateTaskList(ctx, task.ProjectID, task.UserID, id)
+		if err != nil {
+			return "", err
+		}
+		return taskDoc.ID.String(), nil
+	}
+	return "", fmt.Errorf("不支持重新生成的产物类型: %s", impact.Label(artifact.Type))
+}
+
+// startStageSpan 为完整项目文档生成中的单个阶段开始Span
+func startStageSpan(ctx context.Context, stage int) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(ctx, "task.stage")
+	span.SetAttribute("stage", stage)
+	return ctx, span
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"ai-dev-platform/internal/ai"
+	"ai-dev-platform/internal/model"
+	"ai-dev-platform/internal/plantuml"
+
+	"github.com/google/uuid"
+)
+
+// ===== 数据模型图与数据实体、数据库设计的双向同步 =====
+
+// DataModelSync 从数据模型图解析出的数据实体和数据库设计，已与项目现有内容合并
+type DataModelSync struct {
+	DataEntities   []ai.DataEntity    `json:"data_entities"`
+	DatabaseDesign *ai.DatabaseDesign `json:"database_design"`
+	RequirementID  *uuid.UUID         `json:"requirement_id,omitempty"` // 写回的需求分析
+	DocumentID     *uuid.UUID         `json:"document_id,omitempty"`    // 写回的开发文档
+	Applied        bool               `json:"applied"`
+}
+
+// dataModelPUML 数据模型图不调用AI，由项目最新开发文档中的数据库设计或需求分析中的数据实体确定性生成；
+// 不是数据模型图或两者都没有时返回 nil，由AI生成
+func (s *AIService) dataModelPUML(requirement *model.Requirement, diagramType string, style ai.DataModelStyle) (*ai.PUMLDiagram, error) {
+	if diagramType != string(ai.PUMLTypeDataModel) {
+		return nil, nil
+	}
+	analysis, err := requirementToAnalysis(requirement)
+	if err != nil {
+		return nil, err
+	}
+	var design *ai.DatabaseDesign
+	if _, development := s.latestDevelopmentDocument(requirement.ProjectID); development != nil {
+		design = &development.DatabaseDesign
+	}
+	diagram, err := ai.DataModelDiagram(analysis, design, ai.DataModelOptions{Style: style})
+	if errors.Is(err, ai.ErrNoDataModel) {
+		return nil, nil
+	}
+	return diagram, err
+}
+
+// saveDataModelDiagram 确定性生成数据模型图并保存为版本1；不适用时返回 nil，由调用方交给AI生成
+func (s *AIService) saveDataModelDiagram(req *model.GeneratePUMLRequest, authorID uuid.UUID) (*model.PUMLDiagram, error) {
+	if req.DiagramType != string(ai.PUMLTypeDataModel) {
+		return nil, nil
+	}
+	analysisID, err := uuid.Parse(req.AnalysisID)
+	if err != nil {
+		return nil, fmt.Errorf("无效的分析ID: %w", err)
+	}
+	// 有用户时先校验项目归属，避免越权读取其他项目的数据实体和数据库设计
+	var requirement *model.Requirement
+	if authorID != uuid.Nil {
+		requirement, err = s.requirementForUser(analysisID, authorID)
+	} else {
+		requirement, err = s.repo.GetRequirementAnalysis(analysisID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取需求分析失败: %w", err)
+	}
+	generated, err := s.dataModelPUML(requirement, req.DiagramType, ai.DataModelStyle(req.DataModelStyle))
+	if err != nil || generated == nil {
+		return nil, err
+	}
+	syntax, err := plantuml.ParseSyntax(req.Syntax)
+	if err != nil {
+		return nil, err
+	}
+	if err := convertGenerated(generated, syntax); err != nil {
+		return nil, err
+	}
+
+	diagram := &model.PUMLDiagram{
+		DiagramID:   uuid.New(),
+		ProjectID:   requirement.ProjectID,
+		DiagramType: req.DiagramType,
+		DiagramName: generated.Title,
+		PUMLContent: generated.Content,
+		Syntax:      string(syntax),
+		Stage:       1,
+	}
+	if err := s.repo.CreateVersionedPUMLDiagram(diagram, authorID, ""); err != nil {
+		return nil, fmt.Errorf("保存PUML图表失败: %w", err)
+	}
+	s.recordRequirementDerivation(model.ArtifactTypePUMLDiagram, diagram.DiagramID, requirement, true)
+	return diagram, nil
+}
+
+// convertGenerated 确定性生成的数据模型图是 PlantUML，图表语法为 Mermaid 时转换
+func convertGenerated(generated *ai.PUMLDiagram, syntax plantuml.Syntax) error {
+	conversion, err := plantuml.Convert(generated.Content, plantuml.SyntaxPlantUML, syntax)
+	if err != nil {
+		return fmt.Errorf("数据模型图转换为 %s 失败: %w", syntax, err)
+	}
+	generated.Content = conversion.Source
+	return nil
+}
+
+// plantUMLSource 图表的 PlantUML 源码，Mermaid 图表先转换为 PlantUML 再解析数据实体
+func plantUMLSource(diagram *model.PUMLDiagram) (string, error) {
+	conversion, err := plantuml.Convert(diagram.PUMLContent, DiagramSyntax(diagram), plantuml.SyntaxPlantUML)
+	if err != nil {
+		return "", err
+	}
+	return conversion.Source, nil
+}
+
+// latestDevelopmentDocument 项目最新一份JSON格式的开发文档，没有时返回 nil；
+// 导入的数据库结构文档不算开发文档，不会被数据模型图的同步覆盖
+func (s *AIService) latestDevelopmentDocument(projectID uuid.UUID) (*model.Document, *ai.DevelopmentDocument) {
+	documents, err := s.repo.GetDocumentsByProjectID(projectID)
+	if err != nil {
+		return nil, nil
+	}
+	for _, document := range documents {
+		if document.Format != "json" || !isDevelopmentDocument(document) {
+			continue
+		}
+		var development ai.DevelopmentDocument
+		if err := json.Unmarshal([]byte(document.Content), &development); err != nil {
+			continue
+		}
+		return document, &development
+	}
+	return nil, nil
+}
+
+// isDevelopmentDocument 文档是否为AI生成的开发文档
+func isDevelopmentDocument(document *model.Document) bool {
+	return document.DocumentType == "development" || document.DocumentType == "development_plan"
+}
+
+// SyncDataModel 把编辑后的数据模型图解析为数据实体和数据库设计，并与来源需求分析和最新开发文档合并；
+// apply 时写回：需求分析保存为新版本，开发文档只替换数据库设计部分
+func (s *AIService) SyncDataModel(userID, diagramID uuid.UUID, req *model.SyncDataModelRequest) (*DataModelSync, error) {
+	diagram, err := s.repo.GetPUMLDiagram(diagramID)
+	if err != nil {
+		return nil, fmt.Errorf("获取PUML图表失败: %w", err)
+	}
+	if _, err := s.projectForUser(diagram.ProjectID, userID); err != nil {
+		return nil, err
+	}
+
+	source, err := plantUMLSource(diagram)
+	if err != nil {
+		return nil, err
+	}
+	entities, err := ai.ParseDataEntities(source)
+	if err != nil {
+		return nil, err
+	}
+	design, err := ai.ParseDatabaseDesign(source)
+	if err != nil {
+		return nil, err
+	}
+	result := &DataModelSync{DataEntities: entities, DatabaseDesign: design}
+
+	ref := model.ArtifactRef{Type: model.ArtifactTypePUMLDiagram, ID: diagramID.String()}
+	requirement, _ := s.sourceRequirement(diagram.ProjectID, ref)
+	var analysis *ai.RequirementAnalysis
+	if requirement != nil {
+		if analysis, err = requirementToAnalysis(requirement); err != nil {
+			return nil, err
+		}
+		result.DataEntities = ai.MergeDataEntities(analysis.DataEntities, entities)
+		result.RequirementID = &requirement.RequirementID
+	}
+	document, development := s.latestDevelopmentDocument(diagram.ProjectID)
+	if development != nil {
+		result.DatabaseDesign = ai.MergeDatabaseDesign(&development.DatabaseDesign, design)
+		result.DocumentID = &document.DocumentID
+	}
+	if !req.Apply {
+		return result, nil
+	}
+	if requirement == nil && document == nil {
+		return nil, fmt.Errorf("图表没有来源需求分析，项目也没有开发文档，无法写回")
+	}
+
+	if requirement != nil {
+		analysis.DataEntities = result.DataEntities
+		if err := applyAnalysis(requirement, analysis); err != nil {
+			return nil, err
+		}
+		if err := s.repo.UpdateRequirementAnalysis(requirement); err != nil {
+			return nil, fmt.Errorf("保存需求分析失败: %w", err)
+		}
+		s.notifyRequirementChanged(requirement)
+		// 图表本身就是这次修改的来源，依赖新版本而不是被标记为过期
+		s.recordRequirementDerivation(ref.Type, diagramID, requirement, true)
+	}
+	if document != nil {
+		if err := s.replaceDatabaseDesign(document, result.DatabaseDesign); err != nil {
+			return nil, err
+		}
+		notifyUpstreamChanged(s.repo, document.ProjectID, model.ArtifactRef{Type: model.ArtifactTypeDocument, ID: document.DocumentID.String()},
+			fmt.Sprintf("文档《%s》的数据库设计已从数据模型图同步", document.DocumentName))
+	}
+	result.Applied = true
+	return result, nil
+}
+
+// replaceDatabaseDesign 只替换开发文档中的数据库设计，其余字段原样保留
+func (s *AIService) replaceDatabaseDesign(document *model.Document, design *ai.DatabaseDesign) error {
+	var content map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(document.Content), &content); err != nil {
+		return fmt.Errorf("解析开发文档失败: %w", err)
+	}
+	raw, err := json.Marshal(design)
+	if err != nil {
+		return fmt.Errorf("序列化数据库设计失败: %w", err)
+	}
+	content["database_design"] = raw
+	updated, err := json.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("序列化开发文档失败: %w", err)
+	}
+
+	document.Content = string(updated)
+	document.Version++
+	if err := s.repo.UpdateDocument(document); err != nil {
+		return fmt.Errorf("保存开发文档失败: %w", err)
+	}
+	return nil
+}
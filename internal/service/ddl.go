@@ -0,0 +1,121 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"ai-dev-platform/internal/ai"
+	"ai-dev-platform/internal/ddl"
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// ===== 由开发文档中的数据库设计生成SQL =====
+
+// DDLMigration 两份开发文档之间的数据库迁移脚本
+type DDLMigration struct {
+	*ddl.Migration
+	FromDocumentID uuid.UUID `json:"from_document_id"`
+	ToDocumentID   uuid.UUID `json:"to_document_id"`
+}
+
+// GenerateDDL 由开发文档中的数据库设计生成建表、索引和外键语句，并附带检查结果
+func (s *AIService) GenerateDDL(userID, documentID uuid.UUID, dialect string) (*ddl.Script, error) {
+	d, err := ddl.ParseDialect(dialect)
+	if err != nil {
+		return nil, err
+	}
+	_, design, err := s.documentDatabaseDesign(userID, documentID)
+	if err != nil {
+		return nil, err
+	}
+	return ddl.Generate(design, d)
+}
+
+// MigrateDDL 生成从旧版本数据库设计迁移到该开发文档的脚本；未指定旧版本时使用同一项目中上一份开发文档
+func (s *AIService) MigrateDDL(userID, documentID uuid.UUID, req *model.DDLMigrationRequest) (*DDLMigration, error) {
+	d, err := ddl.ParseDialect(req.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	document, design, err := s.documentDatabaseDesign(userID, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var from *model.Document
+	var fromDesign *ai.DatabaseDesign
+	if req.FromDocumentID != "" {
+		fromID, err := uuid.Parse(req.FromDocumentID)
+		if err != nil {
+			return nil, fmt.Errorf("无效的文档ID: %w", err)
+		}
+		if from, fromDesign, err = s.documentDatabaseDesign(userID, fromID); err != nil {
+			return nil, err
+		}
+		if from.ProjectID != document.ProjectID {
+			return nil, fmt.Errorf("两份文档不属于同一项目")
+		}
+	} else if from, fromDesign, err = s.previousDatabaseDesign(document); err != nil {
+		return nil, err
+	}
+
+	migration, err := ddl.Migrate(fromDesign, design, d)
+	if err != nil {
+		return nil, err
+	}
+	return &DDLMigration{Migration: migration, FromDocumentID: from.DocumentID, ToDocumentID: document.DocumentID}, nil
+}
+
+// documentDatabaseDesign 读取开发文档中的数据库设计，并校验用户对所属项目的访问权限
+func (s *AIService) documentDatabaseDesign(userID, documentID uuid.UUID) (*model.Document, *ai.DatabaseDesign, error) {
+	document, err := s.repo.GetDocument(documentID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取文档失败: %w", err)
+	}
+	if _, err := s.projectForUser(document.ProjectID, userID); err != nil {
+		return nil, nil, err
+	}
+	design := databaseDesignOf(document)
+	if design == nil {
+		return nil, nil, fmt.Errorf("文档《%s》中没有数据库设计", document.DocumentName)
+	}
+	return document, design, nil
+}
+
+// previousDatabaseDesign 同一项目中比该文档更早生成、且包含数据库设计的开发文档；
+// 导入的数据库结构文档只在通过 from_document_id 指定时参与比较
+func (s *AIService) previousDatabaseDesign(document *model.Document) (*model.Document, *ai.DatabaseDesign, error) {
+	documents, err := s.repo.GetDocumentsByProjectID(document.ProjectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取项目文档失败: %w", err)
+	}
+	// 文档按生成时间倒序排列
+	seen := false
+	for _, candidate := range documents {
+		if candidate.DocumentID == document.DocumentID {
+			seen = true
+			continue
+		}
+		if !seen || candidate.DocumentType == schemaDocumentType {
+			continue
+		}
+		if design := databaseDesignOf(candidate); design != nil {
+			return candidate, design, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("项目中没有更早的包含数据库设计的开发文档，请指定要比较的文档")
+}
+
+// databaseDesignOf 开发文档中的数据库设计，文档不是JSON格式的开发文档或没有表时返回 nil
+func databaseDesignOf(document *model.Document) *ai.DatabaseDesign {
+	if document.Format != "json" {
+		return nil
+	}
+	var development ai.DevelopmentDocument
+	if err := json.Unmarshal([]byte(document.Content), &development); err != nil || len(development.DatabaseDesign.Tables) == 0 {
+		return nil
+	}
+	return &development.DatabaseDesign
+}
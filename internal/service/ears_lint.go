@@ -0,0 +1,58 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"ai-dev-platform/internal/ears"
+	"ai-dev-platform/internal/model"
+	"ai-dev-platform/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// EARSLintResult 已保存的EARS检查报告及其完整检查结果
+type EARSLintResult struct {
+	*model.EARSLintReport
+	Report *ears.Report `json:"report"`
+}
+
+// saveEARSLintReport 检查需求语句并保存报告
+func saveEARSLintReport(repo repository.Repository, projectID uuid.UUID, sourceType string, sourceID uuid.UUID, sentences []string) (*EARSLintResult, error) {
+	report := ears.Lint(sentences)
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("序列化EARS检查结果失败: %w", err)
+	}
+
+	record := &model.EARSLintReport{
+		ReportID:     uuid.New(),
+		ProjectID:    projectID,
+		SourceType:   sourceType,
+		SourceID:     sourceID,
+		Total:        report.Summary.Total,
+		Compliant:    report.Summary.Compliant,
+		ErrorCount:   report.Summary.Errors,
+		WarningCount: report.Summary.Warnings,
+		ReportData:   string(data),
+		CreatedAt:    time.Now(),
+	}
+	if err := repo.CreateEARSLintReport(record); err != nil {
+		return nil, err
+	}
+
+	return &EARSLintResult{EARSLintReport: record, Report: report}, nil
+}
+
+// loadEARSLintReport 还原已保存报告中的完整检查结果
+func loadEARSLintReport(record *model.EARSLintReport) (*EARSLintResult, error) {
+	report := &ears.Report{}
+	if record.ReportData != "" {
+		if err := json.Unmarshal([]byte(record.ReportData), report); err != nil {
+			return nil, fmt.Errorf("解析EARS检查结果失败: %w", err)
+		}
+	}
+	return &EARSLintResult{EARSLintReport: record, Report: report}, nil
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"ai-dev-platform/internal/model"
+	"ai-dev-platform/internal/plantuml"
+
+	"github.com/google/uuid"
+)
+
+// PUML导出格式
+const (
+	ExportFormatZip      = "zip"      // 每个图表的 .puml 源码及渲染出的 SVG、PNG，Mermaid 图表只有 .mmd 源码
+	ExportFormatMarkdown = "markdown" // 单个 Markdown 文件，图表以 data URI 内嵌，Mermaid 图表以 mermaid 代码块嵌入
+	ExportFormatHTML     = "html"     // 单个 HTML 页面，图表以内联 SVG 嵌入，Mermaid 图表由页面加载的 Mermaid 脚本渲染
+	ExportFormatMermaid  = "mermaid"  // Mermaid 源码，多个图表时打包为 zip
+	ExportFormatDrawio   = "drawio"   // draw.io 文件，每个图表一页
+)
+
+// maxExportDiagrams 单次导出的图表数上限
+const maxExportDiagrams = 50
+
+// PUMLExport 准备好的导出文件：图表已完成权限校验和格式转换，渲染在写出时进行
+type PUMLExport struct {
+	Filename    string
+	ContentType string
+	write       func(ctx context.Context, w io.Writer) error
+}
+
+// Write 把导出文件写到 w，渲染失败的图表以错误说明代替，不中断整个导出
+func (e *PUMLExport) Write(ctx context.Context, w io.Writer) error {
+	return e.write(ctx, w)
+}
+
+// ExportPUML 导出PUML图表：逐个校验图表所属项目属于该用户，按格式准备下载文件
+func (s *PUMLService) ExportPUML(ctx context.Context, userID uuid.UUID, req *model.ExportPUMLRequest) (*PUMLExport, error) {
+	format := strings.ToLower(strings.TrimSpace(req.Format))
+	switch format {
+	case "md":
+		format = ExportFormatMarkdown
+	case ExportFormatZip, ExportFormatMarkdown, ExportFormatHTML, ExportFormatMermaid, ExportFormatDrawio:
+	default:
+		return nil, fmt.Errorf("不支持的导出格式: %s，可选 zip、markdown、html、mermaid、drawio", req.Format)
+	}
+
+	diagrams, err := s.exportDiagrams(userID, req.PUMLIDs)
+	if err != nil {
+		return nil, err
+	}
+	names := exportFileNames(diagrams)
+	base := names[0]
+	if len(diagrams) > 1 {
+		base = "puml-export-" + time.Now().Format("20060102-150405")
+	}
+
+	export := &PUMLExport{}
+	switch format {
+	case ExportFormatZip:
+		export.Filename, export.ContentType = base+".zip", "application/zip"
+		export.write = func(ctx context.Context, w io.Writer) error {
+			return s.writeZip(ctx, w, diagrams, names)
+		}
+	case ExportFormatMarkdown:
+		export.Filename, export.ContentType = base+".md", "text/markdown; charset=utf-8"
+		export.write = func(ctx context.Context, w io.Writer) error {
+			return s.writeMarkdown(ctx, w, diagrams)
+		}
+	case ExportFormatHTML:
+		export.Filename, export.ContentType = base+".html", "text/html; charset=utf-8"
+		export.write = func(ctx context.Context, w io.Writer) error {
+			return s.writeHTML(ctx, w, diagrams)
+		}
+	case ExportFormatMermaid:
+		sources := make([]string, len(diagrams))
+		for i, d := range diagrams {
+			if DiagramSyntax(d) == plantuml.SyntaxMermaid {
+				sources[i] = d.PUMLContent
+				continue
+			}
+			conversion, err := plantuml.ToMermaid(plantuml.Parse(d.PUMLContent))
+			if err != nil {
+				return nil, fmt.Errorf("图表 %s 无法导出为 Mermaid: %w", d.DiagramName, err)
+			}
+			sources[i] = mermaidWithNotes(conversion)
+		}
+		if len(diagrams) == 1 {
+			export.Filename, export.ContentType = base+".mmd", "text/plain; charset=utf-8"
+			export.write = func(ctx context.Context, w io.Writer) error {
+				_, err := io.WriteString(w, sources[0])
+				return err
+			}
+			break
+		}
+		export.Filename, export.ContentType = base+".zip", "application/zip"
+		export.write = func(ctx context.Context, w io.Writer) error {
+			zw := zip.NewWriter(w)
+			for i, source := range sources {
+				if err := writeZipFile(zw, names[i]+".mmd", []byte(source)); err != nil {
+					return err
+				}
+			}
+			return zw.Close()
+		}
+	case ExportFormatDrawio:
+		pages := make([]string, len(diagrams))
+		for i, d := range diagrams {
+			conversion, err := plantuml.ToDrawio(plantuml.ParseAs(d.PUMLContent, DiagramSyntax(d)), d.DiagramName)
+			if err != nil {
+				return nil, fmt.Errorf("图表 %s 无法导出为 draw.io: %w", d.DiagramName, err)
+			}
+			pages[i] = conversion.Source
+			if len(conversion.Dropped) > 0 {
+				pages[i] = fmt.Sprintf("  <!-- 未转换: %s -->\n", strings.ReplaceAll(strings.Join(conversion.Dropped, "; "), "--", "- -")) + pages[i]
+			}
+		}
+		export.Filename, export.ContentType = base+".drawio", "application/vnd.jgraph.mxfile"
+		export.write = func(ctx context.Context, w io.Writer) error {
+			_, err := io.WriteString(w, plantuml.DrawioFile(pages...))
+			return err
+		}
+	}
+	return export, nil
+}
+
+// exportDiagrams 按请求顺序获取图表，去掉重复ID，任何一个图表无权访问时整个导出失败
+func (s *PUMLService) exportDiagrams(userID uuid.UUID, ids []string) ([]*model.PUMLDiagram, error) {
+	seen := make(map[string]bool)
+	var diagrams []*model.PUMLDiagram
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if len(seen) > maxExportDiagrams {
+			return nil, fmt.Errorf("单次最多导出 %d 个图表", maxExportDiagrams)
+		}
+		diagram, err := s.diagramForUser(userID, id)
+		if err != nil {
+			return nil, fmt.Errorf("图表 %s: %w", id, err)
+		}
+		diagrams = append(diagrams, diagram)
+	}
+	if len(diagrams) == 0 {
+		return nil, fmt.Errorf("请选择要导出的图表")
+	}
+	return diagrams, nil
+}
+
+// writeZip 每个图表写入 .puml 源码和渲染出的 .svg、.png；渲染失败时写入 .error.txt。
+// Mermaid 图表不在服务端渲染，只写入 .mmd 源码
+func (s *PUMLService) writeZip(ctx context.Context, w io.Writer, diagrams []*model.PUMLDiagram, names []string) error {
+	zw := zip.NewWriter(w)
+	for i, d := range diagrams {
+		name := names[i]
+		if DiagramSyntax(d) == plantuml.SyntaxMermaid {
+			if err := writeZipFile(zw, name+".mmd", []byte(d.PUMLContent)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := writeZipFile(zw, name+".puml", []byte(d.PUMLContent)); err != nil {
+			return err
+		}
+		var failures []string
+		for _, format := range []string{plantuml.FormatSVG, plantuml.FormatPNG} {
+			result, err := s.RenderPUML(ctx, d.PUMLContent, &RenderOptions{Format: format, UseCache: true, ServerMode: true})
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", format, err))
+				continue
+			}
+			if err := writeZipFile(zw, name+"."+format, result.ImageData); err != nil {
+				return err
+			}
+		}
+		if len(failures) > 0 {
+			if err := writeZipFile(zw, name+".error.txt", []byte(strings.Join(failures, "\n")+"\n")); err != nil {
+				return err
+			}
+		}
+	}
+	return zw.Close()
+}
+
+// writeMarkdown 每个图表一节，渲染出的 SVG 以 data URI 内嵌，源码放在折叠块中
+func (s *PUMLService) writeMarkdown(ctx context.Context, w io.Writer, diagrams []*model.PUMLDiagram) error {
+	if _, err := io.WriteString(w, "# PlantUML 图表导出\n\n"); err != nil {
+		return err
+	}
+	for _, d := range diagrams {
+		var b strings.Builder
+		fmt.Fprintf(&b, "## %s\n\n", d.DiagramName)
+		// GitHub 和大多数 wiki 直接渲染 mermaid 代码块
+		if DiagramSyntax(d) == plantuml.SyntaxMermaid {
+			fmt.Fprintf(&b, "```mermaid\n%s\n```\n\n", strings.TrimRight(d.PUMLContent, "\n"))
+			if _, err := io.WriteString(w, b.String()); err != nil {
+				return err
+			}
+			continue
+		}
+		if result, err := s.RenderPUML(ctx, d.PUMLContent, &RenderOptions{Format: plantuml.FormatSVG, UseCache: true, ServerMode: true}); err != nil {
+			fmt.Fprintf(&b, "> 渲染失败: %v\n\n", err)
+		} else {
+			fmt.Fprintf(&b, "![%s](data:image/svg+xml;base64,%s)\n\n", strings.NewReplacer("[", "\\[", "]", "\\]").Replace(d.DiagramName), base64.StdEncoding.EncodeToString(result.ImageData))
+		}
+		fmt.Fprintf(&b, "<details>\n<summary>PlantUML 源码</summary>\n\n```plantuml\n%s\n```\n\n</details>\n\n", strings.TrimRight(d.PUMLContent, "\n"))
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeHTML 单个HTML页面，渲染出的 SVG 直接内联
+func (s *PUMLService) writeHTML(ctx context.Context, w io.Writer, diagrams []*model.PUMLDiagram) error {
+	head := "<!DOCTYPE html>\n<html lang=\"zh-CN\">\n<head>\n<meta charset=\"utf-8\">\n<title>PlantUML 图表导出</title>\n" +
+		"<style>body{font-family:sans-serif;margin:2em;}section{margin-bottom:3em;}svg{max-width:100%;height:auto;}pre{background:#f6f8fa;padding:1em;overflow:auto;}</style>\n" +
+		"</head>\n<body>\n<h1>PlantUML 图表导出</h1>\n"
+	if _, err := io.WriteString(w, head); err != nil {
+		return err
+	}
+	for _, d := range diagrams {
+		var b strings.Builder
+		fmt.Fprintf(&b, "<section>\n<h2>%s</h2>\n", html.EscapeString(d.DiagramName))
+		if DiagramSyntax(d) == plantuml.SyntaxMermaid {
+			fmt.Fprintf(&b, "<pre class=\"mermaid\">%s</pre>\n<details>\n<summary>Mermaid 源码</summary>\n<pre><code>%s</code></pre>\n</details>\n</section>\n", html.EscapeString(d.PUMLContent), html.EscapeString(d.PUMLContent))
+			if _, err := io.WriteString(w, b.String()); err != nil {
+				return err
+			}
+			continue
+		}
+		if result, err := s.RenderPUML(ctx, d.PUMLContent, &RenderOptions{Format: plantuml.FormatSVG, UseCache: true, ServerMode: true}); err != nil {
+			fmt.Fprintf(&b, "<p>渲染失败: %s</p>\n", html.EscapeString(err.Error()))
+		} else {
+			b.WriteString(inlineSVG(result.ImageData))
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "<details>\n<summary>PlantUML 源码</summary>\n<pre><code>%s</code></pre>\n</details>\n</section>\n", html.EscapeString(d.PUMLContent))
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	tail := "</body>\n</html>\n"
+	for _, d := range diagrams {
+		if DiagramSyntax(d) == plantuml.SyntaxMermaid {
+			tail = mermaidScript + tail
+			break
+		}
+	}
+	_, err := io.WriteString(w, tail)
+	return err
+}
+
+// mermaidScript 导出的页面含 Mermaid 图表时加载 Mermaid 脚本，在浏览器中渲染 class="mermaid" 的元素
+const mermaidScript = "<script type=\"module\">\nimport mermaid from \"https://cdn.jsdelivr.net/npm/mermaid@10/dist/mermaid.esm.min.mjs\";\nmermaid.initialize({ startOnLoad: true });\n</script>\n"
+
+// inlineSVG 去掉 XML 声明和 DOCTYPE，得到可以直接嵌入 HTML 的 <svg> 元素
+func inlineSVG(data []byte) string {
+	svg := string(data)
+	if i := strings.Index(svg, "<svg"); i > 0 {
+		svg = svg[i:]
+	}
+	return svg
+}
+
+// mermaidWithNotes 在 Mermaid 源码末尾以注释列出未能转换的结构
+func mermaidWithNotes(c *plantuml.Conversion) string {
+	if len(c.Dropped) == 0 {
+		return c.Source
+	}
+	var b strings.Builder
+	b.WriteString(c.Source)
+	for _, what := range c.Dropped {
+		fmt.Fprintf(&b, "%%%% 未转换: %s\n", what)
+	}
+	return b.String()
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return fmt.Errorf("写入导出文件 %s 失败: %w", name, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("写入导出文件 %s 失败: %w", name, err)
+	}
+	return nil
+}
+
+// exportFileNames 由图表名称生成文件名（不含扩展名），去掉路径分隔符等字符，重名时追加序号
+func exportFileNames(diagrams []*model.PUMLDiagram) []string {
+	replacer := strings.NewReplacer("/", "_", "\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_", "\n", "_", "\r", "_", "\t", "_")
+	used := make(map[string]int)
+	names := make([]string, len(diagrams))
+	for i, d := range diagrams {
+		name := strings.Trim(strings.TrimSpace(replacer.Replace(d.DiagramName)), ".")
+		if name == "" {
+			name = d.DiagramID.String()
+		}
+		used[name]++
+		if used[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, used[name])
+		}
+		names[i] = name
+	}
+	return names
+}
@@ -3,15 +3,22 @@ package service
 import (
 	"bytes"
 	"compress/zlib"
+	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"strings"
 	"time"
 
 	"ai-dev-platform/internal/config"
+	"ai-dev-platform/internal/metrics"
 	"ai-dev-platform/internal/model"
+	"ai-dev-platform/internal/plantuml"
+	"ai-dev-platform/internal/repository"
+	"ai-dev-platform/internal/tracing"
 	"github.com/google/uuid"
 )
 
@@ -21,7 +28,11 @@ type PUMLService struct {
 	onlineRenderURL string
 	httpClient   *http.Client
 	enableCache  bool
-	cache        map[string]*RenderResult // 简单内存缓存
+	cache        *plantuml.Cache // 渲染缓存，并发安全
+	repo         repository.Repository
+	local        *plantuml.Renderer // 配置为本地渲染时使用，nil 表示使用服务器渲染
+	localErr     error              // 本地渲染初始化失败且不允许回退时的错误，此时所有渲染请求都会失败
+	fallback     bool               // 本地渲染不可用时改用服务器渲染
 }
 
 // RenderResult 渲染结果
@@ -31,6 +42,7 @@ type RenderResult struct {
 	URL         string    `json:"url,omitempty"`
 	RenderedAt  time.Time `json:"rendered_at"`
 	CacheKey    string    `json:"cache_key"`
+	Source      string    `json:"source,omitempty"` // Mermaid 图表不在服务端渲染，原样返回源码由前端渲染
 }
 
 // RenderOptions 渲染选项
@@ -44,38 +56,98 @@ type RenderOptions struct {
 
 // ValidationResult PUML语法验证结果
 type ValidationResult struct {
-	IsValid bool     `json:"is_valid"`
-	Errors  []string `json:"errors"`
-	Warnings []string `json:"warnings"`
+	IsValid     bool                  `json:"is_valid"`
+	Errors      []string              `json:"errors"`
+	Warnings    []string              `json:"warnings"`
+	DiagramType string                `json:"diagram_type"`
+	Syntax      plantuml.Syntax       `json:"syntax"`
+	Diagnostics []plantuml.Diagnostic `json:"diagnostics"`
 }
 
 // NewPUMLService 创建新的PUML服务
-func NewPUMLService(cfg *config.PUMLConfig) *PUMLService {
+func NewPUMLService(cfg *config.PUMLConfig, repo repository.Repository) *PUMLService {
 	pumlServerURL := cfg.ServerURL
 	if pumlServerURL == "" {
 		// 使用官方在线服务器
 		pumlServerURL = "http://www.plantuml.com/plantuml"
 	}
 
-	return &PUMLService{
+	service := &PUMLService{
 		serverURL:    pumlServerURL,
 		onlineRenderURL: fmt.Sprintf("%s/svg", pumlServerURL),
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		enableCache: true,
-		cache:      make(map[string]*RenderResult),
+		enableCache: cfg.CacheEnabled,
+		repo:       repo,
+		fallback:   cfg.FallbackToServer,
 	}
+
+	cacheConfig := plantuml.CacheConfig{
+		MaxEntries:   cfg.CacheMaxEntries,
+		MaxBytes:     int64(cfg.CacheMaxMemoryMB) << 20,
+		Dir:          cfg.CacheDir,
+		MaxDiskBytes: int64(cfg.CacheMaxDiskMB) << 20,
+	}
+	cache, err := plantuml.NewCache(cacheConfig)
+	if err != nil {
+		// 磁盘缓存不可用时只使用内存缓存
+		log.Printf("Warning: PUML磁盘缓存初始化失败，只使用内存缓存: %v", err)
+		cacheConfig.Dir = ""
+		cache, _ = plantuml.NewCache(cacheConfig)
+	}
+	service.cache = cache
+
+	if cfg.Renderer == "local" {
+		local, err := plantuml.NewRenderer(plantuml.Config{
+			Executable: cfg.Executable,
+			JarPath:    cfg.JarPath,
+			JavaPath:   cfg.JavaPath,
+			Workers:    cfg.Workers,
+			Timeout:    time.Duration(cfg.RenderTimeout) * time.Second,
+		})
+		switch {
+		case err == nil:
+			service.local = local
+		case cfg.FallbackToServer:
+			log.Printf("Warning: 本地PlantUML渲染初始化失败，使用服务器渲染: %v", err)
+		default:
+			// 不允许回退时渲染请求直接报错，避免图表被发送到外部服务器
+			log.Printf("Error: 本地PlantUML渲染初始化失败: %v", err)
+			service.localErr = err
+		}
+	}
+
+	return service
 }
 
-// RenderPUMLOnline 使用POST请求在线渲染PUML，返回SVG字符串
-func (s *PUMLService) RenderPUMLOnline(pumlCode string) (string, error) {
+// RenderPUMLOnline 渲染PUML为SVG字符串：配置了本地渲染时使用本地渲染，否则使用POST请求在线渲染
+func (s *PUMLService) RenderPUMLOnline(ctx context.Context, pumlCode string) (svg string, err error) {
+	if s.useLocal() {
+		result, err := s.renderLocally(ctx, pumlCode, &RenderOptions{Format: plantuml.FormatSVG})
+		if err == nil {
+			return string(result.ImageData), nil
+		}
+		if !s.fallbackToServer(err) {
+			return "", err
+		}
+	}
+
+	ctx, span := startRenderSpan(ctx, "online", "svg")
+	started := time.Now()
+	defer func() {
+		observeRender("online", "svg", started, err)
+		span.RecordError(err)
+		span.End()
+	}()
+
 	// 创建POST请求
-	req, err := http.NewRequest("POST", s.onlineRenderURL, strings.NewReader(pumlCode))
+	req, err := http.NewRequestWithContext(ctx, "POST", s.onlineRenderURL, strings.NewReader(pumlCode))
 	if err != nil {
 		return "", fmt.Errorf("创建PlantUML请求失败: %w", err)
 	}
 	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	tracing.Inject(ctx, req.Header)
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
@@ -99,7 +171,7 @@ func (s *PUMLService) RenderPUMLOnline(pumlCode string) (string, error) {
 
 
 // RenderPUML 渲染PUML代码为图像
-func (s *PUMLService) RenderPUML(pumlCode string, options *RenderOptions) (*RenderResult, error) {
+func (s *PUMLService) RenderPUML(ctx context.Context, pumlCode string, options *RenderOptions) (*RenderResult, error) {
 	if options == nil {
 		options = &RenderOptions{
 			Format:     "png",
@@ -113,20 +185,30 @@ func (s *PUMLService) RenderPUML(pumlCode string, options *RenderOptions) (*Rend
 	
 	// 检查缓存
 	if options.UseCache && s.enableCache {
-		if cached, exists := s.cache[cacheKey]; exists {
-			return cached, nil
+		cached, exists := s.cache.Get(cacheKey)
+		metrics.RecordCacheLookup("puml_render", exists)
+		if exists {
+			return &RenderResult{
+				ImageData:  cached.Data,
+				Format:     cached.Format,
+				RenderedAt: cached.RenderedAt,
+				CacheKey:   cacheKey,
+			}, nil
 		}
 	}
 
 	var result *RenderResult
 	var err error
 
-	if options.ServerMode {
-		// 使用在线服务器渲染
-		result, err = s.renderWithServer(pumlCode, options)
+	if s.useLocal() || !options.ServerMode {
+		// 使用本地渲染，不可用时按配置回退到服务器渲染
+		result, err = s.renderLocally(ctx, pumlCode, options)
+		if err != nil && s.fallbackToServer(err) {
+			result, err = s.renderServerTraced(ctx, pumlCode, options)
+		}
 	} else {
-		// 使用本地渲染（需要本地PlantUML环境）
-		result, err = s.renderLocally(pumlCode, options)
+		// 使用在线服务器渲染
+		result, err = s.renderServerTraced(ctx, pumlCode, options)
 	}
 
 	if err != nil {
@@ -138,81 +220,45 @@ func (s *PUMLService) RenderPUML(pumlCode string, options *RenderOptions) (*Rend
 
 	// 缓存结果
 	if options.UseCache && s.enableCache {
-		s.cache[cacheKey] = result
+		s.cache.Put(cacheKey, plantuml.CacheEntry{Data: result.ImageData, Format: result.Format, RenderedAt: result.RenderedAt})
+		metrics.CacheEntries.Set(float64(s.cache.Len()), "puml_render")
 	}
 
 	return result, nil
 }
 
-// ValidatePUML 验证PUML语法
+// ValidatePUML 验证PUML语法：解析图表并返回带行列位置的诊断，编辑器据此标注问题位置；
+// Errors 和 Warnings 为诊断的文本形式
 func (s *PUMLService) ValidatePUML(pumlCode string) *ValidationResult {
-	result := &ValidationResult{
-		IsValid:  true,
-		Errors:   []string{},
-		Warnings: []string{},
-	}
+	return s.ValidateDiagram(pumlCode, plantuml.SyntaxPlantUML)
+}
 
-	// 基本语法检查
-	lines := strings.Split(pumlCode, "\n")
-	
-	hasStart := false
-	hasEnd := false
-	bracketCount := 0
-	
-	for i, line := range lines {
-		line = strings.TrimSpace(line)
-		lineNum := i + 1
-		
-		// 检查开始标记
-		if strings.HasPrefix(line, "@startuml") {
-			if hasStart {
-				result.Errors = append(result.Errors, fmt.Sprintf("行 %d: 重复的 @startuml 标记", lineNum))
-				result.IsValid = false
-			}
-			hasStart = true
-		}
-		
-		// 检查结束标记
-		if strings.HasPrefix(line, "@enduml") {
-			if hasEnd {
-				result.Errors = append(result.Errors, fmt.Sprintf("行 %d: 重复的 @enduml 标记", lineNum))
-				result.IsValid = false
-			}
-			hasEnd = true
-		}
-		
-		// 检查括号匹配
-		bracketCount += strings.Count(line, "{") - strings.Count(line, "}")
-		
-		// 检查常见错误
-		if strings.Contains(line, "->") && !strings.Contains(line, ":") && 
-		   !strings.Contains(line, "[") && !strings.Contains(line, "participant") {
-			result.Warnings = append(result.Warnings, fmt.Sprintf("行 %d: 箭头可能缺少标签", lineNum))
+// ValidateDiagram 按指定语法验证图表源码，结果格式与 ValidatePUML 相同
+func (s *PUMLService) ValidateDiagram(code string, syntax plantuml.Syntax) *ValidationResult {
+	doc := plantuml.ParseAs(code, syntax)
+	result := &ValidationResult{
+		IsValid:     !doc.HasErrors(),
+		Errors:      []string{},
+		Warnings:    []string{},
+		DiagramType: string(doc.Type),
+		Syntax:      syntax,
+		Diagnostics: doc.Diagnostics,
+	}
+
+	for _, d := range doc.Diagnostics {
+		message := fmt.Sprintf("行 %d 列 %d: %s", d.Line, d.Column, d.Message)
+		if d.Severity == plantuml.SeverityError {
+			result.Errors = append(result.Errors, message)
+		} else {
+			result.Warnings = append(result.Warnings, message)
 		}
 	}
-	
-	// 检查必需的标记
-	if !hasStart {
-		result.Errors = append(result.Errors, "缺少 @startuml 开始标记")
-		result.IsValid = false
-	}
-	
-	if !hasEnd {
-		result.Errors = append(result.Errors, "缺少 @enduml 结束标记")
-		result.IsValid = false
-	}
-	
-	// 检查括号平衡
-	if bracketCount != 0 {
-		result.Errors = append(result.Errors, "括号不匹配")
-		result.IsValid = false
-	}
-	
+
 	return result
 }
 
 // renderWithServer 使用在线服务器渲染
-func (s *PUMLService) renderWithServer(pumlCode string, options *RenderOptions) (*RenderResult, error) {
+func (s *PUMLService) renderWithServer(ctx context.Context, pumlCode string, options *RenderOptions) (*RenderResult, error) {
 	// 将PUML代码编码为PlantUML服务器格式
 	encoded, err := s.encodePUML(pumlCode)
 	if err != nil {
@@ -228,7 +274,13 @@ func (s *PUMLService) renderWithServer(pumlCode string, options *RenderOptions)
 	url := fmt.Sprintf("%s/%s/%s", s.serverURL, format, encoded)
 	
 	// 发送HTTP请求
-	resp, err := s.httpClient.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建PlantUML请求失败: %w", err)
+	}
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := s.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("请求PlantUML服务器失败: %w", err)
 	}
@@ -251,11 +303,58 @@ func (s *PUMLService) renderWithServer(pumlCode string, options *RenderOptions)
 	}, nil
 }
 
-// renderLocally 本地渲染（需要本地PlantUML环境）
-func (s *PUMLService) renderLocally(pumlCode string, options *RenderOptions) (*RenderResult, error) {
-	// 这里需要调用本地的PlantUML jar文件
-	// 为了简化，目前返回错误，提示需要配置本地环境
-	return nil, fmt.Errorf("本地渲染需要配置PlantUML环境，当前仅支持在线渲染")
+// renderServerTraced 使用在线服务器渲染并记录耗时和Span
+func (s *PUMLService) renderServerTraced(ctx context.Context, pumlCode string, options *RenderOptions) (*RenderResult, error) {
+	spanCtx, span := startRenderSpan(ctx, "server", options.Format)
+	started := time.Now()
+	result, err := s.renderWithServer(spanCtx, pumlCode, options)
+	observeRender("server", options.Format, started, err)
+	span.RecordError(err)
+	span.End()
+	return result, err
+}
+
+// renderLocally 通过本地PlantUML工作池渲染
+func (s *PUMLService) renderLocally(ctx context.Context, pumlCode string, options *RenderOptions) (*RenderResult, error) {
+	if s.local == nil {
+		if s.localErr != nil {
+			return nil, fmt.Errorf("%w: %v", plantuml.ErrUnavailable, s.localErr)
+		}
+		return nil, fmt.Errorf("%w: 未配置本地PlantUML环境", plantuml.ErrUnavailable)
+	}
+
+	format := options.Format
+	if format == "" {
+		format = plantuml.FormatPNG
+	}
+	spanCtx, span := startRenderSpan(ctx, "local", format)
+	started := time.Now()
+	imageData, err := s.local.Render(spanCtx, pumlCode, format)
+	observeRender("local", format, started, err)
+	span.RecordError(err)
+	span.End()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RenderResult{
+		ImageData: imageData,
+		Format:    format,
+	}, nil
+}
+
+// useLocal 是否按配置使用本地渲染
+func (s *PUMLService) useLocal() bool {
+	return s.local != nil || s.localErr != nil
+}
+
+// fallbackToServer 本地渲染失败后是否改用服务器渲染：只有渲染环境不可用时才回退，图表本身的错误直接返回
+func (s *PUMLService) fallbackToServer(err error) bool {
+	if !s.fallback || s.localErr != nil || !errors.Is(err, plantuml.ErrUnavailable) {
+		return false
+	}
+	log.Printf("Warning: 本地PlantUML渲染不可用，改用服务器渲染: %v", err)
+	return true
 }
 
 // encodePUML 将PUML代码编码为PlantUML服务器格式
@@ -282,45 +381,105 @@ func (s *PUMLService) encodePUML(pumlCode string) (string, error) {
 	return encoded, nil
 }
 
-// generateCacheKey 生成缓存键
+// generateCacheKey 生成缓存键：图表源码和渲染参数的 SHA-256
 func (s *PUMLService) generateCacheKey(pumlCode string, options *RenderOptions) string {
-	return fmt.Sprintf("%s_%s_%d", 
-		base64.StdEncoding.EncodeToString([]byte(pumlCode)), 
-		options.Format, 
-		options.DPI)
+	format := options.Format
+	if format == "" {
+		format = "png"
+	}
+	return plantuml.CacheKey(pumlCode, format, options.DPI)
 }
 
-// ClearCache 清空缓存
+// ClearCache 清空内存和磁盘缓存
 func (s *PUMLService) ClearCache() {
-	s.cache = make(map[string]*RenderResult)
+	s.cache.Clear()
+	metrics.CacheEntries.Set(0, "puml_render")
+}
+
+// observeRender 记录PlantUML渲染耗时
+func observeRender(mode, format string, started time.Time, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	if format == "" {
+		format = "png"
+	}
+	metrics.PlantUMLRenderDuration.Observe(time.Since(started).Seconds(), mode, format, status)
+}
+
+// startRenderSpan 开始PlantUML渲染Span
+func startRenderSpan(ctx context.Context, mode, format string) (context.Context, *tracing.Span) {
+	if format == "" {
+		format = "png"
+	}
+	ctx, span := tracing.Start(ctx, "plantuml.render")
+	span.SetAttribute("plantuml.mode", mode)
+	span.SetAttribute("plantuml.format", format)
+	return ctx, span
 }
 
 // GetCacheStats 获取缓存统计
 func (s *PUMLService) GetCacheStats() map[string]interface{} {
-	return map[string]interface{}{
-		"cache_size": len(s.cache),
-		"cache_enabled": s.enableCache,
-	}
+	cacheStats := s.cache.Stats()
+	stats := map[string]interface{}{
+		"cache_size":      cacheStats.Entries,
+		"cache_enabled":   s.enableCache,
+		"cache_hits":      cacheStats.Hits,
+		"cache_misses":    cacheStats.Misses,
+		"cache_evictions": cacheStats.Evictions,
+		"cache":           cacheStats,
+		"renderer":      "server",
+	}
+	if s.useLocal() {
+		stats["renderer"] = "local"
+		stats["fallback_to_server"] = s.fallback
+	}
+	if s.local != nil {
+		stats["local_pool"] = s.local.Stats()
+	}
+	return stats
 }
 
 // ===== Controller需要的方法 =====
 
-// CreatePUML 创建PUML图表
+// CreatePUML 创建PUML图表，并保存为版本1
 func (s *PUMLService) CreatePUML(userID uuid.UUID, req *model.CreatePUMLRequest) (*model.PUMLDiagram, error) {
+	projectID, err := uuid.Parse(req.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("无效的项目ID: %w", err)
+	}
+	if err := s.checkProjectOwner(projectID, userID); err != nil {
+		return nil, err
+	}
+	syntax, err := requestSyntax(req.Syntax, req.Content)
+	if err != nil {
+		return nil, err
+	}
+
 	// 验证PUML语法
-	validation := s.ValidatePUMLString(req.Content)
+	validation := s.ValidateDiagram(req.Content, syntax)
 	if !validation.IsValid {
-		return nil, fmt.Errorf("PUML语法错误: %v", validation.Errors)
+		return nil, syntaxError(syntax, validation)
 	}
 
-	// 创建PUML图表记录（这里应该调用repository层，暂时返回模拟数据）
+	diagramType := req.DiagramType
+	if diagramType == "" {
+		diagramType = "custom"
+	}
 	diagram := &model.PUMLDiagram{
-		DiagramID:   uuid.New(),
-		ProjectID:   uuid.MustParse(req.ProjectID),
-		DiagramName: req.Title,
-		PUMLContent: req.Content,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		DiagramID:          uuid.New(),
+		ProjectID:          projectID,
+		DiagramType:        diagramType,
+		DiagramName:        req.Title,
+		PUMLContent:        req.Content,
+		Syntax:             string(syntax),
+		Stage:              1,
+		IsValidated:        true,
+		ValidationFeedback: strings.Join(validation.Warnings, "\n"),
+	}
+	if err := s.repo.CreateVersionedPUMLDiagram(diagram, userID, req.Note); err != nil {
+		return nil, err
 	}
 
 	return diagram, nil
@@ -328,37 +487,74 @@ func (s *PUMLService) CreatePUML(userID uuid.UUID, req *model.CreatePUMLRequest)
 
 // GetProjectPUMLs 获取项目PUML图表列表
 func (s *PUMLService) GetProjectPUMLs(userID uuid.UUID, projectID string) ([]*model.PUMLDiagram, error) {
-	// 这里应该调用repository层获取数据，暂时返回空列表
-	return []*model.PUMLDiagram{}, nil
+	projectUUID, err := uuid.Parse(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("无效的项目ID: %w", err)
+	}
+	if err := s.checkProjectOwner(projectUUID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetPUMLDiagramsByProjectID(projectUUID)
 }
 
-// UpdatePUMLDiagram 更新PUML图表
+// UpdatePUMLDiagram 更新PUML图表，每次更新都保存为新版本；标题和内容都没有变化时不生成版本
 func (s *PUMLService) UpdatePUMLDiagram(userID uuid.UUID, pumlID string, req *model.UpdatePUMLRequest) (*model.PUMLDiagram, error) {
+	diagram, err := s.diagramForUser(userID, pumlID)
+	if err != nil {
+		return nil, err
+	}
+	syntax := DiagramSyntax(diagram)
+	if req.Syntax != "" {
+		if syntax, err = plantuml.ParseSyntax(req.Syntax); err != nil {
+			return nil, err
+		}
+	}
+
 	// 验证PUML语法
-	validation := s.ValidatePUMLString(req.Content)
+	validation := s.ValidateDiagram(req.Content, syntax)
 	if !validation.IsValid {
-		return nil, fmt.Errorf("PUML语法错误: %v", validation.Errors)
+		return nil, syntaxError(syntax, validation)
 	}
 
-	// 更新PUML图表（这里应该调用repository层，暂时返回模拟数据）
-	diagram := &model.PUMLDiagram{
-		DiagramID:   uuid.MustParse(pumlID),
-		DiagramName: req.Title,
-		PUMLContent: req.Content,
-		UpdatedAt:   time.Now(),
+	title := diagram.DiagramName
+	if req.Title != "" {
+		title = req.Title
+	}
+	if title == diagram.DiagramName && req.Content == diagram.PUMLContent && syntax == DiagramSyntax(diagram) {
+		return diagram, nil
+	}
+
+	diagram.DiagramName = title
+	diagram.PUMLContent = req.Content
+	diagram.Syntax = string(syntax)
+	diagram.IsValidated = true
+	diagram.ValidationFeedback = strings.Join(validation.Warnings, "\n")
+	if err := s.repo.UpdateVersionedPUMLDiagram(diagram, userID, req.Note); err != nil {
+		return nil, err
 	}
 
 	return diagram, nil
 }
 
-// DeletePUML 删除PUML图表
+// DeletePUML 删除PUML图表及其版本历史
 func (s *PUMLService) DeletePUML(userID uuid.UUID, pumlID string) error {
-	// 这里应该调用repository层删除数据
-	return nil
+	diagram, err := s.diagramForUser(userID, pumlID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeletePUMLDiagram(diagram.DiagramID); err != nil {
+		return err
+	}
+	return s.repo.DeleteArtifactDependencies(model.ArtifactTypePUMLDiagram, diagram.DiagramID.String())
 }
 
-// RenderPUMLImage 渲染PUML图片
-func (s *PUMLService) RenderPUMLImage(req *model.RenderPUMLRequest) (*RenderResult, error) {
+// RenderPUMLImage 渲染PUML图片，Mermaid 图表原样返回源码
+func (s *PUMLService) RenderPUMLImage(ctx context.Context, req *model.RenderPUMLRequest) (*RenderResult, error) {
+	if result, err := mermaidSource(req.Content, req.Syntax); result != nil || err != nil {
+		return result, err
+	}
 	options := &RenderOptions{
 		Format:     req.Format,
 		UseCache:   true,
@@ -368,16 +564,25 @@ func (s *PUMLService) RenderPUMLImage(req *model.RenderPUMLRequest) (*RenderResu
 		options.Format = "png"
 	}
 
-	return s.RenderPUML(req.Content, options)
+	return s.RenderPUML(ctx, req.Content, options)
 }
 
 // RenderPUMLOnlineFromRequest 在线渲染PUML（适配controller接口）
-func (s *PUMLService) RenderPUMLOnlineFromRequest(req *model.RenderPUMLRequest) (string, error) {
-	return s.RenderPUMLOnline(req.Content)
+func (s *PUMLService) RenderPUMLOnlineFromRequest(ctx context.Context, req *model.RenderPUMLRequest) (string, error) {
+	if result, err := mermaidSource(req.Content, req.Syntax); result != nil || err != nil {
+		if err != nil {
+			return "", err
+		}
+		return result.Source, nil
+	}
+	return s.RenderPUMLOnline(ctx, req.Content)
 }
 
-// GenerateImage 生成图片
-func (s *PUMLService) GenerateImage(req *model.GenerateImageRequest) (*RenderResult, error) {
+// GenerateImage 生成图片，Mermaid 图表原样返回源码
+func (s *PUMLService) GenerateImage(ctx context.Context, req *model.GenerateImageRequest) (*RenderResult, error) {
+	if result, err := mermaidSource(req.Content, req.Syntax); result != nil || err != nil {
+		return result, err
+	}
 	options := &RenderOptions{
 		Format:     req.Format,
 		UseCache:   true,
@@ -387,7 +592,7 @@ func (s *PUMLService) GenerateImage(req *model.GenerateImageRequest) (*RenderRes
 		options.Format = "png"
 	}
 
-	return s.RenderPUML(req.Content, options)
+	return s.RenderPUML(ctx, req.Content, options)
 }
 
 // ValidatePUMLString 验证PUML语法（重命名避免方法签名冲突）
@@ -397,30 +602,42 @@ func (s *PUMLService) ValidatePUMLString(pumlCode string) *ValidationResult {
 
 // ValidatePUMLFromRequest 验证PUML语法（适配controller接口）
 func (s *PUMLService) ValidatePUMLFromRequest(req *model.ValidatePUMLRequest) (*ValidationResult, error) {
-	result := s.ValidatePUML(req.Content)
+	syntax, err := requestSyntax(req.Syntax, req.Content)
+	if err != nil {
+		return nil, err
+	}
+	result := s.ValidateDiagram(req.Content, syntax)
 	return result, nil
 }
 
-// PreviewPUML 预览PUML
-func (s *PUMLService) PreviewPUML(req *model.PreviewPUMLRequest) (*RenderResult, error) {
+// ConvertPUML 在 PlantUML 与 Mermaid 之间转换图表源码，Dropped 列出目标语法无法表达而丢弃的写法
+func (s *PUMLService) ConvertPUML(req *model.ConvertPUMLRequest) (*plantuml.Conversion, error) {
+	from, err := requestSyntax(req.From, req.Content)
+	if err != nil {
+		return nil, err
+	}
+	to, err := plantuml.ParseSyntax(req.To)
+	if err != nil {
+		return nil, err
+	}
+	if validation := s.ValidateDiagram(req.Content, from); !validation.IsValid {
+		return nil, syntaxError(from, validation)
+	}
+	return plantuml.Convert(req.Content, from, to)
+}
+
+// PreviewPUML 预览PUML，Mermaid 图表原样返回源码
+func (s *PUMLService) PreviewPUML(ctx context.Context, req *model.PreviewPUMLRequest) (*RenderResult, error) {
+	if result, err := mermaidSource(req.Content, req.Syntax); result != nil || err != nil {
+		return result, err
+	}
 	options := &RenderOptions{
 		Format:     "svg",
 		UseCache:   false, // 预览不使用缓存
 		ServerMode: true,
 	}
 
-	return s.RenderPUML(req.Content, options)
-}
-
-// ExportPUML 导出PUML
-func (s *PUMLService) ExportPUML(userID uuid.UUID, req *model.ExportPUMLRequest) (interface{}, error) {
-	// 这里应该根据format类型导出不同格式的文件
-	// 暂时返回基本响应
-	return map[string]interface{}{
-		"exported_count": len(req.PUMLIDs),
-		"format":        req.Format,
-		"user_id":       userID,
-	}, nil
+	return s.RenderPUML(ctx, req.Content, options)
 }
 
 // GetPUMLStats 获取PUML统计信息
@@ -437,4 +654,47 @@ func (s *PUMLService) GetPUMLStats(userID uuid.UUID) (interface{}, error) {
 func (s *PUMLService) ClearPUMLCache(userID uuid.UUID) error {
 	s.ClearCache()
 	return nil
-} 
\ No newline at end of file
+} 
+
+// DiagramSyntax 图表源码的语法
+func DiagramSyntax(diagram *model.PUMLDiagram) plantuml.Syntax {
+	return storedSyntax(diagram.Syntax)
+}
+
+// storedSyntax 解析图表或版本保存的语法，早于 Mermaid 支持保存的没有记录语法，按 PlantUML 处理
+func storedSyntax(name string) plantuml.Syntax {
+	syntax, err := plantuml.ParseSyntax(name)
+	if err != nil {
+		return plantuml.SyntaxPlantUML
+	}
+	return syntax
+}
+
+// requestSyntax 解析请求指定的语法，未指定时由源码推断
+func requestSyntax(name, content string) (plantuml.Syntax, error) {
+	if strings.TrimSpace(name) == "" {
+		return plantuml.DetectSyntax(content), nil
+	}
+	return plantuml.ParseSyntax(name)
+}
+
+// syntaxError 把验证失败的结果转换为错误
+func syntaxError(syntax plantuml.Syntax, validation *ValidationResult) error {
+	if syntax == plantuml.SyntaxMermaid {
+		return fmt.Errorf("Mermaid语法错误: %v", validation.Errors)
+	}
+	return fmt.Errorf("PUML语法错误: %v", validation.Errors)
+}
+
+// mermaidSource Mermaid 图表不在服务端渲染，返回源码由前端用 Mermaid 渲染；PlantUML 图表返回 nil
+func mermaidSource(content, name string) (*RenderResult, error) {
+	syntax, err := requestSyntax(name, content)
+	if err != nil || syntax != plantuml.SyntaxMermaid {
+		return nil, err
+	}
+	return &RenderResult{
+		Format:     string(plantuml.SyntaxMermaid),
+		RenderedAt: time.Now(),
+		Source:     content,
+	}, nil
+}
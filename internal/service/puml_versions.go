@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// ListPUMLVersions 获取PUML图表的版本历史，按版本号升序
+func (s *PUMLService) ListPUMLVersions(userID uuid.UUID, pumlID string) ([]*model.PUMLDiagramVersion, error) {
+	diagram, err := s.diagramForUser(userID, pumlID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetPUMLDiagramVersions(diagram.DiagramID)
+}
+
+// GetPUMLVersion 获取PUML图表的指定版本
+func (s *PUMLService) GetPUMLVersion(userID uuid.UUID, pumlID string, version int) (*model.PUMLDiagramVersion, error) {
+	diagram, err := s.diagramForUser(userID, pumlID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetPUMLDiagramVersion(diagram.DiagramID, version)
+}
+
+// RestorePUMLVersion 将PUML图表恢复为指定版本的内容，恢复本身保存为一个新版本，历史版本保持不变
+func (s *PUMLService) RestorePUMLVersion(userID uuid.UUID, pumlID string, version int, req *model.RestorePUMLVersionRequest) (*model.PUMLDiagram, error) {
+	diagram, err := s.diagramForUser(userID, pumlID)
+	if err != nil {
+		return nil, err
+	}
+	if version == diagram.Version {
+		return nil, fmt.Errorf("版本 %d 已是当前版本", version)
+	}
+
+	snapshot, err := s.repo.GetPUMLDiagramVersion(diagram.DiagramID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	note := strings.TrimSpace(req.Note)
+	if note == "" {
+		note = fmt.Sprintf("恢复自版本 %d", version)
+	}
+	diagram.DiagramName = snapshot.DiagramName
+	diagram.PUMLContent = snapshot.PUMLContent
+	diagram.Syntax = snapshot.Syntax
+	diagram.IsValidated = snapshot.IsValidated
+	diagram.ValidationFeedback = snapshot.ValidationFeedback
+	if err := s.repo.UpdateVersionedPUMLDiagram(diagram, userID, note); err != nil {
+		return nil, err
+	}
+
+	return diagram, nil
+}
+
+// diagramForUser 获取PUML图表并校验其所属项目属于该用户
+func (s *PUMLService) diagramForUser(userID uuid.UUID, pumlID string) (*model.PUMLDiagram, error) {
+	diagramID, err := uuid.Parse(pumlID)
+	if err != nil {
+		return nil, fmt.Errorf("无效的图表ID: %w", err)
+	}
+	diagram, err := s.repo.GetPUMLDiagram(diagramID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkProjectOwner(diagram.ProjectID, userID); err != nil {
+		return nil, err
+	}
+	return diagram, nil
+}
+
+// checkProjectOwner 校验项目存在且属于该用户
+func (s *PUMLService) checkProjectOwner(projectID, userID uuid.UUID) error {
+	if s.repo == nil {
+		return fmt.Errorf("PUML存储未初始化")
+	}
+	project, err := s.repo.GetProjectByID(projectID)
+	if err != nil {
+		return fmt.Errorf("项目不存在: %w", err)
+	}
+	if project.UserID != userID {
+		return fmt.Errorf("无权访问该项目")
+	}
+	return nil
+}
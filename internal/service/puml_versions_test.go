@@ -0,0 +1,182 @@
+package service
+
+import (
+	"testing"
+
+	"ai-dev-platform/internal/config"
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	pumlV1 = "@startuml\nparticipant A\nparticipant B\nA -> B: 登录\n@enduml"
+	pumlV2 = "@startuml\nparticipant A\nparticipant B\nA -> B: 登录\nB --> A: 成功\n@enduml"
+)
+
+type PUMLVersionsTestSuite struct {
+	suite.Suite
+	mockRepo    *MockRepository
+	pumlService *PUMLService
+	userID      uuid.UUID
+	project     *model.Project
+	diagram     *model.PUMLDiagram
+}
+
+func (suite *PUMLVersionsTestSuite) SetupTest() {
+	suite.mockRepo = new(MockRepository)
+	suite.pumlService = NewPUMLService(&config.PUMLConfig{}, suite.mockRepo)
+	suite.userID = uuid.New()
+	suite.project = &model.Project{ProjectID: uuid.New(), UserID: suite.userID}
+	suite.diagram = &model.PUMLDiagram{
+		DiagramID:   uuid.New(),
+		ProjectID:   suite.project.ProjectID,
+		DiagramType: "custom",
+		DiagramName: "登录流程",
+		PUMLContent: pumlV2,
+		Syntax:      "plantuml",
+		Version:     2,
+	}
+}
+
+func (suite *PUMLVersionsTestSuite) TearDownTest() {
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+// expectDiagram 图表及其项目归属的查询
+func (suite *PUMLVersionsTestSuite) expectDiagram() {
+	suite.mockRepo.On("GetPUMLDiagram", suite.diagram.DiagramID).Return(suite.diagram, nil)
+	suite.mockRepo.On("GetProjectByID", suite.project.ProjectID).Return(suite.project, nil)
+}
+
+func (suite *PUMLVersionsTestSuite) TestCreatePUML_SavesVersion1() {
+	// Arrange
+	req := &model.CreatePUMLRequest{
+		ProjectID: suite.project.ProjectID.String(),
+		Title:     "登录流程",
+		Content:   pumlV1,
+		Note:      "初稿",
+	}
+	suite.mockRepo.On("GetProjectByID", suite.project.ProjectID).Return(suite.project, nil)
+	suite.mockRepo.On("CreateVersionedPUMLDiagram", mock.MatchedBy(func(diagram *model.PUMLDiagram) bool {
+		return diagram.ProjectID == suite.project.ProjectID && diagram.PUMLContent == pumlV1
+	}), suite.userID, "初稿").Return(nil)
+
+	// Act
+	diagram, err := suite.pumlService.CreatePUML(suite.userID, req)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "custom", diagram.DiagramType)
+	assert.True(suite.T(), diagram.IsValidated)
+}
+
+func (suite *PUMLVersionsTestSuite) TestUpdatePUMLDiagram_SavesNewVersion() {
+	// Arrange
+	suite.diagram.PUMLContent = pumlV1
+	suite.expectDiagram()
+	suite.mockRepo.On("UpdateVersionedPUMLDiagram", mock.MatchedBy(func(diagram *model.PUMLDiagram) bool {
+		return diagram.DiagramID == suite.diagram.DiagramID && diagram.PUMLContent == pumlV2
+	}), suite.userID, "补充返回消息").Return(nil).Run(func(args mock.Arguments) {
+		// 版本号由存储层在事务中递增
+		args.Get(0).(*model.PUMLDiagram).Version++
+	})
+
+	// Act
+	diagram, err := suite.pumlService.UpdatePUMLDiagram(suite.userID, suite.diagram.DiagramID.String(), &model.UpdatePUMLRequest{
+		Content: pumlV2,
+		Note:    "补充返回消息",
+	})
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 3, diagram.Version)
+	assert.Equal(suite.T(), "登录流程", diagram.DiagramName)
+}
+
+func (suite *PUMLVersionsTestSuite) TestUpdatePUMLDiagram_UnchangedKeepsVersion() {
+	// Arrange
+	suite.expectDiagram()
+
+	// Act
+	diagram, err := suite.pumlService.UpdatePUMLDiagram(suite.userID, suite.diagram.DiagramID.String(), &model.UpdatePUMLRequest{
+		Content: pumlV2,
+	})
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, diagram.Version)
+	suite.mockRepo.AssertNotCalled(suite.T(), "UpdateVersionedPUMLDiagram", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *PUMLVersionsTestSuite) TestRestorePUMLVersion_SavesNewVersion() {
+	// Arrange
+	suite.expectDiagram()
+	snapshot := &model.PUMLDiagramVersion{
+		DiagramID:   suite.diagram.DiagramID,
+		Version:     1,
+		DiagramName: "登录",
+		PUMLContent: pumlV1,
+		Syntax:      "plantuml",
+		IsValidated: true,
+	}
+	suite.mockRepo.On("GetPUMLDiagramVersion", suite.diagram.DiagramID, 1).Return(snapshot, nil)
+	suite.mockRepo.On("UpdateVersionedPUMLDiagram", mock.MatchedBy(func(diagram *model.PUMLDiagram) bool {
+		return diagram.DiagramName == "登录" && diagram.PUMLContent == pumlV1
+	}), suite.userID, "恢复自版本 1").Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*model.PUMLDiagram).Version++
+	})
+
+	// Act
+	diagram, err := suite.pumlService.RestorePUMLVersion(suite.userID, suite.diagram.DiagramID.String(), 1, &model.RestorePUMLVersionRequest{})
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 3, diagram.Version)
+	assert.Equal(suite.T(), pumlV1, diagram.PUMLContent)
+	// 被恢复的快照本身不变
+	assert.Equal(suite.T(), 1, snapshot.Version)
+	assert.Equal(suite.T(), pumlV1, snapshot.PUMLContent)
+}
+
+func (suite *PUMLVersionsTestSuite) TestRestorePUMLVersion_CurrentVersion() {
+	// Arrange
+	suite.expectDiagram()
+
+	// Act
+	diagram, err := suite.pumlService.RestorePUMLVersion(suite.userID, suite.diagram.DiagramID.String(), 2, &model.RestorePUMLVersionRequest{})
+
+	// Assert
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), diagram)
+	assert.Contains(suite.T(), err.Error(), "已是当前版本")
+	suite.mockRepo.AssertNotCalled(suite.T(), "UpdateVersionedPUMLDiagram", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *PUMLVersionsTestSuite) TestOtherUsersDiagram() {
+	// Arrange
+	otherUserID := uuid.New()
+	suite.expectDiagram()
+	pumlID := suite.diagram.DiagramID.String()
+
+	// Act
+	_, updateErr := suite.pumlService.UpdatePUMLDiagram(otherUserID, pumlID, &model.UpdatePUMLRequest{Content: pumlV1})
+	_, restoreErr := suite.pumlService.RestorePUMLVersion(otherUserID, pumlID, 1, &model.RestorePUMLVersionRequest{})
+	_, listErr := suite.pumlService.ListPUMLVersions(otherUserID, pumlID)
+	_, getErr := suite.pumlService.GetPUMLVersion(otherUserID, pumlID, 1)
+
+	// Assert
+	for _, err := range []error{updateErr, restoreErr, listErr, getErr} {
+		assert.Error(suite.T(), err)
+		assert.Contains(suite.T(), err.Error(), "无权访问")
+	}
+	suite.mockRepo.AssertNotCalled(suite.T(), "GetPUMLDiagramVersion", mock.Anything, mock.Anything)
+	suite.mockRepo.AssertNotCalled(suite.T(), "UpdateVersionedPUMLDiagram", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPUMLVersionsTestSuite(t *testing.T) {
+	suite.Run(t, new(PUMLVersionsTestSuite))
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"ai-dev-platform/internal/ai"
+	"ai-dev-platform/internal/ddl"
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// ===== 从现有数据库的结构导出反向生成数据实体和数据模型图 =====
+
+// schemaDocumentType 导入的现有数据库结构保存为该类型的文档，内容与开发文档相同，只有数据库设计部分
+const schemaDocumentType = "database_schema"
+
+// SchemaImport 导入现有数据库结构的结果
+type SchemaImport struct {
+	Dialect        ddl.Dialect        `json:"dialect"`
+	DatabaseDesign *ai.DatabaseDesign `json:"database_design"`
+	DataEntities   []ai.DataEntity    `json:"data_entities"`            // 与需求分析合并后的数据实体
+	AddedEntities  []string           `json:"added_entities,omitempty"` // 需求分析中原来没有的实体
+	RequirementID  *uuid.UUID         `json:"requirement_id,omitempty"` // 合并写回的需求分析
+	Document       *model.Document    `json:"document"`
+	Diagram        *model.PUMLDiagram `json:"diagram"`
+	Skipped        []string           `json:"skipped,omitempty"`
+	Warnings       []string           `json:"warnings,omitempty"`
+	Findings       []ddl.Finding      `json:"findings,omitempty"` // 现有结构的检查结果，如缺少主键
+}
+
+// schemaDocumentSource 可查询项目文档的仓库
+type schemaDocumentSource interface {
+	GetDocumentsByProjectID(projectID uuid.UUID) ([]*model.Document, error)
+}
+
+// ImportSchema 解析 mysqldump 或 pg_dump 导出的建表语句：保存为数据库结构文档并生成数据模型图，
+// 数据实体合并到需求分析（保存为新版本），此后该项目的AI生成会基于现有数据库结构
+func (s *AIService) ImportSchema(userID, projectID uuid.UUID, req *model.ImportSchemaRequest) (*SchemaImport, error) {
+	if _, err := s.projectForUser(projectID, userID); err != nil {
+		return nil, err
+	}
+	var dialect ddl.Dialect
+	if req.Dialect != "" {
+		d, err := ddl.ParseDialect(req.Dialect)
+		if err != nil {
+			return nil, err
+		}
+		dialect = d
+	}
+	parsed, err := ddl.ParseSchema(req.SQL, dialect)
+	if err != nil {
+		return nil, err
+	}
+	design := parsed.Design
+	result := &SchemaImport{
+		Dialect:        parsed.Dialect,
+		DatabaseDesign: design,
+		DataEntities:   ai.DatabaseDesignEntities(design),
+		Skipped:        parsed.Skipped,
+		Warnings:       parsed.Warnings,
+		Findings:       ddl.Lint(design, parsed.Dialect),
+	}
+
+	var requirement *model.Requirement
+	if req.AnalysisID != "" {
+		analysisID, err := uuid.Parse(req.AnalysisID)
+		if err != nil {
+			return nil, fmt.Errorf("无效的分析ID: %w", err)
+		}
+		if requirement, err = s.repo.GetRequirementAnalysis(analysisID); err != nil {
+			return nil, fmt.Errorf("获取需求分析失败: %w", err)
+		}
+		if requirement.ProjectID != projectID {
+			return nil, fmt.Errorf("需求分析不属于该项目")
+		}
+	} else if requirements, err := s.repo.GetRequirementAnalysesByProject(projectID); err == nil && len(requirements) > 0 {
+		// 按创建时间倒序排列
+		requirement = requirements[0]
+	}
+
+	now := time.Now()
+	content, err := json.Marshal(&ai.DevelopmentDocument{
+		ID:             uuid.New().String(),
+		ProjectID:      projectID.String(),
+		DatabaseDesign: *design,
+		Version:        1,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("序列化数据库结构失败: %w", err)
+	}
+	document := &model.Document{
+		DocumentID:   uuid.New(),
+		ProjectID:    projectID,
+		DocumentType: schemaDocumentType,
+		DocumentName: fmt.Sprintf("现有数据库结构（%s）", parsed.Dialect),
+		Content:      string(content),
+		Format:       "json",
+		Version:      1,
+		Stage:        1,
+		GeneratedAt:  now,
+	}
+
+	generated, err := ai.DataModelDiagram(nil, design, ai.DataModelOptions{Title: "现有数据模型", Style: ai.DataModelStyle(req.DataModelStyle)})
+	if err != nil {
+		return nil, err
+	}
+	diagram := &model.PUMLDiagram{
+		DiagramID:   uuid.New(),
+		ProjectID:   projectID,
+		DiagramType: string(ai.PUMLTypeDataModel),
+		DiagramName: generated.Title,
+		PUMLContent: generated.Content,
+		Stage:       1,
+	}
+
+	if requirement != nil {
+		analysis, err := requirementToAnalysis(requirement)
+		if err != nil {
+			return nil, err
+		}
+		analysis.DataEntities, result.AddedEntities = ai.MergeImportedEntities(analysis.DataEntities, result.DataEntities)
+		if err := applyAnalysis(requirement, analysis); err != nil {
+			return nil, err
+		}
+		result.DataEntities = analysis.DataEntities
+		result.RequirementID = &requirement.RequirementID
+	}
+
+	// 文档、图表和需求分析在同一事务中保存，任一失败时都不留下部分导入的结果
+	if err := s.repo.SaveSchemaImport(document, diagram, requirement, userID); err != nil {
+		return nil, err
+	}
+	result.Document = document
+	result.Diagram = diagram
+
+	if requirement != nil {
+		s.notifyRequirementChanged(requirement)
+		s.recordRequirementDerivation(model.ArtifactTypePUMLDiagram, diagram.DiagramID, requirement, true)
+	}
+	return result, nil
+}
+
+// withProjectSchema 加载项目最近导入的数据库结构并附加到上下文，此后的AI调用会在提示语前注入现有表结构
+// 没有导入过或加载失败时原样返回上下文
+func withProjectSchema(ctx context.Context, repo schemaDocumentSource, projectID uuid.UUID) context.Context {
+	if repo == nil || projectID == uuid.Nil {
+		return ctx
+	}
+
+	documents, err := repo.GetDocumentsByProjectID(projectID)
+	if err != nil {
+		log.Printf("加载现有数据库结构失败: %v", err)
+		return ctx
+	}
+	// 文档按生成时间倒序排列
+	for _, document := range documents {
+		if document.DocumentType != schemaDocumentType {
+			continue
+		}
+		if design := databaseDesignOf(document); design != nil {
+			return ai.WithExistingSchema(ctx, ai.ExistingSchemaPromptSection(design))
+		}
+	}
+	return ctx
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai-dev-platform/internal/ai"
+	"ai-dev-platform/internal/consistency"
+	"ai-dev-platform/internal/model"
+	"github.com/google/uuid"
+)
+
+// 一致性检测的语句来源
+const (
+	consistencySourceRequirementsDoc = "requirements_doc"
+	consistencySourceUserStory       = "user_story"
+)
+
+// ConsistencyAnalysisResult 需求一致性检测结果
+type ConsistencyAnalysisResult struct {
+	Report       *consistency.Report         `json:"report"`   // 词法检测（及AI分类后）的全部候选对和重复簇
+	Findings     []*model.RequirementFinding `json:"findings"` // 本次新建、更新或自动解决的检测结论
+	Created      int                         `json:"created"`
+	Updated      int                         `json:"updated"`
+	Resolved     int                         `json:"resolved"` // 重新检测后不再成立、自动标记为已解决的结论数
+	AIClassified int                         `json:"ai_classified"`
+	Warnings     []string                    `json:"warnings,omitempty"`
+}
+
+// AnalyzeConsistency 检测项目中功能需求和用户故事之间的重复与冲突，并保存检测结论
+// 已存在的同一需求对只更新判定内容，保留其处理状态；未处理的结论重新检测后不再是重复或冲突时标记为已解决
+func (s *SpecService) AnalyzeConsistency(ctx context.Context, projectID, userID uuid.UUID, req *model.AnalyzeConsistencyRequest) (*ConsistencyAnalysisResult, error) {
+	if err := s.checkProjectOwner(projectID, userID); err != nil {
+		return nil, err
+	}
+
+	statements, err := s.consistencyStatements(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := consistency.Analyze(statements, consistency.Options{
+		DuplicateThreshold: req.DuplicateThreshold,
+		ConflictThreshold:  req.ConflictThreshold,
+		CandidateThreshold: req.CandidateThreshold,
+	})
+	result := &ConsistencyAnalysisResult{Report: report, Findings: []*model.RequirementFinding{}}
+
+	if req.UseAI && len(report.Pairs) > 0 {
+		classified, err := s.classifyPairs(s.glossaryContext(ctx, projectID), report.Pairs, req.Provider)
+		if err != nil {
+			// AI分类失败时保留词法检测结果
+			log.Printf("Warning: AI classification failed: %v", err)
+			result.Warnings = append(result.Warnings, "AI分类失败，已使用词法检测结果: "+err.Error())
+		}
+		result.AIClassified = classified
+	}
+
+	existing, err := s.repo.GetRequirementFindings(projectID, "")
+	if err != nil {
+		return nil, err
+	}
+	byFingerprint := make(map[string]*model.RequirementFinding, len(existing))
+	for _, finding := range existing {
+		byFingerprint[finding.Fingerprint] = finding
+	}
+
+	now := time.Now()
+	for _, pair := range report.Pairs {
+		fingerprint := pair.Fingerprint()
+		finding, ok := byFingerprint[fingerprint]
+		if pair.Kind == consistency.KindCandidate || pair.Kind == consistency.KindUnrelated {
+			// 未使用AI时得到的候选对不能推翻此前AI作出的判定
+			if !ok || finding.Status != model.FindingStatusOpen || pair.Kind == consistency.KindCandidate && finding.Method != pair.Method {
+				continue
+			}
+			finding.Status = model.FindingStatusResolved
+			finding.ResolutionNote = fmt.Sprintf("重新检测后判定为 %s，不再是重复或冲突", pair.Kind)
+			finding.ResolvedBy = &userID
+			finding.ResolvedAt = &now
+			finding.UpdatedAt = now
+			if err := s.repo.SaveRequirementFinding(finding); err != nil {
+				return nil, err
+			}
+			result.Resolved++
+			result.Findings = append(result.Findings, finding)
+			continue
+		}
+
+		if !ok {
+			finding = &model.RequirementFinding{
+				FindingID:   uuid.New(),
+				ProjectID:   projectID,
+				Fingerprint: fingerprint,
+				Status:      model.FindingStatusOpen,
+				CreatedAt:   now,
+			}
+		}
+		finding.Kind = string(pair.Kind)
+		finding.Method = pair.Method
+		finding.Similarity = pair.Similarity
+		finding.RefA, finding.TextA = pair.A.Ref, pair.A.Text
+		finding.RefB, finding.TextB = pair.B.Ref, pair.B.Text
+		finding.Reason = pair.Reason
+		finding.UpdatedAt = now
+
+		if err := s.repo.SaveRequirementFinding(finding); err != nil {
+			return nil, err
+		}
+		if ok {
+			result.Updated++
+		} else {
+			result.Created++
+			byFingerprint[fingerprint] = finding
+		}
+		result.Findings = append(result.Findings, finding)
+	}
+
+	return result, nil
+}
+
+// ListFindings 获取项目的需求一致性检测结论，status 为空时返回全部
+func (s *SpecService) ListFindings(ctx context.Context, projectID, userID uuid.UUID, status string) ([]*model.RequirementFinding, error) {
+	if err := s.checkProjectOwner(projectID, userID); err != nil {
+		return nil, err
+	}
+	if status != "" && !validFindingStatus(status) {
+		return nil, fmt.Errorf("无效的状态: %s", status)
+	}
+	return s.repo.GetRequirementFindings(projectID, status)
+}
+
+// UpdateFinding 更新检测结论的处理状态（open、resolved、ignored）
+func (s *SpecService) UpdateFinding(ctx context.Context, projectID, userID, findingID uuid.UUID, req *model.UpdateFindingRequest) (*model.RequirementFinding, error) {
+	if err := s.checkProjectOwner(projectID, userID); err != nil {
+		return nil, err
+	}
+	if !validFindingStatus(req.Status) {
+		return nil, fmt.Errorf("无效的状态: %s", req.Status)
+	}
+
+	finding, err := s.repo.GetRequirementFinding(findingID)
+	if err != nil {
+		return nil, err
+	}
+	if finding.ProjectID != projectID {
+		return nil, fmt.Errorf("检测结论不属于该项目")
+	}
+
+	now := time.Now()
+	finding.Status = req.Status
+	finding.ResolutionNote = strings.TrimSpace(req.Note)
+	finding.UpdatedAt = now
+	if req.Status == model.FindingStatusOpen {
+		finding.ResolvedBy = nil
+		finding.ResolvedAt = nil
+	} else {
+		finding.ResolvedBy = &userID
+		finding.ResolvedAt = &now
+	}
+
+	if err := s.repo.SaveRequirementFinding(finding); err != nil {
+		return nil, err
+	}
+	return finding, nil
+}
+
+func validFindingStatus(status string) bool {
+	switch status {
+	case model.FindingStatusOpen, model.FindingStatusResolved, model.FindingStatusIgnored:
+		return true
+	}
+	return false
+}
+
+// checkProjectOwner 校验项目存在且属于该用户
+func (s *SpecService) checkProjectOwner(projectID, userID uuid.UUID) error {
+	project, err := s.repo.GetProjectByID(projectID)
+	if err != nil {
+		return fmt.Errorf("项目不存在: %w", err)
+	}
+	if project.UserID != userID {
+		return fmt.Errorf("无权访问该项目")
+	}
+	return nil
+}
+
+// consistencyStatements 收集项目所有需求文档的功能需求和用户故事
+func (s *SpecService) consistencyStatements(ctx context.Context, projectID uuid.UUID) ([]consistency.Statement, error) {
+	docs, err := s.listRequirementsDocs(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("查询需求文档失败: %w", err)
+	}
+	stories, err := s.listUserStories(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户故事失败: %w", err)
+	}
+
+	var statements []consistency.Statement
+	for _, doc := range docs {
+		if doc.FunctionalRequirements == "" {
+			continue
+		}
+		var requirements []string
+		if err := json.Unmarshal([]byte(doc.FunctionalRequirements), &requirements); err != nil {
+			log.Printf("Warning: failed to parse functional requirements of %s: %v", doc.ID, err)
+			continue
+		}
+		for i, text := range requirements {
+			statements = append(statements, consistency.Statement{
+				Ref:        fmt.Sprintf("%s:%s#%d", consistencySourceRequirementsDoc, doc.ID, i),
+				SourceType: consistencySourceRequirementsDoc,
+				SourceID:   doc.ID.String(),
+				Index:      i,
+				Text:       text,
+			})
+		}
+	}
+	for _, story := range stories {
+		text := story.Title
+		if story.Description != "" {
+			text += "：" + story.Description
+		}
+		statements = append(statements, consistency.Statement{
+			Ref:        fmt.Sprintf("%s:%s", consistencySourceUserStory, story.ID),
+			SourceType: consistencySourceUserStory,
+			SourceID:   story.ID.String(),
+			Text:       text,
+		})
+	}
+	return statements, nil
+}
+
+// classifyPairs 由AI对相似度最高的候选对进行分类
+func (s *SpecService) classifyPairs(ctx context.Context, pairs []*consistency.Pair, provider string) (int, error) {
+	if s.aiManager == nil {
+		return 0, fmt.Errorf("AI服务未初始化")
+	}
+	if len(pairs) > consistency.MaxAIPairs {
+		pairs = pairs[:consistency.MaxAIPairs]
+	}
+
+	aiProvider := ai.ProviderOpenAI
+	if provider != "" {
+		aiProvider = ai.AIProvider(provider)
+	}
+
+	response, err := s.aiManager.ProjectChat(ctx, consistency.BuildClassificationPrompt(pairs), "", aiProvider)
+	if err != nil {
+		return 0, err
+	}
+	jsonStr := s.extractJSON(response.Message)
+	if jsonStr == "" {
+		return 0, fmt.Errorf("no valid JSON found in response")
+	}
+	return consistency.ApplyClassification(jsonStr, pairs)
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ai-dev-platform/internal/impact"
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// specUpstream Spec 文档作为上游的依赖
+func specUpstream(upstreamType string, id uuid.UUID, version int) *model.ArtifactDependency {
+	return &model.ArtifactDependency{
+		UpstreamType:    upstreamType,
+		UpstreamID:      id.String(),
+		UpstreamVersion: version,
+	}
+}
+
+// recordSpecDerivation 记录 Spec 文档的派生来源，失败时只记录日志
+func (s *SpecService) recordSpecDerivation(projectID uuid.UUID, artifactType string, artifactID uuid.UUID, upstreams ...*model.ArtifactDependency) {
+	if s.repo == nil {
+		return
+	}
+	ref := model.ArtifactRef{Type: artifactType, ID: artifactID.String()}
+	if err := recordDerivation(s.repo, projectID, ref, upstreams); err != nil {
+		log.Printf("Warning: failed to record artifact dependencies: %v", err)
+	}
+}
+
+// GetImpactReport 获取项目中因上游变更而过期的产物及原因
+func (s *SpecService) GetImpactReport(ctx context.Context, projectID, userID uuid.UUID) (*impact.Report, error) {
+	if err := s.checkProjectOwner(projectID, userID); err != nil {
+		return nil, err
+	}
+
+	deps, err := s.repo.GetArtifactDependencies(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("查询产物依赖失败: %w", err)
+	}
+	titles := make(map[string]string)
+
+	// 测试用例随任务列表保存，依赖关系由 task_list_id 直接得到
+	testCases, err := s.listTestCases(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("查询测试用例失败: %w", err)
+	}
+	for _, testCase := range testCases {
+		deps = append(deps, &model.ArtifactDependency{
+			ProjectID:    projectID,
+			ArtifactType: model.ArtifactTypeTestCase,
+			ArtifactID:   testCase.ID.String(),
+			UpstreamType: model.ArtifactTypeTaskListDoc,
+			UpstreamID:   testCase.TaskListID.String(),
+		})
+		titles[impact.Key(model.ArtifactTypeTestCase, testCase.ID.String())] = testCase.Title
+	}
+	impact.Propagate(deps, nil, time.Now())
+
+	diagrams, err := s.repo.GetPUMLDiagramsByProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	for _, diagram := range diagrams {
+		titles[impact.Key(model.ArtifactTypePUMLDiagram, diagram.DiagramID.String())] = diagram.DiagramName
+	}
+	documents, err := s.repo.GetDocumentsByProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	for _, document := range documents {
+		titles[impact.Key(model.ArtifactTypeDocument, document.DocumentID.String())] = document.DocumentName
+	}
+	for _, dep := range deps {
+		key := impact.Key(dep.ArtifactType, dep.ArtifactID)
+		if _, ok := titles[key]; !ok {
+			titles[key] = fmt.Sprintf("%s %s", impact.Label(dep.ArtifactType), shortID(dep.ArtifactID))
+		}
+	}
+
+	return impact.BuildReport(deps, titles), nil
+}
+
+// ReportUpstreamChange 登记上游产物的变更（如在外部修改了需求文档），将其下游产物标记为过期
+func (s *SpecService) ReportUpstreamChange(ctx context.Context, projectID, userID uuid.UUID, req *model.ReportUpstreamChangeRequest) (*impact.Report, error) {
+	if err := s.checkProjectOwner(projectID, userID); err != nil {
+		return nil, err
+	}
+	if req.Upstream.Type == "" || req.Upstream.ID == "" {
+		return nil, fmt.Errorf("必须指定变更的上游产物")
+	}
+
+	if _, err := markStale(s.repo, projectID, impact.UpstreamChanged(req.Upstream, req.Reason)); err != nil {
+		return nil, fmt.Errorf("标记过期产物失败: %w", err)
+	}
+	return s.GetImpactReport(ctx, projectID, userID)
+}
+
+// specSources 产物在依赖图中记录的 Spec 上游
+func (s *SpecService) specSources(projectID uuid.UUID, artifact model.ArtifactRef) (map[string]uuid.UUID, error) {
+	deps, err := s.repo.GetArtifactDependencies(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("查询产物依赖失败: %w", err)
+	}
+
+	sources := make(map[string]uuid.UUID)
+	for _, dep := range deps {
+		if dep.ArtifactType != artifact.Type || dep.ArtifactID != artifact.ID {
+			continue
+		}
+		if id, err := uuid.Parse(dep.UpstreamID); err == nil {
+			sources[dep.UpstreamType] = id
+		}
+	}
+	return sources, nil
+}
+
+// RegenerateDesign 基于来源需求文档重新生成设计文档，原设计文档的下游依赖改为指向新文档
+func (s *SpecService) RegenerateDesign(ctx context.Context, projectID, userID, designID uuid.UUID) (*model.DesignDoc, error) {
+	if err := s.checkProjectOwner(projectID, userID); err != nil {
+		return nil, err
+	}
+
+	ref := model.ArtifactRef{Type: model.ArtifactTypeDesignDoc, ID: designID.String()}
+	sources, err := s.specSources(projectID, ref)
+	if err != nil {
+		return nil, err
+	}
+	requirementsID, ok := sources[model.ArtifactTypeRequirementsDoc]
+	if !ok {
+		return nil, fmt.Errorf("设计文档没有记录来源需求文档，无法重新生成")
+	}
+
+	designDoc, err := s.GenerateDesign(ctx, userID, &model.GenerateDesignRequest{
+		ProjectID:      projectID,
+		RequirementsID: requirementsID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.replaceUpstream(projectID, ref, designDoc.ID); err != nil {
+		return nil, err
+	}
+	return designDoc, nil
+}
+
+// RegenerateTaskList 基于来源需求文档和设计文档重新生成任务列表，原任务列表的下游依赖改为指向新文档
+func (s *SpecService) RegenerateTaskList(ctx context.Context, projectID, userID, taskListID uuid.UUID) (*model.TaskListDoc, error) {
+	if err := s.checkProjectOwner(projectID, userID); err != nil {
+		return nil, err
+	}
+
+	ref := model.ArtifactRef{Type: model.ArtifactTypeTaskListDoc, ID: taskListID.String()}
+	sources, err := s.specSources(projectID, ref)
+	if err != nil {
+		return nil, err
+	}
+	requirementsID, hasRequirements := sources[model.ArtifactTypeRequirementsDoc]
+	designID, hasDesign := sources[model.ArtifactTypeDesignDoc]
+	if !hasRequirements || !hasDesign {
+		return nil, fmt.Errorf("任务列表没有记录来源需求文档和设计文档，无法重新生成")
+	}
+
+	taskDoc, err := s.GenerateTasks(ctx, userID, &model.GenerateTasksRequest{
+		ProjectID:      projectID,
+		RequirementsID: requirementsID,
+		DesignID:       designID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.replaceUpstream(projectID, ref, taskDoc.ID); err != nil {
+		return nil, err
+	}
+	return taskDoc, nil
+}
+
+// replaceUpstream 产物重新生成为新文档后，下游依赖改为指向新文档并保持过期（下游仍基于旧文档），
+// 旧文档的依赖记录随之删除
+func (s *SpecService) replaceUpstream(projectID uuid.UUID, old model.ArtifactRef, newID uuid.UUID) error {
+	deps, err := s.repo.GetArtifactDependencies(projectID)
+	if err != nil {
+		return fmt.Errorf("查询产物依赖失败: %w", err)
+	}
+
+	now := time.Now()
+	for _, dep := range deps {
+		if dep.UpstreamType != old.Type || dep.UpstreamID != old.ID {
+			continue
+		}
+		dep.UpstreamID = newID.String()
+		dep.Stale = true
+		dep.StaleReason = fmt.Sprintf("%s已重新生成", impact.Label(old.Type))
+		dep.StaleAt = &now
+		if err := s.repo.SaveArtifactDependency(dep); err != nil {
+			return fmt.Errorf("更新产物依赖失败: %w", err)
+		}
+	}
+	return s.repo.DeleteArtifactDependencies(old.Type, old.ID)
+}
+
+// shortID 截取ID前8位用于展示
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
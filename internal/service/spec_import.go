@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai-dev-platform/internal/importer"
+	"ai-dev-platform/internal/model"
+	"github.com/google/uuid"
+)
+
+// ImportRequirementsResult 需求导入结果
+type ImportRequirementsResult struct {
+	Format          importer.Format        `json:"format"`
+	RequirementsDoc *model.RequirementsDoc `json:"requirements_doc"`
+	Stories         []*model.UserStory     `json:"stories"`
+	Skipped         []*importer.Item       `json:"skipped"` // 重复或未选中的条目
+	Warnings        []string               `json:"warnings,omitempty"`
+}
+
+// PreviewImport 解析导入内容并标记与项目已有用户故事重复的条目，不写入数据库
+func (s *SpecService) PreviewImport(ctx context.Context, projectID, userID uuid.UUID, req *model.ImportRequirementsRequest) (*importer.Result, error) {
+	if err := s.checkProjectOwner(projectID, userID); err != nil {
+		return nil, err
+	}
+
+	result, err := importer.Parse(req.Content, importer.Options{
+		Format:   importer.Format(req.Format),
+		FileName: req.FileName,
+		Mapping:  req.Mapping,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stories, err := s.listUserStories(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户故事失败: %w", err)
+	}
+	existing := make(map[string]bool, len(stories))
+	for _, story := range stories {
+		existing[importer.TitleHash(story.Title)] = true
+	}
+	result.Duplicates = importer.MarkDuplicates(result.Items, existing)
+
+	return result, nil
+}
+
+// ImportRequirements 导入外部需求：创建一个需求文档，并为每个非重复条目批量创建用户故事
+func (s *SpecService) ImportRequirements(ctx context.Context, projectID, userID uuid.UUID, req *model.ImportRequirementsRequest) (*ImportRequirementsResult, error) {
+	preview, err := s.PreviewImport(ctx, projectID, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool, len(req.Selected))
+	for _, hash := range req.Selected {
+		selected[hash] = true
+	}
+
+	result := &ImportRequirementsResult{Format: preview.Format, Skipped: []*importer.Item{}, Warnings: preview.Warnings}
+	var items []*importer.Item
+	for _, item := range preview.Items {
+		if item.Duplicate || (len(selected) > 0 && !selected[item.TitleHash]) {
+			result.Skipped = append(result.Skipped, item)
+			continue
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("没有可导入的需求条目（共 %d 条，重复 %d 条）", preview.Total, preview.Duplicates)
+	}
+
+	now := time.Now()
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = "导入的需求"
+	}
+
+	titles := make([]string, 0, len(items))
+	for _, item := range items {
+		titles = append(titles, item.Title)
+	}
+	functional, err := json.Marshal(titles)
+	if err != nil {
+		return nil, fmt.Errorf("序列化功能需求失败: %w", err)
+	}
+
+	reqDoc := &model.RequirementsDoc{
+		ID:                        uuid.New(),
+		ProjectID:                 projectID,
+		Content:                   buildImportedRequirementsContent(title, preview.Format, items),
+		Assumptions:               "[]",
+		EdgeCases:                 "[]",
+		FunctionalRequirements:    string(functional),
+		NonFunctionalRequirements: "[]",
+		Version:                   1,
+		CreatedAt:                 now,
+		UpdatedAt:                 now,
+	}
+
+	for _, item := range items {
+		criteria, err := json.Marshal(item.AcceptanceCriteria)
+		if err != nil {
+			return nil, fmt.Errorf("序列化验收标准失败: %w", err)
+		}
+		result.Stories = append(result.Stories, &model.UserStory{
+			ID:                 uuid.New(),
+			RequirementsID:     reqDoc.ID,
+			Title:              item.Title,
+			Description:        item.Description,
+			AcceptanceCriteria: string(criteria),
+			Priority:           item.Priority,
+			StoryPoints:        item.StoryPoints,
+			CreatedAt:          now,
+		})
+	}
+
+	if err := s.saveImportedRequirements(ctx, reqDoc, result.Stories); err != nil {
+		return nil, fmt.Errorf("保存导入的需求失败: %w", err)
+	}
+	result.RequirementsDoc = reqDoc
+
+	if err := s.updateSpecStage(ctx, projectID, model.SpecStageRequirements); err != nil {
+		log.Printf("Warning: failed to update spec stage: %v", err)
+	}
+
+	return result, nil
+}
+
+// saveImportedRequirements 在同一事务中写入需求文档和用户故事
+func (s *SpecService) saveImportedRequirements(ctx context.Context, doc *model.RequirementsDoc, stories []*model.UserStory) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertRequirementsDoc(ctx, tx, doc); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO user_stories (id, requirements_id, title, description, acceptance_criteria,
+			priority, story_points, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	for _, story := range stories {
+		if _, err := tx.ExecContext(ctx, query,
+			story.ID, story.RequirementsID, story.Title, story.Description, story.AcceptanceCriteria,
+			story.Priority, story.StoryPoints, story.CreatedAt,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// buildImportedRequirementsContent 将导入条目整理为需求文档的 Markdown 正文
+func buildImportedRequirementsContent(title string, format importer.Format, items []*importer.Item) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	fmt.Fprintf(&b, "> 从 %s 导入，共 %d 个用户故事\n", format, len(items))
+
+	for i, item := range items {
+		fmt.Fprintf(&b, "\n## %d. %s\n\n", i+1, item.Title)
+		meta := []string{"优先级: " + item.Priority}
+		if item.StoryPoints != nil {
+			meta = append(meta, fmt.Sprintf("故事点: %d", *item.StoryPoints))
+		}
+		if item.SourceRef != "" {
+			meta = append(meta, "来源: "+item.SourceRef)
+		}
+		if len(item.Labels) > 0 {
+			meta = append(meta, "标签: "+strings.Join(item.Labels, ", "))
+		}
+		b.WriteString(strings.Join(meta, " | ") + "\n")
+
+		if item.Description != "" {
+			b.WriteString("\n" + item.Description + "\n")
+		}
+		if len(item.AcceptanceCriteria) > 0 {
+			b.WriteString("\n### 验收标准\n\n")
+			for _, criterion := range item.AcceptanceCriteria {
+				b.WriteString("- " + criterion + "\n")
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai-dev-platform/internal/ai"
+	"ai-dev-platform/internal/model"
+	"ai-dev-platform/internal/prioritization"
+
+	"github.com/google/uuid"
+)
+
+// PrioritizedStory 排序后的用户故事及其评估来源
+type PrioritizedStory struct {
+	*prioritization.Ranked
+	RequirementsID uuid.UUID  `json:"requirements_id"`
+	Source         string     `json:"source,omitempty"` // manual, ai，未评估时为空
+	Rationale      string     `json:"rationale,omitempty"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+}
+
+// PrioritizedBacklog 按优先级排序的用户故事待办列表
+type PrioritizedBacklog struct {
+	Method  prioritization.Method `json:"method"`
+	Total   int                   `json:"total"`
+	Scored  int                   `json:"scored"` // 有评估信息的用户故事数量
+	Stories []*PrioritizedStory   `json:"stories"`
+}
+
+// PrioritySuggestionResult AI建议优先级评估的结果
+type PrioritySuggestionResult struct {
+	Suggested int                 `json:"suggested"` // 写入了AI建议的用户故事数量
+	Remaining int                 `json:"remaining"` // 超出单次上限、未提交给AI的用户故事数量
+	Backlog   *PrioritizedBacklog `json:"backlog"`
+}
+
+// GetPrioritizedBacklog 获取按指定方法排序的用户故事待办列表，requirementsID 为 uuid.Nil 时包含项目全部用户故事
+func (s *SpecService) GetPrioritizedBacklog(ctx context.Context, projectID, userID uuid.UUID, method string, requirementsID uuid.UUID) (*PrioritizedBacklog, error) {
+	if err := s.checkProjectOwner(projectID, userID); err != nil {
+		return nil, err
+	}
+	m, err := prioritization.ParseMethod(method)
+	if err != nil {
+		return nil, err
+	}
+	return s.prioritizedBacklog(ctx, projectID, requirementsID, m)
+}
+
+// UpdateStoryPriority 设置用户故事的优先级评估，有变化时记录变更历史
+func (s *SpecService) UpdateStoryPriority(ctx context.Context, projectID, userID, storyID uuid.UUID, req *model.StoryPriorityRequest) (*model.StoryPriority, error) {
+	if err := s.checkProjectOwner(projectID, userID); err != nil {
+		return nil, err
+	}
+	if _, err := s.projectStory(ctx, projectID, storyID); err != nil {
+		return nil, err
+	}
+
+	inputs := prioritization.Inputs{
+		Reach:           req.Reach,
+		Impact:          req.Impact,
+		Confidence:      req.Confidence,
+		Effort:          req.Effort,
+		BusinessValue:   req.BusinessValue,
+		TimeCriticality: req.TimeCriticality,
+		RiskReduction:   req.RiskReduction,
+		JobSize:         req.JobSize,
+		MoSCoW:          prioritization.NormalizeMoSCoW(req.MoSCoW),
+	}
+	if err := inputs.Validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetStoryPriority(storyID)
+	if err != nil {
+		return nil, err
+	}
+	return s.saveStoryPriority(existing, projectID, storyID, userID, inputs, model.PrioritySourceManual, "", strings.TrimSpace(req.Note))
+}
+
+// SuggestPriorities 由AI为用户故事给出初始评估值，供用户在此基础上调整
+func (s *SpecService) SuggestPriorities(ctx context.Context, projectID, userID uuid.UUID, req *model.SuggestPrioritiesRequest) (*PrioritySuggestionResult, error) {
+	if err := s.checkProjectOwner(projectID, userID); err != nil {
+		return nil, err
+	}
+	if s.aiManager == nil {
+		return nil, fmt.Errorf("AI服务未初始化")
+	}
+
+	stories, err := s.listUserStories(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户故事失败: %w", err)
+	}
+	priorities, err := s.storyPriorities(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []*model.UserStory
+	var items []prioritization.Item
+	for _, story := range stories {
+		existing := priorities[story.ID]
+		if existing != nil && !(req.Overwrite && existing.Source == model.PrioritySourceAI) {
+			continue
+		}
+		pending = append(pending, story)
+		items = append(items, prioritization.Item{ID: story.ID.String(), Title: story.Title, Description: story.Description})
+	}
+
+	result := &PrioritySuggestionResult{}
+	if len(items) > prioritization.MaxAIStories {
+		result.Remaining = len(items) - prioritization.MaxAIStories
+		pending, items = pending[:prioritization.MaxAIStories], items[:prioritization.MaxAIStories]
+	}
+
+	if len(items) > 0 {
+		provider := ai.ProviderOpenAI
+		if req.Provider != "" {
+			provider = ai.AIProvider(req.Provider)
+		}
+		response, err := s.aiManager.ProjectChat(s.glossaryContext(ctx, projectID), prioritization.BuildSuggestionPrompt(items), "", provider)
+		if err != nil {
+			return nil, fmt.Errorf("AI评估建议失败: %w", err)
+		}
+		jsonStr := s.extractJSON(response.Message)
+		if jsonStr == "" {
+			return nil, fmt.Errorf("no valid JSON found in response")
+		}
+		suggestions, err := prioritization.ParseSuggestions(jsonStr, len(items))
+		if err != nil {
+			return nil, err
+		}
+
+		for i, story := range pending {
+			suggestion, ok := suggestions[i]
+			if !ok {
+				continue
+			}
+			if _, err := s.saveStoryPriority(priorities[story.ID], projectID, story.ID, userID,
+				suggestion.Inputs, model.PrioritySourceAI, suggestion.Rationale, "AI建议"); err != nil {
+				return nil, err
+			}
+			result.Suggested++
+		}
+	}
+
+	if result.Backlog, err = s.prioritizedBacklog(ctx, projectID, uuid.Nil, prioritization.MethodRICE); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetStoryPriorityHistory 获取用户故事的优先级变更历史，最新的在前
+func (s *SpecService) GetStoryPriorityHistory(ctx context.Context, projectID, userID, storyID uuid.UUID) ([]*model.StoryPriorityChange, error) {
+	if err := s.checkProjectOwner(projectID, userID); err != nil {
+		return nil, err
+	}
+	if _, err := s.projectStory(ctx, projectID, storyID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetStoryPriorityChanges(storyID)
+}
+
+// storyPriorityPrompt 生成任务分解提示词中的用户故事优先级段落，没有评估信息或查询失败时返回空字符串
+func (s *SpecService) storyPriorityPrompt(ctx context.Context, projectID, requirementsID uuid.UUID, method string) string {
+	if s.repo == nil {
+		return ""
+	}
+	m, err := prioritization.ParseMethod(method)
+	if err != nil {
+		log.Printf("Warning: %v, falling back to %s", err, prioritization.MethodRICE)
+		m = prioritization.MethodRICE
+	}
+
+	backlog, err := s.prioritizedBacklog(ctx, projectID, requirementsID, m)
+	if err != nil {
+		log.Printf("Warning: failed to rank user stories: %v", err)
+		return ""
+	}
+	ranked := make([]*prioritization.Ranked, 0, len(backlog.Stories))
+	for _, story := range backlog.Stories {
+		ranked = append(ranked, story.Ranked)
+	}
+	return prioritization.PromptSection(ranked, m)
+}
+
+// prioritizedBacklog 排序项目（或指定需求文档）的用户故事
+func (s *SpecService) prioritizedBacklog(ctx context.Context, projectID, requirementsID uuid.UUID, method prioritization.Method) (*PrioritizedBacklog, error) {
+	stories, err := s.listUserStories(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户故事失败: %w", err)
+	}
+	priorities, err := s.storyPriorities(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*model.UserStory, len(stories))
+	var items []prioritization.Item
+	for _, story := range stories {
+		if requirementsID != uuid.Nil && story.RequirementsID != requirementsID {
+			continue
+		}
+		byID[story.ID.String()] = story
+		items = append(items, prioritization.Item{
+			ID:     story.ID.String(),
+			Title:  story.Title,
+			Inputs: priorityInputs(priorities[story.ID]),
+		})
+	}
+
+	backlog := &PrioritizedBacklog{Method: method, Stories: []*PrioritizedStory{}}
+	for _, ranked := range prioritization.Rank(items, method) {
+		story := byID[ranked.ID]
+		entry := &PrioritizedStory{Ranked: ranked, RequirementsID: story.RequirementsID}
+		if priority := priorities[story.ID]; priority != nil {
+			entry.Source = priority.Source
+			entry.Rationale = priority.Rationale
+			entry.UpdatedAt = &priority.UpdatedAt
+		}
+		if ranked.Scored() {
+			backlog.Scored++
+		}
+		backlog.Stories = append(backlog.Stories, entry)
+	}
+	backlog.Total = len(backlog.Stories)
+	return backlog, nil
+}
+
+// storyPriorities 项目中用户故事的优先级评估，以用户故事ID为键
+func (s *SpecService) storyPriorities(projectID uuid.UUID) (map[uuid.UUID]*model.StoryPriority, error) {
+	priorities, err := s.repo.GetStoryPriorities(projectID)
+	if err != nil {
+		return nil, err
+	}
+	byStory := make(map[uuid.UUID]*model.StoryPriority, len(priorities))
+	for _, priority := range priorities {
+		byStory[priority.StoryID] = priority
+	}
+	return byStory, nil
+}
+
+// projectStory 获取项目中的用户故事
+func (s *SpecService) projectStory(ctx context.Context, projectID, storyID uuid.UUID) (*model.UserStory, error) {
+	stories, err := s.listUserStories(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户故事失败: %w", err)
+	}
+	for _, story := range stories {
+		if story.ID == storyID {
+			return story, nil
+		}
+	}
+	return nil, fmt.Errorf("用户故事不存在或不属于该项目")
+}
+
+// saveStoryPriority 保存评估输入和重新计算的分数，输入和来源都没有变化时不写入
+func (s *SpecService) saveStoryPriority(existing *model.StoryPriority, projectID, storyID, userID uuid.UUID, inputs prioritization.Inputs, source, rationale, note string) (*model.StoryPriority, error) {
+	changes := prioritization.Diff(priorityInputs(existing), inputs)
+	if existing != nil && len(changes) == 0 && existing.Source == source {
+		return existing, nil
+	}
+
+	now := time.Now()
+	priority := existing
+	if priority == nil {
+		priority = &model.StoryPriority{StoryID: storyID, ProjectID: projectID, CreatedAt: now}
+	}
+	priority.MoSCoW = inputs.MoSCoW
+	priority.Reach, priority.Impact, priority.Confidence, priority.Effort = inputs.Reach, inputs.Impact, inputs.Confidence, inputs.Effort
+	priority.BusinessValue, priority.TimeCriticality = inputs.BusinessValue, inputs.TimeCriticality
+	priority.RiskReduction, priority.JobSize = inputs.RiskReduction, inputs.JobSize
+	priority.RICEScore, priority.WSJFScore = nil, nil
+	if v, ok := prioritization.RICE(inputs); ok {
+		priority.RICEScore = &v
+	}
+	if v, ok := prioritization.WSJF(inputs); ok {
+		priority.WSJFScore = &v
+	}
+	priority.Source = source
+	priority.Rationale = rationale
+	priority.UpdatedBy = userID
+	priority.UpdatedAt = now
+
+	if changes == nil {
+		changes = []prioritization.FieldChange{}
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return nil, fmt.Errorf("序列化优先级变更失败: %w", err)
+	}
+	change := &model.StoryPriorityChange{
+		ChangeID:  uuid.New(),
+		StoryID:   storyID,
+		ProjectID: projectID,
+		Source:    source,
+		Changes:   string(changesJSON),
+		RICEScore: priority.RICEScore,
+		WSJFScore: priority.WSJFScore,
+		Note:      note,
+		ChangedBy: userID,
+		CreatedAt: now,
+	}
+
+	if err := s.repo.SaveStoryPriority(priority, change); err != nil {
+		return nil, err
+	}
+	return priority, nil
+}
+
+// priorityInputs 评估记录中的输入，未评估时返回空输入
+func priorityInputs(priority *model.StoryPriority) prioritization.Inputs {
+	if priority == nil {
+		return prioritization.Inputs{}
+	}
+	return prioritization.Inputs{
+		Reach:           priority.Reach,
+		Impact:          priority.Impact,
+		Confidence:      priority.Confidence,
+		Effort:          priority.Effort,
+		BusinessValue:   priority.BusinessValue,
+		TimeCriticality: priority.TimeCriticality,
+		RiskReduction:   priority.RiskReduction,
+		JobSize:         priority.JobSize,
+		MoSCoW:          priority.MoSCoW,
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"ai-dev-platform/internal/ai"
 	"ai-dev-platform/internal/model"
 	"ai-dev-platform/internal/repository"
+	"ai-dev-platform/internal/traceability"
 	"github.com/google/uuid"
 )
 
@@ -29,6 +30,14 @@ func NewSpecService(db *sql.DB, aiManager *ai.AIManager, repo *repository.MySQLR
 	}
 }
 
+// glossaryContext 在上下文中附加项目术语表，使生成的文档统一使用规范术语
+func (s *SpecService) glossaryContext(ctx context.Context, projectID uuid.UUID) context.Context {
+	if s.repo == nil {
+		return ctx
+	}
+	return withProjectGlossary(ctx, s.repo, projectID)
+}
+
 // InitProjectSpec 初始化项目 Spec
 func (s *SpecService) InitProjectSpec(ctx context.Context, projectID uuid.UUID) (*model.ProjectSpec, error) {
 	specID := uuid.New()
@@ -87,7 +96,7 @@ func (s *SpecService) GenerateRequirements(ctx context.Context, userID uuid.UUID
 	prompt := s.buildRequirementsPrompt(req)
 	
 	// 调用AI生成需求文档（使用ProjectChat作为通用接口）
-	response, err := s.aiManager.ProjectChat(ctx, prompt, "", ai.ProviderOpenAI)
+	response, err := s.aiManager.ProjectChat(s.glossaryContext(ctx, req.ProjectID), prompt, "", ai.ProviderOpenAI)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate requirements: %w", err)
 	}
@@ -123,7 +132,7 @@ func (s *SpecService) GenerateDesign(ctx context.Context, userID uuid.UUID, req
 	prompt := s.buildDesignPrompt(reqDoc, req)
 	
 	// 调用AI生成设计文档
-	response, err := s.aiManager.ProjectChat(ctx, prompt, "", ai.ProviderOpenAI)
+	response, err := s.aiManager.ProjectChat(s.glossaryContext(ctx, req.ProjectID), prompt, "", ai.ProviderOpenAI)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate design: %w", err)
 	}
@@ -138,6 +147,8 @@ func (s *SpecService) GenerateDesign(ctx context.Context, userID uuid.UUID, req
 	if err := s.saveDesignDoc(ctx, designDoc); err != nil {
 		return nil, fmt.Errorf("failed to save design: %w", err)
 	}
+	s.recordSpecDerivation(req.ProjectID, model.ArtifactTypeDesignDoc, designDoc.ID,
+		specUpstream(model.ArtifactTypeRequirementsDoc, reqDoc.ID, reqDoc.Version))
 
 	// 更新项目 spec 状态
 	if err := s.updateSpecStage(ctx, req.ProjectID, model.SpecStageDesign); err != nil {
@@ -160,11 +171,12 @@ func (s *SpecService) GenerateTasks(ctx context.Context, userID uuid.UUID, req *
 		return nil, fmt.Errorf("failed to get design doc: %w", err)
 	}
 
-	// 构建任务分析的提示词
-	prompt := s.buildTasksPrompt(reqDoc, designDoc, req)
+	// 构建任务分析的提示词，按用户故事优先级排序分解
+	priorities := s.storyPriorityPrompt(ctx, req.ProjectID, reqDoc.ID, req.PriorityMethod)
+	prompt := s.buildTasksPrompt(reqDoc, designDoc, req, priorities)
 	
 	// 调用AI生成任务文档
-	response, err := s.aiManager.ProjectChat(ctx, prompt, "", ai.ProviderOpenAI)
+	response, err := s.aiManager.ProjectChat(s.glossaryContext(ctx, req.ProjectID), prompt, "", ai.ProviderOpenAI)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate tasks: %w", err)
 	}
@@ -179,6 +191,9 @@ func (s *SpecService) GenerateTasks(ctx context.Context, userID uuid.UUID, req *
 	if err := s.saveTaskListDoc(ctx, taskDoc); err != nil {
 		return nil, fmt.Errorf("failed to save tasks: %w", err)
 	}
+	s.recordSpecDerivation(req.ProjectID, model.ArtifactTypeTaskListDoc, taskDoc.ID,
+		specUpstream(model.ArtifactTypeRequirementsDoc, reqDoc.ID, reqDoc.Version),
+		specUpstream(model.ArtifactTypeDesignDoc, designDoc.ID, designDoc.Version))
 
 	// 更新项目 spec 状态
 	if err := s.updateSpecStage(ctx, req.ProjectID, model.SpecStageTasks); err != nil {
@@ -305,7 +320,7 @@ func (s *SpecService) buildDesignPrompt(reqDoc *model.RequirementsDoc, req *mode
 }
 
 // buildTasksPrompt 构建任务分析提示词
-func (s *SpecService) buildTasksPrompt(reqDoc *model.RequirementsDoc, designDoc *model.DesignDoc, req *model.GenerateTasksRequest) string {
+func (s *SpecService) buildTasksPrompt(reqDoc *model.RequirementsDoc, designDoc *model.DesignDoc, req *model.GenerateTasksRequest, priorities string) string {
 	teamSize := 3
 	sprintDuration := 2
 	
@@ -329,6 +344,7 @@ func (s *SpecService) buildTasksPrompt(reqDoc *model.RequirementsDoc, designDoc
 - 团队大小：%d 人
 - Sprint 周期：%d 周
 `, reqDoc.Content, designDoc.Content, teamSize, sprintDuration)
+	prompt += priorities
 
 	prompt += `
 请按照以下格式生成任务文档：
@@ -541,6 +557,62 @@ func (s *SpecService) parseTasksResponse(content string, projectID uuid.UUID) (*
 	return taskDoc, nil
 }
 
+// LintRequirementsDoc 对需求文档的功能需求进行EARS检查并保存报告
+func (s *SpecService) LintRequirementsDoc(ctx context.Context, projectID, userID, reqID uuid.UUID) (*EARSLintResult, error) {
+	if err := s.checkProjectOwner(projectID, userID); err != nil {
+		return nil, err
+	}
+	reqDoc, err := s.getRequirementsDoc(ctx, reqID)
+	if err != nil {
+		return nil, fmt.Errorf("获取需求文档失败: %w", err)
+	}
+	if reqDoc.ProjectID != projectID {
+		return nil, fmt.Errorf("需求文档不属于该项目")
+	}
+
+	var sentences []string
+	if reqDoc.FunctionalRequirements != "" {
+		if err := json.Unmarshal([]byte(reqDoc.FunctionalRequirements), &sentences); err != nil {
+			return nil, fmt.Errorf("解析功能需求失败: %w", err)
+		}
+	}
+	if len(sentences) == 0 {
+		return nil, fmt.Errorf("需求文档没有功能需求")
+	}
+
+	return saveEARSLintReport(s.repo, reqDoc.ProjectID, model.EARSSourceRequirementsDoc, reqDoc.ID, sentences)
+}
+
+// GetTraceabilityMatrix 构建项目的需求追踪矩阵（需求文档 → 用户故事 → 开发任务 → 测试用例，及各阶段图表与文档）
+func (s *SpecService) GetTraceabilityMatrix(ctx context.Context, projectID, userID uuid.UUID) (*traceability.Matrix, error) {
+	if err := s.checkProjectOwner(projectID, userID); err != nil {
+		return nil, err
+	}
+
+	var err error
+	input := &traceability.Input{ProjectID: projectID}
+	if input.Requirements, err = s.listRequirementsDocs(ctx, projectID); err != nil {
+		return nil, fmt.Errorf("查询需求文档失败: %w", err)
+	}
+	if input.Stories, err = s.listUserStories(ctx, projectID); err != nil {
+		return nil, fmt.Errorf("查询用户故事失败: %w", err)
+	}
+	if input.Tasks, err = s.listDevelopmentTasks(ctx, projectID); err != nil {
+		return nil, fmt.Errorf("查询开发任务失败: %w", err)
+	}
+	if input.TestCases, err = s.listTestCases(ctx, projectID); err != nil {
+		return nil, fmt.Errorf("查询测试用例失败: %w", err)
+	}
+	if input.Diagrams, err = s.repo.GetPUMLDiagramsByProjectID(projectID); err != nil {
+		return nil, err
+	}
+	if input.Documents, err = s.repo.GetDocumentsByProjectID(projectID); err != nil {
+		return nil, err
+	}
+
+	return traceability.Build(input), nil
+}
+
 // extractJSON 从响应中提取JSON部分
 func (s *SpecService) extractJSON(content string) string {
 	// 寻找JSON代码块
@@ -562,14 +634,23 @@ func (s *SpecService) extractJSON(content string) string {
 }
 
 // 数据库操作方法
+// sqlExecer *sql.DB 与 *sql.Tx 的公共写入接口
+type sqlExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
 func (s *SpecService) saveRequirementsDoc(ctx context.Context, doc *model.RequirementsDoc) error {
+	return insertRequirementsDoc(ctx, s.db, doc)
+}
+
+func insertRequirementsDoc(ctx context.Context, exec sqlExecer, doc *model.RequirementsDoc) error {
 	query := `
 		INSERT INTO requirements_docs (id, project_id, content, assumptions, edge_cases, 
 			functional_requirements, non_functional_requirements, version, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	
-	_, err := s.db.ExecContext(ctx, query,
+	_, err := exec.ExecContext(ctx, query,
 		doc.ID, doc.ProjectID, doc.Content, doc.Assumptions, doc.EdgeCases,
 		doc.FunctionalRequirements, doc.NonFunctionalRequirements,
 		doc.Version, doc.CreatedAt, doc.UpdatedAt,
@@ -627,6 +708,129 @@ func (s *SpecService) getRequirementsDoc(ctx context.Context, reqID uuid.UUID) (
 	return doc, nil
 }
 
+func (s *SpecService) listRequirementsDocs(ctx context.Context, projectID uuid.UUID) ([]*model.RequirementsDoc, error) {
+	query := `
+		SELECT id, project_id, content, functional_requirements, version, created_at, updated_at
+		FROM requirements_docs
+		WHERE project_id = ?
+		ORDER BY created_at
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []*model.RequirementsDoc
+	for rows.Next() {
+		doc := &model.RequirementsDoc{}
+		var functional sql.NullString
+		if err := rows.Scan(&doc.ID, &doc.ProjectID, &doc.Content, &functional, &doc.Version, &doc.CreatedAt, &doc.UpdatedAt); err != nil {
+			return nil, err
+		}
+		doc.FunctionalRequirements = functional.String
+		docs = append(docs, doc)
+	}
+	return docs, rows.Err()
+}
+
+func (s *SpecService) listUserStories(ctx context.Context, projectID uuid.UUID) ([]*model.UserStory, error) {
+	query := `
+		SELECT us.id, us.requirements_id, us.title, us.description, us.priority, us.created_at
+		FROM user_stories us
+		JOIN requirements_docs rd ON rd.id = us.requirements_id
+		WHERE rd.project_id = ?
+		ORDER BY us.created_at
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stories []*model.UserStory
+	for rows.Next() {
+		story := &model.UserStory{}
+		var description sql.NullString
+		if err := rows.Scan(&story.ID, &story.RequirementsID, &story.Title, &description, &story.Priority, &story.CreatedAt); err != nil {
+			return nil, err
+		}
+		story.Description = description.String
+		stories = append(stories, story)
+	}
+	return stories, rows.Err()
+}
+
+func (s *SpecService) listDevelopmentTasks(ctx context.Context, projectID uuid.UUID) ([]*model.DevelopmentTask, error) {
+	query := `
+		SELECT dt.id, dt.task_list_id, dt.title, dt.status, dt.user_story_id, dt.created_at
+		FROM development_tasks dt
+		JOIN task_list_docs tl ON tl.id = dt.task_list_id
+		WHERE tl.project_id = ?
+		ORDER BY dt.created_at
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []*model.DevelopmentTask
+	for rows.Next() {
+		task := &model.DevelopmentTask{}
+		var storyID sql.NullString
+		if err := rows.Scan(&task.ID, &task.TaskListID, &task.Title, &task.Status, &storyID, &task.CreatedAt); err != nil {
+			return nil, err
+		}
+		task.UserStoryID = parseNullUUID(storyID)
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+func (s *SpecService) listTestCases(ctx context.Context, projectID uuid.UUID) ([]*model.TestCase, error) {
+	query := `
+		SELECT tc.id, tc.task_list_id, tc.title, tc.type, tc.task_id, tc.created_at
+		FROM test_cases tc
+		JOIN task_list_docs tl ON tl.id = tc.task_list_id
+		WHERE tl.project_id = ?
+		ORDER BY tc.created_at
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var testCases []*model.TestCase
+	for rows.Next() {
+		testCase := &model.TestCase{}
+		var taskID sql.NullString
+		if err := rows.Scan(&testCase.ID, &testCase.TaskListID, &testCase.Title, &testCase.Type, &taskID, &testCase.CreatedAt); err != nil {
+			return nil, err
+		}
+		testCase.TaskID = parseNullUUID(taskID)
+		testCases = append(testCases, testCase)
+	}
+	return testCases, rows.Err()
+}
+
+// parseNullUUID 解析可为空的UUID列
+func parseNullUUID(value sql.NullString) *uuid.UUID {
+	if !value.Valid || value.String == "" {
+		return nil
+	}
+	id, err := uuid.Parse(value.String)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
 func (s *SpecService) getDesignDoc(ctx context.Context, designID uuid.UUID) (*model.DesignDoc, error) {
 	query := `
 		SELECT id, project_id, content, database_schema, architecture_notes,
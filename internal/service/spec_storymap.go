@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai-dev-platform/internal/ai"
+	"ai-dev-platform/internal/model"
+	"ai-dev-platform/internal/prioritization"
+	"ai-dev-platform/internal/storymap"
+
+	"github.com/google/uuid"
+)
+
+// StorySplitResult 用户故事拆分结果
+type StorySplitResult struct {
+	Parent   *model.UserStory    `json:"parent"`
+	Pattern  storymap.Pattern    `json:"pattern"`
+	Source   string              `json:"source"` // rules, ai
+	Stories  []*model.UserStory  `json:"stories"`
+	Coverage []storymap.Coverage `json:"coverage"` // 父故事验收标准在子故事中的分布，children 为 stories 的下标
+	Warnings []string            `json:"warnings,omitempty"`
+	Saved    bool                `json:"saved"`
+}
+
+// errStoryAlreadySplit 父故事已有子故事
+var errStoryAlreadySplit = errors.New("用户故事已拆分过，请拆分其子故事")
+
+// SplitStory 按拆分模式把用户故事拆成子故事，子故事继承父故事的需求文档和优先级并记录拆分关系；
+// 已拆分过的故事不能再次拆分，可以继续拆分其子故事
+func (s *SpecService) SplitStory(ctx context.Context, projectID, userID, storyID uuid.UUID, req *model.SplitStoryRequest) (*StorySplitResult, error) {
+	if err := s.checkProjectOwner(projectID, userID); err != nil {
+		return nil, err
+	}
+	pattern, err := storymap.ParsePattern(req.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	parent, criteria, err := s.getUserStory(ctx, projectID, storyID)
+	if err != nil {
+		return nil, err
+	}
+	splits, err := s.repo.GetStorySplits(projectID)
+	if err != nil {
+		return nil, err
+	}
+	for _, split := range splits {
+		if split.ParentStoryID == storyID {
+			return nil, errStoryAlreadySplit
+		}
+	}
+
+	story := storymap.Story{Title: parent.Title, Description: parent.Description, AcceptanceCriteria: criteria}
+	opts := storymap.Options{Steps: req.Steps, Variations: req.Variations, Entity: strings.TrimSpace(req.Entity)}
+	if (pattern == storymap.PatternWorkflow && len(opts.Steps) == 0) || (pattern == storymap.PatternCRUD && opts.Entity == "") {
+		processes, entities := s.projectAnalysisContext(projectID)
+		if pattern == storymap.PatternWorkflow {
+			if process := storymap.MatchProcess(processes, story); process != nil {
+				opts.Steps = process.Steps
+			}
+		} else {
+			opts.Entity = storymap.MatchEntity(entities, story)
+		}
+	}
+
+	result := &StorySplitResult{Parent: parent, Pattern: pattern, Source: "rules"}
+	var split *storymap.Result
+	if req.UseAI {
+		if s.aiManager == nil {
+			return nil, fmt.Errorf("AI服务未初始化")
+		}
+		provider := ai.ProviderOpenAI
+		if req.Provider != "" {
+			provider = ai.AIProvider(req.Provider)
+		}
+		response, err := s.aiManager.ProjectChat(s.glossaryContext(ctx, projectID), storymap.BuildSplitPrompt(story, pattern, opts), "", provider)
+		if err != nil {
+			return nil, fmt.Errorf("AI拆分用户故事失败: %w", err)
+		}
+		jsonStr := s.extractJSON(response.Message)
+		if jsonStr == "" {
+			return nil, fmt.Errorf("no valid JSON found in response")
+		}
+		children, err := storymap.ParseSplit(jsonStr)
+		if err != nil {
+			return nil, err
+		}
+		split = storymap.Reconcile(story, pattern, children)
+		result.Source = "ai"
+	} else if split, err = storymap.Split(story, pattern, opts); err != nil {
+		return nil, err
+	}
+	result.Coverage, result.Warnings = split.Coverage, split.Warnings
+
+	now := time.Now()
+	var links []*model.StorySplit
+	for i, child := range split.Children {
+		criteriaJSON, err := json.Marshal(child.AcceptanceCriteria)
+		if err != nil {
+			return nil, fmt.Errorf("序列化验收标准失败: %w", err)
+		}
+		childStory := &model.UserStory{
+			ID:                 uuid.New(),
+			RequirementsID:     parent.RequirementsID,
+			Title:              child.Title,
+			Description:        child.Description,
+			AcceptanceCriteria: string(criteriaJSON),
+			Priority:           parent.Priority,
+			CreatedAt:          now,
+		}
+		result.Stories = append(result.Stories, childStory)
+		links = append(links, &model.StorySplit{
+			ChildStoryID:  childStory.ID,
+			ParentStoryID: parent.ID,
+			ProjectID:     projectID,
+			Pattern:       string(pattern),
+			Position:      i,
+			CreatedBy:     userID,
+			CreatedAt:     now,
+		})
+	}
+
+	if req.Preview {
+		return result, nil
+	}
+	if err := s.saveSplitStories(ctx, parent.ID, result.Stories, links); err != nil {
+		if errors.Is(err, errStoryAlreadySplit) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("保存拆分的用户故事失败: %w", err)
+	}
+	result.Saved = true
+	return result, nil
+}
+
+// GetStoryMap 构建用户故事地图：骨干为需求分析中的业务流程步骤，发布版本由 MoSCoW 分组（未评估时为用户故事优先级）确定；
+// requirementsID 为 uuid.Nil 时包含项目全部用户故事
+func (s *SpecService) GetStoryMap(ctx context.Context, projectID, userID, requirementsID uuid.UUID) (*storymap.Map, error) {
+	if err := s.checkProjectOwner(projectID, userID); err != nil {
+		return nil, err
+	}
+
+	stories, err := s.listUserStories(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户故事失败: %w", err)
+	}
+	byID := make(map[string]*model.UserStory, len(stories))
+	for _, story := range stories {
+		byID[story.ID.String()] = story
+	}
+	backlog, err := s.prioritizedBacklog(ctx, projectID, requirementsID, prioritization.MethodMoSCoW)
+	if err != nil {
+		return nil, err
+	}
+	splits, err := s.repo.GetStorySplits(projectID)
+	if err != nil {
+		return nil, err
+	}
+	parents := make(map[uuid.UUID]uuid.UUID, len(splits))
+	for _, split := range splits {
+		parents[split.ChildStoryID] = split.ParentStoryID
+	}
+
+	var cards []*storymap.Card
+	for _, entry := range backlog.Stories {
+		story := byID[entry.ID]
+		if story == nil {
+			continue
+		}
+		card := &storymap.Card{
+			ID:          entry.ID,
+			Title:       entry.Title,
+			Description: story.Description,
+			Release:     storymap.ReleaseFor(entry.MoSCoW, story.Priority),
+		}
+		if entry.Scored() {
+			card.Rank = entry.Rank
+		}
+		if parentID, ok := parents[story.ID]; ok {
+			card.ParentID = parentID.String()
+		}
+		cards = append(cards, card)
+	}
+
+	processes, _ := s.projectAnalysisContext(projectID)
+	return storymap.Build(processes, cards), nil
+}
+
+// projectAnalysisContext 项目需求分析中的业务流程和数据实体，无法读取时返回空
+func (s *SpecService) projectAnalysisContext(projectID uuid.UUID) ([]ai.BusinessProcess, []ai.DataEntity) {
+	requirements, err := s.repo.GetRequirementAnalysesByProject(projectID)
+	if err != nil {
+		log.Printf("Warning: failed to load requirement analyses: %v", err)
+		return nil, nil
+	}
+
+	var processes []ai.BusinessProcess
+	var entities []ai.DataEntity
+	for _, requirement := range requirements {
+		analysis, err := requirementToAnalysis(requirement)
+		if err != nil {
+			log.Printf("Warning: skip requirement analysis %s: %v", requirement.RequirementID, err)
+			continue
+		}
+		processes = append(processes, analysis.BusinessProcesses...)
+		entities = append(entities, analysis.DataEntities...)
+	}
+	return processes, entities
+}
+
+// getUserStory 获取项目中的用户故事及其验收标准
+func (s *SpecService) getUserStory(ctx context.Context, projectID, storyID uuid.UUID) (*model.UserStory, []string, error) {
+	query := `
+		SELECT us.id, us.requirements_id, us.title, us.description, us.acceptance_criteria,
+			us.priority, us.story_points, us.created_at
+		FROM user_stories us
+		JOIN requirements_docs rd ON rd.id = us.requirements_id
+		WHERE us.id = ? AND rd.project_id = ?
+	`
+
+	story := &model.UserStory{}
+	var description, acceptanceCriteria sql.NullString
+	var storyPoints sql.NullInt64
+	err := s.db.QueryRowContext(ctx, query, storyID, projectID).Scan(
+		&story.ID, &story.RequirementsID, &story.Title, &description, &acceptanceCriteria,
+		&story.Priority, &storyPoints, &story.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil, fmt.Errorf("用户故事不存在或不属于该项目")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询用户故事失败: %w", err)
+	}
+	story.Description = description.String
+	story.AcceptanceCriteria = acceptanceCriteria.String
+	if storyPoints.Valid {
+		points := int(storyPoints.Int64)
+		story.StoryPoints = &points
+	}
+
+	var criteria []string
+	if story.AcceptanceCriteria != "" {
+		if err := json.Unmarshal([]byte(story.AcceptanceCriteria), &criteria); err != nil {
+			// 非 JSON 格式的验收标准按行拆分
+			criteria = strings.Split(story.AcceptanceCriteria, "\n")
+		}
+	}
+	return story, criteria, nil
+}
+
+// saveSplitStories 在同一事务中写入子故事和拆分关系；锁定父故事后再次检查是否已拆分，
+// 避免并发拆分同一故事
+func (s *SpecService) saveSplitStories(ctx context.Context, parentID uuid.UUID, stories []*model.UserStory, links []*model.StorySplit) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var locked string
+	if err := tx.QueryRowContext(ctx, `SELECT id FROM user_stories WHERE id = ? FOR UPDATE`, parentID).Scan(&locked); err != nil {
+		return err
+	}
+	var count int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM story_splits WHERE parent_story_id = ?`, parentID).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return errStoryAlreadySplit
+	}
+
+	query := `
+		INSERT INTO user_stories (id, requirements_id, title, description, acceptance_criteria,
+			priority, story_points, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	for _, story := range stories {
+		if _, err := tx.ExecContext(ctx, query,
+			story.ID, story.RequirementsID, story.Title, story.Description, story.AcceptanceCriteria,
+			story.Priority, story.StoryPoints, story.CreatedAt,
+		); err != nil {
+			return err
+		}
+	}
+
+	linkQuery := `
+		INSERT INTO story_splits (child_story_id, parent_story_id, project_id, pattern, position, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	for _, link := range links {
+		if _, err := tx.ExecContext(ctx, linkQuery,
+			link.ChildStoryID, link.ParentStoryID, link.ProjectID, link.Pattern, link.Position, link.CreatedBy, link.CreatedAt,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -1,11 +1,14 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"ai-dev-platform/internal/config"
 	"ai-dev-platform/internal/model"
+	"ai-dev-platform/internal/repository"
 	"ai-dev-platform/internal/utils"
 
 	"github.com/google/uuid"
@@ -93,6 +96,12 @@ func (m *MockRepository) GetRequirementByProjectID(projectID uuid.UUID) (*model.
 func (m *MockRepository) UpdateRequirementAnalysis(requirement *model.Requirement) error {
 	return nil
 }
+func (m *MockRepository) GetRequirementVersions(requirementID uuid.UUID) ([]*model.RequirementVersion, error) {
+	return nil, nil
+}
+func (m *MockRepository) GetRequirementVersion(requirementID uuid.UUID, version int) (*model.RequirementVersion, error) {
+	return nil, nil
+}
 func (m *MockRepository) CreateChatSession(session *model.ChatSession) error { return nil }
 func (m *MockRepository) GetChatSessionByProjectID(projectID uuid.UUID) (*model.ChatSession, error) {
 	return nil, nil
@@ -113,6 +122,24 @@ func (m *MockRepository) GetPUMLDiagramsByProjectID(projectID uuid.UUID) ([]*mod
 }
 func (m *MockRepository) UpdatePUMLDiagram(diagram *model.PUMLDiagram) error { return nil }
 func (m *MockRepository) DeletePUMLDiagram(diagramID uuid.UUID) error       { return nil }
+func (m *MockRepository) CreateVersionedPUMLDiagram(diagram *model.PUMLDiagram, authorID uuid.UUID, note string) error {
+	args := m.Called(diagram, authorID, note)
+	return args.Error(0)
+}
+func (m *MockRepository) UpdateVersionedPUMLDiagram(diagram *model.PUMLDiagram, authorID uuid.UUID, note string) error {
+	args := m.Called(diagram, authorID, note)
+	return args.Error(0)
+}
+func (m *MockRepository) GetPUMLDiagramVersions(diagramID uuid.UUID) ([]*model.PUMLDiagramVersion, error) {
+	return nil, nil
+}
+func (m *MockRepository) GetPUMLDiagramVersion(diagramID uuid.UUID, version int) (*model.PUMLDiagramVersion, error) {
+	args := m.Called(diagramID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PUMLDiagramVersion), args.Error(1)
+}
 func (m *MockRepository) CreateDocument(document *model.Document) error     { return nil }
 func (m *MockRepository) GetDocumentsByProjectID(projectID uuid.UUID) ([]*model.Document, error) {
 	return nil, nil
@@ -194,7 +221,11 @@ func (m *MockRepository) GetChatMessages(sessionID uuid.UUID) ([]*model.ChatMess
 	return nil, nil
 }
 func (m *MockRepository) GetPUMLDiagram(diagramID uuid.UUID) (*model.PUMLDiagram, error) {
-	return nil, nil
+	args := m.Called(diagramID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PUMLDiagram), args.Error(1)
 }
 func (m *MockRepository) GetDocument(documentID uuid.UUID) (*model.Document, error) {
 	return nil, nil
@@ -202,6 +233,95 @@ func (m *MockRepository) GetDocument(documentID uuid.UUID) (*model.Document, err
 func (m *MockRepository) GetQuestions(requirementID uuid.UUID) ([]*model.Question, error) {
 	return nil, nil
 }
+func (m *MockRepository) GetQuestionByID(questionID uuid.UUID) (*model.Question, error) {
+	return nil, nil
+}
+func (m *MockRepository) SkipQuestion(questionID uuid.UUID) error {
+	return nil
+}
+func (m *MockRepository) CreateRequirementRefinement(refinement *model.RequirementRefinement) error {
+	return nil
+}
+func (m *MockRepository) GetRequirementRefinements(requirementID uuid.UUID) ([]*model.RequirementRefinement, error) {
+	return nil, nil
+}
+func (m *MockRepository) CreateEARSLintReport(report *model.EARSLintReport) error {
+	return nil
+}
+func (m *MockRepository) GetLatestEARSLintReport(sourceType string, sourceID uuid.UUID) (*model.EARSLintReport, error) {
+	return nil, nil
+}
+func (m *MockRepository) GetRequirementFindings(projectID uuid.UUID, status string) ([]*model.RequirementFinding, error) {
+	return nil, nil
+}
+func (m *MockRepository) GetRequirementFinding(findingID uuid.UUID) (*model.RequirementFinding, error) {
+	return nil, nil
+}
+func (m *MockRepository) SaveRequirementFinding(finding *model.RequirementFinding) error {
+	return nil
+}
+func (m *MockRepository) CreateGlossaryTerm(term *model.GlossaryTerm) error {
+	return nil
+}
+func (m *MockRepository) GetGlossaryTermsByProject(projectID uuid.UUID) ([]*model.GlossaryTerm, error) {
+	return nil, nil
+}
+func (m *MockRepository) GetGlossaryTerm(termID uuid.UUID) (*model.GlossaryTerm, error) {
+	return nil, nil
+}
+func (m *MockRepository) UpdateGlossaryTerm(term *model.GlossaryTerm) error {
+	return nil
+}
+func (m *MockRepository) DeleteGlossaryTerm(termID uuid.UUID) error {
+	return nil
+}
+func (m *MockRepository) CreateArtifactDependencies(dependencies []*model.ArtifactDependency) error {
+	return nil
+}
+func (m *MockRepository) GetArtifactDependencies(projectID uuid.UUID) ([]*model.ArtifactDependency, error) {
+	return nil, nil
+}
+func (m *MockRepository) SaveArtifactDependency(dependency *model.ArtifactDependency) error {
+	return nil
+}
+func (m *MockRepository) DeleteArtifactDependencies(artifactType, artifactID string) error {
+	return nil
+}
+func (m *MockRepository) GetStoryPriorities(projectID uuid.UUID) ([]*model.StoryPriority, error) {
+	return nil, nil
+}
+func (m *MockRepository) GetStoryPriority(storyID uuid.UUID) (*model.StoryPriority, error) {
+	return nil, nil
+}
+func (m *MockRepository) SaveStoryPriority(priority *model.StoryPriority, change *model.StoryPriorityChange) error {
+	return nil
+}
+func (m *MockRepository) GetStoryPriorityChanges(storyID uuid.UUID) ([]*model.StoryPriorityChange, error) {
+	return nil, nil
+}
+func (m *MockRepository) CreateStorySplits(splits []*model.StorySplit) error {
+	return nil
+}
+func (m *MockRepository) GetStorySplits(projectID uuid.UUID) ([]*model.StorySplit, error) {
+	return nil, nil
+}
+func (m *MockRepository) SaveSchemaImport(document *model.Document, diagram *model.PUMLDiagram, requirement *model.Requirement, authorID uuid.UUID) error {
+	return nil
+}
+func (m *MockRepository) CreateAIAuditLog(auditLog *model.AIAuditLog) error {
+	return nil
+}
+func (m *MockRepository) GetAIAuditLog(logID uuid.UUID) (*model.AIAuditLog, error) {
+	return nil, nil
+}
+func (m *MockRepository) ListAIAuditLogs(query *model.AIAuditLogQuery) ([]*model.AIAuditLog, int64, error) {
+	return nil, 0, nil
+}
+func (m *MockRepository) DeleteAIAuditLogsBefore(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *MockRepository) WithContext(ctx context.Context) repository.Repository { return m }
 
 func (m *MockRepository) Health() error { return nil }
 
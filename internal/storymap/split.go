@@ -0,0 +1,386 @@
+// Package storymap 用户故事拆分与用户故事地图：按常用模式把过大的故事拆成子故事，并按业务流程步骤和发布版本排列故事
+package storymap
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"ai-dev-platform/internal/ai"
+	"ai-dev-platform/internal/consistency"
+)
+
+// Pattern 拆分模式
+type Pattern string
+
+const (
+	PatternWorkflow       Pattern = "workflow_steps"  // 按业务流程步骤拆分
+	PatternBusinessRules  Pattern = "business_rules"  // 按业务规则变化拆分，每条验收标准对应一条规则
+	PatternDataVariations Pattern = "data_variations" // 按数据类型/数据变化拆分
+	PatternCRUD           Pattern = "crud"            // 按增删改查操作拆分
+)
+
+// relatedThreshold 验收标准与子故事范围的相关度阈值，低于该值视为与所有子故事相关
+const relatedThreshold = 0.3
+
+// coveredThreshold 子故事验收标准与父故事验收标准的相似度阈值，达到该值视为覆盖了父故事的标准
+const coveredThreshold = 0.6
+
+// crudOperations 增删改查操作及用于匹配验收标准的关键词
+var crudOperations = []struct {
+	name     string
+	keywords []string
+}{
+	{"创建", []string{"创建", "新增", "添加", "新建", "录入", "create", "add"}},
+	{"查看", []string{"查看", "查询", "列表", "搜索", "检索", "详情", "浏览", "view", "list", "search"}},
+	{"修改", []string{"修改", "编辑", "更新", "变更", "update", "edit"}},
+	{"删除", []string{"删除", "移除", "注销", "作废", "delete", "remove"}},
+}
+
+// ParsePattern 解析拆分模式
+func ParsePattern(pattern string) (Pattern, error) {
+	switch p := Pattern(strings.ToLower(strings.TrimSpace(pattern))); p {
+	case PatternWorkflow, PatternBusinessRules, PatternDataVariations, PatternCRUD:
+		return p, nil
+	}
+	return "", fmt.Errorf("不支持的拆分模式: %s", pattern)
+}
+
+// Story 参与拆分的用户故事
+type Story struct {
+	Title              string   `json:"title"`
+	Description        string   `json:"description"`
+	AcceptanceCriteria []string `json:"acceptance_criteria"`
+}
+
+// Options 拆分参数，按模式使用其中一项
+type Options struct {
+	Steps      []string // workflow_steps：流程步骤
+	Variations []string // data_variations：数据变化
+	Entity     string   // crud：操作的数据实体
+}
+
+// Coverage 父故事的一条验收标准在子故事中的分布
+type Coverage struct {
+	Criterion string `json:"criterion"`
+	Children  []int  `json:"children"`         // 包含该标准的子故事下标
+	Added     bool   `json:"added,omitempty"`  // 子故事原本没有覆盖，拆分时补充
+	Shared    bool   `json:"shared,omitempty"` // 与所有子故事相关，作为通用标准分给全部子故事
+}
+
+// Result 拆分结果
+type Result struct {
+	Pattern  Pattern    `json:"pattern"`
+	Children []Story    `json:"children"`
+	Coverage []Coverage `json:"coverage"`
+	Warnings []string   `json:"warnings,omitempty"`
+}
+
+// Split 按模式确定性地拆分用户故事，并校正子故事的验收标准
+func Split(parent Story, pattern Pattern, opts Options) (*Result, error) {
+	var children []Story
+	switch pattern {
+	case PatternWorkflow:
+		steps := distinct(opts.Steps)
+		if len(steps) < 2 {
+			return nil, fmt.Errorf("按流程步骤拆分至少需要 2 个步骤")
+		}
+		for _, step := range steps {
+			children = append(children, Story{
+				Title:       fmt.Sprintf("%s：%s", parent.Title, step),
+				Description: scopedDescription(parent.Description, fmt.Sprintf("本故事只覆盖流程步骤「%s」。", step)),
+			})
+		}
+	case PatternBusinessRules:
+		rules := distinct(parent.AcceptanceCriteria)
+		if len(rules) < 2 {
+			return nil, fmt.Errorf("按业务规则拆分要求父故事至少有 2 条验收标准")
+		}
+		for _, rule := range rules {
+			children = append(children, Story{
+				Title:              fmt.Sprintf("%s（%s）", parent.Title, truncate(rule, 20)),
+				Description:        scopedDescription(parent.Description, fmt.Sprintf("本故事只处理业务规则：%s", rule)),
+				AcceptanceCriteria: []string{rule},
+			})
+		}
+	case PatternDataVariations:
+		variations := distinct(opts.Variations)
+		if len(variations) < 2 {
+			return nil, fmt.Errorf("按数据变化拆分至少需要 2 种数据变化")
+		}
+		for _, variation := range variations {
+			children = append(children, Story{
+				Title:       fmt.Sprintf("%s（%s）", parent.Title, variation),
+				Description: scopedDescription(parent.Description, fmt.Sprintf("本故事只处理「%s」的数据。", variation)),
+			})
+		}
+	case PatternCRUD:
+		entity := strings.TrimSpace(opts.Entity)
+		if entity == "" {
+			return nil, fmt.Errorf("按增删改查拆分需要指定数据实体")
+		}
+		for _, op := range crudOperations {
+			children = append(children, Story{
+				Title:       fmt.Sprintf("%s：%s%s", parent.Title, op.name, entity),
+				Description: scopedDescription(parent.Description, fmt.Sprintf("本故事只覆盖%s%s。", op.name, entity)),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("不支持的拆分模式: %s", pattern)
+	}
+	return Reconcile(parent, pattern, children), nil
+}
+
+// MatchProcess 与用户故事最相关的业务流程，用于按流程步骤拆分；流程名称或步骤都没有出现在故事中时返回 nil
+func MatchProcess(processes []ai.BusinessProcess, story Story) *ai.BusinessProcess {
+	text := consistency.Shingles(story.Title + " " + story.Description)
+	var best *ai.BusinessProcess
+	bestScore := 0.0
+	for i, process := range processes {
+		if len(distinct(process.Steps)) < 2 {
+			continue
+		}
+		score := containment(consistency.Shingles(process.Name), text)
+		for _, step := range process.Steps {
+			if s := containment(consistency.Shingles(step), text); s > score {
+				score = s
+			}
+		}
+		if score >= relatedThreshold && score > bestScore {
+			best, bestScore = &processes[i], score
+		}
+	}
+	return best
+}
+
+// MatchEntity 用户故事中提到的第一个数据实体名称，用于按增删改查拆分
+func MatchEntity(entities []ai.DataEntity, story Story) string {
+	text := strings.ToLower(story.Title + " " + story.Description)
+	for _, entity := range entities {
+		if name := strings.TrimSpace(entity.Name); name != "" && strings.Contains(text, strings.ToLower(name)) {
+			return name
+		}
+	}
+	return ""
+}
+
+// Reconcile 保证验收标准在子故事间一致：父故事的每条标准至少出现在一个子故事中，
+// 未覆盖的标准分给最相关的子故事，与所有子故事都不相关的标准作为通用标准分给全部子故事；
+// 同时去掉子故事中重复的标准
+func Reconcile(parent Story, pattern Pattern, children []Story) *Result {
+	result := &Result{Pattern: pattern, Children: make([]Story, len(children)), Coverage: []Coverage{}}
+	for i, child := range children {
+		child.AcceptanceCriteria = distinct(child.AcceptanceCriteria)
+		result.Children[i] = child
+	}
+
+	scopes := make([]map[string]struct{}, len(children))
+	for i, child := range children {
+		scopes[i] = consistency.Shingles(child.Title + " " + child.Description)
+	}
+
+	for _, criterion := range distinct(parent.AcceptanceCriteria) {
+		coverage := Coverage{Criterion: criterion, Children: []int{}}
+		for i, child := range result.Children {
+			if covers(child.AcceptanceCriteria, criterion) {
+				coverage.Children = append(coverage.Children, i)
+			}
+		}
+		if len(coverage.Children) == 0 && len(children) > 0 {
+			coverage.Added = true
+			coverage.Children = relatedChildren(criterion, pattern, scopes)
+			coverage.Shared = len(coverage.Children) == len(children) && len(children) > 1
+			for _, i := range coverage.Children {
+				result.Children[i].AcceptanceCriteria = append(result.Children[i].AcceptanceCriteria, criterion)
+			}
+		}
+		result.Coverage = append(result.Coverage, coverage)
+	}
+
+	for i, child := range result.Children {
+		if child.AcceptanceCriteria == nil {
+			result.Children[i].AcceptanceCriteria = []string{}
+			result.Warnings = append(result.Warnings, fmt.Sprintf("子故事「%s」没有验收标准，请补充", child.Title))
+		}
+	}
+	return result
+}
+
+// covers 子故事的验收标准中是否已有与 criterion 相同或高度相似的条目
+func covers(criteria []string, criterion string) bool {
+	target := consistency.Shingles(criterion)
+	for _, c := range criteria {
+		if normalize(c) == normalize(criterion) || consistency.Jaccard(consistency.Shingles(c), target) >= coveredThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// relatedChildren 与验收标准最相关的子故事下标：增删改查模式优先按操作关键词匹配，
+// 其余按文本相似度取最高者，都不相关时返回全部子故事
+func relatedChildren(criterion string, pattern Pattern, scopes []map[string]struct{}) []int {
+	if pattern == PatternCRUD && len(scopes) == len(crudOperations) {
+		lower := strings.ToLower(criterion)
+		var matched []int
+		for i, op := range crudOperations {
+			for _, keyword := range op.keywords {
+				if strings.Contains(lower, keyword) {
+					matched = append(matched, i)
+					break
+				}
+			}
+		}
+		if len(matched) > 0 {
+			return matched
+		}
+	}
+
+	target := consistency.Shingles(criterion)
+	best, bestScore := []int{}, 0.0
+	for i, scope := range scopes {
+		score := containment(target, scope)
+		switch {
+		case score > bestScore:
+			best, bestScore = []int{i}, score
+		case score == bestScore && score > 0:
+			best = append(best, i)
+		}
+	}
+	if bestScore < relatedThreshold {
+		best = make([]int, len(scopes))
+		for i := range scopes {
+			best[i] = i
+		}
+	}
+	return best
+}
+
+// containment part 的片段中出现在 whole 里的比例
+func containment(part, whole map[string]struct{}) float64 {
+	if len(part) == 0 {
+		return 0
+	}
+	hit := 0
+	for s := range part {
+		if _, ok := whole[s]; ok {
+			hit++
+		}
+	}
+	return float64(hit) / float64(len(part))
+}
+
+// BuildSplitPrompt 构建由AI拆分用户故事的提示词
+func BuildSplitPrompt(parent Story, pattern Pattern, opts Options) string {
+	var b strings.Builder
+	b.WriteString("\n你是一个资深的敏捷教练。下面的用户故事过大，请把它拆分为 2-8 个可以独立交付、独立验收的子故事。\n\n")
+	fmt.Fprintf(&b, "**拆分模式：** %s\n", patternGuide[pattern])
+	switch pattern {
+	case PatternWorkflow:
+		if len(opts.Steps) > 0 {
+			fmt.Fprintf(&b, "**流程步骤：** %s\n", strings.Join(opts.Steps, " → "))
+		}
+	case PatternDataVariations:
+		if len(opts.Variations) > 0 {
+			fmt.Fprintf(&b, "**数据变化：** %s\n", strings.Join(opts.Variations, "、"))
+		}
+	case PatternCRUD:
+		if opts.Entity != "" {
+			fmt.Fprintf(&b, "**数据实体：** %s\n", opts.Entity)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n**用户故事：** %s\n", parent.Title)
+	if description := strings.TrimSpace(parent.Description); description != "" {
+		fmt.Fprintf(&b, "%s\n", description)
+	}
+	if len(parent.AcceptanceCriteria) > 0 {
+		b.WriteString("\n**验收标准：**\n")
+		for i, criterion := range parent.AcceptanceCriteria {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, criterion)
+		}
+	}
+
+	b.WriteString(`
+**要求：**
+- 父故事的每条验收标准都必须原样出现在至少一个子故事中，适用于所有子故事的标准要出现在每个子故事中
+- 子故事可以补充新的验收标准，但不能与父故事的标准冲突
+
+**输出格式（JSON）：**
+` + "```json" + `
+{
+  "children": [
+    {"title": "子故事标题", "description": "作为…，我希望…，以便…", "acceptance_criteria": ["验收标准1"]}
+  ]
+}
+` + "```" + `
+`)
+	return b.String()
+}
+
+var patternGuide = map[Pattern]string{
+	PatternWorkflow:       "按业务流程步骤拆分，每个子故事覆盖流程中的一个或几个连续步骤",
+	PatternBusinessRules:  "按业务规则变化拆分，每个子故事处理一种业务规则",
+	PatternDataVariations: "按数据变化拆分，每个子故事处理一种数据类型或数据来源",
+	PatternCRUD:           "按增删改查操作拆分，每个子故事对应一种操作",
+}
+
+// ParseSplit 解析AI返回的子故事，忽略没有标题的条目
+func ParseSplit(jsonStr string) ([]Story, error) {
+	var parsed struct {
+		Children []Story `json:"children"`
+	}
+	if err := json.Unmarshal([]byte(jsonStr), &parsed); err != nil {
+		return nil, fmt.Errorf("解析AI拆分结果失败: %w", err)
+	}
+
+	var children []Story
+	for _, child := range parsed.Children {
+		child.Title = strings.TrimSpace(child.Title)
+		if child.Title == "" {
+			continue
+		}
+		child.Description = strings.TrimSpace(child.Description)
+		children = append(children, child)
+	}
+	if len(children) < 2 {
+		return nil, fmt.Errorf("AI拆分结果少于 2 个子故事")
+	}
+	return children, nil
+}
+
+func scopedDescription(description, scope string) string {
+	if description = strings.TrimSpace(description); description == "" {
+		return scope
+	}
+	return description + "\n\n" + scope
+}
+
+// distinct 去掉空白和重复的条目，保持原有顺序
+func distinct(values []string) []string {
+	var result []string
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		key := normalize(value)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, value)
+	}
+	return result
+}
+
+// normalize 忽略大小写和空白，中英文混排时「填写 ISBN」与「填写ISBN」视为相同
+func normalize(value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(value), ""))
+}
+
+func truncate(value string, max int) string {
+	runes := []rune(value)
+	if len(runes) <= max {
+		return value
+	}
+	return string(runes[:max]) + "…"
+}
@@ -0,0 +1,260 @@
+package storymap
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"ai-dev-platform/internal/ai"
+	"ai-dev-platform/internal/consistency"
+)
+
+// 发布版本，按规划顺序排列
+const (
+	ReleaseMVP       = "第一版（MVP）"
+	ReleaseSecond    = "第二版"
+	ReleaseThird     = "第三版"
+	ReleaseUnplanned = "待规划"
+	ReleaseExcluded  = "暂不实现"
+)
+
+// Releases 发布版本的顺序
+var Releases = []string{ReleaseMVP, ReleaseSecond, ReleaseThird, ReleaseUnplanned, ReleaseExcluded}
+
+// 未能归入任何流程步骤的故事所在的活动和步骤
+const (
+	UncategorizedActivity = "未归类"
+	UncategorizedStep     = "其他"
+)
+
+// placeThreshold 流程步骤的文本片段出现在故事中的比例达到该值时，故事归入该步骤
+const placeThreshold = 0.5
+
+// ReleaseFor 根据 MoSCoW 分组确定发布版本，未分组时按用户故事的优先级（high、medium、low）确定
+func ReleaseFor(moscow, priority string) string {
+	switch moscow {
+	case "must":
+		return ReleaseMVP
+	case "should":
+		return ReleaseSecond
+	case "could":
+		return ReleaseThird
+	case "wont":
+		return ReleaseExcluded
+	}
+	switch strings.ToLower(strings.TrimSpace(priority)) {
+	case "high":
+		return ReleaseMVP
+	case "medium":
+		return ReleaseSecond
+	case "low":
+		return ReleaseThird
+	}
+	return ReleaseUnplanned
+}
+
+// Card 地图上的用户故事
+type Card struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"-"` // 仅用于匹配流程步骤
+	Release     string `json:"-"`
+	Rank        int    `json:"rank,omitempty"` // 优先级排名，同一格内按排名排列
+	ParentID    string `json:"parent_id,omitempty"`
+}
+
+// Cell 某个流程步骤在某个发布版本中的故事
+type Cell struct {
+	Release string  `json:"release"`
+	Stories []*Card `json:"stories"`
+}
+
+// Step 骨干流程步骤
+type Step struct {
+	Name  string  `json:"name"`
+	Cells []*Cell `json:"releases"`
+}
+
+// Activity 骨干活动，对应一个业务流程
+type Activity struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Steps       []*Step `json:"steps"`
+}
+
+// Map 用户故事地图
+type Map struct {
+	Releases   []string    `json:"releases"` // 地图中出现的发布版本，按规划顺序
+	Activities []*Activity `json:"activities"`
+	Total      int         `json:"total"`
+	Split      int         `json:"split"` // 已拆分、由子故事代替的父故事数量
+}
+
+// Build 以业务流程的步骤为骨干构建用户故事地图：每个故事归入文本最匹配的步骤，
+// 匹配不到的归入「未归类」；已拆分的父故事不上地图，由其子故事代替
+func Build(processes []ai.BusinessProcess, cards []*Card) *Map {
+	m := &Map{Releases: []string{}, Activities: []*Activity{}}
+
+	type slot struct {
+		step    *Step
+		shingle map[string]struct{}
+	}
+	var slots []slot
+	seenActivity := make(map[string]bool)
+	for _, process := range processes {
+		name := strings.TrimSpace(process.Name)
+		if name == "" || seenActivity[normalize(name)] {
+			continue
+		}
+		seenActivity[normalize(name)] = true
+		activity := &Activity{Name: name, Description: strings.TrimSpace(process.Description), Steps: []*Step{}}
+		for _, stepName := range distinct(process.Steps) {
+			step := &Step{Name: stepName, Cells: []*Cell{}}
+			activity.Steps = append(activity.Steps, step)
+			slots = append(slots, slot{step: step, shingle: consistency.Shingles(stepName)})
+		}
+		if len(activity.Steps) > 0 {
+			m.Activities = append(m.Activities, activity)
+		}
+	}
+
+	parents := make(map[string]bool)
+	for _, card := range cards {
+		if card.ParentID != "" {
+			parents[card.ParentID] = true
+		}
+	}
+
+	var other *Step
+	releases := make(map[string]bool)
+	for _, card := range cards {
+		if parents[card.ID] {
+			m.Split++
+			continue
+		}
+		if card.Release == "" {
+			card.Release = ReleaseUnplanned
+		}
+
+		text := consistency.Shingles(card.Title + " " + card.Description)
+		var target *Step
+		best := 0.0
+		for _, s := range slots {
+			if score := containment(s.shingle, text); score >= placeThreshold && score > best {
+				target, best = s.step, score
+			}
+		}
+		if target == nil {
+			if other == nil {
+				other = &Step{Name: UncategorizedStep, Cells: []*Cell{}}
+			}
+			target = other
+		}
+		target.add(card)
+		releases[card.Release] = true
+		m.Total++
+	}
+	if other != nil {
+		m.Activities = append(m.Activities, &Activity{Name: UncategorizedActivity, Steps: []*Step{other}})
+	}
+
+	for _, activity := range m.Activities {
+		for _, step := range activity.Steps {
+			step.sort()
+		}
+	}
+	for _, release := range Releases {
+		if releases[release] {
+			m.Releases = append(m.Releases, release)
+		}
+	}
+	return m
+}
+
+func (s *Step) add(card *Card) {
+	for _, cell := range s.Cells {
+		if cell.Release == card.Release {
+			cell.Stories = append(cell.Stories, card)
+			return
+		}
+	}
+	s.Cells = append(s.Cells, &Cell{Release: card.Release, Stories: []*Card{card}})
+}
+
+// sort 按发布版本顺序排列单元格，格内按优先级排名排列，没有排名的在后
+func (s *Step) sort() {
+	order := make(map[string]int, len(Releases))
+	for i, release := range Releases {
+		order[release] = i
+	}
+	sort.SliceStable(s.Cells, func(i, j int) bool { return order[s.Cells[i].Release] < order[s.Cells[j].Release] })
+	for _, cell := range s.Cells {
+		stories := cell.Stories
+		sort.SliceStable(stories, func(i, j int) bool {
+			a, b := stories[i].Rank, stories[j].Rank
+			if (a == 0) != (b == 0) {
+				return a != 0
+			}
+			return a < b
+		})
+	}
+}
+
+func (s *Step) cell(release string) *Cell {
+	for _, cell := range s.Cells {
+		if cell.Release == release {
+			return cell
+		}
+	}
+	return nil
+}
+
+// WriteMarkdown 以 Markdown 表格导出用户故事地图：每个活动一张表，列为流程步骤，行为发布版本
+func WriteMarkdown(w io.Writer, m *Map) error {
+	var b strings.Builder
+	b.WriteString("# 用户故事地图\n\n")
+	fmt.Fprintf(&b, "共 %d 个用户故事", m.Total)
+	if m.Split > 0 {
+		fmt.Fprintf(&b, "（%d 个已拆分的故事由其子故事代替）", m.Split)
+	}
+	b.WriteString("\n")
+
+	if len(m.Activities) == 0 {
+		b.WriteString("\n暂无用户故事。\n")
+	}
+	for _, activity := range m.Activities {
+		fmt.Fprintf(&b, "\n## %s\n\n", escapeMarkdown(activity.Name))
+		if activity.Description != "" {
+			fmt.Fprintf(&b, "%s\n\n", activity.Description)
+		}
+
+		b.WriteString("| 版本 |")
+		for _, step := range activity.Steps {
+			fmt.Fprintf(&b, " %s |", escapeMarkdown(step.Name))
+		}
+		b.WriteString("\n|---|" + strings.Repeat("---|", len(activity.Steps)) + "\n")
+
+		for _, release := range m.Releases {
+			fmt.Fprintf(&b, "| %s |", release)
+			for _, step := range activity.Steps {
+				var titles []string
+				if cell := step.cell(release); cell != nil {
+					for _, story := range cell.Stories {
+						titles = append(titles, escapeMarkdown(story.Title))
+					}
+				}
+				fmt.Fprintf(&b, " %s |", strings.Join(titles, "<br>"))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func escapeMarkdown(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
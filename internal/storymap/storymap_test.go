@@ -0,0 +1,145 @@
+package storymap
+
+import (
+	"strings"
+	"testing"
+
+	"ai-dev-platform/internal/ai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var epic = Story{
+	Title:       "图书管理",
+	Description: "作为管理员，我希望维护馆藏图书，以便读者可以借阅",
+	AcceptanceCriteria: []string{
+		"新增图书时必须填写ISBN",
+		"删除图书前需要确认没有未归还的借阅",
+		"所有操作都记录操作日志",
+	},
+}
+
+func TestSplit_CRUD(t *testing.T) {
+	result, err := Split(epic, PatternCRUD, Options{Entity: "图书"})
+	require.NoError(t, err)
+	require.Len(t, result.Children, 4)
+	assert.Equal(t, "图书管理：创建图书", result.Children[0].Title)
+
+	assert.Equal(t, []string{"新增图书时必须填写ISBN", "所有操作都记录操作日志"}, result.Children[0].AcceptanceCriteria)
+	assert.Equal(t, []string{"所有操作都记录操作日志"}, result.Children[1].AcceptanceCriteria)
+	assert.Equal(t, []string{"删除图书前需要确认没有未归还的借阅", "所有操作都记录操作日志"}, result.Children[3].AcceptanceCriteria)
+
+	require.Len(t, result.Coverage, 3)
+	assert.Equal(t, []int{0}, result.Coverage[0].Children)
+	assert.True(t, result.Coverage[2].Shared, "与任何操作都无关的标准分给全部子故事")
+	assert.Empty(t, result.Warnings)
+
+	_, err = Split(epic, PatternCRUD, Options{})
+	assert.Error(t, err)
+}
+
+func TestSplit_BusinessRulesAndData(t *testing.T) {
+	result, err := Split(epic, PatternBusinessRules, Options{})
+	require.NoError(t, err)
+	require.Len(t, result.Children, 3)
+	assert.Equal(t, []string{"删除图书前需要确认没有未归还的借阅"}, result.Children[1].AcceptanceCriteria)
+	for _, coverage := range result.Coverage {
+		assert.False(t, coverage.Added)
+	}
+
+	result, err = Split(epic, PatternDataVariations, Options{Variations: []string{"纸质书", "电子书", "纸质书 "}})
+	require.NoError(t, err)
+	require.Len(t, result.Children, 2, "重复的数据变化只保留一个")
+	assert.Equal(t, "图书管理（电子书）", result.Children[1].Title)
+	assert.Equal(t, epic.AcceptanceCriteria, result.Children[1].AcceptanceCriteria, "每种数据变化都满足全部验收标准")
+
+	_, err = Split(Story{Title: "借书", AcceptanceCriteria: []string{"只有一条"}}, PatternBusinessRules, Options{})
+	assert.Error(t, err)
+}
+
+func TestMatch(t *testing.T) {
+	processes := []ai.BusinessProcess{
+		{Name: "还书流程", Steps: []string{"扫描图书", "计算罚金"}},
+		{Name: "馆藏维护", Steps: []string{"登记新书", "下架图书"}},
+		{Name: "单步流程", Steps: []string{"维护馆藏图书"}},
+	}
+	process := MatchProcess(processes, epic)
+	require.NotNil(t, process)
+	assert.Equal(t, "馆藏维护", process.Name, "只有一个步骤的流程不参与匹配")
+	assert.Nil(t, MatchProcess(processes, Story{Title: "导出报表"}))
+
+	entities := []ai.DataEntity{{Name: "Reader"}, {Name: "图书"}}
+	assert.Equal(t, "图书", MatchEntity(entities, epic))
+	assert.Equal(t, "Reader", MatchEntity(entities, Story{Title: "注销 reader 账号"}))
+	assert.Empty(t, MatchEntity(entities, Story{Title: "借书"}))
+}
+
+func TestReconcile(t *testing.T) {
+	children := []Story{
+		{Title: "登记新书", AcceptanceCriteria: []string{"新增图书时必须填写 ISBN", "新增图书时必须填写ISBN"}},
+		{Title: "下架图书", Description: "删除图书前需要确认借阅状态"},
+	}
+
+	result := Reconcile(epic, PatternWorkflow, children)
+
+	assert.Equal(t, []string{"新增图书时必须填写 ISBN", "所有操作都记录操作日志"}, result.Children[0].AcceptanceCriteria, "重复的标准被去掉")
+	assert.False(t, result.Coverage[0].Added, "相似的标准视为已覆盖")
+	assert.True(t, result.Coverage[1].Added)
+	assert.Equal(t, []int{1}, result.Coverage[1].Children)
+	assert.Contains(t, result.Children[1].AcceptanceCriteria, "删除图书前需要确认没有未归还的借阅")
+	assert.Equal(t, []int{0, 1}, result.Coverage[2].Children)
+}
+
+func TestParseSplit(t *testing.T) {
+	children, err := ParseSplit(`{"children":[{"title":" 借书 ","acceptance_criteria":["a"]},{"title":""},{"title":"还书"}]}`)
+	require.NoError(t, err)
+	assert.Equal(t, "借书", children[0].Title)
+	assert.Len(t, children, 2)
+
+	_, err = ParseSplit(`{"children":[{"title":"借书"}]}`)
+	assert.Error(t, err)
+
+	prompt := BuildSplitPrompt(epic, PatternCRUD, Options{Entity: "图书"})
+	assert.Contains(t, prompt, "**数据实体：** 图书")
+	assert.Contains(t, prompt, "2. 删除图书前需要确认没有未归还的借阅")
+}
+
+func TestBuild(t *testing.T) {
+	processes := []ai.BusinessProcess{
+		{Name: "借阅流程", Steps: []string{"检索图书", "办理借阅", "归还图书"}},
+		{Name: "借阅流程", Steps: []string{"重复的流程"}},
+	}
+	cards := []*Card{
+		{ID: "epic", Title: "借书"},
+		{ID: "a", Title: "按书名检索图书", Release: ReleaseFor("should", ""), ParentID: "epic"},
+		{ID: "b", Title: "读者办理借阅", Release: ReleaseFor("", "high"), Rank: 2, ParentID: "epic"},
+		{ID: "c", Title: "扫码办理借阅", Release: ReleaseFor("must", "low"), Rank: 1},
+		{ID: "d", Title: "导出统计报表"},
+	}
+
+	m := Build(processes, cards)
+
+	assert.Equal(t, 4, m.Total)
+	assert.Equal(t, 1, m.Split)
+	assert.Equal(t, []string{ReleaseMVP, ReleaseSecond, ReleaseUnplanned}, m.Releases)
+	require.Len(t, m.Activities, 2)
+
+	flow := m.Activities[0]
+	require.Len(t, flow.Steps, 3)
+	assert.Equal(t, ReleaseSecond, flow.Steps[0].Cells[0].Release)
+	borrow := flow.Steps[1].Cells
+	require.Len(t, borrow, 1)
+	assert.Equal(t, "c", borrow[0].Stories[0].ID, "同一格内按排名排列")
+	assert.Equal(t, "b", borrow[0].Stories[1].ID)
+	assert.Empty(t, flow.Steps[2].Cells)
+
+	assert.Equal(t, UncategorizedActivity, m.Activities[1].Name)
+	assert.Equal(t, "d", m.Activities[1].Steps[0].Cells[0].Stories[0].ID)
+
+	var b strings.Builder
+	require.NoError(t, WriteMarkdown(&b, m))
+	assert.Contains(t, b.String(), "| 版本 | 检索图书 | 办理借阅 | 归还图书 |\n|---|---|---|---|\n")
+	assert.Contains(t, b.String(), "| 第一版（MVP） |  | 扫码办理借阅<br>读者办理借阅 |  |\n")
+	assert.Contains(t, b.String(), "（1 个已拆分的故事由其子故事代替）")
+}
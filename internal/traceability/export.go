@@ -0,0 +1,160 @@
+package traceability
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"ai-dev-platform/internal/model"
+)
+
+// 需求标题最大长度（字符）
+const maxTitleLength = 80
+
+// csvHeader 追踪矩阵CSV表头
+var csvHeader = []string{
+	"requirement_id", "requirement", "story_id", "story",
+	"task_id", "task", "task_status", "test_case_id", "test_case",
+}
+
+// WriteCSV 以CSV格式输出追踪矩阵，每行一条链路
+func WriteCSV(w io.Writer, m *Matrix) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return fmt.Errorf("写入CSV表头失败: %w", err)
+	}
+
+	for _, row := range m.Rows {
+		record := make([]string, 0, len(csvHeader))
+		record = append(record, nodeID(row.Requirement), nodeTitle(row.Requirement))
+		record = append(record, nodeID(row.Story), nodeTitle(row.Story))
+		record = append(record, nodeID(row.Task), nodeTitle(row.Task), nodeStatus(row.Task))
+		record = append(record, nodeID(row.TestCase), nodeTitle(row.TestCase))
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("写入CSV失败: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteMarkdown 以Markdown格式输出追踪矩阵，包含覆盖率、矩阵、孤立项和阶段产物
+func WriteMarkdown(w io.Writer, m *Matrix) error {
+	var b strings.Builder
+
+	b.WriteString("# 需求追踪矩阵\n\n")
+	fmt.Fprintf(&b, "项目ID: `%s`\n\n", m.ProjectID)
+
+	b.WriteString("## 覆盖率\n\n")
+	b.WriteString("| 指标 | 已覆盖 | 总数 | 覆盖率 |\n|---|---|---|---|\n")
+	writeCoverageRow(&b, "需求 → 用户故事", m.Coverage.RequirementsWithStories)
+	writeCoverageRow(&b, "用户故事 → 开发任务", m.Coverage.StoriesWithTasks)
+	writeCoverageRow(&b, "开发任务 → 测试用例", m.Coverage.TasksWithTests)
+	writeCoverageRow(&b, "需求 → 测试用例（端到端）", m.Coverage.RequirementsEndToEnd)
+
+	b.WriteString("\n## 追踪矩阵\n\n")
+	b.WriteString("| 需求 | 用户故事 | 开发任务 | 状态 | 测试用例 |\n|---|---|---|---|---|\n")
+	for _, row := range m.Rows {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+			markdownCell(row.Requirement), markdownCell(row.Story), markdownCell(row.Task),
+			escapeMarkdown(nodeStatus(row.Task)), markdownCell(row.TestCase))
+	}
+
+	b.WriteString("\n## 孤立项\n\n")
+	writeOrphanList(&b, "没有用户故事的需求", m.Orphans.RequirementsWithoutStories)
+	writeOrphanList(&b, "没有开发任务的用户故事", m.Orphans.StoriesWithoutTasks)
+	writeOrphanList(&b, "没有测试用例的开发任务", m.Orphans.TasksWithoutTests)
+	writeOrphanList(&b, "未关联用户故事的开发任务", m.Orphans.TasksWithoutStory)
+	writeOrphanList(&b, "未关联开发任务的测试用例", m.Orphans.TestCasesWithoutTask)
+
+	b.WriteString("## 阶段产物\n\n")
+	b.WriteString("| 阶段 | PUML图表 | 文档 |\n|---|---|---|\n")
+	for _, stage := range m.Stages {
+		fmt.Fprintf(&b, "| %d | %s | %s |\n", stage.Stage, joinTitles(stage.Diagrams), joinTitles(stage.Documents))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeCoverageRow 输出覆盖率表格行
+func writeCoverageRow(b *strings.Builder, name string, c Coverage) {
+	fmt.Fprintf(b, "| %s | %d | %d | %.2f%% |\n", name, c.Covered, c.Total, c.Percent)
+}
+
+// writeOrphanList 输出孤立项列表
+func writeOrphanList(b *strings.Builder, title string, nodes []Node) {
+	fmt.Fprintf(b, "### %s（%d）\n\n", title, len(nodes))
+	if len(nodes) == 0 {
+		b.WriteString("无\n\n")
+		return
+	}
+	for _, node := range nodes {
+		fmt.Fprintf(b, "- %s (`%s`)\n", escapeMarkdown(node.Title), node.ID)
+	}
+	b.WriteString("\n")
+}
+
+// requirementTitle 取需求文档内容的首行作为标题
+func requirementTitle(doc *model.RequirementsDoc) string {
+	for _, line := range strings.Split(doc.Content, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+		if line == "" {
+			continue
+		}
+		if runes := []rune(line); len(runes) > maxTitleLength {
+			line = string(runes[:maxTitleLength]) + "…"
+		}
+		return line
+	}
+	return fmt.Sprintf("需求文档 v%d", doc.Version)
+}
+
+// joinTitles 拼接节点标题
+func joinTitles(nodes []Node) string {
+	if len(nodes) == 0 {
+		return "-"
+	}
+	titles := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		titles = append(titles, escapeMarkdown(node.Title))
+	}
+	return strings.Join(titles, "<br>")
+}
+
+func nodeID(n *Node) string {
+	if n == nil {
+		return ""
+	}
+	return n.ID.String()
+}
+
+func nodeTitle(n *Node) string {
+	if n == nil {
+		return ""
+	}
+	return n.Title
+}
+
+func nodeStatus(n *Node) string {
+	if n == nil {
+		return ""
+	}
+	return n.Status
+}
+
+// markdownCell 矩阵单元格，缺失环节显示为 —
+func markdownCell(n *Node) string {
+	if n == nil {
+		return "—"
+	}
+	return escapeMarkdown(n.Title)
+}
+
+// escapeMarkdown 转义表格中的竖线并去除换行
+func escapeMarkdown(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.Join(strings.Fields(s), " ")
+}
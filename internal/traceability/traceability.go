@@ -0,0 +1,300 @@
+// Package traceability 构建项目的需求追踪矩阵：需求文档 → 用户故事 → 开发任务 → 测试用例，
+// 并关联各阶段的PUML图表与文档，统计覆盖率、找出未被追踪的孤立项
+package traceability
+
+import (
+	"math"
+	"sort"
+
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// NodeType 追踪节点类型
+type NodeType string
+
+const (
+	NodeRequirement NodeType = "requirement"
+	NodeStory       NodeType = "story"
+	NodeTask        NodeType = "task"
+	NodeTestCase    NodeType = "test_case"
+	NodeDiagram     NodeType = "diagram"
+	NodeDocument    NodeType = "document"
+)
+
+// Node 追踪图节点
+type Node struct {
+	ID     uuid.UUID `json:"id"`
+	Type   NodeType  `json:"type"`
+	Title  string    `json:"title"`
+	Stage  int       `json:"stage,omitempty"`  // 图表与文档所属阶段
+	Status string    `json:"status,omitempty"` // 开发任务状态
+}
+
+// Edge 追踪图的边，由上游指向下游
+type Edge struct {
+	From uuid.UUID `json:"from"`
+	To   uuid.UUID `json:"to"`
+}
+
+// Graph 追踪图
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// Row 追踪矩阵的一行：一条 需求 → 故事 → 任务 → 测试 链路，缺失的环节为 nil
+type Row struct {
+	Requirement *Node `json:"requirement,omitempty"`
+	Story       *Node `json:"story,omitempty"`
+	Task        *Node `json:"task,omitempty"`
+	TestCase    *Node `json:"test_case,omitempty"`
+}
+
+// Coverage 单层覆盖率
+type Coverage struct {
+	Total   int     `json:"total"`
+	Covered int     `json:"covered"`
+	Percent float64 `json:"percent"` // 0-100，保留两位小数
+}
+
+// CoverageSummary 各层覆盖率
+type CoverageSummary struct {
+	RequirementsWithStories Coverage `json:"requirements_with_stories"`
+	StoriesWithTasks        Coverage `json:"stories_with_tasks"`
+	TasksWithTests          Coverage `json:"tasks_with_tests"`
+	RequirementsEndToEnd    Coverage `json:"requirements_end_to_end"` // 至少有一条链路到达测试用例的需求
+}
+
+// Orphans 未被追踪的孤立项
+type Orphans struct {
+	RequirementsWithoutStories []Node `json:"requirements_without_stories"`
+	StoriesWithoutTasks        []Node `json:"stories_without_tasks"`
+	TasksWithoutTests          []Node `json:"tasks_without_tests"`
+	TasksWithoutStory          []Node `json:"tasks_without_story"`      // 未关联用户故事的任务
+	TestCasesWithoutTask       []Node `json:"test_cases_without_task"`  // 未关联开发任务的测试用例
+	StagesWithoutArtifacts     []int  `json:"stages_without_artifacts"` // 没有图表和文档的阶段
+}
+
+// StageArtifacts 阶段产物
+type StageArtifacts struct {
+	Stage     int    `json:"stage"`
+	Diagrams  []Node `json:"diagrams"`
+	Documents []Node `json:"documents"`
+}
+
+// Matrix 项目追踪矩阵
+type Matrix struct {
+	ProjectID uuid.UUID        `json:"project_id"`
+	Rows      []Row            `json:"rows"`
+	Stages    []StageArtifacts `json:"stages"`
+	Coverage  CoverageSummary  `json:"coverage"`
+	Orphans   Orphans          `json:"orphans"`
+	Graph     Graph            `json:"graph"`
+}
+
+// Input 构建追踪矩阵所需的项目数据
+type Input struct {
+	ProjectID    uuid.UUID
+	Requirements []*model.RequirementsDoc
+	Stories      []*model.UserStory
+	Tasks        []*model.DevelopmentTask
+	TestCases    []*model.TestCase
+	Diagrams     []*model.PUMLDiagram
+	Documents    []*model.Document
+}
+
+// Stages 项目的阶段编号
+var Stages = []int{1, 2, 3}
+
+// Build 构建追踪矩阵，输入中引用了不存在对象的链接视为未关联
+func Build(input *Input) *Matrix {
+	m := &Matrix{
+		ProjectID: input.ProjectID,
+		Rows:      []Row{},
+		Graph:     Graph{Nodes: []Node{}, Edges: []Edge{}},
+	}
+	m.Orphans = Orphans{
+		RequirementsWithoutStories: []Node{},
+		StoriesWithoutTasks:        []Node{},
+		TasksWithoutTests:          []Node{},
+		TasksWithoutStory:          []Node{},
+		TestCasesWithoutTask:       []Node{},
+		StagesWithoutArtifacts:     []int{},
+	}
+
+	requirements := make([]Node, 0, len(input.Requirements))
+	requirementIDs := make(map[uuid.UUID]bool)
+	for _, doc := range input.Requirements {
+		requirements = append(requirements, RequirementNode(doc))
+		requirementIDs[doc.ID] = true
+	}
+
+	storiesByRequirement := make(map[uuid.UUID][]Node)
+	storyIDs := make(map[uuid.UUID]bool)
+	for _, story := range input.Stories {
+		node := Node{ID: story.ID, Type: NodeStory, Title: story.Title}
+		storyIDs[story.ID] = true
+		m.Graph.Nodes = append(m.Graph.Nodes, node)
+		if requirementIDs[story.RequirementsID] {
+			storiesByRequirement[story.RequirementsID] = append(storiesByRequirement[story.RequirementsID], node)
+			m.Graph.Edges = append(m.Graph.Edges, Edge{From: story.RequirementsID, To: story.ID})
+		}
+	}
+
+	tasksByStory := make(map[uuid.UUID][]Node)
+	taskIDs := make(map[uuid.UUID]bool)
+	var tasks []Node
+	for _, task := range input.Tasks {
+		node := Node{ID: task.ID, Type: NodeTask, Title: task.Title, Status: task.Status}
+		taskIDs[task.ID] = true
+		tasks = append(tasks, node)
+		m.Graph.Nodes = append(m.Graph.Nodes, node)
+		if task.UserStoryID != nil && storyIDs[*task.UserStoryID] {
+			tasksByStory[*task.UserStoryID] = append(tasksByStory[*task.UserStoryID], node)
+			m.Graph.Edges = append(m.Graph.Edges, Edge{From: *task.UserStoryID, To: task.ID})
+		} else {
+			m.Orphans.TasksWithoutStory = append(m.Orphans.TasksWithoutStory, node)
+		}
+	}
+
+	testsByTask := make(map[uuid.UUID][]Node)
+	for _, testCase := range input.TestCases {
+		node := Node{ID: testCase.ID, Type: NodeTestCase, Title: testCase.Title}
+		m.Graph.Nodes = append(m.Graph.Nodes, node)
+		if testCase.TaskID != nil && taskIDs[*testCase.TaskID] {
+			testsByTask[*testCase.TaskID] = append(testsByTask[*testCase.TaskID], node)
+			m.Graph.Edges = append(m.Graph.Edges, Edge{From: *testCase.TaskID, To: testCase.ID})
+		} else {
+			m.Orphans.TestCasesWithoutTask = append(m.Orphans.TestCasesWithoutTask, node)
+		}
+	}
+	m.Graph.Nodes = append(requirements, m.Graph.Nodes...)
+
+	// 按 需求 → 故事 → 任务 → 测试 展开矩阵行
+	var coveredRequirements, endToEnd, storyTotal, coveredStories int
+	for i := range requirements {
+		requirement := &requirements[i]
+		stories := storiesByRequirement[requirement.ID]
+		if len(stories) == 0 {
+			m.Orphans.RequirementsWithoutStories = append(m.Orphans.RequirementsWithoutStories, *requirement)
+			m.Rows = append(m.Rows, Row{Requirement: requirement})
+			continue
+		}
+		coveredRequirements++
+
+		reachesTest := false
+		for j := range stories {
+			story := &stories[j]
+			storyTotal++
+			storyTasks := tasksByStory[story.ID]
+			if len(storyTasks) == 0 {
+				m.Orphans.StoriesWithoutTasks = append(m.Orphans.StoriesWithoutTasks, *story)
+				m.Rows = append(m.Rows, Row{Requirement: requirement, Story: story})
+				continue
+			}
+			coveredStories++
+
+			for k := range storyTasks {
+				task := &storyTasks[k]
+				tests := testsByTask[task.ID]
+				if len(tests) == 0 {
+					m.Rows = append(m.Rows, Row{Requirement: requirement, Story: story, Task: task})
+					continue
+				}
+				reachesTest = true
+				for l := range tests {
+					m.Rows = append(m.Rows, Row{Requirement: requirement, Story: story, Task: task, TestCase: &tests[l]})
+				}
+			}
+		}
+		if reachesTest {
+			endToEnd++
+		}
+	}
+
+	// 未关联需求的故事同样需要统计任务覆盖
+	for _, story := range input.Stories {
+		if requirementIDs[story.RequirementsID] {
+			continue
+		}
+		storyTotal++
+		if len(tasksByStory[story.ID]) > 0 {
+			coveredStories++
+		} else {
+			m.Orphans.StoriesWithoutTasks = append(m.Orphans.StoriesWithoutTasks, Node{ID: story.ID, Type: NodeStory, Title: story.Title})
+		}
+	}
+
+	coveredTasks := 0
+	for _, task := range tasks {
+		if len(testsByTask[task.ID]) > 0 {
+			coveredTasks++
+		} else {
+			m.Orphans.TasksWithoutTests = append(m.Orphans.TasksWithoutTests, task)
+		}
+	}
+
+	m.Coverage = CoverageSummary{
+		RequirementsWithStories: newCoverage(len(requirements), coveredRequirements),
+		StoriesWithTasks:        newCoverage(storyTotal, coveredStories),
+		TasksWithTests:          newCoverage(len(tasks), coveredTasks),
+		RequirementsEndToEnd:    newCoverage(len(requirements), endToEnd),
+	}
+
+	m.Stages = buildStages(input, &m.Graph)
+	for _, stage := range m.Stages {
+		if len(stage.Diagrams) == 0 && len(stage.Documents) == 0 {
+			m.Orphans.StagesWithoutArtifacts = append(m.Orphans.StagesWithoutArtifacts, stage.Stage)
+		}
+	}
+
+	return m
+}
+
+// RequirementNode 需求文档节点，标题取文档首行
+func RequirementNode(doc *model.RequirementsDoc) Node {
+	return Node{ID: doc.ID, Type: NodeRequirement, Title: requirementTitle(doc)}
+}
+
+// buildStages 按阶段归集图表与文档
+func buildStages(input *Input, graph *Graph) []StageArtifacts {
+	byStage := make(map[int]*StageArtifacts)
+	for _, stage := range Stages {
+		byStage[stage] = &StageArtifacts{Stage: stage, Diagrams: []Node{}, Documents: []Node{}}
+	}
+	stageOf := func(stage int) *StageArtifacts {
+		if _, ok := byStage[stage]; !ok {
+			byStage[stage] = &StageArtifacts{Stage: stage, Diagrams: []Node{}, Documents: []Node{}}
+		}
+		return byStage[stage]
+	}
+
+	for _, diagram := range input.Diagrams {
+		node := Node{ID: diagram.DiagramID, Type: NodeDiagram, Title: diagram.DiagramName, Stage: diagram.Stage}
+		stageOf(diagram.Stage).Diagrams = append(stageOf(diagram.Stage).Diagrams, node)
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	for _, document := range input.Documents {
+		node := Node{ID: document.DocumentID, Type: NodeDocument, Title: document.DocumentName, Stage: document.Stage}
+		stageOf(document.Stage).Documents = append(stageOf(document.Stage).Documents, node)
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	stages := make([]StageArtifacts, 0, len(byStage))
+	for _, stage := range byStage {
+		stages = append(stages, *stage)
+	}
+	sort.Slice(stages, func(i, j int) bool { return stages[i].Stage < stages[j].Stage })
+	return stages
+}
+
+// newCoverage 计算覆盖率，总数为0时覆盖率为0
+func newCoverage(total, covered int) Coverage {
+	c := Coverage{Total: total, Covered: covered}
+	if total > 0 {
+		c.Percent = math.Round(float64(covered)*10000/float64(total)) / 100
+	}
+	return c
+}
@@ -0,0 +1,108 @@
+package traceability
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"ai-dev-platform/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ptr(id uuid.UUID) *uuid.UUID { return &id }
+
+func sampleInput() *Input {
+	reqCovered := &model.RequirementsDoc{ID: uuid.New(), Content: "# 图书借阅需求\n读者可以借书", Version: 1}
+	reqOrphan := &model.RequirementsDoc{ID: uuid.New(), Version: 2}
+
+	storyTested := &model.UserStory{ID: uuid.New(), RequirementsID: reqCovered.ID, Title: "借书"}
+	storyNoTask := &model.UserStory{ID: uuid.New(), RequirementsID: reqCovered.ID, Title: "还书"}
+
+	taskTested := &model.DevelopmentTask{ID: uuid.New(), Title: "借书接口", Status: "done", UserStoryID: ptr(storyTested.ID)}
+	taskNoTest := &model.DevelopmentTask{ID: uuid.New(), Title: "借书页面", Status: "todo", UserStoryID: ptr(storyTested.ID)}
+	taskNoStory := &model.DevelopmentTask{ID: uuid.New(), Title: "搭建CI"}
+
+	return &Input{
+		ProjectID:    uuid.New(),
+		Requirements: []*model.RequirementsDoc{reqCovered, reqOrphan},
+		Stories:      []*model.UserStory{storyTested, storyNoTask},
+		Tasks:        []*model.DevelopmentTask{taskTested, taskNoTest, taskNoStory},
+		TestCases: []*model.TestCase{
+			{ID: uuid.New(), Title: "借书成功", TaskID: ptr(taskTested.ID)},
+			{ID: uuid.New(), Title: "库存不足 | 借书失败", TaskID: ptr(taskTested.ID)},
+			{ID: uuid.New(), Title: "孤立测试", TaskID: ptr(uuid.New())},
+		},
+		Diagrams:  []*model.PUMLDiagram{{DiagramID: uuid.New(), DiagramName: "业务流程图", Stage: 1}},
+		Documents: []*model.Document{{DocumentID: uuid.New(), DocumentName: "需求文档", Stage: 1}},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	m := Build(sampleInput())
+
+	// 借书 → 借书接口 两条测试 + 借书页面无测试 + 还书无任务 + 孤立需求
+	require.Len(t, m.Rows, 5)
+	assert.Equal(t, "图书借阅需求", m.Rows[0].Requirement.Title)
+	assert.Equal(t, "借书成功", m.Rows[0].TestCase.Title)
+	assert.Nil(t, m.Rows[2].TestCase)
+	assert.Nil(t, m.Rows[3].Task)
+	assert.Equal(t, "需求文档 v2", m.Rows[4].Requirement.Title)
+	assert.Nil(t, m.Rows[4].Story)
+
+	assert.Equal(t, Coverage{Total: 2, Covered: 1, Percent: 50}, m.Coverage.RequirementsWithStories)
+	assert.Equal(t, Coverage{Total: 2, Covered: 1, Percent: 50}, m.Coverage.StoriesWithTasks)
+	assert.Equal(t, Coverage{Total: 3, Covered: 1, Percent: 33.33}, m.Coverage.TasksWithTests)
+	assert.Equal(t, Coverage{Total: 2, Covered: 1, Percent: 50}, m.Coverage.RequirementsEndToEnd)
+
+	assert.Len(t, m.Orphans.RequirementsWithoutStories, 1)
+	require.Len(t, m.Orphans.StoriesWithoutTasks, 1)
+	assert.Equal(t, "还书", m.Orphans.StoriesWithoutTasks[0].Title)
+	assert.Len(t, m.Orphans.TasksWithoutTests, 2)
+	require.Len(t, m.Orphans.TasksWithoutStory, 1)
+	assert.Equal(t, "搭建CI", m.Orphans.TasksWithoutStory[0].Title)
+	require.Len(t, m.Orphans.TestCasesWithoutTask, 1)
+	assert.Equal(t, []int{2, 3}, m.Orphans.StagesWithoutArtifacts)
+
+	assert.Len(t, m.Graph.Nodes, 12)
+	assert.Len(t, m.Graph.Edges, 6)
+}
+
+func TestBuildEmpty(t *testing.T) {
+	m := Build(&Input{ProjectID: uuid.New()})
+	assert.Empty(t, m.Rows)
+	assert.Equal(t, Coverage{}, m.Coverage.TasksWithTests)
+	assert.Len(t, m.Stages, 3)
+}
+
+func TestWriteCSV(t *testing.T) {
+	m := Build(sampleInput())
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, m))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 6)
+	assert.Equal(t, csvHeader, records[0])
+	assert.Equal(t, "借书接口", records[1][5])
+	assert.Equal(t, "done", records[1][6])
+	assert.Equal(t, "库存不足 | 借书失败", records[2][8])
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	m := Build(sampleInput())
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteMarkdown(&buf, m))
+	out := buf.String()
+
+	assert.Contains(t, out, "| 开发任务 → 测试用例 | 1 | 3 | 33.33% |")
+	assert.Contains(t, out, `库存不足 \| 借书失败`)
+	assert.Contains(t, out, "### 没有开发任务的用户故事（1）")
+	assert.Contains(t, out, "| 1 | 业务流程图 | 需求文档 |")
+	assert.Equal(t, 5, strings.Count(out, "| 图书借阅需求 |")+strings.Count(out, "| 需求文档 v2 |"))
+}
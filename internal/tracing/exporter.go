@@ -0,0 +1,188 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogExporter 以JSON行格式输出Span
+type LogExporter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+// NewLogExporter 创建JSON日志导出器
+func NewLogExporter(writer io.Writer) *LogExporter {
+	return &LogExporter{writer: writer}
+}
+
+// Export 每个Span输出一行JSON
+func (e *LogExporter) Export(ctx context.Context, spans []SpanData) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	encoder := json.NewEncoder(e.writer)
+	for _, span := range spans {
+		if err := encoder.Encode(span); err != nil {
+			return fmt.Errorf("输出Span失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// Shutdown 日志导出器无需释放资源
+func (e *LogExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// OTLPExporter 通过 OTLP/HTTP（JSON编码）导出Span
+type OTLPExporter struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+}
+
+// NewOTLPExporter 创建OTLP导出器，endpoint 为收集器地址，如 http://localhost:4318
+func NewOTLPExporter(endpoint, serviceName string) *OTLPExporter {
+	endpoint = strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint += "/v1/traces"
+	}
+	return &OTLPExporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// otlp 请求体结构，字段命名遵循 OTLP JSON 编码
+type otlpKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope map[string]string `json:"scope"`
+	Spans []otlpSpan        `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   map[string][]otlpKeyValue `json:"resource"`
+	ScopeSpans []otlpScopeSpans          `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+// Export 发送一批Span到收集器
+func (e *OTLPExporter) Export(ctx context.Context, spans []SpanData) error {
+	body, err := json.Marshal(e.buildRequest(spans))
+	if err != nil {
+		return fmt.Errorf("序列化OTLP请求失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建OTLP请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送OTLP请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("OTLP收集器返回错误状态: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Shutdown 关闭空闲连接
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// buildRequest 将Span转换为OTLP请求结构
+func (e *OTLPExporter) buildRequest(spans []SpanData) otlpRequest {
+	converted := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		s := otlpSpan{
+			TraceID:           span.TraceID,
+			SpanID:            span.SpanID,
+			ParentSpanID:      span.ParentSpanID,
+			Name:              span.Name,
+			Kind:              1, // SPAN_KIND_INTERNAL
+			StartTimeUnixNano: fmt.Sprintf("%d", span.StartTime.UnixNano()),
+			EndTimeUnixNano:   fmt.Sprintf("%d", span.EndTime.UnixNano()),
+		}
+		if strings.HasPrefix(span.Name, "HTTP ") {
+			s.Kind = 2 // SPAN_KIND_SERVER
+		}
+		for key, value := range span.Attributes {
+			s.Attributes = append(s.Attributes, otlpKeyValue{Key: key, Value: otlpValue(value)})
+		}
+		if span.Error != "" {
+			s.Status = otlpStatus{Code: 2, Message: span.Error} // STATUS_CODE_ERROR
+		}
+		converted = append(converted, s)
+	}
+
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: map[string][]otlpKeyValue{
+				"attributes": {{Key: "service.name", Value: otlpValue(e.serviceName)}},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: map[string]string{"name": "ai-dev-platform/internal/tracing"},
+				Spans: converted,
+			}},
+		}},
+	}
+}
+
+// otlpValue 将属性值转换为OTLP AnyValue
+func otlpValue(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case string:
+		return map[string]interface{}{"stringValue": v}
+	case bool:
+		return map[string]interface{}{"boolValue": v}
+	case int:
+		return map[string]interface{}{"intValue": fmt.Sprintf("%d", v)}
+	case int64:
+		return map[string]interface{}{"intValue": fmt.Sprintf("%d", v)}
+	case float64:
+		return map[string]interface{}{"doubleValue": v}
+	default:
+		return map[string]interface{}{"stringValue": fmt.Sprint(v)}
+	}
+}
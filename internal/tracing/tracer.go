@@ -0,0 +1,143 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchSize     = 128
+	defaultQueueSize     = 2048
+	defaultFlushInterval = 5 * time.Second
+)
+
+// Exporter Span导出器
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// Tracer 创建Span并异步批量导出
+type Tracer struct {
+	exporter Exporter
+	queue    chan SpanData
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewTracer 创建Tracer并启动后台导出协程
+func NewTracer(exporter Exporter) *Tracer {
+	t := &Tracer{
+		exporter: exporter,
+		queue:    make(chan SpanData, defaultQueueSize),
+		done:     make(chan struct{}),
+	}
+
+	t.wg.Add(1)
+	go t.run()
+
+	return t
+}
+
+// Start 开始一个Span；上下文中存在父Span或上游链路时沿用其链路ID
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	span := &Span{
+		tracer:     t,
+		name:       name,
+		startTime:  time.Now(),
+		attributes: make(map[string]interface{}),
+	}
+
+	parent := SpanContextFromContext(ctx)
+	if parent.IsValid() {
+		span.spanContext.TraceID = parent.TraceID
+		span.spanContext.Sampled = parent.Sampled
+		span.parentSpanID = parent.SpanID
+	} else {
+		randomID(span.spanContext.TraceID[:])
+		span.spanContext.Sampled = true
+	}
+	randomID(span.spanContext.SpanID[:])
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// enqueue 提交已结束的Span，队列已满时丢弃，避免阻塞业务
+func (t *Tracer) enqueue(data SpanData) {
+	if t == nil {
+		return
+	}
+	select {
+	case <-t.done:
+	case t.queue <- data:
+	default:
+	}
+}
+
+// run 按批次或定时导出Span
+func (t *Tracer) run() {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(defaultFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, defaultBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		_ = t.exporter.Export(ctx, batch)
+		cancel()
+		batch = make([]SpanData, 0, defaultBatchSize)
+	}
+
+	for {
+		select {
+		case data := <-t.queue:
+			batch = append(batch, data)
+			if len(batch) >= defaultBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.done:
+			// 导出队列中剩余的Span
+			for {
+				select {
+				case data := <-t.queue:
+					batch = append(batch, data)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Shutdown 停止Tracer并导出剩余Span
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	t.stopOnce.Do(func() {
+		close(t.done)
+	})
+
+	finished := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return t.exporter.Shutdown(ctx)
+}
@@ -0,0 +1,322 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-dev-platform/internal/requestid"
+)
+
+// TraceparentHeader W3C Trace Context 请求头
+const TraceparentHeader = "traceparent"
+
+// SpanContext 可跨进程传播的链路上下文
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid 判断链路上下文是否有效（全零ID无效）
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceIDString 返回十六进制的链路ID
+func (sc SpanContext) TraceIDString() string {
+	if sc.TraceID == [16]byte{} {
+		return ""
+	}
+	return hex.EncodeToString(sc.TraceID[:])
+}
+
+// SpanIDString 返回十六进制的Span ID
+func (sc SpanContext) SpanIDString() string {
+	if sc.SpanID == [8]byte{} {
+		return ""
+	}
+	return hex.EncodeToString(sc.SpanID[:])
+}
+
+// Traceparent 生成 W3C traceparent 头的值
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]), flags)
+}
+
+// ParseTraceparent 解析 W3C traceparent 头，格式为 version-traceid-spanid-flags
+func ParseTraceparent(value string) (SpanContext, error) {
+	var sc SpanContext
+
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return sc, errors.New("traceparent格式错误")
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+
+	if len(version) != 2 || version == "ff" {
+		return sc, errors.New("traceparent版本无效")
+	}
+	// 版本00必须恰好包含4段，更高版本允许追加字段
+	if version == "00" && len(parts) != 4 {
+		return sc, errors.New("traceparent格式错误")
+	}
+	if len(traceID) != 32 || len(spanID) != 16 || len(flags) != 2 {
+		return sc, errors.New("traceparent字段长度无效")
+	}
+	if strings.ToLower(traceID) != traceID || strings.ToLower(spanID) != spanID {
+		return sc, errors.New("traceparent必须使用小写十六进制")
+	}
+
+	if _, err := hex.Decode(sc.TraceID[:], []byte(traceID)); err != nil {
+		return sc, fmt.Errorf("trace-id无效: %w", err)
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(spanID)); err != nil {
+		return sc, fmt.Errorf("parent-id无效: %w", err)
+	}
+	flagBytes, err := hex.DecodeString(flags)
+	if err != nil {
+		return sc, fmt.Errorf("trace-flags无效: %w", err)
+	}
+	sc.Sampled = flagBytes[0]&0x01 == 0x01
+
+	if !sc.IsValid() {
+		return sc, errors.New("traceparent包含全零ID")
+	}
+	return sc, nil
+}
+
+// Span 一次操作的耗时记录
+type Span struct {
+	tracer       *Tracer
+	mutex        sync.Mutex
+	name         string
+	spanContext  SpanContext
+	parentSpanID [8]byte
+	startTime    time.Time
+	endTime      time.Time
+	attributes   map[string]interface{}
+	errMessage   string
+	ended        bool
+}
+
+// SpanContext 返回Span的链路上下文，nil安全
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.spanContext
+}
+
+// SetName 修改Span名称（如路由匹配后补充路由模板），nil安全
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.name = name
+}
+
+// SetAttribute 设置属性，nil安全
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.attributes[key] = value
+}
+
+// RecordError 记录错误并将Span标记为失败，nil安全
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.errMessage = err.Error()
+}
+
+// End 结束Span并提交导出，重复调用无效，nil安全
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.endTime = time.Now()
+	data := s.snapshot()
+	s.mutex.Unlock()
+
+	s.tracer.enqueue(data)
+}
+
+// snapshot 生成导出用数据，调用方需持有锁
+func (s *Span) snapshot() SpanData {
+	attributes := make(map[string]interface{}, len(s.attributes))
+	for k, v := range s.attributes {
+		attributes[k] = v
+	}
+
+	var parent string
+	if s.parentSpanID != [8]byte{} {
+		parent = hex.EncodeToString(s.parentSpanID[:])
+	}
+
+	return SpanData{
+		Name:         s.name,
+		TraceID:      s.spanContext.TraceIDString(),
+		SpanID:       s.spanContext.SpanIDString(),
+		ParentSpanID: parent,
+		StartTime:    s.startTime,
+		EndTime:      s.endTime,
+		DurationMs:   float64(s.endTime.Sub(s.startTime).Microseconds()) / 1000,
+		Attributes:   attributes,
+		Error:        s.errMessage,
+	}
+}
+
+// SpanData 已结束Span的导出数据
+type SpanData struct {
+	Name         string                 `json:"name"`
+	TraceID      string                 `json:"trace_id"`
+	SpanID       string                 `json:"span_id"`
+	ParentSpanID string                 `json:"parent_span_id,omitempty"`
+	StartTime    time.Time              `json:"start_time"`
+	EndTime      time.Time              `json:"end_time"`
+	DurationMs   float64                `json:"duration_ms"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+// ===== 上下文 =====
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// ContextWithSpan 将Span放入上下文
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext 获取上下文中的当前Span，不存在时返回nil
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext 将来自上游的链路上下文放入上下文，作为后续Span的父节点
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext 获取上下文中的链路上下文，优先使用当前Span
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+	if ctx == nil {
+		return SpanContext{}
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// TraceID 获取上下文中的链路ID，不存在时返回空字符串
+func TraceID(ctx context.Context) string {
+	return SpanContextFromContext(ctx).TraceIDString()
+}
+
+// Detach 创建不随请求取消的新上下文，保留链路上下文和请求ID，用于启动后台任务
+func Detach(ctx context.Context) context.Context {
+	detached := context.Background()
+	if id := requestid.GetID(ctx); id != "" {
+		detached = requestid.NewContext(detached, id, requestid.CreateTime(ctx))
+	}
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		detached = ContextWithRemoteSpanContext(detached, sc)
+	}
+	return detached
+}
+
+// Inject 将链路上下文写入出站请求头
+func Inject(ctx context.Context, header http.Header) {
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		header.Set(TraceparentHeader, sc.Traceparent())
+	}
+}
+
+// Extract 从入站请求头读取链路上下文
+func Extract(ctx context.Context, header http.Header) context.Context {
+	value := header.Get(TraceparentHeader)
+	if value == "" {
+		return ctx
+	}
+	sc, err := ParseTraceparent(value)
+	if err != nil {
+		return ctx
+	}
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// ===== 全局Tracer =====
+
+var (
+	globalMutex  sync.RWMutex
+	globalTracer *Tracer
+)
+
+// SetTracer 设置全局Tracer，传入nil关闭链路追踪
+func SetTracer(tracer *Tracer) {
+	globalMutex.Lock()
+	defer globalMutex.Unlock()
+
+	globalTracer = tracer
+}
+
+// Start 使用全局Tracer开始一个Span；未启用追踪时返回原上下文和nil Span
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	globalMutex.RLock()
+	tracer := globalTracer
+	globalMutex.RUnlock()
+
+	if tracer == nil {
+		return ctx, nil
+	}
+	return tracer.Start(ctx, name)
+}
+
+// randomID 生成随机ID
+func randomID(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		// 随机源不可用时退化为时间戳，保证ID非零
+		now := time.Now().UnixNano()
+		for i := range b {
+			b[i] = byte(now >> (8 * (i % 8)))
+		}
+		b[0] |= 0x01
+	}
+}
@@ -0,0 +1,148 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"ai-dev-platform/internal/requestid"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryExporter 记录导出Span的测试用导出器
+type memoryExporter struct {
+	mutex sync.Mutex
+	spans []SpanData
+}
+
+func (e *memoryExporter) Export(ctx context.Context, spans []SpanData) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *memoryExporter) Shutdown(ctx context.Context) error { return nil }
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceIDString())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanIDString())
+	assert.True(t, sc.Sampled)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.Traceparent())
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	}
+	for _, value := range invalid {
+		_, err := ParseTraceparent(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestTracer_ParentChildAndShutdown(t *testing.T) {
+	exporter := &memoryExporter{}
+	tracer := NewTracer(exporter)
+
+	header := http.Header{}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := Extract(context.Background(), header)
+
+	ctx, parent := tracer.Start(ctx, "HTTP GET /api/projects")
+	_, child := tracer.Start(ctx, "db.query")
+	child.SetAttribute("db.table", "projects")
+	child.RecordError(errors.New("boom"))
+	child.End()
+	child.End()
+	parent.End()
+
+	assert.NoError(t, tracer.Shutdown(context.Background()))
+
+	if assert.Len(t, exporter.spans, 2) {
+		childData, parentData := exporter.spans[0], exporter.spans[1]
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", parentData.TraceID)
+		assert.Equal(t, "00f067aa0ba902b7", parentData.ParentSpanID)
+		assert.Equal(t, parentData.TraceID, childData.TraceID)
+		assert.Equal(t, parentData.SpanID, childData.ParentSpanID)
+		assert.Equal(t, "projects", childData.Attributes["db.table"])
+		assert.Equal(t, "boom", childData.Error)
+	}
+}
+
+func TestStart_DisabledReturnsNilSpan(t *testing.T) {
+	SetTracer(nil)
+
+	ctx, span := Start(context.Background(), "noop")
+	assert.Nil(t, span)
+	// nil Span 的方法可安全调用
+	span.SetAttribute("key", "value")
+	span.RecordError(errors.New("ignored"))
+	span.End()
+	assert.Empty(t, TraceID(ctx))
+}
+
+func TestDetach_KeepsTraceAndRequestID(t *testing.T) {
+	tracer := NewTracer(&memoryExporter{})
+	defer tracer.Shutdown(context.Background())
+
+	parent, cancel := context.WithCancel(requestid.NewContext(context.Background(), "req-1", time.Now()))
+	parent, span := tracer.Start(parent, "task.enqueue")
+	detached := Detach(parent)
+	cancel()
+
+	assert.NoError(t, detached.Err())
+	assert.Equal(t, "req-1", requestid.GetID(detached))
+	assert.Equal(t, span.SpanContext().TraceIDString(), TraceID(detached))
+
+	header := http.Header{}
+	Inject(detached, header)
+	assert.Equal(t, span.SpanContext().Traceparent(), header.Get(TraceparentHeader))
+}
+
+func TestOTLPExporter_Export(t *testing.T) {
+	var received otlpRequest
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(server.URL, "ai-dev-platform")
+	err := exporter.Export(context.Background(), []SpanData{{
+		Name:       "ai.call",
+		TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:     "00f067aa0ba902b7",
+		StartTime:  time.Unix(1, 0),
+		EndTime:    time.Unix(2, 0),
+		Attributes: map[string]interface{}{"ai.provider": "openai"},
+		Error:      "timeout",
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1/traces", path)
+
+	if assert.Len(t, received.ResourceSpans, 1) {
+		rs := received.ResourceSpans[0]
+		assert.Equal(t, "ai-dev-platform", rs.Resource["attributes"][0].Value["stringValue"])
+		span := rs.ScopeSpans[0].Spans[0]
+		assert.Equal(t, "ai.call", span.Name)
+		assert.Equal(t, "1000000000", span.StartTimeUnixNano)
+		assert.Equal(t, 2, span.Status.Code)
+		assert.Equal(t, "openai", span.Attributes[0].Value["stringValue"])
+	}
+}